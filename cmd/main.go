@@ -5,12 +5,19 @@ import (
 	"fmt"
 	"os"
 	"projectT/internal/app"
+	"projectT/internal/cli"
 )
 
 var (
 	version     = "dev"
 	showVersion bool
 	showHelp    bool
+	runFsck     bool
+	fsckRepair  bool
+	runBackup   bool
+	listBackups bool
+	restoreFrom string
+	runDaemon   bool
 )
 
 func main() {
@@ -19,6 +26,12 @@ func main() {
 	flag.BoolVar(&showVersion, "v", false, "Показать версию приложения (сокращённая форма)")
 	flag.BoolVar(&showHelp, "help", false, "Показать справку")
 	flag.BoolVar(&showHelp, "h", false, "Показать справку (сокращённая форма)")
+	flag.BoolVar(&runFsck, "fsck", false, "Проверить целостность хранилища без запуска интерфейса")
+	flag.BoolVar(&fsckRepair, "fsck-repair", false, "Проверить целостность хранилища и исправить найденные проблемы")
+	flag.BoolVar(&runBackup, "backup", false, "Создать резервную копию без запуска интерфейса")
+	flag.BoolVar(&listBackups, "backup-list", false, "Показать список резервных копий")
+	flag.StringVar(&restoreFrom, "restore", "", "Восстановить резервную копию: ID, время (2006-01-02T15:04) или latest")
+	flag.BoolVar(&runDaemon, "daemon", false, "Запустить без интерфейса с локальным JSON API")
 
 	// Флаги конфигурации (определяются здесь для отображения в справке)
 	flag.String("config", "", "Путь к файлу конфигурации (YAML)")
@@ -26,6 +39,18 @@ func main() {
 	flag.Int("db-timeout", 0, "Таймаут ожидания БД (мс)")
 	flag.String("storage-path", "", "Путь к корневой директории хранилища")
 	flag.String("storage-files-dir", "", "Поддиректория для файлов")
+	flag.Int("trash-retention-days", -1, "Срок хранения элементов в корзине (дней, 0 - бессрочно)")
+	flag.Int("gc-grace-hours", -1, "Через сколько часов неиспользуемые файлы удаляются с диска")
+	flag.Int("max-revisions", -1, "Сколько ревизий хранить для одного элемента (0 - без ограничения)")
+	flag.String("backup-path", "", "Директория для резервных копий")
+	flag.Int("backup-interval-hours", -1, "Как часто создавать резервную копию (часов, 0 - только вручную)")
+	flag.Int("backup-keep-last", -1, "Сколько последних резервных копий хранить всегда")
+	flag.Int("backup-keep-days", -1, "За сколько дней хранить по одной резервной копии в день")
+	flag.String("libraries-path", "", "Директория библиотек, созданных в приложении")
+	flag.String("library", "", "Библиотека (ID или название), которую открыть при запуске")
+	flag.String("daemon-listen", "", "Адрес API в режиме без интерфейса: host:port или unix:/путь")
+	flag.Int("link-preview-refresh-days", -1, "Через сколько дней превью ссылок загружается заново (0 - не обновлять)")
+	flag.Int("watch-debounce-ms", -1, "Сколько файл не должен меняться перед импортом из отслеживаемой директории (мс)")
 	flag.Bool("p2p-enabled", false, "Включить P2P режим")
 	flag.Int("p2p-port", 0, "Порт для P2P соединений")
 	flag.Bool("p2p-relay", false, "Использовать relay для обхода NAT")
@@ -40,14 +65,25 @@ func main() {
 		fmt.Println()
 		fmt.Println("Использование:")
 		fmt.Println("  projectT [флаги]")
+		fmt.Println("  projectT [флаги] <команда> [флаги команды] [аргументы]")
 		fmt.Println()
 		fmt.Println("Флаги:")
 		flag.PrintDefaults()
 		fmt.Println()
+		fmt.Println("Команды для работы с библиотекой из скриптов:")
+		fmt.Println("  projectT help")
+		fmt.Println()
 		fmt.Println("Примеры:")
 		fmt.Println("  projectT --db-path=\"D:\\Data\\projectT.db\"")
 		fmt.Println("  projectT --storage-path=\"E:\\Files\" --p2p-port=5000")
 		fmt.Println("  projectT --config=config.yaml")
+		fmt.Println("  projectT --library=\"Работа\"")
+		fmt.Println("  projectT --fsck --db-path=\"D:\\Data\\projectT.db\"")
+		fmt.Println("  PROJECTT_BACKUP_PASSWORD=... projectT --restore=latest")
+		fmt.Println("  projectT --daemon --daemon-listen=unix:/run/projectT/api.sock")
+		fmt.Println("  projectT add photo.jpg https://example.com --tags=отпуск,море --parent=12")
+		fmt.Println("  projectT ls --all --sort=modified_date --order=desc --json")
+		fmt.Println("  projectT --library=\"Работа\" search договор")
 		os.Exit(0)
 	}
 
@@ -57,6 +93,33 @@ func main() {
 		os.Exit(0)
 	}
 
+	// Проверка целостности выполняется без интерфейса
+	if runFsck || fsckRepair {
+		os.Exit(app.RunFsck(fsckRepair))
+	}
+
+	// Резервное копирование и восстановление выполняются без интерфейса
+	if runBackup {
+		os.Exit(app.RunBackup())
+	}
+	if listBackups {
+		os.Exit(app.RunBackupList())
+	}
+	if restoreFrom != "" {
+		os.Exit(app.RunRestore(restoreFrom))
+	}
+
+	// Команды клиента командной строки. Остальные аргументы (например, файл из «Открыть с помощью»)
+	// не мешают запуску интерфейса, как и раньше.
+	if flag.NArg() > 0 && (cli.IsCommand(flag.Arg(0)) || flag.Arg(0) == "help") {
+		os.Exit(app.RunCLI(flag.Args()))
+	}
+
+	// Узел без интерфейса с локальным API
+	if runDaemon {
+		os.Exit(app.RunDaemon())
+	}
+
 	myApp := app.NewApp()
 
 	myApp.Run()
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"projectT/internal/services/contacts"
+	"projectT/internal/storage/database/models"
+)
+
+// Размер страницы истории чата по умолчанию
+const defaultMessagesLimit = 50
+
+// contactRequest новый контакт по P2P адресу
+type contactRequest struct {
+	Address string `json:"address"`
+	Notes   string `json:"notes"`
+}
+
+// messageRequest текстовое сообщение контакту
+type messageRequest struct {
+	Content string `json:"content"`
+}
+
+// writeContactError отправляет ошибку работы с контактом с подходящим кодом
+func writeContactError(w http.ResponseWriter, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, errors.New("контакт не найден"))
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err)
+}
+
+// listContacts GET /api/contacts: контакты со статусом подключения
+func (s *Server) listContacts(w http.ResponseWriter, r *http.Request) {
+	list, err := s.contacts.GetAllContacts()
+	if err != nil {
+		writeContactError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"contacts": list})
+}
+
+// addContact POST /api/contacts: добавляет контакт по P2P адресу
+func (s *Server) addContact(w http.ResponseWriter, r *http.Request) {
+	var request contactRequest
+	if err := readJSON(r, &request); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	contact, err := s.contacts.AddContactByAddress(request.Address, request.Notes)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, contact)
+}
+
+// listMessages GET /api/contacts/{id}/messages?limit=&offset=: история чата, новые сообщения первыми.
+// История читается из базы и доступна, даже когда P2P не запущен.
+func (s *Server) listMessages(w http.ResponseWriter, r *http.Request) {
+	contact, ok := s.pathContact(w, r)
+	if !ok {
+		return
+	}
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultMessagesLimit
+	}
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	messages, err := s.messages.GetMessagesForContact(contact.ID, limit, max(offset, 0))
+	if err != nil {
+		writeContactError(w, err)
+		return
+	}
+	if messages == nil {
+		messages = []*models.ChatMessage{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"messages": messages})
+}
+
+// sendMessage POST /api/contacts/{id}/messages: отправляет текстовое сообщение через P2P
+func (s *Server) sendMessage(w http.ResponseWriter, r *http.Request) {
+	contact, ok := s.pathContact(w, r)
+	if !ok {
+		return
+	}
+	var request messageRequest
+	if err := readJSON(r, &request); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if request.Content == "" {
+		writeError(w, http.StatusBadRequest, errors.New("пустое сообщение"))
+		return
+	}
+
+	if err := s.contacts.SendMessage(contact.ID, request.Content); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": "sent"})
+}
+
+// pathContact возвращает контакт из параметра пути {id}; при ошибке ответ уже отправлен
+func (s *Server) pathContact(w http.ResponseWriter, r *http.Request) (*contacts.ContactWithStatus, bool) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+	contact, err := s.contacts.GetContact(id)
+	if err != nil {
+		writeContactError(w, err)
+		return nil, false
+	}
+	return contact, true
+}
+
+// p2pStatus GET /api/p2p/status: состояние P2P узла
+func (s *Server) p2pStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.p2p.GetStatus())
+}
+
+// p2pPeers GET /api/p2p/peers: подключённые пиры
+func (s *Server) p2pPeers(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"peers": s.p2p.GetConnectedPeers()})
+}
@@ -0,0 +1,271 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"projectT/internal/services"
+	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/queries"
+)
+
+// Размер страницы списка элементов по умолчанию и наибольший
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// itemResponse элемент вместе с названиями его тегов
+type itemResponse struct {
+	*models.Item
+	Tags []string `json:"tags"`
+}
+
+// itemUpdate изменения элемента; отсутствующие поля не меняются.
+// ParentID со значением null перемещает элемент в корень.
+type itemUpdate struct {
+	Title       *string         `json:"title"`
+	Description *string         `json:"description"`
+	ParentID    json.RawMessage `json:"parent_id"`
+	Tags        *string         `json:"tags"` // Теги через запятую, заменяют текущие
+}
+
+// pathID возвращает числовой параметр пути {id}
+func pathID(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id <= 0 {
+		return 0, errors.New("неверный ID")
+	}
+	return id, nil
+}
+
+// writeItemError отправляет ошибку работы с элементом с подходящим кодом
+func writeItemError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		writeError(w, http.StatusNotFound, errors.New("элемент не найден"))
+	case errors.Is(err, services.ErrInvalidMoveTarget), errors.Is(err, queries.ErrInvalidItemCursor):
+		writeError(w, http.StatusBadRequest, err)
+	default:
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
+
+// listItems GET /api/items: страница элементов папки parent (или всех папок при all=1)
+// с фильтром type, приоритетом priority и сортировкой sort/order, как в сетке.
+// Следующая страница запрашивается с cursor из поля next ответа.
+func (s *Server) listItems(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	parentID, _ := strconv.Atoi(query.Get("parent"))
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultPageSize
+	}
+	limit = min(limit, maxPageSize)
+
+	options := services.NewSortSettingsService().GetFilterOptions()
+	if all, _ := strconv.ParseBool(query.Get("all")); all {
+		options.TabMode = "all_items"
+	}
+	for param, field := range map[string]*string{
+		"type":     &options.ItemType,
+		"priority": &options.Priority,
+		"sort":     &options.SortBy,
+		"order":    &options.SortOrder,
+	} {
+		if value := query.Get(param); value != "" {
+			*field = value
+		}
+	}
+
+	var after *queries.ItemCursor
+	if cursor := query.Get("cursor"); cursor != "" {
+		if after, err = queries.ParseItemCursor(cursor); err != nil {
+			writeItemError(w, err)
+			return
+		}
+	}
+
+	page, err := s.items.ListItems(parentID, options, after, limit)
+	if err != nil {
+		writeItemError(w, err)
+		return
+	}
+
+	response := struct {
+		Items []*models.Item `json:"items"`
+		Next  string         `json:"next,omitempty"`
+	}{Items: page.Items}
+	if response.Items == nil {
+		response.Items = []*models.Item{}
+	}
+	if page.Next != nil {
+		response.Next = page.Next.String()
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// getItem GET /api/items/{id}: элемент с тегами
+func (s *Server) getItem(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	s.writeItem(w, r, http.StatusOK, id)
+}
+
+// writeItem отправляет элемент id вместе с тегами
+func (s *Server) writeItem(w http.ResponseWriter, r *http.Request, status int, id int) {
+	item, err := s.items.GetItemByID(id)
+	if err != nil {
+		writeItemError(w, err)
+		return
+	}
+	tags, err := s.tags.GetTagsForItem(r.Context(), id)
+	if err != nil {
+		writeItemError(w, err)
+		return
+	}
+
+	response := itemResponse{Item: item, Tags: make([]string, 0, len(tags))}
+	for _, tag := range tags {
+		response.Tags = append(response.Tags, tag.Name)
+	}
+	writeJSON(w, status, response)
+}
+
+// createItem POST /api/items: создает элемент из файлов, ссылок, текста и тегов.
+// Файлы передаются содержимым в uploads ({"name": ..., "data": <base64>}); пути к файлам
+// на компьютере узла не принимаются.
+func (s *Server) createItem(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		services.ItemInput
+		Files []string `json:"files"`
+	}
+	if err := readJSONLimit(r, &input, maxUploadRequestSize); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(input.Files) > 0 {
+		writeError(w, http.StatusBadRequest, errors.New("пути к файлам не принимаются: передайте содержимое файлов в uploads"))
+		return
+	}
+
+	item, err := s.content.CreateItem(r.Context(), input.ItemInput)
+	if err != nil && item == nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	s.writeItem(w, r, http.StatusCreated, item.ID)
+}
+
+// updateItem PATCH /api/items/{id}: меняет название, описание, папку и теги элемента
+func (s *Server) updateItem(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var update itemUpdate
+	if err := readJSON(r, &update); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if update.Title != nil || update.Description != nil {
+		item, err := s.items.GetItemByID(id)
+		if err != nil {
+			writeItemError(w, err)
+			return
+		}
+		title, description := item.Title, item.Description
+		if update.Title != nil {
+			title = *update.Title
+		}
+		if update.Description != nil {
+			description = *update.Description
+		}
+		// Изменение сохраняется с ревизией, как при редактировании в окне элемента
+		if _, _, err := s.content.UpdateItemWithTransaction(r.Context(), id, title, description, item.Type, item.ContentMeta, item.ParentID); err != nil {
+			writeItemError(w, err)
+			return
+		}
+	}
+
+	if update.ParentID != nil {
+		var parentID *int
+		if err := json.Unmarshal(update.ParentID, &parentID); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("parent_id должен быть числом или null"))
+			return
+		}
+		if err := s.items.MoveItem(id, parentID); err != nil {
+			writeItemError(w, err)
+			return
+		}
+	}
+
+	if update.Tags != nil {
+		if err := s.content.ProcessTags(r.Context(), id, *update.Tags); err != nil {
+			writeItemError(w, err)
+			return
+		}
+	}
+
+	s.writeItem(w, r, http.StatusOK, id)
+}
+
+// deleteItem DELETE /api/items/{id}: перемещает элемент со всем содержимым в корзину
+func (s *Server) deleteItem(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if _, err := s.items.GetItemByID(id); err != nil {
+		writeItemError(w, err)
+		return
+	}
+
+	entry, err := s.trash.MoveToTrash(id)
+	if err != nil {
+		writeItemError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, entry)
+}
+
+// listTags GET /api/tags: все теги с количеством элементов
+func (s *Server) listTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := s.tags.GetAllTags(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if tags == nil {
+		tags = []*models.Tag{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"tags": tags})
+}
+
+// search GET /api/search?q=: полнотекстовый поиск с фрагментами совпадений, по релевантности
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		writeError(w, http.StatusBadRequest, errors.New("не задан запрос q"))
+		return
+	}
+
+	results, err := s.items.SearchItemsWithSnippets(query)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if results == nil {
+		results = []*models.SearchResult{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"results": results})
+}
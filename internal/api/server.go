@@ -0,0 +1,177 @@
+// Package api предоставляет локальный JSON API узла, запущенного без интерфейса (--daemon).
+//
+// API слушает loopback или Unix-сокет и принимает только запросы с токеном доступа
+// в заголовке "Authorization: Bearer <токен>". Обработчики вызывают те же сервисы,
+// что и интерфейс, поэтому элементы, созданные через API, не отличаются от созданных в окне.
+//
+// Файлы новых элементов передаются содержимым (поле uploads), а не путями: иначе владелец токена
+// мог бы скопировать в библиотеку и прочитать любой файл, доступный процессу узла.
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"projectT/internal/services"
+	"projectT/internal/services/contacts"
+	"projectT/internal/services/p2p/network"
+	"projectT/internal/storage/database/repository"
+)
+
+// unixPrefix префикс адреса Unix-сокета
+const unixPrefix = "unix:"
+
+const (
+	// maxRequestSize наибольший размер тела запроса
+	maxRequestSize = 1 << 20
+	// maxUploadRequestSize наибольший размер запроса создания элемента с файлами в base64
+	maxUploadRequestSize = 64 << 20
+)
+
+// ErrNotLoopback адрес API доступен не только с этого компьютера
+var ErrNotLoopback = errors.New("API можно открыть только на loopback-адресе или Unix-сокете")
+
+// Server обработчики JSON API
+type Server struct {
+	items    *services.ItemsService
+	tags     *services.TagsService
+	content  *services.ContentBlocksService
+	trash    *services.TrashService
+	contacts *contacts.ContactService
+	messages repository.Messages
+	p2p      *network.UIP2P
+	token    string
+	mux      *http.ServeMux
+}
+
+// NewServer создает API над сервисами открытой библиотеки и P2P сетью p2pNetwork.
+// Запросы принимаются только с токеном token.
+func NewServer(p2pNetwork *network.P2PNetwork, token string) *Server {
+	repos := repository.Default()
+	s := &Server{
+		items:    services.NewItemsService(),
+		tags:     services.NewTagsService(),
+		content:  services.NewContentBlocksService(),
+		trash:    services.NewTrashService(),
+		contacts: contacts.NewContactService(p2pNetwork, repos.Contacts, repos.Profiles),
+		messages: repos.Messages,
+		p2p:      network.NewUIP2P(p2pNetwork),
+		token:    token,
+		mux:      http.NewServeMux(),
+	}
+	s.routes()
+	return s
+}
+
+// routes регистрирует обработчики
+func (s *Server) routes() {
+	s.mux.HandleFunc("GET /api/items", s.listItems)
+	s.mux.HandleFunc("POST /api/items", s.createItem)
+	s.mux.HandleFunc("GET /api/items/{id}", s.getItem)
+	s.mux.HandleFunc("PATCH /api/items/{id}", s.updateItem)
+	s.mux.HandleFunc("DELETE /api/items/{id}", s.deleteItem)
+	s.mux.HandleFunc("GET /api/tags", s.listTags)
+	s.mux.HandleFunc("GET /api/search", s.search)
+	s.mux.HandleFunc("GET /api/contacts", s.listContacts)
+	s.mux.HandleFunc("POST /api/contacts", s.addContact)
+	s.mux.HandleFunc("GET /api/contacts/{id}/messages", s.listMessages)
+	s.mux.HandleFunc("POST /api/contacts/{id}/messages", s.sendMessage)
+	s.mux.HandleFunc("GET /api/p2p/status", s.p2pStatus)
+	s.mux.HandleFunc("GET /api/p2p/peers", s.p2pPeers)
+}
+
+// ServeHTTP проверяет токен и передает запрос обработчику
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+		writeError(w, http.StatusUnauthorized, errors.New("требуется токен доступа"))
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// Listen открывает адрес API: host:port на loopback или unix:/путь/к/сокету.
+// Оставшийся от прошлого запуска сокет удаляется, новый доступен только владельцу.
+func Listen(addr string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, unixPrefix); ok {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("ошибка удаления старого сокета: %w", err)
+		}
+		listener, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.Chmod(path, 0600); err != nil {
+			listener.Close()
+			return nil, err
+		}
+		return listener, nil
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("неверный адрес API %q: %w", addr, err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, ErrNotLoopback
+	}
+	return net.Listen("tcp", addr)
+}
+
+// Serve обслуживает запросы на listener, пока не отменён ctx, затем дожидается начатых запросов
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	server := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.Serve(listener) }()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	}
+}
+
+// writeJSON отправляет ответ в JSON
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Предупреждение: ошибка отправки ответа API: %v", err)
+	}
+}
+
+// writeError отправляет ошибку в виде {"error": "..."}
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// readJSON разбирает тело запроса в v
+func readJSON(r *http.Request, v interface{}) error {
+	return readJSONLimit(r, v, maxRequestSize)
+}
+
+// readJSONLimit разбирает тело запроса не больше limit байт
+func readJSONLimit(r *http.Request, v interface{}, limit int64) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, limit))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("неверное тело запроса: %w", err)
+	}
+	return nil
+}
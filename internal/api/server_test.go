@@ -0,0 +1,190 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"projectT/internal/storage/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testToken токен доступа тестового сервера
+const testToken = "test-token"
+
+// setupTestServer подключает БД в памяти и запускает API без P2P сети
+func setupTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	db, err := database.Open(":memory:")
+	require.NoError(t, err)
+	originalDB := database.GetDB()
+	database.SetDB(db)
+	database.RunMigrations()
+
+	server := httptest.NewServer(NewServer(nil, testToken))
+	t.Cleanup(func() {
+		server.Close()
+		database.CloseDB()
+		database.SetDB(originalDB)
+	})
+	return server
+}
+
+// doJSON выполняет запрос с токеном и разбирает ответ в out, возвращает код ответа
+func doJSON(t *testing.T, server *httptest.Server, method, path string, body interface{}, out interface{}) int {
+	t.Helper()
+
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, server.URL+path, reader)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	if out != nil {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+	}
+	return resp.StatusCode
+}
+
+// testItem поля элемента в ответе API, нужные тестам
+type testItem struct {
+	ID       int      `json:"id"`
+	Title    string   `json:"title"`
+	ParentID *int     `json:"parent_id"`
+	Tags     []string `json:"tags"`
+}
+
+// TestServerRequiresToken проверяет, что запросы без верного токена отклоняются
+func TestServerRequiresToken(t *testing.T) {
+	server := setupTestServer(t)
+
+	for _, header := range []string{"", "Bearer wrong", testToken} {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/api/items", nil)
+		require.NoError(t, err)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		resp, err := server.Client().Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, header)
+	}
+}
+
+// TestServerItems проверяет создание, постраничный список, изменение, поиск и удаление элементов
+func TestServerItems(t *testing.T) {
+	server := setupTestServer(t)
+
+	var folder testItem
+	status := doJSON(t, server, http.MethodPost, "/api/items", map[string]interface{}{"title": "Папка", "type": "folder"}, &folder)
+	require.Equal(t, http.StatusCreated, status)
+
+	var note testItem
+	status = doJSON(t, server, http.MethodPost, "/api/items", map[string]interface{}{
+		"title":       "Заметка",
+		"description": "Рецепт пирога",
+		"tags":        "кухня, рецепты",
+	}, &note)
+	require.Equal(t, http.StatusCreated, status)
+	assert.ElementsMatch(t, []string{"кухня", "рецепты"}, note.Tags)
+
+	var got testItem
+	require.Equal(t, http.StatusOK, doJSON(t, server, http.MethodGet, "/api/items/"+strconv.Itoa(note.ID), nil, &got))
+	assert.Equal(t, "Заметка", got.Title)
+	assert.Equal(t, http.StatusNotFound, doJSON(t, server, http.MethodGet, "/api/items/9999", nil, nil))
+
+	// Первая страница из одного элемента и курсор на следующую
+	var page struct {
+		Items []testItem `json:"items"`
+		Next  string     `json:"next"`
+	}
+	require.Equal(t, http.StatusOK, doJSON(t, server, http.MethodGet, "/api/items?limit=1&sort=name", nil, &page))
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, "Заметка", page.Items[0].Title)
+	require.NotEmpty(t, page.Next)
+	next := page.Next
+	page.Next = ""
+	require.Equal(t, http.StatusOK, doJSON(t, server, http.MethodGet, "/api/items?limit=1&sort=name&cursor="+url.QueryEscape(next), nil, &page))
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, "Папка", page.Items[0].Title)
+	assert.Empty(t, page.Next)
+	assert.Equal(t, http.StatusBadRequest, doJSON(t, server, http.MethodGet, "/api/items?cursor=broken", nil, nil))
+
+	// Перемещение в папку и замена тегов
+	var updated testItem
+	status = doJSON(t, server, http.MethodPatch, "/api/items/"+strconv.Itoa(note.ID), map[string]interface{}{
+		"title":     "Пирог",
+		"parent_id": folder.ID,
+		"tags":      "выпечка",
+	}, &updated)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "Пирог", updated.Title)
+	require.NotNil(t, updated.ParentID)
+	assert.Equal(t, folder.ID, *updated.ParentID)
+	assert.Equal(t, []string{"выпечка"}, updated.Tags)
+
+	// Папку нельзя переместить внутрь элемента
+	status = doJSON(t, server, http.MethodPatch, "/api/items/"+strconv.Itoa(folder.ID), map[string]interface{}{"parent_id": note.ID}, nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	var found struct {
+		Results []json.RawMessage `json:"results"`
+	}
+	require.Equal(t, http.StatusOK, doJSON(t, server, http.MethodGet, "/api/search?q=пирога", nil, &found))
+	assert.Len(t, found.Results, 1)
+
+	// Удалённый элемент попадает в корзину и пропадает из списка
+	require.Equal(t, http.StatusOK, doJSON(t, server, http.MethodDelete, "/api/items/"+strconv.Itoa(note.ID), nil, nil))
+	require.Equal(t, http.StatusOK, doJSON(t, server, http.MethodGet, "/api/items?all=1", nil, &page))
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, "Папка", page.Items[0].Title)
+
+	// Элемент в корзине недоступен по ID
+	trashed := "/api/items/" + strconv.Itoa(note.ID)
+	assert.Equal(t, http.StatusNotFound, doJSON(t, server, http.MethodGet, trashed, nil, nil))
+	assert.Equal(t, http.StatusNotFound, doJSON(t, server, http.MethodPatch, trashed, map[string]interface{}{"title": "Из корзины"}, nil))
+	assert.Equal(t, http.StatusNotFound, doJSON(t, server, http.MethodDelete, trashed, nil, nil))
+}
+
+// TestServerRejectsFilePaths проверяет, что API не копирует в библиотеку файлы по путям узла
+func TestServerRejectsFilePaths(t *testing.T) {
+	server := setupTestServer(t)
+
+	var apiErr struct {
+		Error string `json:"error"`
+	}
+	status := doJSON(t, server, http.MethodPost, "/api/items", map[string]interface{}{
+		"title": "Ключ",
+		"files": []string{"/etc/passwd"},
+	}, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, apiErr.Error, "uploads")
+}
+
+// TestListenLoopbackOnly проверяет, что API не открывается на внешних адресах
+func TestListenLoopbackOnly(t *testing.T) {
+	for _, addr := range []string{"0.0.0.0:0", ":0", "192.168.1.10:7878"} {
+		_, err := Listen(addr)
+		assert.ErrorIs(t, err, ErrNotLoopback, addr)
+	}
+
+	listener, err := Listen("127.0.0.1:0")
+	require.NoError(t, err)
+	listener.Close()
+}
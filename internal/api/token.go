@@ -0,0 +1,53 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// tokenSize длина токена доступа в байтах
+const tokenSize = 32
+
+// LoadOrCreateToken возвращает токен доступа из файла path, а если файла нет - создает случайный токен
+// и сохраняет его с правами только для владельца. Локальные клиенты читают токен из того же файла.
+func LoadOrCreateToken(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if token := strings.TrimSpace(string(data)); token != "" {
+			return token, nil
+		}
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("ошибка чтения токена API: %w", err)
+	}
+
+	raw := make([]byte, tokenSize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(raw)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", fmt.Errorf("ошибка создания директории токена API: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(token+"\n"), 0600); err != nil {
+		return "", fmt.Errorf("ошибка сохранения токена API: %w", err)
+	}
+	return token, nil
+}
+
+// ReadToken возвращает токен доступа из файла path, не создавая его. Так его читают локальные клиенты.
+func ReadToken(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("ошибка чтения токена API: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("файл токена API пуст: %s", path)
+	}
+	return token, nil
+}
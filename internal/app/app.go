@@ -2,11 +2,19 @@ package app
 
 import (
 	"log"
+	"time"
 
 	"projectT/internal/config"
+	"projectT/internal/services"
+	"projectT/internal/services/backup"
+	"projectT/internal/services/library"
+	"projectT/internal/services/linkpreview"
 	"projectT/internal/services/p2p/network"
+	"projectT/internal/services/snapshot"
+	"projectT/internal/services/watch"
 	"projectT/internal/storage/database"
 	"projectT/internal/storage/filesystem"
+	"projectT/internal/storage/vault"
 	"projectT/internal/ui"
 	"projectT/internal/ui/theme"
 
@@ -18,25 +26,17 @@ type App struct {
 	fyneApp    fyne.App
 	mainWindow fyne.Window
 	UI         *ui.UI
-	config     *config.Config
+	config     *config.Config // Конфигурация с путями открытой библиотеки
+	baseConfig *config.Config // Конфигурация из файла, окружения и флагов
 	p2pNetwork *network.P2PNetwork
 }
 
 func NewApp() *App {
-	// Загружаем конфигурацию
-	loader := config.NewLoader()
-	cfg, err := loader.Load()
-	if err != nil {
-		log.Printf("Предупреждение: ошибка при загрузке конфигурации: %v", err)
-		cfg = config.DefaultConfig()
-	}
-
-	// Инициализируем базу данных с конфигурацией
-	database.InitDBWithConfig(cfg.Database)
-	database.RunMigrations()
-
-	// Инициализируем файловое хранилище с конфигурацией
-	filesystem.InitStorage(cfg.Storage)
+	baseConfig := loadConfig()
+	registry, lib := openLibraries(baseConfig)
+	cfg := libraryConfig(baseConfig, lib)
+	initStorage(cfg)
+	startBackgroundServices(cfg)
 
 	fyneApp := fyneApp.New()
 
@@ -49,18 +49,47 @@ func NewApp() *App {
 	// Инициализируем P2P сеть
 	p2pNetwork := network.NewP2PNetwork()
 
-	return &App{
+	app := &App{
 		fyneApp:    fyneApp,
 		mainWindow: window,
 		UI:         nil,
 		config:     cfg,
+		baseConfig: baseConfig,
 		p2pNetwork: p2pNetwork,
 	}
+	if registry != nil {
+		library.Configure(registry, app.openLibrary)
+	}
+	return app
 }
 
 func (a *App) Run() {
 	a.fyneApp.Settings().SetTheme(theme.GetFyneTheme())
 
+	// Зашифрованное хранилище сначала разблокируется: до этого P2P не принимает сообщения,
+	// иначе они были бы записаны в базу открытыми
+	if filesystem.VaultEnabled() && !vault.Unlocked() {
+		a.showVaultUnlock(a.start)
+	} else {
+		a.start()
+	}
+	a.mainWindow.ShowAndRun()
+
+	// Удаляем временные расшифрованные копии файлов
+	if err := filesystem.ClearPlainCache(); err != nil {
+		log.Printf("Предупреждение: ошибка удаления временных файлов: %v", err)
+	}
+
+	// Останавливаем P2P при выходе
+	if a.p2pNetwork != nil {
+		if err := a.p2pNetwork.Stop(); err != nil {
+			log.Printf("Предупреждение: ошибка остановки P2P: %v", err)
+		}
+	}
+}
+
+// start запускает P2P и интерфейс
+func (a *App) start() {
 	// Запускаем P2P если включён в конфигурации
 	if a.config.P2P.Enabled {
 		if err := a.p2pNetwork.Start(); err != nil {
@@ -71,17 +100,89 @@ func (a *App) Run() {
 	}
 
 	a.UI = ui.NewUI(a.mainWindow, a.p2pNetwork)
-	a.mainWindow.ShowAndRun()
+}
 
-	// Останавливаем P2P при выходе
-	if a.p2pNetwork != nil {
-		if err := a.p2pNetwork.Stop(); err != nil {
-			log.Printf("Предупреждение: ошибка остановки P2P: %v", err)
-		}
+// loadConfig загружает конфигурацию; при ошибке используются значения по умолчанию
+func loadConfig() *config.Config {
+	loader := config.NewLoader()
+	cfg, err := loader.Load()
+	if err != nil {
+		log.Printf("Предупреждение: ошибка при загрузке конфигурации: %v", err)
+		cfg = config.DefaultConfig()
+	}
+	return cfg
+}
+
+// initStorage открывает базу данных, применяет миграции, настраивает файловое хранилище
+// и разблокирует его, если оно зашифровано и пароль задан в окружении
+func initStorage(cfg *config.Config) {
+	database.InitDBWithConfig(cfg.Database)
+	database.RunMigrations()
+	filesystem.InitStorage(cfg.Storage)
+	unlockVault(cfg)
+}
+
+// startBackgroundServices запускает фоновое обслуживание библиотеки: очистку корзины,
+// сборку неиспользуемых файлов, резервное копирование по расписанию, импорт из директорий,
+// превью ссылок и поиск по сохранённым копиям страниц
+func startBackgroundServices(cfg *config.Config) {
+	// Окончательно удаляем элементы, пролежавшие в корзине дольше срока хранения
+	services.NewTrashService().StartAutoPurge(cfg.Storage.TrashRetentionDays)
+
+	// Удаляем с диска файлы, на которые не осталось ссылок
+	services.NewFileGCService().StartPeriodic(time.Duration(cfg.Storage.GCGraceHours) * time.Hour)
+
+	// Ограничение истории изменений элементов
+	services.NewRevisionService().SetMaxRevisions(cfg.Storage.MaxRevisionsPerItem)
+
+	// Зашифрованные резервные копии по расписанию
+	configureBackup(cfg)
+	backup.NewService().StartScheduled(time.Duration(cfg.Backup.IntervalHours) * time.Hour)
+
+	// Автоматический импорт из отслеживаемых директорий
+	startWatch(cfg)
+
+	// Превью ссылок загружаются и обновляются в фоне
+	if cfg.LinkPreview.Enabled {
+		linkpreview.Get().Start(time.Duration(cfg.LinkPreview.RefreshDays) * 24 * time.Hour)
+	}
+
+	go indexPageTexts()
+}
+
+// indexPageTexts добавляет в поиск текст сохранённых копий страниц, которого в нём нет
+func indexPageTexts() {
+	indexed, err := snapshot.Get().IndexTexts()
+	if err != nil {
+		log.Printf("Ошибка добавления текста копий страниц в поиск: %v", err)
+		return
+	}
+	if indexed > 0 {
+		log.Printf("В поиск добавлен текст копий страниц: %d", indexed)
+	}
+}
+
+// startWatch начинает отслеживать директории из настроек; файлы импортируются в открытую библиотеку
+func startWatch(cfg *config.Config) {
+	if len(cfg.Watch.Folders) == 0 {
+		return
+	}
+	rules := make([]watch.Rule, 0, len(cfg.Watch.Folders))
+	for _, folder := range cfg.Watch.Folders {
+		rules = append(rules, watch.Rule{
+			Dir:          folder.Path,
+			Parent:       folder.Parent,
+			Tags:         folder.Tags,
+			DeleteSource: folder.DeleteSource,
+			Ignore:       folder.Ignore,
+		})
+	}
+	if err := watch.New(rules, time.Duration(cfg.Watch.DebounceMs)*time.Millisecond).Start(); err != nil {
+		log.Printf("Предупреждение: %v", err)
 	}
 }
 
-// GetConfig возвращает текущую конфигурацию приложения
+// GetConfig возвращает конфигурацию приложения с путями открытой библиотеки
 func (a *App) GetConfig() *config.Config {
 	return a.config
 }
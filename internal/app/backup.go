@@ -0,0 +1,89 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"projectT/internal/config"
+	"projectT/internal/services/backup"
+	"projectT/internal/storage/database"
+)
+
+// configureBackup передаёт настройки резервного копирования сервису
+func configureBackup(cfg *config.Config) {
+	backup.Configure(backup.Config{
+		Dir:      cfg.Backup.Path,
+		Password: cfg.Backup.Password,
+		KeepLast: cfg.Backup.KeepLast,
+		KeepDays: cfg.Backup.KeepDays,
+		Database: cfg.Database,
+	})
+}
+
+// RunBackup создаёт резервную копию без запуска интерфейса.
+// Возвращает код выхода: 0 - копия создана, 2 - ошибка.
+func RunBackup() int {
+	cfg := loadLibraryConfig()
+	initStorage(cfg)
+	defer database.CloseDB()
+	configureBackup(cfg)
+
+	report, err := backup.NewService().Create(context.Background())
+	if err != nil {
+		fmt.Printf("Ошибка резервного копирования: %v\n", err)
+		return 2
+	}
+	fmt.Println(report.String())
+	return 0
+}
+
+// RunBackupList печатает список резервных копий
+func RunBackupList() int {
+	cfg := loadLibraryConfig()
+	configureBackup(cfg)
+
+	snapshots, err := backup.NewService().List()
+	if err != nil {
+		fmt.Printf("Ошибка чтения резервных копий: %v\n", err)
+		return 2
+	}
+	if len(snapshots) == 0 {
+		fmt.Println("Резервных копий нет")
+		return 0
+	}
+	for _, snapshot := range snapshots {
+		fmt.Printf("%s  %s  база %d байт, файлов %d\n", snapshot.ID,
+			snapshot.CreatedAt.Local().Format("02.01.2006 15:04:05"), snapshot.Size, snapshot.Blobs)
+	}
+	return 0
+}
+
+// RunRestore восстанавливает библиотеку из резервной копии без запуска интерфейса.
+// target - ID копии, latest или время в формате 2006-01-02T15:04 (последняя копия не позже него).
+func RunRestore(target string) int {
+	cfg := loadLibraryConfig()
+	initStorage(cfg)
+	defer database.CloseDB()
+	configureBackup(cfg)
+
+	service := backup.NewService()
+	ctx := context.Background()
+	var (
+		report *backup.RestoreReport
+		err    error
+	)
+	if target == "latest" {
+		report, err = service.RestoreAt(ctx, time.Now())
+	} else if at, parseErr := time.ParseInLocation("2006-01-02T15:04", target, time.Local); parseErr == nil {
+		report, err = service.RestoreAt(ctx, at)
+	} else {
+		report, err = service.Restore(ctx, target)
+	}
+	if err != nil {
+		fmt.Printf("Ошибка восстановления: %v\n", err)
+		return 2
+	}
+	fmt.Println(report.String())
+	return 0
+}
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"projectT/internal/api"
+	"projectT/internal/cli"
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/filesystem"
+	"projectT/internal/storage/vault"
+)
+
+// RunCLI выполняет команду клиента командной строки (add, ls, search, ...) над открытой библиотекой
+// или, если задан --api, через API запущенного узла. Возвращает код выхода команды.
+func RunCLI(args []string) int {
+	// Сервисы печатают ход работы в stdout; в нём должен остаться только результат команды
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	defer func() { os.Stdout = stdout }()
+
+	opened := false
+	defer func() {
+		if !opened {
+			return
+		}
+		if err := filesystem.ClearPlainCache(); err != nil {
+			log.Printf("Предупреждение: ошибка удаления временных файлов: %v", err)
+		}
+		database.CloseDB()
+	}()
+
+	open := func(apiAddr string) (cli.Backend, error) {
+		cfg := loadLibraryConfig()
+		if apiAddr != "" {
+			token := cfg.Daemon.Token
+			if token == "" {
+				var err error
+				if token, err = api.ReadToken(cfg.Daemon.TokenFile); err != nil {
+					return nil, err
+				}
+			}
+			return cli.NewRemote(apiAddr, token), nil
+		}
+
+		initStorage(cfg)
+		opened = true
+		if filesystem.VaultEnabled() && !vault.Unlocked() {
+			return nil, errors.New("хранилище зашифровано: задайте пароль в PROJECTT_VAULT_PASSWORD")
+		}
+		return cli.NewLocal(), nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return cli.Run(ctx, cli.Env{Open: open, Stdin: os.Stdin, Stdout: stdout, Stderr: os.Stderr}, args)
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"projectT/internal/api"
+	"projectT/internal/services/p2p/network"
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/filesystem"
+	"projectT/internal/storage/vault"
+)
+
+// RunDaemon запускает узел без интерфейса: открывает библиотеку, P2P и локальный JSON API
+// и работает до SIGINT или SIGTERM. Так библиотека остаётся доступной контактам, например на домашнем сервере.
+// Возвращает код выхода: 0 - узел остановлен сигналом, 2 - ошибка запуска или работы.
+func RunDaemon() int {
+	cfg := loadLibraryConfig()
+	initStorage(cfg)
+	defer database.CloseDB()
+
+	// Спросить пароль негде: без него P2P записывал бы сообщения в базу открытыми
+	if filesystem.VaultEnabled() && !vault.Unlocked() {
+		fmt.Println("Хранилище зашифровано: задайте пароль в PROJECTT_VAULT_PASSWORD")
+		return 2
+	}
+	defer func() {
+		if err := filesystem.ClearPlainCache(); err != nil {
+			log.Printf("Предупреждение: ошибка удаления временных файлов: %v", err)
+		}
+	}()
+
+	token := cfg.Daemon.Token
+	if token == "" {
+		var err error
+		if token, err = api.LoadOrCreateToken(cfg.Daemon.TokenFile); err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return 2
+		}
+	}
+
+	listener, err := api.Listen(cfg.Daemon.Listen)
+	if err != nil {
+		fmt.Printf("Ошибка открытия API: %v\n", err)
+		return 2
+	}
+
+	startBackgroundServices(cfg)
+
+	p2pNetwork := network.NewP2PNetwork()
+	if cfg.P2P.Enabled {
+		if err := p2pNetwork.Start(); err != nil {
+			log.Printf("Предупреждение: P2P не запущен: %v", err)
+		} else {
+			log.Println("P2P запущен")
+		}
+	}
+	defer func() {
+		if err := p2pNetwork.Stop(); err != nil {
+			log.Printf("Предупреждение: ошибка остановки P2P: %v", err)
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("API доступен на %s", cfg.Daemon.Listen)
+	if cfg.Daemon.Token == "" {
+		log.Printf("Токен доступа: %s", cfg.Daemon.TokenFile)
+	}
+	if err := api.NewServer(p2pNetwork, token).Serve(ctx, listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Printf("Ошибка API: %v\n", err)
+		return 2
+	}
+	log.Println("Узел остановлен")
+	return 0
+}
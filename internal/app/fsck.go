@@ -0,0 +1,28 @@
+package app
+
+import (
+	"fmt"
+
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/fsck"
+)
+
+// RunFsck проверяет целостность хранилища без запуска интерфейса и печатает отчёт.
+// Возвращает код выхода: 0 - проблем нет или все исправлены, 1 - остались проблемы, 2 - проверка не выполнена.
+func RunFsck(repair bool) int {
+	cfg := loadLibraryConfig()
+	initStorage(cfg)
+	defer database.CloseDB()
+
+	report, err := fsck.Run(fsck.Options{Repair: repair})
+	if err != nil {
+		fmt.Printf("Ошибка проверки целостности: %v\n", err)
+		return 2
+	}
+
+	fmt.Println(report.String())
+	if report.Unresolved() > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"fmt"
+	"log"
+
+	"projectT/internal/config"
+	"projectT/internal/services"
+	"projectT/internal/services/backup"
+	"projectT/internal/services/library"
+	"projectT/internal/services/linkpreview"
+	"projectT/internal/services/p2p/network"
+	"projectT/internal/services/snapshot"
+	"projectT/internal/services/watch"
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/filesystem"
+	"projectT/internal/storage/vault"
+	"projectT/internal/ui"
+)
+
+// openLibraries открывает реестр библиотек и выбирает библиотеку для запуска: заданную
+// в конфигурации (--library, PROJECTT_LIBRARY) или открытую последней.
+// Если реестр не читается, открывается основная библиотека, а переключение недоступно.
+func openLibraries(cfg *config.Config) (*library.Registry, library.Library) {
+	primary := library.Library{
+		ID:           library.DefaultID,
+		Name:         library.DefaultName,
+		DatabasePath: cfg.Database.Path,
+		StoragePath:  cfg.Storage.Path,
+		BackupPath:   cfg.Backup.Path,
+	}
+	registry, err := library.Open(cfg.Libraries.Path, primary)
+	if err != nil {
+		log.Printf("Предупреждение: %v; открыта основная библиотека", err)
+		return nil, primary
+	}
+
+	if cfg.Libraries.Active != "" {
+		lib, err := registry.Get(cfg.Libraries.Active)
+		if err != nil {
+			log.Printf("Предупреждение: %v; открыта последняя библиотека", err)
+		} else if err := registry.SetActive(lib.ID); err != nil {
+			log.Printf("Предупреждение: %v", err)
+			return registry, lib
+		}
+	}
+	return registry, registry.Active()
+}
+
+// libraryConfig возвращает конфигурацию с базой, хранилищем и резервными копиями библиотеки lib
+func libraryConfig(base *config.Config, lib library.Library) *config.Config {
+	cfg := *base
+	cfg.Database.Path = lib.DatabasePath
+	cfg.Storage.Path = lib.StoragePath
+	cfg.Backup.Path = lib.BackupPath
+	return &cfg
+}
+
+// loadLibraryConfig загружает конфигурацию активной библиотеки для запуска без интерфейса
+func loadLibraryConfig() *config.Config {
+	cfg := loadConfig()
+	_, lib := openLibraries(cfg)
+	return libraryConfig(cfg, lib)
+}
+
+// openLibrary закрывает открытую библиотеку и открывает lib без перезапуска: останавливает P2P,
+// дожидается фоновых операций с файлами и копиями, открывает базу и хранилище lib и строит интерфейс заново.
+// Если базу lib открыть не удалось, остаётся открытой прежняя библиотека.
+func (a *App) openLibrary(lib library.Library) error {
+	cfg := libraryConfig(a.baseConfig, lib)
+
+	// P2P пишет в базу и использует ключи профиля открытой библиотеки
+	if err := a.p2pNetwork.Stop(); err != nil {
+		log.Printf("Предупреждение: ошибка остановки P2P: %v", err)
+	}
+	a.p2pNetwork = network.NewP2PNetwork()
+
+	err := switchStorage(cfg)
+	if err == nil {
+		a.config = cfg
+		log.Printf("Открыта библиотека %q", lib.Name)
+	}
+
+	ui.ResetState()
+	if filesystem.VaultEnabled() && !vault.Unlocked() {
+		a.showVaultUnlock(a.start)
+	} else {
+		a.start()
+	}
+	return err
+}
+
+// switchStorage открывает базу и хранилище cfg вместо текущих
+func switchStorage(cfg *config.Config) error {
+	resumeFiles := services.SuspendFileStorage()
+	defer resumeFiles()
+	resumeTrash := services.SuspendTrashPurge()
+	defer resumeTrash()
+	resumeBackup := backup.Suspend()
+	defer resumeBackup()
+	resumeWatch := watch.Suspend()
+	defer resumeWatch()
+	resumePreviews := linkpreview.Suspend()
+	defer resumePreviews()
+	resumeSnapshots := snapshot.Suspend()
+	defer resumeSnapshots()
+
+	if err := database.Switch(cfg.Database); err != nil {
+		return fmt.Errorf("библиотека не открыта: %w", err)
+	}
+
+	// Ключ и расшифрованные копии файлов относятся к прежнему хранилищу
+	vault.Lock()
+	if err := filesystem.ClearPlainCache(); err != nil {
+		log.Printf("Предупреждение: ошибка удаления временных файлов: %v", err)
+	}
+	filesystem.InitStorage(cfg.Storage)
+	configureBackup(cfg)
+	unlockVault(cfg)
+
+	// Начнётся, когда хранилище будет снова доступно
+	go indexPageTexts()
+	return nil
+}
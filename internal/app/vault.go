@@ -0,0 +1,55 @@
+package app
+
+import (
+	"log"
+
+	"projectT/internal/config"
+	"projectT/internal/services"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// unlockVault разблокирует зашифрованное хранилище паролем из PROJECTT_VAULT_PASSWORD, если он задан
+func unlockVault(cfg *config.Config) {
+	service := services.NewVaultService()
+	if !service.Enabled() || cfg.Storage.VaultPassword == "" {
+		return
+	}
+	if err := service.Unlock(cfg.Storage.VaultPassword); err != nil {
+		log.Printf("Предупреждение: хранилище не разблокировано: %v", err)
+	}
+}
+
+// showVaultUnlock показывает в окне форму пароля зашифрованного хранилища и вызывает onUnlocked после разблокировки
+func (a *App) showVaultUnlock(onUnlocked func()) {
+	service := services.NewVaultService()
+
+	password := widget.NewPasswordEntry()
+	errorLabel := widget.NewLabel("")
+	errorLabel.Importance = widget.DangerImportance
+
+	submit := func() {
+		if err := service.Unlock(password.Text); err != nil {
+			errorLabel.SetText(err.Error())
+			password.SetText("")
+			return
+		}
+		onUnlocked()
+	}
+	password.OnSubmitted = func(string) { submit() }
+
+	title := widget.NewLabel("Хранилище зашифровано")
+	title.TextStyle = fyne.TextStyle{Bold: true}
+
+	form := container.NewVBox(
+		title,
+		widget.NewLabel("Введите пароль, чтобы открыть библиотеку"),
+		password,
+		widget.NewButton("Открыть", submit),
+		errorLabel,
+	)
+	a.mainWindow.SetContent(container.NewCenter(container.NewGridWrap(fyne.NewSize(360, 200), form)))
+	a.mainWindow.Canvas().Focus(password)
+}
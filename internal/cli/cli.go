@@ -0,0 +1,197 @@
+// Package cli реализует клиент командной строки для работы с библиотекой из скриптов:
+//
+//	projectT [флаги конфигурации] <команда> [флаги команды] [аргументы]
+//
+// Команды работают либо напрямую с сервисами открытой библиотеки, либо с запущенным узлом
+// (projectT --daemon) через его JSON API, если задан --api или PROJECTT_API. Элементы создаются
+// тем же путём, что и в окне создания, поэтому не отличаются от добавленных через интерфейс.
+// С флагом --json результат печатается в JSON.
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"projectT/internal/services"
+	"projectT/internal/services/archive"
+	"projectT/internal/services/contacts"
+	"projectT/internal/storage/database/models"
+)
+
+// ErrRemoteUnsupported команда не поддерживается при работе через API узла
+var ErrRemoteUnsupported = errors.New("команда доступна только без --api: запустите её на компьютере с библиотекой")
+
+// Item элемент вместе с названиями его тегов
+type Item struct {
+	*models.Item
+	Tags []string `json:"tags"`
+}
+
+// ListQuery параметры выборки элементов, как в сетке
+type ListQuery struct {
+	ParentID int
+	All      bool   // Элементы всех папок
+	Type     string // Фильтр по типу: all, folders, images, files, links, text
+	Priority string // Какие элементы показывать первыми
+	Sort     string // name, created_date, modified_date, content_size
+	Order    string // asc или desc
+	Limit    int
+	Cursor   string // Курсор следующей страницы из Page.Next
+}
+
+// Page страница элементов
+type Page struct {
+	Items []*models.Item `json:"items"`
+	Next  string         `json:"next,omitempty"`
+}
+
+// Backend операции над библиотекой: локальные сервисы или API запущенного узла
+type Backend interface {
+	AddItem(ctx context.Context, input services.ItemInput) (*Item, error)
+	GetItem(ctx context.Context, id int) (*Item, error)
+	ListItems(ctx context.Context, query ListQuery) (*Page, error)
+	Search(ctx context.Context, query string) ([]*models.SearchResult, error)
+	SetTags(ctx context.Context, id int, tags string) (*Item, error)
+	MoveItem(ctx context.Context, id int, parentID *int) (*Item, error)
+	RemoveItem(ctx context.Context, id int) (*models.TrashEntry, error)
+	Contacts(ctx context.Context) ([]*contacts.ContactWithStatus, error)
+	AddContact(ctx context.Context, address, notes string) (*models.Contact, error)
+	Messages(ctx context.Context, contactID, limit int) ([]*models.ChatMessage, error)
+	SendMessage(ctx context.Context, contactID int, content string) error
+	// Export экспортирует в архив filePath библиотеку, папку id или элементы с тегом tagName
+	Export(ctx context.Context, filePath string, scope archive.ScopeKind, id int, tagName string) (*archive.ExportReport, error)
+	Import(ctx context.Context, filePath string, parentID *int) (*archive.ImportReport, error)
+}
+
+// Opener открывает библиотеку: локально, если apiAddr пуст, иначе через API узла на apiAddr
+type Opener func(apiAddr string) (Backend, error)
+
+// Env окружение команды
+type Env struct {
+	Open   Opener
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// command подкоманда клиента
+type command struct {
+	usage string // Аргументы команды для справки
+	help  string
+	// setup регистрирует флаги команды, кроме общих, и возвращает её обработчик
+	setup func(fs *flag.FlagSet) func(c *cmdContext, args []string) error
+}
+
+// commands подкоманды по имени
+var commands = map[string]*command{
+	"add":      addCommand,
+	"ls":       lsCommand,
+	"search":   searchCommand,
+	"tag":      tagCommand,
+	"mv":       mvCommand,
+	"rm":       rmCommand,
+	"export":   exportCommand,
+	"import":   importCommand,
+	"contacts": contactsCommand,
+	"chat":     chatCommand,
+}
+
+// IsCommand сообщает, является ли name подкомандой клиента
+func IsCommand(name string) bool {
+	_, ok := commands[name]
+	return ok
+}
+
+// cmdContext состояние выполняемой команды
+type cmdContext struct {
+	ctx     context.Context
+	env     Env
+	backend Backend
+	json    bool
+}
+
+// Run выполняет команду args[0] с аргументами args[1:].
+// Возвращает код выхода: 0 - команда выполнена, 2 - ошибка.
+func Run(ctx context.Context, env Env, args []string) int {
+	if len(args) == 0 || args[0] == "help" {
+		printUsage(env.Stdout)
+		return 0
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(env.Stderr, "Неизвестная команда %q\n", args[0])
+		printUsage(env.Stderr)
+		return 2
+	}
+
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	jsonOutput := fs.Bool("json", false, "Печатать результат в JSON")
+	apiAddr := fs.String("api", os.Getenv("PROJECTT_API"), "Адрес API запущенного узла: host:port или unix:/путь")
+	run := cmd.setup(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(env.Stderr, "Использование: projectT %s [флаги] %s\n%s\n\nФлаги:\n", args[0], cmd.usage, cmd.help)
+		fs.PrintDefaults()
+	}
+	positional, err := parseArgs(fs, args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	backend, err := env.Open(*apiAddr)
+	if err != nil {
+		fmt.Fprintf(env.Stderr, "Ошибка: %v\n", err)
+		return 2
+	}
+
+	c := &cmdContext{ctx: ctx, env: env, backend: backend, json: *jsonOutput}
+	if err := run(c, positional); err != nil {
+		if errors.Is(err, errUsage) {
+			fs.Usage()
+		} else {
+			fmt.Fprintf(env.Stderr, "Ошибка: %v\n", err)
+		}
+		return 2
+	}
+	return 0
+}
+
+// errUsage неверные аргументы команды: печатается справка по команде
+var errUsage = errors.New("неверные аргументы")
+
+// printUsage печатает список команд
+func printUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "Команды:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-9s %s\n", name, commands[name].help)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Общие флаги команд: --json (вывод в JSON), --api=адрес (работать через запущенный узел, также PROJECTT_API)")
+	fmt.Fprintln(w, "Справка по команде: projectT <команда> --help")
+}
+
+// output печатает v в JSON или, без --json, вызывает text
+func (c *cmdContext) output(v interface{}, text func(w io.Writer)) error {
+	if !c.json {
+		text(c.env.Stdout)
+		return nil
+	}
+	encoder := json.NewEncoder(c.env.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,225 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"projectT/internal/api"
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/filesystem"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testStorageConfig хранилище файлов во временной директории
+type testStorageConfig struct{ path string }
+
+func (c testStorageConfig) GetPath() string     { return c.path }
+func (c testStorageConfig) GetFilesDir() string { return "files" }
+
+// setupTestLibrary подключает БД в памяти и временное хранилище файлов
+func setupTestLibrary(t *testing.T) {
+	t.Helper()
+
+	db, err := database.Open(":memory:")
+	require.NoError(t, err)
+	originalDB := database.GetDB()
+	database.SetDB(db)
+	database.RunMigrations()
+
+	root := filesystem.GetStorageRoot()
+	filesystem.InitStorage(testStorageConfig{path: t.TempDir()})
+
+	t.Cleanup(func() {
+		database.CloseDB()
+		database.SetDB(originalDB)
+		filesystem.InitStorage(testStorageConfig{path: root})
+	})
+}
+
+// runCommand выполняет команду клиента и возвращает код выхода и вывод
+func runCommand(t *testing.T, backend Backend, stdin string, args ...string) (int, string) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	env := Env{
+		Open:   func(string) (Backend, error) { return backend, nil },
+		Stdin:  strings.NewReader(stdin),
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	code := Run(context.Background(), env, args)
+	if code != 0 {
+		t.Logf("%v: %s", args, stderr.String())
+	}
+	return code, stdout.String()
+}
+
+// runJSON выполняет команду с --json и разбирает результат в out
+func runJSON(t *testing.T, backend Backend, out interface{}, args ...string) {
+	t.Helper()
+
+	code, output := runCommand(t, backend, "", append(args, "--json")...)
+	require.Equal(t, 0, code, args)
+	require.NoError(t, json.Unmarshal([]byte(output), out), output)
+}
+
+// testBackends возвращает локальный клиент и клиент API узла над одной тестовой библиотекой
+func testBackends(t *testing.T) map[string]Backend {
+	server := httptest.NewServer(api.NewServer(nil, "token"))
+	t.Cleanup(server.Close)
+	return map[string]Backend{
+		"local":  NewLocal(),
+		"remote": NewRemote(strings.TrimPrefix(server.URL, "http://"), "token"),
+	}
+}
+
+// TestItemCommands проверяет add, ls, tag, mv, search и rm локально и через API узла
+func TestItemCommands(t *testing.T) {
+	setupTestLibrary(t)
+
+	for name, backend := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			var folder Item
+			runJSON(t, backend, &folder, "add", "--folder", "--title", "Папка "+name)
+
+			file := filepath.Join(t.TempDir(), "notes-"+name+".txt")
+			require.NoError(t, os.WriteFile(file, []byte("содержимое "+name), 0644))
+
+			// Флаги можно писать после файлов
+			var item Item
+			runJSON(t, backend, &item, "add", file, "https://example.com/"+name, "--tags", "работа, "+name, "--parent", strconv.Itoa(folder.ID))
+			assert.Equal(t, "notes-"+name+".txt", item.Title)
+			assert.ElementsMatch(t, []string{"работа", name}, item.Tags)
+			require.NotNil(t, item.ParentID)
+			assert.Equal(t, folder.ID, *item.ParentID)
+			assert.Contains(t, item.ContentMeta, `"file"`)
+			assert.Contains(t, item.ContentMeta, "https://example.com/"+name)
+
+			var page Page
+			runJSON(t, backend, &page, "ls", "--parent", strconv.Itoa(folder.ID))
+			require.Len(t, page.Items, 1)
+			assert.Equal(t, item.ID, page.Items[0].ID)
+
+			var tagged Item
+			runJSON(t, backend, &tagged, "tag", "--add", strconv.Itoa(item.ID), "важное")
+			assert.ElementsMatch(t, []string{"работа", name, "важное"}, tagged.Tags)
+			runJSON(t, backend, &tagged, "tag", strconv.Itoa(item.ID), "архив")
+			assert.Equal(t, []string{"архив"}, tagged.Tags)
+
+			var moved struct {
+				Items []Item `json:"items"`
+			}
+			runJSON(t, backend, &moved, "mv", strconv.Itoa(item.ID), "/")
+			require.Len(t, moved.Items, 1)
+			assert.Nil(t, moved.Items[0].ParentID)
+
+			// Папку нельзя переместить внутрь неё самой
+			code, _ := runCommand(t, backend, "", "mv", strconv.Itoa(folder.ID), strconv.Itoa(folder.ID))
+			assert.Equal(t, 2, code)
+
+			code, output := runCommand(t, backend, "Список покупок\nмолоко", "add", "--text", "-")
+			require.Equal(t, 0, code)
+			assert.Contains(t, output, "Список покупок")
+
+			var found struct {
+				Results []json.RawMessage `json:"results"`
+			}
+			runJSON(t, backend, &found, "search", "молоко")
+			assert.NotEmpty(t, found.Results)
+
+			var removed struct {
+				Trash []json.RawMessage `json:"trash"`
+			}
+			runJSON(t, backend, &removed, "rm", strconv.Itoa(folder.ID))
+			assert.Len(t, removed.Trash, 1)
+			runJSON(t, backend, &page, "ls", "--all")
+			for _, listed := range page.Items {
+				assert.NotEqual(t, folder.ID, listed.ID)
+			}
+		})
+	}
+}
+
+// TestListPages проверяет, что ls с --limit отдаёт курсор, по которому читается следующая страница
+func TestListPages(t *testing.T) {
+	setupTestLibrary(t)
+
+	for name, backend := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, title := range []string{"a", "b", "c"} {
+				code, _ := runCommand(t, backend, "", "add", "--title", name+"-"+title, "--text", "текст")
+				require.Equal(t, 0, code)
+			}
+
+			var titles []string
+			cursor := ""
+			for pages := 0; pages < 10; pages++ {
+				args := []string{"ls", "--all", "--sort", "name", "--limit", "2"}
+				if cursor != "" {
+					args = append(args, "--cursor", cursor)
+				}
+				var page Page
+				runJSON(t, backend, &page, args...)
+				for _, item := range page.Items {
+					if strings.HasPrefix(item.Title, name+"-") {
+						titles = append(titles, item.Title)
+					}
+				}
+				if page.Next == "" {
+					break
+				}
+				cursor = page.Next
+			}
+			assert.Equal(t, []string{name + "-a", name + "-b", name + "-c"}, titles)
+		})
+	}
+}
+
+// TestExportImport проверяет экспорт в архив и импорт архива в папку
+func TestExportImport(t *testing.T) {
+	setupTestLibrary(t)
+	backend := NewLocal()
+
+	code, _ := runCommand(t, backend, "", "add", "--title", "Заметка", "--text", "текст", "--tags", "перенос")
+	require.Equal(t, 0, code)
+
+	archivePath := filepath.Join(t.TempDir(), "export.zip")
+	var exported struct{ Items int }
+	runJSON(t, backend, &exported, "export", "--tag", "перенос", archivePath)
+	assert.Equal(t, 1, exported.Items)
+
+	var folder Item
+	runJSON(t, backend, &folder, "add", "--folder", "--title", "Импорт")
+	var imported struct{ ItemsCreated, ItemsMerged int }
+	runJSON(t, backend, &imported, "import", "--parent", strconv.Itoa(folder.ID), archivePath)
+	assert.Equal(t, 1, imported.ItemsCreated+imported.ItemsMerged)
+
+	// Экспорт и импорт через API узла не поддерживаются
+	remote := NewRemote("127.0.0.1:1", "token")
+	code, _ = runCommand(t, remote, "", "export", archivePath)
+	assert.Equal(t, 2, code)
+}
+
+// TestRunUsage проверяет коды выхода при неверных командах и аргументах
+func TestRunUsage(t *testing.T) {
+	backend := NewLocal()
+
+	code, _ := runCommand(t, backend, "", "unknown")
+	assert.Equal(t, 2, code)
+	code, _ = runCommand(t, backend, "", "mv", "1")
+	assert.Equal(t, 2, code)
+	code, _ = runCommand(t, backend, "", "tag", "abc")
+	assert.Equal(t, 2, code)
+	code, output := runCommand(t, backend, "", "help")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, output, "search")
+}
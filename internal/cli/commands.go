@@ -0,0 +1,485 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"projectT/internal/services"
+	"projectT/internal/services/archive"
+	"projectT/internal/storage/database/models"
+)
+
+// Наибольшая длина названия, взятого из текста элемента
+const maxTitleFromText = 60
+
+// listPageSize размер страницы, которыми ls без --limit получает все элементы
+const listPageSize = 1000
+
+// parseArgs разбирает флаги команды вперемешку с аргументами, чтобы флаги можно было
+// писать и после файлов: projectT add photo.jpg --tags отпуск. После "--" все аргументы позиционные.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		if len(args) > len(rest) && args[len(args)-len(rest)-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// parseID разбирает ID элемента или контакта
+func parseID(s string) (int, error) {
+	id, err := strconv.Atoi(s)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("неверный ID %q", s)
+	}
+	return id, nil
+}
+
+// parseParent разбирает ID папки; "/" и "0" означают корень библиотеки
+func parseParent(s string) (*int, error) {
+	if s == "/" || s == "0" {
+		return nil, nil
+	}
+	id, err := parseID(s)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// printItem печатает элемент одной строкой: ID, название и теги. Названия папок заканчиваются на "/".
+func printItem(w io.Writer, item *models.Item, tags []string) {
+	title := item.Title
+	if item.Type == models.ItemTypeFolder {
+		title += "/"
+	}
+	if len(tags) > 0 {
+		fmt.Fprintf(w, "%d\t%s\t[%s]\n", item.ID, title, strings.Join(tags, ", "))
+		return
+	}
+	fmt.Fprintf(w, "%d\t%s\n", item.ID, title)
+}
+
+// defaultTitle возвращает название элемента без --title: имя первого файла, адрес первой ссылки
+// или начало первой строки текста
+func defaultTitle(files, links []string, text string) string {
+	if len(files) > 0 {
+		return filepath.Base(files[0])
+	}
+	if len(links) > 0 {
+		return links[0]
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	if utf8.RuneCountInString(line) > maxTitleFromText {
+		line = string([]rune(line)[:maxTitleFromText]) + "…"
+	}
+	return line
+}
+
+// isLink сообщает, является ли аргумент add ссылкой, а не путём к файлу
+func isLink(arg string) bool {
+	u, err := url.Parse(arg)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+var addCommand = &command{
+	usage: "[файл или ссылка...]",
+	help:  "Добавить элемент из файлов, ссылок и текста",
+	setup: func(fs *flag.FlagSet) func(c *cmdContext, args []string) error {
+		title := fs.String("title", "", "Название (по умолчанию имя первого файла, ссылка или начало текста)")
+		text := fs.String("text", "", "Текст элемента; \"-\" читает текст из stdin")
+		tags := fs.String("tags", "", "Теги через запятую")
+		parent := fs.String("parent", "/", "ID папки")
+		folder := fs.Bool("folder", false, "Создать папку")
+
+		return func(c *cmdContext, args []string) error {
+			input := services.ItemInput{Title: *title, Description: *text, Tags: *tags}
+			if *text == "-" {
+				data, err := io.ReadAll(c.env.Stdin)
+				if err != nil {
+					return fmt.Errorf("ошибка чтения текста: %w", err)
+				}
+				input.Description = string(data)
+			}
+			if *folder {
+				input.Type = models.ItemTypeFolder
+			}
+			parentID, err := parseParent(*parent)
+			if err != nil {
+				return err
+			}
+			input.ParentID = parentID
+
+			for _, arg := range args {
+				if isLink(arg) {
+					input.Links = append(input.Links, arg)
+					continue
+				}
+				// Узел может быть запущен в другой рабочей директории
+				path, err := filepath.Abs(arg)
+				if err != nil {
+					return err
+				}
+				input.Files = append(input.Files, path)
+			}
+			if input.Title == "" {
+				input.Title = defaultTitle(input.Files, input.Links, input.Description)
+			}
+			if input.Title == "" {
+				return errUsage
+			}
+
+			item, err := c.backend.AddItem(c.ctx, input)
+			if err != nil {
+				return err
+			}
+			return c.output(item, func(w io.Writer) { printItem(w, item.Item, item.Tags) })
+		}
+	},
+}
+
+var lsCommand = &command{
+	usage: "",
+	help:  "Показать элементы папки с сортировкой и фильтром, как в сетке",
+	setup: func(fs *flag.FlagSet) func(c *cmdContext, args []string) error {
+		var query ListQuery
+		parent := fs.String("parent", "/", "ID папки")
+		fs.BoolVar(&query.All, "all", false, "Элементы всех папок")
+		fs.StringVar(&query.Type, "type", "", "Тип: all, folders, images, files, links, text")
+		fs.StringVar(&query.Priority, "priority", "", "Что показывать первыми: none, folders_first, images_first, ...")
+		fs.StringVar(&query.Sort, "sort", "", "Сортировка: name, created_date, modified_date, content_size")
+		fs.StringVar(&query.Order, "order", "", "Порядок: asc или desc")
+		fs.IntVar(&query.Limit, "limit", 0, "Сколько элементов показать (0 - все); курсор следующей страницы печатается в stderr")
+		fs.StringVar(&query.Cursor, "cursor", "", "Курсор следующей страницы")
+
+		return func(c *cmdContext, args []string) error {
+			if len(args) > 0 {
+				return errUsage
+			}
+			parentID, err := parseParent(*parent)
+			if err != nil {
+				return err
+			}
+			if parentID != nil {
+				query.ParentID = *parentID
+			}
+
+			var page *Page
+			if query.Limit > 0 {
+				if page, err = c.backend.ListItems(c.ctx, query); err != nil {
+					return err
+				}
+			} else {
+				// Без ограничения собираем все страницы
+				page = &Page{Items: []*models.Item{}}
+				pageQuery := query
+				pageQuery.Limit = listPageSize
+				for {
+					next, err := c.backend.ListItems(c.ctx, pageQuery)
+					if err != nil {
+						return err
+					}
+					page.Items = append(page.Items, next.Items...)
+					if next.Next == "" {
+						break
+					}
+					pageQuery.Cursor = next.Next
+				}
+			}
+
+			return c.output(page, func(w io.Writer) {
+				for _, item := range page.Items {
+					printItem(w, item, nil)
+				}
+				if page.Next != "" {
+					fmt.Fprintf(c.env.Stderr, "Следующая страница: --cursor=%s\n", page.Next)
+				}
+			})
+		}
+	},
+}
+
+var searchCommand = &command{
+	usage: "ЗАПРОС...",
+	help:  "Полнотекстовый поиск по названиям, описаниям и тегам",
+	setup: func(fs *flag.FlagSet) func(c *cmdContext, args []string) error {
+		return func(c *cmdContext, args []string) error {
+			query := strings.TrimSpace(strings.Join(args, " "))
+			if query == "" {
+				return errUsage
+			}
+			results, err := c.backend.Search(c.ctx, query)
+			if err != nil {
+				return err
+			}
+			if results == nil {
+				results = []*models.SearchResult{}
+			}
+			return c.output(map[string]interface{}{"results": results}, func(w io.Writer) {
+				for _, result := range results {
+					printItem(w, result.Item, nil)
+					if result.Snippet != "" {
+						fmt.Fprintf(w, "\t%s\n", result.Snippet)
+					}
+				}
+			})
+		}
+	},
+}
+
+var tagCommand = &command{
+	usage: "ID [ТЕГ...]",
+	help:  "Показать теги элемента или заменить их (теги через пробел или запятую)",
+	setup: func(fs *flag.FlagSet) func(c *cmdContext, args []string) error {
+		add := fs.Bool("add", false, "Добавить теги к текущим, а не заменить")
+
+		return func(c *cmdContext, args []string) error {
+			if len(args) == 0 {
+				return errUsage
+			}
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+
+			var item *Item
+			if len(args) == 1 && !*add {
+				item, err = c.backend.GetItem(c.ctx, id)
+			} else {
+				tags := args[1:]
+				if *add {
+					current, err := c.backend.GetItem(c.ctx, id)
+					if err != nil {
+						return err
+					}
+					tags = append(current.Tags, tags...)
+				}
+				item, err = c.backend.SetTags(c.ctx, id, strings.Join(tags, ","))
+			}
+			if err != nil {
+				return err
+			}
+			return c.output(item, func(w io.Writer) {
+				for _, tag := range item.Tags {
+					fmt.Fprintln(w, tag)
+				}
+			})
+		}
+	},
+}
+
+var mvCommand = &command{
+	usage: "ID... ПАПКА",
+	help:  "Переместить элементы в папку (ПАПКА \"/\" - корень библиотеки)",
+	setup: func(fs *flag.FlagSet) func(c *cmdContext, args []string) error {
+		return func(c *cmdContext, args []string) error {
+			if len(args) < 2 {
+				return errUsage
+			}
+			parentID, err := parseParent(args[len(args)-1])
+			if err != nil {
+				return err
+			}
+
+			moved := []*Item{}
+			for _, arg := range args[:len(args)-1] {
+				id, err := parseID(arg)
+				if err != nil {
+					return err
+				}
+				item, err := c.backend.MoveItem(c.ctx, id, parentID)
+				if err != nil {
+					return fmt.Errorf("элемент %d: %w", id, err)
+				}
+				moved = append(moved, item)
+			}
+			return c.output(map[string]interface{}{"items": moved}, func(w io.Writer) {
+				for _, item := range moved {
+					printItem(w, item.Item, nil)
+				}
+			})
+		}
+	},
+}
+
+var rmCommand = &command{
+	usage: "ID...",
+	help:  "Переместить элементы в корзину",
+	setup: func(fs *flag.FlagSet) func(c *cmdContext, args []string) error {
+		return func(c *cmdContext, args []string) error {
+			if len(args) == 0 {
+				return errUsage
+			}
+			entries := []*models.TrashEntry{}
+			for _, arg := range args {
+				id, err := parseID(arg)
+				if err != nil {
+					return err
+				}
+				entry, err := c.backend.RemoveItem(c.ctx, id)
+				if err != nil {
+					return fmt.Errorf("элемент %d: %w", id, err)
+				}
+				entries = append(entries, entry)
+			}
+			return c.output(map[string]interface{}{"trash": entries}, func(w io.Writer) {
+				for _, entry := range entries {
+					fmt.Fprintf(w, "В корзине: %d (элементов: %d)\n", entry.ItemID, entry.ItemCount)
+				}
+			})
+		}
+	},
+}
+
+var exportCommand = &command{
+	usage: "ФАЙЛ",
+	help:  "Экспортировать библиотеку, папку или элементы с тегом в архив",
+	setup: func(fs *flag.FlagSet) func(c *cmdContext, args []string) error {
+		folder := fs.Int("folder", 0, "ID папки")
+		tag := fs.String("tag", "", "Название тега")
+
+		return func(c *cmdContext, args []string) error {
+			if len(args) != 1 || (*folder != 0 && *tag != "") {
+				return errUsage
+			}
+			scope := archive.ScopeLibrary
+			switch {
+			case *folder != 0:
+				scope = archive.ScopeFolder
+			case *tag != "":
+				scope = archive.ScopeTag
+			}
+
+			report, err := c.backend.Export(c.ctx, args[0], scope, *folder, *tag)
+			if err != nil {
+				return err
+			}
+			return c.output(report, func(w io.Writer) { fmt.Fprintln(w, report.String()) })
+		}
+	},
+}
+
+var importCommand = &command{
+	usage: "ФАЙЛ",
+	help:  "Импортировать архив, созданный export или в настройках",
+	setup: func(fs *flag.FlagSet) func(c *cmdContext, args []string) error {
+		parent := fs.String("parent", "/", "ID папки, в которую импортировать")
+
+		return func(c *cmdContext, args []string) error {
+			if len(args) != 1 {
+				return errUsage
+			}
+			parentID, err := parseParent(*parent)
+			if err != nil {
+				return err
+			}
+
+			report, err := c.backend.Import(c.ctx, args[0], parentID)
+			if err != nil {
+				return err
+			}
+			return c.output(report, func(w io.Writer) {
+				fmt.Fprintln(w, report.String())
+				for _, warning := range report.Warnings {
+					fmt.Fprintf(c.env.Stderr, "Предупреждение: %s\n", warning)
+				}
+			})
+		}
+	},
+}
+
+var contactsCommand = &command{
+	usage: "[add АДРЕС]",
+	help:  "Показать контакты или добавить контакт по P2P адресу",
+	setup: func(fs *flag.FlagSet) func(c *cmdContext, args []string) error {
+		notes := fs.String("notes", "", "Заметка к добавляемому контакту")
+
+		return func(c *cmdContext, args []string) error {
+			switch {
+			case len(args) == 0:
+				list, err := c.backend.Contacts(c.ctx)
+				if err != nil {
+					return err
+				}
+				return c.output(map[string]interface{}{"contacts": list}, func(w io.Writer) {
+					for _, contact := range list {
+						name := contact.Username
+						if name == "" {
+							name = contact.PeerID
+						}
+						status := "не в сети"
+						if contact.IsOnline {
+							status = "в сети"
+						}
+						fmt.Fprintf(w, "%d\t%s\t%s\n", contact.ID, name, status)
+					}
+				})
+			case len(args) == 2 && args[0] == "add":
+				contact, err := c.backend.AddContact(c.ctx, args[1], *notes)
+				if err != nil {
+					return err
+				}
+				return c.output(contact, func(w io.Writer) {
+					fmt.Fprintf(w, "Контакт добавлен: %d\t%s\n", contact.ID, contact.PeerID)
+				})
+			default:
+				return errUsage
+			}
+		}
+	},
+}
+
+var chatCommand = &command{
+	usage: "ID [СООБЩЕНИЕ...]",
+	help:  "Показать историю чата с контактом или отправить сообщение",
+	setup: func(fs *flag.FlagSet) func(c *cmdContext, args []string) error {
+		limit := fs.Int("limit", 20, "Сколько последних сообщений показать")
+
+		return func(c *cmdContext, args []string) error {
+			if len(args) == 0 {
+				return errUsage
+			}
+			contactID, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+
+			if len(args) > 1 {
+				if err := c.backend.SendMessage(c.ctx, contactID, strings.Join(args[1:], " ")); err != nil {
+					return err
+				}
+				return c.output(map[string]string{"status": "sent"}, func(w io.Writer) {})
+			}
+
+			messages, err := c.backend.Messages(c.ctx, contactID, *limit)
+			if err != nil {
+				return err
+			}
+			if messages == nil {
+				messages = []*models.ChatMessage{}
+			}
+			return c.output(map[string]interface{}{"messages": messages}, func(w io.Writer) {
+				// История приходит от новых сообщений к старым, печатаем как в окне чата
+				for i := len(messages) - 1; i >= 0; i-- {
+					message := messages[i]
+					fmt.Fprintf(w, "[%s] %s\n", message.SentAt.Local().Format("02.01.2006 15:04"), message.Content)
+				}
+			})
+		}
+	},
+}
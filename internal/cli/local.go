@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"projectT/internal/services"
+	"projectT/internal/services/archive"
+	"projectT/internal/services/contacts"
+	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/queries"
+	"projectT/internal/storage/database/repository"
+)
+
+// Local выполняет команды сервисами открытой библиотеки
+type Local struct {
+	items    *services.ItemsService
+	tags     *services.TagsService
+	content  *services.ContentBlocksService
+	trash    *services.TrashService
+	contacts *contacts.ContactService
+	messages repository.Messages
+	archive  *archive.Service
+}
+
+// NewLocal создает клиент над открытой библиотекой. P2P не запускается: сообщения отправляет только запущенный узел.
+func NewLocal() *Local {
+	repos := repository.Default()
+	return &Local{
+		items:    services.NewItemsService(),
+		tags:     services.NewTagsService(),
+		content:  services.NewContentBlocksService(),
+		trash:    services.NewTrashService(),
+		contacts: contacts.NewContactService(nil, repos.Contacts, repos.Profiles),
+		messages: repos.Messages,
+		archive:  archive.NewService(),
+	}
+}
+
+// item возвращает элемент с названиями тегов
+func (l *Local) item(ctx context.Context, id int) (*Item, error) {
+	item, err := l.items.GetItemByID(id)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := l.tags.GetTagsForItem(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	result := &Item{Item: item, Tags: make([]string, 0, len(tags))}
+	for _, tag := range tags {
+		result.Tags = append(result.Tags, tag.Name)
+	}
+	return result, nil
+}
+
+// AddItem создает элемент так же, как окно создания
+func (l *Local) AddItem(ctx context.Context, input services.ItemInput) (*Item, error) {
+	item, err := l.content.CreateItem(ctx, input)
+	if item == nil {
+		return nil, err
+	}
+	if err != nil {
+		log.Printf("Предупреждение: %v", err)
+	}
+	return l.item(ctx, item.ID)
+}
+
+// GetItem возвращает элемент с тегами
+func (l *Local) GetItem(ctx context.Context, id int) (*Item, error) {
+	return l.item(ctx, id)
+}
+
+// ListItems возвращает страницу элементов; незаданные параметры берутся из настроек сортировки
+func (l *Local) ListItems(ctx context.Context, query ListQuery) (*Page, error) {
+	options := services.NewSortSettingsService().GetFilterOptions()
+	if query.All {
+		options.TabMode = "all_items"
+	}
+	for _, param := range []struct {
+		value string
+		field *string
+	}{
+		{query.Type, &options.ItemType},
+		{query.Priority, &options.Priority},
+		{query.Sort, &options.SortBy},
+		{query.Order, &options.SortOrder},
+	} {
+		if param.value != "" {
+			*param.field = param.value
+		}
+	}
+
+	var after *queries.ItemCursor
+	if query.Cursor != "" {
+		var err error
+		if after, err = queries.ParseItemCursor(query.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	page, err := l.items.ListItems(query.ParentID, options, after, query.Limit)
+	if err != nil {
+		return nil, err
+	}
+	result := &Page{Items: page.Items}
+	if result.Items == nil {
+		result.Items = []*models.Item{}
+	}
+	if page.Next != nil {
+		result.Next = page.Next.String()
+	}
+	return result, nil
+}
+
+// Search выполняет полнотекстовый поиск
+func (l *Local) Search(ctx context.Context, query string) ([]*models.SearchResult, error) {
+	return l.items.SearchItemsWithSnippets(query)
+}
+
+// SetTags заменяет теги элемента
+func (l *Local) SetTags(ctx context.Context, id int, tags string) (*Item, error) {
+	if _, err := l.items.GetItemByID(id); err != nil {
+		return nil, err
+	}
+	if err := l.content.ProcessTags(ctx, id, tags); err != nil {
+		return nil, err
+	}
+	return l.item(ctx, id)
+}
+
+// MoveItem перемещает элемент в папку parentID (nil - корень)
+func (l *Local) MoveItem(ctx context.Context, id int, parentID *int) (*Item, error) {
+	if err := l.items.MoveItem(id, parentID); err != nil {
+		return nil, err
+	}
+	return l.item(ctx, id)
+}
+
+// RemoveItem перемещает элемент в корзину
+func (l *Local) RemoveItem(ctx context.Context, id int) (*models.TrashEntry, error) {
+	if _, err := l.items.GetItemByID(id); err != nil {
+		return nil, err
+	}
+	return l.trash.MoveToTrash(id)
+}
+
+// Contacts возвращает контакты
+func (l *Local) Contacts(ctx context.Context) ([]*contacts.ContactWithStatus, error) {
+	return l.contacts.GetAllContacts()
+}
+
+// AddContact добавляет контакт по P2P адресу
+func (l *Local) AddContact(ctx context.Context, address, notes string) (*models.Contact, error) {
+	return l.contacts.AddContactByAddress(address, notes)
+}
+
+// Messages возвращает последние сообщения чата с контактом
+func (l *Local) Messages(ctx context.Context, contactID, limit int) ([]*models.ChatMessage, error) {
+	if _, err := l.contacts.GetContact(contactID); err != nil {
+		return nil, err
+	}
+	return l.messages.GetMessagesForContact(contactID, limit, 0)
+}
+
+// SendMessage недоступен без запущенного узла: сообщение некому доставить
+func (l *Local) SendMessage(ctx context.Context, contactID int, content string) error {
+	return errors.New("сообщения отправляет запущенный узел: укажите его адрес в --api")
+}
+
+// Export экспортирует часть библиотеки в архив
+func (l *Local) Export(ctx context.Context, filePath string, scope archive.ScopeKind, id int, tagName string) (*archive.ExportReport, error) {
+	if scope == archive.ScopeTag {
+		tag, err := l.tags.GetTagByName(ctx, tagName)
+		if err != nil {
+			return nil, err
+		}
+		id = tag.ID
+	}
+	return l.archive.ExportToFile(ctx, filePath, scope, id)
+}
+
+// Import импортирует архив в папку parentID
+func (l *Local) Import(ctx context.Context, filePath string, parentID *int) (*archive.ImportReport, error) {
+	return l.archive.ImportFile(ctx, filePath, archive.ImportOptions{ParentID: parentID})
+}
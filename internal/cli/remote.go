@@ -0,0 +1,248 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"projectT/internal/services"
+	"projectT/internal/services/archive"
+	"projectT/internal/services/contacts"
+	"projectT/internal/storage/database/models"
+)
+
+// Remote выполняет команды через JSON API запущенного узла
+type Remote struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewRemote создает клиент API узла на addr (host:port или unix:/путь) с токеном доступа token
+func NewRemote(addr, token string) *Remote {
+	transport := &http.Transport{}
+	baseURL := "http://" + addr
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", path)
+		}
+		baseURL = "http://unix"
+	}
+	return &Remote{
+		baseURL: baseURL,
+		token:   token,
+		client:  &http.Client{Transport: transport, Timeout: 5 * time.Minute},
+	}
+}
+
+// do выполняет запрос к API и разбирает ответ в out (если не nil)
+func (r *Remote) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, r.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+r.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("узел недоступен: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) != nil || apiErr.Error == "" {
+			return fmt.Errorf("ошибка API: %s", resp.Status)
+		}
+		return errors.New(apiErr.Error)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("неверный ответ API: %w", err)
+	}
+	return nil
+}
+
+// itemPath возвращает путь элемента в API
+func itemPath(id int) string {
+	return "/api/items/" + strconv.Itoa(id)
+}
+
+// AddItem создает элемент на узле. Файлы читаются здесь и передаются узлу содержимым:
+// пути API не принимает.
+func (r *Remote) AddItem(ctx context.Context, input services.ItemInput) (*Item, error) {
+	for _, path := range input.Files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения файла %s: %w", path, err)
+		}
+		input.Uploads = append(input.Uploads, services.FileUpload{Name: filepath.Base(path), Data: data})
+	}
+	input.Files = nil
+
+	var item Item
+	if err := r.do(ctx, http.MethodPost, "/api/items", input, &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// GetItem возвращает элемент с тегами
+func (r *Remote) GetItem(ctx context.Context, id int) (*Item, error) {
+	var item Item
+	if err := r.do(ctx, http.MethodGet, itemPath(id), nil, &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// ListItems возвращает страницу элементов
+func (r *Remote) ListItems(ctx context.Context, query ListQuery) (*Page, error) {
+	params := url.Values{}
+	params.Set("parent", strconv.Itoa(query.ParentID))
+	if query.All {
+		params.Set("all", "1")
+	}
+	if query.Limit > 0 {
+		params.Set("limit", strconv.Itoa(query.Limit))
+	}
+	for name, value := range map[string]string{
+		"type":     query.Type,
+		"priority": query.Priority,
+		"sort":     query.Sort,
+		"order":    query.Order,
+		"cursor":   query.Cursor,
+	} {
+		if value != "" {
+			params.Set(name, value)
+		}
+	}
+
+	var page Page
+	if err := r.do(ctx, http.MethodGet, "/api/items?"+params.Encode(), nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Search выполняет полнотекстовый поиск на узле
+func (r *Remote) Search(ctx context.Context, query string) ([]*models.SearchResult, error) {
+	var response struct {
+		Results []*models.SearchResult `json:"results"`
+	}
+	if err := r.do(ctx, http.MethodGet, "/api/search?q="+url.QueryEscape(query), nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Results, nil
+}
+
+// SetTags заменяет теги элемента
+func (r *Remote) SetTags(ctx context.Context, id int, tags string) (*Item, error) {
+	var item Item
+	if err := r.do(ctx, http.MethodPatch, itemPath(id), map[string]string{"tags": tags}, &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// MoveItem перемещает элемент в папку parentID (nil - корень)
+func (r *Remote) MoveItem(ctx context.Context, id int, parentID *int) (*Item, error) {
+	var item Item
+	if err := r.do(ctx, http.MethodPatch, itemPath(id), map[string]*int{"parent_id": parentID}, &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// RemoveItem перемещает элемент в корзину
+func (r *Remote) RemoveItem(ctx context.Context, id int) (*models.TrashEntry, error) {
+	var entry models.TrashEntry
+	if err := r.do(ctx, http.MethodDelete, itemPath(id), nil, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Contacts возвращает контакты со статусом подключения
+func (r *Remote) Contacts(ctx context.Context) ([]*contacts.ContactWithStatus, error) {
+	// Ошибка подключения приходит без текста, поэтому разбирается только статус
+	var response struct {
+		Contacts []struct {
+			*models.Contact
+			IsOnline bool `json:"is_online"`
+		} `json:"contacts"`
+	}
+	if err := r.do(ctx, http.MethodGet, "/api/contacts", nil, &response); err != nil {
+		return nil, err
+	}
+	list := make([]*contacts.ContactWithStatus, 0, len(response.Contacts))
+	for _, contact := range response.Contacts {
+		list = append(list, &contacts.ContactWithStatus{Contact: contact.Contact, IsOnline: contact.IsOnline})
+	}
+	return list, nil
+}
+
+// AddContact добавляет контакт по P2P адресу
+func (r *Remote) AddContact(ctx context.Context, address, notes string) (*models.Contact, error) {
+	var contact models.Contact
+	body := map[string]string{"address": address, "notes": notes}
+	if err := r.do(ctx, http.MethodPost, "/api/contacts", body, &contact); err != nil {
+		return nil, err
+	}
+	return &contact, nil
+}
+
+// Messages возвращает последние сообщения чата с контактом
+func (r *Remote) Messages(ctx context.Context, contactID, limit int) ([]*models.ChatMessage, error) {
+	var response struct {
+		Messages []*models.ChatMessage `json:"messages"`
+	}
+	path := fmt.Sprintf("/api/contacts/%d/messages?limit=%d", contactID, limit)
+	if err := r.do(ctx, http.MethodGet, path, nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Messages, nil
+}
+
+// SendMessage отправляет сообщение через P2P узла
+func (r *Remote) SendMessage(ctx context.Context, contactID int, content string) error {
+	path := fmt.Sprintf("/api/contacts/%d/messages", contactID)
+	return r.do(ctx, http.MethodPost, path, map[string]string{"content": content}, nil)
+}
+
+// Export не поддерживается API
+func (r *Remote) Export(ctx context.Context, filePath string, scope archive.ScopeKind, id int, tagName string) (*archive.ExportReport, error) {
+	return nil, ErrRemoteUnsupported
+}
+
+// Import не поддерживается API
+func (r *Remote) Import(ctx context.Context, filePath string, parentID *int) (*archive.ImportReport, error) {
+	return nil, ErrRemoteUnsupported
+}
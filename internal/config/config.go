@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 
 	"gopkg.in/yaml.v3"
 )
@@ -18,6 +19,16 @@ type Config struct {
 	Storage StorageConfig `yaml:"storage" json:"storage"`
 	// P2P настройки P2P сети
 	P2P P2PConfig `yaml:"p2p" json:"p2p"`
+	// Backup настройки резервного копирования
+	Backup BackupConfig `yaml:"backup" json:"backup"`
+	// Libraries настройки нескольких библиотек
+	Libraries LibrariesConfig `yaml:"libraries" json:"libraries"`
+	// Daemon настройки запуска без интерфейса
+	Daemon DaemonConfig `yaml:"daemon" json:"daemon"`
+	// Watch отслеживаемые директории, файлы из которых импортируются автоматически
+	Watch WatchConfig `yaml:"watch" json:"watch"`
+	// LinkPreview загрузка превью ссылок
+	LinkPreview LinkPreviewConfig `yaml:"link_preview" json:"link_preview"`
 }
 
 // DatabaseConfig настройки базы данных
@@ -58,6 +69,14 @@ type StorageConfig struct {
 	Path string `yaml:"path" json:"path"`
 	// FilesDir поддиректория для файлов контента
 	FilesDir string `yaml:"files_dir" json:"files_dir"`
+	// TrashRetentionDays через сколько дней элементы корзины удаляются окончательно (0 - хранить бессрочно)
+	TrashRetentionDays int `yaml:"trash_retention_days" json:"trash_retention_days"`
+	// GCGraceHours сколько часов неиспользуемый файл хранится, прежде чем сборщик мусора удалит его с диска
+	GCGraceHours int `yaml:"gc_grace_hours" json:"gc_grace_hours"`
+	// MaxRevisionsPerItem сколько ревизий хранится для одного элемента (0 - без ограничения)
+	MaxRevisionsPerItem int `yaml:"max_revisions_per_item" json:"max_revisions_per_item"`
+	// VaultPassword пароль зашифрованного хранилища для запуска без запроса; только из PROJECTT_VAULT_PASSWORD
+	VaultPassword string `yaml:"-" json:"-"`
 }
 
 // GetPath возвращает путь к хранилищу
@@ -70,6 +89,21 @@ func (c StorageConfig) GetFilesDir() string {
 	return c.FilesDir
 }
 
+// GetTrashRetentionDays возвращает срок хранения элементов в корзине (дней)
+func (c StorageConfig) GetTrashRetentionDays() int {
+	return c.TrashRetentionDays
+}
+
+// GetGCGraceHours возвращает период ожидания сборщика мусора (часов)
+func (c StorageConfig) GetGCGraceHours() int {
+	return c.GCGraceHours
+}
+
+// GetMaxRevisionsPerItem возвращает ограничение числа ревизий элемента
+func (c StorageConfig) GetMaxRevisionsPerItem() int {
+	return c.MaxRevisionsPerItem
+}
+
 // P2PConfig настройки P2P сети
 type P2PConfig struct {
 	// Enabled включён ли P2P режим
@@ -82,6 +116,80 @@ type P2PConfig struct {
 	EnableRelayDiscovery bool `yaml:"enable_relay_discovery" json:"enable_relay_discovery"`
 }
 
+// BackupConfig настройки резервного копирования
+type BackupConfig struct {
+	// Path директория для резервных копий
+	Path string `yaml:"path" json:"path"`
+	// Password пароль шифрования копий; лучше задавать через PROJECTT_BACKUP_PASSWORD
+	Password string `yaml:"password,omitempty" json:"-"`
+	// IntervalHours как часто создавать копию (часов, 0 - только вручную)
+	IntervalHours int `yaml:"interval_hours" json:"interval_hours"`
+	// KeepLast сколько последних копий хранить всегда
+	KeepLast int `yaml:"keep_last" json:"keep_last"`
+	// KeepDays за сколько последних дней хранить по одной копии в день
+	KeepDays int `yaml:"keep_days" json:"keep_days"`
+}
+
+// GetPath возвращает директорию резервных копий
+func (c BackupConfig) GetPath() string {
+	return c.Path
+}
+
+// GetIntervalHours возвращает интервал резервного копирования (часов)
+func (c BackupConfig) GetIntervalHours() int {
+	return c.IntervalHours
+}
+
+// LibrariesConfig настройки нескольких библиотек.
+// Основная библиотека - Database.Path и Storage.Path; остальные создаются в Path.
+type LibrariesConfig struct {
+	// Path директория реестра библиотек и созданных в приложении библиотек
+	Path string `yaml:"path" json:"path"`
+	// Active ID или название библиотеки, которая открывается при запуске (по умолчанию - последняя открытая)
+	Active string `yaml:"active" json:"active"`
+}
+
+// DaemonConfig настройки запуска без интерфейса (--daemon) и его локального JSON API
+type DaemonConfig struct {
+	// Listen адрес API: host:port на loopback или unix:/путь/к/сокету
+	Listen string `yaml:"listen" json:"listen"`
+	// Token токен доступа к API; лучше задавать через PROJECTT_DAEMON_TOKEN.
+	// Если не задан, токен создаётся при первом запуске и хранится в TokenFile.
+	Token string `yaml:"token,omitempty" json:"-"`
+	// TokenFile файл с токеном доступа, из которого его читают локальные клиенты
+	TokenFile string `yaml:"token_file" json:"token_file"`
+}
+
+// WatchConfig настройки автоматического импорта файлов из отслеживаемых директорий
+type WatchConfig struct {
+	// DebounceMs сколько миллисекунд файл не должен меняться, прежде чем его импортировать
+	DebounceMs int `yaml:"debounce_ms" json:"debounce_ms"`
+	// Folders отслеживаемые директории с правилами импорта
+	Folders []WatchFolder `yaml:"folders" json:"folders"`
+}
+
+// WatchFolder отслеживаемая директория и правила импорта её файлов
+type WatchFolder struct {
+	// Path отслеживаемая директория (без вложенных директорий)
+	Path string `yaml:"path" json:"path"`
+	// Parent папка библиотеки для новых элементов: названия через "/", недостающие создаются; пусто - корень
+	Parent string `yaml:"parent" json:"parent"`
+	// Tags теги, которые получают новые элементы
+	Tags []string `yaml:"tags" json:"tags"`
+	// DeleteSource удалять ли файл из директории после импорта
+	DeleteSource bool `yaml:"delete_source" json:"delete_source"`
+	// Ignore шаблоны имён файлов, которые не импортируются (например "*.tmp")
+	Ignore []string `yaml:"ignore" json:"ignore"`
+}
+
+// LinkPreviewConfig настройки загрузки превью ссылок (заголовок, описание, значок сайта, изображение)
+type LinkPreviewConfig struct {
+	// Enabled загружать ли превью: страницы ссылок запрашиваются с этого компьютера
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// RefreshDays через сколько дней превью загружается заново (0 - не обновлять)
+	RefreshDays int `yaml:"refresh_days" json:"refresh_days"`
+}
+
 // DefaultConfig возвращает конфигурацию со значениями по умолчанию
 func DefaultConfig() *Config {
 	// Пути по умолчанию относительно текущей рабочей директории
@@ -101,8 +209,11 @@ func DefaultConfig() *Config {
 			MaxIdleConns: 1,
 		},
 		Storage: StorageConfig{
-			Path:     filepath.Join(cwd, "storage"),
-			FilesDir: "files",
+			Path:                filepath.Join(cwd, "storage"),
+			FilesDir:            "files",
+			TrashRetentionDays:  30,
+			GCGraceHours:        24,
+			MaxRevisionsPerItem: 50,
 		},
 		P2P: P2PConfig{
 			Enabled:              true,
@@ -110,6 +221,26 @@ func DefaultConfig() *Config {
 			EnableRelay:          true,
 			EnableRelayDiscovery: true,
 		},
+		Backup: BackupConfig{
+			Path:          filepath.Join(cwd, "storage", "backups"),
+			IntervalHours: 24,
+			KeepLast:      7,
+			KeepDays:      30,
+		},
+		Libraries: LibrariesConfig{
+			Path: filepath.Join(cwd, "libraries"),
+		},
+		Daemon: DaemonConfig{
+			Listen:    "127.0.0.1:7878",
+			TokenFile: filepath.Join(cwd, "storage", "daemon.token"),
+		},
+		Watch: WatchConfig{
+			DebounceMs: 2000,
+		},
+		LinkPreview: LinkPreviewConfig{
+			Enabled:     true,
+			RefreshDays: 30,
+		},
 	}
 }
 
@@ -160,6 +291,18 @@ type parsedFlags struct {
 	dbBusyTimeout   int
 	storagePath     string
 	storageFilesDir string
+	trashRetention  int
+	gcGraceHours    int
+	maxRevisions    int
+	backupPath      string
+	backupInterval  int
+	backupKeepLast  int
+	backupKeepDays  int
+	librariesPath   string
+	library         string
+	daemonListen    string
+	watchDebounceMs int
+	previewRefresh  int
 	p2pEnabled      bool
 	p2pPort         int
 	p2pRelay        bool
@@ -176,11 +319,31 @@ func (l *Loader) parseAllFlags() *parsedFlags {
 	flagSet.IntVar(&flags.dbBusyTimeout, "db-timeout", 0, "Таймаут ожидания БД (мс)")
 	flagSet.StringVar(&flags.storagePath, "storage-path", "", "Путь к корневой директории хранилища")
 	flagSet.StringVar(&flags.storageFilesDir, "storage-files-dir", "", "Поддиректория для файлов")
+	flagSet.IntVar(&flags.trashRetention, "trash-retention-days", -1, "Срок хранения элементов в корзине (дней, 0 - бессрочно)")
+	flagSet.IntVar(&flags.gcGraceHours, "gc-grace-hours", -1, "Через сколько часов неиспользуемые файлы удаляются с диска")
+	flagSet.IntVar(&flags.maxRevisions, "max-revisions", -1, "Сколько ревизий хранить для одного элемента (0 - без ограничения)")
+	flagSet.StringVar(&flags.backupPath, "backup-path", "", "Директория для резервных копий")
+	flagSet.IntVar(&flags.backupInterval, "backup-interval-hours", -1, "Как часто создавать резервную копию (часов, 0 - только вручную)")
+	flagSet.IntVar(&flags.backupKeepLast, "backup-keep-last", -1, "Сколько последних резервных копий хранить всегда")
+	flagSet.IntVar(&flags.backupKeepDays, "backup-keep-days", -1, "За сколько дней хранить по одной резервной копии в день")
+	flagSet.StringVar(&flags.librariesPath, "libraries-path", "", "Директория библиотек, созданных в приложении")
+	flagSet.StringVar(&flags.library, "library", "", "Библиотека (ID или название), которую открыть при запуске")
+	flagSet.StringVar(&flags.daemonListen, "daemon-listen", "", "Адрес API в режиме без интерфейса: host:port или unix:/путь")
+	flagSet.IntVar(&flags.watchDebounceMs, "watch-debounce-ms", -1, "Сколько миллисекунд файл в отслеживаемой директории не должен меняться перед импортом")
+	flagSet.IntVar(&flags.previewRefresh, "link-preview-refresh-days", -1, "Через сколько дней превью ссылок загружается заново (0 - не обновлять)")
 	flagSet.BoolVar(&flags.p2pEnabled, "p2p-enabled", false, "Включить P2P режим")
 	flagSet.IntVar(&flags.p2pPort, "p2p-port", 0, "Порт для P2P соединений")
 	flagSet.BoolVar(&flags.p2pRelay, "p2p-relay", false, "Использовать relay для обхода NAT")
 	flagSet.BoolVar(&flags.p2pRelayDisc, "p2p-relay-discovery", false, "Автообнаружение relay")
 
+	// Флаги режимов запуска обрабатываются в cmd; объявлены здесь, чтобы разбор не останавливался на них
+	flagSet.Bool("fsck", false, "Проверить целостность хранилища без запуска интерфейса")
+	flagSet.Bool("fsck-repair", false, "Проверить целостность хранилища и исправить найденные проблемы")
+	flagSet.Bool("backup", false, "Создать резервную копию без запуска интерфейса")
+	flagSet.Bool("backup-list", false, "Показать список резервных копий")
+	flagSet.String("restore", "", "Восстановить резервную копию: ID, время (2006-01-02T15:04) или latest")
+	flagSet.Bool("daemon", false, "Запустить без интерфейса с локальным JSON API")
+
 	// Игнорируем ошибку парсинга - флаги могут быть не переданы
 	_ = flagSet.Parse(os.Args[1:])
 
@@ -201,6 +364,42 @@ func (l *Loader) applyFlags(flags *parsedFlags) {
 	if flags.storageFilesDir != "" {
 		l.config.Storage.FilesDir = flags.storageFilesDir
 	}
+	if flags.trashRetention >= 0 {
+		l.config.Storage.TrashRetentionDays = flags.trashRetention
+	}
+	if flags.gcGraceHours >= 0 {
+		l.config.Storage.GCGraceHours = flags.gcGraceHours
+	}
+	if flags.maxRevisions >= 0 {
+		l.config.Storage.MaxRevisionsPerItem = flags.maxRevisions
+	}
+	if flags.backupPath != "" {
+		l.config.Backup.Path = filepath.ToSlash(flags.backupPath)
+	}
+	if flags.backupInterval >= 0 {
+		l.config.Backup.IntervalHours = flags.backupInterval
+	}
+	if flags.backupKeepLast >= 0 {
+		l.config.Backup.KeepLast = flags.backupKeepLast
+	}
+	if flags.backupKeepDays >= 0 {
+		l.config.Backup.KeepDays = flags.backupKeepDays
+	}
+	if flags.librariesPath != "" {
+		l.config.Libraries.Path = filepath.ToSlash(flags.librariesPath)
+	}
+	if flags.library != "" {
+		l.config.Libraries.Active = flags.library
+	}
+	if flags.daemonListen != "" {
+		l.config.Daemon.Listen = flags.daemonListen
+	}
+	if flags.watchDebounceMs >= 0 {
+		l.config.Watch.DebounceMs = flags.watchDebounceMs
+	}
+	if flags.previewRefresh >= 0 {
+		l.config.LinkPreview.RefreshDays = flags.previewRefresh
+	}
 	if flags.p2pEnabled {
 		l.config.P2P.Enabled = flags.p2pEnabled
 	}
@@ -241,6 +440,12 @@ func (l *Loader) loadFromYAML(path string) error {
 	// Нормализуем пути из YAML в Unix-стиль
 	l.config.Database.Path = filepath.ToSlash(l.config.Database.Path)
 	l.config.Storage.Path = filepath.ToSlash(l.config.Storage.Path)
+	l.config.Backup.Path = filepath.ToSlash(l.config.Backup.Path)
+	l.config.Libraries.Path = filepath.ToSlash(l.config.Libraries.Path)
+	l.config.Daemon.TokenFile = filepath.ToSlash(l.config.Daemon.TokenFile)
+	for i := range l.config.Watch.Folders {
+		l.config.Watch.Folders[i].Path = filepath.ToSlash(l.config.Watch.Folders[i].Path)
+	}
 
 	return nil
 }
@@ -264,6 +469,84 @@ func (l *Loader) loadFromEnv() {
 	if val := os.Getenv("PROJECTT_STORAGE_FILES_DIR"); val != "" {
 		l.config.Storage.FilesDir = val
 	}
+	if val := os.Getenv("PROJECTT_TRASH_RETENTION_DAYS"); val != "" {
+		// 0 допустим (хранить бессрочно), поэтому parseInt с его 0 при ошибке не подходит
+		if days, err := strconv.Atoi(val); err == nil && days >= 0 {
+			l.config.Storage.TrashRetentionDays = days
+		}
+	}
+	if val := os.Getenv("PROJECTT_GC_GRACE_HOURS"); val != "" {
+		if hours, err := strconv.Atoi(val); err == nil && hours >= 0 {
+			l.config.Storage.GCGraceHours = hours
+		}
+	}
+	if val := os.Getenv("PROJECTT_MAX_REVISIONS"); val != "" {
+		if count, err := strconv.Atoi(val); err == nil && count >= 0 {
+			l.config.Storage.MaxRevisionsPerItem = count
+		}
+	}
+
+	// Backup
+	if val := os.Getenv("PROJECTT_BACKUP_PATH"); val != "" {
+		l.config.Backup.Path = filepath.ToSlash(val)
+	}
+	if val := os.Getenv("PROJECTT_BACKUP_PASSWORD"); val != "" {
+		l.config.Backup.Password = val
+	}
+	if val := os.Getenv("PROJECTT_VAULT_PASSWORD"); val != "" {
+		l.config.Storage.VaultPassword = val
+	}
+	if val := os.Getenv("PROJECTT_BACKUP_INTERVAL_HOURS"); val != "" {
+		if hours, err := strconv.Atoi(val); err == nil && hours >= 0 {
+			l.config.Backup.IntervalHours = hours
+		}
+	}
+	if val := os.Getenv("PROJECTT_BACKUP_KEEP_LAST"); val != "" {
+		if count, err := strconv.Atoi(val); err == nil && count >= 0 {
+			l.config.Backup.KeepLast = count
+		}
+	}
+	if val := os.Getenv("PROJECTT_BACKUP_KEEP_DAYS"); val != "" {
+		if days, err := strconv.Atoi(val); err == nil && days >= 0 {
+			l.config.Backup.KeepDays = days
+		}
+	}
+
+	// Libraries
+	if val := os.Getenv("PROJECTT_LIBRARIES_PATH"); val != "" {
+		l.config.Libraries.Path = filepath.ToSlash(val)
+	}
+	if val := os.Getenv("PROJECTT_LIBRARY"); val != "" {
+		l.config.Libraries.Active = val
+	}
+
+	// Daemon
+	if val := os.Getenv("PROJECTT_DAEMON_LISTEN"); val != "" {
+		l.config.Daemon.Listen = val
+	}
+	if val := os.Getenv("PROJECTT_DAEMON_TOKEN"); val != "" {
+		l.config.Daemon.Token = val
+	}
+	if val := os.Getenv("PROJECTT_DAEMON_TOKEN_FILE"); val != "" {
+		l.config.Daemon.TokenFile = filepath.ToSlash(val)
+	}
+
+	// Watch
+	if val := os.Getenv("PROJECTT_WATCH_DEBOUNCE_MS"); val != "" {
+		if ms, err := strconv.Atoi(val); err == nil && ms >= 0 {
+			l.config.Watch.DebounceMs = ms
+		}
+	}
+
+	// LinkPreview
+	if val := os.Getenv("PROJECTT_LINK_PREVIEW_ENABLED"); val != "" {
+		l.config.LinkPreview.Enabled = parseBool(val)
+	}
+	if val := os.Getenv("PROJECTT_LINK_PREVIEW_REFRESH_DAYS"); val != "" {
+		if days, err := strconv.Atoi(val); err == nil && days >= 0 {
+			l.config.LinkPreview.RefreshDays = days
+		}
+	}
 
 	// P2P
 	if val := os.Getenv("PROJECTT_P2P_ENABLED"); val != "" {
@@ -300,6 +583,36 @@ func (l *Loader) normalizePaths() {
 			l.config.Storage.Path = filepath.ToSlash(abs)
 		}
 	}
+
+	// Нормализуем путь к резервным копиям
+	if !filepath.IsAbs(l.config.Backup.Path) {
+		if abs, err := filepath.Abs(l.config.Backup.Path); err == nil {
+			l.config.Backup.Path = filepath.ToSlash(abs)
+		}
+	}
+
+	// Нормализуем путь к библиотекам
+	if !filepath.IsAbs(l.config.Libraries.Path) {
+		if abs, err := filepath.Abs(l.config.Libraries.Path); err == nil {
+			l.config.Libraries.Path = filepath.ToSlash(abs)
+		}
+	}
+
+	// Нормализуем путь к файлу токена API
+	if !filepath.IsAbs(l.config.Daemon.TokenFile) {
+		if abs, err := filepath.Abs(l.config.Daemon.TokenFile); err == nil {
+			l.config.Daemon.TokenFile = filepath.ToSlash(abs)
+		}
+	}
+
+	// Нормализуем пути отслеживаемых директорий
+	for i, folder := range l.config.Watch.Folders {
+		if !filepath.IsAbs(folder.Path) {
+			if abs, err := filepath.Abs(folder.Path); err == nil {
+				l.config.Watch.Folders[i].Path = filepath.ToSlash(abs)
+			}
+		}
+	}
 }
 
 // parseInt парсит строку в int
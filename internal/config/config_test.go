@@ -18,6 +18,9 @@ func TestDefaultConfig(t *testing.T) {
 	assert.NotEmpty(t, cfg.Database.Path)
 	assert.NotEmpty(t, cfg.Storage.Path)
 	assert.Equal(t, "files", cfg.Storage.FilesDir)
+	assert.Equal(t, 30, cfg.Storage.TrashRetentionDays)
+	assert.Equal(t, 24, cfg.Storage.GCGraceHours)
+	assert.Equal(t, 50, cfg.Storage.MaxRevisionsPerItem)
 	assert.Equal(t, 30000, cfg.Database.BusyTimeout)
 	assert.Equal(t, 1, cfg.Database.MaxOpenConns)
 	assert.Equal(t, 1, cfg.Database.MaxIdleConns)
@@ -25,6 +28,21 @@ func TestDefaultConfig(t *testing.T) {
 	assert.True(t, cfg.P2P.Enabled)
 	assert.True(t, cfg.P2P.EnableRelay)
 	assert.True(t, cfg.P2P.EnableRelayDiscovery)
+	assert.NotEmpty(t, cfg.Backup.Path)
+	assert.Empty(t, cfg.Backup.Password)
+	assert.Empty(t, cfg.Storage.VaultPassword)
+	assert.Equal(t, 24, cfg.Backup.IntervalHours)
+	assert.Equal(t, 7, cfg.Backup.KeepLast)
+	assert.Equal(t, 30, cfg.Backup.KeepDays)
+	assert.NotEmpty(t, cfg.Libraries.Path)
+	assert.Empty(t, cfg.Libraries.Active)
+	assert.Equal(t, "127.0.0.1:7878", cfg.Daemon.Listen)
+	assert.Empty(t, cfg.Daemon.Token)
+	assert.NotEmpty(t, cfg.Daemon.TokenFile)
+	assert.Equal(t, 2000, cfg.Watch.DebounceMs)
+	assert.Empty(t, cfg.Watch.Folders)
+	assert.True(t, cfg.LinkPreview.Enabled)
+	assert.Equal(t, 30, cfg.LinkPreview.RefreshDays)
 }
 
 // TestDatabaseConfigMethods проверяет методы DatabaseConfig
@@ -215,6 +233,39 @@ p2p:
 	assert.NotEmpty(t, cfg.Storage.Path)
 }
 
+// TestLoadFromYAML_WatchFolders проверяет загрузку правил отслеживаемых директорий
+func TestLoadFromYAML_WatchFolders(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "watch.yaml")
+	yamlContent := `
+watch:
+  debounce_ms: 3000
+  folders:
+    - path: /home/user/Screenshots
+      parent: Входящие/Скриншоты
+      tags: [скриншоты]
+      delete_source: true
+      ignore: ["*.tmp"]
+    - path: /home/user/Downloads
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(yamlContent), 0644))
+
+	loader := NewLoader()
+	require.NoError(t, loader.loadFromYAML(configPath))
+
+	cfg := loader.Get()
+	assert.Equal(t, 3000, cfg.Watch.DebounceMs)
+	require.Len(t, cfg.Watch.Folders, 2)
+	assert.Equal(t, WatchFolder{
+		Path:         "/home/user/Screenshots",
+		Parent:       "Входящие/Скриншоты",
+		Tags:         []string{"скриншоты"},
+		DeleteSource: true,
+		Ignore:       []string{"*.tmp"},
+	}, cfg.Watch.Folders[0])
+	assert.Equal(t, "/home/user/Downloads", cfg.Watch.Folders[1].Path)
+	assert.False(t, cfg.Watch.Folders[1].DeleteSource)
+}
+
 // TestNormalizePaths проверяет нормализацию путей
 func TestNormalizePaths(t *testing.T) {
 	loader := NewLoader()
@@ -359,14 +410,31 @@ func TestSave_CreatesDirectory(t *testing.T) {
 func TestLoadFromEnv(t *testing.T) {
 	// Сохраняем текущие значения
 	originalEnv := map[string]string{
-		"PROJECTT_DB_PATH":             os.Getenv("PROJECTT_DB_PATH"),
-		"PROJECTT_DB_BUSY_TIMEOUT":     os.Getenv("PROJECTT_DB_BUSY_TIMEOUT"),
-		"PROJECTT_STORAGE_PATH":        os.Getenv("PROJECTT_STORAGE_PATH"),
-		"PROJECTT_STORAGE_FILES_DIR":   os.Getenv("PROJECTT_STORAGE_FILES_DIR"),
-		"PROJECTT_P2P_ENABLED":         os.Getenv("PROJECTT_P2P_ENABLED"),
-		"PROJECTT_P2P_PORT":            os.Getenv("PROJECTT_P2P_PORT"),
-		"PROJECTT_P2P_RELAY":           os.Getenv("PROJECTT_P2P_RELAY"),
-		"PROJECTT_P2P_RELAY_DISCOVERY": os.Getenv("PROJECTT_P2P_RELAY_DISCOVERY"),
+		"PROJECTT_DB_PATH":                   os.Getenv("PROJECTT_DB_PATH"),
+		"PROJECTT_DB_BUSY_TIMEOUT":           os.Getenv("PROJECTT_DB_BUSY_TIMEOUT"),
+		"PROJECTT_STORAGE_PATH":              os.Getenv("PROJECTT_STORAGE_PATH"),
+		"PROJECTT_STORAGE_FILES_DIR":         os.Getenv("PROJECTT_STORAGE_FILES_DIR"),
+		"PROJECTT_TRASH_RETENTION_DAYS":      os.Getenv("PROJECTT_TRASH_RETENTION_DAYS"),
+		"PROJECTT_GC_GRACE_HOURS":            os.Getenv("PROJECTT_GC_GRACE_HOURS"),
+		"PROJECTT_MAX_REVISIONS":             os.Getenv("PROJECTT_MAX_REVISIONS"),
+		"PROJECTT_BACKUP_PATH":               os.Getenv("PROJECTT_BACKUP_PATH"),
+		"PROJECTT_BACKUP_PASSWORD":           os.Getenv("PROJECTT_BACKUP_PASSWORD"),
+		"PROJECTT_VAULT_PASSWORD":            os.Getenv("PROJECTT_VAULT_PASSWORD"),
+		"PROJECTT_BACKUP_INTERVAL_HOURS":     os.Getenv("PROJECTT_BACKUP_INTERVAL_HOURS"),
+		"PROJECTT_BACKUP_KEEP_LAST":          os.Getenv("PROJECTT_BACKUP_KEEP_LAST"),
+		"PROJECTT_BACKUP_KEEP_DAYS":          os.Getenv("PROJECTT_BACKUP_KEEP_DAYS"),
+		"PROJECTT_LIBRARIES_PATH":            os.Getenv("PROJECTT_LIBRARIES_PATH"),
+		"PROJECTT_LIBRARY":                   os.Getenv("PROJECTT_LIBRARY"),
+		"PROJECTT_DAEMON_LISTEN":             os.Getenv("PROJECTT_DAEMON_LISTEN"),
+		"PROJECTT_DAEMON_TOKEN":              os.Getenv("PROJECTT_DAEMON_TOKEN"),
+		"PROJECTT_DAEMON_TOKEN_FILE":         os.Getenv("PROJECTT_DAEMON_TOKEN_FILE"),
+		"PROJECTT_WATCH_DEBOUNCE_MS":         os.Getenv("PROJECTT_WATCH_DEBOUNCE_MS"),
+		"PROJECTT_LINK_PREVIEW_ENABLED":      os.Getenv("PROJECTT_LINK_PREVIEW_ENABLED"),
+		"PROJECTT_LINK_PREVIEW_REFRESH_DAYS": os.Getenv("PROJECTT_LINK_PREVIEW_REFRESH_DAYS"),
+		"PROJECTT_P2P_ENABLED":               os.Getenv("PROJECTT_P2P_ENABLED"),
+		"PROJECTT_P2P_PORT":                  os.Getenv("PROJECTT_P2P_PORT"),
+		"PROJECTT_P2P_RELAY":                 os.Getenv("PROJECTT_P2P_RELAY"),
+		"PROJECTT_P2P_RELAY_DISCOVERY":       os.Getenv("PROJECTT_P2P_RELAY_DISCOVERY"),
 	}
 
 	// Восстанавливаем после теста
@@ -385,6 +453,23 @@ func TestLoadFromEnv(t *testing.T) {
 	os.Setenv("PROJECTT_DB_BUSY_TIMEOUT", "45000")
 	os.Setenv("PROJECTT_STORAGE_PATH", "/env/storage")
 	os.Setenv("PROJECTT_STORAGE_FILES_DIR", "env_files")
+	os.Setenv("PROJECTT_TRASH_RETENTION_DAYS", "0")
+	os.Setenv("PROJECTT_GC_GRACE_HOURS", "2")
+	os.Setenv("PROJECTT_MAX_REVISIONS", "0")
+	os.Setenv("PROJECTT_BACKUP_PATH", "/env/backups")
+	os.Setenv("PROJECTT_BACKUP_PASSWORD", "env-secret")
+	os.Setenv("PROJECTT_VAULT_PASSWORD", "vault-secret")
+	os.Setenv("PROJECTT_BACKUP_INTERVAL_HOURS", "0")
+	os.Setenv("PROJECTT_BACKUP_KEEP_LAST", "3")
+	os.Setenv("PROJECTT_BACKUP_KEEP_DAYS", "0")
+	os.Setenv("PROJECTT_LIBRARIES_PATH", "/env/libraries")
+	os.Setenv("PROJECTT_LIBRARY", "Работа")
+	os.Setenv("PROJECTT_DAEMON_LISTEN", "unix:/run/projectT.sock")
+	os.Setenv("PROJECTT_DAEMON_TOKEN", "api-secret")
+	os.Setenv("PROJECTT_DAEMON_TOKEN_FILE", "/env/daemon.token")
+	os.Setenv("PROJECTT_WATCH_DEBOUNCE_MS", "500")
+	os.Setenv("PROJECTT_LINK_PREVIEW_ENABLED", "false")
+	os.Setenv("PROJECTT_LINK_PREVIEW_REFRESH_DAYS", "0")
 	os.Setenv("PROJECTT_P2P_ENABLED", "false")
 	os.Setenv("PROJECTT_P2P_PORT", "6000")
 	os.Setenv("PROJECTT_P2P_RELAY", "false")
@@ -398,6 +483,23 @@ func TestLoadFromEnv(t *testing.T) {
 	assert.Equal(t, 45000, cfg.Database.BusyTimeout)
 	assert.Equal(t, "/env/storage", cfg.Storage.Path)
 	assert.Equal(t, "env_files", cfg.Storage.FilesDir)
+	assert.Equal(t, 0, cfg.Storage.TrashRetentionDays)
+	assert.Equal(t, 2, cfg.Storage.GCGraceHours)
+	assert.Equal(t, 0, cfg.Storage.MaxRevisionsPerItem)
+	assert.Equal(t, "/env/backups", cfg.Backup.Path)
+	assert.Equal(t, "env-secret", cfg.Backup.Password)
+	assert.Equal(t, "vault-secret", cfg.Storage.VaultPassword)
+	assert.Equal(t, 0, cfg.Backup.IntervalHours)
+	assert.Equal(t, 3, cfg.Backup.KeepLast)
+	assert.Equal(t, 0, cfg.Backup.KeepDays)
+	assert.Equal(t, "/env/libraries", cfg.Libraries.Path)
+	assert.Equal(t, "Работа", cfg.Libraries.Active)
+	assert.Equal(t, "unix:/run/projectT.sock", cfg.Daemon.Listen)
+	assert.Equal(t, "api-secret", cfg.Daemon.Token)
+	assert.Equal(t, "/env/daemon.token", cfg.Daemon.TokenFile)
+	assert.Equal(t, 500, cfg.Watch.DebounceMs)
+	assert.False(t, cfg.LinkPreview.Enabled)
+	assert.Equal(t, 0, cfg.LinkPreview.RefreshDays)
 	assert.False(t, cfg.P2P.Enabled)
 	assert.Equal(t, 6000, cfg.P2P.Port)
 	assert.False(t, cfg.P2P.EnableRelay)
@@ -407,8 +509,9 @@ func TestLoadFromEnv(t *testing.T) {
 // TestLoadFromEnv_InvalidValues проверяет обработку невалидных значений env
 func TestLoadFromEnv_InvalidValues(t *testing.T) {
 	originalEnv := map[string]string{
-		"PROJECTT_DB_BUSY_TIMEOUT": os.Getenv("PROJECTT_DB_BUSY_TIMEOUT"),
-		"PROJECTT_P2P_PORT":        os.Getenv("PROJECTT_P2P_PORT"),
+		"PROJECTT_DB_BUSY_TIMEOUT":      os.Getenv("PROJECTT_DB_BUSY_TIMEOUT"),
+		"PROJECTT_P2P_PORT":             os.Getenv("PROJECTT_P2P_PORT"),
+		"PROJECTT_TRASH_RETENTION_DAYS": os.Getenv("PROJECTT_TRASH_RETENTION_DAYS"),
 	}
 
 	defer func() {
@@ -424,16 +527,19 @@ func TestLoadFromEnv_InvalidValues(t *testing.T) {
 	// Устанавливаем невалидные значения
 	os.Setenv("PROJECTT_DB_BUSY_TIMEOUT", "invalid")
 	os.Setenv("PROJECTT_P2P_PORT", "not_a_number")
+	os.Setenv("PROJECTT_TRASH_RETENTION_DAYS", "-5")
 
 	loader := NewLoader()
 	originalTimeout := loader.config.Database.BusyTimeout
 	originalPort := loader.config.P2P.Port
+	originalRetention := loader.config.Storage.TrashRetentionDays
 
 	loader.loadFromEnv()
 
 	// При невалидных значениях должны остаться оригинальные
 	assert.Equal(t, originalTimeout, loader.config.Database.BusyTimeout)
 	assert.Equal(t, originalPort, loader.config.P2P.Port)
+	assert.Equal(t, originalRetention, loader.config.Storage.TrashRetentionDays)
 }
 
 // TestLoadFromFlags_Help проверяет флаг помощи
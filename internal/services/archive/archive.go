@@ -0,0 +1,102 @@
+// Package archive переносит библиотеку между устройствами без P2P: экспортирует элементы,
+// теги, дерево папок и файлы в переносимый zip-архив и импортирует такой архив обратно.
+//
+// Формат архива (версия 1):
+//
+//	manifest.json          - Manifest: формат, версия, что экспортировано и сколько
+//	tags.json              - []Tag: теги с цветом и описанием
+//	items.json             - []Item: элементы с блоками content_meta, тегами и файлами; родители раньше вложенных
+//	blobs/<ab>/<hash><ext> - содержимое файлов, имя - SHA-256 содержимого
+package archive
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"projectT/internal/storage/database/models"
+)
+
+const (
+	// FormatName идентификатор формата в манифесте
+	FormatName = "projectT-library"
+	// FormatVersion текущая версия формата. Импорт принимает архивы этой и более ранних версий.
+	FormatVersion = 1
+
+	manifestFile = "manifest.json"
+	tagsFile     = "tags.json"
+	itemsFile    = "items.json"
+	blobsDir     = "blobs"
+)
+
+// ScopeKind что экспортировано в архив
+type ScopeKind string
+
+const (
+	ScopeLibrary ScopeKind = "library" // Вся библиотека
+	ScopeFolder  ScopeKind = "folder"  // Папка со всем содержимым
+	ScopeTag     ScopeKind = "tag"     // Элементы с тегом
+)
+
+// Scope описание экспортированной части библиотеки
+type Scope struct {
+	Kind ScopeKind `json:"kind"`
+	Name string    `json:"name,omitempty"` // Название папки или тега
+}
+
+// Manifest заголовок архива
+type Manifest struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Scope     Scope     `json:"scope"`
+	Items     int       `json:"items"`
+	Tags      int       `json:"tags"`
+	Blobs     int       `json:"blobs"`
+}
+
+// Tag тег в архиве. Теги сопоставляются по имени.
+type Tag struct {
+	Name        string `json:"name"`
+	Color       string `json:"color,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// File файл элемента в архиве
+type File struct {
+	Hash     string `json:"hash"`
+	Path     string `json:"path"` // Путь к содержимому внутри архива
+	Size     int64  `json:"size"`
+	MimeType string `json:"mime_type,omitempty"`
+}
+
+// Item элемент в архиве. ID и ParentID действуют только внутри архива и при импорте переназначаются.
+type Item struct {
+	ID          int             `json:"id"`
+	ParentID    *int            `json:"parent_id,omitempty"` // nil - элемент в корне экспортированной части
+	Type        models.ItemType `json:"type"`
+	Title       string          `json:"title"`
+	Description string          `json:"description,omitempty"`
+	ContentHash string          `json:"content_hash,omitempty"`
+	ContentMeta string          `json:"content_meta"` // JSON блоков контента байт в байт: от него зависит хэш содержимого
+	Tags        []string        `json:"tags,omitempty"`
+	Files       []File          `json:"files,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// blobPath возвращает путь к содержимому файла внутри архива
+func blobPath(hash, ext string) string {
+	return path.Join(blobsDir, hash[:2], hash+ext)
+}
+
+// checkManifest проверяет, что архив в поддерживаемом формате
+func checkManifest(manifest *Manifest) error {
+	if manifest.Format != FormatName {
+		return fmt.Errorf("неизвестный формат архива %q", manifest.Format)
+	}
+	if manifest.Version < 1 || manifest.Version > FormatVersion {
+		return fmt.Errorf("версия архива %d не поддерживается (поддерживаются 1-%d)", manifest.Version, FormatVersion)
+	}
+	return nil
+}
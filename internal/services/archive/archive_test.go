@@ -0,0 +1,243 @@
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"projectT/internal/services"
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/queries"
+	"projectT/internal/storage/filesystem"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testStorageConfig конфигурация временного хранилища файлов
+type testStorageConfig struct {
+	path string
+}
+
+func (c testStorageConfig) GetPath() string     { return c.path }
+func (c testStorageConfig) GetFilesDir() string { return "files" }
+
+// useFreshLibrary подключает пустую БД в памяти и пустое временное хранилище файлов
+func useFreshLibrary(t *testing.T) {
+	t.Helper()
+
+	db, err := database.Open(":memory:")
+	require.NoError(t, err)
+	originalDB := database.GetDB()
+	database.SetDB(db)
+	database.RunMigrations()
+
+	root := filesystem.GetStorageRoot()
+	filesystem.InitStorage(testStorageConfig{path: t.TempDir()})
+
+	t.Cleanup(func() {
+		db.Close()
+		database.SetDB(originalDB)
+		filesystem.InitStorage(testStorageConfig{path: root})
+	})
+}
+
+// testLibrary содержимое исходной библиотеки
+type testLibrary struct {
+	folder, nested, note, loose *models.Item
+	fileHash                    string
+}
+
+// createTestLibrary создаёт папку с вложенной папкой, элемент с файлом и тегами и элемент в корне
+func createTestLibrary(t *testing.T) *testLibrary {
+	t.Helper()
+	ctx := context.Background()
+	contentService := services.NewContentBlocksService()
+	tagsService := services.NewTagsService()
+
+	lib := &testLibrary{}
+	var err error
+	lib.folder, err = contentService.CreateItemWithTransaction(ctx, "Проекты", "", models.ItemTypeFolder, "[]", nil)
+	require.NoError(t, err)
+	lib.nested, err = contentService.CreateItemWithTransaction(ctx, "Архив", "", models.ItemTypeFolder, "[]", &lib.folder.ID)
+	require.NoError(t, err)
+
+	fileData, err := filesystem.SaveFileWithOriginalName([]byte("отчёт за квартал"), "report.txt")
+	require.NoError(t, err)
+	lib.fileHash = fileData.Hash
+	blocks := []services.Block{{Type: "file", FileHash: fileData.Hash, OriginalName: "report.txt", Extension: ".txt"}}
+	meta, err := contentService.BlocksToJSON(blocks)
+	require.NoError(t, err)
+	lib.note, err = contentService.CreateItemWithTransaction(ctx, "Отчёт", "Квартальный отчёт", models.ItemTypeElement, meta, &lib.nested.ID)
+	require.NoError(t, err)
+	require.NoError(t, contentService.SaveItemFiles(lib.note.ID, blocks))
+
+	require.NoError(t, tagsService.CreateTag(ctx, &models.Tag{Name: "работа", Color: "#ff0000", Description: "Рабочее"}))
+	require.NoError(t, contentService.ProcessTags(ctx, lib.note.ID, "работа, отчёты"))
+
+	lib.loose, err = contentService.CreateItemWithTransaction(ctx, "Заметка", "Без папки", models.ItemTypeElement, "[]", nil)
+	require.NoError(t, err)
+	require.NoError(t, contentService.ProcessTags(ctx, lib.loose.ID, "работа"))
+	return lib
+}
+
+// itemByTitle находит элемент библиотеки по заголовку
+func itemByTitle(t *testing.T, title string) *models.Item {
+	t.Helper()
+
+	items, err := queries.GetAllItems()
+	require.NoError(t, err)
+	var found *models.Item
+	for _, item := range items {
+		if item.Title == title {
+			require.Nil(t, found, "элемент %q встречается дважды", title)
+			found = item
+		}
+	}
+	require.NotNil(t, found, "элемент %q не найден", title)
+	return found
+}
+
+// TestExportImportLibrary проверяет перенос всей библиотеки в пустую и повторный импорт без дублей
+func TestExportImportLibrary(t *testing.T) {
+	ctx := context.Background()
+	service := NewService()
+
+	useFreshLibrary(t)
+	lib := createTestLibrary(t)
+
+	var buf bytes.Buffer
+	exportReport, err := service.ExportLibrary(ctx, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, 4, exportReport.Items)
+	assert.Equal(t, 2, exportReport.Tags)
+	assert.Equal(t, 1, exportReport.Blobs)
+	assert.Empty(t, exportReport.Missing)
+
+	// Манифест и содержимое файла в архиве
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	names := make(map[string]bool)
+	for _, file := range zr.File {
+		names[file.Name] = true
+	}
+	assert.True(t, names[manifestFile])
+	assert.True(t, names[blobPath(lib.fileHash, ".txt")])
+
+	// Импорт в другую, пустую библиотеку
+	useFreshLibrary(t)
+	report, err := service.Import(ctx, bytes.NewReader(buf.Bytes()), int64(buf.Len()), ImportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, FormatVersion, report.Manifest.Version)
+	assert.Equal(t, ScopeLibrary, report.Manifest.Scope.Kind)
+	assert.Equal(t, 4, report.ItemsCreated)
+	assert.Zero(t, report.ItemsMerged)
+	assert.Equal(t, 2, report.TagsCreated)
+	assert.Equal(t, 1, report.BlobsWritten)
+	assert.Empty(t, report.Warnings)
+
+	folder := itemByTitle(t, "Проекты")
+	nested := itemByTitle(t, "Архив")
+	note := itemByTitle(t, "Отчёт")
+	assert.Nil(t, folder.ParentID)
+	require.NotNil(t, nested.ParentID)
+	assert.Equal(t, folder.ID, *nested.ParentID)
+	require.NotNil(t, note.ParentID)
+	assert.Equal(t, nested.ID, *note.ParentID)
+	assert.Equal(t, note.ID, report.IDMap[lib.note.ID])
+	assert.Equal(t, lib.note.ContentMeta, note.ContentMeta)
+	assert.Equal(t, lib.note.ContentHash, note.ContentHash)
+
+	data, err := filesystem.ReadFile(lib.fileHash)
+	require.NoError(t, err)
+	assert.Equal(t, "отчёт за квартал", string(data))
+	files, err := queries.GetFilesByItemID(note.ID)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, lib.fileHash, files[0].Hash)
+
+	tags, err := queries.GetTagsForItem(ctx, note.ID)
+	require.NoError(t, err)
+	require.Len(t, tags, 2)
+	tag, err := queries.GetTagByName(ctx, "работа")
+	require.NoError(t, err)
+	assert.Equal(t, "#ff0000", tag.Color, "цвет тега перенесён")
+
+	// Повторный импорт ничего не дублирует
+	report, err = service.Import(ctx, bytes.NewReader(buf.Bytes()), int64(buf.Len()), ImportOptions{})
+	require.NoError(t, err)
+	assert.Zero(t, report.ItemsCreated)
+	assert.Equal(t, 4, report.ItemsMerged)
+	assert.Zero(t, report.TagsCreated)
+	assert.Zero(t, report.BlobsWritten)
+	items, err := queries.GetAllItems()
+	require.NoError(t, err)
+	assert.Len(t, items, 4)
+}
+
+// TestExportFolderAndTag проверяет экспорт поддерева папки и элементов тега
+func TestExportFolderAndTag(t *testing.T) {
+	ctx := context.Background()
+	service := NewService()
+
+	useFreshLibrary(t)
+	lib := createTestLibrary(t)
+
+	var folderArchive bytes.Buffer
+	report, err := service.ExportFolder(ctx, &folderArchive, lib.nested.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Items, "папка и вложенный элемент")
+
+	_, err = service.ExportFolder(ctx, &bytes.Buffer{}, lib.note.ID)
+	assert.Error(t, err, "экспортировать можно только папку")
+
+	tag, err := queries.GetTagByName(ctx, "работа")
+	require.NoError(t, err)
+	var tagArchive bytes.Buffer
+	report, err = service.ExportTag(ctx, &tagArchive, tag.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Items)
+	assert.Equal(t, 1, report.Blobs)
+
+	// Поддерево папки импортируется в указанную папку
+	useFreshLibrary(t)
+	target, err := services.NewContentBlocksService().CreateItemWithTransaction(ctx, "Входящие", "", models.ItemTypeFolder, "[]", nil)
+	require.NoError(t, err)
+	_, err = service.Import(ctx, bytes.NewReader(folderArchive.Bytes()), int64(folderArchive.Len()), ImportOptions{ParentID: &target.ID})
+	require.NoError(t, err)
+	nested := itemByTitle(t, "Архив")
+	require.NotNil(t, nested.ParentID)
+	assert.Equal(t, target.ID, *nested.ParentID)
+
+	// Элементы тега попадают в корень, уже импортированный элемент не дублируется
+	importReport, err := service.Import(ctx, bytes.NewReader(tagArchive.Bytes()), int64(tagArchive.Len()), ImportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, importReport.ItemsCreated)
+	assert.Equal(t, 1, importReport.ItemsMerged)
+	assert.Zero(t, importReport.BlobsWritten, "файлы уже импортированного элемента не записываются повторно")
+	assert.Nil(t, itemByTitle(t, "Заметка").ParentID)
+	assert.Equal(t, nested.ID, *itemByTitle(t, "Отчёт").ParentID)
+}
+
+// TestImportRejectsUnknownFormat проверяет отказ от архивов чужого формата или более новой версии
+func TestImportRejectsUnknownFormat(t *testing.T) {
+	useFreshLibrary(t)
+
+	for _, manifest := range []Manifest{
+		{Format: "other", Version: 1},
+		{Format: FormatName, Version: FormatVersion + 1},
+	} {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		w, err := zw.Create(manifestFile)
+		require.NoError(t, err)
+		require.NoError(t, json.NewEncoder(w).Encode(manifest))
+		require.NoError(t, zw.Close())
+
+		_, err = NewService().Import(context.Background(), bytes.NewReader(buf.Bytes()), int64(buf.Len()), ImportOptions{})
+		assert.Error(t, err)
+	}
+}
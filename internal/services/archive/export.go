@@ -0,0 +1,363 @@
+package archive
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"projectT/internal/services"
+	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/queries"
+	"projectT/internal/storage/filesystem"
+)
+
+// ExportReport результат экспорта
+type ExportReport struct {
+	Items   int
+	Tags    int
+	Blobs   int
+	Missing []string // Хэши файлов, которых нет на диске: ссылки на них сохранены без содержимого
+}
+
+// String возвращает итог экспорта одной строкой
+func (r *ExportReport) String() string {
+	s := fmt.Sprintf("экспортировано элементов %d, тегов %d, файлов %d", r.Items, r.Tags, r.Blobs)
+	if len(r.Missing) > 0 {
+		s += fmt.Sprintf("; файлов нет на диске: %d", len(r.Missing))
+	}
+	return s
+}
+
+// Service экспорт и импорт библиотеки
+type Service struct {
+	tagsService *services.TagsService
+}
+
+// NewService создает новый экземпляр сервиса архивов
+func NewService() *Service {
+	return &Service{tagsService: services.NewTagsService()}
+}
+
+// ExportLibrary экспортирует всю библиотеку, кроме корзины
+func (s *Service) ExportLibrary(ctx context.Context, w io.Writer) (*ExportReport, error) {
+	items, err := queries.GetAllItems()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения элементов: %w", err)
+	}
+	return s.export(ctx, w, Scope{Kind: ScopeLibrary}, items)
+}
+
+// ExportFolder экспортирует папку вместе со всеми вложенными элементами
+func (s *Service) ExportFolder(ctx context.Context, w io.Writer, folderID int) (*ExportReport, error) {
+	items, err := queries.GetItemSubtree(folderID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения содержимого папки: %w", err)
+	}
+	if len(items) == 0 || items[0].Type != models.ItemTypeFolder {
+		return nil, fmt.Errorf("папка %d не найдена", folderID)
+	}
+	return s.export(ctx, w, Scope{Kind: ScopeFolder, Name: items[0].Title}, items)
+}
+
+// ExportTag экспортирует элементы с тегом. Папки, в которых они лежат, не экспортируются:
+// при импорте такие элементы попадают в корень.
+func (s *Service) ExportTag(ctx context.Context, w io.Writer, tagID int) (*ExportReport, error) {
+	tag, err := s.tagsService.GetTagByID(ctx, tagID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.tagsService.GetItemsForTag(ctx, tagID)
+	if err != nil {
+		return nil, err
+	}
+
+	// GetItemsForTag не возвращает хэш содержимого
+	full := make([]*models.Item, 0, len(items))
+	for _, item := range items {
+		loaded, err := queries.GetItemByID(item.ID)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка получения элемента %d: %w", item.ID, err)
+		}
+		full = append(full, loaded)
+	}
+	return s.export(ctx, w, Scope{Kind: ScopeTag, Name: tag.Name}, full)
+}
+
+// Export экспортирует часть библиотеки, выбранную scope; id - папка или тег
+func (s *Service) Export(ctx context.Context, w io.Writer, scope ScopeKind, id int) (*ExportReport, error) {
+	switch scope {
+	case ScopeFolder:
+		return s.ExportFolder(ctx, w, id)
+	case ScopeTag:
+		return s.ExportTag(ctx, w, id)
+	default:
+		return s.ExportLibrary(ctx, w)
+	}
+}
+
+// ExportToFile экспортирует в файл архива; scope выбирает экспортируемую часть, id - папку или тег
+func (s *Service) ExportToFile(ctx context.Context, filePath string, scope ScopeKind, id int) (*ExportReport, error) {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания файла архива: %w", err)
+	}
+
+	report, err := s.Export(ctx, file, scope, id)
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("ошибка записи файла архива: %w", closeErr)
+	}
+	if err != nil {
+		os.Remove(filePath)
+		return nil, err
+	}
+	return report, nil
+}
+
+// export записывает элементы в архив. Родитель, не попавший в экспорт, заменяется корнем.
+func (s *Service) export(ctx context.Context, w io.Writer, scope Scope, items []*models.Item) (*ExportReport, error) {
+	report := &ExportReport{}
+	included := make(map[int]bool, len(items))
+	for _, item := range items {
+		included[item.ID] = true
+	}
+
+	archived := make([]*Item, 0, len(items))
+	blobs := make(map[string]*File) // Хэш -> файл, содержимое которого нужно записать
+	tagNames := make(map[string]bool)
+
+	for _, item := range items {
+		entry, err := s.exportItem(ctx, item, blobs)
+		if err != nil {
+			return nil, err
+		}
+		if entry.ParentID != nil && !included[*entry.ParentID] {
+			entry.ParentID = nil
+		}
+		for _, name := range entry.Tags {
+			tagNames[name] = true
+		}
+		archived = append(archived, entry)
+	}
+	archived = sortParentsFirst(archived)
+
+	tags, err := s.exportTags(ctx, tagNames)
+	if err != nil {
+		return nil, err
+	}
+
+	zw := zip.NewWriter(w)
+
+	// Содержимое файлов; отсутствующие на диске пропускаются
+	hashes := make([]string, 0, len(blobs))
+	for hash := range blobs {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	for _, hash := range hashes {
+		written, err := writeBlob(zw, blobs[hash])
+		if err != nil {
+			return nil, err
+		}
+		if written {
+			report.Blobs++
+		} else {
+			report.Missing = append(report.Missing, hash)
+		}
+	}
+
+	report.Items = len(archived)
+	report.Tags = len(tags)
+	manifest := &Manifest{
+		Format:    FormatName,
+		Version:   FormatVersion,
+		CreatedAt: time.Now().UTC(),
+		Scope:     scope,
+		Items:     report.Items,
+		Tags:      report.Tags,
+		Blobs:     report.Blobs,
+	}
+
+	for _, entry := range []struct {
+		name  string
+		value interface{}
+	}{
+		{tagsFile, tags},
+		{itemsFile, archived},
+		{manifestFile, manifest},
+	} {
+		if err := writeJSON(zw, entry.name, entry.value); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("ошибка записи архива: %w", err)
+	}
+	return report, nil
+}
+
+// exportItem переводит элемент в формат архива и добавляет его файлы в blobs
+func (s *Service) exportItem(ctx context.Context, item *models.Item, blobs map[string]*File) (*Item, error) {
+	entry := &Item{
+		ID:          item.ID,
+		ParentID:    item.ParentID,
+		Type:        item.Type,
+		Title:       item.Title,
+		Description: item.Description,
+		ContentHash: item.ContentHash,
+		ContentMeta: item.ContentMeta,
+		CreatedAt:   item.CreatedAt,
+		UpdatedAt:   item.UpdatedAt,
+	}
+	if entry.ParentID != nil && *entry.ParentID == 0 {
+		entry.ParentID = nil
+	}
+
+	tags, err := s.tagsService.GetTagsForItem(ctx, item.ID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения тегов элемента %d: %w", item.ID, err)
+	}
+	for _, tag := range tags {
+		entry.Tags = append(entry.Tags, tag.Name)
+	}
+
+	files, err := queries.GetFilesByItemID(item.ID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения файлов элемента %d: %w", item.ID, err)
+	}
+	seen := make(map[string]bool)
+	addFile := func(file File) {
+		if seen[file.Hash] || !filesystem.IsValidHash(file.Hash) {
+			return
+		}
+		seen[file.Hash] = true
+		if existing, ok := blobs[file.Hash]; ok {
+			file.Path = existing.Path
+		} else {
+			blobs[file.Hash] = &file
+		}
+		entry.Files = append(entry.Files, file)
+	}
+
+	for _, file := range files {
+		if file.IsRemote {
+			continue
+		}
+		addFile(File{
+			Hash:     file.Hash,
+			Path:     blobPath(file.Hash, strings.ToLower(filepath.Ext(file.FilePath))),
+			Size:     file.Size,
+			MimeType: file.MimeType,
+		})
+	}
+
+	// Файлы блоков (и копии страниц ссылок) без записи в item_files
+	var blocks []services.Block
+	if err := json.Unmarshal([]byte(entry.ContentMeta), &blocks); err == nil {
+		for _, block := range blocks {
+			for _, hash := range block.FileHashes() {
+				if seen[hash] {
+					continue
+				}
+				ext := strings.ToLower(filepath.Ext(filesystem.GetFilePathByHash(hash)))
+				if ext == "" && hash == block.FileHash {
+					ext = strings.ToLower(block.Extension)
+				}
+				addFile(File{Hash: hash, Path: blobPath(hash, ext)})
+			}
+		}
+	}
+
+	return entry, nil
+}
+
+// exportTags возвращает теги с цветом и описанием по именам
+func (s *Service) exportTags(ctx context.Context, names map[string]bool) ([]Tag, error) {
+	tags := make([]Tag, 0, len(names))
+	for name := range names {
+		tag, err := s.tagsService.GetTagByName(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, Tag{Name: tag.Name, Color: tag.Color, Description: tag.Description})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
+
+// writeBlob записывает содержимое файла в архив. Возвращает false, если файла нет на диске.
+func writeBlob(zw *zip.Writer, file *File) (bool, error) {
+	data, err := filesystem.ReadFile(file.Hash)
+	if err != nil {
+		return false, nil
+	}
+	if filesystem.CalculateHash(data) != file.Hash {
+		// Повреждённый файл в архив не попадает: при импорте его нельзя было бы проверить
+		return false, nil
+	}
+
+	w, err := zw.Create(file.Path)
+	if err != nil {
+		return false, fmt.Errorf("ошибка записи файла %s в архив: %w", file.Hash, err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return false, fmt.Errorf("ошибка записи файла %s в архив: %w", file.Hash, err)
+	}
+	return true, nil
+}
+
+// writeJSON записывает значение в архив как JSON
+func writeJSON(zw *zip.Writer, name string, value interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("ошибка записи %s: %w", name, err)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Errorf("ошибка записи %s: %w", name, err)
+	}
+	return nil
+}
+
+// sortParentsFirst упорядочивает элементы так, чтобы родитель шёл раньше вложенных
+func sortParentsFirst(items []*Item) []*Item {
+	byID := make(map[int]*Item, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+
+	sorted := make([]*Item, 0, len(items))
+	state := make(map[int]int) // 1 - обрабатывается, 2 - добавлен
+	var visit func(item *Item)
+	visit = func(item *Item) {
+		if state[item.ID] != 0 {
+			return
+		}
+		state[item.ID] = 1
+		if item.ParentID != nil {
+			if parent, ok := byID[*item.ParentID]; ok {
+				if state[parent.ID] == 1 {
+					// Цикл по parent_id: разрываем, перенося элемент в корень
+					item.ParentID = nil
+				} else {
+					visit(parent)
+				}
+			}
+		}
+		state[item.ID] = 2
+		sorted = append(sorted, item)
+	}
+
+	for _, item := range items {
+		visit(item)
+	}
+	return sorted
+}
@@ -0,0 +1,361 @@
+package archive
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/queries"
+	"projectT/internal/storage/filesystem"
+)
+
+// maxJSONSize ограничение размера JSON-файлов архива при чтении
+const maxJSONSize = 256 << 20
+
+// ImportOptions параметры импорта
+type ImportOptions struct {
+	ParentID *int // Папка, в которую попадают корневые элементы архива (nil - корень библиотеки)
+}
+
+// ImportReport результат импорта
+type ImportReport struct {
+	Manifest      Manifest
+	ItemsCreated  int
+	ItemsMerged   int // Элементы, уже существовавшие в библиотеке (по хэшу содержимого или папки с тем же именем)
+	TagsCreated   int
+	BlobsWritten  int
+	BlobsExisting int         // Файлы, уже бывшие в хранилище
+	IDMap         map[int]int // ID в архиве -> ID в библиотеке
+	Warnings      []string
+}
+
+// String возвращает итог импорта одной строкой
+func (r *ImportReport) String() string {
+	s := fmt.Sprintf("создано элементов %d, уже были в библиотеке %d, новых тегов %d, файлов записано %d, уже были %d",
+		r.ItemsCreated, r.ItemsMerged, r.TagsCreated, r.BlobsWritten, r.BlobsExisting)
+	if len(r.Warnings) > 0 {
+		s += fmt.Sprintf("; предупреждений %d", len(r.Warnings))
+	}
+	return s
+}
+
+func (r *ImportReport) warn(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// importer состояние одного импорта
+type importer struct {
+	service *Service
+	ctx     context.Context
+	opts    ImportOptions
+	report  *ImportReport
+	files   map[string]*zip.File            // Содержимое архива по пути
+	blobs   map[string]*filesystem.FileData // Импортированные файлы по хэшу (nil - файл недоступен)
+}
+
+// ImportFile импортирует архив из файла
+func (s *Service) ImportFile(ctx context.Context, filePath string, opts ImportOptions) (*ImportReport, error) {
+	zr, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия архива: %w", err)
+	}
+	defer zr.Close()
+
+	return s.importZip(ctx, &zr.Reader, opts)
+}
+
+// Import импортирует архив. Элементы с уже существующим хэшем содержимого и папки с тем же
+// именем в той же папке не дублируются, файлы с уже имеющимся хэшем не перезаписываются,
+// теги сопоставляются по имени.
+func (s *Service) Import(ctx context.Context, r io.ReaderAt, size int64, opts ImportOptions) (*ImportReport, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия архива: %w", err)
+	}
+	return s.importZip(ctx, zr, opts)
+}
+
+func (s *Service) importZip(ctx context.Context, zr *zip.Reader, opts ImportOptions) (*ImportReport, error) {
+	im := &importer{
+		service: s,
+		ctx:     ctx,
+		opts:    opts,
+		report:  &ImportReport{IDMap: make(map[int]int)},
+		files:   make(map[string]*zip.File, len(zr.File)),
+		blobs:   make(map[string]*filesystem.FileData),
+	}
+	for _, file := range zr.File {
+		im.files[file.Name] = file
+	}
+
+	if err := im.readJSON(manifestFile, &im.report.Manifest); err != nil {
+		return nil, err
+	}
+	if err := checkManifest(&im.report.Manifest); err != nil {
+		return nil, err
+	}
+
+	var tags []Tag
+	if err := im.readJSON(tagsFile, &tags); err != nil {
+		return nil, err
+	}
+	var items []*Item
+	if err := im.readJSON(itemsFile, &items); err != nil {
+		return nil, err
+	}
+
+	if err := im.importTags(tags); err != nil {
+		return nil, err
+	}
+
+	for _, item := range sortParentsFirst(items) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := im.importItem(item); err != nil {
+			return nil, fmt.Errorf("ошибка импорта элемента %q: %w", item.Title, err)
+		}
+	}
+
+	return im.report, nil
+}
+
+// readJSON читает JSON-файл архива
+func (im *importer) readJSON(name string, value interface{}) error {
+	file, ok := im.files[name]
+	if !ok {
+		return fmt.Errorf("в архиве нет %s", name)
+	}
+	r, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("ошибка чтения %s: %w", name, err)
+	}
+	defer r.Close()
+
+	if err := json.NewDecoder(io.LimitReader(r, maxJSONSize)).Decode(value); err != nil {
+		return fmt.Errorf("ошибка разбора %s: %w", name, err)
+	}
+	return nil
+}
+
+// importTags создаёт отсутствующие теги с цветом и описанием из архива
+func (im *importer) importTags(tags []Tag) error {
+	for _, tag := range tags {
+		name := strings.TrimSpace(tag.Name)
+		if name == "" {
+			continue
+		}
+		if _, err := im.service.tagsService.GetTagByName(im.ctx, name); err == nil {
+			continue
+		}
+
+		created := &models.Tag{Name: name, Color: tag.Color, Description: tag.Description}
+		if created.Color == "" {
+			created.Color = "#808080"
+		}
+		if err := im.service.tagsService.CreateTag(im.ctx, created); err != nil {
+			return fmt.Errorf("ошибка создания тега %q: %w", name, err)
+		}
+		im.report.TagsCreated++
+	}
+	return nil
+}
+
+// importItem создаёт элемент или находит уже существующий и добавляет ему теги
+func (im *importer) importItem(item *Item) error {
+	parentID := im.opts.ParentID
+	if item.ParentID != nil {
+		mapped, ok := im.report.IDMap[*item.ParentID]
+		if !ok {
+			im.report.warn("элемент %q: родитель %d отсутствует в архиве, элемент помещён в корень импорта", item.Title, *item.ParentID)
+		} else {
+			parentID = &mapped
+		}
+	}
+
+	contentMeta := item.ContentMeta
+	if contentMeta == "" {
+		contentMeta = "[]"
+	}
+
+	contentHash := item.ContentHash
+	if item.Type != models.ItemTypeFolder || contentHash != "" {
+		contentHash = filesystem.GenerateContentHash(item.Title, item.Description, contentMeta)
+		if item.ContentHash != "" && item.ContentHash != contentHash {
+			im.report.warn("элемент %q: хэш содержимого в архиве не совпадает, пересчитан", item.Title)
+		}
+	}
+
+	existing, err := im.findExisting(item, contentHash, parentID)
+	if err != nil {
+		return err
+	}
+
+	var itemID int
+	if existing != nil {
+		itemID = existing.ID
+		im.report.ItemsMerged++
+	} else {
+		created := &models.Item{
+			Type:        item.Type,
+			Title:       item.Title,
+			Description: item.Description,
+			ContentMeta: contentMeta,
+			ParentID:    parentID,
+			ContentHash: contentHash,
+		}
+		if err := queries.CreateItem(created); err != nil {
+			return fmt.Errorf("ошибка создания элемента: %w", err)
+		}
+		itemID = created.ID
+		im.report.ItemsCreated++
+
+		if !item.CreatedAt.IsZero() && !item.UpdatedAt.IsZero() {
+			if err := queries.UpdateItemTimestamps(itemID, item.CreatedAt, item.UpdatedAt); err != nil {
+				return fmt.Errorf("ошибка восстановления времени элемента: %w", err)
+			}
+		}
+		if err := im.linkFiles(itemID, item); err != nil {
+			return err
+		}
+	}
+	im.report.IDMap[item.ID] = itemID
+
+	if len(item.Tags) > 0 {
+		tagIDs, err := im.service.tagsService.GetOrCreateTags(im.ctx, item.Tags)
+		if err != nil {
+			return err
+		}
+		for _, tagID := range tagIDs {
+			if err := im.service.tagsService.AddTagToItem(im.ctx, itemID, tagID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// findExisting ищет элемент библиотеки, совпадающий с элементом архива:
+// элемент - по хэшу содержимого, папку - по имени в той же папке
+func (im *importer) findExisting(item *Item, contentHash string, parentID *int) (*models.Item, error) {
+	if item.Type != models.ItemTypeFolder {
+		existing, err := queries.GetItemByHash(contentHash)
+		if err != nil {
+			// GetItemByHash не различает "не найден" и ошибки БД
+			return nil, nil
+		}
+		return existing, nil
+	}
+
+	parent := 0
+	if parentID != nil {
+		parent = *parentID
+	}
+	siblings, err := queries.GetItemsByParent(parent)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения содержимого папки: %w", err)
+	}
+	for _, sibling := range siblings {
+		if sibling.Type == models.ItemTypeFolder && sibling.Title == item.Title {
+			return sibling, nil
+		}
+	}
+	return nil, nil
+}
+
+// linkFiles сохраняет файлы элемента в хранилище и привязывает их к элементу
+func (im *importer) linkFiles(itemID int, item *Item) error {
+	for _, file := range item.Files {
+		fileData, err := im.importBlob(file)
+		if err != nil {
+			return err
+		}
+		if fileData == nil {
+			continue
+		}
+
+		mimeType := file.MimeType
+		if mimeType == "" {
+			mimeType = fileData.MimeType
+		}
+		if err := queries.CreateItemFile(&models.ItemFile{
+			ItemID:   itemID,
+			Hash:     fileData.Hash,
+			FilePath: fileData.Path,
+			Size:     fileData.Size,
+			MimeType: mimeType,
+		}); err != nil {
+			return fmt.Errorf("ошибка привязки файла %s: %w", file.Hash, err)
+		}
+	}
+	return nil
+}
+
+// importBlob сохраняет содержимое файла из архива, проверяя хэш. Файл, уже имеющийся в хранилище,
+// не перезаписывается. Возвращает nil, если файла нет ни в архиве, ни в хранилище.
+func (im *importer) importBlob(file File) (*filesystem.FileData, error) {
+	if fileData, done := im.blobs[file.Hash]; done {
+		return fileData, nil
+	}
+	if !filesystem.IsValidHash(file.Hash) {
+		im.report.warn("неверный хэш файла %q", file.Hash)
+		im.blobs[file.Hash] = nil
+		return nil, nil
+	}
+
+	if filesystem.Exists(file.Hash) {
+		fileData, err := filesystem.GetFileInfo(file.Hash)
+		if err != nil {
+			return nil, err
+		}
+		im.report.BlobsExisting++
+		im.blobs[file.Hash] = fileData
+		return fileData, nil
+	}
+
+	fileData, err := im.readBlob(file)
+	if err != nil {
+		if !errors.Is(err, errBlobUnavailable) {
+			return nil, err
+		}
+		im.report.warn("файл %s: %v", file.Hash, err)
+	} else {
+		im.report.BlobsWritten++
+	}
+	im.blobs[file.Hash] = fileData
+	return fileData, nil
+}
+
+// errBlobUnavailable содержимого файла нет в архиве или оно повреждено
+var errBlobUnavailable = errors.New("содержимое файла отсутствует в архиве или повреждено")
+
+// readBlob читает содержимое файла из архива и сохраняет его в хранилище
+func (im *importer) readBlob(file File) (*filesystem.FileData, error) {
+	entry, ok := im.files[file.Path]
+	if !ok || !strings.HasPrefix(file.Path, blobsDir+"/") {
+		return nil, errBlobUnavailable
+	}
+
+	r, err := entry.Open()
+	if err != nil {
+		return nil, errBlobUnavailable
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil || filesystem.CalculateHash(data) != file.Hash {
+		return nil, errBlobUnavailable
+	}
+
+	fileData, err := filesystem.SaveFileWithOriginalName(data, path.Base(file.Path))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сохранения файла %s: %w", file.Hash, err)
+	}
+	return fileData, nil
+}
@@ -0,0 +1,290 @@
+// Package backup создаёт зашифрованные резервные копии базы данных и хранилища файлов
+// и восстанавливает библиотеку из них.
+//
+// Хранилище резервных копий:
+//
+//	repository.key                   - случайный ключ хранилища, зашифрованный паролем (crypto.EncryptPrivateKey)
+//	snapshots/<id>/manifest.json     - Manifest: время копии, контрольная сумма базы и список файлов
+//	snapshots/<id>/database.db.enc   - снимок базы данных (VACUUM INTO), зашифрованный ключом хранилища
+//	blobs/<ab>/<hash><ext>.enc       - файлы хранилища; общие для всех копий, каждая копия дописывает только новые
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"projectT/internal/services/crypto"
+	"projectT/internal/storage/database"
+)
+
+const (
+	// FormatVersion версия формата снимка
+	FormatVersion = 1
+
+	keyFile      = "repository.key"
+	snapshotsDir = "snapshots"
+	blobsDir     = "blobs"
+	manifestFile = "manifest.json"
+	databaseFile = "database.db.enc"
+	encExt       = ".enc"
+	partialExt   = ".partial"
+
+	// minWrappedKeySize соль, nonce и тег GCM зашифрованного ключа
+	minWrappedKeySize = 16 + 12 + 16
+
+	// snapshotIDLayout формат ID снимка: время создания в UTC, сортируется как строка
+	snapshotIDLayout = "20060102-150405.000"
+)
+
+var (
+	// ErrNoPassword пароль резервных копий не задан
+	ErrNoPassword = errors.New("пароль резервных копий не задан")
+	// ErrWrongPassword пароль не подходит к хранилищу резервных копий
+	ErrWrongPassword = errors.New("неверный пароль резервных копий")
+	// ErrSnapshotNotFound снимок не найден
+	ErrSnapshotNotFound = errors.New("резервная копия не найдена")
+)
+
+// Config настройки резервного копирования
+type Config struct {
+	Dir      string                  // Директория хранилища резервных копий
+	Password string                  // Пароль, которым зашифрован ключ хранилища
+	KeepLast int                     // Сколько последних копий хранить всегда (0 - правило не действует)
+	KeepDays int                     // За сколько последних дней хранить по одной копии в день (0 - правило не действует)
+	Database database.DatabaseConfig // База данных, которая копируется и заменяется при восстановлении
+}
+
+var (
+	configMu      sync.RWMutex
+	defaultConfig Config
+
+	// backupMu не даёт создавать, удалять и восстанавливать копии одновременно
+	backupMu sync.Mutex
+)
+
+// Configure задаёт настройки, с которыми создаются сервисы NewService
+func Configure(cfg Config) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	defaultConfig = cfg
+}
+
+// Suspend ждёт окончания текущей операции с резервными копиями и не даёт начать новую до вызова resume
+func Suspend() (resume func()) {
+	backupMu.Lock()
+	return backupMu.Unlock
+}
+
+// HasPassword сообщает, задан ли пароль в настройках
+func HasPassword() bool {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return defaultConfig.Password != ""
+}
+
+// Manifest описание снимка
+type Manifest struct {
+	Version   int       `json:"version"`
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Database  BlobRef   `json:"database"` // Name - файл снимка базы, Hash - SHA-256 расшифрованной базы
+	Blobs     []BlobRef `json:"blobs"`
+}
+
+// BlobRef файл в снимке
+type BlobRef struct {
+	Name string `json:"name"` // Имя файла в хранилище: хэш и расширение
+	Hash string `json:"hash"` // SHA-256 содержимого
+	Size int64  `json:"size"`
+}
+
+// Snapshot снимок в списке резервных копий
+type Snapshot struct {
+	ID        string
+	CreatedAt time.Time
+	Size      int64 // Размер базы данных
+	Blobs     int
+}
+
+// Service резервное копирование и восстановление
+type Service struct {
+	cfg      Config
+	defaults bool // Настройки взяты из Configure и перечитываются фоновым копированием
+}
+
+// NewService создает сервис с настройками из Configure
+func NewService() *Service {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return &Service{cfg: defaultConfig, defaults: true}
+}
+
+// NewServiceWithConfig создает сервис с заданными настройками
+func NewServiceWithConfig(cfg Config) *Service {
+	return &Service{cfg: cfg}
+}
+
+// SetPassword задаёт пароль для этого сервиса, например введённый пользователем
+func (s *Service) SetPassword(password string) {
+	s.cfg.Password = password
+}
+
+// List возвращает снимки, новые первыми. Пароль не нужен.
+func (s *Service) List() ([]*Snapshot, error) {
+	manifests, _, err := s.manifests()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]*Snapshot, 0, len(manifests))
+	for _, m := range manifests {
+		snapshots = append(snapshots, &Snapshot{
+			ID:        m.ID,
+			CreatedAt: m.CreatedAt,
+			Size:      m.Database.Size,
+			Blobs:     len(m.Blobs),
+		})
+	}
+	return snapshots, nil
+}
+
+// manifests читает манифесты всех завершённых снимков, новые первыми.
+// unreadable - сколько снимков пропущено из-за повреждённого манифеста.
+func (s *Service) manifests() (manifests []*Manifest, unreadable int, err error) {
+	entries, err := os.ReadDir(filepath.Join(s.cfg.Dir, snapshotsDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, 0, nil
+		}
+		return nil, 0, fmt.Errorf("ошибка чтения списка резервных копий: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasSuffix(entry.Name(), partialExt) {
+			continue
+		}
+		m, err := s.readManifest(entry.Name())
+		if err != nil {
+			// Повреждённый снимок не мешает работать с остальными
+			unreadable++
+			continue
+		}
+		manifests = append(manifests, m)
+	}
+
+	sort.Slice(manifests, func(i, j int) bool { return manifests[i].ID > manifests[j].ID })
+	return manifests, unreadable, nil
+}
+
+// readManifest читает манифест снимка
+func (s *Service) readManifest(id string) (*Manifest, error) {
+	if id == "" || filepath.Base(id) != id {
+		return nil, ErrSnapshotNotFound
+	}
+	data, err := os.ReadFile(filepath.Join(s.snapshotDir(id), manifestFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrSnapshotNotFound
+		}
+		return nil, fmt.Errorf("ошибка чтения резервной копии %s: %w", id, err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("ошибка разбора резервной копии %s: %w", id, err)
+	}
+	if m.Version < 1 || m.Version > FormatVersion {
+		return nil, fmt.Errorf("версия резервной копии %s (%d) не поддерживается", id, m.Version)
+	}
+	m.ID = id
+	return &m, nil
+}
+
+// repositoryKey возвращает ключ хранилища, создавая хранилище при первом использовании
+func (s *Service) repositoryKey() ([]byte, error) {
+	if s.cfg.Password == "" {
+		return nil, ErrNoPassword
+	}
+
+	path := filepath.Join(s.cfg.Dir, keyFile)
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if len(data) < len(crypto.EncryptedKeyMarker)+minWrappedKeySize {
+			return nil, fmt.Errorf("файл ключа резервных копий повреждён")
+		}
+		key, err := crypto.DecryptPrivateKey(data, s.cfg.Password)
+		if err != nil {
+			return nil, ErrWrongPassword
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("ошибка чтения ключа резервных копий: %w", err)
+	}
+
+	// Новое хранилище: случайный ключ, зашифрованный паролем. Смена пароля не требует перешифровки копий.
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := crypto.EncryptPrivateKey(key, s.cfg.Password)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(s.cfg.Dir, 0700); err != nil {
+		return nil, fmt.Errorf("ошибка создания директории резервных копий: %w", err)
+	}
+	if err := writeFileAtomic(path, encrypted); err != nil {
+		return nil, fmt.Errorf("ошибка сохранения ключа резервных копий: %w", err)
+	}
+	return key, nil
+}
+
+// ChangePassword перешифровывает ключ хранилища новым паролем
+func (s *Service) ChangePassword(newPassword string) error {
+	backupMu.Lock()
+	defer backupMu.Unlock()
+
+	if newPassword == "" {
+		return ErrNoPassword
+	}
+	key, err := s.repositoryKey()
+	if err != nil {
+		return err
+	}
+	encrypted, err := crypto.EncryptPrivateKey(key, newPassword)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(s.cfg.Dir, keyFile), encrypted); err != nil {
+		return fmt.Errorf("ошибка сохранения ключа резервных копий: %w", err)
+	}
+	s.cfg.Password = newPassword
+	return nil
+}
+
+// snapshotDir возвращает директорию снимка
+func (s *Service) snapshotDir(id string) string {
+	return filepath.Join(s.cfg.Dir, snapshotsDir, id)
+}
+
+// blobPath возвращает путь к зашифрованному файлу в хранилище копий
+func (s *Service) blobPath(name string) string {
+	return filepath.Join(s.cfg.Dir, blobsDir, name[:2], name+encExt)
+}
+
+// writeFileAtomic записывает файл через временный, чтобы не оставить его недописанным
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + partialExt
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,196 @@
+package backup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"projectT/internal/services"
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/queries"
+	"projectT/internal/storage/filesystem"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDBConfig файловая база во временной директории
+type testDBConfig struct {
+	path string
+}
+
+func (c testDBConfig) GetPath() string      { return c.path }
+func (c testDBConfig) GetBusyTimeout() int  { return 30000 }
+func (c testDBConfig) GetMaxOpenConns() int { return 1 }
+func (c testDBConfig) GetMaxIdleConns() int { return 1 }
+
+// testStorageConfig временное хранилище файлов
+type testStorageConfig struct {
+	path string
+}
+
+func (c testStorageConfig) GetPath() string     { return c.path }
+func (c testStorageConfig) GetFilesDir() string { return "files" }
+
+// setupBackupTest подключает файловую базу и хранилище во временной директории
+// и возвращает сервис копий с паролем
+func setupBackupTest(t *testing.T) *Service {
+	t.Helper()
+	dir := t.TempDir()
+
+	originalDB := database.GetDB()
+	dbConfig := testDBConfig{path: filepath.Join(dir, "projectT.db")}
+	database.InitDBWithConfig(dbConfig)
+	database.RunMigrations()
+
+	root := filesystem.GetStorageRoot()
+	filesystem.InitStorage(testStorageConfig{path: filepath.Join(dir, "storage")})
+
+	t.Cleanup(func() {
+		database.CloseDB()
+		database.SetDB(originalDB)
+		filesystem.InitStorage(testStorageConfig{path: root})
+	})
+
+	return NewServiceWithConfig(Config{
+		Dir:      filepath.Join(dir, "backups"),
+		Password: "secret",
+		Database: dbConfig,
+	})
+}
+
+// createItem создаёт элемент с файлом
+func createItem(t *testing.T, title string, content []byte) (*models.Item, string) {
+	t.Helper()
+	ctx := context.Background()
+	contentService := services.NewContentBlocksService()
+
+	fileData, err := filesystem.SaveFileWithOriginalName(content, title+".txt")
+	require.NoError(t, err)
+	blocks := []services.Block{{Type: "file", FileHash: fileData.Hash, OriginalName: title + ".txt", Extension: ".txt"}}
+	meta, err := contentService.BlocksToJSON(blocks)
+	require.NoError(t, err)
+	item, err := contentService.CreateItemWithTransaction(ctx, title, "", models.ItemTypeElement, meta, nil)
+	require.NoError(t, err)
+	require.NoError(t, contentService.SaveItemFiles(item.ID, blocks))
+	return item, fileData.Hash
+}
+
+// TestBackupRestore проверяет копию, восстановление базы и возврат удалённых файлов
+func TestBackupRestore(t *testing.T) {
+	ctx := context.Background()
+	service := setupBackupTest(t)
+
+	first, firstHash := createItem(t, "Первый", []byte("содержимое первого"))
+	report, err := service.Create(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.BlobsWritten)
+
+	// Состояние после копии: новый элемент, удалённый с диска файл первого
+	createItem(t, "Второй", []byte("содержимое второго"))
+	require.NoError(t, os.Remove(filesystem.GetFilePathByHash(firstHash)))
+
+	// Вторая копия дописывает только новый файл
+	time.Sleep(2 * time.Millisecond)
+	second, err := service.Create(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, second.BlobsWritten)
+	assert.Zero(t, second.BlobsSkipped)
+
+	snapshots, err := service.List()
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, second.Snapshot.ID, snapshots[0].ID, "новые копии первыми")
+	require.NoError(t, service.Verify(ctx, report.Snapshot.ID))
+
+	restored, err := service.RestoreAt(ctx, report.Snapshot.CreatedAt.Add(time.Millisecond))
+	require.NoError(t, err)
+	assert.Equal(t, report.Snapshot.ID, restored.Snapshot.ID)
+	assert.Equal(t, 1, restored.BlobsRestored)
+	assert.FileExists(t, restored.PreviousDB)
+
+	items, err := queries.GetAllItems()
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, first.ID, items[0].ID)
+	data, err := filesystem.ReadFile(firstHash)
+	require.NoError(t, err)
+	assert.Equal(t, "содержимое первого", string(data))
+}
+
+// TestBackupWrongPasswordAndCorruption проверяет, что неверный пароль и повреждённая копия
+// не меняют библиотеку
+func TestBackupWrongPasswordAndCorruption(t *testing.T) {
+	ctx := context.Background()
+	service := setupBackupTest(t)
+
+	createItem(t, "Элемент", []byte("данные"))
+	report, err := service.Create(ctx)
+	require.NoError(t, err)
+	createItem(t, "После копии", []byte("новые данные"))
+
+	wrong := NewServiceWithConfig(service.cfg)
+	wrong.SetPassword("other")
+	_, err = wrong.Restore(ctx, report.Snapshot.ID)
+	assert.ErrorIs(t, err, ErrWrongPassword)
+
+	_, err = service.Restore(ctx, "missing")
+	assert.ErrorIs(t, err, ErrSnapshotNotFound)
+
+	// Порча снимка базы обнаруживается до замены
+	path := filepath.Join(service.snapshotDir(report.Snapshot.ID), databaseFile)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	data[len(data)/2] ^= 0xff
+	require.NoError(t, os.WriteFile(path, data, 0600))
+
+	assert.Error(t, service.Verify(ctx, report.Snapshot.ID))
+	_, err = service.Restore(ctx, report.Snapshot.ID)
+	assert.Error(t, err)
+
+	items, err := queries.GetAllItems()
+	require.NoError(t, err)
+	assert.Len(t, items, 2, "библиотека не изменилась")
+}
+
+// TestBackupRetention проверяет правила хранения и удаление файлов, не нужных ни одной копии
+func TestBackupRetention(t *testing.T) {
+	day := func(daysAgo int) time.Time { return time.Now().AddDate(0, 0, -daysAgo) }
+	manifests := []*Manifest{
+		{ID: "5", CreatedAt: day(0)},
+		{ID: "4", CreatedAt: day(0).Add(-time.Minute)},
+		{ID: "3", CreatedAt: day(1)},
+		{ID: "2", CreatedAt: day(3)},
+		{ID: "1", CreatedAt: day(10)},
+	}
+
+	keep := retain(manifests, 2, 0, time.Now())
+	assert.Equal(t, map[string]bool{"5": true, "4": true}, keep)
+	keep = retain(manifests, 1, 5, time.Now())
+	assert.Equal(t, map[string]bool{"5": true, "3": true, "2": true}, keep)
+	assert.Len(t, retain(manifests, 0, 0, time.Now()), 5, "без правил хранятся все копии")
+
+	ctx := context.Background()
+	service := setupBackupTest(t)
+	service.cfg.KeepLast = 1
+
+	_, hash := createItem(t, "Элемент", []byte("данные"))
+	first, err := service.Create(ctx)
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(filesystem.GetFilePathByHash(hash)))
+
+	time.Sleep(2 * time.Millisecond)
+	second, err := service.Create(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, second.Pruned)
+
+	snapshots, err := service.List()
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+	assert.Equal(t, second.Snapshot.ID, snapshots[0].ID)
+	assert.NoDirExists(t, service.snapshotDir(first.Snapshot.ID))
+	assert.NoFileExists(t, service.blobPath(hash+".txt"), "файл нужен только удалённой копии")
+}
@@ -0,0 +1,287 @@
+package backup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"projectT/internal/services/crypto"
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/filesystem"
+	"projectT/internal/storage/vault"
+)
+
+// RestoreReport результат восстановления
+type RestoreReport struct {
+	Snapshot      *Snapshot
+	BlobsRestored int    // Файлы, возвращённые в хранилище
+	BlobsPresent  int    // Файлы, уже бывшие в хранилище
+	PreviousDB    string // Куда сохранена база, которая была до восстановления
+}
+
+// String возвращает итог одной строкой
+func (r *RestoreReport) String() string {
+	return fmt.Sprintf("восстановлена копия %s: файлов возвращено %d, уже были %d, прежняя база сохранена в %s",
+		r.Snapshot.ID, r.BlobsRestored, r.BlobsPresent, r.PreviousDB)
+}
+
+// RestoreAt восстанавливает последнюю копию, созданную не позже at
+func (s *Service) RestoreAt(ctx context.Context, at time.Time) (*RestoreReport, error) {
+	manifests, _, err := s.manifests()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range manifests {
+		if !m.CreatedAt.After(at) {
+			return s.Restore(ctx, m.ID)
+		}
+	}
+	return nil, fmt.Errorf("%w: нет копий на %s", ErrSnapshotNotFound, at.Local().Format("02.01.2006 15:04:05"))
+}
+
+// Restore заменяет базу данных снимком и возвращает в хранилище недостающие файлы.
+// Снимок целиком расшифровывается и проверяется (контрольные суммы, PRAGMA integrity_check,
+// версия схемы) до того, как текущая база будет заменена; при любой ошибке библиотека не меняется.
+func (s *Service) Restore(ctx context.Context, id string) (*RestoreReport, error) {
+	backupMu.Lock()
+	defer backupMu.Unlock()
+
+	manifest, err := s.readManifest(id)
+	if err != nil {
+		return nil, err
+	}
+	if s.cfg.Database == nil {
+		return nil, fmt.Errorf("не задана база данных для восстановления")
+	}
+	key, err := s.repositoryKey()
+	if err != nil {
+		return nil, err
+	}
+
+	// Проверка: база расшифровывается рядом с текущей, файлы - в директорию восстановления хранилища,
+	// чтобы замена была переименованием в пределах одного диска
+	dbPath := s.cfg.Database.GetPath()
+	stagedDB := dbPath + ".restore"
+	defer os.Remove(stagedDB)
+	if err := s.stageDatabase(manifest, key, stagedDB); err != nil {
+		return nil, err
+	}
+
+	stageDir := filesystem.GetRestoreDir()
+	defer os.RemoveAll(stageDir)
+	staged, present, err := s.stageBlobs(ctx, manifest, key, stageDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Замена: сначала файлы (лишние файлы безвредны и будут убраны сборщиком мусора), затем база
+	for _, blob := range staged {
+		target := blobTarget(blob.Name)
+		if err := filesystem.EnsureParentDir(target); err != nil {
+			return nil, fmt.Errorf("ошибка восстановления файла %s: %w", blob.Name, err)
+		}
+		if err := os.Rename(filepath.Join(stageDir, blob.Name), target); err != nil {
+			return nil, fmt.Errorf("ошибка восстановления файла %s: %w", blob.Name, err)
+		}
+	}
+
+	previous := fmt.Sprintf("%s.before-restore-%s", dbPath, time.Now().Format("20060102-150405"))
+	if err := s.swapDatabase(dbPath, stagedDB, previous); err != nil {
+		return nil, err
+	}
+
+	return &RestoreReport{
+		Snapshot:      &Snapshot{ID: manifest.ID, CreatedAt: manifest.CreatedAt, Size: manifest.Database.Size, Blobs: len(manifest.Blobs)},
+		BlobsRestored: len(staged),
+		BlobsPresent:  present,
+		PreviousDB:    previous,
+	}, nil
+}
+
+// Verify расшифровывает снимок во временные файлы и проверяет его, ничего не меняя в библиотеке
+func (s *Service) Verify(ctx context.Context, id string) error {
+	backupMu.Lock()
+	defer backupMu.Unlock()
+
+	manifest, err := s.readManifest(id)
+	if err != nil {
+		return err
+	}
+	key, err := s.repositoryKey()
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp(s.cfg.Dir, "verify-")
+	if err != nil {
+		return fmt.Errorf("ошибка создания временной директории: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := s.stageDatabase(manifest, key, filepath.Join(dir, "database.db")); err != nil {
+		return err
+	}
+	for _, blob := range manifest.Blobs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := decryptVerified(io.Discard, s.blobPath(blob.Name), key, blob.Hash); err != nil {
+			return fmt.Errorf("файл %s: %w", blob.Name, err)
+		}
+	}
+	return nil
+}
+
+// stageDatabase расшифровывает снимок базы в path и проверяет его целостность
+func (s *Service) stageDatabase(manifest *Manifest, key []byte, path string) error {
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("ошибка создания временной базы: %w", err)
+	}
+	err = decryptVerified(out, filepath.Join(s.snapshotDir(manifest.ID), databaseFile), key, manifest.Database.Hash)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("снимок базы данных: %w", err)
+	}
+
+	db, err := database.Open(path)
+	if err != nil {
+		return fmt.Errorf("снимок базы данных не открывается: %w", err)
+	}
+	defer db.Close()
+
+	var result string
+	if err := db.QueryRow(`PRAGMA integrity_check`).Scan(&result); err != nil {
+		return fmt.Errorf("ошибка проверки снимка базы данных: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("снимок базы данных повреждён: %s", result)
+	}
+
+	version, err := database.SchemaVersion(db)
+	if err != nil {
+		return fmt.Errorf("ошибка получения версии схемы снимка: %w", err)
+	}
+	if version > database.LatestSchemaVersion() {
+		return fmt.Errorf("%w: версия схемы снимка %d", database.ErrDatabaseTooNew, version)
+	}
+	return nil
+}
+
+// stageBlobs расшифровывает в dir файлы снимка, которых нет в хранилище, проверяя их хэши.
+// Возвращает расшифрованные файлы и число файлов, уже бывших в хранилище.
+func (s *Service) stageBlobs(ctx context.Context, manifest *Manifest, key []byte, dir string) ([]BlobRef, int, error) {
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, 0, fmt.Errorf("ошибка очистки директории восстановления: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, 0, fmt.Errorf("ошибка создания директории восстановления: %w", err)
+	}
+
+	var staged []BlobRef
+	present := 0
+	for _, blob := range manifest.Blobs {
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+		if filepath.Base(blob.Name) != blob.Name || len(blob.Name) < 2 {
+			return nil, 0, fmt.Errorf("неверное имя файла в копии: %q", blob.Name)
+		}
+		if blobPresent(blobTarget(blob.Name), blob.Size) {
+			present++
+			continue
+		}
+
+		path := filepath.Join(dir, blob.Name)
+		out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		if err != nil {
+			return nil, 0, fmt.Errorf("ошибка восстановления файла %s: %w", blob.Name, err)
+		}
+		err = decryptVerified(out, s.blobPath(blob.Name), key, blob.Hash)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("файл %s: %w", blob.Name, err)
+		}
+		// В зашифрованное хранилище файлы возвращаются зашифрованными
+		if vault.Unlocked() {
+			if _, err := filesystem.SealBlob(filesystem.BlobInfo{Hash: blob.Hash, Path: path}); err != nil {
+				return nil, 0, fmt.Errorf("ошибка шифрования файла %s: %w", blob.Name, err)
+			}
+		}
+		staged = append(staged, blob)
+	}
+	return staged, present, nil
+}
+
+// swapDatabase закрывает текущую базу, сохраняет её как previous, ставит на её место staged
+// и открывает заново с применением миграций
+func (s *Service) swapDatabase(dbPath, staged, previous string) error {
+	database.CloseDB()
+
+	if err := os.Rename(dbPath, previous); err != nil && !os.IsNotExist(err) {
+		database.InitDBWithConfig(s.cfg.Database)
+		return fmt.Errorf("ошибка сохранения текущей базы данных: %w", err)
+	}
+	// Журналы относятся к прежней базе
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		os.Remove(dbPath + suffix)
+	}
+	if err := os.Rename(staged, dbPath); err != nil {
+		os.Rename(previous, dbPath)
+		database.InitDBWithConfig(s.cfg.Database)
+		return fmt.Errorf("ошибка замены базы данных: %w", err)
+	}
+
+	database.InitDBWithConfig(s.cfg.Database)
+	if err := database.Migrate(database.GetDB()); err != nil {
+		return fmt.Errorf("ошибка миграции восстановленной базы данных: %w", err)
+	}
+	log.Printf("База данных восстановлена из резервной копии, прежняя сохранена в %s", previous)
+	return nil
+}
+
+// decryptVerified расшифровывает файл копии в dst и сверяет SHA-256 содержимого с hash
+func decryptVerified(dst io.Writer, path string, key []byte, hash string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("отсутствует в хранилище копий")
+		}
+		return err
+	}
+	defer in.Close()
+
+	hasher := sha256.New()
+	if err := crypto.DecryptStream(io.MultiWriter(dst, hasher), in, key); err != nil {
+		return err
+	}
+	if hex.EncodeToString(hasher.Sum(nil)) != hash {
+		return fmt.Errorf("контрольная сумма не совпадает")
+	}
+	return nil
+}
+
+// blobPresent проверяет, лежит ли файл в хранилище с ожидаемым размером открытого содержимого
+func blobPresent(path string, size int64) bool {
+	file, actual, err := filesystem.OpenBlobFile(path)
+	if err != nil {
+		return false
+	}
+	file.Close()
+	return actual == size
+}
+
+// blobTarget возвращает путь, по которому файл копии лежит в хранилище
+func blobTarget(name string) string {
+	return filepath.Join(filesystem.GetStorageRoot(), filesystem.GetFilesDir(), name[:2], name)
+}
@@ -0,0 +1,344 @@
+package backup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"projectT/internal/services/crypto"
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/filesystem"
+)
+
+// scheduleCheckInterval как часто планировщик проверяет, не пора ли создать копию
+const scheduleCheckInterval = time.Hour
+
+// CreateReport результат создания копии
+type CreateReport struct {
+	Snapshot     *Snapshot
+	BlobsWritten int      // Новые файлы, записанные в хранилище копий
+	BlobsSkipped int      // Файлы, уже бывшие в хранилище копий
+	Corrupted    []string // Файлы, содержимое которых не совпадает с хэшем: в копию не попали
+	Pruned       int      // Старые копии, удалённые по правилам хранения
+	Duration     time.Duration
+}
+
+// String возвращает итог одной строкой
+func (r *CreateReport) String() string {
+	s := fmt.Sprintf("копия %s: новых файлов %d, уже были %d, удалено старых копий %d",
+		r.Snapshot.ID, r.BlobsWritten, r.BlobsSkipped, r.Pruned)
+	if len(r.Corrupted) > 0 {
+		s += fmt.Sprintf("; повреждённых файлов пропущено: %d", len(r.Corrupted))
+	}
+	return s
+}
+
+// Create создаёт снимок базы данных и дописывает в хранилище копий новые файлы,
+// после чего удаляет копии, не подпадающие под правила хранения
+func (s *Service) Create(ctx context.Context) (*CreateReport, error) {
+	backupMu.Lock()
+	defer backupMu.Unlock()
+
+	started := time.Now()
+	key, err := s.repositoryKey()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	id := now.Format(snapshotIDLayout)
+	if _, err := os.Stat(s.snapshotDir(id)); err == nil {
+		return nil, fmt.Errorf("резервная копия %s уже существует", id)
+	}
+
+	partial := s.snapshotDir(id) + partialExt
+	if err := os.MkdirAll(partial, 0700); err != nil {
+		return nil, fmt.Errorf("ошибка создания директории копии: %w", err)
+	}
+	defer os.RemoveAll(partial)
+
+	manifest := &Manifest{Version: FormatVersion, ID: id, CreatedAt: now}
+	manifest.Database, err = s.snapshotDatabase(ctx, key, partial)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CreateReport{}
+	if err := s.copyBlobs(ctx, key, manifest, report); err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(partial, manifestFile), data, 0600); err != nil {
+		return nil, fmt.Errorf("ошибка записи манифеста копии: %w", err)
+	}
+	// Снимок появляется в списке только целиком
+	if err := os.Rename(partial, s.snapshotDir(id)); err != nil {
+		return nil, fmt.Errorf("ошибка сохранения копии: %w", err)
+	}
+
+	report.Snapshot = &Snapshot{ID: id, CreatedAt: now, Size: manifest.Database.Size, Blobs: len(manifest.Blobs)}
+	report.Pruned, err = s.prune()
+	if err != nil {
+		return report, fmt.Errorf("копия создана, но старые копии не удалены: %w", err)
+	}
+	report.Duration = time.Since(started)
+	return report, nil
+}
+
+// snapshotDatabase снимает согласованную копию базы через VACUUM INTO и шифрует её в dir
+func (s *Service) snapshotDatabase(ctx context.Context, key []byte, dir string) (BlobRef, error) {
+	plain := filepath.Join(dir, "database.db")
+	if _, err := database.GetDB().ExecContext(ctx, `VACUUM INTO ?`, plain); err != nil {
+		return BlobRef{}, fmt.Errorf("ошибка снимка базы данных: %w", err)
+	}
+	defer os.Remove(plain)
+
+	hash, size, err := encryptFile(filepath.Join(dir, databaseFile), plain, key)
+	if err != nil {
+		return BlobRef{}, fmt.Errorf("ошибка шифрования снимка базы данных: %w", err)
+	}
+	return BlobRef{Name: databaseFile, Hash: hash, Size: size}, nil
+}
+
+// copyBlobs добавляет в манифест все файлы хранилища и шифрует в хранилище копий те, которых там ещё нет
+func (s *Service) copyBlobs(ctx context.Context, key []byte, manifest *Manifest, report *CreateReport) error {
+	blobs, err := filesystem.ListBlobs()
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for _, blob := range blobs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		name := filepath.Base(blob.Path)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		target := s.blobPath(name)
+		if info, err := os.Stat(target); err == nil && info.Size() > 0 {
+			manifest.Blobs = append(manifest.Blobs, BlobRef{Name: name, Hash: blob.Hash, Size: blob.Size})
+			report.BlobsSkipped++
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			return fmt.Errorf("ошибка создания директории копии: %w", err)
+		}
+		hash, size, err := encryptFile(target, blob.Path, key)
+		if err != nil {
+			return fmt.Errorf("ошибка копирования файла %s: %w", name, err)
+		}
+		if hash != blob.Hash {
+			// Файл на диске повреждён: такая копия не восстановила бы исходное содержимое
+			os.Remove(target)
+			report.Corrupted = append(report.Corrupted, name)
+			continue
+		}
+		manifest.Blobs = append(manifest.Blobs, BlobRef{Name: name, Hash: hash, Size: size})
+		report.BlobsWritten++
+	}
+	return nil
+}
+
+// Prune удаляет копии по правилам хранения и файлы, на которые не ссылается ни одна копия
+func (s *Service) Prune() (int, error) {
+	backupMu.Lock()
+	defer backupMu.Unlock()
+	return s.prune()
+}
+
+func (s *Service) prune() (int, error) {
+	manifests, unreadable, err := s.manifests()
+	if err != nil {
+		return 0, err
+	}
+
+	keep := retain(manifests, s.cfg.KeepLast, s.cfg.KeepDays, time.Now())
+	removed := 0
+	referenced := make(map[string]bool)
+	for _, m := range manifests {
+		if !keep[m.ID] {
+			if err := os.RemoveAll(s.snapshotDir(m.ID)); err != nil {
+				return removed, fmt.Errorf("ошибка удаления копии %s: %w", m.ID, err)
+			}
+			removed++
+			continue
+		}
+		for _, blob := range m.Blobs {
+			referenced[blob.Name] = true
+		}
+	}
+
+	// Недописанные снимки прерванных копий. prune выполняется под backupMu, поэтому текущих среди них нет.
+	entries, _ := os.ReadDir(filepath.Join(s.cfg.Dir, snapshotsDir))
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), partialExt) {
+			os.RemoveAll(filepath.Join(s.cfg.Dir, snapshotsDir, entry.Name()))
+		}
+	}
+
+	if unreadable > 0 {
+		// Неизвестно, на какие файлы ссылаются снимки с повреждённым манифестом
+		return removed, nil
+	}
+	err = filepath.WalkDir(filepath.Join(s.cfg.Dir, blobsDir), func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		name := strings.TrimSuffix(entry.Name(), encExt)
+		if !referenced[name] || strings.HasSuffix(entry.Name(), partialExt) {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return removed, fmt.Errorf("ошибка очистки файлов резервных копий: %w", err)
+	}
+	return removed, nil
+}
+
+// retain возвращает ID копий, которые нужно сохранить: keepLast последних и по одной (самой новой)
+// за каждый из keepDays последних дней. Если оба правила отключены, хранятся все копии.
+func retain(manifests []*Manifest, keepLast, keepDays int, now time.Time) map[string]bool {
+	keep := make(map[string]bool)
+	if keepLast <= 0 && keepDays <= 0 {
+		for _, m := range manifests {
+			keep[m.ID] = true
+		}
+		return keep
+	}
+
+	// manifests отсортированы от новых к старым
+	for i, m := range manifests {
+		if i < keepLast {
+			keep[m.ID] = true
+		}
+	}
+	if keepDays > 0 {
+		since := now.Local().AddDate(0, 0, -keepDays)
+		days := make(map[string]bool)
+		for _, m := range manifests {
+			created := m.CreatedAt.Local()
+			if created.Before(since) {
+				continue
+			}
+			day := created.Format("2006-01-02")
+			if !days[day] {
+				days[day] = true
+				keep[m.ID] = true
+			}
+		}
+	}
+	return keep
+}
+
+// StartScheduled создаёт копию, если последняя старше interval, и дальше проверяет это в фоне.
+// Без пароля или с нулевым интервалом расписание не запускается.
+func (s *Service) StartScheduled(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	if s.cfg.Password == "" {
+		log.Printf("Резервное копирование по расписанию отключено: %v", ErrNoPassword)
+		return
+	}
+
+	check := func() {
+		// После переключения библиотеки копии идут в её директорию
+		s := s
+		if s.defaults {
+			s = NewService()
+		}
+		snapshots, err := s.List()
+		if err != nil {
+			log.Printf("Ошибка резервного копирования: %v", err)
+			return
+		}
+		if len(snapshots) > 0 && time.Since(snapshots[0].CreatedAt) < interval {
+			return
+		}
+		report, err := s.Create(context.Background())
+		if err != nil {
+			log.Printf("Ошибка резервного копирования: %v", err)
+			return
+		}
+		log.Printf("Резервное копирование: %s", report)
+	}
+
+	go func() {
+		check()
+		ticker := time.NewTicker(scheduleCheckInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			check()
+		}
+	}()
+}
+
+// encryptFile шифрует файл src в dst и возвращает SHA-256 и размер исходного содержимого
+func encryptFile(dst, src string, key []byte) (string, int64, error) {
+	// Файлы зашифрованного хранилища копируются по открытому содержимому
+	in, _, err := filesystem.OpenBlobFile(src)
+	if err != nil {
+		return "", 0, err
+	}
+	defer in.Close()
+
+	tmp := dst + partialExt
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", 0, err
+	}
+
+	hasher := sha256.New()
+	counter := &countingReader{r: io.TeeReader(in, hasher)}
+	err = crypto.EncryptStream(out, counter, key)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return "", 0, err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return "", 0, err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), counter.n, nil
+}
+
+// countingReader считает прочитанные байты
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -14,12 +14,14 @@ import (
 	p2p "projectT/internal/services/p2p"
 	p2pnet "projectT/internal/services/p2p/network"
 	"projectT/internal/storage/database/models"
-	"projectT/internal/storage/database/queries"
+	"projectT/internal/storage/database/repository"
 )
 
 // ContactService сервис для управления контактами
 type ContactService struct {
 	p2pNetwork *p2pnet.P2PNetwork
+	contacts   repository.Contacts
+	profiles   repository.Profiles
 }
 
 // ContactWithStatus контакт с расширенной информацией о статусе
@@ -30,10 +32,12 @@ type ContactWithStatus struct {
 	LastPing      time.Duration `json:"last_ping,omitempty"`
 }
 
-// NewContactService создаёт сервис управления контактами
-func NewContactService(p2pNetwork *p2pnet.P2PNetwork) *ContactService {
+// NewContactService создаёт сервис управления контактами над репозиториями контактов и профилей
+func NewContactService(p2pNetwork *p2pnet.P2PNetwork, contacts repository.Contacts, profiles repository.Profiles) *ContactService {
 	return &ContactService{
 		p2pNetwork: p2pNetwork,
+		contacts:   contacts,
+		profiles:   profiles,
 	}
 }
 
@@ -46,14 +50,14 @@ func (s *ContactService) AddContactByAddress(addrStr string, notes string) (*mod
 	}
 
 	// Проверяем, не существует ли уже контакт с таким PeerID
-	existing, err := queries.GetContactByPeerID(addr.PeerID)
+	existing, err := s.contacts.GetContactByPeerID(addr.PeerID)
 	if err == nil && existing != nil {
 		return nil, errors.New("контакт с таким PeerID уже существует")
 	}
 
 	// Создаём профиль для контакта если он ещё не существует
 	username := addr.PeerID[:8] // Первые 8 символов как временное имя
-	if err := queries.EnsureProfileForContact(addr.PeerID, username, ""); err != nil {
+	if err := s.profiles.EnsureProfileForContact(addr.PeerID, username, ""); err != nil {
 		log.Printf("Предупреждение: не удалось создать профиль: %v", err)
 	}
 
@@ -65,7 +69,7 @@ func (s *ContactService) AddContactByAddress(addrStr string, notes string) (*mod
 		IsBlocked: false,
 	}
 
-	if err := queries.CreateContact(contact); err != nil {
+	if err := s.contacts.CreateContact(contact); err != nil {
 		return nil, fmt.Errorf("ошибка создания контакта: %w", err)
 	}
 
@@ -76,13 +80,13 @@ func (s *ContactService) AddContactByAddress(addrStr string, notes string) (*mod
 // AddContactByPeerID добавляет контакт по PeerID (если уже есть в peerstore)
 func (s *ContactService) AddContactByPeerID(peerID, username, multiaddr string, notes string) (*models.Contact, error) {
 	// Проверяем, не существует ли уже
-	existing, err := queries.GetContactByPeerID(peerID)
+	existing, err := s.contacts.GetContactByPeerID(peerID)
 	if err == nil && existing != nil {
 		return nil, errors.New("контакт с таким PeerID уже существует")
 	}
 
 	// Создаём профиль для контакта если он ещё не существует
-	if err := queries.EnsureProfileForContact(peerID, username, ""); err != nil {
+	if err := s.profiles.EnsureProfileForContact(peerID, username, ""); err != nil {
 		log.Printf("Предупреждение: не удалось создать профиль: %v", err)
 	}
 
@@ -93,7 +97,7 @@ func (s *ContactService) AddContactByPeerID(peerID, username, multiaddr string,
 		IsBlocked: false,
 	}
 
-	if err := queries.CreateContact(contact); err != nil {
+	if err := s.contacts.CreateContact(contact); err != nil {
 		return nil, fmt.Errorf("ошибка создания контакта: %w", err)
 	}
 
@@ -103,7 +107,7 @@ func (s *ContactService) AddContactByPeerID(peerID, username, multiaddr string,
 
 // GetContact получает контакт по ID
 func (s *ContactService) GetContact(id int) (*ContactWithStatus, error) {
-	contact, err := queries.GetContact(id)
+	contact, err := s.contacts.GetContact(id)
 	if err != nil {
 		return nil, err
 	}
@@ -113,7 +117,7 @@ func (s *ContactService) GetContact(id int) (*ContactWithStatus, error) {
 
 // GetContactByPeerID получает контакт по PeerID
 func (s *ContactService) GetContactByPeerID(peerID string) (*ContactWithStatus, error) {
-	contact, err := queries.GetContactByPeerID(peerID)
+	contact, err := s.contacts.GetContactByPeerID(peerID)
 	if err != nil {
 		return nil, err
 	}
@@ -123,7 +127,7 @@ func (s *ContactService) GetContactByPeerID(peerID string) (*ContactWithStatus,
 
 // GetAllContacts получает все контакты с информацией о статусе
 func (s *ContactService) GetAllContacts() ([]*ContactWithStatus, error) {
-	contacts, err := queries.GetAllContacts()
+	contacts, err := s.contacts.GetAllContacts()
 	if err != nil {
 		return nil, err
 	}
@@ -174,7 +178,7 @@ func (s *ContactService) parsePeerID(peerIDStr string) (peer.ID, error) {
 
 // UpdateContact обновляет контакт
 func (s *ContactService) UpdateContact(id int, multiaddr, notes string) error {
-	contact, err := queries.GetContact(id)
+	contact, err := s.contacts.GetContact(id)
 	if err != nil {
 		return err
 	}
@@ -182,29 +186,29 @@ func (s *ContactService) UpdateContact(id int, multiaddr, notes string) error {
 	contact.Multiaddr = multiaddr
 	contact.Notes = notes
 
-	return queries.UpdateContact(contact)
+	return s.contacts.UpdateContact(contact)
 }
 
 // DeleteContact удаляет контакт по ID
 func (s *ContactService) DeleteContact(id int) error {
-	contact, err := queries.GetContact(id)
+	contact, err := s.contacts.GetContact(id)
 	if err != nil {
 		return err
 	}
 
 	log.Printf("Удаление контакта: %s (%s)", contact.Username, contact.PeerID)
-	return queries.DeleteContact(id)
+	return s.contacts.DeleteContact(id)
 }
 
 // BlockContact блокирует контакт
 func (s *ContactService) BlockContact(id int) error {
-	contact, err := queries.GetContact(id)
+	contact, err := s.contacts.GetContact(id)
 	if err != nil {
 		return err
 	}
 
 	contact.IsBlocked = true
-	if err := queries.UpdateContact(contact); err != nil {
+	if err := s.contacts.UpdateContact(contact); err != nil {
 		return err
 	}
 
@@ -214,13 +218,13 @@ func (s *ContactService) BlockContact(id int) error {
 
 // UnblockContact разблокирует контакт
 func (s *ContactService) UnblockContact(id int) error {
-	contact, err := queries.GetContact(id)
+	contact, err := s.contacts.GetContact(id)
 	if err != nil {
 		return err
 	}
 
 	contact.IsBlocked = false
-	if err := queries.UpdateContact(contact); err != nil {
+	if err := s.contacts.UpdateContact(contact); err != nil {
 		return err
 	}
 
@@ -230,12 +234,12 @@ func (s *ContactService) UnblockContact(id int) error {
 
 // IsContactBlocked проверяет, заблокирован ли контакт
 func (s *ContactService) IsContactBlocked(peerID string) (bool, error) {
-	return queries.IsContactBlocked(peerID)
+	return s.contacts.IsContactBlocked(peerID)
 }
 
 // SearchContacts ищет контакты по имени
 func (s *ContactService) SearchContacts(query string) ([]*ContactWithStatus, error) {
-	contacts, err := queries.SearchContacts(query)
+	contacts, err := s.contacts.SearchContacts(query)
 	if err != nil {
 		return nil, err
 	}
@@ -256,7 +260,7 @@ func (s *ContactService) RefreshContactStatus(id int) error {
 
 	// Обновляем время последней активности контакта
 	now := time.Now()
-	return queries.UpdateContactLastSeen(id, &now)
+	return s.contacts.UpdateContactLastSeen(id, &now)
 }
 
 // RefreshAllContactsStatuses обновляет статусы всех контактов
@@ -277,7 +281,7 @@ func (s *ContactService) RefreshAllContactsStatuses() {
 
 // SendMessage отправляет сообщение контакту
 func (s *ContactService) SendMessage(contactID int, content string) error {
-	contact, err := queries.GetContact(contactID)
+	contact, err := s.contacts.GetContact(contactID)
 	if err != nil {
 		return err
 	}
@@ -303,7 +307,7 @@ func (s *ContactService) SendMessage(contactID int, content string) error {
 
 // SendFileMessage отправляет файл контакту
 func (s *ContactService) SendFileMessage(contactID int, filePath, fileName, mimeType string) error {
-	contact, err := queries.GetContact(contactID)
+	contact, err := s.contacts.GetContact(contactID)
 	if err != nil {
 		return err
 	}
@@ -371,7 +375,7 @@ func (s *ContactService) ImportContactFromP2PAddress(addrStr string, notes strin
 		if err := s.p2pNetwork.ConnectToPeer(ctx, addrStr); err == nil {
 			// Обновляем время последней активности
 			now := time.Now()
-			_ = queries.UpdateContactLastSeen(contact.ID, &now)
+			_ = s.contacts.UpdateContactLastSeen(contact.ID, &now)
 		}
 	}
 
@@ -380,7 +384,7 @@ func (s *ContactService) ImportContactFromP2PAddress(addrStr string, notes strin
 
 // ExportContactAddress экспортирует адрес контакта
 func (s *ContactService) ExportContactAddress(id int) (string, error) {
-	contact, err := queries.GetContact(id)
+	contact, err := s.contacts.GetContact(id)
 	if err != nil {
 		return "", err
 	}
@@ -4,7 +4,9 @@ package contacts
 import (
 	"testing"
 
+	"projectT/internal/storage/database"
 	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/repository"
 )
 
 // TestContactService_Stats тестирует статистику контактов
@@ -59,7 +61,7 @@ func TestContactWithStatus(t *testing.T) {
 // TestContactService_Creation тестирует создание сервиса
 func TestContactService_Creation(t *testing.T) {
 	// Создаём сервис без P2P сети (для тестов)
-	service := NewContactService(nil)
+	service := NewContactService(nil, nil, nil)
 
 	if service == nil {
 		t.Fatal("Сервис не создан")
@@ -72,7 +74,7 @@ func TestContactService_Creation(t *testing.T) {
 
 // TestContactService_ParsePeerID тестирует парсинг PeerID
 func TestContactService_ParsePeerID(t *testing.T) {
-	service := NewContactService(nil)
+	service := NewContactService(nil, nil, nil)
 
 	// Валидные PeerID (base58 encoded)
 	tests := []struct {
@@ -191,3 +193,35 @@ func TestContactWithStatus_Enrich(t *testing.T) {
 		t.Error("Ожидалось IsOnline=false")
 	}
 }
+
+// TestContactService_OwnDatabase проверяет, что сервис работает с переданными ему репозиториями,
+// а не с глобальной базой
+func TestContactService_OwnDatabase(t *testing.T) {
+	db, err := database.Open(":memory:")
+	if err != nil {
+		t.Fatalf("Ошибка открытия БД: %v", err)
+	}
+	defer db.Close()
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Ошибка миграции БД: %v", err)
+	}
+
+	repos := repository.NewSQLite(db)
+	service := NewContactService(nil, repos.Contacts, repos.Profiles)
+
+	const peerID = "12D3KooWQYhTNQdmr3ArTeUHRYzFg94BKyTkoWBDWez9kSCVe2Xo"
+	if _, err := service.AddContactByPeerID(peerID, "friend", "", "заметка"); err != nil {
+		t.Fatalf("Ошибка добавления контакта: %v", err)
+	}
+
+	contact, err := repos.Contacts.GetContactByPeerID(peerID)
+	if err != nil {
+		t.Fatalf("Контакт не сохранён в переданной базе: %v", err)
+	}
+	if contact.Notes != "заметка" {
+		t.Errorf("Ожидалась заметка 'заметка', получено '%s'", contact.Notes)
+	}
+	if exists, err := repos.Profiles.ProfileExists(peerID); err != nil || !exists {
+		t.Errorf("Профиль контакта не создан в переданной базе: %v", err)
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"strings"
 	"time"
 
+	"projectT/internal/services/linkpreview"
+	"projectT/internal/services/thumbnail"
 	"projectT/internal/storage/database/models"
 	"projectT/internal/storage/database/queries"
 	"projectT/internal/storage/filesystem"
@@ -24,6 +26,26 @@ type Block struct {
 	OriginalName string `json:"original_name,omitempty"`
 	Extension    string `json:"extension,omitempty"`
 	Description  string `json:"description,omitempty"`
+	// Preview превью ссылки; заполняется в фоне сервисом linkpreview
+	Preview *models.LinkPreview `json:"preview,omitempty"`
+	// Snapshot сохранённая копия страницы ссылки; создаётся по запросу сервисом snapshot
+	Snapshot *models.PageSnapshot `json:"snapshot,omitempty"`
+}
+
+// FileHashes возвращает хэши файлов блока: файла блока и сохранённой копии страницы ссылки
+func (b Block) FileHashes() []string {
+	var hashes []string
+	if b.FileHash != "" {
+		hashes = append(hashes, b.FileHash)
+	}
+	if b.Snapshot != nil {
+		for _, hash := range []string{b.Snapshot.FileHash, b.Snapshot.TextHash} {
+			if hash != "" {
+				hashes = append(hashes, hash)
+			}
+		}
+	}
+	return hashes
 }
 
 // ContentBlocksService предоставляет методы для работы с блоками контента
@@ -58,30 +80,20 @@ func (s *ContentBlocksService) ProcessFileData(selectedFiles *[]string, linkEntr
 		fmt.Printf("Файл успешно прочитан, размер: %d байт\n", len(fileBytes))
 
 		// Сохраняем в файловую систему
-		fileData, err := filesystem.SaveFileWithOriginalName(fileBytes, filepath)
+		block, err := s.saveFileBlock(fileBytes, filepath)
 		if err != nil {
 			fmt.Printf("Ошибка сохранения файла %s: %v\n", filepath, err)
-			return Block{}, fmt.Errorf("ошибка сохранения файла %s: %v", filepath, err)
+			return Block{}, err
 		}
-		fmt.Printf("Файл успешно сохранен с хешем: %s\n", fileData.Hash)
-
-		return Block{
-			Type:         blockType,
-			FileHash:     fileData.Hash,
-			OriginalName: path.Base(filepath),
-			Extension:    strings.TrimPrefix(path.Ext(filepath), "."),
-		}, nil
+		fmt.Printf("Файл успешно сохранен с хешем: %s\n", block.FileHash)
+		return block, nil
 	}
 
 	// Обрабатываем изображения (в данном случае все файлы из selectedFiles)
 	for i, filepath := range *selectedFiles {
 		fmt.Printf("Обрабатываем файл %d/%d: %s\n", i+1, len(*selectedFiles), filepath)
-		ext := strings.ToLower(strings.TrimPrefix(path.Ext(filepath), "."))
-		blockType := "file"
-		if ext == "jpg" || ext == "jpeg" || ext == "png" || ext == "gif" || ext == "bmp" {
-			blockType = "image"
-		}
-		fmt.Printf("Расширение файла: %s, определенный тип: %s\n", ext, blockType)
+		blockType := fileBlockType(filepath)
+		fmt.Printf("Определенный тип: %s\n", blockType)
 
 		block, err := processSingleFile(filepath, blockType)
 		if err != nil {
@@ -112,6 +124,35 @@ func (s *ContentBlocksService) ProcessFileData(selectedFiles *[]string, linkEntr
 	return blocks, errors
 }
 
+// saveFileBlock сохраняет содержимое файла name в хранилище и возвращает его блок
+func (s *ContentBlocksService) saveFileBlock(data []byte, name string) (Block, error) {
+	// Имя может прийти из API: от него нужны только базовое имя и расширение
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" {
+		name = ""
+	}
+	fileData, err := filesystem.SaveFileWithOriginalName(data, name)
+	if err != nil {
+		return Block{}, fmt.Errorf("ошибка сохранения файла %s: %v", name, err)
+	}
+	return Block{
+		Type:         fileBlockType(name),
+		FileHash:     fileData.Hash,
+		OriginalName: name,
+		Extension:    strings.TrimPrefix(path.Ext(name), "."),
+	}, nil
+}
+
+// fileBlockType возвращает тип блока файла по расширению: изображение или файл
+func fileBlockType(name string) string {
+	switch strings.ToLower(strings.TrimPrefix(path.Ext(name), ".")) {
+	case "jpg", "jpeg", "png", "gif", "bmp":
+		return "image"
+	default:
+		return "file"
+	}
+}
+
 // BlocksToJSON конвертирует блоки в JSON строку
 func (s *ContentBlocksService) BlocksToJSON(blocks []Block) (string, error) {
 	if len(blocks) == 0 {
@@ -160,25 +201,29 @@ func (s *ContentBlocksService) DetermineItemType(description string, blocks []Bl
 	return models.ItemTypeElement
 }
 
-// CleanupOldFiles удаляет неиспользуемые файлы
-func (s *ContentBlocksService) CleanupOldFiles(oldBlocks, newBlocks []Block) {
+// CleanupOldFiles возвращает хэши файлов, которые элемент больше не использует.
+// С диска файлы не удаляются: блоб может использоваться другим элементом или сообщением чата,
+// неиспользуемые блобы удаляет FileGCService.
+func (s *ContentBlocksService) CleanupOldFiles(oldBlocks, newBlocks []Block) []string {
 	// Создаем мапу новых хэшей
 	newHashes := make(map[string]bool)
 	for _, block := range newBlocks {
-		if block.FileHash != "" {
-			newHashes[block.FileHash] = true
+		for _, hash := range block.FileHashes() {
+			newHashes[hash] = true
 		}
 	}
 
-	// Удаляем старые файлы, которых нет в новых
+	// Собираем старые файлы, которых нет в новых
+	var unused []string
 	for _, block := range oldBlocks {
-		if block.FileHash != "" && !newHashes[block.FileHash] {
-			if err := filesystem.DeleteFile(block.FileHash); err != nil {
-				// Логируем, но не прерываем
-				fmt.Printf("WARN: ошибка удаления файла %s: %v\n", block.FileHash, err)
+		for _, hash := range block.FileHashes() {
+			if !newHashes[hash] {
+				unused = append(unused, hash)
+				newHashes[hash] = true
 			}
 		}
 	}
+	return unused
 }
 
 // CreateItemWithTransaction создает элемент в транзакции
@@ -255,6 +300,11 @@ func (s *ContentBlocksService) UpdateItemWithTransaction(ctx context.Context, it
 		}
 	}
 
+	// Сохраняем текущее состояние в историю до изменения
+	if _, err := queries.CreateItemRevisionTx(ctx, tx, itemID, MaxRevisionsPerItem()); err != nil {
+		return nil, nil, fmt.Errorf("ошибка сохранения ревизии: %w", err)
+	}
+
 	// Генерируем новый content_hash
 	newContentHash := filesystem.GenerateContentHash(title, description, contentMeta)
 
@@ -284,21 +334,25 @@ func (s *ContentBlocksService) UpdateItemWithTransaction(ctx context.Context, it
 	return &item, oldBlocks, nil
 }
 
-// SaveItemFiles сохраняет информацию о файлах элемента в таблицу item_files
+// SaveItemFiles сохраняет информацию о файлах элемента в таблицу item_files, включая сохранённые копии страниц
 func (s *ContentBlocksService) SaveItemFiles(itemID int, blocks []Block) error {
 	for _, block := range blocks {
-		if block.FileHash != "" {
+		// Превью ссылок загружаются в фоне, чтобы карточка показала их вскоре после сохранения
+		if block.Type == "link" {
+			linkpreview.Get().Schedule(itemID)
+		}
+		for _, hash := range block.FileHashes() {
 			// Получаем информацию о файле
-			fileInfo, err := filesystem.GetFileInfo(block.FileHash)
+			fileInfo, err := filesystem.GetFileInfo(hash)
 			if err != nil {
-				fmt.Printf("WARN: не удалось получить информацию о файле %s: %v\n", block.FileHash, err)
+				fmt.Printf("WARN: не удалось получить информацию о файле %s: %v\n", hash, err)
 				continue
 			}
 
 			// Создаём запись в item_files
 			itemFile := &models.ItemFile{
 				ItemID:       itemID,
-				Hash:         block.FileHash,
+				Hash:         hash,
 				FilePath:     fileInfo.Path,
 				Size:         fileInfo.Size,
 				MimeType:     fileInfo.MimeType,
@@ -310,7 +364,12 @@ func (s *ContentBlocksService) SaveItemFiles(itemID int, blocks []Block) error {
 				fmt.Printf("WARN: ошибка сохранения файла в item_files: %v\n", err)
 				// Не прерываем процесс, продолжаем с остальными файлами
 			} else {
-				fmt.Printf("Файл сохранён в item_files: %s\n", block.FileHash)
+				fmt.Printf("Файл сохранён в item_files: %s\n", hash)
+			}
+
+			// Миниатюры изображений строятся заранее, чтобы карточка не ждала их при первом показе
+			if hash == block.FileHash && strings.HasPrefix(fileInfo.MimeType, "image/") {
+				thumbnail.Get().Schedule(hash)
 			}
 		}
 	}
@@ -393,3 +452,77 @@ func (s *ContentBlocksService) RemoveLinksFromText(text string, links []string)
 
 	return result
 }
+
+// ItemInput данные нового элемента в том виде, в каком их вводят в окне создания элемента.
+// Пути Files читаются с диска этого компьютера, поэтому их задаёт только локальный код
+// (окно создания, командная строка без узла) и в JSON они не попадают: через API файлы
+// передаются содержимым в Uploads.
+type ItemInput struct {
+	Title       string          `json:"title"`
+	Description string          `json:"description,omitempty"` // Ссылки из описания выносятся в блоки ссылок
+	Type        models.ItemType `json:"type,omitempty"`        // По умолчанию element
+	ParentID    *int            `json:"parent_id,omitempty"`
+	Files       []string        `json:"-"`                 // Пути к файлам, которые копируются в хранилище
+	Uploads     []FileUpload    `json:"uploads,omitempty"` // Файлы, переданные содержимым
+	Links       []string        `json:"links,omitempty"`
+	Tags        string          `json:"tags,omitempty"` // Теги через запятую
+}
+
+// FileUpload файл нового элемента, переданный содержимым; в JSON Data кодируется в base64
+type FileUpload struct {
+	Name string `json:"name"`
+	Data []byte `json:"data"`
+}
+
+// CreateItem создает элемент из введённых данных: сохраняет файлы в хранилище, собирает блоки контента,
+// создает элемент с его файлами и тегами. Так создаются элементы в окне создания, через API и из командной строки,
+// поэтому результат не зависит от того, откуда элемент добавлен.
+func (s *ContentBlocksService) CreateItem(ctx context.Context, input ItemInput) (*models.Item, error) {
+	itemType := input.Type
+	if itemType == "" {
+		itemType = models.ItemTypeElement
+	}
+	if itemType != models.ItemTypeElement && itemType != models.ItemTypeFolder {
+		return nil, fmt.Errorf("неизвестный тип элемента: %s", itemType)
+	}
+
+	linksFromDescription := s.ExtractLinks(input.Description)
+	description := s.RemoveLinksFromText(input.Description, linksFromDescription)
+	links := append(linksFromDescription, input.Links...)
+
+	var blocks []Block
+	for _, upload := range input.Uploads {
+		block, err := s.saveFileBlock(upload.Data, upload.Name)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка обработки файлов: %w", err)
+		}
+		blocks = append(blocks, block)
+	}
+	files := append([]string(nil), input.Files...)
+	fileBlocks, processingErrors := s.ProcessFileData(&files, links)
+	if len(processingErrors) > 0 {
+		return nil, fmt.Errorf("ошибка обработки файлов: %v", processingErrors[0])
+	}
+	blocks = append(blocks, fileBlocks...)
+
+	contentMeta, err := s.BlocksToJSON(blocks)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сериализации контента: %v", err)
+	}
+
+	item, err := s.CreateItemWithTransaction(ctx, input.Title, description, itemType, contentMeta, input.ParentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.SaveItemFiles(item.ID, blocks); err != nil {
+		fmt.Printf("WARN: ошибка сохранения файлов: %v\n", err)
+	}
+
+	if input.Tags != "" {
+		if err := s.ProcessTags(ctx, item.ID, input.Tags); err != nil {
+			return item, err
+		}
+	}
+	return item, nil
+}
@@ -0,0 +1,211 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// streamChunkSize размер открытого текста в одном фрагменте потока
+	streamChunkSize = 64 << 10
+	// streamPrefixSize случайная часть nonce; остальные 4 байта - номер фрагмента
+	streamPrefixSize = 8
+	// KeySize размер ключа потокового шифрования
+	KeySize = 32
+)
+
+// EncryptedStreamMarker маркер зашифрованного потока ("TPSE" - ProjectT Stream Encrypted)
+var EncryptedStreamMarker = []byte{0x54, 0x50, 0x53, 0x45}
+
+// ErrStreamCorrupted поток повреждён, обрезан или зашифрован другим ключом
+var ErrStreamCorrupted = errors.New("зашифрованные данные повреждены или ключ неверный")
+
+// GenerateKey создаёт случайный ключ потокового шифрования
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("ошибка генерации ключа: %w", err)
+	}
+	return key, nil
+}
+
+// EncryptStream шифрует src в dst фрагментами AES-256-GCM, не загружая данные в память целиком.
+// Формат: marker (4) + nonce prefix (8) + фрагменты; фрагмент: флаг последнего (1) + длина (4) + ciphertext.
+// Флаг входит в аутентифицируемые данные, поэтому обрезанный поток не расшифруется.
+func EncryptStream(dst io.Writer, src io.Reader, key []byte) error {
+	gcm, err := newStreamGCM(key)
+	if err != nil {
+		return err
+	}
+
+	prefix := make([]byte, streamPrefixSize)
+	if _, err := io.ReadFull(rand.Reader, prefix); err != nil {
+		return fmt.Errorf("ошибка генерации nonce: %w", err)
+	}
+	if _, err := dst.Write(append(append([]byte{}, EncryptedStreamMarker...), prefix...)); err != nil {
+		return err
+	}
+
+	plain := make([]byte, streamChunkSize)
+	header := make([]byte, 5)
+	for counter := uint32(0); ; counter++ {
+		n, err := io.ReadFull(src, plain)
+		final := false
+		switch {
+		case err == io.EOF || err == io.ErrUnexpectedEOF:
+			final = true
+		case err != nil:
+			return err
+		}
+		if counter == ^uint32(0) && !final {
+			return errors.New("слишком большой поток для шифрования")
+		}
+
+		header[0] = 0
+		if final {
+			header[0] = 1
+		}
+		sealed := gcm.Seal(nil, streamNonce(prefix, counter), plain[:n], header[:1])
+		binary.BigEndian.PutUint32(header[1:], uint32(len(sealed)))
+		if _, err := dst.Write(header); err != nil {
+			return err
+		}
+		if _, err := dst.Write(sealed); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+	}
+}
+
+// DecryptStream расшифровывает поток EncryptStream. Каждый фрагмент проверяется перед записью в dst,
+// но при ошибке в dst уже может оказаться начало данных: вызывающий пишет во временный файл.
+func DecryptStream(dst io.Writer, src io.Reader, key []byte) error {
+	r, err := NewStreamReader(src, key)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, r)
+	return err
+}
+
+// streamReader расшифровывает поток EncryptStream по мере чтения
+type streamReader struct {
+	src     io.Reader
+	gcm     cipher.AEAD
+	prefix  []byte
+	counter uint32
+	sealed  []byte
+	plain   []byte // Расшифрованный, но ещё не прочитанный остаток фрагмента
+	done    bool
+}
+
+// NewStreamReader возвращает Reader открытого текста потока EncryptStream.
+// Read возвращает ErrStreamCorrupted, если фрагмент не проходит проверку или поток обрезан.
+func NewStreamReader(src io.Reader, key []byte) (io.Reader, error) {
+	gcm, err := newStreamGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, len(EncryptedStreamMarker)+streamPrefixSize)
+	if _, err := io.ReadFull(src, head); err != nil {
+		return nil, ErrStreamCorrupted
+	}
+	if !bytes.Equal(head[:len(EncryptedStreamMarker)], EncryptedStreamMarker) {
+		return nil, errors.New("данные не зашифрованы или имеют неверный формат")
+	}
+
+	return &streamReader{
+		src:    src,
+		gcm:    gcm,
+		prefix: head[len(EncryptedStreamMarker):],
+		sealed: make([]byte, streamChunkSize+gcm.Overhead()),
+	}, nil
+}
+
+func (r *streamReader) Read(p []byte) (int, error) {
+	for len(r.plain) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.plain)
+	r.plain = r.plain[n:]
+	return n, nil
+}
+
+// next читает и расшифровывает следующий фрагмент
+func (r *streamReader) next() error {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(r.src, header); err != nil {
+		return ErrStreamCorrupted
+	}
+	final := header[0] == 1
+	size := binary.BigEndian.Uint32(header[1:])
+	if header[0] > 1 || size > uint32(len(r.sealed)) {
+		return ErrStreamCorrupted
+	}
+	if _, err := io.ReadFull(r.src, r.sealed[:size]); err != nil {
+		return ErrStreamCorrupted
+	}
+
+	plain, err := r.gcm.Open(r.plain[:0], streamNonce(r.prefix, r.counter), r.sealed[:size], header[:1])
+	if err != nil {
+		return ErrStreamCorrupted
+	}
+	r.plain = plain
+	r.counter++
+
+	if final {
+		r.done = true
+		// После последнего фрагмента данных быть не должно
+		if n, _ := r.src.Read(header[:1]); n > 0 {
+			return ErrStreamCorrupted
+		}
+	}
+	return nil
+}
+
+// newStreamGCM создаёт AES-GCM для потокового шифрования
+func newStreamGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("неверный размер ключа: %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания шифра: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания GCM: %w", err)
+	}
+	return gcm, nil
+}
+
+// streamNonce возвращает nonce фрагмента: случайный префикс потока и номер фрагмента
+func streamNonce(prefix []byte, counter uint32) []byte {
+	nonce := make([]byte, nonceSize)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[streamPrefixSize:], counter)
+	return nonce
+}
+
+// DeriveSubkey выводит из ключа независимый ключ для другого назначения (HMAC-SHA256 с меткой)
+func DeriveSubkey(key []byte, label string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(label))
+	return mac.Sum(nil)
+}
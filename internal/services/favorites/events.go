@@ -9,6 +9,7 @@ import (
 type EventManager struct {
 	mu          sync.RWMutex
 	subscribers []chan<- string
+	channels    map[<-chan string]chan<- string // Каналы подписчиков по выданной им стороне для Unsubscribe
 }
 
 var instance *EventManager
@@ -30,9 +31,32 @@ func (em *EventManager) Subscribe() <-chan string {
 	em.mu.Lock()
 	defer em.mu.Unlock()
 	em.subscribers = append(em.subscribers, ch)
+	if em.channels == nil {
+		em.channels = make(map[<-chan string]chan<- string)
+	}
+	em.channels[ch] = ch
 	return ch
 }
 
+// Unsubscribe отписывает канал ch, полученный от Subscribe, и закрывает его
+func (em *EventManager) Unsubscribe(ch <-chan string) {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+
+	subscriber, ok := em.channels[ch]
+	if !ok {
+		return
+	}
+	delete(em.channels, ch)
+	for i, s := range em.subscribers {
+		if s == subscriber {
+			em.subscribers = append(em.subscribers[:i], em.subscribers[i+1:]...)
+			break
+		}
+	}
+	close(subscriber)
+}
+
 // Notify отправляет уведомление всем подписчикам
 func (em *EventManager) Notify(eventType string) {
 	em.mu.RLock()
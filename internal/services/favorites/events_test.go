@@ -319,3 +319,25 @@ func TestEventManager_Notify_SpecialCharacters(t *testing.T) {
 		}
 	}
 }
+
+func TestEventManager_Unsubscribe(t *testing.T) {
+	em := &EventManager{
+		subscribers: make([]chan<- string, 0),
+	}
+
+	kept := em.Subscribe()
+	removed := em.Subscribe()
+	em.Unsubscribe(removed)
+	assert.Len(t, em.subscribers, 1)
+
+	// Канал отписанного закрывается, чтобы его читатель завершился
+	_, open := <-removed
+	assert.False(t, open)
+
+	em.Notify("after_unsubscribe")
+	assert.Equal(t, "after_unsubscribe", <-kept)
+
+	// Повторная отписка ничего не делает
+	em.Unsubscribe(removed)
+	assert.Len(t, em.subscribers, 1)
+}
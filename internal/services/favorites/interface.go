@@ -10,5 +10,6 @@ type ServiceInterface interface {
 	IsFavorite(entityType string, entityID int) (bool, error)
 	GetFavoriteFolders() ([]*models.Item, error)
 	GetFavoriteTags() ([]*models.Tag, error)
+	GetFavoriteSavedSearches() ([]*models.SavedSearch, error)
 	GetAllFavorites() ([]*models.Favorite, error)
 }
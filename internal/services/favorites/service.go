@@ -3,18 +3,25 @@ package favorites
 
 import (
 	"projectT/internal/storage/database/models"
-	"projectT/internal/storage/database/queries"
+	"projectT/internal/storage/database/repository"
 )
 
 // Service предоставляет сервис для работы с избранным
 type Service struct {
-	favoritesImpl *queries.FavoritesServiceImpl
+	favoritesImpl repository.Favorites
 }
 
 // NewService создает новый экземпляр сервиса избранного
 func NewService() *Service {
 	return &Service{
-		favoritesImpl: queries.NewFavoritesServiceImpl(),
+		favoritesImpl: repository.Default().Favorites,
+	}
+}
+
+// NewServiceWithRepo создает сервис избранного над репозиторием repo
+func NewServiceWithRepo(repo repository.Favorites) *Service {
+	return &Service{
+		favoritesImpl: repo,
 	}
 }
 
@@ -61,6 +68,11 @@ func (s *Service) GetFavoriteTags() ([]*models.Tag, error) {
 	return s.favoritesImpl.GetFavoriteTags()
 }
 
+// GetFavoriteSavedSearches возвращает все избранные сохранённые поиски
+func (s *Service) GetFavoriteSavedSearches() ([]*models.SavedSearch, error) {
+	return s.favoritesImpl.GetFavoriteSavedSearches()
+}
+
 // GetAllFavorites возвращает все избранные элементы
 func (s *Service) GetAllFavorites() ([]*models.Favorite, error) {
 	return s.favoritesImpl.GetAllFavorites()
@@ -3,9 +3,23 @@ package favorites
 import (
 	"testing"
 
+	"projectT/internal/storage/database/repository"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// fakeFavorites подставной репозиторий избранного; не переопределённые методы паникуют
+type fakeFavorites struct {
+	repository.Favorites
+	added []string
+}
+
+func (f *fakeFavorites) AddToFavorites(entityType string, entityID int) error {
+	f.added = append(f.added, entityType)
+	return nil
+}
+
 func TestNewService(t *testing.T) {
 	service := NewService()
 	assert.NotNil(t, service)
@@ -99,3 +113,14 @@ func TestService_StructMethods(t *testing.T) {
 func TestService_ConcurrentAccess(t *testing.T) {
 	t.Skip("Требует подключения к базе данных")
 }
+
+// TestAddToFavorites_WithRepo проверяет запись через переданный репозиторий и уведомление подписчиков
+func TestAddToFavorites_WithRepo(t *testing.T) {
+	repo := &fakeFavorites{}
+	service := NewServiceWithRepo(repo)
+	events := GetEventManager().Subscribe()
+
+	require.NoError(t, service.AddToFavorites("folder", 1))
+	assert.Equal(t, []string{"folder"}, repo.added)
+	assert.Equal(t, "favorites_changed", <-events)
+}
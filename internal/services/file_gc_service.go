@@ -0,0 +1,146 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"projectT/internal/storage/database/queries"
+	"projectT/internal/storage/filesystem"
+)
+
+// fileGCInterval как часто фоновый сборщик мусора проверяет хранилище файлов
+const fileGCInterval = 24 * time.Hour
+
+// fileGCMu не даёт двум сборкам мусора идти одновременно
+var fileGCMu sync.Mutex
+
+// SuspendFileStorage ждёт окончания текущей сборки мусора или шифрования хранилища и не даёт
+// начать новые до вызова resume. Нужна при замене хранилища, например при переключении библиотеки.
+func SuspendFileStorage() (resume func()) {
+	fileGCMu.Lock()
+	return fileGCMu.Unlock
+}
+
+// FileGCReport результат сборки мусора в хранилище файлов
+type FileGCReport struct {
+	Scanned        int           // Сколько блобов просмотрено на диске
+	Removed        int           // Сколько блобов удалено
+	ReclaimedBytes int64         // Сколько байт освобождено
+	Skipped        int           // Сколько неиспользуемых блобов оставлено до конца периода ожидания
+	Duration       time.Duration // Длительность сборки
+}
+
+// String возвращает краткое описание результата для журнала и интерфейса
+func (r *FileGCReport) String() string {
+	return fmt.Sprintf("просмотрено файлов: %d, удалено: %d, освобождено: %s, ожидают удаления: %d",
+		r.Scanned, r.Removed, FormatBytes(r.ReclaimedBytes), r.Skipped)
+}
+
+// FileGCService сборщик мусора хранилища файлов.
+// Блобы адресуются хэшем и могут использоваться несколькими элементами и сообщениями чата,
+// поэтому удаление элемента только отвязывает файл, а с диска его убирает mark-and-sweep сборка.
+type FileGCService struct{}
+
+// NewFileGCService создает новый экземпляр сборщика мусора
+func NewFileGCService() *FileGCService {
+	return &FileGCService{}
+}
+
+// Collect удаляет блобы без ссылок, которые не использовались дольше grace.
+// Период ожидания защищает файлы, сохранённые на диск, но ещё не привязанные к элементу или сообщению.
+func (s *FileGCService) Collect(grace time.Duration) (*FileGCReport, error) {
+	fileGCMu.Lock()
+	defer fileGCMu.Unlock()
+
+	started := time.Now()
+	cutoff := started.Add(-grace)
+
+	// Mark: хэши, на которые есть ссылки
+	referenced, err := queries.GetReferencedFileHashes()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения используемых файлов: %w", err)
+	}
+	recent, err := queries.GetRecentlyUnreferencedHashes(cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения недавно отвязанных файлов: %w", err)
+	}
+
+	// Sweep: всё остальное старше периода ожидания удаляется
+	blobs, err := filesystem.ListBlobs()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &FileGCReport{Scanned: len(blobs)}
+	removed := make(map[string]bool)
+	for _, blob := range blobs {
+		if referenced[blob.Hash] {
+			continue
+		}
+		if recent[blob.Hash] || blob.ModTime.After(cutoff) {
+			report.Skipped++
+			continue
+		}
+
+		if err := filesystem.RemoveBlob(blob); err != nil {
+			log.Printf("Предупреждение: не удалось удалить файл %s: %v", blob.Path, err)
+			continue
+		}
+		report.Removed++
+		report.ReclaimedBytes += blob.Size
+		removed[blob.Hash] = true
+	}
+
+	hashes := make([]string, 0, len(removed))
+	for hash := range removed {
+		hashes = append(hashes, hash)
+	}
+	if err := queries.DeleteUnreferencedFileRecords(hashes); err != nil {
+		return report, fmt.Errorf("ошибка очистки реестра файлов: %w", err)
+	}
+
+	report.Duration = time.Since(started)
+	return report, nil
+}
+
+// StartPeriodic запускает сборку мусора сразу и затем периодически в фоне
+func (s *FileGCService) StartPeriodic(grace time.Duration) {
+	collect := func() {
+		report, err := s.Collect(grace)
+		if err != nil {
+			log.Printf("Ошибка сборки мусора в хранилище файлов: %v", err)
+			return
+		}
+		if report.Removed > 0 {
+			log.Printf("Сборка мусора в хранилище файлов: %s", report)
+		}
+	}
+
+	go func() {
+		collect()
+		ticker := time.NewTicker(fileGCInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			collect()
+		}
+	}()
+}
+
+// FormatBytes форматирует размер в байтах в читаемый вид: 512 Б, 1.5 КБ, 10.0 МБ
+func FormatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d Б", size)
+	}
+
+	units := []string{"КБ", "МБ", "ГБ", "ТБ"}
+	value := float64(size) / unit
+	i := 0
+	for value >= unit && i < len(units)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %s", value, units[i])
+}
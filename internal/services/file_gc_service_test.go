@@ -0,0 +1,132 @@
+package services
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/queries"
+	"projectT/internal/storage/filesystem"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gcTestStorageConfig конфигурация временного хранилища файлов
+type gcTestStorageConfig struct {
+	path string
+}
+
+func (c gcTestStorageConfig) GetPath() string     { return c.path }
+func (c gcTestStorageConfig) GetFilesDir() string { return "files" }
+
+// setupFileGCTest подключает БД в памяти и временное хранилище файлов
+func setupFileGCTest(t *testing.T) {
+	t.Helper()
+
+	db, err := database.Open(":memory:")
+	require.NoError(t, err)
+	originalDB := database.GetDB()
+	database.SetDB(db)
+	database.RunMigrations()
+
+	root := filesystem.GetStorageRoot()
+	filesystem.InitStorage(gcTestStorageConfig{path: t.TempDir()})
+
+	t.Cleanup(func() {
+		database.CloseDB()
+		database.SetDB(originalDB)
+		filesystem.InitStorage(gcTestStorageConfig{path: root})
+	})
+}
+
+// saveAgedBlob сохраняет блоб и делает его старше age
+func saveAgedBlob(t *testing.T, content string, age time.Duration) *filesystem.FileData {
+	t.Helper()
+
+	fileData, err := filesystem.SaveFileWithOriginalName([]byte(content), "file.txt")
+	require.NoError(t, err)
+
+	old := time.Now().Add(-age)
+	require.NoError(t, os.Chtimes(fileData.Path, old, old))
+	return fileData
+}
+
+// linkBlob привязывает блоб к новому элементу
+func linkBlob(t *testing.T, fileData *filesystem.FileData) *models.Item {
+	t.Helper()
+
+	item := &models.Item{Type: models.ItemTypeElement, Title: "Элемент", ContentMeta: "[]"}
+	require.NoError(t, queries.CreateItem(item))
+	require.NoError(t, queries.CreateItemFile(&models.ItemFile{
+		ItemID: item.ID, Hash: fileData.Hash, FilePath: fileData.Path, Size: fileData.Size,
+	}))
+	return item
+}
+
+// TestFileGCCollect проверяет, что удаляются только блобы без ссылок после периода ожидания
+func TestFileGCCollect(t *testing.T) {
+	setupFileGCTest(t)
+
+	shared := saveAgedBlob(t, "используется двумя элементами", 48*time.Hour)
+	first := linkBlob(t, shared)
+	linkBlob(t, shared)
+
+	orphan := saveAgedBlob(t, "давно никому не нужен", 48*time.Hour)
+	fresh := saveAgedBlob(t, "только что сохранён", 0)
+
+	// Блоб старый, но ссылку потерял только что
+	unlinked := saveAgedBlob(t, "недавно отвязан", 48*time.Hour)
+	item := linkBlob(t, unlinked)
+	require.NoError(t, queries.DeleteItem(item.ID))
+
+	// Удаление одного из элементов не затрагивает общий блоб
+	require.NoError(t, queries.DeleteItem(first.ID))
+
+	gc := NewFileGCService()
+	report, err := gc.Collect(24 * time.Hour)
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, report.Scanned)
+	assert.Equal(t, 1, report.Removed)
+	assert.Equal(t, orphan.Size, report.ReclaimedBytes)
+	assert.Equal(t, 2, report.Skipped)
+
+	assert.True(t, filesystem.Exists(shared.Hash))
+	assert.False(t, filesystem.Exists(orphan.Hash))
+	assert.True(t, filesystem.Exists(fresh.Hash))
+	assert.True(t, filesystem.Exists(unlinked.Hash))
+
+	// Без периода ожидания удаляется всё, на что нет ссылок
+	report, err = gc.Collect(0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Removed)
+	assert.Equal(t, fresh.Size+unlinked.Size, report.ReclaimedBytes)
+	assert.True(t, filesystem.Exists(shared.Hash))
+
+	_, err = queries.GetFileRecord(unlinked.Hash)
+	assert.ErrorIs(t, err, queries.ErrFileRecordNotFound)
+}
+
+// TestFileGCResaveProtectsBlob проверяет, что повторное сохранение продлевает жизнь блоба
+func TestFileGCResaveProtectsBlob(t *testing.T) {
+	setupFileGCTest(t)
+
+	blob := saveAgedBlob(t, "сохранён повторно", 48*time.Hour)
+	_, err := filesystem.SaveFileWithOriginalName([]byte("сохранён повторно"), "file.txt")
+	require.NoError(t, err)
+
+	report, err := NewFileGCService().Collect(24 * time.Hour)
+	require.NoError(t, err)
+	assert.Zero(t, report.Removed)
+	assert.True(t, filesystem.Exists(blob.Hash))
+}
+
+// TestFormatBytes проверяет форматирование размера
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 Б", FormatBytes(512))
+	assert.Equal(t, "1.5 КБ", FormatBytes(1536))
+	assert.Equal(t, "10.0 МБ", FormatBytes(10<<20))
+}
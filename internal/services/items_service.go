@@ -1,49 +1,111 @@
 package services
 
 import (
+	"errors"
+
 	"projectT/internal/storage/database/models"
 	"projectT/internal/storage/database/queries"
+	"projectT/internal/storage/database/repository"
 )
 
+// ErrInvalidMoveTarget папка назначения не подходит для перемещения элемента
+var ErrInvalidMoveTarget = errors.New("элемент нельзя переместить в эту папку")
+
 // ItemsService предоставляет сервис для работы с элементами
-type ItemsService struct{}
+type ItemsService struct {
+	items repository.Items
+}
 
 // NewItemsService создает новый экземпляр сервиса элементов
 func NewItemsService() *ItemsService {
-	return &ItemsService{}
+	return NewItemsServiceWithRepo(repository.Default().Items)
+}
+
+// NewItemsServiceWithRepo создает сервис над репозиторием элементов
+func NewItemsServiceWithRepo(repo repository.Items) *ItemsService {
+	return &ItemsService{items: repo}
 }
 
 // CreateItem создает новый элемент
 func (is *ItemsService) CreateItem(item *models.Item) error {
-	return queries.CreateItem(item)
+	return is.items.CreateItem(item)
 }
 
 // GetItemByID возвращает элемент по ID
 func (is *ItemsService) GetItemByID(id int) (*models.Item, error) {
-	return queries.GetItemByID(id)
+	return is.items.GetItemByID(id)
 }
 
 // GetItemsByParent возвращает элементы по родительскому ID
 func (is *ItemsService) GetItemsByParent(parentID int) ([]*models.Item, error) {
-	return queries.GetItemsByParent(parentID)
+	return is.items.GetItemsByParent(parentID)
 }
 
 // UpdateItem обновляет элемент
 func (is *ItemsService) UpdateItem(item *models.Item) error {
-	return queries.UpdateItem(item)
+	return is.items.UpdateItem(item)
 }
 
 // DeleteItem удаляет элемент по ID
 func (is *ItemsService) DeleteItem(id int) error {
-	return queries.DeleteItem(id)
+	return is.items.DeleteItem(id)
 }
 
 // SearchItems выполняет поиск элементов по запросу
 func (is *ItemsService) SearchItems(query string) ([]*models.Item, error) {
-	return queries.SearchItems(query)
+	return is.items.SearchItems(query)
+}
+
+// SearchItemsWithSnippets выполняет поиск элементов по запросу с фрагментами совпадений
+func (is *ItemsService) SearchItemsWithSnippets(query string) ([]*models.SearchResult, error) {
+	return is.items.SearchItemsWithSnippets(query)
 }
 
 // GetAllItemsWithoutParentFilter возвращает все элементы без фильтрации по родительскому ID
 func (is *ItemsService) GetAllItemsWithoutParentFilter() ([]*models.Item, error) {
-	return queries.GetAllItems()
+	return is.items.GetAllItems()
+}
+
+// ListItems возвращает страницу элементов папки parentID (или всех папок в режиме "all_items")
+// с фильтрацией и сортировкой по options. Следующая страница запрашивается с курсором page.Next.
+func (is *ItemsService) ListItems(parentID int, options *FilterOptions, after *queries.ItemCursor, limit int) (*queries.ItemPage, error) {
+	return is.items.ListItems(queries.ItemListOptions{
+		ParentID:  parentID,
+		AllItems:  options.TabMode == "all_items",
+		ItemType:  options.ItemType,
+		Priority:  options.Priority,
+		SortBy:    options.SortBy,
+		SortOrder: options.SortOrder,
+	}, after, limit)
+}
+
+// MoveItem перемещает элемент в папку parentID; nil перемещает его в корень.
+// Папку нельзя переместить в неё саму или во вложенную в неё папку.
+func (is *ItemsService) MoveItem(itemID int, parentID *int) error {
+	item, err := is.items.GetItemByID(itemID)
+	if err != nil {
+		return err
+	}
+
+	if parentID != nil {
+		parent, err := is.items.GetItemByID(*parentID)
+		if err != nil {
+			return err
+		}
+		if parent.Type != models.ItemTypeFolder {
+			return ErrInvalidMoveTarget
+		}
+		subtree, err := is.items.GetItemSubtree(itemID)
+		if err != nil {
+			return err
+		}
+		for _, nested := range subtree {
+			if nested.ID == parent.ID {
+				return ErrInvalidMoveTarget
+			}
+		}
+	}
+
+	item.ParentID = parentID
+	return is.items.UpdateItem(item)
 }
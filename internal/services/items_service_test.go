@@ -3,9 +3,29 @@ package services
 import (
 	"testing"
 
+	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/repository"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// fakeItems подставной репозиторий элементов; не переопределённые методы паникуют
+type fakeItems struct {
+	repository.Items
+	items map[int]*models.Item
+}
+
+func (f *fakeItems) CreateItem(item *models.Item) error {
+	item.ID = len(f.items) + 1
+	f.items[item.ID] = item
+	return nil
+}
+
+func (f *fakeItems) GetItemByID(id int) (*models.Item, error) {
+	return f.items[id], nil
+}
+
 func TestNewItemsService(t *testing.T) {
 	service := NewItemsService()
 	assert.NotNil(t, service)
@@ -143,3 +163,17 @@ func TestItemsService_ConcurrentAccess(t *testing.T) {
 		<-done
 	}
 }
+
+// TestItemsService_WithRepo проверяет, что сервис работает через переданный репозиторий
+func TestItemsService_WithRepo(t *testing.T) {
+	repo := &fakeItems{items: make(map[int]*models.Item)}
+	service := NewItemsServiceWithRepo(repo)
+
+	item := &models.Item{Type: models.ItemTypeElement, Title: "Заметка"}
+	require.NoError(t, service.CreateItem(item))
+	assert.Equal(t, 1, item.ID)
+
+	got, err := service.GetItemByID(item.ID)
+	require.NoError(t, err)
+	assert.Same(t, item, got)
+}
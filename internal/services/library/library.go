@@ -0,0 +1,412 @@
+// Package library ведёт реестр библиотек: независимых наборов базы данных и хранилища файлов,
+// между которыми можно переключаться без перезапуска (например, рабочая и личная коллекции).
+//
+// Основная библиотека - база и хранилище из конфигурации; она есть всегда и не удаляется.
+// Остальные создаются в директории реестра:
+//
+//	libraries.json              - реестр: активная библиотека и список созданных
+//	<id>/projectT.db            - база данных библиотеки
+//	<id>/files/...              - хранилище файлов (корень хранилища - <id>)
+//	<id>/backups/...            - резервные копии библиотеки
+package library
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultID ID основной библиотеки
+	DefaultID = "default"
+	// DefaultName название основной библиотеки, пока её не переименовали
+	DefaultName = "Основная"
+
+	registryFile = "libraries.json"
+	databaseFile = "projectT.db"
+	backupsDir   = "backups"
+
+	// idSize длина ID созданной библиотеки в байтах
+	idSize = 6
+)
+
+var (
+	// ErrNotFound библиотека не найдена
+	ErrNotFound = errors.New("библиотека не найдена")
+	// ErrNameTaken библиотека с таким названием уже есть
+	ErrNameTaken = errors.New("библиотека с таким названием уже существует")
+	// ErrEmptyName пустое название
+	ErrEmptyName = errors.New("название библиотеки не может быть пустым")
+	// ErrRemoveDefault основную библиотеку удалить нельзя
+	ErrRemoveDefault = errors.New("основную библиотеку удалить нельзя")
+	// ErrRemoveActive открытую библиотеку удалить нельзя
+	ErrRemoveActive = errors.New("нельзя удалить открытую библиотеку: сначала переключитесь на другую")
+)
+
+// Library библиотека: база данных и хранилище файлов
+type Library struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+
+	DatabasePath string `json:"-"` // Файл базы данных
+	StoragePath  string `json:"-"` // Корень хранилища файлов
+	BackupPath   string `json:"-"` // Директория резервных копий
+}
+
+// IsDefault сообщает, является ли библиотека основной
+func (l Library) IsDefault() bool {
+	return l.ID == DefaultID
+}
+
+// state содержимое libraries.json
+type state struct {
+	Active      string    `json:"active"`
+	DefaultName string    `json:"default_name,omitempty"`
+	Libraries   []Library `json:"libraries"`
+}
+
+// Registry реестр библиотек в директории dir
+type Registry struct {
+	mu    sync.Mutex
+	dir   string
+	def   Library
+	state state
+}
+
+// Open открывает реестр в директории dir. def - основная библиотека с путями из конфигурации.
+// Если реестра ещё нет, он будет создан при первом изменении.
+func Open(dir string, def Library) (*Registry, error) {
+	def.ID = DefaultID
+	r := &Registry{dir: dir, def: def, state: state{Active: DefaultID}}
+
+	data, err := os.ReadFile(filepath.Join(dir, registryFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return r, nil
+		}
+		return nil, fmt.Errorf("ошибка чтения реестра библиотек: %w", err)
+	}
+	if err := json.Unmarshal(data, &r.state); err != nil {
+		return nil, fmt.Errorf("реестр библиотек повреждён: %w", err)
+	}
+	// ID - имя директории, которая удаляется вместе с библиотекой: чужие значения не принимаются
+	libraries := r.state.Libraries[:0]
+	for _, lib := range r.state.Libraries {
+		if validID(lib.ID) {
+			libraries = append(libraries, lib)
+		}
+	}
+	r.state.Libraries = libraries
+	if _, ok := r.find(r.state.Active); !ok {
+		r.state.Active = DefaultID
+	}
+	return r, nil
+}
+
+// List возвращает библиотеки: основную первой, остальные по названию
+func (r *Registry) List() []Library {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	libraries := r.all()
+	others := libraries[1:]
+	sort.Slice(others, func(i, j int) bool {
+		return strings.ToLower(others[i].Name) < strings.ToLower(others[j].Name)
+	})
+	return libraries
+}
+
+// Get находит библиотеку по ID или названию (без учёта регистра)
+func (r *Registry) Get(ref string) (Library, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if lib, ok := r.find(ref); ok {
+		return lib, nil
+	}
+	for _, lib := range r.all() {
+		if strings.EqualFold(lib.Name, strings.TrimSpace(ref)) {
+			return lib, nil
+		}
+	}
+	return Library{}, fmt.Errorf("%w: %s", ErrNotFound, ref)
+}
+
+// Active возвращает активную библиотеку
+func (r *Registry) Active() Library {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lib, _ := r.find(r.state.Active)
+	return lib
+}
+
+// SetActive запоминает библиотеку, которая открывается при следующем запуске
+func (r *Registry) SetActive(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.find(id); !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if r.state.Active == id {
+		return nil
+	}
+	previous := r.state.Active
+	r.state.Active = id
+	if err := r.save(); err != nil {
+		r.state.Active = previous
+		return err
+	}
+	return nil
+}
+
+// Create создает пустую библиотеку с названием name. Схема базы создаётся при первом открытии.
+func (r *Registry) Create(name string) (Library, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name, err := r.checkName(name, "")
+	if err != nil {
+		return Library{}, err
+	}
+	id, err := newID()
+	if err != nil {
+		return Library{}, err
+	}
+
+	lib := Library{ID: id, Name: name, CreatedAt: time.Now().UTC()}
+	if err := os.MkdirAll(filepath.Join(r.dir, id), 0755); err != nil {
+		return Library{}, fmt.Errorf("ошибка создания директории библиотеки: %w", err)
+	}
+	r.state.Libraries = append(r.state.Libraries, lib)
+	if err := r.save(); err != nil {
+		r.state.Libraries = r.state.Libraries[:len(r.state.Libraries)-1]
+		os.RemoveAll(filepath.Join(r.dir, id))
+		return Library{}, err
+	}
+	return r.withPaths(lib), nil
+}
+
+// Rename переименовывает библиотеку
+func (r *Registry) Rename(id, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.find(id); !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	name, err := r.checkName(name, id)
+	if err != nil {
+		return err
+	}
+
+	if id == DefaultID {
+		previous := r.state.DefaultName
+		r.state.DefaultName = name
+		if err := r.save(); err != nil {
+			r.state.DefaultName = previous
+			return err
+		}
+		return nil
+	}
+	for i := range r.state.Libraries {
+		if r.state.Libraries[i].ID == id {
+			previous := r.state.Libraries[i].Name
+			r.state.Libraries[i].Name = name
+			if err := r.save(); err != nil {
+				r.state.Libraries[i].Name = previous
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Remove удаляет библиотеку вместе с её базой, файлами и резервными копиями.
+// Основную и открытую библиотеки удалить нельзя.
+func (r *Registry) Remove(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if id == DefaultID {
+		return ErrRemoveDefault
+	}
+	if id == r.state.Active {
+		return ErrRemoveActive
+	}
+	index := -1
+	for i, lib := range r.state.Libraries {
+		if lib.ID == id {
+			index = i
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	libraries := r.state.Libraries
+	r.state.Libraries = append(append([]Library{}, libraries[:index]...), libraries[index+1:]...)
+	if err := r.save(); err != nil {
+		r.state.Libraries = libraries
+		return err
+	}
+	// Запись из реестра уже удалена: если удалить файлы не удалось, они просто останутся на диске
+	if err := os.RemoveAll(filepath.Join(r.dir, id)); err != nil {
+		return fmt.Errorf("библиотека удалена из списка, но не все её файлы удалены: %w", err)
+	}
+	return nil
+}
+
+// find ищет библиотеку по ID; вызывается под mu
+func (r *Registry) find(id string) (Library, bool) {
+	if id == DefaultID {
+		return r.defaultLibrary(), true
+	}
+	for _, lib := range r.state.Libraries {
+		if lib.ID == id {
+			return r.withPaths(lib), true
+		}
+	}
+	return Library{}, false
+}
+
+// all возвращает основную библиотеку и созданные с путями; вызывается под mu
+func (r *Registry) all() []Library {
+	libraries := []Library{r.defaultLibrary()}
+	for _, lib := range r.state.Libraries {
+		libraries = append(libraries, r.withPaths(lib))
+	}
+	return libraries
+}
+
+// checkName проверяет название на пустоту и уникальность; except - ID переименовываемой библиотеки
+func (r *Registry) checkName(name, except string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", ErrEmptyName
+	}
+	for _, lib := range r.all() {
+		if lib.ID != except && strings.EqualFold(lib.Name, name) {
+			return "", ErrNameTaken
+		}
+	}
+	return name, nil
+}
+
+// defaultLibrary основная библиотека с названием из реестра
+func (r *Registry) defaultLibrary() Library {
+	lib := r.def
+	if r.state.DefaultName != "" {
+		lib.Name = r.state.DefaultName
+	}
+	if lib.Name == "" {
+		lib.Name = DefaultName
+	}
+	return lib
+}
+
+// withPaths дополняет созданную библиотеку путями внутри директории реестра
+func (r *Registry) withPaths(lib Library) Library {
+	root := filepath.Join(r.dir, lib.ID)
+	lib.StoragePath = root
+	lib.DatabasePath = filepath.Join(root, databaseFile)
+	lib.BackupPath = filepath.Join(root, backupsDir)
+	return lib
+}
+
+// save записывает реестр через временный файл; вызывается под mu
+func (r *Registry) save() error {
+	data, err := json.MarshalIndent(r.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return fmt.Errorf("ошибка создания директории библиотек: %w", err)
+	}
+	path := filepath.Join(r.dir, registryFile)
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("ошибка сохранения реестра библиотек: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("ошибка сохранения реестра библиотек: %w", err)
+	}
+	return nil
+}
+
+// validID проверяет, что id получен из newID
+func validID(id string) bool {
+	if len(id) != idSize*2 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+// newID возвращает случайный ID библиотеки; он же имя её директории
+func newID() (string, error) {
+	buf := make([]byte, idSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("ошибка генерации ID библиотеки: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+var (
+	currentMu sync.RWMutex
+	current   *Registry
+	opener    func(Library) error
+
+	// switchMu не даёт переключать библиотеки одновременно
+	switchMu sync.Mutex
+)
+
+// Configure задаёт реестр библиотек процесса и функцию open, которая закрывает открытую библиотеку
+// и открывает переданную
+func Configure(registry *Registry, open func(Library) error) {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	current = registry
+	opener = open
+}
+
+// Current возвращает реестр, заданный Configure, или nil
+func Current() *Registry {
+	currentMu.RLock()
+	defer currentMu.RUnlock()
+	return current
+}
+
+// Switch открывает библиотеку с ID или названием ref и запоминает её активной
+func Switch(ref string) error {
+	switchMu.Lock()
+	defer switchMu.Unlock()
+
+	currentMu.RLock()
+	registry, open := current, opener
+	currentMu.RUnlock()
+	if registry == nil || open == nil {
+		return errors.New("библиотеки не настроены")
+	}
+
+	lib, err := registry.Get(ref)
+	if err != nil {
+		return err
+	}
+	if lib.ID == registry.Active().ID {
+		return nil
+	}
+	if err := open(lib); err != nil {
+		return err
+	}
+	return registry.SetActive(lib.ID)
+}
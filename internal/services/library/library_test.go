@@ -0,0 +1,123 @@
+package library
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openTestRegistry(t *testing.T, dir string) *Registry {
+	registry, err := Open(dir, Library{
+		Name:         DefaultName,
+		DatabasePath: "/data/projectT.db",
+		StoragePath:  "/data",
+		BackupPath:   "/backups",
+	})
+	require.NoError(t, err)
+	return registry
+}
+
+// TestRegistryDefault проверяет, что без реестра есть только основная библиотека с путями из конфигурации
+func TestRegistryDefault(t *testing.T) {
+	registry := openTestRegistry(t, t.TempDir())
+
+	libraries := registry.List()
+	require.Len(t, libraries, 1)
+	assert.True(t, libraries[0].IsDefault())
+	assert.Equal(t, "/data/projectT.db", libraries[0].DatabasePath)
+	assert.Equal(t, DefaultID, registry.Active().ID)
+}
+
+// TestRegistryCreateRenameReopen проверяет создание, переименование и сохранение реестра между запусками
+func TestRegistryCreateRenameReopen(t *testing.T) {
+	dir := t.TempDir()
+	registry := openTestRegistry(t, dir)
+
+	work, err := registry.Create(" Работа ")
+	require.NoError(t, err)
+	assert.Equal(t, "Работа", work.Name)
+	assert.Equal(t, filepath.Join(dir, work.ID), work.StoragePath)
+	assert.Equal(t, filepath.Join(dir, work.ID, "projectT.db"), work.DatabasePath)
+	assert.DirExists(t, work.StoragePath)
+
+	_, err = registry.Create("работа")
+	assert.ErrorIs(t, err, ErrNameTaken)
+	_, err = registry.Create("  ")
+	assert.ErrorIs(t, err, ErrEmptyName)
+
+	require.NoError(t, registry.Rename(work.ID, "Офис"))
+	require.NoError(t, registry.Rename(DefaultID, "Личная"))
+	require.NoError(t, registry.SetActive(work.ID))
+
+	reopened := openTestRegistry(t, dir)
+	assert.Equal(t, work.ID, reopened.Active().ID)
+	found, err := reopened.Get("офис")
+	require.NoError(t, err)
+	assert.Equal(t, work.ID, found.ID)
+	main, err := reopened.Get(DefaultID)
+	require.NoError(t, err)
+	assert.Equal(t, "Личная", main.Name)
+	assert.Equal(t, "/data", main.StoragePath)
+}
+
+// TestRegistryRemove проверяет, что основную и открытую библиотеки удалить нельзя, а остальные удаляются с файлами
+func TestRegistryRemove(t *testing.T) {
+	registry := openTestRegistry(t, t.TempDir())
+	work, err := registry.Create("Работа")
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, registry.Remove(DefaultID), ErrRemoveDefault)
+	require.NoError(t, registry.SetActive(work.ID))
+	assert.ErrorIs(t, registry.Remove(work.ID), ErrRemoveActive)
+
+	require.NoError(t, registry.SetActive(DefaultID))
+	require.NoError(t, registry.Remove(work.ID))
+	assert.NoDirExists(t, work.StoragePath)
+	assert.Len(t, registry.List(), 1)
+	assert.ErrorIs(t, registry.Remove(work.ID), ErrNotFound)
+}
+
+// TestRegistryIgnoresForeignIDs проверяет, что записи с чужими ID не принимаются: их директория удалялась бы вместе с библиотекой
+func TestRegistryIgnoresForeignIDs(t *testing.T) {
+	dir := t.TempDir()
+	data := `{"active": "..", "libraries": [{"id": "..", "name": "Чужая"}]}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, registryFile), []byte(data), 0644))
+
+	registry := openTestRegistry(t, dir)
+	assert.Len(t, registry.List(), 1)
+	assert.Equal(t, DefaultID, registry.Active().ID)
+	assert.ErrorIs(t, registry.Remove(".."), ErrNotFound)
+}
+
+// TestSwitch проверяет, что переключение открывает библиотеку и запоминает её, а при ошибке активная не меняется
+func TestSwitch(t *testing.T) {
+	registry := openTestRegistry(t, t.TempDir())
+	work, err := registry.Create("Работа")
+	require.NoError(t, err)
+
+	var opened []string
+	failOpen := errors.New("база недоступна")
+	Configure(registry, func(lib Library) error {
+		opened = append(opened, lib.ID)
+		if lib.Name == "Сломанная" {
+			return failOpen
+		}
+		return nil
+	})
+	defer Configure(nil, nil)
+
+	require.NoError(t, Switch("Работа"))
+	assert.Equal(t, work.ID, registry.Active().ID)
+	// Повторное открытие той же библиотеки ничего не делает
+	require.NoError(t, Switch(work.ID))
+	assert.Equal(t, []string{work.ID}, opened)
+
+	_, err = registry.Create("Сломанная")
+	require.NoError(t, err)
+	assert.ErrorIs(t, Switch("Сломанная"), failOpen)
+	assert.Equal(t, work.ID, registry.Active().ID)
+}
@@ -0,0 +1,74 @@
+package linkpreview
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"projectT/internal/storage/database/models"
+)
+
+// block блок content_meta. Поля хранятся как есть, чтобы сохранение превью не теряло
+// поля, о которых этот пакет не знает.
+type block struct {
+	fields  map[string]json.RawMessage
+	kind    string
+	content string
+	preview *models.LinkPreview
+}
+
+// parseBlocks разбирает content_meta элемента
+func parseBlocks(contentMeta string) ([]*block, error) {
+	if contentMeta == "" {
+		return nil, nil
+	}
+	var raw []map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(contentMeta), &raw); err != nil {
+		return nil, fmt.Errorf("ошибка разбора JSON блоков: %w", err)
+	}
+
+	blocks := make([]*block, 0, len(raw))
+	for _, fields := range raw {
+		b := &block{fields: fields}
+		_ = json.Unmarshal(fields["type"], &b.kind)
+		_ = json.Unmarshal(fields["content"], &b.content)
+		if data, ok := fields["preview"]; ok {
+			var preview models.LinkPreview
+			if json.Unmarshal(data, &preview) == nil {
+				b.preview = &preview
+			}
+		}
+		blocks = append(blocks, b)
+	}
+	return blocks, nil
+}
+
+// isLink сообщает, что блок - непустая ссылка
+func (b *block) isLink() bool {
+	return b.kind == "link" && strings.TrimSpace(b.content) != ""
+}
+
+// setPreview заменяет превью блока
+func (b *block) setPreview(preview *models.LinkPreview) error {
+	data, err := json.Marshal(preview)
+	if err != nil {
+		return err
+	}
+	b.fields["preview"] = data
+	b.preview = preview
+	return nil
+}
+
+// MarshalJSON сериализует блок со всеми исходными полями
+func (b *block) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.fields)
+}
+
+// normalizeURL дополняет ссылку без протокола до https, как при открытии ссылки в интерфейсе
+func normalizeURL(link string) string {
+	link = strings.TrimSpace(link)
+	if !strings.HasPrefix(link, "http://") && !strings.HasPrefix(link, "https://") {
+		return "https://" + link
+	}
+	return link
+}
@@ -0,0 +1,294 @@
+package linkpreview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"projectT/internal/services/webfetch"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	// fetchTimeout ограничивает загрузку страницы, значка или изображения целиком
+	fetchTimeout = 10 * time.Second
+	// previewTimeout ограничивает сбор превью целиком: страницу, изображение и все попытки загрузить значок
+	previewTimeout = 30 * time.Second
+	// maxIcons сколько указанных на странице значков пробуется загрузить
+	maxIcons = 3
+	// maxPageSize сколько байт страницы читается: метаданные находятся в начале документа
+	maxPageSize = 1 << 20
+	// maxImageSize наибольший размер изображения превью
+	maxImageSize = 5 << 20
+	// maxFaviconSize наибольший размер значка сайта
+	maxFaviconSize = 256 << 10
+	// maxTitleLength и maxDescriptionLength ограничивают длину текста превью в символах
+	maxTitleLength       = 300
+	maxDescriptionLength = 1000
+)
+
+// userAgent с которым запрашиваются страницы: часть сайтов не отдаёт метаданные без него
+const userAgent = "Mozilla/5.0 (compatible; projectT link preview)"
+
+// imageExtensions расширения, с которыми сохраняются изображения: по ним хранилище находит файл.
+// Значки ICO сохраняются без расширения.
+var imageExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+	"image/bmp":  ".bmp",
+}
+
+// Page метаданные страницы и загруженные значок и изображение
+type Page struct {
+	URL         string // Итоговый адрес после перенаправлений
+	Title       string
+	Description string
+	SiteName    string
+	Favicon     *Image // nil, если значок не найден или не загрузился
+	Image       *Image // nil, если изображения нет или оно не загрузилось
+}
+
+// Image загруженное изображение
+type Image struct {
+	Data      []byte
+	Extension string // Расширение для хранилища файлов (может быть пустым)
+}
+
+// Fetcher загружает страницы и извлекает из них превью
+type Fetcher struct {
+	client *webfetch.Client
+}
+
+// NewFetcher создает загрузчик со строгими ограничениями времени и числа перенаправлений
+func NewFetcher() *Fetcher {
+	return &Fetcher{client: webfetch.NewClient(userAgent, fetchTimeout)}
+}
+
+// Fetch загружает страницу rawURL и возвращает её превью. Ошибкой считается только недоступность
+// страницы: значок и изображение, которые не удалось загрузить, просто отсутствуют в результате.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*Page, error) {
+	pageURL, err := url.Parse(rawURL)
+	if err != nil || !webfetch.IsHTTP(pageURL) {
+		return nil, fmt.Errorf("неподдерживаемый адрес: %s", rawURL)
+	}
+	ctx, cancel := context.WithTimeout(ctx, previewTimeout)
+	defer cancel()
+
+	resp, err := f.client.Get(ctx, pageURL.String(), "text/html,application/xhtml+xml;q=0.9,*/*;q=0.5")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	page := &Page{URL: resp.Request.URL.String()}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+
+	// Ссылка ведёт прямо на изображение: оно и есть превью
+	if strings.HasPrefix(mediaType, "image/") {
+		page.Title = path.Base(resp.Request.URL.Path)
+		if image, err := readImage(resp, maxImageSize); err == nil {
+			page.Image = image
+		}
+		return page, nil
+	}
+	if mediaType != "" && mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		page.Title = path.Base(resp.Request.URL.Path)
+		return page, nil
+	}
+
+	body, err := charset.NewReader(io.LimitReader(resp.Body, maxPageSize), resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("неизвестная кодировка страницы: %w", err)
+	}
+	meta := parseHead(body, resp.Request.URL)
+
+	page.Title = truncate(firstNonEmpty(meta.values["og:title"], meta.values["twitter:title"], meta.title), maxTitleLength)
+	page.Description = truncate(firstNonEmpty(
+		meta.values["og:description"], meta.values["twitter:description"], meta.values["description"],
+	), maxDescriptionLength)
+	page.SiteName = truncate(meta.values["og:site_name"], maxTitleLength)
+
+	imageURL := firstNonEmpty(
+		meta.values["og:image:secure_url"], meta.values["og:image"], meta.values["og:image:url"],
+		meta.values["twitter:image"], meta.values["twitter:image:src"],
+	)
+	if image, err := f.fetchImage(ctx, meta.resolve(imageURL), maxImageSize); err == nil {
+		page.Image = image
+	}
+
+	// Без указанного на странице значка браузеры запрашивают /favicon.ico
+	icons := meta.icons
+	if len(icons) > maxIcons {
+		icons = icons[:maxIcons]
+	}
+	icons = append(icons[:len(icons):len(icons)], resp.Request.URL.ResolveReference(&url.URL{Path: "/favicon.ico"}).String())
+	for _, icon := range icons {
+		if image, err := f.fetchImage(ctx, icon, maxFaviconSize); err == nil {
+			page.Favicon = image
+			break
+		}
+	}
+
+	return page, nil
+}
+
+// fetchImage загружает изображение не больше limit байт
+func (f *Fetcher) fetchImage(ctx context.Context, rawURL string, limit int64) (*Image, error) {
+	if rawURL == "" {
+		return nil, errors.New("адрес изображения не указан")
+	}
+	resp, err := f.client.Get(ctx, rawURL, "image/*")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return readImage(resp, limit)
+}
+
+// readImage читает изображение из ответа, проверяя размер и тип содержимого
+func readImage(resp *http.Response, limit int64) (*Image, error) {
+	if resp.ContentLength > limit {
+		return nil, webfetch.ErrTooLarge
+	}
+	data, err := webfetch.ReadLimited(resp.Body, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Тип определяется по содержимому: заголовку Content-Type серверы часто врут
+	mimeType := http.DetectContentType(data)
+	if !strings.HasPrefix(mimeType, "image/") {
+		return nil, fmt.Errorf("не изображение: %s", mimeType)
+	}
+	return &Image{Data: data, Extension: imageExtensions[mimeType]}, nil
+}
+
+// headMeta метаданные из <head> страницы
+type headMeta struct {
+	base   *url.URL
+	title  string
+	values map[string]string // meta property/name -> content, первое вхождение
+	icons  []string          // адреса значков в порядке предпочтения
+}
+
+// resolve возвращает абсолютный адрес ref относительно страницы; пусто для неподдерживаемых адресов
+func (m *headMeta) resolve(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+	target, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	target = m.base.ResolveReference(target)
+	if !webfetch.IsHTTP(target) {
+		return ""
+	}
+	return target.String()
+}
+
+// parseHead разбирает начало HTML-документа до <body> и собирает заголовок, meta-теги и значки
+func parseHead(r io.Reader, pageURL *url.URL) *headMeta {
+	meta := &headMeta{base: pageURL, values: make(map[string]string)}
+	var touchIcons []string
+	tokenizer := html.NewTokenizer(r)
+	inTitle := false
+
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			meta.icons = append(meta.icons, touchIcons...)
+			return meta
+		case html.TextToken:
+			if inTitle && meta.title == "" {
+				meta.title = string(tokenizer.Text())
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				meta.icons = append(meta.icons, touchIcons...)
+				return meta
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			attrs := map[string]string{}
+			for hasAttr {
+				var key, value []byte
+				key, value, hasAttr = tokenizer.TagAttr()
+				attrs[string(key)] = string(value)
+			}
+
+			switch string(name) {
+			case "title":
+				inTitle = tokenType == html.StartTagToken
+			case "base":
+				if href, err := url.Parse(strings.TrimSpace(attrs["href"])); err == nil && attrs["href"] != "" {
+					meta.base = pageURL.ResolveReference(href)
+				}
+			case "meta":
+				key := strings.ToLower(firstNonEmpty(attrs["property"], attrs["name"]))
+				if key != "" && attrs["content"] != "" {
+					if _, ok := meta.values[key]; !ok {
+						meta.values[key] = attrs["content"]
+					}
+				}
+			case "link":
+				rel := strings.Fields(strings.ToLower(attrs["rel"]))
+				href := meta.resolve(attrs["href"])
+				if href == "" {
+					continue
+				}
+				for _, value := range rel {
+					if value == "icon" {
+						meta.icons = append(meta.icons, href)
+						break
+					}
+					if value == "apple-touch-icon" || value == "apple-touch-icon-precomposed" {
+						touchIcons = append(touchIcons, href)
+						break
+					}
+				}
+			case "body":
+				meta.icons = append(meta.icons, touchIcons...)
+				return meta
+			}
+		}
+	}
+}
+
+// firstNonEmpty возвращает первое непустое значение
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// truncate схлопывает пробелы и обрезает текст до limit символов
+func truncate(text string, limit int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:limit-1])) + "…"
+}
@@ -0,0 +1,336 @@
+// Package linkpreview загружает превью ссылок: заголовок, описание, название сайта, значок и изображение.
+//
+// Превью хранится в блоке ссылки content_meta (поле preview), значок и изображение - в хранилище
+// файлов, как файлы блоков. Страницы загружаются в фоне одним воркером: после сохранения элемента
+// со ссылками и при периодической проверке устаревших превью. Загрузка выключена, пока сервис
+// не запущен (Start), поэтому тесты и команды без фоновых служб сеть не используют.
+package linkpreview
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"projectT/internal/services/favorites"
+	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/repository"
+	"projectT/internal/storage/filesystem"
+	"projectT/internal/storage/vault"
+)
+
+const (
+	// scanInterval как часто проверяются устаревшие превью
+	scanInterval = time.Hour
+	// retryInterval через сколько повторяется загрузка страницы, которая не открылась
+	retryInterval = 24 * time.Hour
+	// queueSize сколько элементов может ждать загрузки превью
+	queueSize = 1024
+)
+
+// storeMu не даёт сохранять превью во время замены базы и хранилища
+var storeMu sync.Mutex
+
+// Suspend ждёт окончания сохранения текущего превью и не даёт начать новое до вызова resume.
+// Нужна при замене хранилища, например при переключении библиотеки.
+func Suspend() (resume func()) {
+	storeMu.Lock()
+	return storeMu.Unlock
+}
+
+// Service загружает превью ссылок элементов в фоне
+type Service struct {
+	fetcher *Fetcher
+	items   repository.Items
+
+	mu      sync.Mutex
+	maxAge  time.Duration // 0 - превью не обновляются
+	running bool
+	queue   chan int
+	queued  map[int]bool
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+var (
+	instance *Service
+	once     sync.Once
+)
+
+// Get возвращает глобальный сервис превью ссылок
+func Get() *Service {
+	once.Do(func() {
+		instance = NewService(NewFetcher())
+	})
+	return instance
+}
+
+// NewService создает сервис превью с загрузчиком fetcher
+func NewService(fetcher *Fetcher) *Service {
+	return &Service{
+		fetcher: fetcher,
+		items:   repository.Default().Items,
+		queued:  make(map[int]bool),
+	}
+}
+
+// Start запускает фоновую загрузку превью. Превью старше maxAge загружаются заново (0 - не обновляются).
+func (s *Service) Start(maxAge time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return
+	}
+	s.running = true
+	s.maxAge = maxAge
+	s.queue = make(chan int, queueSize)
+	s.stop = make(chan struct{})
+
+	s.wg.Add(2)
+	go s.worker(s.queue, s.stop)
+	go s.scanner(s.stop)
+}
+
+// Stop останавливает фоновую загрузку и дожидается текущей
+func (s *Service) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	close(s.stop)
+	s.queued = make(map[int]bool)
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// Schedule ставит элемент в очередь на загрузку превью его ссылок, не дожидаясь результата.
+// Пока сервис не запущен или очередь заполнена, элемент пропускается: его превью загрузит
+// периодическая проверка.
+func (s *Service) Schedule(itemID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.running || s.queued[itemID] {
+		return
+	}
+	select {
+	case s.queue <- itemID:
+		s.queued[itemID] = true
+	default:
+	}
+}
+
+// worker загружает превью элементов из очереди
+func (s *Service) worker(queue <-chan int, stop <-chan struct{}) {
+	defer s.wg.Done()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case itemID := <-queue:
+			s.mu.Lock()
+			delete(s.queued, itemID)
+			s.mu.Unlock()
+
+			// Пока зашифрованное хранилище заблокировано, изображения сохранить нельзя:
+			// элемент снова попадёт в очередь при следующей проверке
+			if filesystem.VaultEnabled() && !vault.Unlocked() {
+				continue
+			}
+			updated, err := s.RefreshItem(ctx, itemID)
+			if err != nil {
+				log.Printf("Ошибка загрузки превью ссылок элемента %d: %v", itemID, err)
+			} else if updated {
+				favorites.GetEventManager().Notify("items_changed")
+			}
+		}
+	}
+}
+
+// scanner периодически ставит в очередь элементы с устаревшими превью
+func (s *Service) scanner(stop <-chan struct{}) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(scanInterval)
+	defer ticker.Stop()
+
+	for {
+		s.scheduleStale()
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scheduleStale ставит в очередь элементы, у ссылок которых нет превью или оно устарело
+func (s *Service) scheduleStale() {
+	items, err := s.items.GetItemsWithLinks()
+	if err != nil {
+		log.Printf("Ошибка поиска ссылок для превью: %v", err)
+		return
+	}
+	now := time.Now()
+	for _, item := range items {
+		blocks, err := parseBlocks(item.ContentMeta)
+		if err != nil {
+			continue
+		}
+		for _, block := range blocks {
+			if block.isLink() && s.stale(block.preview, now) {
+				s.Schedule(item.ID)
+				break
+			}
+		}
+	}
+}
+
+// stale сообщает, что превью нужно загрузить заново
+func (s *Service) stale(preview *models.LinkPreview, now time.Time) bool {
+	if preview == nil {
+		return true
+	}
+	age := now.Sub(preview.FetchedAt)
+	if preview.Error != "" {
+		return age > retryInterval
+	}
+	s.mu.Lock()
+	maxAge := s.maxAge
+	s.mu.Unlock()
+	if maxAge > 0 && age > maxAge {
+		return true
+	}
+	// Файлы превью могли не попасть в хранилище, например при импорте архива
+	for _, hash := range []string{preview.FaviconHash, preview.ImageHash} {
+		if hash != "" && !filesystem.Exists(hash) {
+			return true
+		}
+	}
+	return false
+}
+
+// RefreshItem загружает превью ссылок элемента, у которых его нет или оно устарело, и сохраняет их в блоки.
+// Возвращает false, если сохранять было нечего или элемент успели изменить во время загрузки.
+func (s *Service) RefreshItem(ctx context.Context, itemID int) (bool, error) {
+	item, err := s.items.GetItemByID(itemID)
+	if err != nil {
+		return false, err
+	}
+	blocks, err := parseBlocks(item.ContentMeta)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	results := make(map[string]*models.LinkPreview)
+	pages := make(map[string]*Page)
+	for _, block := range blocks {
+		if !block.isLink() || !s.stale(block.preview, now) {
+			continue
+		}
+		if _, done := results[block.content]; done {
+			continue
+		}
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+
+		page, err := s.fetcher.Fetch(ctx, normalizeURL(block.content))
+		if err != nil {
+			// Прежнее превью остаётся: страница могла быть временно недоступна
+			failed := &models.LinkPreview{}
+			if block.preview != nil {
+				*failed = *block.preview
+			}
+			failed.FetchedAt, failed.Error = now, err.Error()
+			results[block.content] = failed
+			continue
+		}
+		pages[block.content] = page
+		results[block.content] = nil
+	}
+	if len(results) == 0 {
+		return false, nil
+	}
+
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	// Файлы сохраняются только после загрузки всех страниц, под блокировкой хранилища
+	for link, page := range pages {
+		preview, err := storePage(page, now)
+		if err != nil {
+			return false, err
+		}
+		results[link] = preview
+	}
+
+	// Элемент перечитывается: за время загрузки его могли изменить или библиотеку переключить
+	current, err := s.items.GetItemByID(itemID)
+	if err != nil {
+		return false, err
+	}
+	blocks, err = parseBlocks(current.ContentMeta)
+	if err != nil {
+		return false, err
+	}
+	changed := false
+	for _, block := range blocks {
+		if preview, ok := results[block.content]; ok && block.isLink() {
+			if err := block.setPreview(preview); err != nil {
+				return false, err
+			}
+			changed = true
+		}
+	}
+	if !changed {
+		return false, nil
+	}
+
+	data, err := json.Marshal(blocks)
+	if err != nil {
+		return false, fmt.Errorf("ошибка сериализации контента: %w", err)
+	}
+	meta := string(data)
+	return s.items.UpdateItemContentMeta(itemID, current.ContentMeta, meta,
+		filesystem.GenerateContentHash(current.Title, current.Description, meta))
+}
+
+// storePage сохраняет значок и изображение страницы в хранилище и возвращает превью
+func storePage(page *Page, fetchedAt time.Time) (*models.LinkPreview, error) {
+	preview := &models.LinkPreview{
+		URL:         page.URL,
+		Title:       page.Title,
+		Description: page.Description,
+		SiteName:    page.SiteName,
+		FetchedAt:   fetchedAt,
+	}
+	for _, file := range []struct {
+		image *Image
+		name  string
+		hash  *string
+	}{
+		{page.Favicon, "favicon", &preview.FaviconHash},
+		{page.Image, "preview", &preview.ImageHash},
+	} {
+		if file.image == nil {
+			continue
+		}
+		saved, err := filesystem.SaveFileWithOriginalName(file.image.Data, file.name+file.image.Extension)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сохранения изображения превью: %w", err)
+		}
+		*file.hash = saved.Hash
+	}
+	return preview, nil
+}
@@ -0,0 +1,306 @@
+package linkpreview
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/queries"
+	"projectT/internal/storage/filesystem"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testStorageConfig хранилище файлов во временной директории
+type testStorageConfig struct{ path string }
+
+func (c testStorageConfig) GetPath() string     { return c.path }
+func (c testStorageConfig) GetFilesDir() string { return "files" }
+
+// setupTestLibrary подключает БД в памяти и временное хранилище файлов
+func setupTestLibrary(t *testing.T) {
+	t.Helper()
+
+	db, err := database.Open(":memory:")
+	require.NoError(t, err)
+	originalDB := database.GetDB()
+	database.SetDB(db)
+	database.RunMigrations()
+
+	root := filesystem.GetStorageRoot()
+	filesystem.InitStorage(testStorageConfig{path: t.TempDir()})
+
+	t.Cleanup(func() {
+		database.CloseDB()
+		database.SetDB(originalDB)
+		filesystem.InitStorage(testStorageConfig{path: root})
+	})
+}
+
+// testPNG возвращает PNG размером width x height
+func testPNG(t *testing.T, width, height int, fill color.Color) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, fill)
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// mustParseURL разбирает адрес
+func mustParseURL(t *testing.T, rawURL string) *url.URL {
+	t.Helper()
+
+	parsed, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	return parsed
+}
+
+// newTestSite запускает сайт со страницами для превью
+func newTestSite(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	ogImage := testPNG(t, 40, 20, color.RGBA{R: 200, A: 255})
+	icon := testPNG(t, 16, 16, color.RGBA{B: 200, A: 255})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/article", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<!DOCTYPE html><html><head>
+			<title>Заголовок страницы</title>
+			<meta property="og:title" content="  Статья   о &laquo;Go&raquo; ">
+			<meta property="og:description" content="Описание статьи">
+			<meta property="og:site_name" content="Пример">
+			<meta property="og:image" content="/images/og.png">
+			<link rel="shortcut icon" href="/static/icon.png">
+			</head><body><meta property="og:title" content="Не из head"></body></html>`))
+	})
+	mux.HandleFunc("/plain", func(w http.ResponseWriter, r *http.Request) {
+		// Старая страница в windows-1251 без OpenGraph: заголовок и описание из HTML
+		w.Header().Set("Content-Type", "text/html; charset=windows-1251")
+		w.Write([]byte("<html><head><base href=\"/sub/\"><title>\xcf\xf0\xe8\xe2\xe5\xf2</title>" +
+			"<meta name=\"description\" content=\"Twitter-less\">" +
+			"<meta name=\"twitter:image\" content=\"big.png\"></head></html>"))
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/article", http.StatusFound)
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/picture.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(ogImage)
+	})
+	mux.HandleFunc("/images/og.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(ogImage)
+	})
+	mux.HandleFunc("/static/icon.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(icon)
+	})
+	mux.HandleFunc("/sub/big.png", func(w http.ResponseWriter, r *http.Request) {
+		// Изображение больше допустимого размера не загружается
+		w.Write(append(append([]byte{}, ogImage...), make([]byte, maxImageSize)...))
+	})
+	mux.HandleFunc("/favicon.ico", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/x-icon")
+		w.Write([]byte("<html>не значок</html>"))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestFetchOpenGraph проверяет разбор OpenGraph, загрузку изображения и значка и перенаправления
+func TestFetchOpenGraph(t *testing.T) {
+	site := newTestSite(t)
+	fetcher := NewFetcher()
+
+	page, err := fetcher.Fetch(context.Background(), site.URL+"/redirect")
+	require.NoError(t, err)
+	assert.Equal(t, site.URL+"/article", page.URL)
+	assert.Equal(t, "Статья о «Go»", page.Title)
+	assert.Equal(t, "Описание статьи", page.Description)
+	assert.Equal(t, "Пример", page.SiteName)
+	require.NotNil(t, page.Image)
+	assert.Equal(t, ".png", page.Image.Extension)
+	require.NotNil(t, page.Favicon)
+	assert.NotEqual(t, page.Image.Data, page.Favicon.Data)
+}
+
+// TestFetchFallbacks проверяет HTML без OpenGraph, кодировку страницы и ограничения размера
+func TestFetchFallbacks(t *testing.T) {
+	site := newTestSite(t)
+	fetcher := NewFetcher()
+	ctx := context.Background()
+
+	page, err := fetcher.Fetch(ctx, site.URL+"/plain")
+	require.NoError(t, err)
+	assert.Equal(t, "Привет", page.Title)
+	assert.Equal(t, "Twitter-less", page.Description)
+	assert.Nil(t, page.Image, "изображение больше maxImageSize")
+	assert.Nil(t, page.Favicon, "/favicon.ico не является изображением")
+
+	// Ссылка прямо на изображение
+	page, err = fetcher.Fetch(ctx, site.URL+"/picture.png")
+	require.NoError(t, err)
+	assert.Equal(t, "picture.png", page.Title)
+	assert.NotNil(t, page.Image)
+
+	_, err = fetcher.Fetch(ctx, site.URL+"/missing")
+	assert.Error(t, err)
+	_, err = fetcher.Fetch(ctx, "file:///etc/passwd")
+	assert.Error(t, err)
+}
+
+// TestFetchIconLimit проверяет, что из длинного списка значков пробуются только первые maxIcons
+func TestFetchIconLimit(t *testing.T) {
+	var mu sync.Mutex
+	var requested []string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/icons", func(w http.ResponseWriter, r *http.Request) {
+		var links strings.Builder
+		for i := 0; i < maxIcons+5; i++ {
+			fmt.Fprintf(&links, `<link rel="icon" href="/broken/%d.png">`, i)
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<html><head><title>Значки</title>" + links.String() + "</head></html>"))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.URL.Path)
+		mu.Unlock()
+		http.NotFound(w, r)
+	})
+	site := httptest.NewServer(mux)
+	t.Cleanup(site.Close)
+
+	page, err := NewFetcher().Fetch(context.Background(), site.URL+"/icons")
+	require.NoError(t, err)
+	assert.Nil(t, page.Favicon)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"/broken/0.png", "/broken/1.png", "/broken/2.png", "/favicon.ico"}, requested)
+}
+
+// TestParseHeadLimits проверяет обрезку длинного текста и порядок значков
+func TestParseHeadLimits(t *testing.T) {
+	assert.Equal(t, "a b", truncate(" a \n b ", 10))
+	long := truncate(strings.Repeat("я", maxTitleLength+10), maxTitleLength)
+	assert.Equal(t, maxTitleLength, len([]rune(long)))
+	assert.True(t, strings.HasSuffix(long, "…"))
+
+	base := mustParseURL(t, "https://example.com/a/page")
+	meta := parseHead(strings.NewReader(`<head>
+		<link rel="apple-touch-icon" href="/touch.png">
+		<link rel="icon" href="icon.png">
+		<link rel="icon" href="javascript:alert(1)">
+		</head>`), base)
+	assert.Equal(t, []string{"https://example.com/a/icon.png", "https://example.com/touch.png"}, meta.icons)
+}
+
+// TestRefreshItem проверяет сохранение превью в блоки элемента и файлов в хранилище
+func TestRefreshItem(t *testing.T) {
+	setupTestLibrary(t)
+	site := newTestSite(t)
+	ctx := context.Background()
+	service := NewService(NewFetcher())
+	service.maxAge = 24 * time.Hour
+
+	meta := `[{"type":"text","content":"заметка"},` +
+		`{"type":"link","content":"` + site.URL + `/article","custom":"сохраняется"},` +
+		`{"type":"link","content":"` + site.URL + `/missing"}]`
+	item := &models.Item{
+		Type:        models.ItemTypeElement,
+		Title:       "Ссылки",
+		ContentMeta: meta,
+		ContentHash: filesystem.GenerateContentHash("Ссылки", "", meta),
+	}
+	require.NoError(t, queries.CreateItem(item))
+
+	updated, err := service.RefreshItem(ctx, item.ID)
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	saved, err := queries.GetItemByID(item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, filesystem.GenerateContentHash(saved.Title, saved.Description, saved.ContentMeta), saved.ContentHash)
+
+	var blocks []struct {
+		Type    string              `json:"type"`
+		Custom  string              `json:"custom"`
+		Preview *models.LinkPreview `json:"preview"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(saved.ContentMeta), &blocks))
+	require.Len(t, blocks, 3)
+	assert.Nil(t, blocks[0].Preview)
+
+	article := blocks[1]
+	assert.Equal(t, "сохраняется", article.Custom)
+	require.NotNil(t, article.Preview)
+	assert.Equal(t, "Статья о «Go»", article.Preview.Title)
+	assert.Empty(t, article.Preview.Error)
+	assert.True(t, filesystem.Exists(article.Preview.ImageHash))
+	assert.True(t, filesystem.Exists(article.Preview.FaviconHash))
+
+	// Недоступная страница запоминается с ошибкой, чтобы не запрашивать её при каждой проверке
+	require.NotNil(t, blocks[2].Preview)
+	assert.NotEmpty(t, blocks[2].Preview.Error)
+
+	// Файлы превью удерживаются от сборщика мусора
+	referenced, err := queries.GetReferencedFileHashes()
+	require.NoError(t, err)
+	assert.True(t, referenced[article.Preview.ImageHash])
+	assert.True(t, referenced[article.Preview.FaviconHash])
+
+	// Свежие превью заново не загружаются
+	updated, err = service.RefreshItem(ctx, item.ID)
+	require.NoError(t, err)
+	assert.False(t, updated)
+}
+
+// TestStale проверяет, когда превью загружается заново
+func TestStale(t *testing.T) {
+	setupTestLibrary(t)
+	service := NewService(NewFetcher())
+	now := time.Now()
+
+	assert.True(t, service.stale(nil, now))
+	assert.False(t, service.stale(&models.LinkPreview{FetchedAt: now.Add(-365 * 24 * time.Hour)}, now), "без maxAge превью не устаревает")
+	assert.False(t, service.stale(&models.LinkPreview{FetchedAt: now.Add(-time.Hour), Error: "недоступна"}, now))
+	assert.True(t, service.stale(&models.LinkPreview{FetchedAt: now.Add(-2 * retryInterval), Error: "недоступна"}, now))
+	assert.True(t, service.stale(&models.LinkPreview{FetchedAt: now, ImageHash: strings.Repeat("0", 64)}, now), "файла превью нет в хранилище")
+
+	service.maxAge = time.Hour
+	assert.True(t, service.stale(&models.LinkPreview{FetchedAt: now.Add(-2 * time.Hour)}, now))
+	assert.False(t, service.stale(&models.LinkPreview{FetchedAt: now.Add(-time.Minute)}, now))
+}
+
+// TestScheduleStopped проверяет, что без Start превью не загружаются
+func TestScheduleStopped(t *testing.T) {
+	service := NewService(NewFetcher())
+	service.Schedule(1)
+	assert.Empty(t, service.queued)
+}
@@ -4,8 +4,6 @@ package p2p
 import (
 	"bufio"
 	"context"
-	"crypto/rand"
-	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -20,7 +18,7 @@ import (
 	"github.com/libp2p/go-libp2p/core/peer"
 
 	"projectT/internal/storage/database/models"
-	"projectT/internal/storage/database/queries"
+	"projectT/internal/storage/database/repository"
 )
 
 // MessageType тип сообщения
@@ -49,6 +47,8 @@ type ChatMessage struct {
 	Signature   []byte      `protobuf:"bytes,8,opt,name=signature,proto3" json:"signature,omitempty"`
 	Encrypted   bool        `protobuf:"varint,9,opt,name=encrypted,proto3" json:"encrypted,omitempty"`
 	Nonce       []byte      `protobuf:"bytes,10,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Version     uint32      `protobuf:"varint,11,opt,name=version,proto3" json:"version,omitempty"`
+	Ciphertext  []byte      `protobuf:"bytes,12,opt,name=ciphertext,proto3" json:"ciphertext,omitempty"`
 }
 
 // QueuedMessage сообщение в очереди для оффлайн-режима
@@ -63,36 +63,47 @@ type QueuedMessage struct {
 
 // ChatService сервис для управления чатом
 type ChatService struct {
-	host          host.Host
-	config        *P2PConfig
-	ctx           context.Context
-	cancel        context.CancelFunc
-	mu            sync.RWMutex
-	messageQueue  map[peer.ID][]*QueuedMessage // очередь сообщений для оффлайн-пиров
-	localPrivKey  crypto.PrivKey               // локальный приватный ключ для подписи
-	localPubKey   crypto.PubKey                // локальный публичный ключ
-	encryptionKey []byte                       // ключ для симметричного шифрования
-}
-
-// NewChatService создаёт сервис чата
-func NewChatService(host host.Host, config *P2PConfig, privKey crypto.PrivKey, pubKey crypto.PubKey) *ChatService {
+	host         host.Host
+	config       *P2PConfig
+	ctx          context.Context
+	cancel       context.CancelFunc
+	mu           sync.RWMutex
+	messageQueue map[peer.ID][]*QueuedMessage // очередь сообщений для оффлайн-пиров
+	localPrivKey crypto.PrivKey               // локальный приватный ключ для подписи
+	localPubKey  crypto.PubKey                // локальный публичный ключ
+	keysMu       sync.Mutex
+	sessionKeys  map[peer.ID][]byte   // сессионные ключи шифрования по пирам
+	fileTransfer *FileTransferService // сервис передачи файлов (может быть nil)
+	contacts     repository.Contacts
+	messages     repository.Messages
+}
+
+// NewChatService создаёт сервис чата, который хранит контакты и сообщения в репозиториях contacts и messages
+func NewChatService(host host.Host, config *P2PConfig, privKey crypto.PrivKey, pubKey crypto.PubKey, contacts repository.Contacts, messages repository.Messages) *ChatService {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	// Генерируем ключ для симметричного шифрования
-	encryptionKey := generateEncryptionKey()
-
 	return &ChatService{
-		host:          host,
-		config:        config,
-		ctx:           ctx,
-		cancel:        cancel,
-		messageQueue:  make(map[peer.ID][]*QueuedMessage),
-		localPrivKey:  privKey,
-		localPubKey:   pubKey,
-		encryptionKey: encryptionKey,
+		host:         host,
+		config:       config,
+		ctx:          ctx,
+		cancel:       cancel,
+		messageQueue: make(map[peer.ID][]*QueuedMessage),
+		localPrivKey: privKey,
+		localPubKey:  pubKey,
+		sessionKeys:  make(map[peer.ID][]byte),
+		contacts:     contacts,
+		messages:     messages,
 	}
 }
 
+// SetFileTransferService устанавливает сервис передачи файлов
+func (cs *ChatService) SetFileTransferService(fts *FileTransferService) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.fileTransfer = fts
+}
+
 // Start запускает сервис чата
 func (cs *ChatService) Start() error {
 	cs.mu.Lock()
@@ -156,20 +167,14 @@ func (cs *ChatService) SendMessage(ctx context.Context, peerID peer.ID, content,
 	}
 	msg.Signature = signature
 
-	// Шифруем сообщение если включено шифрование
-	if cs.encryptionKey != nil {
-		encrypted, nonce, err := cs.encryptMessage(msg)
-		if err != nil {
-			log.Printf("Предупреждение: не удалось зашифровать сообщение: %v", err)
-		} else {
-			msg = encrypted
-			msg.Nonce = nonce
-			msg.Encrypted = true
-		}
+	// Шифруем сообщение сессионным ключом пира
+	envelope, err := cs.sealEnvelope(peerID, msg)
+	if err != nil {
+		return fmt.Errorf("ошибка шифрования сообщения: %w", err)
 	}
 
-	// Сериализуем сообщение в JSON
-	data, err := json.Marshal(msg)
+	// Сериализуем конверт в JSON
+	data, err := json.Marshal(envelope)
 	if err != nil {
 		return fmt.Errorf("ошибка сериализации сообщения: %w", err)
 	}
@@ -205,7 +210,8 @@ func (cs *ChatService) SendMessage(ctx context.Context, peerID peer.ID, content,
 	n, err := stream.Read(ackBuf)
 	if err == nil && n == 1 && ackBuf[0] == 0x01 {
 		// Получили подтверждение - сохраняем в БД
-		return cs.saveMessage(peerID.String(), content, contentType, metadata, false)
+		_, err := cs.saveMessage(peerID.String(), content, contentType, metadata, false)
+		return err
 	}
 
 	// Подтверждение не получено - добавляем в очередь
@@ -226,7 +232,7 @@ func (cs *ChatService) HandleChatStream(stream network.Stream) {
 	log.Printf("Получен поток чата от: %s", remotePeer.String())
 
 	// Проверяем, не заблокирован ли пир
-	contact, err := queries.GetContactByPeerID(remotePeer.String())
+	contact, err := cs.contacts.GetContactByPeerID(remotePeer.String())
 	if err != nil {
 		log.Printf("Ошибка получения контакта: %v", err)
 		return
@@ -245,22 +251,18 @@ func (cs *ChatService) HandleChatStream(stream network.Stream) {
 		return
 	}
 
-	// Десериализуем сообщение из JSON
-	msg := &ChatMessage{}
-	if err := json.Unmarshal(data, msg); err != nil {
+	// Десериализуем конверт из JSON
+	envelope := &ChatMessage{}
+	if err := json.Unmarshal(data, envelope); err != nil {
 		log.Printf("Ошибка десериализации сообщения: %v", err)
 		return
 	}
 
-	// Расшифровываем сообщение если зашифровано
-	if msg.Encrypted && cs.encryptionKey != nil && len(msg.Nonce) > 0 {
-		decrypted, err := cs.decryptMessage(msg)
-		if err != nil {
-			log.Printf("Ошибка расшифровки сообщения: %v", err)
-			// Не расшифровываем - пробуем обработать как есть
-		} else {
-			msg = decrypted
-		}
+	// Расшифровываем конверт; незашифрованные и устаревшие сообщения отклоняются
+	msg, err := cs.openEnvelope(remotePeer, envelope)
+	if err != nil {
+		log.Printf("Сообщение от %s отклонено: %v", remotePeer, err)
+		return
 	}
 
 	// Проверяем подпись
@@ -269,8 +271,23 @@ func (cs *ChatService) HandleChatStream(stream network.Stream) {
 		return
 	}
 
+	// Для файлов сохраняем метаданные со статусом ожидания загрузки
+	metadata := msg.Metadata
+	isFile := msg.MessageType == MessageTypeFile || msg.MessageType == MessageTypeImage
+	if isFile {
+		fileMeta, err := ParseFileMetadata(msg.Metadata)
+		if err != nil {
+			log.Printf("Некорректные метаданные файла от %s: %v", remotePeer, err)
+			return
+		}
+		fileMeta.Status = FileStatusPending
+		fileMeta.Error = ""
+		metadata = fileMeta.String()
+	}
+
 	// Сохраняем сообщение в БД
-	if err := cs.saveMessage(remotePeer.String(), msg.Content, msg.ContentType, msg.Metadata, true); err != nil {
+	saved, err := cs.saveMessage(remotePeer.String(), msg.Content, msg.ContentType, metadata, true)
+	if err != nil {
 		log.Printf("Ошибка сохранения сообщения: %v", err)
 		return
 	}
@@ -281,28 +298,40 @@ func (cs *ChatService) HandleChatStream(stream network.Stream) {
 	}
 
 	log.Printf("Получено сообщение от %s: %s", remotePeer, msg.Content)
+
+	// Загружаем файл после подтверждения, чтобы не задерживать отправителя
+	if isFile {
+		go cs.downloadFile(remotePeer, saved)
+	}
 }
 
-// saveMessage сохраняет сообщение в базу данных
-func (cs *ChatService) saveMessage(fromPeerID, content, contentType, metadata string, isIncoming bool) error {
+// saveMessage сохраняет сообщение в базу данных.
+// remotePeerID - пир-собеседник; для исходящих сообщений отправителем
+// записывается локальный пир.
+func (cs *ChatService) saveMessage(remotePeerID, content, contentType, metadata string, isIncoming bool) (*models.ChatMessage, error) {
 	// Получаем контакт по PeerID
-	contact, err := queries.GetContactByPeerID(fromPeerID)
+	contact, err := cs.contacts.GetContactByPeerID(remotePeerID)
 	if err != nil {
 		// Контакт не найден - создаём временный
 		contact = &models.Contact{
-			PeerID:   fromPeerID,
-			Username: fromPeerID[:8],
+			PeerID:   remotePeerID,
+			Username: remotePeerID[:8],
 		}
-		if err := queries.CreateContact(contact); err != nil && !contains(err.Error(), "UNIQUE constraint") {
-			return fmt.Errorf("ошибка создания контакта: %w", err)
+		if err := cs.contacts.CreateContact(contact); err != nil && !contains(err.Error(), "UNIQUE constraint") {
+			return nil, fmt.Errorf("ошибка создания контакта: %w", err)
 		}
 		// Перечитываем контакт
-		contact, err = queries.GetContactByPeerID(fromPeerID)
+		contact, err = cs.contacts.GetContactByPeerID(remotePeerID)
 		if err != nil {
-			return fmt.Errorf("ошибка получения контакта: %w", err)
+			return nil, fmt.Errorf("ошибка получения контакта: %w", err)
 		}
 	}
 
+	fromPeerID := remotePeerID
+	if !isIncoming {
+		fromPeerID = cs.host.ID().String()
+	}
+
 	// Создаём сообщение
 	message := &models.ChatMessage{
 		ContactID:   contact.ID,
@@ -313,12 +342,12 @@ func (cs *ChatService) saveMessage(fromPeerID, content, contentType, metadata st
 		IsRead:      isIncoming, // Входящие считаем прочитанными
 	}
 
-	if err := queries.CreateChatMessage(message); err != nil {
-		return fmt.Errorf("ошибка сохранения сообщения: %w", err)
+	if err := cs.messages.CreateChatMessage(message); err != nil {
+		return nil, fmt.Errorf("ошибка сохранения сообщения: %w", err)
 	}
 
 	log.Printf("Сообщение сохранено в БД (ID: %d)", message.ID)
-	return nil
+	return message, nil
 }
 
 // queueMessage добавляет сообщение в очередь для оффлайн-пира
@@ -410,8 +439,14 @@ func (cs *ChatService) sendQueuedMessage(ctx context.Context, peerID peer.ID, ms
 	}
 	chatMsg.Signature = signature
 
+	// Шифруем сообщение сессионным ключом пира
+	envelope, err := cs.sealEnvelope(peerID, chatMsg)
+	if err != nil {
+		return fmt.Errorf("ошибка шифрования: %w", err)
+	}
+
 	// Сериализуем в JSON
-	data, err := json.Marshal(chatMsg)
+	data, err := json.Marshal(envelope)
 	if err != nil {
 		return fmt.Errorf("ошибка сериализации: %w", err)
 	}
@@ -442,7 +477,8 @@ func (cs *ChatService) sendQueuedMessage(ctx context.Context, peerID peer.ID, ms
 	n, err := stream.Read(ackBuf)
 	if err == nil && n == 1 && ackBuf[0] == 0x01 {
 		// Сохраняем в БД
-		return cs.saveMessage(peerID.String(), msg.Content, msg.ContentType, msg.Metadata, false)
+		_, err := cs.saveMessage(peerID.String(), msg.Content, msg.ContentType, msg.Metadata, false)
+		return err
 	}
 
 	return errors.New("подтверждение не получено")
@@ -498,70 +534,6 @@ func (cs *ChatService) verifyMessageSignature(msg *ChatMessage) bool {
 	return valid
 }
 
-// encryptMessage шифрует сообщение с использованием симметричного ключа
-func (cs *ChatService) encryptMessage(msg *ChatMessage) (*ChatMessage, []byte, error) {
-	if cs.encryptionKey == nil {
-		return msg, nil, errors.New("ключ шифрования не инициализирован")
-	}
-
-	// Сериализуем сообщение в JSON
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	// Генерируем nonce
-	nonce := make([]byte, 24)
-	if _, err := rand.Read(nonce); err != nil {
-		return nil, nil, fmt.Errorf("ошибка генерации nonce: %w", err)
-	}
-
-	// Шифруем XOR с ключом и nonce (упрощённое шифрование)
-	encrypted := make([]byte, len(data))
-	for i, b := range data {
-		encrypted[i] = b ^ cs.encryptionKey[i%len(cs.encryptionKey)] ^ nonce[i%len(nonce)]
-	}
-
-	// Создаём новое сообщение с зашифрованным контентом
-	encryptedMsg := &ChatMessage{
-		FromPeerID:  msg.FromPeerID,
-		ContentType: msg.ContentType,
-		Timestamp:   msg.Timestamp,
-		MessageType: msg.MessageType,
-		Content:     base64.StdEncoding.EncodeToString(encrypted),
-		Encrypted:   true,
-	}
-
-	return encryptedMsg, nonce, nil
-}
-
-// decryptMessage расшифровывает сообщение
-func (cs *ChatService) decryptMessage(msg *ChatMessage) (*ChatMessage, error) {
-	if cs.encryptionKey == nil || len(msg.Nonce) == 0 {
-		return nil, errors.New("ключ шифрования не инициализирован или nonce отсутствует")
-	}
-
-	// Декодируем зашифрованные данные
-	encrypted, err := base64.StdEncoding.DecodeString(msg.Content)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка декодирования: %w", err)
-	}
-
-	// Расшифровываем
-	decrypted := make([]byte, len(encrypted))
-	for i, b := range encrypted {
-		decrypted[i] = b ^ cs.encryptionKey[i%len(cs.encryptionKey)] ^ msg.Nonce[i%len(msg.Nonce)]
-	}
-
-	// Десериализуем сообщение из JSON
-	originalMsg := &ChatMessage{}
-	if err := json.Unmarshal(decrypted, originalMsg); err != nil {
-		return nil, fmt.Errorf("ошибка десериализации: %w", err)
-	}
-
-	return originalMsg, nil
-}
-
 // parseMessageType определяет тип сообщения по content type
 func (cs *ChatService) parseMessageType(contentType string) MessageType {
 	switch contentType {
@@ -596,42 +568,32 @@ func (cs *ChatService) ClearQueuedMessages(peerID peer.ID) {
 
 // GetMessagesForContact получает сообщения для контакта
 func (cs *ChatService) GetMessagesForContact(contactID int, limit, offset int) ([]*models.ChatMessage, error) {
-	return queries.GetMessagesForContact(contactID, limit, offset)
+	return cs.messages.GetMessagesForContact(contactID, limit, offset)
 }
 
 // GetUnreadMessagesCount получает количество непрочитанных сообщений
 func (cs *ChatService) GetUnreadMessagesCount(contactID int) (int, error) {
-	return queries.GetUnreadMessagesCount(contactID)
+	return cs.messages.GetUnreadMessagesCount(contactID)
 }
 
 // MarkMessageAsRead помечает сообщение как прочитанное
 func (cs *ChatService) MarkMessageAsRead(id int) error {
-	return queries.MarkMessageAsRead(id)
+	return cs.messages.MarkMessageAsRead(id)
 }
 
 // MarkAllMessagesAsRead помечает все сообщения для контакта как прочитанные
 func (cs *ChatService) MarkAllMessagesAsRead(contactID int) error {
-	return queries.MarkAllMessagesAsRead(contactID)
+	return cs.messages.MarkAllMessagesAsRead(contactID)
 }
 
 // DeleteMessage удаляет сообщение
 func (cs *ChatService) DeleteMessage(id int) error {
-	return queries.DeleteChatMessage(id)
+	return cs.messages.DeleteChatMessage(id)
 }
 
 // DeleteMessagesForContact удаляет все сообщения для контакта
 func (cs *ChatService) DeleteMessagesForContact(contactID int) error {
-	return queries.DeleteMessagesForContact(contactID)
-}
-
-// generateEncryptionKey генерирует ключ для симметричного шифрования
-func generateEncryptionKey() []byte {
-	key := make([]byte, 32)
-	if _, err := rand.Read(key); err != nil {
-		// Fallback к детерминированному ключу в случае ошибки
-		return []byte("projectt-chat-encryption-key-32")
-	}
-	return key
+	return cs.messages.DeleteMessagesForContact(contactID)
 }
 
 // contains проверяет, содержит ли строка подстроку
@@ -649,37 +611,106 @@ func findSubstring(s, substr string) bool {
 	return false
 }
 
-// SendFileMessage отправляет сообщение с файлом
+// SendFileMessage отправляет файл: файл сохраняется в локальное хранилище,
+// а получатель загружает его по хэшу через FileProtocolID
 func (cs *ChatService) SendFileMessage(ctx context.Context, peerID peer.ID, filePath, fileName, mimeType string) error {
-	// В реальной реализации здесь будет отправка файла через отдельный стрим
-	// Для пока просто создаём метаданные файла
-	metadata := map[string]string{
-		"file_name": fileName,
-		"file_path": filePath,
-		"mime_type": mimeType,
+	fts := cs.getFileTransfer()
+	if fts == nil {
+		return errors.New("сервис передачи файлов не инициализирован")
 	}
 
-	metadataJSON, err := json.Marshal(metadata)
+	meta, err := fts.PrepareFile(peerID, filePath, fileName, mimeType)
 	if err != nil {
-		return fmt.Errorf("ошибка сериализации метаданных: %w", err)
+		return err
 	}
 
-	content := fmt.Sprintf("Файл: %s", fileName)
-	return cs.SendMessage(ctx, peerID, content, "file", string(metadataJSON))
+	content := fmt.Sprintf("Файл: %s", meta.FileName)
+	return cs.SendMessage(ctx, peerID, content, "file", meta.String())
 }
 
 // SendImageMessage отправляет сообщение с изображением
 func (cs *ChatService) SendImageMessage(ctx context.Context, peerID peer.ID, imagePath, imageName string) error {
-	metadata := map[string]string{
-		"image_name": imageName,
-		"image_path": imagePath,
+	fts := cs.getFileTransfer()
+	if fts == nil {
+		return errors.New("сервис передачи файлов не инициализирован")
+	}
+
+	meta, err := fts.PrepareFile(peerID, imagePath, imageName, "")
+	if err != nil {
+		return err
+	}
+
+	content := fmt.Sprintf("Изображение: %s", meta.FileName)
+	return cs.SendMessage(ctx, peerID, content, "image", meta.String())
+}
+
+// ResumeFileTransfers возобновляет незавершённые загрузки файлов от пира
+func (cs *ChatService) ResumeFileTransfers(peerID peer.ID) {
+	contact, err := cs.contacts.GetContactByPeerID(peerID.String())
+	if err != nil || contact == nil {
+		return
 	}
 
-	metadataJSON, err := json.Marshal(metadata)
+	messages, err := cs.messages.GetFileMessagesForContact(contact.ID)
 	if err != nil {
-		return fmt.Errorf("ошибка сериализации метаданных: %w", err)
+		log.Printf("Ошибка получения файловых сообщений: %v", err)
+		return
 	}
 
-	content := fmt.Sprintf("Изображение: %s", imageName)
-	return cs.SendMessage(ctx, peerID, content, "image", string(metadataJSON))
+	for _, message := range messages {
+		if message.FromPeerID != peerID.String() {
+			continue
+		}
+		meta, err := ParseFileMetadata(message.Metadata)
+		if err != nil {
+			continue
+		}
+		if meta.Status == FileStatusPending || meta.Status == FileStatusFailed {
+			cs.downloadFile(peerID, message)
+		}
+	}
+}
+
+// downloadFile загружает файл из сообщения и обновляет его метаданные
+func (cs *ChatService) downloadFile(peerID peer.ID, message *models.ChatMessage) {
+	fts := cs.getFileTransfer()
+	if fts == nil || message == nil {
+		return
+	}
+
+	meta, err := ParseFileMetadata(message.Metadata)
+	if err != nil {
+		log.Printf("Ошибка разбора метаданных файла: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(cs.ctx, 30*time.Minute)
+	defer cancel()
+
+	fileData, err := fts.Download(ctx, peerID, meta)
+	if err != nil {
+		log.Printf("Ошибка загрузки файла %s от %s: %v", meta.FileName, peerID, err)
+		meta.Status = FileStatusFailed
+		meta.Error = err.Error()
+	} else {
+		// Метаданные ссылаются на файл в локальном хранилище
+		meta.FileHash = fileData.Hash
+		meta.Size = fileData.Size
+		meta.Status = FileStatusReceived
+		meta.Error = ""
+		log.Printf("Файл %s получен от %s (%d байт)", meta.FileName, peerID, fileData.Size)
+	}
+
+	message.Metadata = meta.String()
+	if err := cs.messages.UpdateChatMessageMetadata(message.ID, message.Metadata); err != nil {
+		log.Printf("Ошибка обновления метаданных сообщения: %v", err)
+	}
+}
+
+// getFileTransfer возвращает сервис передачи файлов
+func (cs *ChatService) getFileTransfer() *FileTransferService {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	return cs.fileTransfer
 }
@@ -0,0 +1,293 @@
+package p2p
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+
+	"projectT/internal/storage/database/queries"
+)
+
+// ChatEnvelopeVersion текущая версия конверта сообщений чата.
+// Сообщения без версии (старые сборки с XOR-шифрованием) и сообщения
+// другой версии отклоняются.
+const ChatEnvelopeVersion uint32 = 2
+
+// chatKDFInfo контекст HKDF для выработки сессионных ключей чата
+const chatKDFInfo = "projectt/chat/session-key/v2"
+
+var (
+	// ErrPlaintextMessage сообщение пришло без шифрования
+	ErrPlaintextMessage = errors.New("сообщение не зашифровано: отправитель использует устаревшую версию клиента")
+	// ErrUnsupportedEnvelope версия конверта не поддерживается
+	ErrUnsupportedEnvelope = errors.New("неподдерживаемая версия конверта сообщения")
+	// ErrUnsupportedKeyType тип ключа не поддерживает ECDH
+	ErrUnsupportedKeyType = errors.New("для шифрования чата поддерживаются только ключи Ed25519")
+)
+
+// curve25519P модуль поля Curve25519: 2^255 - 19
+var curve25519P = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+
+// sealEnvelope шифрует подписанное сообщение для пира и возвращает конверт.
+// Внутреннее сообщение целиком (включая подпись) сериализуется и шифруется
+// XChaCha20-Poly1305; заголовок конверта аутентифицируется как AAD.
+func (cs *ChatService) sealEnvelope(peerID peer.ID, msg *ChatMessage) (*ChatMessage, error) {
+	key, err := cs.sessionKey(peerID)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка инициализации шифра: %w", err)
+	}
+
+	plaintext, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сериализации сообщения: %w", err)
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("ошибка генерации nonce: %w", err)
+	}
+
+	envelope := &ChatMessage{
+		FromPeerID: msg.FromPeerID,
+		Timestamp:  msg.Timestamp,
+		Version:    ChatEnvelopeVersion,
+		Encrypted:  true,
+		Nonce:      nonce,
+	}
+	envelope.Ciphertext = aead.Seal(nil, nonce, plaintext, envelopeAAD(envelope, peerID))
+
+	return envelope, nil
+}
+
+// openEnvelope проверяет и расшифровывает конверт, полученный от пира
+func (cs *ChatService) openEnvelope(remotePeer peer.ID, envelope *ChatMessage) (*ChatMessage, error) {
+	if !envelope.Encrypted {
+		return nil, ErrPlaintextMessage
+	}
+	if envelope.Version != ChatEnvelopeVersion {
+		return nil, fmt.Errorf("%w: %d (ожидается %d)", ErrUnsupportedEnvelope, envelope.Version, ChatEnvelopeVersion)
+	}
+	if envelope.FromPeerID != remotePeer.String() {
+		return nil, fmt.Errorf("отправитель конверта %s не совпадает с пиром соединения %s", envelope.FromPeerID, remotePeer)
+	}
+
+	key, err := cs.sessionKey(remotePeer)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка инициализации шифра: %w", err)
+	}
+	if len(envelope.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("неверная длина nonce: %d", len(envelope.Nonce))
+	}
+
+	plaintext, err := aead.Open(nil, envelope.Nonce, envelope.Ciphertext, envelopeAAD(envelope, cs.host.ID()))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка расшифровки сообщения: %w", err)
+	}
+
+	msg := &ChatMessage{}
+	if err := json.Unmarshal(plaintext, msg); err != nil {
+		return nil, fmt.Errorf("ошибка десериализации сообщения: %w", err)
+	}
+
+	if msg.FromPeerID != envelope.FromPeerID || msg.Timestamp != envelope.Timestamp {
+		return nil, errors.New("заголовок конверта не совпадает с содержимым сообщения")
+	}
+
+	return msg, nil
+}
+
+// envelopeAAD формирует дополнительные аутентифицированные данные конверта
+func envelopeAAD(envelope *ChatMessage, toPeerID peer.ID) []byte {
+	return []byte(fmt.Sprintf("%s|v%d|%s|%s|%d",
+		ChatProtocolID, envelope.Version, envelope.FromPeerID, toPeerID.String(), envelope.Timestamp))
+}
+
+// sessionKey возвращает сессионный ключ для пира, вычисляя его при первом обращении
+func (cs *ChatService) sessionKey(peerID peer.ID) ([]byte, error) {
+	cs.keysMu.Lock()
+	defer cs.keysMu.Unlock()
+
+	if key, ok := cs.sessionKeys[peerID]; ok {
+		return key, nil
+	}
+
+	if cs.localPrivKey == nil {
+		return nil, errors.New("приватный ключ не установлен")
+	}
+
+	remotePubKey, err := cs.peerPublicKey(peerID)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := deriveSessionKey(cs.localPrivKey, cs.host.ID(), remotePubKey, peerID)
+	if err != nil {
+		return nil, err
+	}
+
+	cs.sessionKeys[peerID] = key
+	return key, nil
+}
+
+// ForgetSessionKey удаляет закэшированный сессионный ключ пира
+// (например, после обновления его ключей в profile_keys)
+func (cs *ChatService) ForgetSessionKey(peerID peer.ID) {
+	cs.keysMu.Lock()
+	defer cs.keysMu.Unlock()
+
+	delete(cs.sessionKeys, peerID)
+}
+
+// peerPublicKey возвращает публичный ключ пира. Ключ берётся из profile_keys,
+// затем из самого PeerID и из peerstore; в любом случае ключ обязан
+// соответствовать PeerID.
+func (cs *ChatService) peerPublicKey(peerID peer.ID) (crypto.PubKey, error) {
+	candidates := make([]crypto.PubKey, 0, 3)
+
+	if profile, err := queries.GetProfileByPeerID(peerID.String()); err == nil && profile != nil {
+		if keys, err := queries.GetProfileKeys(profile.ID); err == nil && len(keys.PublicKey) > 0 {
+			if pubKey, err := crypto.UnmarshalPublicKey(keys.PublicKey); err == nil {
+				candidates = append(candidates, pubKey)
+			}
+		}
+	}
+
+	if pubKey, err := peerID.ExtractPublicKey(); err == nil && pubKey != nil {
+		candidates = append(candidates, pubKey)
+	}
+
+	if cs.host != nil {
+		if pubKey := cs.host.Peerstore().PubKey(peerID); pubKey != nil {
+			candidates = append(candidates, pubKey)
+		}
+	}
+
+	for _, pubKey := range candidates {
+		if peerID.MatchesPublicKey(pubKey) {
+			return pubKey, nil
+		}
+	}
+
+	return nil, fmt.Errorf("публичный ключ пира %s не найден", peerID)
+}
+
+// deriveSessionKey вычисляет общий ключ двух пиров: X25519 над ключами,
+// полученными из Ed25519-идентичностей, и HKDF-SHA256. Результат одинаков
+// на обеих сторонах.
+func deriveSessionKey(localPriv crypto.PrivKey, localID peer.ID, remotePub crypto.PubKey, remoteID peer.ID) ([]byte, error) {
+	scalar, err := ed25519PrivateToX25519(localPriv)
+	if err != nil {
+		return nil, err
+	}
+
+	point, err := ed25519PublicToX25519(remotePub)
+	if err != nil {
+		return nil, err
+	}
+
+	shared, err := curve25519.X25519(scalar, point)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка ECDH: %w", err)
+	}
+
+	// Соль не зависит от направления: идентификаторы упорядочены
+	first, second := []byte(localID), []byte(remoteID)
+	if bytes.Compare(first, second) > 0 {
+		first, second = second, first
+	}
+	salt := append(append([]byte{}, first...), second...)
+
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, salt, []byte(chatKDFInfo)), key); err != nil {
+		return nil, fmt.Errorf("ошибка выработки ключа: %w", err)
+	}
+
+	return key, nil
+}
+
+// ed25519PrivateToX25519 преобразует приватный ключ Ed25519 в скаляр X25519 (RFC 8032, 5.1.5)
+func ed25519PrivateToX25519(privKey crypto.PrivKey) ([]byte, error) {
+	if privKey.Type() != crypto.Ed25519 {
+		return nil, ErrUnsupportedKeyType
+	}
+
+	raw, err := privKey.Raw()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения приватного ключа: %w", err)
+	}
+	if len(raw) < 32 {
+		return nil, errors.New("неверная длина приватного ключа Ed25519")
+	}
+
+	digest := sha512.Sum512(raw[:32])
+	scalar := digest[:32]
+	scalar[0] &= 248
+	scalar[31] &= 127
+	scalar[31] |= 64
+
+	return scalar, nil
+}
+
+// ed25519PublicToX25519 преобразует публичный ключ Ed25519 в координату u
+// Curve25519: u = (1 + y) / (1 - y) mod p
+func ed25519PublicToX25519(pubKey crypto.PubKey) ([]byte, error) {
+	if pubKey.Type() != crypto.Ed25519 {
+		return nil, ErrUnsupportedKeyType
+	}
+
+	raw, err := pubKey.Raw()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения публичного ключа: %w", err)
+	}
+	if len(raw) != 32 {
+		return nil, errors.New("неверная длина публичного ключа Ed25519")
+	}
+
+	// Координата y хранится в little-endian, старший бит - знак x
+	yBytes := make([]byte, 32)
+	for i := 0; i < 32; i++ {
+		yBytes[i] = raw[31-i]
+	}
+	yBytes[0] &= 0x7f
+	y := new(big.Int).SetBytes(yBytes)
+
+	numerator := new(big.Int).Add(big.NewInt(1), y)
+	denominator := new(big.Int).Sub(big.NewInt(1), y)
+	denominator.Mod(denominator, curve25519P)
+	if denominator.Sign() == 0 {
+		return nil, errors.New("некорректный публичный ключ Ed25519")
+	}
+	denominator.ModInverse(denominator, curve25519P)
+
+	u := numerator.Mul(numerator, denominator)
+	u.Mod(u, curve25519P)
+
+	uBytes := u.FillBytes(make([]byte, 32))
+	for i, j := 0, 31; i < j; i, j = i+1, j-1 {
+		uBytes[i], uBytes[j] = uBytes[j], uBytes[i]
+	}
+
+	return uBytes, nil
+}
@@ -2,9 +2,11 @@
 package p2p
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -13,6 +15,9 @@ import (
 	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
+
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/database/repository"
 )
 
 // createTestHost создаёт тестовый хост для использования в тестах
@@ -43,6 +48,45 @@ func createTestHost(t *testing.T, port int) host.Host {
 	return h
 }
 
+// setupChatTestDB подключает тестовую базу данных в памяти
+func setupChatTestDB(t *testing.T) {
+	t.Helper()
+
+	db, err := database.Open(":memory:")
+	if err != nil {
+		t.Fatalf("Ошибка открытия БД: %v", err)
+	}
+
+	originalDB := database.GetDB()
+	database.SetDB(db)
+	database.RunMigrations()
+
+	t.Cleanup(func() {
+		database.CloseDB()
+		database.SetDB(originalDB)
+	})
+}
+
+// createTestChatService создаёт ChatService на тестовом хосте с ключом хоста
+func createTestChatService(t *testing.T) *ChatService {
+	t.Helper()
+
+	privKey, pubKey, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatalf("Ошибка генерации ключей: %v", err)
+	}
+
+	h, err := libp2p.New(libp2p.Identity(privKey), libp2p.NoListenAddrs)
+	if err != nil {
+		t.Fatalf("Ошибка создания хоста: %v", err)
+	}
+	t.Cleanup(func() {
+		h.Close()
+	})
+
+	return NewChatService(h, DefaultConfig(), privKey, pubKey, repository.Default().Contacts, repository.Default().Messages)
+}
+
 // TestChatServiceCreation тестирует создание ChatService
 func TestChatServiceCreation(t *testing.T) {
 	privKey, _, err := crypto.GenerateEd25519Key(rand.Reader)
@@ -54,7 +98,7 @@ func TestChatServiceCreation(t *testing.T) {
 
 	config := DefaultConfig()
 	pubKey := privKey.GetPublic()
-	chatService := NewChatService(host, config, privKey, pubKey)
+	chatService := NewChatService(host, config, privKey, pubKey, repository.Default().Contacts, repository.Default().Messages)
 
 	if chatService == nil {
 		t.Fatal("ChatService не создан")
@@ -84,7 +128,7 @@ func TestChatServiceStartStop(t *testing.T) {
 
 	config := DefaultConfig()
 	pubKey := privKey.GetPublic()
-	chatService := NewChatService(host, config, privKey, pubKey)
+	chatService := NewChatService(host, config, privKey, pubKey, repository.Default().Contacts, repository.Default().Messages)
 
 	if err := chatService.Start(); err != nil {
 		t.Fatalf("Ошибка запуска ChatService: %v", err)
@@ -109,7 +153,7 @@ func TestQueueMessage(t *testing.T) {
 
 	config := DefaultConfig()
 	pubKey := privKey.GetPublic()
-	chatService := NewChatService(host, config, privKey, pubKey)
+	chatService := NewChatService(host, config, privKey, pubKey, repository.Default().Contacts, repository.Default().Messages)
 
 	peerID := host.ID()
 
@@ -142,7 +186,7 @@ func TestClearQueuedMessages(t *testing.T) {
 
 	config := DefaultConfig()
 	pubKey := privKey.GetPublic()
-	chatService := NewChatService(host, config, privKey, pubKey)
+	chatService := NewChatService(host, config, privKey, pubKey, repository.Default().Contacts, repository.Default().Messages)
 
 	peerID := host.ID()
 
@@ -171,7 +215,7 @@ func TestParseMessageType(t *testing.T) {
 
 	config := DefaultConfig()
 	pubKey := privKey.GetPublic()
-	chatService := NewChatService(host, config, privKey, pubKey)
+	chatService := NewChatService(host, config, privKey, pubKey, repository.Default().Contacts, repository.Default().Messages)
 
 	tests := []struct {
 		contentType string
@@ -210,7 +254,7 @@ func TestSignAndVerifyMessage(t *testing.T) {
 	defer host.Close()
 
 	config := DefaultConfig()
-	chatService := NewChatService(host, config, privKey, pubKey)
+	chatService := NewChatService(host, config, privKey, pubKey, repository.Default().Contacts, repository.Default().Messages)
 
 	msg := &ChatMessage{
 		FromPeerID:  host.ID().String(),
@@ -270,7 +314,7 @@ func TestSendMessageToOfflinePeer(t *testing.T) {
 
 	config := DefaultConfig()
 	pubKey := privKey.GetPublic()
-	chatService := NewChatService(host, config, privKey, pubKey)
+	chatService := NewChatService(host, config, privKey, pubKey, repository.Default().Contacts, repository.Default().Messages)
 
 	if err := chatService.Start(); err != nil {
 		t.Fatalf("Ошибка запуска ChatService: %v", err)
@@ -340,3 +384,138 @@ func TestChatMessageSerialization(t *testing.T) {
 		t.Errorf("MessageType: ожидается %v, получено %v", msg.MessageType, deserialized.MessageType)
 	}
 }
+
+// TestSessionKeySymmetric проверяет, что оба пира вырабатывают одинаковый сессионный ключ
+func TestSessionKeySymmetric(t *testing.T) {
+	setupChatTestDB(t)
+
+	alice := createTestChatService(t)
+	bob := createTestChatService(t)
+
+	aliceKey, err := alice.sessionKey(bob.host.ID())
+	if err != nil {
+		t.Fatalf("Ошибка выработки ключа: %v", err)
+	}
+	bobKey, err := bob.sessionKey(alice.host.ID())
+	if err != nil {
+		t.Fatalf("Ошибка выработки ключа: %v", err)
+	}
+
+	if !bytes.Equal(aliceKey, bobKey) {
+		t.Fatal("Сессионные ключи пиров не совпадают")
+	}
+
+	carol := createTestChatService(t)
+	carolKey, err := carol.sessionKey(bob.host.ID())
+	if err != nil {
+		t.Fatalf("Ошибка выработки ключа: %v", err)
+	}
+	if bytes.Equal(aliceKey, carolKey) {
+		t.Error("Сессионные ключи разных пар пиров совпадают")
+	}
+}
+
+// TestSealOpenEnvelope тестирует шифрование и расшифровку конверта
+func TestSealOpenEnvelope(t *testing.T) {
+	setupChatTestDB(t)
+
+	alice := createTestChatService(t)
+	bob := createTestChatService(t)
+
+	msg := &ChatMessage{
+		FromPeerID:  alice.host.ID().String(),
+		Content:     "Секретное сообщение",
+		ContentType: "text",
+		Metadata:    `{"key": "value"}`,
+		Timestamp:   time.Now().UnixNano(),
+		MessageType: MessageTypeText,
+	}
+	signature, err := alice.signMessage(msg)
+	if err != nil {
+		t.Fatalf("Ошибка подписи: %v", err)
+	}
+	msg.Signature = signature
+
+	envelope, err := alice.sealEnvelope(bob.host.ID(), msg)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+
+	if envelope.Content != "" || envelope.Metadata != "" {
+		t.Error("Конверт содержит открытый текст")
+	}
+	if envelope.Version != ChatEnvelopeVersion || !envelope.Encrypted {
+		t.Errorf("Неверный заголовок конверта: version=%d encrypted=%v", envelope.Version, envelope.Encrypted)
+	}
+
+	// Конверт проходит через JSON, как в сети
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatalf("Ошибка сериализации: %v", err)
+	}
+	received := &ChatMessage{}
+	if err := json.Unmarshal(data, received); err != nil {
+		t.Fatalf("Ошибка десериализации: %v", err)
+	}
+
+	opened, err := bob.openEnvelope(alice.host.ID(), received)
+	if err != nil {
+		t.Fatalf("Ошибка расшифровки: %v", err)
+	}
+	if opened.Content != msg.Content || opened.Metadata != msg.Metadata {
+		t.Errorf("Содержимое не совпадает: %q / %q", opened.Content, opened.Metadata)
+	}
+	if !bytes.Equal(opened.Signature, msg.Signature) {
+		t.Error("Подпись потеряна при расшифровке")
+	}
+
+	// Изменённый шифротекст не должен расшифровываться
+	tampered := *received
+	tampered.Ciphertext = append([]byte{}, received.Ciphertext...)
+	tampered.Ciphertext[0] ^= 0xff
+	if _, err := bob.openEnvelope(alice.host.ID(), &tampered); err == nil {
+		t.Error("Изменённый шифротекст прошёл проверку")
+	}
+
+	// Изменённый заголовок не должен расшифровываться
+	replayed := *received
+	replayed.Timestamp++
+	if _, err := bob.openEnvelope(alice.host.ID(), &replayed); err == nil {
+		t.Error("Изменённый заголовок прошёл проверку")
+	}
+
+	// Третий пир не может расшифровать сообщение
+	carol := createTestChatService(t)
+	if _, err := carol.openEnvelope(alice.host.ID(), received); err == nil {
+		t.Error("Чужой пир расшифровал сообщение")
+	}
+}
+
+// TestOpenEnvelopeRejectsLegacy проверяет отклонение открытых и устаревших сообщений
+func TestOpenEnvelopeRejectsLegacy(t *testing.T) {
+	setupChatTestDB(t)
+
+	alice := createTestChatService(t)
+	bob := createTestChatService(t)
+
+	plaintext := &ChatMessage{
+		FromPeerID:  alice.host.ID().String(),
+		Content:     "Старое сообщение",
+		Timestamp:   time.Now().UnixNano(),
+		MessageType: MessageTypeText,
+	}
+	if _, err := bob.openEnvelope(alice.host.ID(), plaintext); !errors.Is(err, ErrPlaintextMessage) {
+		t.Errorf("Ожидается ErrPlaintextMessage, получено: %v", err)
+	}
+
+	legacy := &ChatMessage{
+		FromPeerID: alice.host.ID().String(),
+		Content:    "c3RhcnlqIFhPUg==",
+		Timestamp:  time.Now().UnixNano(),
+		Encrypted:  true,
+		Nonce:      make([]byte, 24),
+	}
+	if _, err := bob.openEnvelope(alice.host.ID(), legacy); !errors.Is(err, ErrUnsupportedEnvelope) {
+		t.Errorf("Ожидается ErrUnsupportedEnvelope, получено: %v", err)
+	}
+}
@@ -14,6 +14,9 @@ const ProtocolID = "/projectt/1.0.0"
 // ChatProtocolID идентификатор протокола чата
 const ChatProtocolID = "/projectt/chat/1.0.0"
 
+// FileProtocolID идентификатор протокола передачи файлов
+const FileProtocolID = "/projectt/file/1.0.0"
+
 // HelperProtocolID идентификатор протокола помощника
 const HelperProtocolID = "/projectt/helper/1.0.0"
 
@@ -73,6 +76,12 @@ type P2PConfig struct {
 
 	// EnableHelperMode включить режим помощника (хранение адресов пиров)
 	EnableHelperMode bool
+
+	// MaxFileSize максимальный размер файла для передачи через чат (в байтах)
+	MaxFileSize int64
+
+	// LibraryRefreshInterval интервал фонового обновления библиотек подписанных контактов
+	LibraryRefreshInterval time.Duration
 }
 
 // DefaultConfig возвращает конфигурацию по умолчанию
@@ -97,5 +106,8 @@ func DefaultConfig() *P2PConfig {
 		BootstrapPeers:    []string{},
 		EnableSTUNClient:  false,
 		EnableHelperMode:  false,
+		MaxFileSize:       100 * 1024 * 1024,
+
+		LibraryRefreshInterval: 5 * time.Minute,
 	}
 }
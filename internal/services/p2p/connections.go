@@ -46,6 +46,7 @@ type ConnectionService struct {
 	peerStatus     map[peer.ID]*PeerConnectionInfo // статус пиров
 	reconnectQueue []peer.ID                       // очередь на переподключение
 	keepAliveFail  map[peer.ID]int                 // счётчик неудачных ping
+	onlineHandlers []func(peer.ID)                 // подписчики на выход пира в онлайн
 }
 
 // PeerConnectionInfo информация о подключении к пиру
@@ -198,6 +199,8 @@ func (cs *ConnectionService) checkConnections() {
 
 				// Обновляем время последней активности в БД
 				go cs.updateContactLastSeen(p)
+
+				cs.notifyPeerOnline(p)
 			}
 		} else {
 			// Новый пир
@@ -206,6 +209,8 @@ func (cs *ConnectionService) checkConnections() {
 				LastSeen: time.Now(),
 				AddedAt:  time.Now(),
 			}
+
+			cs.notifyPeerOnline(p)
 		}
 	}
 
@@ -482,6 +487,21 @@ func (cs *ConnectionService) updateContactLastSeen(peerID peer.ID) {
 	_ = queries.UpdateContactLastSeen(contact.ID, &now)
 }
 
+// OnPeerOnline регистрирует обработчик, вызываемый при переходе пира в статус connected
+func (cs *ConnectionService) OnPeerOnline(handler func(peer.ID)) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.onlineHandlers = append(cs.onlineHandlers, handler)
+}
+
+// notifyPeerOnline запускает обработчики выхода пира в онлайн (вызывается под cs.mu)
+func (cs *ConnectionService) notifyPeerOnline(peerID peer.ID) {
+	for _, handler := range cs.onlineHandlers {
+		go handler(peerID)
+	}
+}
+
 // GetConnectionStatus возвращает статус подключения к пиру
 func (cs *ConnectionService) GetConnectionStatus(peerID peer.ID) ConnectionStatus {
 	cs.mu.RLock()
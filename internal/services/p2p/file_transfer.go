@@ -0,0 +1,523 @@
+package p2p
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"projectT/internal/storage/database/queries"
+	"projectT/internal/storage/filesystem"
+)
+
+// FileChunkSize размер одного чанка при передаче файла
+const FileChunkSize = 64 * 1024
+
+// Статусы передачи файла в метаданных сообщения
+const (
+	// FileStatusPending файл ещё не получен
+	FileStatusPending = "pending"
+	// FileStatusReceived файл получен и сохранён в хранилище
+	FileStatusReceived = "received"
+	// FileStatusFailed передача завершилась ошибкой (будет возобновлена)
+	FileStatusFailed = "failed"
+	// FileStatusSent файл предложен получателю
+	FileStatusSent = "sent"
+)
+
+var (
+	// ErrFileTooLarge размер файла превышает лимит
+	ErrFileTooLarge = errors.New("размер файла превышает допустимый лимит")
+	// ErrFileHashMismatch хэш полученного файла не совпадает с ожидаемым
+	ErrFileHashMismatch = errors.New("хэш полученного файла не совпадает с ожидаемым")
+	// ErrFileNotOffered запрошенный файл не предлагался этому пиру
+	ErrFileNotOffered = errors.New("файл не предлагался этому пиру")
+)
+
+// FileMetadata метаданные файла в сообщении чата
+type FileMetadata struct {
+	FileHash string `json:"file_hash"`
+	FileName string `json:"file_name"`
+	MimeType string `json:"mime_type,omitempty"`
+	Size     int64  `json:"size"`
+	Status   string `json:"status,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// FileRequest запрос на получение файла с указанного смещения
+type FileRequest struct {
+	Hash   string `json:"hash"`
+	Offset int64  `json:"offset"`
+}
+
+// FileResponseHeader заголовок ответа, за которым следуют чанки
+type FileResponseHeader struct {
+	Size  int64  `json:"size"`
+	Error string `json:"error,omitempty"`
+}
+
+// FileTransferService сервис передачи файлов по протоколу /projectt/file/1.0.0.
+// Получатель сам запрашивает файл по хэшу, поэтому прерванная передача
+// продолжается с размера уже записанной части.
+type FileTransferService struct {
+	host   host.Host
+	config *P2PConfig
+	mu     sync.Mutex
+	offers map[string]map[peer.ID]string // хэш -> пиры, которым предложен файл, и путь к файлу
+	active map[string]bool               // хэши файлов, загружаемых в данный момент
+}
+
+// NewFileTransferService создаёт сервис передачи файлов
+func NewFileTransferService(host host.Host, config *P2PConfig) *FileTransferService {
+	return &FileTransferService{
+		host:   host,
+		config: config,
+		offers: make(map[string]map[peer.ID]string),
+		active: make(map[string]bool),
+	}
+}
+
+// Start запускает сервис передачи файлов
+func (fts *FileTransferService) Start() error {
+	fts.host.SetStreamHandler(FileProtocolID, fts.handleFileStream)
+	log.Println("FileTransferService запущен")
+	return nil
+}
+
+// Stop останавливает сервис передачи файлов
+func (fts *FileTransferService) Stop() error {
+	fts.host.RemoveStreamHandler(FileProtocolID)
+	log.Println("FileTransferService остановлен")
+	return nil
+}
+
+// maxFileSize возвращает лимит размера файла
+func (fts *FileTransferService) maxFileSize() int64 {
+	if fts.config == nil || fts.config.MaxFileSize <= 0 {
+		return DefaultConfig().MaxFileSize
+	}
+	return fts.config.MaxFileSize
+}
+
+// PrepareFile сохраняет файл в локальное хранилище и предлагает его пиру
+func (fts *FileTransferService) PrepareFile(peerID peer.ID, filePath, fileName, mimeType string) (*FileMetadata, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения информации о файле: %w", err)
+	}
+	if info.Size() > fts.maxFileSize() {
+		return nil, fmt.Errorf("%w: %d байт (лимит %d)", ErrFileTooLarge, info.Size(), fts.maxFileSize())
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла: %w", err)
+	}
+
+	if fileName == "" {
+		fileName = filepath.Base(filePath)
+	}
+
+	fileData, err := filesystem.SaveFileWithOriginalName(data, fileName)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сохранения файла в хранилище: %w", err)
+	}
+
+	if mimeType == "" {
+		mimeType = fileData.MimeType
+	}
+
+	fts.Offer(peerID, fileData.Hash, fileData.Path)
+
+	return &FileMetadata{
+		FileHash: fileData.Hash,
+		FileName: fileName,
+		MimeType: mimeType,
+		Size:     fileData.Size,
+		Status:   FileStatusSent,
+	}, nil
+}
+
+// Offer разрешает пиру скачать файл из хранилища
+func (fts *FileTransferService) Offer(peerID peer.ID, hash, path string) {
+	fts.mu.Lock()
+	defer fts.mu.Unlock()
+
+	if fts.offers[hash] == nil {
+		fts.offers[hash] = make(map[peer.ID]string)
+	}
+	fts.offers[hash][peerID] = path
+}
+
+// offeredPath возвращает путь к файлу, если он предлагался пиру.
+// После перезапуска предложения восстанавливаются из исходящих сообщений чата.
+func (fts *FileTransferService) offeredPath(peerID peer.ID, hash string) (string, error) {
+	fts.mu.Lock()
+	path, ok := fts.offers[hash][peerID]
+	fts.mu.Unlock()
+	if ok {
+		return path, nil
+	}
+
+	contact, err := queries.GetContactByPeerID(peerID.String())
+	if err != nil || contact == nil {
+		return "", ErrFileNotOffered
+	}
+
+	messages, err := queries.GetFileMessagesForContact(contact.ID)
+	if err != nil {
+		return "", fmt.Errorf("ошибка получения сообщений: %w", err)
+	}
+
+	for _, message := range messages {
+		// Предлагать можно только файлы из исходящих сообщений
+		if message.FromPeerID != fts.host.ID().String() {
+			continue
+		}
+		meta, err := ParseFileMetadata(message.Metadata)
+		if err != nil || meta.FileHash != hash || meta.Status != FileStatusSent {
+			continue
+		}
+
+		path := filesystem.GetFilePathWithExtension(hash, filepath.Ext(meta.FileName))
+		if _, err := os.Stat(path); err != nil {
+			path = filesystem.GetFilePathByHash(hash)
+		}
+		fts.Offer(peerID, hash, path)
+		return path, nil
+	}
+
+	return "", ErrFileNotOffered
+}
+
+// handleFileStream отдаёт запрошенный файл чанками
+func (fts *FileTransferService) handleFileStream(stream network.Stream) {
+	defer stream.Close()
+
+	remotePeer := stream.Conn().RemotePeer()
+
+	if blocked, err := queries.IsContactBlocked(remotePeer.String()); err == nil && blocked {
+		log.Printf("Пир %s заблокирован, запрос файла отклонён", remotePeer)
+		return
+	}
+
+	if err := stream.SetReadDeadline(time.Now().Add(30 * time.Second)); err != nil {
+		log.Printf("Предупреждение: не удалось установить таймаут: %v", err)
+	}
+
+	var req FileRequest
+	if err := json.NewDecoder(io.LimitReader(stream, 4096)).Decode(&req); err != nil {
+		log.Printf("Ошибка чтения запроса файла: %v", err)
+		return
+	}
+
+	writer := bufio.NewWriter(stream)
+	defer func() {
+		if err := writer.Flush(); err != nil {
+			log.Printf("Ошибка flush: %v", err)
+		}
+	}()
+
+	file, size, err := fts.openOfferedFile(remotePeer, &req)
+	if err != nil {
+		log.Printf("Запрос файла %s от %s отклонён: %v", req.Hash, remotePeer, err)
+		_ = json.NewEncoder(writer).Encode(&FileResponseHeader{Error: err.Error()})
+		return
+	}
+	defer file.Close()
+
+	// Заголовок пишется без перевода строки: сразу за ним идут чанки
+	header, err := json.Marshal(&FileResponseHeader{Size: size})
+	if err != nil {
+		log.Printf("Ошибка сериализации заголовка: %v", err)
+		return
+	}
+	if _, err := writer.Write(header); err != nil {
+		log.Printf("Ошибка отправки заголовка: %v", err)
+		return
+	}
+
+	if err := sendChunks(writer, file); err != nil {
+		log.Printf("Ошибка отправки файла %s пиру %s: %v", req.Hash, remotePeer, err)
+	}
+}
+
+// openOfferedFile открывает предложенный пиру файл и позиционирует его на req.Offset.
+// Файл зашифрованного хранилища отдаётся расшифрованным.
+func (fts *FileTransferService) openOfferedFile(remotePeer peer.ID, req *FileRequest) (io.ReadCloser, int64, error) {
+	if !filesystem.IsValidHash(req.Hash) {
+		return nil, 0, errors.New("некорректный хэш файла")
+	}
+
+	path, err := fts.offeredPath(remotePeer, req.Hash)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	file, size, err := filesystem.OpenBlobFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, 0, fmt.Errorf("файл с хэшем %s не найден", req.Hash)
+		}
+		return nil, 0, fmt.Errorf("ошибка открытия файла: %w", err)
+	}
+
+	if size > fts.maxFileSize() {
+		file.Close()
+		return nil, 0, ErrFileTooLarge
+	}
+	if req.Offset < 0 || req.Offset > size {
+		file.Close()
+		return nil, 0, fmt.Errorf("некорректное смещение: %d", req.Offset)
+	}
+
+	// Зашифрованный поток не поддерживает Seek, поэтому начало пропускается чтением
+	if _, err := io.CopyN(io.Discard, file, req.Offset); err != nil {
+		file.Close()
+		return nil, 0, fmt.Errorf("ошибка позиционирования: %w", err)
+	}
+
+	return file, size, nil
+}
+
+// sendChunks отправляет остаток файла чанками и завершает передачу пустым чанком
+func sendChunks(writer io.Writer, file io.Reader) error {
+	buf := make([]byte, FileChunkSize)
+	for {
+		n, readErr := file.Read(buf)
+		if n > 0 {
+			if err := writeChunk(writer, buf[:n]); err != nil {
+				return err
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return fmt.Errorf("ошибка чтения файла: %w", readErr)
+		}
+	}
+
+	// Чанк нулевой длины - конец передачи
+	return writeChunk(writer, nil)
+}
+
+// Download скачивает файл у пира и сохраняет его в хранилище.
+// Уже полученная часть файла хранится в GetTransfersDir и используется
+// для продолжения передачи.
+func (fts *FileTransferService) Download(ctx context.Context, peerID peer.ID, meta *FileMetadata) (*filesystem.FileData, error) {
+	if !filesystem.IsValidHash(meta.FileHash) {
+		return nil, errors.New("некорректный хэш файла")
+	}
+	if meta.Size > fts.maxFileSize() {
+		return nil, fmt.Errorf("%w: %d байт (лимит %d)", ErrFileTooLarge, meta.Size, fts.maxFileSize())
+	}
+
+	// Файл уже есть в хранилище
+	if filesystem.Exists(meta.FileHash) {
+		return filesystem.GetFileInfo(meta.FileHash)
+	}
+
+	fts.mu.Lock()
+	if fts.active[meta.FileHash] {
+		fts.mu.Unlock()
+		return nil, errors.New("файл уже загружается")
+	}
+	fts.active[meta.FileHash] = true
+	fts.mu.Unlock()
+
+	defer func() {
+		fts.mu.Lock()
+		delete(fts.active, meta.FileHash)
+		fts.mu.Unlock()
+	}()
+
+	partPath := filepath.Join(filesystem.GetTransfersDir(), meta.FileHash+".part")
+	if err := filesystem.EnsureParentDir(partPath); err != nil {
+		return nil, fmt.Errorf("ошибка создания директории: %w", err)
+	}
+
+	if err := fts.fetch(ctx, peerID, meta, partPath); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(partPath)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения полученного файла: %w", err)
+	}
+
+	if filesystem.CalculateHash(data) != meta.FileHash {
+		_ = os.Remove(partPath)
+		return nil, ErrFileHashMismatch
+	}
+
+	fileData, err := filesystem.SaveFileWithOriginalName(data, meta.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сохранения файла: %w", err)
+	}
+
+	if err := os.Remove(partPath); err != nil {
+		log.Printf("Предупреждение: не удалось удалить временный файл: %v", err)
+	}
+
+	return fileData, nil
+}
+
+// fetch дописывает в partPath недостающую часть файла
+func (fts *FileTransferService) fetch(ctx context.Context, peerID peer.ID, meta *FileMetadata, partPath string) error {
+	part, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("ошибка открытия временного файла: %w", err)
+	}
+	defer part.Close()
+
+	info, err := part.Stat()
+	if err != nil {
+		return fmt.Errorf("ошибка получения информации о файле: %w", err)
+	}
+
+	offset := info.Size()
+	if offset > meta.Size {
+		// Часть повреждена - начинаем заново
+		offset = 0
+	}
+	if err := part.Truncate(offset); err != nil {
+		return fmt.Errorf("ошибка усечения временного файла: %w", err)
+	}
+	if _, err := part.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("ошибка позиционирования: %w", err)
+	}
+
+	if offset == meta.Size {
+		return nil
+	}
+
+	stream, err := fts.host.NewStream(ctx, peerID, FileProtocolID)
+	if err != nil {
+		return fmt.Errorf("ошибка создания стрима: %w", err)
+	}
+	defer stream.Close()
+
+	writer := bufio.NewWriter(stream)
+	if err := json.NewEncoder(writer).Encode(&FileRequest{Hash: meta.FileHash, Offset: offset}); err != nil {
+		return fmt.Errorf("ошибка отправки запроса: %w", err)
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("ошибка flush: %w", err)
+	}
+	if err := stream.CloseWrite(); err != nil {
+		log.Printf("Предупреждение: не удалось закрыть запись стрима: %v", err)
+	}
+
+	decoder := json.NewDecoder(stream)
+	var header FileResponseHeader
+	if err := decoder.Decode(&header); err != nil {
+		return fmt.Errorf("ошибка чтения заголовка: %w", err)
+	}
+	if header.Error != "" {
+		return fmt.Errorf("пир отклонил запрос: %s", header.Error)
+	}
+	if header.Size != meta.Size {
+		return fmt.Errorf("размер файла у пира (%d) не совпадает с заявленным (%d)", header.Size, meta.Size)
+	}
+
+	reader := bufio.NewReader(io.MultiReader(decoder.Buffered(), stream))
+	buf := make([]byte, FileChunkSize)
+	for {
+		if err := stream.SetReadDeadline(time.Now().Add(30 * time.Second)); err != nil {
+			log.Printf("Предупреждение: не удалось установить таймаут: %v", err)
+		}
+
+		chunk, err := readChunk(reader, buf)
+		if err != nil {
+			return fmt.Errorf("передача прервана на %d байт: %w", offset, err)
+		}
+		if len(chunk) == 0 {
+			break
+		}
+
+		if offset+int64(len(chunk)) > meta.Size {
+			return fmt.Errorf("%w: пир прислал больше заявленного", ErrFileTooLarge)
+		}
+		if _, err := part.Write(chunk); err != nil {
+			return fmt.Errorf("ошибка записи временного файла: %w", err)
+		}
+		offset += int64(len(chunk))
+	}
+
+	if offset != meta.Size {
+		return fmt.Errorf("получено %d байт из %d", offset, meta.Size)
+	}
+
+	return part.Sync()
+}
+
+// writeChunk записывает чанк: длина (uint32, big-endian) и данные
+func writeChunk(w io.Writer, data []byte) error {
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(data)))
+	if _, err := w.Write(size[:]); err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// readChunk читает чанк в buf; пустой срез означает конец передачи
+func readChunk(r io.Reader, buf []byte) ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+
+	n := binary.BigEndian.Uint32(size[:])
+	if int(n) > len(buf) {
+		return nil, fmt.Errorf("размер чанка %d превышает %d", n, len(buf))
+	}
+	if _, err := io.ReadFull(r, buf[:n]); err != nil {
+		return nil, err
+	}
+
+	return buf[:n], nil
+}
+
+// ParseFileMetadata разбирает метаданные файла из сообщения чата
+func ParseFileMetadata(metadata string) (*FileMetadata, error) {
+	if metadata == "" {
+		return nil, errors.New("метаданные файла отсутствуют")
+	}
+
+	meta := &FileMetadata{}
+	if err := json.Unmarshal([]byte(metadata), meta); err != nil {
+		return nil, fmt.Errorf("ошибка разбора метаданных файла: %w", err)
+	}
+	if meta.FileHash == "" {
+		return nil, errors.New("в метаданных отсутствует хэш файла")
+	}
+
+	return meta, nil
+}
+
+// String сериализует метаданные для сохранения в chat_messages.metadata
+func (m *FileMetadata) String() string {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,208 @@
+package p2p
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"projectT/internal/storage/filesystem"
+)
+
+// testStorageConfig конфигурация хранилища для тестов
+type testStorageConfig struct {
+	path string
+}
+
+func (c testStorageConfig) GetPath() string     { return c.path }
+func (c testStorageConfig) GetFilesDir() string { return "files" }
+
+// setupFileTransfer создаёт два соединённых хоста с сервисами передачи файлов
+func setupFileTransfer(t *testing.T, maxFileSize int64) (*FileTransferService, *FileTransferService) {
+	t.Helper()
+
+	setupChatTestDB(t)
+
+	root := filesystem.GetStorageRoot()
+	filesystem.InitStorage(testStorageConfig{path: t.TempDir()})
+	t.Cleanup(func() {
+		filesystem.InitStorage(testStorageConfig{path: root})
+	})
+
+	config := DefaultConfig()
+	config.MaxFileSize = maxFileSize
+
+	senderHost := createTestHost(t, 0)
+	receiverHost := createTestHost(t, 0)
+	connectTestHosts(t, receiverHost, senderHost)
+
+	sender := NewFileTransferService(senderHost, config)
+	if err := sender.Start(); err != nil {
+		t.Fatalf("Ошибка запуска FileTransferService: %v", err)
+	}
+	receiver := NewFileTransferService(receiverHost, config)
+	if err := receiver.Start(); err != nil {
+		t.Fatalf("Ошибка запуска FileTransferService: %v", err)
+	}
+
+	return sender, receiver
+}
+
+// connectTestHosts соединяет два тестовых хоста
+func connectTestHosts(t *testing.T, from, to host.Host) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := from.Connect(ctx, peer.AddrInfo{ID: to.ID(), Addrs: to.Addrs()}); err != nil {
+		t.Fatalf("Ошибка подключения хостов: %v", err)
+	}
+}
+
+// writeTestFile создаёт временный файл со случайным содержимым
+func writeTestFile(t *testing.T, size int) (string, []byte) {
+	t.Helper()
+
+	data := make([]byte, size)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatalf("Ошибка генерации данных: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "photo.png")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Ошибка записи файла: %v", err)
+	}
+
+	return path, data
+}
+
+// TestFileTransferDownload тестирует передачу файла из нескольких чанков
+func TestFileTransferDownload(t *testing.T) {
+	sender, receiver := setupFileTransfer(t, 10*1024*1024)
+
+	path, data := writeTestFile(t, 3*FileChunkSize+123)
+	hash := filesystem.CalculateHash(data)
+	sender.Offer(receiver.host.ID(), hash, path)
+
+	meta := &FileMetadata{FileHash: hash, FileName: "photo.png", Size: int64(len(data))}
+	fileData, err := receiver.Download(context.Background(), sender.host.ID(), meta)
+	if err != nil {
+		t.Fatalf("Ошибка загрузки файла: %v", err)
+	}
+
+	if fileData.Hash != hash {
+		t.Errorf("Хэш: ожидается %s, получено %s", hash, fileData.Hash)
+	}
+
+	stored, err := os.ReadFile(fileData.Path)
+	if err != nil {
+		t.Fatalf("Файл не сохранён в хранилище: %v", err)
+	}
+	if !bytes.Equal(stored, data) {
+		t.Error("Содержимое сохранённого файла не совпадает")
+	}
+
+	if _, err := os.Stat(filepath.Join(filesystem.GetTransfersDir(), hash+".part")); !os.IsNotExist(err) {
+		t.Error("Временный файл не удалён после загрузки")
+	}
+}
+
+// TestFileTransferResume тестирует продолжение прерванной передачи
+func TestFileTransferResume(t *testing.T) {
+	sender, receiver := setupFileTransfer(t, 10*1024*1024)
+
+	path, data := writeTestFile(t, 2*FileChunkSize+10)
+	hash := filesystem.CalculateHash(data)
+	sender.Offer(receiver.host.ID(), hash, path)
+
+	// Имитируем прерванную передачу: первая часть уже получена
+	partPath := filepath.Join(filesystem.GetTransfersDir(), hash+".part")
+	if err := filesystem.EnsureParentDir(partPath); err != nil {
+		t.Fatalf("Ошибка создания директории: %v", err)
+	}
+	if err := os.WriteFile(partPath, data[:FileChunkSize+5], 0644); err != nil {
+		t.Fatalf("Ошибка записи части файла: %v", err)
+	}
+
+	meta := &FileMetadata{FileHash: hash, FileName: "photo.png", Size: int64(len(data))}
+	fileData, err := receiver.Download(context.Background(), sender.host.ID(), meta)
+	if err != nil {
+		t.Fatalf("Ошибка продолжения загрузки: %v", err)
+	}
+	if fileData.Hash != hash {
+		t.Errorf("Хэш: ожидается %s, получено %s", hash, fileData.Hash)
+	}
+}
+
+// TestFileTransferHashMismatch тестирует отклонение файла с неверным хэшем
+func TestFileTransferHashMismatch(t *testing.T) {
+	sender, receiver := setupFileTransfer(t, 10*1024*1024)
+
+	path, data := writeTestFile(t, FileChunkSize)
+	hash := filesystem.CalculateHash(data)
+	sender.Offer(receiver.host.ID(), hash, path)
+
+	// Повреждённая часть даёт неверный итоговый хэш
+	partPath := filepath.Join(filesystem.GetTransfersDir(), hash+".part")
+	if err := filesystem.EnsureParentDir(partPath); err != nil {
+		t.Fatalf("Ошибка создания директории: %v", err)
+	}
+	if err := os.WriteFile(partPath, bytes.Repeat([]byte{0x42}, 100), 0644); err != nil {
+		t.Fatalf("Ошибка записи части файла: %v", err)
+	}
+
+	meta := &FileMetadata{FileHash: hash, FileName: "photo.png", Size: int64(len(data))}
+	if _, err := receiver.Download(context.Background(), sender.host.ID(), meta); !errors.Is(err, ErrFileHashMismatch) {
+		t.Fatalf("Ожидается ErrFileHashMismatch, получено: %v", err)
+	}
+	if filesystem.Exists(hash) {
+		t.Error("Файл с неверным хэшем сохранён в хранилище")
+	}
+	if _, err := os.Stat(partPath); !os.IsNotExist(err) {
+		t.Error("Повреждённая часть не удалена")
+	}
+}
+
+// TestFileTransferNotOffered тестирует отказ в выдаче непредложенного файла
+func TestFileTransferNotOffered(t *testing.T) {
+	sender, receiver := setupFileTransfer(t, 10*1024*1024)
+
+	_, data := writeTestFile(t, 100)
+	meta := &FileMetadata{
+		FileHash: filesystem.CalculateHash(data),
+		FileName: "photo.png",
+		Size:     int64(len(data)),
+	}
+
+	if _, err := receiver.Download(context.Background(), sender.host.ID(), meta); err == nil {
+		t.Error("Ожидается ошибка при загрузке непредложенного файла")
+	}
+}
+
+// TestFileTransferSizeLimit тестирует ограничение размера файла
+func TestFileTransferSizeLimit(t *testing.T) {
+	sender, receiver := setupFileTransfer(t, 1024)
+
+	path, data := writeTestFile(t, 2048)
+
+	if _, err := sender.PrepareFile(receiver.host.ID(), path, "photo.png", ""); !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("Ожидается ErrFileTooLarge при отправке, получено: %v", err)
+	}
+
+	meta := &FileMetadata{
+		FileHash: filesystem.CalculateHash(data),
+		FileName: "photo.png",
+		Size:     int64(len(data)),
+	}
+	if _, err := receiver.Download(context.Background(), sender.host.ID(), meta); !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("Ожидается ErrFileTooLarge при загрузке, получено: %v", err)
+	}
+}
@@ -0,0 +1,267 @@
+package p2p
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/queries"
+)
+
+// LibraryRefreshTimeout максимальное время одной фоновой синхронизации библиотеки
+const LibraryRefreshTimeout = time.Minute
+
+// ItemDeltaHeader первая строка ответа на дельта-запрос
+type ItemDeltaHeader struct {
+	Cursor int64 `json:"cursor"` // Новый курсор (UnixNano по часам владельца)
+}
+
+// ItemDeltaResult результат дельта-синхронизации библиотеки пира
+type ItemDeltaResult struct {
+	Cursor  int64
+	Updated []*models.RemoteItem // Новые и изменённые элементы
+	Deleted []int                // ID у владельца элементов, удалённых из кэша
+}
+
+// buildDelta формирует ответ на дельта-запрос.
+// Отправляются доступные пиру элементы, изменённые после курсора или ещё
+// отсутствующие у него, и надгробия для известных пиру элементов, которые
+// удалены или больше ему не доступны (в том числе после смены правил доступа).
+func (iss *ItemSyncService) buildDelta(peerID peer.ID, req *ItemRequest) ([]*ItemResponse, error) {
+	items, err := queries.GetItemsSharedWithPeer(peerID.String())
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения доступных элементов: %w", err)
+	}
+
+	known := make(map[int]bool, len(req.Known))
+	for _, id := range req.Known {
+		known[id] = true
+	}
+
+	shared := make(map[int]bool, len(items))
+	var responses []*ItemResponse
+	for _, item := range items {
+		shared[item.ID] = true
+
+		if known[item.ID] && item.UpdatedAt.UnixNano() <= req.Since {
+			continue
+		}
+
+		resp, err := iss.itemToResponse(item)
+		if err != nil {
+			log.Printf("Ошибка конвертации элемента %d: %v", item.ID, err)
+			continue
+		}
+		if resp != nil {
+			responses = append(responses, resp)
+		}
+	}
+
+	now := time.Now().UnixNano()
+	for _, id := range req.Known {
+		if !shared[id] {
+			responses = append(responses, &ItemResponse{
+				ItemID:     id,
+				OriginalID: id,
+				Deleted:    true,
+				Timestamp:  now,
+			})
+		}
+	}
+
+	return responses, nil
+}
+
+// RequestDelta запрашивает у пира изменения библиотеки после сохранённого курсора,
+// применяет их к кэшу remote_items и сохраняет новый курсор
+func (iss *ItemSyncService) RequestDelta(ctx context.Context, peerID peer.ID) (*ItemDeltaResult, error) {
+	peerIDStr := peerID.String()
+
+	state, err := queries.GetItemSyncState(peerIDStr)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения курсора: %w", err)
+	}
+
+	known, err := queries.GetRemoteOriginalIDs(peerIDStr)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения сохранённых элементов: %w", err)
+	}
+
+	stream, err := iss.host.NewStream(ctx, peerID, ItemSyncProtocolID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания стрима: %w", err)
+	}
+	defer stream.Close()
+
+	// Отправляем запрос
+	req := &ItemRequest{Delta: true, Since: state.Cursor, Known: known}
+	reqData, _ := json.Marshal(req)
+
+	writer := bufio.NewWriter(stream)
+	if _, err := writer.Write(reqData); err != nil {
+		return nil, fmt.Errorf("ошибка отправки запроса: %w", err)
+	}
+
+	if err := writer.Flush(); err != nil {
+		return nil, fmt.Errorf("ошибка flush: %w", err)
+	}
+
+	// Закрываем запись, чтобы обработчик получил конец запроса
+	if err := stream.CloseWrite(); err != nil {
+		log.Printf("Предупреждение: не удалось закрыть запись стрима: %v", err)
+	}
+
+	// Устанавливаем таймаут
+	if err := stream.SetReadDeadline(time.Now().Add(60 * time.Second)); err != nil {
+		log.Printf("Предупреждение: не удалось установить таймаут: %v", err)
+	}
+
+	decoder := json.NewDecoder(bufio.NewReader(stream))
+
+	var header ItemDeltaHeader
+	if err := decoder.Decode(&header); err != nil {
+		return nil, fmt.Errorf("ошибка чтения заголовка дельты: %w", err)
+	}
+	if header.Cursor <= 0 {
+		return nil, errors.New("пир вернул некорректный курсор")
+	}
+
+	result := &ItemDeltaResult{Cursor: header.Cursor}
+	for {
+		var resp ItemResponse
+		if err := decoder.Decode(&resp); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			// Курсор не сдвигаем: при следующей синхронизации дельта придёт заново
+			return nil, fmt.Errorf("ошибка чтения дельты: %w", err)
+		}
+
+		if resp.Deleted {
+			if err := queries.DeleteRemoteItemByOriginalID(peerIDStr, resp.OriginalID); err != nil {
+				log.Printf("Ошибка удаления элемента %d пира %s: %v", resp.OriginalID, peerIDStr, err)
+				continue
+			}
+			result.Deleted = append(result.Deleted, resp.OriginalID)
+			continue
+		}
+
+		remoteItem, err := iss.saveRemoteItem(peerIDStr, &resp)
+		if err != nil {
+			log.Printf("Ошибка сохранения элемента: %v", err)
+			continue
+		}
+		result.Updated = append(result.Updated, remoteItem)
+	}
+
+	if err := queries.UpdateItemSyncCursor(peerIDStr, header.Cursor); err != nil {
+		return nil, fmt.Errorf("ошибка сохранения курсора: %w", err)
+	}
+
+	log.Printf("Библиотека %s синхронизирована: обновлено %d, удалено %d",
+		peerIDStr, len(result.Updated), len(result.Deleted))
+	return result, nil
+}
+
+// Subscribe подписывается на библиотеку пира: она будет обновляться в фоне,
+// пока пир онлайн
+func (iss *ItemSyncService) Subscribe(peerID peer.ID) error {
+	return queries.SetItemSubscription(peerID.String(), true)
+}
+
+// Unsubscribe отменяет подписку на библиотеку пира (кэш элементов сохраняется)
+func (iss *ItemSyncService) Unsubscribe(peerID peer.ID) error {
+	return queries.SetItemSubscription(peerID.String(), false)
+}
+
+// IsSubscribed проверяет, есть ли подписка на библиотеку пира
+func (iss *ItemSyncService) IsSubscribed(peerID peer.ID) bool {
+	state, err := queries.GetItemSyncState(peerID.String())
+	return err == nil && state.Subscribed
+}
+
+// StartAutoRefresh запускает фоновое обновление библиотек подписанных
+// контактов: сразу при выходе контакта в онлайн и затем с заданным интервалом,
+// пока ConnectionService считает его подключённым
+func (iss *ItemSyncService) StartAutoRefresh(connections *ConnectionService, interval time.Duration) {
+	connections.OnPeerOnline(iss.refreshSubscription)
+
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-iss.ctx.Done():
+				return
+			case <-ticker.C:
+				iss.refreshOnlineSubscriptions(connections)
+			}
+		}
+	}()
+}
+
+// refreshOnlineSubscriptions обновляет библиотеки всех подписанных контактов онлайн
+func (iss *ItemSyncService) refreshOnlineSubscriptions(connections *ConnectionService) {
+	peers, err := queries.GetSubscribedPeers()
+	if err != nil {
+		log.Printf("Ошибка получения подписок: %v", err)
+		return
+	}
+
+	for _, peerIDStr := range peers {
+		peerID, err := peer.Decode(peerIDStr)
+		if err != nil {
+			log.Printf("Ошибка декодирования PeerID %s: %v", peerIDStr, err)
+			continue
+		}
+
+		if connections.GetConnectionStatus(peerID) == StatusConnected {
+			go iss.refreshSubscription(peerID)
+		}
+	}
+}
+
+// refreshSubscription обновляет библиотеку пира, если на неё есть подписка.
+// Параллельные обновления одного пира не запускаются.
+func (iss *ItemSyncService) refreshSubscription(peerID peer.ID) {
+	if !iss.IsSubscribed(peerID) {
+		return
+	}
+	if blocked, err := queries.IsContactBlocked(peerID.String()); err == nil && blocked {
+		return
+	}
+
+	iss.mu.Lock()
+	if iss.refreshing[peerID] {
+		iss.mu.Unlock()
+		return
+	}
+	iss.refreshing[peerID] = true
+	iss.mu.Unlock()
+
+	defer func() {
+		iss.mu.Lock()
+		delete(iss.refreshing, peerID)
+		iss.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(iss.ctx, LibraryRefreshTimeout)
+	defer cancel()
+
+	if _, err := iss.RequestDelta(ctx, peerID); err != nil {
+		log.Printf("Не удалось обновить библиотеку %s: %v", peerID, err)
+	}
+}
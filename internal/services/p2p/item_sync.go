@@ -5,9 +5,11 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/libp2p/go-libp2p/core/crypto"
@@ -28,6 +30,11 @@ type ItemRequest struct {
 	ItemIDs []int  `json:"item_ids,omitempty"` // Запрос конкретных элементов
 	All     bool   `json:"all,omitempty"`      // Запрос всех элементов
 	Hash    string `json:"hash,omitempty"`     // Запрос элемента по хешу
+
+	// Дельта-синхронизация: изменения после курсора и надгробия для удалённых элементов
+	Delta bool  `json:"delta,omitempty"`
+	Since int64 `json:"since,omitempty"` // Курсор владельца из предыдущего ответа
+	Known []int `json:"known,omitempty"` // ID у владельца, уже сохранённые у запрашивающего
 }
 
 // ItemResponse ответ с элементом
@@ -42,6 +49,7 @@ type ItemResponse struct {
 	Signature    []byte          `json:"signature,omitempty"`
 	Timestamp    int64           `json:"timestamp"`
 	FileData     *ItemFileData   `json:"file_data,omitempty"`
+	Deleted      bool            `json:"deleted,omitempty"` // Надгробие: элемент удалён или больше не доступен
 }
 
 // ItemFileData данные о файле элемента
@@ -52,19 +60,31 @@ type ItemFileData struct {
 	Content  []byte `json:"content,omitempty"` // Содержимое файла (опционально)
 }
 
+// ErrItemNotShared элемент не открыт для запросившего пира.
+// Пиру возвращается тот же результат, что и для несуществующего элемента.
+var ErrItemNotShared = errors.New("элемент не доступен этому пиру")
+
 // ItemSyncService сервис для синхронизации элементов между пирами
 type ItemSyncService struct {
 	host         host.Host
 	localPrivKey crypto.PrivKey
 	localPubKey  crypto.PubKey
+	ctx          context.Context
+	cancel       context.CancelFunc
+	mu           sync.Mutex
+	refreshing   map[peer.ID]bool // пиры, библиотека которых обновляется прямо сейчас
 }
 
 // NewItemSyncService создаёт сервис синхронизации элементов
 func NewItemSyncService(host host.Host, privKey crypto.PrivKey, pubKey crypto.PubKey) *ItemSyncService {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &ItemSyncService{
 		host:         host,
 		localPrivKey: privKey,
 		localPubKey:  pubKey,
+		ctx:          ctx,
+		cancel:       cancel,
+		refreshing:   make(map[peer.ID]bool),
 	}
 }
 
@@ -77,6 +97,7 @@ func (iss *ItemSyncService) Start() error {
 
 // Stop останавливает сервис
 func (iss *ItemSyncService) Stop() error {
+	iss.cancel()
 	log.Println("ItemSyncService остановлен")
 	return nil
 }
@@ -88,6 +109,11 @@ func (iss *ItemSyncService) handleItemRequest(stream network.Stream) {
 	remotePeer := stream.Conn().RemotePeer()
 	log.Printf("Получен запрос элементов от: %s", remotePeer.String())
 
+	if blocked, err := queries.IsContactBlocked(remotePeer.String()); err == nil && blocked {
+		log.Printf("Пир %s заблокирован, запрос элементов отклонён", remotePeer)
+		return
+	}
+
 	// Читаем запрос
 	reader := bufio.NewReader(stream)
 	reqData, err := io.ReadAll(reader)
@@ -105,9 +131,24 @@ func (iss *ItemSyncService) handleItemRequest(stream network.Stream) {
 	// Обрабатываем запрос
 	var responses []*ItemResponse
 
-	if req.Hash != "" {
+	writer := bufio.NewWriter(stream)
+	encoder := json.NewEncoder(writer)
+
+	if req.Delta {
+		// Курсор фиксируется до выборки, чтобы не потерять параллельные изменения
+		header := &ItemDeltaHeader{Cursor: time.Now().UnixNano()}
+		responses, err = iss.buildDelta(remotePeer, &req)
+		if err != nil {
+			log.Printf("Ошибка формирования дельты: %v", err)
+			return
+		}
+		if err := encoder.Encode(header); err != nil {
+			log.Printf("Ошибка отправки заголовка дельты: %v", err)
+			return
+		}
+	} else if req.Hash != "" {
 		// Запрос по хешу
-		resp, err := iss.getItemByHash(req.Hash)
+		resp, err := iss.getItemByHash(remotePeer, req.Hash)
 		if err != nil {
 			log.Printf("Элемент с хэшем %s не найден: %v", req.Hash, err)
 		} else if resp != nil {
@@ -116,7 +157,7 @@ func (iss *ItemSyncService) handleItemRequest(stream network.Stream) {
 	} else if len(req.ItemIDs) > 0 {
 		// Запрос конкретных элементов
 		for _, itemID := range req.ItemIDs {
-			resp, err := iss.getItemByID(itemID)
+			resp, err := iss.getItemByID(remotePeer, itemID)
 			if err != nil {
 				log.Printf("Элемент %d не найден: %v", itemID, err)
 				continue
@@ -126,10 +167,10 @@ func (iss *ItemSyncService) handleItemRequest(stream network.Stream) {
 			}
 		}
 	} else if req.All {
-		// Запрос всех элементов
-		items, err := queries.GetAllItems()
+		// Запрос всех элементов, к которым у пира есть доступ
+		items, err := queries.GetItemsSharedWithPeer(remotePeer.String())
 		if err != nil {
-			log.Printf("Ошибка получения всех элементов: %v", err)
+			log.Printf("Ошибка получения доступных элементов: %v", err)
 			return
 		}
 
@@ -146,9 +187,6 @@ func (iss *ItemSyncService) handleItemRequest(stream network.Stream) {
 	}
 
 	// Отправляем ответы
-	writer := bufio.NewWriter(stream)
-	encoder := json.NewEncoder(writer)
-
 	for _, resp := range responses {
 		if err := encoder.Encode(resp); err != nil {
 			log.Printf("Ошибка отправки элемента: %v", err)
@@ -163,8 +201,12 @@ func (iss *ItemSyncService) handleItemRequest(stream network.Stream) {
 	log.Printf("Отправлено %d элементов пиру %s", len(responses), remotePeer)
 }
 
-// getItemByID возвращает элемент по ID для отправки
-func (iss *ItemSyncService) getItemByID(itemID int) (*ItemResponse, error) {
+// getItemByID возвращает элемент по ID для отправки, если он доступен пиру
+func (iss *ItemSyncService) getItemByID(peerID peer.ID, itemID int) (*ItemResponse, error) {
+	if err := checkItemShared(peerID, itemID); err != nil {
+		return nil, err
+	}
+
 	item, err := queries.GetItemByID(itemID)
 	if err != nil {
 		return nil, err
@@ -173,40 +215,62 @@ func (iss *ItemSyncService) getItemByID(itemID int) (*ItemResponse, error) {
 	return iss.itemToResponse(item)
 }
 
-// getItemByHash возвращает элемент по хешу для отправки
-func (iss *ItemSyncService) getItemByHash(hash string) (*ItemResponse, error) {
+// getItemByHash возвращает элемент по хешу для отправки, если он доступен пиру
+func (iss *ItemSyncService) getItemByHash(peerID peer.ID, hash string) (*ItemResponse, error) {
 	item, err := queries.GetItemByHash(hash)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := checkItemShared(peerID, item.ID); err != nil {
+		return nil, err
+	}
+
 	return iss.itemToResponse(item)
 }
 
+// checkItemShared возвращает ErrItemNotShared, если пир не имеет доступа к элементу
+func checkItemShared(peerID peer.ID, itemID int) error {
+	shared, err := queries.IsItemSharedWithPeer(peerID.String(), itemID)
+	if err != nil {
+		return fmt.Errorf("ошибка проверки доступа: %w", err)
+	}
+	if !shared {
+		return ErrItemNotShared
+	}
+	return nil
+}
+
 // itemToResponse преобразует элемент в ответ
 func (iss *ItemSyncService) itemToResponse(item *models.Item) (*ItemResponse, error) {
 	if item == nil {
 		return nil, nil
 	}
 
-	// Подписываем элемент
-	signature, err := iss.signItem(item)
-	if err != nil {
-		log.Printf("Предупреждение: не удалось подписать элемент: %v", err)
+	// У старых элементов content_hash может отсутствовать
+	contentHash := item.ContentHash
+	if contentHash == "" {
+		contentHash = filesystem.GenerateContentHash(item.Title, item.Description, item.ContentMeta)
 	}
 
 	resp := &ItemResponse{
 		ItemID:       item.ID,
 		OriginalID:   item.ID,
-		OriginalHash: item.ContentHash,
+		OriginalHash: contentHash,
 		Type:         item.Type,
 		Title:        item.Title,
 		Description:  item.Description,
 		ContentMeta:  item.ContentMeta,
-		Signature:    signature,
 		Timestamp:    time.Now().UnixNano(),
 	}
 
+	// Подписываем элемент
+	signature, err := iss.signItem(resp.Type, resp.Title, resp.Description, resp.OriginalHash)
+	if err != nil {
+		log.Printf("Предупреждение: не удалось подписать элемент: %v", err)
+	}
+	resp.Signature = signature
+
 	// Получаем файл если есть
 	file, err := queries.GetItemFile(item.ID)
 	if err == nil && file != nil {
@@ -246,6 +310,11 @@ func (iss *ItemSyncService) RequestItems(ctx context.Context, peerID peer.ID, it
 		return nil, fmt.Errorf("ошибка flush: %w", err)
 	}
 
+	// Закрываем запись, чтобы обработчик получил конец запроса
+	if err := stream.CloseWrite(); err != nil {
+		log.Printf("Предупреждение: не удалось закрыть запись стрима: %v", err)
+	}
+
 	// Устанавливаем таймаут
 	if err := stream.SetReadDeadline(time.Now().Add(30 * time.Second)); err != nil {
 		log.Printf("Предупреждение: не удалось установить таймаут: %v", err)
@@ -300,6 +369,11 @@ func (iss *ItemSyncService) RequestItemByHash(ctx context.Context, peerID peer.I
 		return nil, fmt.Errorf("ошибка flush: %w", err)
 	}
 
+	// Закрываем запись, чтобы обработчик получил конец запроса
+	if err := stream.CloseWrite(); err != nil {
+		log.Printf("Предупреждение: не удалось закрыть запись стрима: %v", err)
+	}
+
 	// Устанавливаем таймаут
 	if err := stream.SetReadDeadline(time.Now().Add(30 * time.Second)); err != nil {
 		log.Printf("Предупреждение: не удалось установить таймаут: %v", err)
@@ -338,6 +412,11 @@ func (iss *ItemSyncService) RequestAllItems(ctx context.Context, peerID peer.ID)
 		return nil, fmt.Errorf("ошибка flush: %w", err)
 	}
 
+	// Закрываем запись, чтобы обработчик получил конец запроса
+	if err := stream.CloseWrite(); err != nil {
+		log.Printf("Предупреждение: не удалось закрыть запись стрима: %v", err)
+	}
+
 	// Устанавливаем таймаут
 	if err := stream.SetReadDeadline(time.Now().Add(60 * time.Second)); err != nil {
 		log.Printf("Предупреждение: не удалось установить таймаут: %v", err)
@@ -372,11 +451,16 @@ func (iss *ItemSyncService) RequestAllItems(ctx context.Context, peerID peer.ID)
 
 // saveRemoteItem сохраняет полученный элемент в базу данных
 func (iss *ItemSyncService) saveRemoteItem(sourcePeerID string, resp *ItemResponse) (*models.RemoteItem, error) {
+	if !filesystem.IsValidHash(resp.OriginalHash) {
+		return nil, fmt.Errorf("некорректный хэш элемента: %q", resp.OriginalHash)
+	}
+
 	// Создаём remote item
 	remoteItem := &models.RemoteItem{
 		SourcePeerID: sourcePeerID,
 		OriginalID:   resp.OriginalID,
 		OriginalHash: resp.OriginalHash,
+		ItemType:     resp.Type,
 		Title:        resp.Title,
 		Description:  resp.Description,
 		ContentMeta:  resp.ContentMeta,
@@ -384,20 +468,21 @@ func (iss *ItemSyncService) saveRemoteItem(sourcePeerID string, resp *ItemRespon
 		Version:      1,
 	}
 
-	// Проверяем, существует ли уже элемент с таким хешем
-	exists, err := queries.RemoteItemExists(sourcePeerID, resp.OriginalHash)
+	// Ищем уже сохранённый элемент: по ID у владельца (хэш меняется при
+	// редактировании), затем по хэшу для записей без original_id
+	existing, err := queries.GetRemoteItemByOriginalID(sourcePeerID, resp.OriginalID)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка проверки существования: %w", err)
+		existing, _ = queries.GetRemoteItemByHash(sourcePeerID, resp.OriginalHash)
 	}
 
-	if exists {
-		// Обновляем существующий
-		existing, err := queries.GetRemoteItemByHash(sourcePeerID, resp.OriginalHash)
-		if err == nil && existing != nil {
-			remoteItem.ID = existing.ID
-			if err := queries.UpdateRemoteItem(remoteItem); err != nil {
-				return nil, fmt.Errorf("ошибка обновления элемента: %w", err)
-			}
+	if existing != nil {
+		remoteItem.ID = existing.ID
+		remoteItem.Version = existing.Version
+		if remoteItemChanged(existing, remoteItem) {
+			remoteItem.Version++
+		}
+		if err := queries.UpdateRemoteItem(remoteItem); err != nil {
+			return nil, fmt.Errorf("ошибка обновления элемента: %w", err)
 		}
 	} else {
 		// Создаём новый
@@ -432,21 +517,22 @@ func (iss *ItemSyncService) saveRemoteItem(sourcePeerID string, resp *ItemRespon
 	return remoteItem, nil
 }
 
+// remoteItemChanged проверяет, отличается ли полученный элемент от сохранённого
+func remoteItemChanged(existing, received *models.RemoteItem) bool {
+	return existing.OriginalHash != received.OriginalHash ||
+		existing.ItemType != received.ItemType ||
+		existing.Title != received.Title ||
+		existing.Description != received.Description ||
+		existing.ContentMeta != received.ContentMeta
+}
+
 // signItem подписывает элемент
-func (iss *ItemSyncService) signItem(item *models.Item) ([]byte, error) {
+func (iss *ItemSyncService) signItem(itemType models.ItemType, title, description, contentHash string) ([]byte, error) {
 	if iss.localPrivKey == nil {
 		return nil, fmt.Errorf("приватный ключ не установлен")
 	}
 
-	// Данные для подписи
-	data := fmt.Sprintf("%s|%s|%s|%s",
-		item.Type,
-		item.Title,
-		item.Description,
-		item.ContentHash,
-	)
-
-	signature, err := iss.localPrivKey.Sign([]byte(data))
+	signature, err := iss.localPrivKey.Sign(itemSignatureData(itemType, title, description, contentHash))
 	if err != nil {
 		return nil, fmt.Errorf("ошибка подписи: %w", err)
 	}
@@ -470,15 +556,12 @@ func (iss *ItemSyncService) VerifyItemSignature(item *models.RemoteItem, publicK
 		return false, fmt.Errorf("ошибка восстановления публичного ключа: %w", err)
 	}
 
-	// Данные для проверки
-	data := fmt.Sprintf("%s|%s|%s|%s",
-		"element", // type
-		item.Title,
-		item.Description,
-		item.OriginalHash,
-	)
+	itemType := item.ItemType
+	if itemType == "" {
+		itemType = models.ItemTypeElement
+	}
 
-	valid, err := pubKey.Verify([]byte(data), signature)
+	valid, err := pubKey.Verify(itemSignatureData(itemType, item.Title, item.Description, item.OriginalHash), signature)
 	if err != nil {
 		return false, fmt.Errorf("ошибка проверки подписи: %w", err)
 	}
@@ -486,6 +569,73 @@ func (iss *ItemSyncService) VerifyItemSignature(item *models.RemoteItem, publicK
 	return valid, nil
 }
 
+// itemSignatureData формирует данные для подписи элемента: type|title|description|hash
+func itemSignatureData(itemType models.ItemType, title, description, contentHash string) []byte {
+	return []byte(fmt.Sprintf("%s|%s|%s|%s", itemType, title, description, contentHash))
+}
+
+// RemoteItemStatus элемент из библиотеки пира с результатом проверки подписи
+type RemoteItemStatus struct {
+	Item        *models.RemoteItem
+	Verified    bool
+	VerifyError string
+}
+
+// VerifyRemoteItem проверяет подпись элемента ключом владельца.
+// Ключ берётся из profile_keys, а при его отсутствии - из PeerID владельца.
+func (iss *ItemSyncService) VerifyRemoteItem(item *models.RemoteItem) (bool, error) {
+	publicKey, err := peerPublicKeyBytes(item.SourcePeerID)
+	if err != nil {
+		return false, err
+	}
+
+	return iss.VerifyItemSignature(item, publicKey, item.Signature)
+}
+
+// GetRemoteItemsWithStatus возвращает закэшированные элементы пира с результатом проверки подписей
+func (iss *ItemSyncService) GetRemoteItemsWithStatus(peerID string) ([]*RemoteItemStatus, error) {
+	items, err := queries.GetRemoteItemsByPeer(peerID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения элементов пира: %w", err)
+	}
+
+	statuses := make([]*RemoteItemStatus, 0, len(items))
+	for _, item := range items {
+		status := &RemoteItemStatus{Item: item}
+		valid, err := iss.VerifyRemoteItem(item)
+		if err != nil {
+			status.VerifyError = err.Error()
+		}
+		status.Verified = valid
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// peerPublicKeyBytes возвращает сериализованный публичный ключ пира
+func peerPublicKeyBytes(peerIDStr string) ([]byte, error) {
+	peerID, err := peer.Decode(peerIDStr)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка декодирования PeerID: %w", err)
+	}
+
+	if profile, err := queries.GetProfileByPeerID(peerIDStr); err == nil && profile != nil {
+		if keys, err := queries.GetProfileKeys(profile.ID); err == nil && len(keys.PublicKey) > 0 {
+			if pubKey, err := crypto.UnmarshalPublicKey(keys.PublicKey); err == nil && peerID.MatchesPublicKey(pubKey) {
+				return keys.PublicKey, nil
+			}
+		}
+	}
+
+	pubKey, err := peerID.ExtractPublicKey()
+	if err != nil {
+		return nil, fmt.Errorf("публичный ключ пира %s не найден", peerIDStr)
+	}
+
+	return crypto.MarshalPublicKey(pubKey)
+}
+
 // GetRemoteItemsByPeer возвращает все элементы от указанного пира
 func (iss *ItemSyncService) GetRemoteItemsByPeer(peerID string) ([]*models.RemoteItem, error) {
 	return queries.GetRemoteItemsByPeer(peerID)
@@ -0,0 +1,287 @@
+package p2p
+
+import (
+	"context"
+	"crypto/rand"
+	"testing"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/crypto"
+
+	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/queries"
+)
+
+// createTestItemSyncService создаёт ItemSyncService на хосте с его собственными ключами
+func createTestItemSyncService(t *testing.T) *ItemSyncService {
+	t.Helper()
+
+	privKey, pubKey, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatalf("Ошибка генерации ключей: %v", err)
+	}
+
+	h, err := libp2p.New(libp2p.Identity(privKey), libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatalf("Ошибка создания хоста: %v", err)
+	}
+	t.Cleanup(func() {
+		h.Close()
+	})
+
+	service := NewItemSyncService(h, privKey, pubKey)
+	if err := service.Start(); err != nil {
+		t.Fatalf("Ошибка запуска ItemSyncService: %v", err)
+	}
+	t.Cleanup(func() {
+		service.Stop()
+	})
+
+	return service
+}
+
+// shareTestFolder открывает папку всем пирам
+func shareTestFolder(t *testing.T, folderID int) {
+	t.Helper()
+
+	rule := &models.SharingRule{
+		EntityType: models.SharingEntityFolder,
+		EntityID:   folderID,
+		Visibility: models.SharingPublic,
+	}
+	if err := queries.SetSharingRule(rule); err != nil {
+		t.Fatalf("Ошибка сохранения правила доступа: %v", err)
+	}
+}
+
+// TestItemSyncRequestAllItems тестирует получение и проверку подписи элементов контакта
+func TestItemSyncRequestAllItems(t *testing.T) {
+	setupChatTestDB(t)
+
+	owner := createTestItemSyncService(t)
+	browser := createTestItemSyncService(t)
+	connectTestHosts(t, browser.host, owner.host)
+
+	folder := &models.Item{Type: models.ItemTypeFolder, Title: "Рецепты"}
+	if err := queries.CreateItem(folder); err != nil {
+		t.Fatalf("Ошибка создания элемента: %v", err)
+	}
+	shareTestFolder(t, folder.ID)
+
+	// Элемент вне открытой папки не должен уходить пиру
+	private := &models.Item{Type: models.ItemTypeElement, Title: "Личное"}
+	if err := queries.CreateItem(private); err != nil {
+		t.Fatalf("Ошибка создания элемента: %v", err)
+	}
+
+	items, err := browser.RequestAllItems(context.Background(), owner.host.ID())
+	if err != nil {
+		t.Fatalf("Ошибка запроса элементов: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("Ожидается 1 элемент, получено %d", len(items))
+	}
+	if items[0].ItemType != models.ItemTypeFolder {
+		t.Errorf("Тип элемента: ожидается %s, получено %s", models.ItemTypeFolder, items[0].ItemType)
+	}
+
+	statuses, err := browser.GetRemoteItemsWithStatus(owner.host.ID().String())
+	if err != nil {
+		t.Fatalf("Ошибка получения статусов: %v", err)
+	}
+	if len(statuses) != 1 {
+		t.Fatalf("Ожидается 1 статус, получено %d", len(statuses))
+	}
+	if !statuses[0].Verified {
+		t.Errorf("Подпись элемента не подтверждена: %s", statuses[0].VerifyError)
+	}
+
+	// Подмена описания должна ломать проверку подписи
+	tampered := *statuses[0].Item
+	tampered.Description = "подменено"
+	if ok, _ := browser.VerifyRemoteItem(&tampered); ok {
+		t.Error("Подпись изменённого элемента не должна проходить проверку")
+	}
+}
+
+// TestItemSyncDeniesBlockedPeer тестирует отказ заблокированному контакту
+func TestItemSyncDeniesBlockedPeer(t *testing.T) {
+	setupChatTestDB(t)
+
+	owner := createTestItemSyncService(t)
+	browser := createTestItemSyncService(t)
+	connectTestHosts(t, browser.host, owner.host)
+
+	folder := &models.Item{Type: models.ItemTypeFolder, Title: "Рецепты"}
+	if err := queries.CreateItem(folder); err != nil {
+		t.Fatalf("Ошибка создания элемента: %v", err)
+	}
+	shareTestFolder(t, folder.ID)
+
+	contact := &models.Contact{PeerID: browser.host.ID().String(), IsBlocked: true}
+	if err := queries.CreateContact(contact); err != nil {
+		t.Fatalf("Ошибка создания контакта: %v", err)
+	}
+
+	items, err := browser.RequestAllItems(context.Background(), owner.host.ID())
+	if err == nil && len(items) > 0 {
+		t.Errorf("Заблокированный контакт получил %d элементов", len(items))
+	}
+
+	items, err = browser.RequestItems(context.Background(), owner.host.ID(), []int{folder.ID})
+	if err == nil && len(items) > 0 {
+		t.Errorf("Заблокированный контакт получил элемент по ID")
+	}
+}
+
+// TestItemSyncDelta тестирует дельта-синхронизацию с курсором и надгробиями
+func TestItemSyncDelta(t *testing.T) {
+	setupChatTestDB(t)
+
+	owner := createTestItemSyncService(t)
+	browser := createTestItemSyncService(t)
+	connectTestHosts(t, browser.host, owner.host)
+	ownerID := owner.host.ID()
+
+	folder := &models.Item{Type: models.ItemTypeFolder, Title: "Рецепты"}
+	if err := queries.CreateItem(folder); err != nil {
+		t.Fatalf("Ошибка создания элемента: %v", err)
+	}
+	shareTestFolder(t, folder.ID)
+
+	soup := &models.Item{Type: models.ItemTypeElement, Title: "Суп", ParentID: &folder.ID}
+	salad := &models.Item{Type: models.ItemTypeElement, Title: "Салат", ParentID: &folder.ID}
+	for _, item := range []*models.Item{soup, salad} {
+		if err := queries.CreateItem(item); err != nil {
+			t.Fatalf("Ошибка создания элемента: %v", err)
+		}
+	}
+
+	result, err := browser.RequestDelta(context.Background(), ownerID)
+	if err != nil {
+		t.Fatalf("Ошибка первой синхронизации: %v", err)
+	}
+	if len(result.Updated) != 3 || len(result.Deleted) != 0 {
+		t.Fatalf("Первая синхронизация: ожидается 3 новых элемента, получено %d новых и %d удалённых",
+			len(result.Updated), len(result.Deleted))
+	}
+
+	// Без изменений дельта пустая
+	result, err = browser.RequestDelta(context.Background(), ownerID)
+	if err != nil {
+		t.Fatalf("Ошибка повторной синхронизации: %v", err)
+	}
+	if len(result.Updated) != 0 || len(result.Deleted) != 0 {
+		t.Fatalf("Без изменений ожидается пустая дельта, получено %d новых и %d удалённых",
+			len(result.Updated), len(result.Deleted))
+	}
+
+	// Редактирование меняет хэш, удаление даёт надгробие
+	soup.Title = "Суп харчо"
+	if err := queries.UpdateItem(soup); err != nil {
+		t.Fatalf("Ошибка обновления элемента: %v", err)
+	}
+	if err := queries.DeleteItem(salad.ID); err != nil {
+		t.Fatalf("Ошибка удаления элемента: %v", err)
+	}
+
+	result, err = browser.RequestDelta(context.Background(), ownerID)
+	if err != nil {
+		t.Fatalf("Ошибка синхронизации изменений: %v", err)
+	}
+	if len(result.Updated) != 1 || len(result.Deleted) != 1 || result.Deleted[0] != salad.ID {
+		t.Fatalf("Ожидается 1 изменённый и удалённый элемент %d, получено %d изменённых и удалённые %v",
+			salad.ID, len(result.Updated), result.Deleted)
+	}
+
+	cached, err := queries.GetRemoteItemByOriginalID(ownerID.String(), soup.ID)
+	if err != nil {
+		t.Fatalf("Изменённый элемент не найден в кэше: %v", err)
+	}
+	if cached.Title != "Суп харчо" || cached.Version != 2 {
+		t.Errorf("Ожидается элемент «Суп харчо» версии 2, получено «%s» версии %d", cached.Title, cached.Version)
+	}
+	if _, err := queries.GetRemoteItemByOriginalID(ownerID.String(), salad.ID); err == nil {
+		t.Error("Удалённый у владельца элемент остался в кэше")
+	}
+
+	// Изменение только тегов тоже попадает в дельту
+	tag, err := queries.GetOrCreateTag(context.Background(), "первое")
+	if err != nil {
+		t.Fatalf("Ошибка создания тега: %v", err)
+	}
+	if err := queries.AddTagToItem(context.Background(), soup.ID, tag.ID); err != nil {
+		t.Fatalf("Ошибка добавления тега: %v", err)
+	}
+	result, err = browser.RequestDelta(context.Background(), ownerID)
+	if err != nil {
+		t.Fatalf("Ошибка синхронизации после добавления тега: %v", err)
+	}
+	if len(result.Updated) != 1 || result.Updated[0].OriginalID != soup.ID {
+		t.Fatalf("После добавления тега ожидается изменённый элемент %d, получено %d изменённых", soup.ID, len(result.Updated))
+	}
+	if err := queries.RemoveTagFromItem(context.Background(), soup.ID, tag.ID); err != nil {
+		t.Fatalf("Ошибка удаления тега: %v", err)
+	}
+	result, err = browser.RequestDelta(context.Background(), ownerID)
+	if err != nil {
+		t.Fatalf("Ошибка синхронизации после удаления тега: %v", err)
+	}
+	if len(result.Updated) != 1 {
+		t.Fatalf("После удаления тега ожидается 1 изменённый элемент, получено %d", len(result.Updated))
+	}
+
+	// Закрытие доступа превращает оставшиеся элементы в надгробия
+	if err := queries.DeleteSharingRule(models.SharingEntityFolder, folder.ID); err != nil {
+		t.Fatalf("Ошибка удаления правила доступа: %v", err)
+	}
+	result, err = browser.RequestDelta(context.Background(), ownerID)
+	if err != nil {
+		t.Fatalf("Ошибка синхронизации после закрытия доступа: %v", err)
+	}
+	if len(result.Deleted) != 2 {
+		t.Errorf("Ожидается 2 надгробия после закрытия доступа, получено %d", len(result.Deleted))
+	}
+}
+
+// TestItemSyncRefreshSubscription тестирует фоновое обновление только для подписок
+func TestItemSyncRefreshSubscription(t *testing.T) {
+	setupChatTestDB(t)
+
+	owner := createTestItemSyncService(t)
+	browser := createTestItemSyncService(t)
+	connectTestHosts(t, browser.host, owner.host)
+	ownerID := owner.host.ID()
+
+	folder := &models.Item{Type: models.ItemTypeFolder, Title: "Рецепты"}
+	if err := queries.CreateItem(folder); err != nil {
+		t.Fatalf("Ошибка создания элемента: %v", err)
+	}
+	shareTestFolder(t, folder.ID)
+
+	browser.refreshSubscription(ownerID)
+	if ids, _ := queries.GetRemoteOriginalIDs(ownerID.String()); len(ids) != 0 {
+		t.Fatalf("Без подписки библиотека не должна обновляться, получено %d элементов", len(ids))
+	}
+
+	if err := browser.Subscribe(ownerID); err != nil {
+		t.Fatalf("Ошибка подписки: %v", err)
+	}
+	browser.refreshSubscription(ownerID)
+
+	ids, err := queries.GetRemoteOriginalIDs(ownerID.String())
+	if err != nil {
+		t.Fatalf("Ошибка получения кэша: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != folder.ID {
+		t.Errorf("Ожидается элемент %d в кэше, получено %v", folder.ID, ids)
+	}
+
+	state, err := queries.GetItemSyncState(ownerID.String())
+	if err != nil {
+		t.Fatalf("Ошибка получения курсора: %v", err)
+	}
+	if !state.Subscribed || state.Cursor == 0 || state.LastSyncedAt == nil {
+		t.Errorf("Состояние синхронизации не сохранено: %+v", state)
+	}
+}
@@ -180,6 +180,71 @@ func (n *P2PNetwork) Chat() *p2p.ChatService {
 	return n.chat
 }
 
+// FileTransfer возвращает сервис передачи файлов
+func (n *P2PNetwork) FileTransfer() *p2p.FileTransferService {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.fileTransfer
+}
+
+// ItemSync возвращает сервис синхронизации элементов
+func (n *P2PNetwork) ItemSync() *p2p.ItemSyncService {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.itemSync
+}
+
+// RequestAllItems запрашивает все доступные элементы у пира и сохраняет их в remote_items
+func (n *P2PNetwork) RequestAllItems(ctx context.Context, peerID peer.ID) ([]*models.RemoteItem, error) {
+	n.mu.RLock()
+	itemSync := n.itemSync
+	n.mu.RUnlock()
+
+	if itemSync == nil {
+		return nil, errors.New("ItemSyncService не инициализирован")
+	}
+	return itemSync.RequestAllItems(ctx, peerID)
+}
+
+// SyncLibrary запрашивает изменения библиотеки пира после сохранённого курсора
+func (n *P2PNetwork) SyncLibrary(ctx context.Context, peerID peer.ID) (*p2p.ItemDeltaResult, error) {
+	n.mu.RLock()
+	itemSync := n.itemSync
+	n.mu.RUnlock()
+
+	if itemSync == nil {
+		return nil, errors.New("ItemSyncService не инициализирован")
+	}
+	return itemSync.RequestDelta(ctx, peerID)
+}
+
+// SetLibrarySubscription включает или выключает фоновое обновление библиотеки пира
+func (n *P2PNetwork) SetLibrarySubscription(peerID peer.ID, subscribed bool) error {
+	n.mu.RLock()
+	itemSync := n.itemSync
+	n.mu.RUnlock()
+
+	if itemSync == nil {
+		return errors.New("ItemSyncService не инициализирован")
+	}
+	if subscribed {
+		return itemSync.Subscribe(peerID)
+	}
+	return itemSync.Unsubscribe(peerID)
+}
+
+// GetRemoteItemsWithStatus возвращает закэшированные элементы пира с проверкой подписей
+func (n *P2PNetwork) GetRemoteItemsWithStatus(peerID string) ([]*p2p.RemoteItemStatus, error) {
+	n.mu.RLock()
+	itemSync := n.itemSync
+	n.mu.RUnlock()
+
+	if itemSync == nil {
+		return nil, errors.New("ItemSyncService не инициализирован")
+	}
+	return itemSync.GetRemoteItemsWithStatus(peerID)
+}
+
 // SendMessage отправляет сообщение пиру
 func (n *P2PNetwork) SendMessage(ctx context.Context, peerID peer.ID, content, contentType, metadata string) error {
 	n.mu.RLock()
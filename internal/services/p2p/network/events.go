@@ -23,6 +23,11 @@ func (n *P2PNetwork) onPeerConnected(peerID peer.ID) {
 		_ = queries.UpdateContactLastSeen(contact.ID, &now)
 	}
 
+	// Возобновляем незавершённые загрузки файлов от пира
+	if n.chat != nil {
+		go n.chat.ResumeFileTransfers(peerID)
+	}
+
 	// Запрашиваем профиль у пира
 	if n.profileExchange != nil {
 		go func() {
@@ -37,7 +37,9 @@ type P2PNetwork struct {
 	discovery       *p2p.DiscoveryService
 	connections     *p2p.ConnectionService
 	chat            *p2p.ChatService
+	fileTransfer    *p2p.FileTransferService
 	profileExchange *p2p.ProfileExchangeService
+	itemSync        *p2p.ItemSyncService
 	helper          *HelperService
 	config          *p2p.P2PConfig
 	ctx             context.Context
@@ -139,6 +141,11 @@ func (n *P2PNetwork) Start() error {
 		log.Printf("Предупреждение: сервис обмена профилями не инициализирован: %v", err)
 	}
 
+	// Инициализируем сервис синхронизации элементов
+	if err := n.initItemSync(); err != nil {
+		log.Printf("Предупреждение: сервис синхронизации элементов не инициализирован: %v", err)
+	}
+
 	// Инициализируем и запускаем сервис обнаружения
 	if err := n.initDiscovery(); err != nil {
 		log.Printf("Предупреждение: сервис обнаружения не инициализирован: %v", err)
@@ -149,6 +156,11 @@ func (n *P2PNetwork) Start() error {
 		log.Printf("Предупреждение: сервис соединений не инициализирован: %v", err)
 	}
 
+	// Библиотеки подписанных контактов обновляются, когда контакт онлайн
+	if n.itemSync != nil && n.connections != nil {
+		n.itemSync.StartAutoRefresh(n.connections, n.config.LibraryRefreshInterval)
+	}
+
 	// Инициализируем режим помощника если включён
 	if n.config.EnableHelperMode {
 		if err := n.initHelper(); err != nil {
@@ -177,6 +189,13 @@ func (n *P2PNetwork) Stop() error {
 		}
 	}
 
+	// Останавливаем сервис передачи файлов
+	if n.fileTransfer != nil {
+		if err := n.fileTransfer.Stop(); err != nil {
+			errs = append(errs, fmt.Sprintf("FileTransfer: %v", err))
+		}
+	}
+
 	// Останавливаем сервис обнаружения
 	if n.discovery != nil {
 		if err := n.discovery.Stop(); err != nil {
@@ -205,6 +224,13 @@ func (n *P2PNetwork) Stop() error {
 		}
 	}
 
+	// Останавливаем сервис синхронизации элементов
+	if n.itemSync != nil {
+		if err := n.itemSync.Stop(); err != nil {
+			errs = append(errs, fmt.Sprintf("ItemSync: %v", err))
+		}
+	}
+
 	if n.dht != nil {
 		if err := n.dht.Close(); err != nil {
 			errs = append(errs, fmt.Sprintf("DHT: %v", err))
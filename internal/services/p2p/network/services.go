@@ -9,6 +9,7 @@ import (
 
 	p2p "projectT/internal/services/p2p"
 	"projectT/internal/storage/database/queries"
+	"projectT/internal/storage/database/repository"
 )
 
 // initDiscovery инициализирует и запускает сервис обнаружения
@@ -77,12 +78,31 @@ func (n *P2PNetwork) initProfileExchange() error {
 	return n.profileExchange.Start()
 }
 
+// initItemSync инициализирует сервис синхронизации элементов
+func (n *P2PNetwork) initItemSync() error {
+	if n.host == nil {
+		return errors.New("хост не инициализирован")
+	}
+
+	n.itemSync = p2p.NewItemSyncService(n.host, n.localPrivKey, n.localPubKey)
+	return n.itemSync.Start()
+}
+
 // initChat инициализирует сервис чата
 func (n *P2PNetwork) initChat() error {
 	if n.host == nil {
 		return errors.New("хост не инициализирован")
 	}
 
-	n.chat = p2p.NewChatService(n.host, n.config, n.localPrivKey, n.localPubKey)
+	repos := repository.Default()
+	n.chat = p2p.NewChatService(n.host, n.config, n.localPrivKey, n.localPubKey, repos.Contacts, repos.Messages)
+
+	// Файлы из чата передаются отдельным протоколом
+	n.fileTransfer = p2p.NewFileTransferService(n.host, n.config)
+	if err := n.fileTransfer.Start(); err != nil {
+		return fmt.Errorf("ошибка запуска сервиса передачи файлов: %w", err)
+	}
+	n.chat.SetFileTransferService(n.fileTransfer)
+
 	return n.chat.Start()
 }
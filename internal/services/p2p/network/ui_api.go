@@ -11,6 +11,7 @@ import (
 	"projectT/internal/storage/database/models"
 	"projectT/internal/storage/database/queries"
 
+	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 )
 
@@ -488,6 +489,41 @@ func (api *UIP2P) RequestAllProfiles() {
 	api.network.profileExchange.RequestProfilesForAllContacts(ctx)
 }
 
+// BrowseContactLibrary обновляет кэш элементов контакта (если он онлайн)
+// и возвращает элементы с результатом проверки подписи
+func (api *UIP2P) BrowseContactLibrary(peerIDStr string) ([]*p2p.RemoteItemStatus, error) {
+	peerID, err := peer.Decode(peerIDStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if api.network.host != nil && api.network.host.Network().Connectedness(peerID) == network.Connected {
+		ctx, cancel := context.WithTimeout(api.network.ctx, 60*time.Second)
+		defer cancel()
+
+		if _, err := api.network.SyncLibrary(ctx, peerID); err != nil {
+			log.Printf("Не удалось обновить элементы контакта %s: %v", peerIDStr, err)
+		}
+	}
+
+	return api.network.GetRemoteItemsWithStatus(peerIDStr)
+}
+
+// IsLibrarySubscribed проверяет, обновляется ли библиотека контакта автоматически
+func (api *UIP2P) IsLibrarySubscribed(peerIDStr string) bool {
+	state, err := queries.GetItemSyncState(peerIDStr)
+	return err == nil && state.Subscribed
+}
+
+// SetLibrarySubscription включает или выключает автоматическое обновление библиотеки контакта
+func (api *UIP2P) SetLibrarySubscription(peerIDStr string, subscribed bool) error {
+	peerID, err := peer.Decode(peerIDStr)
+	if err != nil {
+		return err
+	}
+	return api.network.SetLibrarySubscription(peerID, subscribed)
+}
+
 // GetPeerID декодирует PeerID из строки
 func (api *UIP2P) GetPeerID(peerIDStr string) (peer.ID, error) {
 	return peer.Decode(peerIDStr)
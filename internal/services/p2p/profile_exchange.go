@@ -85,6 +85,11 @@ func (pes *ProfileExchangeService) handleProfileRequest(stream network.Stream) {
 	remotePeer := stream.Conn().RemotePeer()
 	log.Printf("Получен запрос профиля от: %s", remotePeer.String())
 
+	if blocked, err := queries.IsContactBlocked(remotePeer.String()); err == nil && blocked {
+		log.Printf("Пир %s заблокирован, запрос профиля отклонён", remotePeer)
+		return
+	}
+
 	// Читаем запрос
 	reader := bufio.NewReader(stream)
 	reqData, err := io.ReadAll(reader)
@@ -182,6 +187,11 @@ func (pes *ProfileExchangeService) RequestPeerProfile(ctx context.Context, peerI
 		return nil, fmt.Errorf("ошибка flush: %w", err)
 	}
 
+	// Закрываем запись, чтобы обработчик получил конец запроса
+	if err := stream.CloseWrite(); err != nil {
+		log.Printf("Предупреждение: не удалось закрыть запись стрима: %v", err)
+	}
+
 	// Устанавливаем таймаут
 	if err := stream.SetReadDeadline(time.Now().Add(10 * time.Second)); err != nil {
 		log.Printf("Предупреждение: не удалось установить таймаут: %v", err)
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+
+	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/queries"
+)
+
+// defaultMaxRevisionsPerItem ограничение числа ревизий элемента, если оно не задано в конфигурации
+const defaultMaxRevisionsPerItem = 50
+
+var maxRevisionsPerItem atomic.Int64
+
+func init() {
+	maxRevisionsPerItem.Store(defaultMaxRevisionsPerItem)
+}
+
+// MaxRevisionsPerItem возвращает, сколько ревизий хранится для одного элемента (0 - без ограничения)
+func MaxRevisionsPerItem() int {
+	return int(maxRevisionsPerItem.Load())
+}
+
+// BlockChangeKind тип изменения блока между ревизиями
+type BlockChangeKind string
+
+const (
+	BlockUnchanged BlockChangeKind = "unchanged"
+	BlockAdded     BlockChangeKind = "added"
+	BlockRemoved   BlockChangeKind = "removed"
+	BlockChanged   BlockChangeKind = "changed" // Блок заменён блоком того же типа с другим содержимым
+)
+
+// BlockChange изменение одного блока. Old пуст у добавленных блоков, New - у удалённых.
+type BlockChange struct {
+	Kind BlockChangeKind
+	Old  *Block
+	New  *Block
+}
+
+// FieldChange изменение поля элемента между ревизиями
+type FieldChange struct {
+	Field string // Название поля для отображения
+	Old   string
+	New   string
+}
+
+// RevisionDiff различия двух состояний элемента
+type RevisionDiff struct {
+	Fields []FieldChange
+	Blocks []BlockChange
+}
+
+// HasChanges сообщает, различаются ли состояния
+func (d *RevisionDiff) HasChanges() bool {
+	if len(d.Fields) > 0 {
+		return true
+	}
+	for _, change := range d.Blocks {
+		if change.Kind != BlockUnchanged {
+			return true
+		}
+	}
+	return false
+}
+
+// RevisionService предоставляет историю изменений элементов: просмотр, сравнение и откат
+type RevisionService struct {
+	contentService *ContentBlocksService
+}
+
+// NewRevisionService создает новый экземпляр сервиса истории изменений
+func NewRevisionService() *RevisionService {
+	return &RevisionService{contentService: NewContentBlocksService()}
+}
+
+// SetMaxRevisions задаёт, сколько ревизий хранить для одного элемента (0 - без ограничения),
+// и удаляет ревизии сверх нового ограничения
+func (rs *RevisionService) SetMaxRevisions(limit int) {
+	if limit < 0 {
+		limit = 0
+	}
+	maxRevisionsPerItem.Store(int64(limit))
+
+	pruned, err := queries.PruneItemRevisions(limit)
+	if err != nil {
+		log.Printf("Ошибка удаления старых ревизий: %v", err)
+		return
+	}
+	if pruned > 0 {
+		log.Printf("Удалено старых ревизий: %d", pruned)
+	}
+}
+
+// GetRevisions возвращает ревизии элемента, начиная с самой новой
+func (rs *RevisionService) GetRevisions(itemID int) ([]*models.ItemRevision, error) {
+	return queries.GetItemRevisions(itemID)
+}
+
+// CurrentState возвращает текущее состояние элемента в виде ревизии без ID - для сравнения с историей
+func (rs *RevisionService) CurrentState(ctx context.Context, itemID int) (*models.ItemRevision, error) {
+	item, err := queries.GetItemByID(itemID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения элемента: %w", err)
+	}
+
+	tags, err := queries.GetTagsForItem(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	state := &models.ItemRevision{
+		ItemID:      item.ID,
+		Type:        item.Type,
+		Title:       item.Title,
+		Description: item.Description,
+		ContentMeta: item.ContentMeta,
+		Tags:        make([]string, 0, len(tags)),
+		CreatedAt:   item.UpdatedAt,
+	}
+	for _, tag := range tags {
+		state.Tags = append(state.Tags, tag.Name)
+	}
+	return state, nil
+}
+
+// Restore возвращает элемент к состоянию ревизии: поля, блоки, теги и файлы.
+// Текущее состояние перед откатом само становится ревизией, поэтому откат можно отменить.
+func (rs *RevisionService) Restore(ctx context.Context, revisionID int) (*models.Item, error) {
+	revision, err := queries.GetItemRevision(revisionID)
+	if err != nil {
+		return nil, err
+	}
+	current, err := queries.GetItemByID(revision.ItemID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения элемента: %w", err)
+	}
+
+	blocks, err := rs.contentService.JSONToBlocks(revision.ContentMeta)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора блоков ревизии: %w", err)
+	}
+
+	item, oldBlocks, err := rs.contentService.UpdateItemWithTransaction(ctx, revision.ItemID,
+		revision.Title, revision.Description, revision.Type, revision.ContentMeta, current.ParentID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Файлы ревизии защищены от сборщика мусора ссылками item_revision_files и остаются на диске
+	if err := rs.contentService.SaveItemFiles(item.ID, blocks); err != nil {
+		return nil, err
+	}
+	for _, hash := range rs.contentService.CleanupOldFiles(oldBlocks, blocks) {
+		if err := queries.DeleteItemFile(item.ID, hash); err != nil {
+			log.Printf("Ошибка удаления записи о файле %s: %v", hash, err)
+		}
+	}
+
+	if err := rs.contentService.ProcessTags(ctx, item.ID, strings.Join(revision.Tags, ",")); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// Diff сравнивает два состояния элемента: old - более раннее, new - более позднее
+func (rs *RevisionService) Diff(old, new *models.ItemRevision) (*RevisionDiff, error) {
+	oldBlocks, err := rs.contentService.JSONToBlocks(old.ContentMeta)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора блоков: %w", err)
+	}
+	newBlocks, err := rs.contentService.JSONToBlocks(new.ContentMeta)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора блоков: %w", err)
+	}
+
+	diff := &RevisionDiff{Blocks: DiffBlocks(oldBlocks, newBlocks)}
+	addField := func(field, oldValue, newValue string) {
+		if oldValue != newValue {
+			diff.Fields = append(diff.Fields, FieldChange{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+	addField("Заголовок", old.Title, new.Title)
+	addField("Описание", old.Description, new.Description)
+	addField("Теги", strings.Join(old.Tags, ", "), strings.Join(new.Tags, ", "))
+	return diff, nil
+}
+
+// sameBlock сравнивает блоки без превью и копий страниц ссылок: они сохраняются отдельно от правки блоков
+func sameBlock(a, b Block) bool {
+	a.Preview, b.Preview = nil, nil
+	a.Snapshot, b.Snapshot = nil, nil
+	return a == b
+}
+
+// DiffBlocks сравнивает списки блоков по наибольшей общей подпоследовательности.
+// Среди блоков, удалённых и добавленных между одними и теми же неизменными блоками,
+// удалённый и добавленный блок одного типа считаются изменённым блоком.
+func DiffBlocks(oldBlocks, newBlocks []Block) []BlockChange {
+	n, m := len(oldBlocks), len(newBlocks)
+
+	// lcs[i][j] - длина общей подпоследовательности oldBlocks[i:] и newBlocks[j:]
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if sameBlock(oldBlocks[i], newBlocks[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var changes []BlockChange
+	var removed, added []*Block
+	// flush добавляет накопленные удаления и добавления, объединяя пары блоков одного типа
+	flush := func() {
+		used := make([]bool, len(added))
+		for _, old := range removed {
+			paired := false
+			for k, block := range added {
+				if !used[k] && block.Type == old.Type {
+					used[k] = true
+					paired = true
+					changes = append(changes, BlockChange{Kind: BlockChanged, Old: old, New: block})
+					break
+				}
+			}
+			if !paired {
+				changes = append(changes, BlockChange{Kind: BlockRemoved, Old: old})
+			}
+		}
+		for k, block := range added {
+			if !used[k] {
+				changes = append(changes, BlockChange{Kind: BlockAdded, New: block})
+			}
+		}
+		removed, added = nil, nil
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && sameBlock(oldBlocks[i], newBlocks[j]):
+			flush()
+			changes = append(changes, BlockChange{Kind: BlockUnchanged, Old: &oldBlocks[i], New: &newBlocks[j]})
+			i++
+			j++
+		case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+			removed = append(removed, &oldBlocks[i])
+			i++
+		default:
+			added = append(added, &newBlocks[j])
+			j++
+		}
+	}
+	flush()
+	return changes
+}
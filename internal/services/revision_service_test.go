@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/queries"
+	"projectT/internal/storage/filesystem"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDiffBlocks проверяет сопоставление блоков двух ревизий
+func TestDiffBlocks(t *testing.T) {
+	intro := Block{Type: "text", Content: "Вступление"}
+	photo := Block{Type: "image", FileHash: "photo", OriginalName: "photo.png"}
+	link := Block{Type: "link", Content: "https://example.com"}
+
+	changes := DiffBlocks(
+		[]Block{intro, photo, {Type: "text", Content: "Старый вывод"}},
+		[]Block{intro, {Type: "text", Content: "Новый вывод"}, link},
+	)
+
+	kinds := make([]BlockChangeKind, len(changes))
+	for i, change := range changes {
+		kinds[i] = change.Kind
+	}
+	assert.Equal(t, []BlockChangeKind{BlockUnchanged, BlockRemoved, BlockChanged, BlockAdded}, kinds)
+	assert.Equal(t, "photo", changes[1].Old.FileHash)
+	assert.Equal(t, "Старый вывод", changes[2].Old.Content)
+	assert.Equal(t, "Новый вывод", changes[2].New.Content)
+	assert.Equal(t, link, *changes[3].New)
+
+	assert.Empty(t, DiffBlocks(nil, nil))
+	for _, change := range DiffBlocks([]Block{intro, photo}, []Block{intro, photo}) {
+		assert.Equal(t, BlockUnchanged, change.Kind)
+	}
+
+	// Загруженное в фоне превью ссылки правкой не считается
+	previewed := link
+	previewed.Preview = &models.LinkPreview{Title: "Пример"}
+	changes = DiffBlocks([]Block{link}, []Block{previewed})
+	require.Len(t, changes, 1)
+	assert.Equal(t, BlockUnchanged, changes[0].Kind)
+}
+
+// TestRevisionRestore проверяет, что сохранение создаёт ревизию, а откат возвращает блоки, теги и файлы
+func TestRevisionRestore(t *testing.T) {
+	setupFileGCTest(t)
+	ctx := context.Background()
+	contentService := NewContentBlocksService()
+	revisionService := NewRevisionService()
+
+	// Исходная версия с файлом и тегом
+	fileData, err := filesystem.SaveFileWithOriginalName([]byte("вложение"), "doc.txt")
+	require.NoError(t, err)
+	firstBlocks := []Block{
+		{Type: "text", Content: "Первая версия"},
+		{Type: "file", FileHash: fileData.Hash, OriginalName: "doc.txt"},
+	}
+	firstMeta, err := contentService.BlocksToJSON(firstBlocks)
+	require.NoError(t, err)
+	item, err := contentService.CreateItemWithTransaction(ctx, "Заметка", "", models.ItemTypeElement, firstMeta, nil)
+	require.NoError(t, err)
+	require.NoError(t, contentService.SaveItemFiles(item.ID, firstBlocks))
+	require.NoError(t, contentService.ProcessTags(ctx, item.ID, "черновик"))
+
+	// Вторая версия без файла и с другим тегом - как при сохранении из формы
+	secondBlocks := []Block{{Type: "text", Content: "Вторая версия"}}
+	secondMeta, err := contentService.BlocksToJSON(secondBlocks)
+	require.NoError(t, err)
+	_, oldBlocks, err := contentService.UpdateItemWithTransaction(ctx, item.ID, "Заметка v2", "", models.ItemTypeElement, secondMeta, nil)
+	require.NoError(t, err)
+	for _, hash := range contentService.CleanupOldFiles(oldBlocks, secondBlocks) {
+		require.NoError(t, queries.DeleteItemFile(item.ID, hash))
+	}
+	require.NoError(t, contentService.ProcessTags(ctx, item.ID, "готово"))
+
+	revisions, err := revisionService.GetRevisions(item.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, "Заметка", revisions[0].Title)
+	assert.Equal(t, []string{"черновик"}, revisions[0].Tags)
+	assert.Equal(t, []string{fileData.Hash}, revisions[0].FileHashes)
+
+	// Файл убран из элемента, но ревизия удерживает его от сборщика мусора
+	record, err := queries.GetFileRecord(fileData.Hash)
+	require.NoError(t, err)
+	assert.Equal(t, 1, record.RefCount)
+
+	// Сравнение ревизии с текущим состоянием
+	current, err := revisionService.CurrentState(ctx, item.ID)
+	require.NoError(t, err)
+	diff, err := revisionService.Diff(revisions[0], current)
+	require.NoError(t, err)
+	assert.True(t, diff.HasChanges())
+	assert.Equal(t, []FieldChange{
+		{Field: "Заголовок", Old: "Заметка", New: "Заметка v2"},
+		{Field: "Теги", Old: "черновик", New: "готово"},
+	}, diff.Fields)
+	require.Len(t, diff.Blocks, 2)
+	assert.Equal(t, BlockChanged, diff.Blocks[0].Kind)
+	assert.Equal(t, BlockRemoved, diff.Blocks[1].Kind)
+
+	// Откат
+	restored, err := revisionService.Restore(ctx, revisions[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Заметка", restored.Title)
+	assert.Equal(t, firstMeta, restored.ContentMeta)
+
+	files, err := queries.GetFilesByItemID(item.ID)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, fileData.Hash, files[0].Hash)
+
+	tags, err := queries.GetTagsForItem(ctx, item.ID)
+	require.NoError(t, err)
+	require.Len(t, tags, 1)
+	assert.Equal(t, "черновик", tags[0].Name)
+
+	// Состояние до отката тоже сохранено в истории
+	revisions, err = revisionService.GetRevisions(item.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, "Заметка v2", revisions[0].Title)
+	assert.Equal(t, []string{"готово"}, revisions[0].Tags)
+	assert.Empty(t, revisions[0].FileHashes)
+
+	current, err = revisionService.CurrentState(ctx, item.ID)
+	require.NoError(t, err)
+	diff, err = revisionService.Diff(revisions[1], current)
+	require.NoError(t, err)
+	assert.False(t, diff.HasChanges(), "элемент совпадает с восстановленной ревизией")
+}
+
+// TestRevisionLimit проверяет ограничение числа ревизий из конфигурации
+func TestRevisionLimit(t *testing.T) {
+	setupFileGCTest(t)
+	ctx := context.Background()
+	contentService := NewContentBlocksService()
+	revisionService := NewRevisionService()
+	t.Cleanup(func() { revisionService.SetMaxRevisions(defaultMaxRevisionsPerItem) })
+
+	item, err := contentService.CreateItemWithTransaction(ctx, "v0", "", models.ItemTypeElement, "[]", nil)
+	require.NoError(t, err)
+	for _, title := range []string{"v1", "v2", "v3", "v4"} {
+		_, _, err := contentService.UpdateItemWithTransaction(ctx, item.ID, title, "", models.ItemTypeElement, "[]", nil)
+		require.NoError(t, err)
+	}
+
+	revisions, err := revisionService.GetRevisions(item.ID)
+	require.NoError(t, err)
+	assert.Len(t, revisions, 4)
+
+	revisionService.SetMaxRevisions(2)
+	assert.Equal(t, 2, MaxRevisionsPerItem())
+	revisions, err = revisionService.GetRevisions(item.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2, "лишние ревизии удалены при смене ограничения")
+	assert.Equal(t, "v3", revisions[0].Title)
+
+	_, _, err = contentService.UpdateItemWithTransaction(ctx, item.ID, "v5", "", models.ItemTypeElement, "[]", nil)
+	require.NoError(t, err)
+	revisions, err = revisionService.GetRevisions(item.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, "v4", revisions[0].Title)
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"projectT/internal/services/favorites"
+	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/queries"
+)
+
+// SavedSearchesService предоставляет сервис для работы с сохранёнными поисками (умными папками)
+type SavedSearchesService struct{}
+
+// NewSavedSearchesService создает новый экземпляр сервиса сохранённых поисков
+func NewSavedSearchesService() *SavedSearchesService {
+	return &SavedSearchesService{}
+}
+
+// CreateSavedSearch сохраняет запрос вместе с настройками фильтрации и сортировки
+func (s *SavedSearchesService) CreateSavedSearch(name, query string, options *FilterOptions) (*models.SavedSearch, error) {
+	search := &models.SavedSearch{Name: name, Query: query}
+	applyFilterOptions(search, options)
+
+	if err := queries.CreateSavedSearch(search); err != nil {
+		return nil, err
+	}
+
+	// Боковая панель показывает сохранённые поиски рядом с избранным
+	favorites.GetEventManager().Notify("saved_searches_changed")
+	return search, nil
+}
+
+// UpdateSavedSearch изменяет название, запрос и настройки сохранённого поиска
+func (s *SavedSearchesService) UpdateSavedSearch(search *models.SavedSearch, options *FilterOptions) error {
+	applyFilterOptions(search, options)
+
+	if err := queries.UpdateSavedSearch(search); err != nil {
+		return err
+	}
+
+	favorites.GetEventManager().Notify("saved_searches_changed")
+	return nil
+}
+
+// GetSavedSearchByID возвращает сохранённый поиск по ID
+func (s *SavedSearchesService) GetSavedSearchByID(id int) (*models.SavedSearch, error) {
+	return queries.GetSavedSearchByID(id)
+}
+
+// GetAllSavedSearches возвращает все сохранённые поиски
+func (s *SavedSearchesService) GetAllSavedSearches() ([]*models.SavedSearch, error) {
+	return queries.GetAllSavedSearches()
+}
+
+// DeleteSavedSearch удаляет сохранённый поиск (и из избранного тоже)
+func (s *SavedSearchesService) DeleteSavedSearch(id int) error {
+	if err := queries.DeleteSavedSearch(id); err != nil {
+		return err
+	}
+
+	favorites.GetEventManager().Notify("saved_searches_changed")
+	return nil
+}
+
+// SavedSearchFilterOptions возвращает настройки фильтрации и сортировки сохранённого поиска
+func SavedSearchFilterOptions(search *models.SavedSearch) *FilterOptions {
+	options := NewSortSettingsService().GetFilterOptions()
+	if search.ItemType != "" {
+		options.ItemType = search.ItemType
+	}
+	if search.Priority != "" {
+		options.Priority = search.Priority
+	}
+	if search.SortBy != "" {
+		options.SortBy = search.SortBy
+	}
+	if search.SortOrder != "" {
+		options.SortOrder = search.SortOrder
+	}
+	return options
+}
+
+// applyFilterOptions переносит настройки фильтрации в сохранённый поиск (nil - настройки по умолчанию)
+func applyFilterOptions(search *models.SavedSearch, options *FilterOptions) {
+	defaults := NewSortSettingsService().GetFilterOptions()
+	if options == nil {
+		options = defaults
+	}
+
+	search.ItemType = firstNonEmpty(options.ItemType, defaults.ItemType)
+	search.Priority = firstNonEmpty(options.Priority, defaults.Priority)
+	search.SortBy = firstNonEmpty(options.SortBy, defaults.SortBy)
+	search.SortOrder = firstNonEmpty(options.SortOrder, defaults.SortOrder)
+}
+
+// firstNonEmpty возвращает value, а если оно пустое - fallback
+func firstNonEmpty(value, fallback string) string {
+	if value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"testing"
+
+	"projectT/internal/storage/database/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSavedSearchesService(t *testing.T) {
+	service := NewSavedSearchesService()
+	assert.NotNil(t, service)
+}
+
+// TestSavedSearchFilterOptions_Defaults проверяет настройки по умолчанию для пустых полей
+func TestSavedSearchFilterOptions_Defaults(t *testing.T) {
+	options := SavedSearchFilterOptions(&models.SavedSearch{SortOrder: "desc"})
+
+	assert.Equal(t, "all", options.ItemType)
+	assert.Equal(t, "none", options.Priority)
+	assert.Equal(t, "name", options.SortBy)
+	assert.Equal(t, "desc", options.SortOrder)
+}
+
+// TestApplyFilterOptions проверяет перенос настроек фильтрации в сохранённый поиск
+func TestApplyFilterOptions(t *testing.T) {
+	search := &models.SavedSearch{}
+	applyFilterOptions(search, &FilterOptions{ItemType: "images", SortBy: "created_date"})
+
+	assert.Equal(t, "images", search.ItemType)
+	assert.Equal(t, "none", search.Priority)
+	assert.Equal(t, "created_date", search.SortBy)
+	assert.Equal(t, "asc", search.SortOrder)
+
+	// Без настроек используются значения по умолчанию
+	applyFilterOptions(search, nil)
+	assert.Equal(t, "all", search.ItemType)
+	assert.Equal(t, "name", search.SortBy)
+
+	// Туда и обратно настройки не меняются
+	options := SavedSearchFilterOptions(search)
+	assert.Equal(t, "all", options.ItemType)
+	assert.Equal(t, "asc", options.SortOrder)
+}
+
+// TestCreateSavedSearch_ValidQuery проверяет сохранение поиска
+func TestCreateSavedSearch_ValidQuery(t *testing.T) {
+	t.Skip("Требует подключения к базе данных")
+}
@@ -0,0 +1,540 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"projectT/internal/services/webfetch"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	// archiveTimeout ограничивает сохранение одной страницы со всеми стилями и изображениями
+	archiveTimeout = 2 * time.Minute
+	// fetchTimeout ограничивает загрузку страницы или одного её ресурса
+	fetchTimeout = 30 * time.Second
+	// maxPageSize наибольший размер HTML страницы
+	maxPageSize = 5 << 20
+	// maxResourceSize наибольший размер одного стиля, изображения или шрифта
+	maxResourceSize = 5 << 20
+	// maxResourcesSize сколько байт ресурсов встраивается в одну копию; остальные остаются ссылками
+	maxResourcesSize = 30 << 20
+	// maxResources сколько ресурсов загружается для одной копии
+	maxResources = 300
+	// maxImportDepth глубина вложенных @import в стилях
+	maxImportDepth = 3
+)
+
+// userAgent с которым запрашиваются страницы
+const userAgent = "Mozilla/5.0 (compatible; projectT page archive)"
+
+// Page сохранённая копия страницы
+type Page struct {
+	URL   string // Итоговый адрес после перенаправлений
+	Title string
+	HTML  []byte // Страница со встроенными стилями и изображениями, без скриптов
+	Text  string // Читаемый текст страницы
+}
+
+// Archiver загружает страницы и собирает из них копии, которые открываются без сети
+type Archiver struct {
+	client *webfetch.Client
+}
+
+// NewArchiver создает загрузчик страниц с ограничениями времени и числа перенаправлений
+func NewArchiver() *Archiver {
+	return &Archiver{client: webfetch.NewClient(userAgent, fetchTimeout)}
+}
+
+// Archive загружает страницу rawURL и собирает её копию. Стили, изображения и шрифты встраиваются
+// в HTML; ресурсы, которые не удалось загрузить, остаются абсолютными ссылками.
+func (a *Archiver) Archive(ctx context.Context, rawURL string) (*Page, error) {
+	pageURL, err := url.Parse(rawURL)
+	if err != nil || !webfetch.IsHTTP(pageURL) {
+		return nil, fmt.Errorf("неподдерживаемый адрес: %s", rawURL)
+	}
+	ctx, cancel := context.WithTimeout(ctx, archiveTimeout)
+	defer cancel()
+
+	resp, err := a.client.Get(ctx, pageURL.String(), "text/html,application/xhtml+xml;q=0.9,*/*;q=0.5")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != "" && mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, fmt.Errorf("адрес ведёт не на веб-страницу (%s)", mediaType)
+	}
+	data, err := webfetch.ReadLimited(resp.Body, maxPageSize)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки страницы: %w", err)
+	}
+	body, err := charset.NewReader(bytes.NewReader(data), contentType)
+	if err != nil {
+		return nil, fmt.Errorf("неизвестная кодировка страницы: %w", err)
+	}
+	doc, err := html.Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора страницы: %w", err)
+	}
+
+	page := &Page{
+		URL:   resp.Request.URL.String(),
+		Title: documentTitle(doc),
+		Text:  extractText(doc),
+	}
+
+	inliner := &inliner{
+		archiver: a,
+		ctx:      ctx,
+		base:     resp.Request.URL,
+		budget:   maxResourcesSize,
+		cache:    make(map[string]string),
+	}
+	inliner.processDocument(doc)
+
+	var buf bytes.Buffer
+	if err := html.Render(&buf, doc); err != nil {
+		return nil, fmt.Errorf("ошибка сборки копии страницы: %w", err)
+	}
+	page.HTML = buf.Bytes()
+	return page, nil
+}
+
+// removedElements элементы, которые в копии не нужны или небезопасны: скрипты, встраиваемые
+// документы и плагины. Без скриптов копия не меняется при открытии и не обращается к сети.
+var removedElements = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Noscript: true,
+	atom.Iframe:   true,
+	atom.Frame:    true,
+	atom.Frameset: true,
+	atom.Object:   true,
+	atom.Embed:    true,
+	atom.Applet:   true,
+	atom.Template: true,
+	atom.Base:     true, // Адреса в копии абсолютные
+}
+
+// removedLinks значения rel ссылок <link>, которые в копии не нужны
+var removedLinks = map[string]bool{
+	"preload":       true,
+	"prefetch":      true,
+	"modulepreload": true,
+	"preconnect":    true,
+	"dns-prefetch":  true,
+	"prerender":     true,
+	"manifest":      true,
+	"import":        true,
+}
+
+// urlAttributes атрибуты, в которых могут быть javascript:-адреса
+var urlAttributes = map[string]bool{
+	"href":       true,
+	"src":        true,
+	"action":     true,
+	"formaction": true,
+	"poster":     true,
+	"background": true,
+	"xlink:href": true,
+}
+
+var (
+	// cssImport правило @import со ссылкой в кавычках или в url() и необязательным списком медиа
+	cssImport = regexp.MustCompile(`@import\s+(?:url\(\s*)?(?:"([^"]*)"|'([^']*)'|([^\s"');]+))\s*\)?\s*([^;]*);`)
+	// cssURL ссылка url() в стилях
+	cssURL = regexp.MustCompile(`url\(\s*(?:"([^"]*)"|'([^']*)'|([^\s"')]*))\s*\)`)
+)
+
+// inliner встраивает ресурсы страницы в её HTML
+type inliner struct {
+	archiver  *Archiver
+	ctx       context.Context
+	base      *url.URL          // Адрес, относительно которого разрешаются ссылки страницы
+	budget    int64             // Сколько байт ресурсов ещё можно встроить
+	resources int               // Сколько ресурсов загружено
+	cache     map[string]string // Абсолютный адрес -> data:-адрес или сам адрес, если загрузить не удалось
+}
+
+// processDocument встраивает ресурсы, удаляет скрипты и объявляет кодировку UTF-8, в которой сохраняется копия
+func (in *inliner) processDocument(doc *html.Node) {
+	if base := findElement(doc, atom.Base); base != nil {
+		if target, err := in.base.Parse(strings.TrimSpace(getAttr(base, "href"))); err == nil && getAttr(base, "href") != "" {
+			in.base = target
+		}
+	}
+	in.processChildren(doc)
+
+	if head := findElement(doc, atom.Head); head != nil {
+		head.InsertBefore(&html.Node{
+			Type:     html.ElementNode,
+			Data:     "meta",
+			DataAtom: atom.Meta,
+			Attr:     []html.Attribute{{Key: "charset", Val: "utf-8"}},
+		}, head.FirstChild)
+	}
+}
+
+// processChildren обрабатывает дочерние элементы n, удаляя ненужные
+func (in *inliner) processChildren(n *html.Node) {
+	for child := n.FirstChild; child != nil; {
+		next := child.NextSibling
+		if child.Type == html.ElementNode {
+			if removed(child) {
+				n.RemoveChild(child)
+			} else if replacement := in.processElement(child); replacement != nil {
+				n.InsertBefore(replacement, child)
+				n.RemoveChild(child)
+			} else {
+				in.processChildren(child)
+			}
+		}
+		child = next
+	}
+}
+
+// removed сообщает, что элемент не попадает в копию
+func removed(n *html.Node) bool {
+	if removedElements[n.DataAtom] {
+		return true
+	}
+	switch n.DataAtom {
+	case atom.Meta:
+		// Кодировка объявляется заново, а обновление страницы и политика безопасности в копии не нужны
+		equiv := strings.ToLower(getAttr(n, "http-equiv"))
+		return hasAttr(n, "charset") || equiv == "content-type" || equiv == "refresh" || equiv == "content-security-policy"
+	case atom.Link:
+		for _, rel := range strings.Fields(strings.ToLower(getAttr(n, "rel"))) {
+			if removedLinks[rel] {
+				return true
+			}
+		}
+	case atom.Source:
+		// Варианты изображения в <picture>: в копии остаётся встроенный <img>
+		return n.Parent != nil && n.Parent.DataAtom == atom.Picture
+	}
+	return false
+}
+
+// processElement встраивает ресурсы элемента и делает его ссылки абсолютными.
+// Возвращает элемент, которым нужно заменить n, или nil.
+func (in *inliner) processElement(n *html.Node) *html.Node {
+	// Обработчики событий и javascript:-адреса без скриптов не нужны
+	attrs := n.Attr[:0]
+	for _, attr := range n.Attr {
+		key := strings.ToLower(attr.Key)
+		if attr.Namespace == "xlink" {
+			key = "xlink:" + key
+		}
+		if strings.HasPrefix(key, "on") {
+			continue
+		}
+		if urlAttributes[key] && strings.HasPrefix(strings.ToLower(strings.TrimSpace(attr.Val)), "javascript:") {
+			continue
+		}
+		attrs = append(attrs, attr)
+	}
+	n.Attr = attrs
+
+	if style := getAttr(n, "style"); style != "" {
+		setAttr(n, "style", in.processCSS(in.base, style, 0))
+	}
+
+	switch n.DataAtom {
+	case atom.Link:
+		rels := strings.Fields(strings.ToLower(getAttr(n, "rel")))
+		href := getAttr(n, "href")
+		for _, rel := range rels {
+			switch rel {
+			case "stylesheet":
+				return in.inlineStylesheet(n, href)
+			case "icon", "apple-touch-icon":
+				setAttr(n, "href", in.inline(in.base, href))
+				return nil
+			}
+		}
+		setAttr(n, "href", in.absolute(href))
+
+	case atom.Style:
+		var css strings.Builder
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == html.TextNode {
+				css.WriteString(child.Data)
+			}
+		}
+		for n.FirstChild != nil {
+			n.RemoveChild(n.FirstChild)
+		}
+		n.AppendChild(&html.Node{Type: html.TextNode, Data: in.processCSS(in.base, css.String(), 0)})
+
+	case atom.Img:
+		in.inlineImage(n)
+
+	case atom.A, atom.Area:
+		if hasAttr(n, "href") {
+			setAttr(n, "href", in.absolute(getAttr(n, "href")))
+		}
+
+	case atom.Form:
+		if hasAttr(n, "action") {
+			setAttr(n, "action", in.absolute(getAttr(n, "action")))
+		}
+
+	case atom.Video, atom.Audio, atom.Source, atom.Track:
+		// Видео и звук не встраиваются: для копии они слишком большие
+		if hasAttr(n, "src") {
+			setAttr(n, "src", in.absolute(getAttr(n, "src")))
+		}
+		if hasAttr(n, "poster") {
+			setAttr(n, "poster", in.inline(in.base, getAttr(n, "poster")))
+		}
+
+	case atom.Input:
+		if strings.EqualFold(getAttr(n, "type"), "image") && hasAttr(n, "src") {
+			setAttr(n, "src", in.inline(in.base, getAttr(n, "src")))
+		}
+
+	case atom.Body, atom.Table, atom.Td, atom.Th:
+		if hasAttr(n, "background") {
+			setAttr(n, "background", in.inline(in.base, getAttr(n, "background")))
+		}
+
+	case atom.Image:
+		// <image> внутри SVG
+		for i, attr := range n.Attr {
+			if attr.Key == "href" {
+				n.Attr[i].Val = in.inline(in.base, attr.Val)
+			}
+		}
+	}
+	return nil
+}
+
+// inlineStylesheet заменяет <link rel="stylesheet"> встроенным <style>. Если стиль не загрузился,
+// ссылка остаётся абсолютной, чтобы копия хотя бы при наличии сети выглядела как страница.
+func (in *inliner) inlineStylesheet(link *html.Node, href string) *html.Node {
+	target, err := in.base.Parse(strings.TrimSpace(href))
+	if err != nil || href == "" {
+		return nil
+	}
+	css, err := in.fetchCSS(target, 0)
+	if err != nil {
+		setAttr(link, "href", target.String())
+		return nil
+	}
+
+	style := &html.Node{Type: html.ElementNode, Data: "style", DataAtom: atom.Style}
+	if media := getAttr(link, "media"); media != "" {
+		style.Attr = append(style.Attr, html.Attribute{Key: "media", Val: media})
+	}
+	style.AppendChild(&html.Node{Type: html.TextNode, Data: css})
+	return style
+}
+
+// inlineImage встраивает изображение <img>. Ленивые изображения часто держат настоящий адрес
+// в data-src, а в src - заглушку, поэтому data-src предпочтительнее. Из srcset берётся первый вариант,
+// если других адресов нет: выбор по размеру экрана в копии не нужен.
+func (in *inliner) inlineImage(img *html.Node) {
+	src := firstNonEmpty(getAttr(img, "data-src"), getAttr(img, "src"))
+	if src == "" {
+		if candidates := strings.Split(firstNonEmpty(getAttr(img, "srcset"), getAttr(img, "data-srcset")), ","); len(candidates) > 0 {
+			if fields := strings.Fields(candidates[0]); len(fields) > 0 {
+				src = fields[0]
+			}
+		}
+	}
+	for _, key := range []string{"srcset", "data-srcset", "data-src", "sizes", "loading"} {
+		removeAttr(img, key)
+	}
+	if src != "" {
+		setAttr(img, "src", in.inline(in.base, src))
+	}
+}
+
+// fetchCSS загружает стиль и встраивает в него импортированные стили, изображения и шрифты
+func (in *inliner) fetchCSS(target *url.URL, depth int) (string, error) {
+	data, contentType, err := in.fetch(target)
+	if err != nil {
+		return "", err
+	}
+	reader, err := charset.NewReader(bytes.NewReader(data), contentType)
+	if err != nil {
+		return "", err
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	return in.processCSS(target, string(decoded), depth), nil
+}
+
+// processCSS встраивает в стиль правила @import и ресурсы url(), адреса которых разрешаются относительно base
+func (in *inliner) processCSS(base *url.URL, css string, depth int) string {
+	css = cssImport.ReplaceAllStringFunc(css, func(rule string) string {
+		match := cssImport.FindStringSubmatch(rule)
+		ref := firstNonEmpty(match[1], match[2], match[3])
+		media := strings.TrimSpace(match[4])
+		target, err := base.Parse(ref)
+		if err != nil {
+			return ""
+		}
+		if depth < maxImportDepth {
+			if imported, err := in.fetchCSS(target, depth+1); err == nil {
+				if media != "" {
+					return "@media " + media + " {\n" + imported + "\n}"
+				}
+				return imported
+			}
+		}
+		return strings.TrimSpace(`@import url("`+target.String()+`") `+media) + ";"
+	})
+
+	return cssURL.ReplaceAllStringFunc(css, func(match string) string {
+		parts := cssURL.FindStringSubmatch(match)
+		ref := firstNonEmpty(parts[1], parts[2], parts[3])
+		if ref == "" {
+			return match
+		}
+		return `url("` + in.inline(base, ref) + `")`
+	})
+}
+
+// inline возвращает data:-адрес ресурса ref, а если его не удалось загрузить - абсолютный адрес
+func (in *inliner) inline(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "#") || strings.HasPrefix(strings.ToLower(ref), "data:") {
+		return ref
+	}
+	target, err := base.Parse(ref)
+	if err != nil || !webfetch.IsHTTP(target) {
+		return ref
+	}
+	key := target.String()
+	if cached, ok := in.cache[key]; ok {
+		return cached
+	}
+
+	result := key
+	if data, contentType, err := in.fetch(target); err == nil {
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		if mediaType == "" || mediaType == "application/octet-stream" || mediaType == "text/plain" {
+			mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(data))
+		}
+		result = "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data)
+	}
+	in.cache[key] = result
+	return result
+}
+
+// absolute возвращает абсолютный адрес ссылки, чтобы она вела на страницу и из копии
+func (in *inliner) absolute(ref string) string {
+	trimmed := strings.TrimSpace(ref)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return ref
+	}
+	target, err := in.base.Parse(trimmed)
+	if err != nil {
+		return ref
+	}
+	return target.String()
+}
+
+// fetch загружает ресурс страницы в пределах оставшегося объёма копии
+func (in *inliner) fetch(target *url.URL) ([]byte, string, error) {
+	if in.resources >= maxResources || in.budget <= 0 {
+		return nil, "", webfetch.ErrTooLarge
+	}
+	in.resources++
+
+	resp, err := in.archiver.client.Get(in.ctx, target.String(), "*/*")
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := webfetch.ReadLimited(resp.Body, min(maxResourceSize, in.budget))
+	if err != nil {
+		return nil, "", err
+	}
+	in.budget -= int64(len(data))
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+// findElement возвращает первый элемент a в поддереве n
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := findElement(child, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// getAttr возвращает значение атрибута key
+func getAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Namespace == "" && attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// hasAttr сообщает, что у элемента есть атрибут key
+func hasAttr(n *html.Node, key string) bool {
+	for _, attr := range n.Attr {
+		if attr.Namespace == "" && attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// setAttr задаёт значение атрибута key
+func setAttr(n *html.Node, key, value string) {
+	for i, attr := range n.Attr {
+		if attr.Namespace == "" && attr.Key == key {
+			n.Attr[i].Val = value
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: value})
+}
+
+// removeAttr удаляет атрибут key
+func removeAttr(n *html.Node, key string) {
+	attrs := n.Attr[:0]
+	for _, attr := range n.Attr {
+		if attr.Namespace != "" || attr.Key != key {
+			attrs = append(attrs, attr)
+		}
+	}
+	n.Attr = attrs
+}
+
+// firstNonEmpty возвращает первое непустое значение
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
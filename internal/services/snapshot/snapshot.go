@@ -0,0 +1,355 @@
+// Package snapshot сохраняет копии веб-страниц из блоков ссылок, чтобы они оставались доступны,
+// когда страница изменится или пропадёт.
+//
+// Копия - один HTML-файл: стили, изображения и шрифты встроены в него как data:-адреса, а скрипты
+// удалены, поэтому она открывается в браузере без сети. Вместе с копией сохраняется читаемый текст
+// страницы: он показывается во встроенном просмотре и попадает в полнотекстовый поиск (page_texts).
+// В зашифрованном хранилище текст в поиск не попадает: таблица хранила бы его открытым.
+// Оба файла лежат в хранилище файлов, адресуются хэшем содержимого и привязаны к элементу через
+// item_files; блок ссылки хранит их в поле snapshot. Копии сохраняются только по запросу пользователя.
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"projectT/internal/services"
+	"projectT/internal/services/favorites"
+	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/repository"
+	"projectT/internal/storage/filesystem"
+)
+
+// attachAttempts сколько раз копия сохраняется в элемент, если его изменили во время записи
+const attachAttempts = 3
+
+// ErrNoLinks у элемента нет ссылок, копии которых можно сохранить
+var ErrNoLinks = errors.New("у элемента нет ссылок")
+
+// storeMu не даёт сохранять копии во время замены базы и хранилища
+var storeMu sync.Mutex
+
+// Suspend ждёт окончания сохранения текущей копии и не даёт начать новое до вызова resume.
+// Нужна при замене хранилища, например при переключении библиотеки.
+func Suspend() (resume func()) {
+	storeMu.Lock()
+	return storeMu.Unlock
+}
+
+// Service сохраняет копии страниц ссылок элементов
+type Service struct {
+	archiver *Archiver
+	items    repository.Items
+	files    repository.Files
+}
+
+var (
+	instance *Service
+	once     sync.Once
+)
+
+// Get возвращает глобальный сервис копий страниц
+func Get() *Service {
+	once.Do(func() {
+		instance = NewService(NewArchiver())
+	})
+	return instance
+}
+
+// NewService создает сервис копий страниц с загрузчиком archiver
+func NewService(archiver *Archiver) *Service {
+	repos := repository.Default()
+	return &Service{
+		archiver: archiver,
+		items:    repos.Items,
+		files:    repos.Files,
+	}
+}
+
+// ArchiveItem сохраняет копии страниц всех ссылок элемента, заменяя прежние копии.
+// Возвращает число сохранённых копий; ошибка возвращается, только если не сохранилась ни одна.
+func (s *Service) ArchiveItem(ctx context.Context, itemID int) (int, error) {
+	item, err := s.items.GetItemByID(itemID)
+	if err != nil {
+		return 0, err
+	}
+	blocks, err := parseBlocks(item.ContentMeta)
+	if err != nil {
+		return 0, err
+	}
+
+	// Страницы загружаются до блокировки хранилища: это самая долгая часть
+	pages := make(map[string]*Page)
+	var failures []error
+	for _, block := range blocks {
+		if !block.isLink() {
+			continue
+		}
+		if _, done := pages[block.content]; done {
+			continue
+		}
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		page, err := s.archiver.Archive(ctx, normalizeURL(block.content))
+		if err != nil {
+			failures = append(failures, err)
+			continue
+		}
+		pages[block.content] = page
+	}
+	if len(pages) == 0 {
+		if len(failures) == 0 {
+			return 0, ErrNoLinks
+		}
+		return 0, errors.Join(failures...)
+	}
+	for _, err := range failures {
+		log.Printf("Не удалось сохранить копию страницы элемента %d: %v", itemID, err)
+	}
+
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	now := time.Now()
+	snapshots := make(map[string]*models.PageSnapshot, len(pages))
+	for link, page := range pages {
+		snapshot, err := s.store(page, now)
+		if err != nil {
+			return 0, err
+		}
+		snapshots[link] = snapshot
+	}
+	if err := s.attach(itemID, snapshots); err != nil {
+		return 0, err
+	}
+
+	favorites.GetEventManager().Notify("items_changed")
+	return len(snapshots), nil
+}
+
+// store сохраняет копию и её текст в хранилище файлов, а текст - ещё и в поиск, если хранилище не зашифровано
+func (s *Service) store(page *Page, archivedAt time.Time) (*models.PageSnapshot, error) {
+	htmlFile, err := filesystem.SaveFileWithOriginalName(page.HTML, "snapshot.html")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сохранения копии страницы: %w", err)
+	}
+	textFile, err := filesystem.SaveFileWithOriginalName([]byte(page.Text), "snapshot.txt")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сохранения текста страницы: %w", err)
+	}
+	if !filesystem.VaultEnabled() {
+		if err := s.files.SavePageText(textFile.Hash, page.Text); err != nil {
+			return nil, fmt.Errorf("ошибка сохранения текста страницы для поиска: %w", err)
+		}
+	}
+
+	return &models.PageSnapshot{
+		URL:        page.URL,
+		Title:      page.Title,
+		FileHash:   htmlFile.Hash,
+		TextHash:   textFile.Hash,
+		Size:       htmlFile.Size,
+		ArchivedAt: archivedAt,
+	}, nil
+}
+
+// attach записывает копии в блоки ссылок элемента и привязывает их файлы к элементу,
+// отвязывая файлы заменённых копий
+func (s *Service) attach(itemID int, snapshots map[string]*models.PageSnapshot) error {
+	for attempt := 0; attempt < attachAttempts; attempt++ {
+		// Элемент перечитывается: за время загрузки страниц его могли изменить
+		item, err := s.items.GetItemByID(itemID)
+		if err != nil {
+			return err
+		}
+		blocks, err := parseBlocks(item.ContentMeta)
+		if err != nil {
+			return err
+		}
+		var oldBlocks []services.Block
+		_ = json.Unmarshal([]byte(item.ContentMeta), &oldBlocks)
+
+		changed := false
+		for _, block := range blocks {
+			if snapshot, ok := snapshots[block.content]; ok && block.isLink() {
+				if err := block.setSnapshot(snapshot); err != nil {
+					return err
+				}
+				changed = true
+			}
+		}
+		if !changed {
+			return errors.New("ссылки элемента изменились во время сохранения копии")
+		}
+
+		data, err := json.Marshal(blocks)
+		if err != nil {
+			return fmt.Errorf("ошибка сериализации контента: %w", err)
+		}
+		meta := string(data)
+		updated, err := s.items.UpdateItemContentMeta(itemID, item.ContentMeta, meta,
+			filesystem.GenerateContentHash(item.Title, item.Description, meta))
+		if err != nil {
+			return err
+		}
+		if !updated {
+			continue
+		}
+
+		var newBlocks []services.Block
+		if err := json.Unmarshal(data, &newBlocks); err != nil {
+			return err
+		}
+		s.linkFiles(itemID, oldBlocks, newBlocks)
+		return nil
+	}
+	return errors.New("элемент изменялся во время сохранения копии, попробуйте ещё раз")
+}
+
+// linkFiles приводит item_files элемента в соответствие с копиями страниц в его блоках
+func (s *Service) linkFiles(itemID int, oldBlocks, newBlocks []services.Block) {
+	for _, block := range newBlocks {
+		if block.Snapshot == nil {
+			continue
+		}
+		for _, hash := range []string{block.Snapshot.FileHash, block.Snapshot.TextHash} {
+			info, err := filesystem.GetFileInfo(hash)
+			if err != nil {
+				log.Printf("Не удалось получить информацию о файле копии %s: %v", hash, err)
+				continue
+			}
+			err = s.files.CreateItemFile(&models.ItemFile{
+				ItemID:   itemID,
+				Hash:     hash,
+				FilePath: info.Path,
+				Size:     info.Size,
+				MimeType: info.MimeType,
+			})
+			if err != nil {
+				log.Printf("Не удалось привязать файл копии %s к элементу %d: %v", hash, itemID, err)
+			}
+		}
+	}
+
+	// Файлы заменённых копий отвязываются; с диска их удалит сборщик мусора
+	for _, hash := range services.NewContentBlocksService().CleanupOldFiles(oldBlocks, newBlocks) {
+		if err := s.files.DeleteItemFile(itemID, hash); err != nil {
+			log.Printf("Не удалось отвязать файл %s от элемента %d: %v", hash, itemID, err)
+		}
+	}
+}
+
+// IndexTexts добавляет в поиск текст копий, которого в нём нет: например, у элементов,
+// пришедших при синхронизации или из архива, или после выключения шифрования. В зашифрованном
+// хранилище ничего не делает. Возвращает число добавленных текстов.
+func (s *Service) IndexTexts() (int, error) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	if filesystem.VaultEnabled() {
+		return 0, nil
+	}
+	hashes, err := s.files.GetUnindexedPageTexts()
+	if err != nil {
+		return 0, err
+	}
+
+	indexed := 0
+	for _, hash := range hashes {
+		// Файл текста ещё не получен: его текст добавится при следующем запуске
+		if !filesystem.Exists(hash) {
+			continue
+		}
+		data, err := filesystem.ReadFile(hash)
+		if err != nil {
+			return indexed, err
+		}
+		if err := s.files.SavePageText(hash, string(data)); err != nil {
+			return indexed, err
+		}
+		indexed++
+	}
+	return indexed, nil
+}
+
+// ReadText возвращает текст сохранённой копии страницы
+func ReadText(snapshot *models.PageSnapshot) (string, error) {
+	data, err := filesystem.ReadFile(snapshot.TextHash)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// HTMLPath возвращает путь к HTML-копии страницы для открытия в браузере.
+// Копия из зашифрованного хранилища расшифровывается во временную директорию.
+func HTMLPath(snapshot *models.PageSnapshot) (string, error) {
+	if !filesystem.Exists(snapshot.FileHash) {
+		return "", fmt.Errorf("файл копии страницы %s не найден", snapshot.FileHash)
+	}
+	return filesystem.PlainFilePath(snapshot.FileHash)
+}
+
+// block блок content_meta. Поля хранятся как есть, чтобы сохранение копии не теряло
+// поля, о которых этот пакет не знает.
+type block struct {
+	fields  map[string]json.RawMessage
+	kind    string
+	content string
+}
+
+// parseBlocks разбирает content_meta элемента
+func parseBlocks(contentMeta string) ([]*block, error) {
+	if contentMeta == "" {
+		return nil, nil
+	}
+	var raw []map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(contentMeta), &raw); err != nil {
+		return nil, fmt.Errorf("ошибка разбора JSON блоков: %w", err)
+	}
+
+	blocks := make([]*block, 0, len(raw))
+	for _, fields := range raw {
+		b := &block{fields: fields}
+		_ = json.Unmarshal(fields["type"], &b.kind)
+		_ = json.Unmarshal(fields["content"], &b.content)
+		blocks = append(blocks, b)
+	}
+	return blocks, nil
+}
+
+// isLink сообщает, что блок - непустая ссылка
+func (b *block) isLink() bool {
+	return b.kind == "link" && strings.TrimSpace(b.content) != ""
+}
+
+// setSnapshot заменяет копию страницы блока
+func (b *block) setSnapshot(snapshot *models.PageSnapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	b.fields["snapshot"] = data
+	return nil
+}
+
+// MarshalJSON сериализует блок со всеми исходными полями
+func (b *block) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.fields)
+}
+
+// normalizeURL дополняет ссылку без протокола до https, как при открытии ссылки в интерфейсе
+func normalizeURL(link string) string {
+	link = strings.TrimSpace(link)
+	if !strings.HasPrefix(link, "http://") && !strings.HasPrefix(link, "https://") {
+		return "https://" + link
+	}
+	return link
+}
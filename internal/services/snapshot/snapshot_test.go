@@ -0,0 +1,339 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/queries"
+	"projectT/internal/storage/filesystem"
+	"projectT/internal/storage/vault"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testStorageConfig хранилище файлов во временной директории
+type testStorageConfig struct{ path string }
+
+func (c testStorageConfig) GetPath() string     { return c.path }
+func (c testStorageConfig) GetFilesDir() string { return "files" }
+
+// setupTestLibrary подключает БД в памяти и временное хранилище файлов
+func setupTestLibrary(t *testing.T) {
+	t.Helper()
+
+	db, err := database.Open(":memory:")
+	require.NoError(t, err)
+	originalDB := database.GetDB()
+	database.SetDB(db)
+	database.RunMigrations()
+
+	root := filesystem.GetStorageRoot()
+	filesystem.InitStorage(testStorageConfig{path: t.TempDir()})
+
+	t.Cleanup(func() {
+		database.CloseDB()
+		database.SetDB(originalDB)
+		filesystem.InitStorage(testStorageConfig{path: root})
+	})
+}
+
+// testPNG возвращает PNG 2x2 цвета fill
+func testPNG(t *testing.T, fill color.Color) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	for x := 0; x < 2; x++ {
+		for y := 0; y < 2; y++ {
+			img.Set(x, y, fill)
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// newTestSite запускает сайт со страницей, её стилями и изображениями
+func newTestSite(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	picture := testPNG(t, color.RGBA{G: 200, A: 255})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/article", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<!doctype html><html><head>
+			<meta charset="utf-8">
+			<title>  Статья   о копиях </title>
+			<link rel="stylesheet" href="/css/main.css">
+			<link rel="icon" href="/favicon.png">
+			<script>alert("tracking")</script>
+			</head><body onload="init()">
+			<nav>Меню сайта</nav>
+			<article>
+				<h1>Заголовок статьи</h1>
+				<p>Первый абзац про <b>уникальноеслово</b>.</p>
+				<p onclick="steal()">Второй абзац</p>
+				<img data-src="/img/photo.png" src="/img/placeholder.gif" alt="фото">
+				<a href="/other">дальше</a>
+				<a href="javascript:void(0)">скрипт</a>
+			</article>
+			<footer>Подвал</footer>
+			</body></html>`))
+	})
+	mux.HandleFunc("/css/main.css", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/css")
+		w.Write([]byte(`@import "base.css"; body { background: url(../img/photo.png) }`))
+	})
+	mux.HandleFunc("/css/base.css", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/css")
+		w.Write([]byte(`h1 { color: red }`))
+	})
+	for _, path := range []string{"/img/photo.png", "/favicon.png"} {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(picture)
+		})
+	}
+	mux.HandleFunc("/second", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><head><title>Вторая</title></head><body><p>Другая страница</p></body></html>`))
+	})
+	mux.HandleFunc("/file.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte("%PDF-1.4"))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestArchive проверяет сборку копии страницы со встроенными ресурсами и без скриптов
+func TestArchive(t *testing.T) {
+	site := newTestSite(t)
+
+	page, err := NewArchiver().Archive(context.Background(), site.URL+"/article")
+	require.NoError(t, err)
+	assert.Equal(t, site.URL+"/article", page.URL)
+	assert.Equal(t, "Статья о копиях", page.Title)
+	assert.Equal(t, "Заголовок статьи\nПервый абзац про уникальноеслово.\nВторой абзац\nдальше скрипт", page.Text)
+
+	copy := string(page.HTML)
+	assert.NotContains(t, copy, "<script")
+	assert.NotContains(t, copy, "tracking")
+	assert.NotContains(t, copy, "onload")
+	assert.NotContains(t, copy, "onclick")
+	assert.NotContains(t, copy, "javascript:")
+	assert.NotContains(t, copy, "main.css", "стиль встроен")
+	assert.NotContains(t, copy, "@import", "импортированный стиль встроен")
+	assert.Contains(t, copy, "h1 { color: red }")
+	assert.Contains(t, copy, `url("data:image/png;base64,`)
+	assert.Contains(t, copy, `<img src="data:image/png;base64,`)
+	assert.NotContains(t, copy, "placeholder.gif", "ленивое изображение берётся из data-src")
+	assert.Contains(t, copy, `rel="icon" href="data:image/png;base64,`)
+	assert.Contains(t, copy, `href="`+site.URL+`/other"`, "ссылки становятся абсолютными")
+	assert.Contains(t, copy, `<meta charset="utf-8"/>`)
+}
+
+// TestArchiveErrors проверяет отказ сохранять не веб-страницы
+func TestArchiveErrors(t *testing.T) {
+	site := newTestSite(t)
+	archiver := NewArchiver()
+
+	for _, rawURL := range []string{site.URL + "/file.pdf", site.URL + "/missing", "file:///etc/passwd", "ftp://example.com"} {
+		_, err := archiver.Archive(context.Background(), rawURL)
+		assert.Error(t, err, rawURL)
+	}
+}
+
+// snapshotBlock блок элемента с копией страницы
+type snapshotBlock struct {
+	Type     string               `json:"type"`
+	Content  string               `json:"content"`
+	Custom   string               `json:"custom"`
+	Snapshot *models.PageSnapshot `json:"snapshot"`
+}
+
+// createLinkItem создает элемент с блоками content_meta
+func createLinkItem(t *testing.T, meta string) *models.Item {
+	t.Helper()
+
+	item := &models.Item{
+		Type:        models.ItemTypeElement,
+		Title:       "Ссылки",
+		ContentMeta: meta,
+		ContentHash: filesystem.GenerateContentHash("Ссылки", "", meta),
+	}
+	require.NoError(t, queries.CreateItem(item))
+	return item
+}
+
+// itemBlocks возвращает блоки сохранённого элемента
+func itemBlocks(t *testing.T, itemID int) []snapshotBlock {
+	t.Helper()
+
+	item, err := queries.GetItemByID(itemID)
+	require.NoError(t, err)
+	var blocks []snapshotBlock
+	require.NoError(t, json.Unmarshal([]byte(item.ContentMeta), &blocks))
+	return blocks
+}
+
+// itemFileHashes возвращает хэши файлов, привязанных к элементу
+func itemFileHashes(t *testing.T, itemID int) map[string]bool {
+	t.Helper()
+
+	files, err := queries.GetFilesByItemID(itemID)
+	require.NoError(t, err)
+	hashes := make(map[string]bool, len(files))
+	for _, file := range files {
+		hashes[file.Hash] = true
+	}
+	return hashes
+}
+
+// searchIDs возвращает ID элементов, найденных по запросу
+func searchIDs(t *testing.T, query string) []int {
+	t.Helper()
+
+	items, err := queries.SearchItems(query)
+	require.NoError(t, err)
+	var ids []int
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
+
+// TestArchiveItem проверяет сохранение копий в блоки элемента, файлы и поиск
+func TestArchiveItem(t *testing.T) {
+	setupTestLibrary(t)
+	site := newTestSite(t)
+	service := NewService(NewArchiver())
+
+	item := createLinkItem(t, `[{"type":"text","content":"заметка"},`+
+		`{"type":"link","content":"`+site.URL+`/article","custom":"сохраняется"},`+
+		`{"type":"link","content":"`+site.URL+`/file.pdf"}]`)
+	assert.Empty(t, searchIDs(t, "уникальноеслово"))
+
+	saved, err := service.ArchiveItem(context.Background(), item.ID)
+	require.NoError(t, err, "ошибка одной ссылки не мешает сохранить остальные")
+	assert.Equal(t, 1, saved)
+
+	blocks := itemBlocks(t, item.ID)
+	require.Len(t, blocks, 3)
+	assert.Nil(t, blocks[0].Snapshot)
+	assert.Nil(t, blocks[2].Snapshot)
+
+	article := blocks[1]
+	assert.Equal(t, "сохраняется", article.Custom)
+	require.NotNil(t, article.Snapshot)
+	assert.Equal(t, "Статья о копиях", article.Snapshot.Title)
+	assert.False(t, article.Snapshot.ArchivedAt.IsZero())
+	assert.Positive(t, article.Snapshot.Size)
+
+	text, err := ReadText(article.Snapshot)
+	require.NoError(t, err)
+	assert.Contains(t, text, "уникальноеслово")
+	path, err := HTMLPath(article.Snapshot)
+	require.NoError(t, err)
+	assert.FileExists(t, path)
+
+	// Файлы копии привязаны к элементу и удерживаются от сборщика мусора
+	files := itemFileHashes(t, item.ID)
+	assert.True(t, files[article.Snapshot.FileHash])
+	assert.True(t, files[article.Snapshot.TextHash])
+	referenced, err := queries.GetReferencedFileHashes()
+	require.NoError(t, err)
+	assert.True(t, referenced[article.Snapshot.FileHash])
+	assert.True(t, referenced[article.Snapshot.TextHash])
+
+	// Текст копии ищется, а меню сайта - нет
+	assert.Equal(t, []int{item.ID}, searchIDs(t, "уникальноеслово"))
+	assert.Empty(t, searchIDs(t, "Подвал"))
+
+	_, err = service.ArchiveItem(context.Background(), createLinkItem(t, `[{"type":"text","content":"без ссылок"}]`).ID)
+	assert.ErrorIs(t, err, ErrNoLinks)
+}
+
+// TestArchiveItemReplace проверяет, что новая копия заменяет прежнюю
+func TestArchiveItemReplace(t *testing.T) {
+	setupTestLibrary(t)
+	site := newTestSite(t)
+	service := NewService(NewArchiver())
+
+	item := createLinkItem(t, `[{"type":"link","content":"`+site.URL+`/article"}]`)
+	_, err := service.ArchiveItem(context.Background(), item.ID)
+	require.NoError(t, err)
+	first := itemBlocks(t, item.ID)[0].Snapshot
+	require.NotNil(t, first)
+
+	// Ссылку заменили: прежняя копия отвязывается, текст прежней страницы больше не ищется
+	current, err := queries.GetItemByID(item.ID)
+	require.NoError(t, err)
+	meta := strings.Replace(current.ContentMeta, site.URL+"/article", site.URL+"/second", 1)
+	updated, err := service.items.UpdateItemContentMeta(item.ID, current.ContentMeta, meta,
+		filesystem.GenerateContentHash(current.Title, current.Description, meta))
+	require.NoError(t, err)
+	require.True(t, updated)
+
+	_, err = service.ArchiveItem(context.Background(), item.ID)
+	require.NoError(t, err)
+	second := itemBlocks(t, item.ID)[0].Snapshot
+	require.NotNil(t, second)
+	assert.Equal(t, "Вторая", second.Title)
+
+	files := itemFileHashes(t, item.ID)
+	assert.False(t, files[first.FileHash])
+	assert.False(t, files[first.TextHash])
+	assert.True(t, files[second.FileHash])
+	assert.True(t, files[second.TextHash])
+	assert.Empty(t, searchIDs(t, "уникальноеслово"))
+	assert.Equal(t, []int{item.ID}, searchIDs(t, "Другая"))
+}
+
+// TestIndexTexts проверяет добавление в поиск текста копий, пришедших без него
+func TestIndexTexts(t *testing.T) {
+	setupTestLibrary(t)
+	service := NewService(NewArchiver())
+
+	textFile, err := filesystem.SaveFileWithOriginalName([]byte("текст из архива"), "snapshot.txt")
+	require.NoError(t, err)
+	snapshot, err := json.Marshal(&models.PageSnapshot{FileHash: strings.Repeat("0", 64), TextHash: textFile.Hash})
+	require.NoError(t, err)
+	item := createLinkItem(t, `[{"type":"link","content":"https://example.com","snapshot":`+string(snapshot)+`}]`)
+	assert.Empty(t, searchIDs(t, "архива"))
+
+	indexed, err := service.IndexTexts()
+	require.NoError(t, err)
+	assert.Equal(t, 1, indexed)
+	assert.Equal(t, []int{item.ID}, searchIDs(t, "архива"))
+
+	indexed, err = service.IndexTexts()
+	require.NoError(t, err)
+	assert.Zero(t, indexed)
+
+	// В зашифрованном хранилище текст в базу не попадает
+	require.NoError(t, vault.Setup(filesystem.GetStorageRoot(), "secret"))
+	t.Cleanup(vault.Lock)
+	sealedFile, err := filesystem.SaveFileWithOriginalName([]byte("зашифрованный текст"), "snapshot.txt")
+	require.NoError(t, err)
+	snapshot, err = json.Marshal(&models.PageSnapshot{FileHash: strings.Repeat("1", 64), TextHash: sealedFile.Hash})
+	require.NoError(t, err)
+	createLinkItem(t, `[{"type":"link","content":"https://example.org","snapshot":`+string(snapshot)+`}]`)
+
+	indexed, err = service.IndexTexts()
+	require.NoError(t, err)
+	assert.Zero(t, indexed)
+	assert.Empty(t, searchIDs(t, "зашифрованный"))
+}
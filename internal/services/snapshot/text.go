@@ -0,0 +1,138 @@
+package snapshot
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	// maxTitleLength наибольшая длина заголовка копии в символах
+	maxTitleLength = 300
+	// maxTextLength наибольший размер текста копии в байтах: полнотекстовому поиску хватает начала страницы
+	maxTextLength = 1 << 20
+)
+
+// skippedText элементы, текст которых не относится к содержимому страницы: служебные, навигация и формы
+var skippedText = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+	atom.Iframe:   true,
+	atom.Object:   true,
+	atom.Nav:      true,
+	atom.Aside:    true,
+	atom.Footer:   true,
+	atom.Form:     true,
+	atom.Button:   true,
+	atom.Select:   true,
+}
+
+// textBlocks элементы, текст которых начинается с новой строки
+var textBlocks = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Blockquote: true, atom.Br: true,
+	atom.Dd: true, atom.Div: true, atom.Dl: true, atom.Dt: true,
+	atom.Figcaption: true, atom.Figure: true, atom.H1: true, atom.H2: true,
+	atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Header: true, atom.Hr: true, atom.Li: true, atom.Main: true,
+	atom.Ol: true, atom.P: true, atom.Pre: true, atom.Section: true,
+	atom.Table: true, atom.Td: true, atom.Th: true, atom.Tr: true, atom.Ul: true,
+}
+
+// documentTitle возвращает заголовок страницы из <title>
+func documentTitle(doc *html.Node) string {
+	title := findElement(doc, atom.Title)
+	if title == nil {
+		return ""
+	}
+	var text strings.Builder
+	for child := title.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.TextNode {
+			text.WriteString(child.Data)
+		}
+	}
+	return truncate(strings.Join(strings.Fields(text.String()), " "), maxTitleLength)
+}
+
+// extractText возвращает читаемый текст страницы: абзацы с новой строки, без меню, скриптов и стилей.
+// Если статья на странице одна (<article>) или выделено основное содержимое (<main>), берётся только оно.
+func extractText(doc *html.Node) string {
+	root := findElement(doc, atom.Body)
+	if articles := findElements(doc, atom.Article); len(articles) == 1 {
+		root = articles[0]
+	} else if content := findElement(doc, atom.Main); content != nil {
+		root = content
+	}
+	if root == nil {
+		root = doc
+	}
+
+	var lines []string
+	var line strings.Builder
+	flush := func() {
+		if text := strings.Join(strings.Fields(line.String()), " "); text != "" {
+			lines = append(lines, text)
+		}
+		line.Reset()
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			line.WriteString(n.Data)
+			return
+		case html.ElementNode:
+			if skippedText[n.DataAtom] {
+				return
+			}
+		}
+		block := n.Type == html.ElementNode && textBlocks[n.DataAtom]
+		if block {
+			flush()
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+		if block {
+			flush()
+		}
+	}
+	walk(root)
+	flush()
+
+	text := strings.Join(lines, "\n")
+	if len(text) > maxTextLength {
+		text = text[:maxTextLength]
+		for !utf8.ValidString(text) {
+			text = text[:len(text)-1]
+		}
+	}
+	return text
+}
+
+// findElements возвращает все элементы a в поддереве n
+func findElements(n *html.Node, a atom.Atom) []*html.Node {
+	var found []*html.Node
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		found = append(found, n)
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		found = append(found, findElements(child, a)...)
+	}
+	return found
+}
+
+// truncate обрезает текст до limit символов
+func truncate(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:limit-1])) + "…"
+}
@@ -0,0 +1,266 @@
+// Package thumbnail строит и кэширует уменьшенные копии изображений из хранилища файлов.
+//
+// Карточки показывают изображения в рамке 250 точек, поэтому декодировать оригинал на каждую
+// отрисовку незачем: миниатюры нескольких размеров строятся один раз на пуле воркеров и
+// хранятся в filesystem.GetThumbnailsDir() по хэшу файла и размеру. Файл кэша адресуется только
+// хэшем, поэтому одно изображение, сохранённое с разными расширениями, делит миниатюры.
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+
+	"projectT/internal/storage/filesystem"
+
+	"golang.org/x/image/draw"
+
+	// Декодеры форматов, которые могут лежать в хранилище
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/webp"
+)
+
+// Size наибольшая сторона миниатюры в пикселях
+type Size int
+
+const (
+	// Small значки в списках и всплывающих подсказках
+	Small Size = 128
+	// Medium карточки в сетке
+	Medium Size = 256
+	// Large карточки на экранах с высокой плотностью пикселей и просмотр
+	Large Size = 512
+)
+
+// Sizes все размеры, которые строятся для файла
+var Sizes = []Size{Small, Medium, Large}
+
+// maxSourcePixels ограничивает размер декодируемого изображения, чтобы повреждённый
+// или специально подготовленный файл не занял всю память
+const maxSourcePixels = 100 << 20
+
+// jpegQuality качество миниатюр без прозрачности
+const jpegQuality = 85
+
+// ErrNotImage файл не является изображением поддерживаемого формата
+var ErrNotImage = errors.New("файл не является изображением")
+
+// Service строит миниатюры на пуле воркеров.
+// Одновременные запросы одного файла объединяются: он декодируется один раз для всех размеров.
+type Service struct {
+	jobs     chan string
+	mu       sync.Mutex
+	inflight map[string]*call
+}
+
+// call построение миниатюр одного файла
+type call struct {
+	done chan struct{}
+	err  error
+}
+
+var (
+	instance *Service
+	once     sync.Once
+)
+
+// Get возвращает глобальный сервис миниатюр с пулом по числу процессоров
+func Get() *Service {
+	once.Do(func() {
+		instance = NewService(runtime.NumCPU())
+	})
+	return instance
+}
+
+// NewService создает сервис с workers воркерами
+func NewService(workers int) *Service {
+	if workers < 1 {
+		workers = 1
+	}
+	s := &Service{
+		jobs:     make(chan string, 256),
+		inflight: make(map[string]*call),
+	}
+	for i := 0; i < workers; i++ {
+		go s.worker()
+	}
+	return s
+}
+
+// Load возвращает миниатюру размера size; если её нет в кэше, строит миниатюры файла и ждёт результата
+func (s *Service) Load(hash string, size Size) ([]byte, error) {
+	if !filesystem.IsValidHash(hash) {
+		return nil, fmt.Errorf("некорректный хэш файла: %s", hash)
+	}
+	if data, err := readCached(hash, size); err == nil {
+		return data, nil
+	}
+
+	c := s.start(hash, true)
+	<-c.done
+	if c.err != nil {
+		return nil, c.err
+	}
+	return readCached(hash, size)
+}
+
+// Schedule ставит файл в очередь на построение миниатюр, не дожидаясь результата.
+// Если очередь заполнена, файл пропускается: миниатюры будут построены при первом показе.
+func (s *Service) Schedule(hash string) {
+	if !filesystem.IsValidHash(hash) || cached(hash) {
+		return
+	}
+	s.start(hash, false)
+}
+
+// Invalidate удаляет миниатюры файла
+func (s *Service) Invalidate(hash string) {
+	filesystem.RemoveThumbnails(hash)
+}
+
+// start возвращает текущее построение миниатюр файла или ставит новое в очередь.
+// wait означает, что вызывающий будет ждать результата и очередь можно не пропускать.
+func (s *Service) start(hash string, wait bool) *call {
+	s.mu.Lock()
+	if c, ok := s.inflight[hash]; ok {
+		s.mu.Unlock()
+		return c
+	}
+	c := &call{done: make(chan struct{})}
+	s.inflight[hash] = c
+	s.mu.Unlock()
+
+	if wait {
+		s.jobs <- hash
+		return c
+	}
+	select {
+	case s.jobs <- hash:
+	default:
+		s.finish(hash, c, nil)
+	}
+	return c
+}
+
+// finish завершает построение и будит ожидающих
+func (s *Service) finish(hash string, c *call, err error) {
+	s.mu.Lock()
+	delete(s.inflight, hash)
+	s.mu.Unlock()
+	c.err = err
+	close(c.done)
+}
+
+// worker строит миниатюры файлов из очереди
+func (s *Service) worker() {
+	for hash := range s.jobs {
+		s.mu.Lock()
+		c := s.inflight[hash]
+		s.mu.Unlock()
+
+		err := generate(hash)
+		if err != nil && !errors.Is(err, ErrNotImage) {
+			log.Printf("Ошибка построения миниатюр %s: %v", hash, err)
+		}
+		if c != nil {
+			s.finish(hash, c, err)
+		}
+	}
+}
+
+// generate декодирует оригинал один раз и записывает миниатюры всех размеров
+func generate(hash string) error {
+	data, err := filesystem.ReadFile(hash)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(filesystem.DetectMimeType(data), "image/") {
+		return ErrNotImage
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return ErrNotImage
+	}
+	if config.Width*config.Height > maxSourcePixels {
+		return fmt.Errorf("изображение слишком большое: %dx%d", config.Width, config.Height)
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotImage, err)
+	}
+
+	for _, size := range Sizes {
+		encoded, err := encode(scale(src, size))
+		if err != nil {
+			return err
+		}
+		path := filesystem.GetThumbnailPath(hash, int(size))
+		if err := filesystem.EnsureParentDir(path); err != nil {
+			return err
+		}
+		// В зашифрованном хранилище миниатюры тоже шифруются
+		if err := filesystem.WriteBlobFile(path, encoded); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scale уменьшает изображение так, чтобы большая сторона была не больше size; меньшие не увеличиваются
+func scale(src image.Image, size Size) image.Image {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= int(size) && h <= int(size) {
+		return src
+	}
+	if w >= h {
+		h = max(1, h*int(size)/w)
+		w = int(size)
+	} else {
+		w = max(1, w*int(size)/h)
+		h = int(size)
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+	return dst
+}
+
+// encode сохраняет миниатюру в JPEG, а изображения с прозрачностью - в PNG
+func encode(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && !opaque.Opaque() {
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, fmt.Errorf("ошибка кодирования миниатюры: %w", err)
+		}
+		return buf.Bytes(), nil
+	}
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, fmt.Errorf("ошибка кодирования миниатюры: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// readCached читает миниатюру из кэша
+func readCached(hash string, size Size) ([]byte, error) {
+	return filesystem.ReadBlobFile(filesystem.GetThumbnailPath(hash, int(size)))
+}
+
+// cached проверяет, построены ли все миниатюры файла
+func cached(hash string) bool {
+	for _, size := range Sizes {
+		if _, err := os.Stat(filesystem.GetThumbnailPath(hash, int(size))); err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,108 @@
+package thumbnail
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"sync"
+	"testing"
+
+	"projectT/internal/storage/filesystem"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testStorageConfig временное хранилище файлов
+type testStorageConfig struct {
+	path string
+}
+
+func (c testStorageConfig) GetPath() string     { return c.path }
+func (c testStorageConfig) GetFilesDir() string { return "files" }
+
+// setupThumbnailTest подключает временное хранилище и возвращает сервис с двумя воркерами
+func setupThumbnailTest(t *testing.T) *Service {
+	t.Helper()
+	root := filesystem.GetStorageRoot()
+	filesystem.InitStorage(testStorageConfig{path: t.TempDir()})
+	t.Cleanup(func() {
+		filesystem.InitStorage(testStorageConfig{path: root})
+	})
+	return NewService(2)
+}
+
+// savePNG сохраняет в хранилище изображение заданного размера
+func savePNG(t *testing.T, width, height int, alpha uint8) *filesystem.FileData {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 100, A: alpha})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	fileData, err := filesystem.SaveFileWithOriginalName(buf.Bytes(), "image.png")
+	require.NoError(t, err)
+	return fileData
+}
+
+// TestLoad проверяет размеры миниатюр, формат и отсутствие увеличения маленьких изображений
+func TestLoad(t *testing.T) {
+	service := setupThumbnailTest(t)
+
+	photo := savePNG(t, 1000, 500, 255)
+	data, err := service.Load(photo.Hash, Medium)
+	require.NoError(t, err)
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, "jpeg", format, "непрозрачные миниатюры сохраняются в JPEG")
+	assert.Equal(t, 256, config.Width)
+	assert.Equal(t, 128, config.Height)
+	for _, size := range Sizes {
+		assert.FileExists(t, filesystem.GetThumbnailPath(photo.Hash, int(size)), "все размеры строятся за одно декодирование")
+	}
+
+	icon := savePNG(t, 64, 32, 128)
+	data, err = service.Load(icon.Hash, Large)
+	require.NoError(t, err)
+	config, format, err = image.DecodeConfig(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, "png", format, "прозрачность сохраняется")
+	assert.Equal(t, 64, config.Width)
+
+	text, err := filesystem.SaveFileWithOriginalName([]byte("не изображение"), "note.txt")
+	require.NoError(t, err)
+	_, err = service.Load(text.Hash, Small)
+	assert.ErrorIs(t, err, ErrNotImage)
+}
+
+// TestConcurrentLoadAndInvalidate проверяет объединение одновременных запросов и удаление миниатюр вместе с файлом
+func TestConcurrentLoadAndInvalidate(t *testing.T) {
+	service := setupThumbnailTest(t)
+	photo := savePNG(t, 300, 600, 255)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(size Size) {
+			defer wg.Done()
+			data, err := service.Load(photo.Hash, size)
+			assert.NoError(t, err)
+			assert.NotEmpty(t, data)
+		}(Sizes[i%len(Sizes)])
+	}
+	wg.Wait()
+
+	service.Invalidate(photo.Hash)
+	assert.NoFileExists(t, filesystem.GetThumbnailPath(photo.Hash, int(Small)))
+
+	_, err := service.Load(photo.Hash, Small)
+	require.NoError(t, err)
+	require.NoError(t, filesystem.DeleteFile(photo.Hash))
+	for _, size := range Sizes {
+		assert.NoFileExists(t, filesystem.GetThumbnailPath(photo.Hash, int(size)), "миниатюры удаляются вместе с файлом")
+	}
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"projectT/internal/services/favorites"
+	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/queries"
+)
+
+// trashPurgeInterval как часто фоновая очистка проверяет просроченные элементы корзины
+const trashPurgeInterval = 6 * time.Hour
+
+// trashPurgeMu не даёт очистке корзины идти во время переключения библиотеки
+var trashPurgeMu sync.Mutex
+
+// SuspendTrashPurge ждёт окончания текущей очистки просроченных элементов корзины и не даёт
+// начать новую до вызова resume. Нужна при замене базы, например при переключении библиотеки.
+func SuspendTrashPurge() (resume func()) {
+	trashPurgeMu.Lock()
+	return trashPurgeMu.Unlock
+}
+
+// TrashService предоставляет сервис корзины: удаление с возможностью восстановления
+type TrashService struct{}
+
+// NewTrashService создает новый экземпляр сервиса корзины
+func NewTrashService() *TrashService {
+	return &TrashService{}
+}
+
+// MoveToTrash перемещает элемент (папку - со всем содержимым) в корзину
+func (ts *TrashService) MoveToTrash(itemID int) (*models.TrashEntry, error) {
+	entry, err := queries.MoveItemToTrash(itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Удалённая папка пропадает из избранного на боковой панели
+	favorites.GetEventManager().Notify("trash_changed")
+	return entry, nil
+}
+
+// GetTrashEntries возвращает содержимое корзины
+func (ts *TrashService) GetTrashEntries() ([]*models.TrashEntry, error) {
+	return queries.GetTrashEntries()
+}
+
+// Restore восстанавливает элемент из корзины вместе с вложенными элементами, тегами и файлами
+func (ts *TrashService) Restore(trashID int) (*models.Item, error) {
+	item, err := queries.RestoreFromTrash(trashID)
+	if err != nil {
+		return nil, err
+	}
+
+	favorites.GetEventManager().Notify("trash_changed")
+	return item, nil
+}
+
+// Purge окончательно удаляет запись корзины. Файлы элементов остаются на диске,
+// пока их не соберёт FileGCService: блоб может использоваться другим элементом.
+func (ts *TrashService) Purge(trashID int) error {
+	return queries.PurgeTrashEntry(trashID)
+}
+
+// EmptyTrash окончательно удаляет всё содержимое корзины
+func (ts *TrashService) EmptyTrash() error {
+	entries, err := queries.GetTrashEntries()
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := ts.Purge(entry.ID); err != nil {
+			return fmt.Errorf("ошибка очистки корзины: %w", err)
+		}
+	}
+	return nil
+}
+
+// PurgeExpired окончательно удаляет элементы, пролежавшие в корзине дольше retentionDays.
+// При retentionDays <= 0 корзина не очищается автоматически. Возвращает число удалённых записей.
+func (ts *TrashService) PurgeExpired(retentionDays int) (int, error) {
+	if retentionDays <= 0 {
+		return 0, nil
+	}
+	trashPurgeMu.Lock()
+	defer trashPurgeMu.Unlock()
+
+	cutoff := time.Now().AddDate(0, 0, -retentionDays)
+	ids, err := queries.GetExpiredTrashEntryIDs(cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, id := range ids {
+		if err := ts.Purge(id); err != nil {
+			return purged, fmt.Errorf("ошибка очистки корзины: %w", err)
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// StartAutoPurge очищает просроченные элементы корзины сразу и затем периодически в фоне
+func (ts *TrashService) StartAutoPurge(retentionDays int) {
+	if retentionDays <= 0 {
+		return
+	}
+
+	purge := func() {
+		purged, err := ts.PurgeExpired(retentionDays)
+		if err != nil {
+			log.Printf("Ошибка автоочистки корзины: %v", err)
+			return
+		}
+		if purged > 0 {
+			log.Printf("Из корзины окончательно удалено записей: %d", purged)
+		}
+	}
+
+	go func() {
+		purge()
+		ticker := time.NewTicker(trashPurgeInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			purge()
+		}
+	}()
+}
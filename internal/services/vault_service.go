@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"projectT/internal/storage/database/queries"
+	"projectT/internal/storage/filesystem"
+	"projectT/internal/storage/vault"
+)
+
+// VaultReport результат включения или выключения шифрования хранилища
+type VaultReport struct {
+	Files    int           // Сколько файлов зашифровано или расшифровано
+	Columns  int           // Сколько значений колонок зашифровано или расшифровано
+	Skipped  []string      // Файлы, которые не удалось обработать (повреждены)
+	Duration time.Duration // Длительность
+}
+
+// String возвращает краткое описание результата для журнала и интерфейса
+func (r *VaultReport) String() string {
+	s := fmt.Sprintf("файлов: %d, значений в базе: %d", r.Files, r.Columns)
+	if len(r.Skipped) > 0 {
+		s += fmt.Sprintf(", пропущено повреждённых файлов: %d", len(r.Skipped))
+	}
+	return s
+}
+
+// VaultService включает и выключает шифрование хранилища.
+// Перевод библиотеки в другой режим идемпотентен: каждый файл и каждое значение помечены своим форматом,
+// поэтому прерванное включение или выключение можно просто запустить повторно.
+type VaultService struct{}
+
+// NewVaultService создает новый экземпляр сервиса шифрования хранилища
+func NewVaultService() *VaultService {
+	return &VaultService{}
+}
+
+// Enabled сообщает, включено ли шифрование
+func (s *VaultService) Enabled() bool {
+	return filesystem.VaultEnabled()
+}
+
+// Unlocked сообщает, разблокировано ли зашифрованное хранилище
+func (s *VaultService) Unlocked() bool {
+	return vault.Unlocked()
+}
+
+// Unlock разблокирует хранилище паролем и удаляет расшифрованные копии файлов,
+// оставшиеся от аварийно завершившихся запусков
+func (s *VaultService) Unlock(password string) error {
+	if err := vault.Unlock(filesystem.GetStorageRoot(), password); err != nil {
+		return err
+	}
+	if err := filesystem.RemoveStalePlainCaches(); err != nil {
+		log.Printf("Не удалось удалить временные расшифрованные файлы прошлых запусков: %v", err)
+	}
+	return nil
+}
+
+// ChangePassword меняет пароль хранилища
+func (s *VaultService) ChangePassword(oldPassword, newPassword string) error {
+	if err := vault.ChangePassword(filesystem.GetStorageRoot(), oldPassword, newPassword); err != nil {
+		return err
+	}
+	return vault.Unlock(filesystem.GetStorageRoot(), newPassword)
+}
+
+// Enable включает шифрование: создаёт мастер-ключ и шифрует существующие файлы и колонки.
+// Если шифрование уже включено (например, прошлый запуск прервался), пароль проверяется и
+// шифруется то, что осталось открытым. Повреждённые файлы пропускаются - их найдёт проверка целостности.
+func (s *VaultService) Enable(ctx context.Context, password string) (*VaultReport, error) {
+	fileGCMu.Lock()
+	defer fileGCMu.Unlock()
+
+	started := time.Now()
+	root := filesystem.GetStorageRoot()
+	if vault.Enabled(root) {
+		if err := vault.Unlock(root, password); err != nil {
+			return nil, err
+		}
+	} else if err := vault.Setup(root, password); err != nil {
+		return nil, err
+	}
+
+	report := &VaultReport{}
+	if err := s.convertFiles(ctx, filesystem.SealBlob, report); err != nil {
+		return nil, err
+	}
+	// Миниатюры построены по открытым файлам; новые будут построены уже зашифрованными
+	if err := os.RemoveAll(filesystem.GetThumbnailsDir()); err != nil {
+		return nil, fmt.Errorf("ошибка удаления миниатюр: %w", err)
+	}
+
+	columns, err := queries.RecodeSensitiveColumns(ctx, vault.EncryptField)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка шифрования базы данных: %w", err)
+	}
+	// Поиск по тексту копий страниц хранил его открытым
+	if err := queries.ClearPageTexts(ctx); err != nil {
+		return nil, err
+	}
+	report.Columns = columns
+	report.Duration = time.Since(started)
+	return report, nil
+}
+
+// Disable выключает шифрование: расшифровывает файлы и колонки и удаляет мастер-ключ.
+// Если хоть один файл расшифровать не удалось, ключ остаётся, чтобы данные не были потеряны.
+func (s *VaultService) Disable(ctx context.Context, password string) (*VaultReport, error) {
+	fileGCMu.Lock()
+	defer fileGCMu.Unlock()
+
+	started := time.Now()
+	root := filesystem.GetStorageRoot()
+	if err := vault.Unlock(root, password); err != nil {
+		return nil, err
+	}
+
+	report := &VaultReport{}
+	if err := s.convertFiles(ctx, filesystem.UnsealBlob, report); err != nil {
+		return nil, err
+	}
+	if len(report.Skipped) > 0 {
+		return report, fmt.Errorf("не удалось расшифровать файлов: %d, шифрование не выключено", len(report.Skipped))
+	}
+
+	columns, err := queries.RecodeSensitiveColumns(ctx, vault.OpenField)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка расшифровки базы данных: %w", err)
+	}
+	report.Columns = columns
+
+	if err := vault.Remove(root); err != nil {
+		return nil, err
+	}
+	if err := filesystem.ClearPlainCache(); err != nil {
+		return nil, fmt.Errorf("ошибка удаления временных файлов: %w", err)
+	}
+	if err := os.RemoveAll(filesystem.GetThumbnailsDir()); err != nil {
+		return nil, fmt.Errorf("ошибка удаления миниатюр: %w", err)
+	}
+	report.Duration = time.Since(started)
+	return report, nil
+}
+
+// convertFiles применяет convert ко всем файлам хранилища
+func (s *VaultService) convertFiles(ctx context.Context, convert func(filesystem.BlobInfo) (bool, error), report *VaultReport) error {
+	blobs, err := filesystem.ListBlobs()
+	if err != nil {
+		return err
+	}
+
+	for _, blob := range blobs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		changed, err := convert(blob)
+		switch {
+		case err == nil:
+			if changed {
+				report.Files++
+			}
+		case os.IsNotExist(err):
+			// Файл удалён, пока шли по списку
+		case errors.Is(err, filesystem.ErrBlobCorrupted):
+			report.Skipped = append(report.Skipped, blob.Path)
+		default:
+			return err
+		}
+	}
+	return nil
+}
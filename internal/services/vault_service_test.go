@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/queries"
+	"projectT/internal/storage/filesystem"
+	"projectT/internal/storage/vault"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestVaultEnableDisable проверяет шифрование существующей библиотеки, прозрачное чтение и обратную расшифровку
+func TestVaultEnableDisable(t *testing.T) {
+	setupFileGCTest(t)
+	t.Cleanup(vault.Lock)
+	ctx := context.Background()
+
+	before, err := filesystem.SaveFileWithOriginalName([]byte("файл до шифрования"), "before.txt")
+	require.NoError(t, err)
+
+	_, err = database.GetDB().Exec(`INSERT INTO profiles (owner_type, peer_id, username, title, avatar_path) VALUES ('remote', 'QmFriend', 'friend', '', '')`)
+	require.NoError(t, err)
+	contact := &models.Contact{PeerID: "QmFriend", Notes: "личная заметка"}
+	require.NoError(t, queries.CreateContact(contact))
+	message := &models.ChatMessage{ContactID: contact.ID, FromPeerID: "QmFriend", Content: "секретное сообщение", ContentType: "text"}
+	require.NoError(t, queries.CreateChatMessage(message))
+
+	// Текст копии страницы в поиске хранится открытым
+	textHash := strings.Repeat("a", 64)
+	require.NoError(t, queries.NewFilesRepo(nil).SavePageText(textHash, "текст копии страницы"))
+	link := &models.Item{Type: models.ItemTypeElement, Title: "Ссылка",
+		ContentMeta: `[{"type":"link","content":"https://example.com","snapshot":{"text_hash":"` + textHash + `"}}]`}
+	require.NoError(t, queries.CreateItem(link))
+
+	service := NewVaultService()
+	report, err := service.Enable(ctx, "secret")
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Files)
+	assert.Equal(t, 2, report.Columns)
+	assert.True(t, service.Enabled())
+
+	// На диске и в базе - только шифротекст
+	raw, err := os.ReadFile(before.Path)
+	require.NoError(t, err)
+	assert.True(t, vault.IsSealed(raw))
+	var content string
+	require.NoError(t, database.GetDB().QueryRow(`SELECT content FROM chat_messages WHERE id = ?`, message.ID).Scan(&content))
+	assert.True(t, vault.IsEncryptedField(content))
+	var pageTexts int
+	require.NoError(t, database.GetDB().QueryRow(`SELECT COUNT(*) FROM page_texts`).Scan(&pageTexts))
+	assert.Zero(t, pageTexts)
+	if database.SearchIndexAvailable(database.GetDB()) {
+		var archive string
+		require.NoError(t, database.GetDB().QueryRow(`SELECT archive FROM items_fts WHERE rowid = ?`, link.ID).Scan(&archive))
+		assert.Empty(t, archive)
+	}
+
+	// Чтение прозрачно, новые файлы сразу шифруются
+	data, info, err := filesystem.ReadFileByHash(before.Hash)
+	require.NoError(t, err)
+	assert.Equal(t, "файл до шифрования", string(data))
+	assert.Equal(t, int64(len(data)), info.Size)
+	after, err := filesystem.SaveFileWithOriginalName([]byte("новый файл"), "after.txt")
+	require.NoError(t, err)
+	raw, err = os.ReadFile(after.Path)
+	require.NoError(t, err)
+	assert.True(t, vault.IsSealed(raw))
+	loaded, err := queries.GetChatMessage(message.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "секретное сообщение", loaded.Content)
+
+	// Заблокированное хранилище не отдаёт содержимое и не затирает заметки заглушкой
+	vault.Lock()
+	_, err = filesystem.ReadFile(before.Hash)
+	assert.ErrorIs(t, err, vault.ErrLocked)
+	locked, err := queries.GetContactByPeerID("QmFriend")
+	require.NoError(t, err)
+	assert.Equal(t, vault.LockedPlaceholder, locked.Notes)
+	require.NoError(t, queries.UpdateContact(locked))
+	_, err = service.Disable(ctx, "wrong")
+	assert.ErrorIs(t, err, vault.ErrWrongPassword)
+
+	report, err = service.Disable(ctx, "secret")
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Files)
+	assert.False(t, service.Enabled())
+
+	raw, err = os.ReadFile(before.Path)
+	require.NoError(t, err)
+	assert.Equal(t, "файл до шифрования", string(raw))
+	unlocked, err := queries.GetContactByPeerID("QmFriend")
+	require.NoError(t, err)
+	assert.Equal(t, "личная заметка", unlocked.Notes)
+}
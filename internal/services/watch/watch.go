@@ -0,0 +1,377 @@
+// Package watch автоматически импортирует файлы, появившиеся в отслеживаемых директориях,
+// например скриншоты и загрузки.
+//
+// Каждый новый файл становится элементом через ContentBlocksService, как при добавлении в окне
+// создания. Файл импортируется, только когда он перестал меняться в течение периода ожидания,
+// поэтому недописанные файлы не попадают в библиотеку. Файлы, содержимое которых уже есть
+// в хранилище, пропускаются. Вложенные директории не отслеживаются.
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"projectT/internal/services"
+	"projectT/internal/services/favorites"
+	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/queries"
+	"projectT/internal/storage/database/repository"
+	"projectT/internal/storage/filesystem"
+	"projectT/internal/storage/vault"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// partialPatterns имена незаконченных загрузок и временных файлов: браузеры и редакторы пишут в них,
+// а затем переименовывают в итоговое имя, которое и импортируется
+var partialPatterns = []string{".*", "~*", "*.part", "*.partial", "*.crdownload", "*.download", "*.tmp"}
+
+// errAlreadyStored содержимое файла уже есть в хранилище
+var errAlreadyStored = errors.New("файл уже есть в библиотеке")
+
+// importMu не даёт импортировать файлы во время замены базы и хранилища
+var importMu sync.Mutex
+
+// Suspend ждёт окончания текущего импорта и не даёт начать новый до вызова resume.
+// Нужна при замене хранилища, например при переключении библиотеки.
+func Suspend() (resume func()) {
+	importMu.Lock()
+	return importMu.Unlock
+}
+
+// Rule отслеживаемая директория и правила импорта её файлов
+type Rule struct {
+	Dir          string   // Отслеживаемая директория
+	Parent       string   // Папка библиотеки: названия через "/", недостающие создаются; пусто - корень
+	Tags         []string // Теги новых элементов
+	DeleteSource bool     // Удалять файл из директории после импорта
+	Ignore       []string // Шаблоны имён файлов (filepath.Match), которые не импортируются
+}
+
+// ignored сообщает, что файл с именем name не импортируется по правилу
+func (r *Rule) ignored(name string) bool {
+	for _, patterns := range [][]string{partialPatterns, r.Ignore} {
+		for _, pattern := range patterns {
+			if matched, _ := filepath.Match(pattern, name); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pendingFile файл, ожидающий окончания записи
+type pendingFile struct {
+	rule    *Rule
+	timer   *time.Timer
+	size    int64
+	modTime time.Time
+}
+
+// Watcher отслеживает директории и импортирует новые файлы
+type Watcher struct {
+	rules    []Rule
+	debounce time.Duration
+	content  *services.ContentBlocksService
+	items    repository.Items
+	files    repository.Files
+
+	mu      sync.Mutex
+	watcher *fsnotify.Watcher
+	pending map[string]*pendingFile
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// New создает наблюдатель за директориями rules. Файл импортируется, когда он не менялся в течение debounce.
+func New(rules []Rule, debounce time.Duration) *Watcher {
+	repos := repository.Default()
+	return &Watcher{
+		rules:    rules,
+		debounce: debounce,
+		content:  services.NewContentBlocksService(),
+		items:    repos.Items,
+		files:    repos.Files,
+		pending:  make(map[string]*pendingFile),
+	}
+}
+
+// Start начинает отслеживать директории и ставит в очередь файлы, появившиеся в них, пока приложение
+// не было запущено. Директории, которые не удалось открыть, пропускаются с предупреждением.
+func (w *Watcher) Start() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("ошибка запуска отслеживания директорий: %w", err)
+	}
+	w.watcher = watcher
+
+	for i := range w.rules {
+		rule := &w.rules[i]
+		if err := watcher.Add(rule.Dir); err != nil {
+			log.Printf("Предупреждение: директория %s не отслеживается: %v", rule.Dir, err)
+			continue
+		}
+		log.Printf("Отслеживается директория %s", rule.Dir)
+
+		entries, err := os.ReadDir(rule.Dir)
+		if err != nil {
+			log.Printf("Предупреждение: ошибка чтения директории %s: %v", rule.Dir, err)
+			continue
+		}
+		for _, entry := range entries {
+			if entry.Type().IsRegular() {
+				w.schedule(filepath.Join(rule.Dir, entry.Name()), rule)
+			}
+		}
+	}
+
+	w.wg.Add(1)
+	go w.run()
+	return nil
+}
+
+// Stop прекращает отслеживание и дожидается начатого импорта
+func (w *Watcher) Stop() {
+	w.mu.Lock()
+	if w.stopped {
+		w.mu.Unlock()
+		return
+	}
+	w.stopped = true
+	for path, file := range w.pending {
+		file.timer.Stop()
+		delete(w.pending, path)
+	}
+	w.mu.Unlock()
+
+	if w.watcher != nil {
+		w.watcher.Close()
+	}
+	w.wg.Wait()
+}
+
+// run обрабатывает события файловой системы
+func (w *Watcher) run() {
+	defer w.wg.Done()
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			rule := w.ruleFor(event.Name)
+			if rule == nil {
+				continue
+			}
+			switch {
+			case event.Has(fsnotify.Create), event.Has(fsnotify.Write):
+				w.schedule(event.Name, rule)
+			case event.Has(fsnotify.Remove), event.Has(fsnotify.Rename):
+				w.cancel(event.Name)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("Предупреждение: ошибка отслеживания директорий: %v", err)
+		}
+	}
+}
+
+// ruleFor возвращает правило директории, в которой лежит path
+func (w *Watcher) ruleFor(path string) *Rule {
+	dir := filepath.Clean(filepath.Dir(path))
+	for i := range w.rules {
+		if filepath.Clean(w.rules[i].Dir) == dir {
+			return &w.rules[i]
+		}
+	}
+	return nil
+}
+
+// schedule откладывает импорт файла до окончания записи: каждое изменение продлевает ожидание
+func (w *Watcher) schedule(path string, rule *Rule) {
+	if rule.ignored(filepath.Base(path)) {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopped {
+		return
+	}
+	if file, ok := w.pending[path]; ok {
+		file.size, file.modTime = info.Size(), info.ModTime()
+		file.timer.Reset(w.debounce)
+		return
+	}
+	file := &pendingFile{rule: rule, size: info.Size(), modTime: info.ModTime()}
+	file.timer = time.AfterFunc(w.debounce, func() { w.settle(path) })
+	w.pending[path] = file
+}
+
+// cancel отменяет ожидание удалённого или переименованного файла
+func (w *Watcher) cancel(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if file, ok := w.pending[path]; ok {
+		file.timer.Stop()
+		delete(w.pending, path)
+	}
+}
+
+// settle импортирует файл, если он не менялся с последнего события; иначе ждёт снова.
+// Размер сверяется заново, потому что не все системы сообщают о каждой записи.
+func (w *Watcher) settle(path string) {
+	w.mu.Lock()
+	file, ok := w.pending[path]
+	if !ok || w.stopped {
+		w.mu.Unlock()
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		delete(w.pending, path)
+		w.mu.Unlock()
+		return
+	}
+	// Пока зашифрованное хранилище заблокировано, файл сохранить нельзя: ждём разблокировки
+	locked := filesystem.VaultEnabled() && !vault.Unlocked()
+	if locked || info.Size() != file.size || !info.ModTime().Equal(file.modTime) {
+		file.size, file.modTime = info.Size(), info.ModTime()
+		file.timer.Reset(w.debounce)
+		w.mu.Unlock()
+		return
+	}
+	delete(w.pending, path)
+	w.wg.Add(1)
+	w.mu.Unlock()
+	defer w.wg.Done()
+
+	item, err := w.importFile(context.Background(), file.rule, path)
+	switch {
+	case errors.Is(err, errAlreadyStored):
+		log.Printf("Файл %s уже есть в библиотеке, пропущен", path)
+	case err != nil:
+		log.Printf("Ошибка импорта файла %s: %v", path, err)
+	default:
+		log.Printf("Файл %s импортирован как элемент %d", path, item.ID)
+		favorites.GetEventManager().Notify("items_changed")
+	}
+}
+
+// importFile создает элемент из файла по правилу rule. Файл, содержимое которого уже есть в хранилище,
+// не импортируется (errAlreadyStored), но при DeleteSource всё равно удаляется: его копия уже в библиотеке.
+func (w *Watcher) importFile(ctx context.Context, rule *Rule, path string) (*models.Item, error) {
+	importMu.Lock()
+	defer importMu.Unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("пустой файл")
+	}
+
+	exists, err := w.stored(filesystem.CalculateHash(data))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка проверки файла в библиотеке: %w", err)
+	}
+	if exists {
+		w.removeSource(rule, path)
+		return nil, errAlreadyStored
+	}
+
+	parentID, err := w.resolveParent(ctx, rule.Parent)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := w.content.CreateItem(ctx, services.ItemInput{
+		Title:    filepath.Base(path),
+		ParentID: parentID,
+		Files:    []string{path},
+		Tags:     strings.Join(rule.Tags, ","),
+	})
+	if item == nil {
+		return nil, err
+	}
+	if err != nil {
+		log.Printf("Предупреждение: элемент %d создан без тегов: %v", item.ID, err)
+	}
+	w.removeSource(rule, path)
+	return item, nil
+}
+
+// stored сообщает, хранится ли уже содержимое с хэшем hash: на блоб ссылается элемент,
+// вложение чата или ревизия
+func (w *Watcher) stored(hash string) (bool, error) {
+	record, err := w.files.GetFileRecord(hash)
+	if errors.Is(err, queries.ErrFileRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return record.RefCount > 0, nil
+}
+
+// removeSource удаляет импортированный файл, если этого требует правило
+func (w *Watcher) removeSource(rule *Rule, path string) {
+	if !rule.DeleteSource {
+		return
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.Printf("Предупреждение: ошибка удаления файла %s: %v", path, err)
+	}
+}
+
+// resolveParent возвращает ID папки библиотеки по пути из названий через "/", создавая недостающие папки.
+// Пустой путь - корень библиотеки (nil).
+func (w *Watcher) resolveParent(ctx context.Context, folderPath string) (*int, error) {
+	var parentID *int
+	for _, name := range strings.Split(folderPath, "/") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		parent := 0
+		if parentID != nil {
+			parent = *parentID
+		}
+		siblings, err := w.items.GetItemsByParent(parent)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка получения содержимого папки: %w", err)
+		}
+
+		var folder *models.Item
+		for _, sibling := range siblings {
+			if sibling.Type == models.ItemTypeFolder && sibling.Title == name {
+				folder = sibling
+				break
+			}
+		}
+		if folder == nil {
+			folder, err = w.content.CreateItem(ctx, services.ItemInput{Title: name, Type: models.ItemTypeFolder, ParentID: parentID})
+			if err != nil {
+				return nil, fmt.Errorf("ошибка создания папки %q: %w", name, err)
+			}
+		}
+		id := folder.ID
+		parentID = &id
+	}
+	return parentID, nil
+}
@@ -0,0 +1,134 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"projectT/internal/services"
+	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/queries"
+	"projectT/internal/storage/filesystem"
+	"projectT/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFile создает файл name с содержимым data в директории dir
+func writeFile(t *testing.T, dir, name, data string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+	return path
+}
+
+// TestRuleIgnored проверяет шаблоны пропускаемых файлов
+func TestRuleIgnored(t *testing.T) {
+	rule := &Rule{Ignore: []string{"*.log"}}
+
+	for _, name := range []string{".hidden", "~lock", "video.mp4.part", "file.crdownload", "debug.log"} {
+		assert.True(t, rule.ignored(name), name)
+	}
+	for _, name := range []string{"screenshot.png", "report.pdf", "log.txt"} {
+		assert.False(t, rule.ignored(name), name)
+	}
+}
+
+// TestImportFile проверяет создание элемента в папке по пути с тегами и пропуск уже сохранённого содержимого
+func TestImportFile(t *testing.T) {
+	testutil.SetupLibrary(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	w := New(nil, time.Millisecond)
+	rule := &Rule{Dir: dir, Parent: "Входящие/Скриншоты", Tags: []string{"скриншот", "авто"}, DeleteSource: true}
+
+	path := writeFile(t, dir, "shot.png", "изображение")
+	item, err := w.importFile(ctx, rule, path)
+	require.NoError(t, err)
+	assert.Equal(t, "shot.png", item.Title)
+	assert.Contains(t, item.ContentMeta, filesystem.CalculateHash([]byte("изображение")))
+	assert.NoFileExists(t, path)
+
+	// Папки созданы один раз и повторно используются
+	parentID, err := w.resolveParent(ctx, rule.Parent)
+	require.NoError(t, err)
+	require.NotNil(t, item.ParentID)
+	assert.Equal(t, *parentID, *item.ParentID)
+	roots, err := w.items.GetItemsByParent(0)
+	require.NoError(t, err)
+	var inbox int
+	for _, root := range roots {
+		if root.Type == models.ItemTypeFolder && root.Title == "Входящие" {
+			inbox++
+		}
+	}
+	assert.Equal(t, 1, inbox)
+
+	tags, err := services.NewTagsService().GetTagsForItem(ctx, item.ID)
+	require.NoError(t, err)
+	var names []string
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	assert.ElementsMatch(t, []string{"скриншот", "авто"}, names)
+
+	// Копия того же содержимого не импортируется, но удаляется из директории
+	duplicate := writeFile(t, dir, "copy.png", "изображение")
+	_, err = w.importFile(ctx, rule, duplicate)
+	assert.ErrorIs(t, err, errAlreadyStored)
+	assert.NoFileExists(t, duplicate)
+
+	// Содержимое, которое хранится только как вложение чата, тоже уже сохранено
+	contact := &models.Contact{PeerID: "peer-watch"}
+	require.NoError(t, queries.CreateContact(contact))
+	attachment := filesystem.CalculateHash([]byte("вложение"))
+	require.NoError(t, queries.CreateChatMessage(&models.ChatMessage{
+		ContactID:   contact.ID,
+		FromPeerID:  "me",
+		Content:     "photo.png",
+		ContentType: "image",
+		Metadata:    `{"file_hash":"` + attachment + `","file_name":"photo.png","size":16,"status":"sent"}`,
+	}))
+	_, err = w.importFile(ctx, rule, writeFile(t, dir, "photo.png", "вложение"))
+	assert.ErrorIs(t, err, errAlreadyStored)
+
+	// Без DeleteSource файл остаётся на месте
+	keep := &Rule{Dir: dir}
+	kept := writeFile(t, dir, "notes.txt", "заметки")
+	item, err = w.importFile(ctx, keep, kept)
+	require.NoError(t, err)
+	assert.Nil(t, item.ParentID)
+	assert.FileExists(t, kept)
+}
+
+// TestWatcherImportsNewFiles проверяет импорт файлов, лежавших в директории до запуска и появившихся после
+func TestWatcherImportsNewFiles(t *testing.T) {
+	testutil.SetupLibrary(t)
+	dir := t.TempDir()
+	writeFile(t, dir, "existing.txt", "старый файл")
+
+	w := New([]Rule{{Dir: dir, DeleteSource: true}}, 50*time.Millisecond)
+	require.NoError(t, w.Start())
+	defer w.Stop()
+
+	writeFile(t, dir, "new.txt", "новый файл")
+	writeFile(t, dir, "download.crdownload", "недокачанный файл")
+
+	titles := func() []string {
+		items, err := w.items.GetItemsByParent(0)
+		require.NoError(t, err)
+		var result []string
+		for _, item := range items {
+			result = append(result, item.Title)
+		}
+		return result
+	}
+	require.Eventually(t, func() bool { return len(titles()) == 2 }, 5*time.Second, 20*time.Millisecond)
+	assert.ElementsMatch(t, []string{"existing.txt", "new.txt"}, titles())
+	assert.FileExists(t, filepath.Join(dir, "download.crdownload"))
+}
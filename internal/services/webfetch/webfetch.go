@@ -0,0 +1,82 @@
+// Package webfetch загружает веб-страницы и их ресурсы для превью ссылок и копий страниц:
+// HTTP-клиент с ограничением перенаправлений, GET-запросы с проверкой ответа и чтение с ограничением размера
+package webfetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// maxRedirects сколько перенаправлений допускается для одного запроса
+const maxRedirects = 5
+
+// ErrTooLarge ответ больше допустимого размера
+var ErrTooLarge = errors.New("ответ слишком большой")
+
+// Client загружает адреса http и https от имени userAgent
+type Client struct {
+	client    *http.Client
+	userAgent string
+}
+
+// NewClient создает клиента, у которого каждый запрос ограничен timeout, а перенаправления
+// допускаются не больше maxRedirects раз и только на адреса http и https
+func NewClient(userAgent string, timeout time.Duration) *Client {
+	return &Client{
+		client: &http.Client{
+			Timeout: timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= maxRedirects {
+					return fmt.Errorf("больше %d перенаправлений", maxRedirects)
+				}
+				if !IsHTTP(req.URL) {
+					return fmt.Errorf("перенаправление на неподдерживаемый адрес %s", req.URL)
+				}
+				return nil
+			},
+		},
+		userAgent: userAgent,
+	}
+}
+
+// Get выполняет GET-запрос и проверяет код ответа; тело ответа закрывает вызывающий
+func (c *Client) Get(ctx context.Context, rawURL, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Accept", accept)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки %s: %w", rawURL, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("ошибка загрузки %s: %s", rawURL, resp.Status)
+	}
+	return resp, nil
+}
+
+// ReadLimited читает не больше limit байт; больший ответ считается ошибкой ErrTooLarge
+func ReadLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
+
+// IsHTTP сообщает, что адрес можно загрузить
+func IsHTTP(u *url.URL) bool {
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,73 @@
+package webfetch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClientGet проверяет заголовки запроса, код ответа и ограничение перенаправлений
+func TestClientGet(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("User-Agent") + "|" + r.Header.Get("Accept")))
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	mux.HandleFunc("/file", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "file:///etc/passwd", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := NewClient("test-agent", time.Second)
+	ctx := context.Background()
+
+	resp, err := client.Get(ctx, server.URL+"/page", "text/html")
+	require.NoError(t, err)
+	body, err := ReadLimited(resp.Body, 100)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "test-agent|text/html", string(body))
+
+	for _, path := range []string{"/missing", "/loop", "/file"} {
+		_, err = client.Get(ctx, server.URL+path, "*/*")
+		assert.Error(t, err, path)
+	}
+}
+
+// TestReadLimited проверяет чтение ответа не больше заданного размера
+func TestReadLimited(t *testing.T) {
+	data, err := ReadLimited(strings.NewReader("12345"), 5)
+	require.NoError(t, err)
+	assert.Equal(t, "12345", string(data))
+
+	_, err = ReadLimited(strings.NewReader("123456"), 5)
+	assert.ErrorIs(t, err, ErrTooLarge)
+}
+
+// TestIsHTTP проверяет, какие адреса можно загрузить
+func TestIsHTTP(t *testing.T) {
+	for rawURL, want := range map[string]bool{
+		"https://example.com/a": true,
+		"http://example.com":    true,
+		"file:///etc/passwd":    false,
+		"javascript:alert(1)":   false,
+		"/relative":             false,
+	} {
+		u, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		assert.Equal(t, want, IsHTTP(u), rawURL)
+	}
+}
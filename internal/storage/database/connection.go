@@ -2,14 +2,33 @@ package database
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"sync/atomic"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
-var DB *sql.DB
+// current текущая база данных. Библиотеку можно переключить, пока другие горутины
+// выполняют запросы, поэтому база читается и меняется только через GetDB и SetDB.
+var current atomic.Pointer[sql.DB]
+
+// closeGrace сколько прежняя база остаётся открытой после переключения: запросы, которые
+// успели получить её через GetDB, завершаются на ней, а не с ошибкой "database is closed"
+var closeGrace = 5 * time.Second
+
+// GetDB возвращает текущую базу данных
+func GetDB() *sql.DB {
+	return current.Load()
+}
+
+// SetDB делает db текущей базой и возвращает прежнюю. Прежняя база не закрывается.
+func SetDB(db *sql.DB) *sql.DB {
+	return current.Swap(db)
+}
 
 // DatabaseConfig конфигурация базы данных
 type DatabaseConfig interface {
@@ -35,6 +54,46 @@ func (defaultDBConfig) GetMaxIdleConns() int { return 1 }
 
 // InitDBWithConfig инициализирует подключение к базе данных SQLite с заданной конфигурацией
 func InitDBWithConfig(cfg DatabaseConfig) {
+	db, err := openWithConfig(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	SetDB(db)
+
+	// Подключение к SQLite успешно установлено
+}
+
+// Switch открывает базу данных cfg, применяет миграции и делает её текущей.
+// При ошибке текущая база не меняется. Прежняя база закрывается в фоне, когда
+// на ней не останется выполняющихся запросов (см. closeWhenIdle).
+func Switch(cfg DatabaseConfig) error {
+	db, err := openWithConfig(cfg)
+	if err != nil {
+		return err
+	}
+	if err := Migrate(db); err != nil {
+		db.Close()
+		return fmt.Errorf("ошибка миграции базы данных: %w", err)
+	}
+
+	if previous := SetDB(db); previous != nil {
+		go closeWhenIdle(previous)
+	}
+	return nil
+}
+
+// closeWhenIdle закрывает прежнюю базу db через closeGrace после переключения,
+// дождавшись завершения запросов, которые ещё выполняются на ней
+func closeWhenIdle(db *sql.DB) {
+	time.Sleep(closeGrace)
+	for db.Stats().InUse > 0 {
+		time.Sleep(closeGrace / 10)
+	}
+	db.Close()
+}
+
+// openWithConfig открывает подключение к базе данных с заданной конфигурацией
+func openWithConfig(cfg DatabaseConfig) (*sql.DB, error) {
 	dbPath := cfg.GetPath()
 	busyTimeout := cfg.GetBusyTimeout()
 	maxOpenConns := cfg.GetMaxOpenConns()
@@ -43,25 +102,24 @@ func InitDBWithConfig(cfg DatabaseConfig) {
 	// Создаем директорию для базы данных, если она не существует
 	dbDir := filepath.Dir(dbPath)
 	if err := os.MkdirAll(dbDir, 0755); err != nil {
-		log.Fatal("Ошибка при создании директории для базы данных:", err)
+		return nil, fmt.Errorf("ошибка при создании директории для базы данных: %w", err)
 	}
 
-	var err error
-	DB, err = sql.Open("sqlite3", dbPath+"?cache=shared&_busy_timeout="+itoa(busyTimeout))
+	db, err := sql.Open("sqlite3", dbPath+"?cache=shared&_busy_timeout="+itoa(busyTimeout))
 	if err != nil {
-		log.Fatal("Ошибка при открытии базы данных:", err)
+		return nil, fmt.Errorf("ошибка при открытии базы данных: %w", err)
 	}
 
 	// Устанавливаем параметры соединения для лучшей обработки конкурентного доступа
-	DB.SetMaxOpenConns(maxOpenConns)
-	DB.SetMaxIdleConns(maxIdleConns)
+	db.SetMaxOpenConns(maxOpenConns)
+	db.SetMaxIdleConns(maxIdleConns)
 
 	// Проверяем подключение
-	if err = DB.Ping(); err != nil {
-		log.Fatal("Ошибка подключения к базе данных:", err)
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("ошибка подключения к базе данных: %w", err)
 	}
-
-	// Подключение к SQLite успешно установлено
+	return db, nil
 }
 
 // itoa конвертирует int в string
@@ -103,7 +161,7 @@ func Open(dbPath string) (*sql.DB, error) {
 
 // CloseDB закрывает соединение с базой данных
 func CloseDB() {
-	if DB != nil {
-		DB.Close()
+	if db := GetDB(); db != nil {
+		db.Close()
 	}
 }
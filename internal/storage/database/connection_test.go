@@ -0,0 +1,65 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDBConfig конфигурация базы по пути
+type testDBConfig string
+
+func (c testDBConfig) GetPath() string    { return string(c) }
+func (testDBConfig) GetBusyTimeout() int  { return 1000 }
+func (testDBConfig) GetMaxOpenConns() int { return 1 }
+func (testDBConfig) GetMaxIdleConns() int { return 1 }
+
+// TestSwitch проверяет, что Switch открывает новую базу со схемой, а при ошибке оставляет текущую
+func TestSwitch(t *testing.T) {
+	original := GetDB()
+	defer SetDB(original)
+	grace := closeGrace
+	closeGrace = 50 * time.Millisecond
+	defer func() { closeGrace = grace }()
+
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first", "projectT.db")
+	require.NoError(t, Switch(testDBConfig(first)))
+	firstDB := GetDB()
+	t.Cleanup(func() { firstDB.Close() })
+	_, err := GetDB().Exec(`INSERT INTO tags (name) VALUES ('первая')`)
+	require.NoError(t, err)
+
+	// Путь, где вместо директории лежит файл, не открывается
+	blocker := filepath.Join(dir, "blocker")
+	require.NoError(t, os.WriteFile(blocker, nil, 0644))
+	assert.Error(t, Switch(testDBConfig(filepath.Join(blocker, "projectT.db"))))
+	assert.Same(t, firstDB, GetDB())
+
+	second := filepath.Join(dir, "second", "projectT.db")
+	require.NoError(t, Switch(testDBConfig(second)))
+	secondDB := GetDB()
+	t.Cleanup(func() { secondDB.Close() })
+	version, err := SchemaVersion(GetDB())
+	require.NoError(t, err)
+	assert.Equal(t, LatestSchemaVersion(), version)
+
+	var count int
+	require.NoError(t, GetDB().QueryRow(`SELECT COUNT(*) FROM tags`).Scan(&count))
+	assert.Equal(t, 0, count)
+
+	// Прежняя база закрывается не сразу, а когда на ней не останется запросов
+	rows, err := firstDB.Query(`SELECT name FROM tags`)
+	require.NoError(t, err)
+	time.Sleep(2 * closeGrace)
+	var name string
+	require.True(t, rows.Next(), "запрос на прежней базе завершается")
+	require.NoError(t, rows.Scan(&name))
+	assert.Equal(t, "первая", name)
+	rows.Close()
+	assert.Eventually(t, func() bool { return firstDB.Ping() != nil }, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,210 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ErrDatabaseTooNew возвращается, если база данных создана более новой версией приложения
+var ErrDatabaseTooNew = errors.New("база данных создана более новой версией приложения")
+
+// migration одна версионированная миграция схемы.
+// up выполняется в транзакции и должна быть идемпотентной по отношению
+// к базам, созданным до появления schema_migrations.
+type migration struct {
+	version int
+	name    string
+	up      func(tx *sql.Tx) error
+}
+
+// LatestSchemaVersion возвращает версию схемы, которую ожидает приложение
+func LatestSchemaVersion() int {
+	latest := 0
+	for _, m := range migrations {
+		if m.version > latest {
+			latest = m.version
+		}
+	}
+	return latest
+}
+
+// SchemaVersion возвращает версию схемы базы данных (0, если миграции не применялись)
+func SchemaVersion(db *sql.DB) (int, error) {
+	var exists int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`).Scan(&exists)
+	if err != nil {
+		return 0, err
+	}
+	if exists == 0 {
+		return 0, nil
+	}
+
+	var version int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// Migrate применяет к базе данных недостающие миграции и проверяет полнотекстовый индекс.
+// Каждая миграция выполняется в отдельной транзакции и записывается в schema_migrations.
+// База более новой версии не изменяется (ErrDatabaseTooNew), а перед обновлением
+// существующей файловой базы создаётся её резервная копия.
+func Migrate(db *sql.DB) error {
+	if err := applyPendingMigrations(db); err != nil {
+		return err
+	}
+
+	return ensureSearchIndex(db)
+}
+
+// applyPendingMigrations применяет миграции новее текущей версии схемы
+func applyPendingMigrations(db *sql.DB) error {
+	current, err := SchemaVersion(db)
+	if err != nil {
+		return fmt.Errorf("ошибка получения версии схемы: %w", err)
+	}
+
+	latest := LatestSchemaVersion()
+	if current > latest {
+		return fmt.Errorf("%w: версия схемы %d, поддерживается до %d", ErrDatabaseTooNew, current, latest)
+	}
+
+	var pending []migration
+	for _, m := range migrations {
+		if m.version > current {
+			pending = append(pending, m)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	hasData, err := hasUserTables(db)
+	if err != nil {
+		return fmt.Errorf("ошибка проверки базы данных: %w", err)
+	}
+	if hasData {
+		backupPath, err := backupDatabase(db, current)
+		if err != nil {
+			return fmt.Errorf("ошибка резервного копирования перед миграцией: %w", err)
+		}
+		if backupPath != "" {
+			log.Printf("Резервная копия базы данных перед миграцией: %s", backupPath)
+		}
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			name       TEXT NOT NULL,
+			applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("ошибка создания таблицы schema_migrations: %w", err)
+	}
+
+	for _, m := range pending {
+		if err := applyMigration(db, m); err != nil {
+			return fmt.Errorf("ошибка миграции %d (%s): %w", m.version, m.name, err)
+		}
+		log.Printf("Применена миграция %d: %s", m.version, m.name)
+	}
+
+	return nil
+}
+
+// applyMigration выполняет миграцию и записывает её версию в одной транзакции
+func applyMigration(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback() // Игнорируем ошибку отката, т.к. коммит уже мог состояться
+	}()
+
+	if err := m.up(tx); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, m.version, m.name); err != nil {
+		return fmt.Errorf("ошибка записи версии схемы: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка коммита транзакции: %w", err)
+	}
+
+	return nil
+}
+
+// hasUserTables проверяет, есть ли в базе таблицы приложения (пустую базу копировать незачем)
+func hasUserTables(db *sql.DB) (bool, error) {
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name != 'schema_migrations'
+	`).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// backupDatabase сохраняет копию файла базы рядом с ним через VACUUM INTO.
+// Для базы в памяти копия не создаётся и возвращается пустой путь.
+func backupDatabase(db *sql.DB, version int) (string, error) {
+	var path string
+	if err := db.QueryRow(`SELECT file FROM pragma_database_list WHERE name = 'main'`).Scan(&path); err != nil {
+		return "", err
+	}
+	if path == "" {
+		return "", nil
+	}
+
+	backupPath := fmt.Sprintf("%s.v%d-%s.bak", path, version, time.Now().Format("20060102-150405"))
+	if _, err := db.Exec(`VACUUM INTO ?`, backupPath); err != nil {
+		return "", err
+	}
+
+	return backupPath, nil
+}
+
+// columnExists проверяет наличие колонки в таблице
+func columnExists(tx *sql.Tx, table, column string) (bool, error) {
+	var count int
+	err := tx.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&count)
+	return count > 0, err
+}
+
+// addColumnIfMissing добавляет колонку, если её ещё нет (базы до schema_migrations
+// могли получить её ad-hoc ALTER TABLE)
+func addColumnIfMissing(tx *sql.Tx, table, column, definition string) error {
+	exists, err := columnExists(tx, table, column)
+	if err != nil {
+		return fmt.Errorf("ошибка проверки колонки %s.%s: %w", table, column, err)
+	}
+	if exists {
+		return nil
+	}
+
+	if _, err := tx.Exec(`ALTER TABLE ` + table + ` ADD COLUMN ` + column + ` ` + definition); err != nil {
+		return fmt.Errorf("ошибка добавления колонки %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+// execAll выполняет SQL-выражения по порядку
+func execAll(tx *sql.Tx, statements ...string) error {
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openTestDB открывает файловую БД во временной директории
+func openTestDB(t *testing.T) (*sql.DB, string) {
+	path := filepath.Join(t.TempDir(), "projectT.db")
+	db, err := Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db, path
+}
+
+// TestMigrateFreshDatabase проверяет создание схемы с нуля без резервной копии
+func TestMigrateFreshDatabase(t *testing.T) {
+	db, path := openTestDB(t)
+
+	require.NoError(t, Migrate(db))
+
+	version, err := SchemaVersion(db)
+	require.NoError(t, err)
+	assert.Equal(t, LatestSchemaVersion(), version)
+
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&count))
+	assert.Equal(t, len(migrations), count)
+
+	backups, err := filepath.Glob(path + ".v*.bak")
+	require.NoError(t, err)
+	assert.Empty(t, backups, "для новой БД резервная копия не нужна")
+}
+
+// TestMigrateIdempotent проверяет, что повторный запуск ничего не меняет
+func TestMigrateIdempotent(t *testing.T) {
+	db, path := openTestDB(t)
+
+	require.NoError(t, Migrate(db))
+	require.NoError(t, Migrate(db))
+
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&count))
+	assert.Equal(t, len(migrations), count)
+
+	backups, err := filepath.Glob(path + ".v*.bak")
+	require.NoError(t, err)
+	assert.Empty(t, backups, "без новых миграций резервная копия не создаётся")
+}
+
+// TestMigrateRefusesNewerDatabase проверяет отказ работать с БД более новой версии
+func TestMigrateRefusesNewerDatabase(t *testing.T) {
+	db, _ := openTestDB(t)
+
+	require.NoError(t, Migrate(db))
+	_, err := db.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, 'future')`, LatestSchemaVersion()+1)
+	require.NoError(t, err)
+
+	err = Migrate(db)
+	assert.True(t, errors.Is(err, ErrDatabaseTooNew), "ожидалась ErrDatabaseTooNew, получено: %v", err)
+}
+
+// TestMigrateLegacyDatabase проверяет обновление БД, созданной до schema_migrations:
+// ad-hoc колонки, перенос status в title и нормализацию contacts, а также резервную копию
+func TestMigrateLegacyDatabase(t *testing.T) {
+	db, path := openTestDB(t)
+
+	_, err := db.Exec(`
+		CREATE TABLE tags (id INTEGER PRIMARY KEY, name TEXT UNIQUE NOT NULL);
+		CREATE TABLE profiles (
+			id INTEGER PRIMARY KEY,
+			owner_type TEXT NOT NULL,
+			peer_id TEXT UNIQUE NOT NULL,
+			username TEXT NOT NULL,
+			status TEXT,
+			avatar_path TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE contacts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			peer_id TEXT UNIQUE NOT NULL,
+			username TEXT NOT NULL,
+			public_key TEXT,
+			status TEXT,
+			avatar_path TEXT,
+			multiaddr TEXT,
+			notes TEXT,
+			is_blocked BOOLEAN DEFAULT 0,
+			last_seen DATETIME,
+			added_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE favorites (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			entity_type TEXT NOT NULL CHECK (entity_type IN ('tag', 'folder')),
+			entity_id INTEGER NOT NULL
+		);
+		CREATE TABLE items (id INTEGER PRIMARY KEY, type TEXT NOT NULL, title TEXT, description TEXT, content_meta TEXT, parent_id INTEGER, created_at DATETIME, updated_at DATETIME);
+		CREATE TABLE item_files (
+			item_id INTEGER NOT NULL,
+			hash TEXT NOT NULL,
+			file_path TEXT NOT NULL,
+			size INTEGER,
+			mime_type TEXT,
+			is_remote BOOLEAN DEFAULT 0,
+			source_peer_id TEXT,
+			PRIMARY KEY (item_id, hash)
+		);
+		INSERT INTO items (id, type, title) VALUES (1, 'element', 'a'), (2, 'element', 'b');
+		INSERT INTO item_files (item_id, hash, file_path, size) VALUES (1, 'shared', 'p', 10), (2, 'shared', 'p', 10), (3, 'orphan', 'o', 5);
+		INSERT INTO tags (name) VALUES ('work');
+		INSERT INTO favorites (entity_type, entity_id) VALUES ('tag', 1);
+		INSERT INTO profiles (owner_type, peer_id, username, status) VALUES ('local', 'QmLocal', 'me', 'Привет');
+		INSERT INTO contacts (peer_id, username, status, notes) VALUES ('QmFriend', 'friend', 'Занят', 'заметка');
+	`)
+	require.NoError(t, err)
+
+	require.NoError(t, Migrate(db))
+
+	var color string
+	require.NoError(t, db.QueryRow(`SELECT color FROM tags WHERE name = 'work'`).Scan(&color))
+	assert.Equal(t, "#FFBB00", color)
+
+	var title string
+	require.NoError(t, db.QueryRow(`SELECT title FROM profiles WHERE peer_id = 'QmLocal'`).Scan(&title))
+	assert.Equal(t, "Привет", title)
+
+	var username string
+	require.NoError(t, db.QueryRow(`SELECT username, title FROM profiles WHERE peer_id = 'QmFriend'`).Scan(&username, &title))
+	assert.Equal(t, "friend", username)
+	assert.Equal(t, "Занят", title)
+
+	var notes string
+	require.NoError(t, db.QueryRow(`SELECT notes FROM contacts WHERE peer_id = 'QmFriend'`).Scan(&notes))
+	assert.Equal(t, "заметка", notes)
+
+	var favoriteType string
+	require.NoError(t, db.QueryRow(`SELECT entity_type FROM favorites WHERE entity_id = 1`).Scan(&favoriteType))
+	assert.Equal(t, "tag", favoriteType, "избранное должно пережить пересоздание таблицы")
+	_, err = db.Exec(`INSERT INTO favorites (entity_type, entity_id) VALUES ('saved_search', 1)`)
+	assert.NoError(t, err)
+
+	var refCount int
+	require.NoError(t, db.QueryRow(`SELECT ref_count FROM files WHERE hash = 'shared'`).Scan(&refCount))
+	assert.Equal(t, 2, refCount, "счётчик ссылок заполняется по существующим item_files")
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM item_files WHERE hash = 'orphan'`).Scan(&refCount))
+	assert.Zero(t, refCount, "файлы удалённых ранее элементов отвязываются")
+
+	var oldColumns int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('contacts') WHERE name IN ('username', 'status')`).Scan(&oldColumns))
+	assert.Zero(t, oldColumns, "старые колонки contacts должны быть удалены")
+
+	backups, err := filepath.Glob(path + ".v0-*.bak")
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+
+	backup, err := Open(backups[0])
+	require.NoError(t, err)
+	defer backup.Close()
+
+	version, err := SchemaVersion(backup)
+	require.NoError(t, err)
+	assert.Zero(t, version, "копия должна содержать БД до миграции")
+}
+
+// TestMigrateRollsBackFailedMigration проверяет, что неудачная миграция не применяется частично
+func TestMigrateRollsBackFailedMigration(t *testing.T) {
+	db, _ := openTestDB(t)
+	require.NoError(t, Migrate(db))
+
+	original := migrations
+	t.Cleanup(func() { migrations = original })
+
+	failing := migration{
+		version: LatestSchemaVersion() + 1,
+		name:    "failing",
+		up: func(tx *sql.Tx) error {
+			if _, err := tx.Exec(`CREATE TABLE half_applied (id INTEGER)`); err != nil {
+				return err
+			}
+			return errors.New("сбой миграции")
+		},
+	}
+	migrations = append(append([]migration{}, original...), failing)
+
+	require.Error(t, Migrate(db))
+
+	version, err := SchemaVersion(db)
+	require.NoError(t, err)
+	assert.Equal(t, failing.version-1, version)
+
+	var exists int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'half_applied'`).Scan(&exists))
+	assert.Zero(t, exists, "изменения неудачной миграции должны быть откатаны")
+}
@@ -1,16 +1,44 @@
 package database
 
 import (
+	"database/sql"
+	"fmt"
 	"log"
-	"strings"
 )
 
-// RunMigrations выполняет миграции базы данных
-// Порядок миграций важен! Сначала создаются основные таблицы, затем новые, затем перенос данных
+// migrations список миграций схемы в порядке применения.
+// Применённые миграции не меняются: изменения схемы добавляются новой миграцией в конец списка.
+var migrations = []migration{
+	{version: 1, name: "baseline", up: migrateBaseline},
+	{version: 2, name: "legacy_columns", up: migrateLegacyColumns},
+	{version: 3, name: "profiles_status_to_title", up: migrateProfilesStatusToTitle},
+	{version: 4, name: "normalize_contacts", up: migrateNormalizeContacts},
+	{version: 5, name: "remote_items_item_type", up: migrateRemoteItemsItemType},
+	{version: 6, name: "sharing_rules", up: migrateSharingRules},
+	{version: 7, name: "item_sync_state", up: migrateItemSyncState},
+	{version: 8, name: "saved_searches", up: migrateSavedSearches},
+	{version: 9, name: "trash", up: migrateTrash},
+	{version: 10, name: "file_refcount", up: migrateFileRefcount},
+	{version: 11, name: "item_revisions", up: migrateItemRevisions},
+	{version: 12, name: "item_list_indexes", up: migrateItemListIndexes},
+	{version: 13, name: "page_texts", up: migratePageTexts},
+}
+
+// RunMigrations приводит схему базы данных к текущей версии.
+// Завершает приложение, если миграция не удалась или база создана более новой версией.
 func RunMigrations() {
-	// 1. ТАБЛИЦА ЭЛЕМЕНТОВ (основная)
-	_, err := DB.Exec(`
-		CREATE TABLE IF NOT EXISTS items (
+	if err := Migrate(GetDB()); err != nil {
+		log.Fatal("Ошибка миграции базы данных: ", err)
+	}
+
+	seedBootstrapPeers()
+}
+
+// migrateBaseline создаёт исходную схему: элементы, теги, профили, контакты и чат
+func migrateBaseline(tx *sql.Tx) error {
+	return execAll(tx,
+		// 1. ТАБЛИЦА ЭЛЕМЕНТОВ (основная)
+		`CREATE TABLE IF NOT EXISTS items (
 			id          INTEGER PRIMARY KEY,
 			type        TEXT NOT NULL CHECK (type IN ('folder', 'element')),
 			title       TEXT,
@@ -20,130 +48,59 @@ func RunMigrations() {
 			created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY (parent_id) REFERENCES items (id) ON DELETE CASCADE
-		);
-	`)
-	if err != nil {
-		log.Fatal("Ошибка при создании таблицы items:", err)
-	}
+		)`,
 
-	// 2. ТАБЛИЦА ФАЙЛОВ (для дедупликации)
-	_, err = DB.Exec(`
-		CREATE TABLE IF NOT EXISTS files (
+		// 2. ТАБЛИЦА ФАЙЛОВ (для дедупликации)
+		`CREATE TABLE IF NOT EXISTS files (
 			id          INTEGER PRIMARY KEY,
 			hash        TEXT UNIQUE NOT NULL,
 			size        INTEGER NOT NULL,
 			mime_type   TEXT,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		);
-	`)
-	if err != nil {
-		log.Fatal("Ошибка при создании таблицы files:", err)
-	}
+		)`,
 
-	// 3. ТЕГИ
-	_, err = DB.Exec(`
-		CREATE TABLE IF NOT EXISTS tags (
+		// 3. ТЕГИ
+		`CREATE TABLE IF NOT EXISTS tags (
 			id          INTEGER PRIMARY KEY,
 			name        TEXT UNIQUE NOT NULL,
 			color       TEXT DEFAULT '#FFBB00',
 			description TEXT DEFAULT ''
-		);
-	`)
-	if err != nil {
-		log.Fatal("Ошибка при создании таблицы tags:", err)
-	}
+		)`,
 
-	// 4. СВЯЗЬ ЭЛЕМЕНТОВ С ТЕГАМИ
-	_, err = DB.Exec(`
-		CREATE TABLE IF NOT EXISTS item_tags (
+		// 4. СВЯЗЬ ЭЛЕМЕНТОВ С ТЕГАМИ
+		`CREATE TABLE IF NOT EXISTS item_tags (
 			item_id INTEGER,
 			tag_id  INTEGER,
 			PRIMARY KEY (item_id, tag_id),
 			FOREIGN KEY (item_id) REFERENCES items (id) ON DELETE CASCADE,
 			FOREIGN KEY (tag_id)  REFERENCES tags (id) ON DELETE CASCADE
-		);
-	`)
-	if err != nil {
-		log.Fatal("Ошибка при создании таблицы item_tags:", err)
-	}
+		)`,
 
-	// 5. ИЗБРАННОЕ
-	_, err = DB.Exec(`
-		CREATE TABLE IF NOT EXISTS favorites (
+		// 5. ИЗБРАННОЕ
+		`CREATE TABLE IF NOT EXISTS favorites (
 			id          INTEGER PRIMARY KEY AUTOINCREMENT,
 			entity_type TEXT NOT NULL CHECK (entity_type IN ('tag', 'folder')),
 			entity_id   INTEGER NOT NULL
-		);
-	`)
-	if err != nil {
-		log.Fatal("Ошибка при создании таблицы favorites:", err)
-	}
+		)`,
 
-	// 6. ЗАКРЕПЛЁННЫЕ ЭЛЕМЕНТЫ В ПРОФИЛЕ
-	_, err = DB.Exec(`
-		CREATE TABLE IF NOT EXISTS pinned_items (
+		// 6. ЗАКРЕПЛЁННЫЕ ЭЛЕМЕНТЫ В ПРОФИЛЕ
+		`CREATE TABLE IF NOT EXISTS pinned_items (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			item_id INTEGER NOT NULL,
 			order_num INTEGER DEFAULT 0,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY (item_id) REFERENCES items (id) ON DELETE CASCADE
-		);
-	`)
-	if err != nil {
-		log.Fatal("Ошибка при создании таблицы pinned_items:", err)
-	}
-
-	// ИНДЕКСЫ для производительности
-	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_items_parent ON items(parent_id);`)
-	if err != nil {
-		log.Fatal("Ошибка при создании индекса idx_items_parent:", err)
-	}
-
-	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_items_type ON items(type);`)
-	if err != nil {
-		log.Fatal("Ошибка при создании индекса idx_items_type:", err)
-	}
+		)`,
 
-	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_items_updated ON items(updated_at DESC);`)
-	if err != nil {
-		log.Fatal("Ошибка при создании индекса idx_items_updated:", err)
-	}
-
-	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_files_hash ON files(hash);`)
-	if err != nil {
-		log.Fatal("Ошибка при создании индекса idx_files_hash:", err)
-	}
+		// ИНДЕКСЫ для производительности
+		`CREATE INDEX IF NOT EXISTS idx_items_parent ON items(parent_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_items_type ON items(type)`,
+		`CREATE INDEX IF NOT EXISTS idx_items_updated ON items(updated_at DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_files_hash ON files(hash)`,
 
-	// Если таблица tags уже существует, добавляем поле color
-	_, err = DB.Exec(`ALTER TABLE tags ADD COLUMN color TEXT DEFAULT '#FFBB00'`)
-	// Игнорируем ошибку, если столбец уже существует
-	if err != nil {
-		// Проверяем, возможно столбец уже существует
-		if err.Error() != "duplicate column name: color" {
-			_ = err //nolint:staticcheck // Логируем ошибку, но не выводим в пользовательский интерфейс
-		}
-	}
-
-	// Добавляем поле description, если оно не существует
-	_, err = DB.Exec(`ALTER TABLE tags ADD COLUMN description TEXT DEFAULT ''`)
-	// Игнорируем ошибку, если столбец уже существует
-	if err != nil {
-		_ = err //nolint:staticcheck // Логируем ошибку, но не выводим в пользовательский интерфейс
-	}
-
-	// Создаём новые таблицы для профилей и элементов
-	createNewProfileTables()
-
-	seedBootstrapPeers()
-}
-
-// createNewProfileTables создаёт новые таблицы для профилей и элементов
-// Это новая схема с поддержкой множественных профилей (локальный + чужие)
-func createNewProfileTables() {
-	// 1. TABLE profiles - универсальная таблица для всех профилей
-	_, err := DB.Exec(`
-		CREATE TABLE IF NOT EXISTS profiles (
+		// 7. profiles - универсальная таблица для всех профилей (локальный + чужие)
+		`CREATE TABLE IF NOT EXISTS profiles (
 			id              INTEGER PRIMARY KEY,
 			owner_type      TEXT NOT NULL CHECK (owner_type IN ('local', 'remote')),
 			peer_id         TEXT UNIQUE NOT NULL,
@@ -156,40 +113,21 @@ func createNewProfileTables() {
 			cached_at       DATETIME,
 			created_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at      DATETIME DEFAULT CURRENT_TIMESTAMP
-		)
-	`)
-	if err != nil {
-		log.Printf("Ошибка при создании таблицы profiles: %v", err)
-	}
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_profiles_peer_id ON profiles(peer_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_profiles_owner_type ON profiles(owner_type)`,
 
-	// Индексы для profiles
-	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_profiles_peer_id ON profiles(peer_id)`)
-	if err != nil {
-		log.Printf("Ошибка при создании индекса idx_profiles_peer_id: %v", err)
-	}
-
-	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_profiles_owner_type ON profiles(owner_type)`)
-	if err != nil {
-		log.Printf("Ошибка при создании индекса idx_profiles_owner_type: %v", err)
-	}
-
-	// 2. TABLE profile_keys - криптографические ключи
-	_, err = DB.Exec(`
-		CREATE TABLE IF NOT EXISTS profile_keys (
+		// 8. profile_keys - криптографические ключи
+		`CREATE TABLE IF NOT EXISTS profile_keys (
 			profile_id      INTEGER PRIMARY KEY REFERENCES profiles(id) ON DELETE CASCADE,
 			private_key     BLOB,
 			public_key      BLOB NOT NULL,
 			signature       BLOB,
 			is_key_encrypted BOOLEAN DEFAULT 0
-		)
-	`)
-	if err != nil {
-		log.Printf("Ошибка при создании таблицы profile_keys: %v", err)
-	}
+		)`,
 
-	// 3. TABLE remote_items - кэшированные чужие элементы
-	_, err = DB.Exec(`
-		CREATE TABLE IF NOT EXISTS remote_items (
+		// 9. remote_items - кэшированные чужие элементы
+		`CREATE TABLE IF NOT EXISTS remote_items (
 			id              INTEGER PRIMARY KEY,
 			source_peer_id  TEXT NOT NULL REFERENCES profiles(peer_id),
 			original_id     INTEGER NOT NULL,
@@ -201,26 +139,12 @@ func createNewProfileTables() {
 			version         INTEGER DEFAULT 1,
 			cached_at       DATETIME DEFAULT CURRENT_TIMESTAMP,
 			UNIQUE(source_peer_id, original_hash)
-		)
-	`)
-	if err != nil {
-		log.Printf("Ошибка при создании таблицы remote_items: %v", err)
-	}
-
-	// Индексы для remote_items
-	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_remote_items_source_peer ON remote_items(source_peer_id)`)
-	if err != nil {
-		log.Printf("Ошибка при создании индекса idx_remote_items_source_peer: %v", err)
-	}
-
-	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_remote_items_hash ON remote_items(original_hash)`)
-	if err != nil {
-		log.Printf("Ошибка при создании индекса idx_remote_items_hash: %v", err)
-	}
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_remote_items_source_peer ON remote_items(source_peer_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_remote_items_hash ON remote_items(original_hash)`,
 
-	// 4. TABLE item_files - файлы элементов
-	_, err = DB.Exec(`
-		CREATE TABLE IF NOT EXISTS item_files (
+		// 10. item_files - файлы элементов
+		`CREATE TABLE IF NOT EXISTS item_files (
 			item_id         INTEGER NOT NULL,
 			hash            TEXT NOT NULL,
 			file_path       TEXT NOT NULL,
@@ -229,38 +153,13 @@ func createNewProfileTables() {
 			is_remote       BOOLEAN DEFAULT 0,
 			source_peer_id  TEXT,
 			PRIMARY KEY (item_id, hash)
-		)
-	`)
-	if err != nil {
-		log.Printf("Ошибка при создании таблицы item_files: %v", err)
-	}
-
-	// Индекс для item_files
-	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_item_files_item_id ON item_files(item_id)`)
-	if err != nil {
-		log.Printf("Ошибка при создании индекса idx_item_files_item_id: %v", err)
-	}
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_item_files_item_id ON item_files(item_id)`,
 
-	// 5. Добавляем content_hash в items (если ещё нет)
-	_, err = DB.Exec(`ALTER TABLE items ADD COLUMN content_hash TEXT`)
-	if err != nil {
-		// Игнорируем ошибку, если столбец уже существует
-		if !strings.Contains(err.Error(), "duplicate column name") && !strings.Contains(err.Error(), "column already exists") {
-			log.Printf("Ошибка при добавлении content_hash в items: %v", err)
-		}
-	}
-
-	// Индекс для content_hash
-	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_items_content_hash ON items(content_hash)`)
-	if err != nil {
-		log.Printf("Ошибка при создании индекса idx_items_content_hash: %v", err)
-	}
-
-	// 6. Таблица contacts - адресная книга (избранные пользователи)
-	// Хранит только уникальные данные: адрес для подключения, заметки, настройки
-	// Профиль пользователя (username, avatar, title) берётся из таблицы profiles
-	_, err = DB.Exec(`
-		CREATE TABLE IF NOT EXISTS contacts (
+		// 11. contacts - адресная книга (избранные пользователи)
+		// Хранит только уникальные данные: адрес для подключения, заметки, настройки
+		// Профиль пользователя (username, avatar, title) берётся из таблицы profiles
+		`CREATE TABLE IF NOT EXISTS contacts (
 			id          INTEGER PRIMARY KEY AUTOINCREMENT,
 			peer_id     TEXT UNIQUE NOT NULL REFERENCES profiles(peer_id),
 			multiaddr   TEXT,
@@ -270,15 +169,11 @@ func createNewProfileTables() {
 			last_seen   DATETIME,
 			added_at    DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP
-		);
-	`)
-	if err != nil {
-		log.Printf("Ошибка при создании таблицы contacts: %v", err)
-	}
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_contacts_peer_id ON contacts(peer_id)`,
 
-	// 7. Таблица chat_messages - история сообщений
-	_, err = DB.Exec(`
-		CREATE TABLE IF NOT EXISTS chat_messages (
+		// 12. chat_messages - история сообщений
+		`CREATE TABLE IF NOT EXISTS chat_messages (
 			id           INTEGER PRIMARY KEY AUTOINCREMENT,
 			contact_id   INTEGER NOT NULL,
 			from_peer_id TEXT NOT NULL,
@@ -289,53 +184,368 @@ func createNewProfileTables() {
 			sent_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at   DATETIME,
 			FOREIGN KEY (contact_id) REFERENCES contacts (id) ON DELETE CASCADE
-		);
-	`)
-	if err != nil {
-		log.Printf("Ошибка при создании таблицы chat_messages: %v", err)
-	}
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_chat_messages_contact_id ON chat_messages(contact_id)`,
 
-	// Добавляем колонку updated_at если она не существует (для существующих БД)
-	_, err = DB.Exec(`ALTER TABLE chat_messages ADD COLUMN updated_at DATETIME`)
-	if err != nil {
-		// Игнорируем ошибку, если столбец уже существует
-		if !strings.Contains(err.Error(), "duplicate column name") && !strings.Contains(err.Error(), "column already exists") {
-			log.Printf("Ошибка при добавлении updated_at в chat_messages: %v", err)
-		}
-	}
-
-	// 8. Таблица bootstrap_peers - узлы для входа в сеть
-	_, err = DB.Exec(`
-		CREATE TABLE IF NOT EXISTS bootstrap_peers (
+		// 13. bootstrap_peers - узлы для входа в сеть
+		`CREATE TABLE IF NOT EXISTS bootstrap_peers (
 			id            INTEGER PRIMARY KEY AUTOINCREMENT,
 			multiaddr     TEXT UNIQUE NOT NULL,
 			peer_id       TEXT,
 			is_active     BOOLEAN DEFAULT 1,
 			last_connected DATETIME,
 			added_at      DATETIME DEFAULT CURRENT_TIMESTAMP
-		);
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_bootstrap_peers_multiaddr ON bootstrap_peers(multiaddr)`,
+	)
+}
+
+// migrateLegacyColumns добавляет колонки, которые раньше добавлялись ALTER TABLE при каждом запуске
+func migrateLegacyColumns(tx *sql.Tx) error {
+	columns := []struct{ table, column, definition string }{
+		{"tags", "color", "TEXT DEFAULT '#FFBB00'"},
+		{"tags", "description", "TEXT DEFAULT ''"},
+		{"items", "content_hash", "TEXT"},
+		{"chat_messages", "updated_at", "DATETIME"},
+	}
+	for _, c := range columns {
+		if err := addColumnIfMissing(tx, c.table, c.column, c.definition); err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_items_content_hash ON items(content_hash)`)
+	return err
+}
+
+// migrateProfilesStatusToTitle переносит profiles.status в profiles.title
+// (бывший scripts/migrate_status_to_title.go). Колонка status остаётся для совместимости.
+func migrateProfilesStatusToTitle(tx *sql.Tx) error {
+	hasStatus, err := columnExists(tx, "profiles", "status")
+	if err != nil || !hasStatus {
+		return err
+	}
+
+	if err := addColumnIfMissing(tx, "profiles", "title", "TEXT"); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE profiles
+		SET title = status
+		WHERE status IS NOT NULL AND (title IS NULL OR title = '')
 	`)
 	if err != nil {
-		log.Printf("Ошибка при создании таблицы bootstrap_peers: %v", err)
+		return fmt.Errorf("ошибка копирования status в title: %w", err)
 	}
+	return nil
+}
 
-	// Индексы для производительности
-	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_contacts_peer_id ON contacts(peer_id);`)
-	if err != nil {
-		log.Printf("Ошибка при создании индекса idx_contacts_peer_id: %v", err)
+// migrateNormalizeContacts убирает из contacts поля, дублирующие profiles
+// (бывший scripts/migrate_contacts_table.go): для контактов без профиля создаются
+// удалённые профили, затем таблица пересоздаётся без username, public_key, status и avatar_path.
+func migrateNormalizeContacts(tx *sql.Tx) error {
+	hasOldColumns := false
+	for _, column := range []string{"username", "public_key", "status", "avatar_path"} {
+		exists, err := columnExists(tx, "contacts", column)
+		if err != nil {
+			return err
+		}
+		hasOldColumns = hasOldColumns || exists
+	}
+	if !hasOldColumns {
+		return nil
 	}
 
-	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_chat_messages_contact_id ON chat_messages(contact_id);`)
-	if err != nil {
-		log.Printf("Ошибка при создании индекса idx_chat_messages_contact_id: %v", err)
+	// Недостающие старые колонки подставляются пустыми, чтобы перенос профилей
+	// работал при любом наборе устаревших полей
+	for _, column := range []string{"username", "status", "avatar_path"} {
+		if err := addColumnIfMissing(tx, "contacts", column, "TEXT"); err != nil {
+			return err
+		}
 	}
 
-	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_bootstrap_peers_multiaddr ON bootstrap_peers(multiaddr);`)
-	if err != nil {
-		log.Printf("Ошибка при создании индекса idx_bootstrap_peers_multiaddr: %v", err)
+	return execAll(tx,
+		`INSERT OR IGNORE INTO profiles (owner_type, peer_id, username, title, avatar_path, created_at, updated_at)
+		SELECT
+			'remote',
+			peer_id,
+			COALESCE(NULLIF(username, ''), substr(peer_id, 1, 8)),
+			COALESCE(status, ''),
+			COALESCE(avatar_path, ''),
+			CURRENT_TIMESTAMP,
+			CURRENT_TIMESTAMP
+		FROM contacts`,
+		`CREATE TABLE contacts_new (
+			id          INTEGER PRIMARY KEY AUTOINCREMENT,
+			peer_id     TEXT UNIQUE NOT NULL REFERENCES profiles(peer_id),
+			multiaddr   TEXT,
+			notes       TEXT,
+			is_blocked  BOOLEAN DEFAULT 0,
+			is_favorite BOOLEAN DEFAULT 1,
+			last_seen   DATETIME,
+			added_at    DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`INSERT INTO contacts_new (id, peer_id, multiaddr, notes, is_blocked, last_seen, added_at, updated_at)
+		SELECT id, peer_id, multiaddr, notes, is_blocked, last_seen, added_at, updated_at
+		FROM contacts`,
+		`DROP TABLE contacts`,
+		`ALTER TABLE contacts_new RENAME TO contacts`,
+		`CREATE INDEX IF NOT EXISTS idx_contacts_peer_id ON contacts(peer_id)`,
+	)
+}
+
+// migrateRemoteItemsItemType добавляет тип элемента в remote_items (нужен для проверки
+// подписи владельца) и индекс для дельта-синхронизации по original_id
+func migrateRemoteItemsItemType(tx *sql.Tx) error {
+	if err := addColumnIfMissing(tx, "remote_items", "item_type", "TEXT DEFAULT 'element'"); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_remote_items_original_id ON remote_items(source_peer_id, original_id)`)
+	return err
+}
+
+// migrateSharingRules создаёт правила доступа к папкам и тегам для P2P
+func migrateSharingRules(tx *sql.Tx) error {
+	return execAll(tx,
+		`CREATE TABLE IF NOT EXISTS sharing_rules (
+			id          INTEGER PRIMARY KEY AUTOINCREMENT,
+			entity_type TEXT NOT NULL CHECK (entity_type IN ('folder', 'tag')),
+			entity_id   INTEGER NOT NULL,
+			visibility  TEXT NOT NULL DEFAULT 'private' CHECK (visibility IN ('private', 'contacts', 'public')),
+			created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(entity_type, entity_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS sharing_rule_contacts (
+			rule_id    INTEGER NOT NULL,
+			contact_id INTEGER NOT NULL,
+			PRIMARY KEY (rule_id, contact_id),
+			FOREIGN KEY (rule_id) REFERENCES sharing_rules (id) ON DELETE CASCADE,
+			FOREIGN KEY (contact_id) REFERENCES contacts (id) ON DELETE CASCADE
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_sharing_rules_entity ON sharing_rules(entity_type, entity_id)`,
+	)
+}
+
+// migrateItemSyncState создаёт подписки на библиотеки контактов и курсоры дельта-синхронизации
+func migrateItemSyncState(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS item_sync_state (
+			peer_id        TEXT PRIMARY KEY,
+			subscribed     BOOLEAN DEFAULT 0,
+			cursor         INTEGER DEFAULT 0,
+			last_synced_at DATETIME
+		)
+	`)
+	return err
+}
+
+// migrateSavedSearches создаёт сохранённые поиски (умные папки) и разрешает добавлять их в избранное.
+// CHECK в SQLite не изменить через ALTER TABLE, поэтому favorites пересоздаётся.
+func migrateSavedSearches(tx *sql.Tx) error {
+	return execAll(tx,
+		`CREATE TABLE IF NOT EXISTS saved_searches (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			name       TEXT NOT NULL,
+			query      TEXT NOT NULL DEFAULT '',
+			item_type  TEXT NOT NULL DEFAULT 'all',
+			priority   TEXT NOT NULL DEFAULT 'none',
+			sort_by    TEXT NOT NULL DEFAULT 'name',
+			sort_order TEXT NOT NULL DEFAULT 'asc',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE favorites_new (
+			id          INTEGER PRIMARY KEY AUTOINCREMENT,
+			entity_type TEXT NOT NULL CHECK (entity_type IN ('tag', 'folder', 'saved_search')),
+			entity_id   INTEGER NOT NULL
+		)`,
+		`INSERT INTO favorites_new (id, entity_type, entity_id) SELECT id, entity_type, entity_id FROM favorites`,
+		`DROP TABLE favorites`,
+		`ALTER TABLE favorites_new RENAME TO favorites`,
+	)
+}
+
+// migrateTrash создаёт корзину: удалённое поддерево остаётся в items с trash_id,
+// а запись trash хранит его корень, исходную папку и время удаления
+func migrateTrash(tx *sql.Tx) error {
+	if err := addColumnIfMissing(tx, "items", "trash_id", "INTEGER"); err != nil {
+		return err
+	}
+
+	return execAll(tx,
+		`CREATE TABLE IF NOT EXISTS trash (
+			id                 INTEGER PRIMARY KEY AUTOINCREMENT,
+			item_id            INTEGER NOT NULL,
+			original_parent_id INTEGER,
+			deleted_at         DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_items_trash ON items(trash_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_trash_deleted_at ON trash(deleted_at)`,
+	)
+}
+
+// chatFileHashExpr извлекает хэш вложения из метаданных файлового сообщения чата
+func chatFileHashExpr(row string) string {
+	return `CASE WHEN ` + row + `.content_type IN ('file', 'image') AND json_valid(` + row + `.metadata)
+		THEN NULLIF(json_extract(` + row + `.metadata, '$.file_hash'), '') END`
+}
+
+// fileRefIncrement и fileRefDecrement изменяют счётчик ссылок на блоб в files.
+// Блоб без ссылок получает unreferenced_at - от этого момента отсчитывается период ожидания сборщика мусора.
+func fileRefIncrement(hashExpr, sizeExpr, mimeExpr string) string {
+	return `INSERT INTO files (hash, size, mime_type, ref_count) VALUES (` + hashExpr + `, COALESCE(` + sizeExpr + `, 0), ` + mimeExpr + `, 1)
+		ON CONFLICT(hash) DO UPDATE SET ref_count = ref_count + 1, unreferenced_at = NULL;`
+}
+
+func fileRefDecrement(hashExpr string) string {
+	return `UPDATE files SET
+			ref_count = MAX(ref_count - 1, 0),
+			unreferenced_at = CASE WHEN ref_count <= 1 THEN CURRENT_TIMESTAMP ELSE unreferenced_at END
+		WHERE hash = ` + hashExpr + `;`
+}
+
+// migrateFileRefcount превращает files в реестр блобов со счётчиком ссылок.
+// Ссылки - локальные строки item_files и файловые сообщения чата; счётчик ведут триггеры,
+// поэтому удаление элемента или сообщения только отвязывает блоб, а с диска его убирает сборщик мусора.
+func migrateFileRefcount(tx *sql.Tx) error {
+	if err := addColumnIfMissing(tx, "files", "ref_count", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(tx, "files", "unreferenced_at", "DATETIME"); err != nil {
+		return err
 	}
 
-	log.Println("Новые таблицы профилей и элементов созданы")
+	newHash := chatFileHashExpr("NEW")
+	oldHash := chatFileHashExpr("OLD")
+	chatHash := chatFileHashExpr("chat_messages")
+
+	return execAll(tx,
+		// Строки item_files, оставшиеся от удалённых ранее элементов, ссылками не считаются
+		`DELETE FROM item_files WHERE is_remote = 0 AND item_id NOT IN (SELECT id FROM items)`,
+
+		// Заполняем реестр существующими ссылками
+		`INSERT OR IGNORE INTO files (hash, size, mime_type)
+			SELECT hash, MAX(COALESCE(size, 0)), MAX(mime_type)
+			FROM item_files WHERE is_remote = 0
+			GROUP BY hash`,
+		`INSERT OR IGNORE INTO files (hash, size, mime_type)
+			SELECT `+chatHash+`, MAX(COALESCE(json_extract(metadata, '$.size'), 0)), MAX(json_extract(metadata, '$.mime_type'))
+			FROM chat_messages
+			WHERE `+chatHash+` IS NOT NULL
+			GROUP BY 1`,
+		`UPDATE files SET ref_count =
+			(SELECT COUNT(*) FROM item_files WHERE is_remote = 0 AND item_files.hash = files.hash) +
+			(SELECT COUNT(*) FROM chat_messages WHERE `+chatHash+` = files.hash)`,
+		`UPDATE files SET unreferenced_at = CURRENT_TIMESTAMP WHERE ref_count = 0 AND unreferenced_at IS NULL`,
+
+		// Удалённый элемент отвязывает свои файлы
+		`CREATE TRIGGER IF NOT EXISTS items_unlink_files AFTER DELETE ON items BEGIN
+			DELETE FROM item_files WHERE item_id = OLD.id AND is_remote = 0;
+		END`,
+
+		`CREATE TRIGGER IF NOT EXISTS item_files_ref_insert AFTER INSERT ON item_files
+		WHEN NEW.is_remote = 0 BEGIN
+			`+fileRefIncrement("NEW.hash", "NEW.size", "NEW.mime_type")+`
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS item_files_ref_delete AFTER DELETE ON item_files
+		WHEN OLD.is_remote = 0 BEGIN
+			`+fileRefDecrement("OLD.hash")+`
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS item_files_ref_update_old AFTER UPDATE OF hash, is_remote ON item_files
+		WHEN OLD.is_remote = 0 BEGIN
+			`+fileRefDecrement("OLD.hash")+`
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS item_files_ref_update_new AFTER UPDATE OF hash, is_remote ON item_files
+		WHEN NEW.is_remote = 0 BEGIN
+			`+fileRefIncrement("NEW.hash", "NEW.size", "NEW.mime_type")+`
+		END`,
+
+		`CREATE TRIGGER IF NOT EXISTS chat_files_ref_insert AFTER INSERT ON chat_messages
+		WHEN `+newHash+` IS NOT NULL BEGIN
+			`+fileRefIncrement(newHash, "json_extract(NEW.metadata, '$.size')", "json_extract(NEW.metadata, '$.mime_type')")+`
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS chat_files_ref_delete AFTER DELETE ON chat_messages
+		WHEN `+oldHash+` IS NOT NULL BEGIN
+			`+fileRefDecrement(oldHash)+`
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS chat_files_ref_update_old AFTER UPDATE OF content_type, metadata ON chat_messages
+		WHEN `+oldHash+` IS NOT NULL BEGIN
+			`+fileRefDecrement(oldHash)+`
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS chat_files_ref_update_new AFTER UPDATE OF content_type, metadata ON chat_messages
+		WHEN `+newHash+` IS NOT NULL BEGIN
+			`+fileRefIncrement(newHash, "json_extract(NEW.metadata, '$.size')", "json_extract(NEW.metadata, '$.mime_type')")+`
+		END`,
+	)
+}
+
+// migrateItemRevisions создаёт историю изменений элементов. Ревизия хранит предыдущее состояние
+// элемента (блоки, теги), а item_revision_files - хэши его файлов: они считаются ссылками,
+// чтобы сборщик мусора не удалил файлы, нужные для восстановления ревизии.
+func migrateItemRevisions(tx *sql.Tx) error {
+	return execAll(tx,
+		`CREATE TABLE IF NOT EXISTS item_revisions (
+			id           INTEGER PRIMARY KEY AUTOINCREMENT,
+			item_id      INTEGER NOT NULL,
+			type         TEXT NOT NULL,
+			title        TEXT NOT NULL DEFAULT '',
+			description  TEXT NOT NULL DEFAULT '',
+			content_meta TEXT NOT NULL DEFAULT '[]',
+			tags         TEXT NOT NULL DEFAULT '[]',
+			created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_item_revisions_item ON item_revisions(item_id, id)`,
+		`CREATE TABLE IF NOT EXISTS item_revision_files (
+			revision_id INTEGER NOT NULL,
+			hash        TEXT NOT NULL,
+			PRIMARY KEY (revision_id, hash)
+		)`,
+
+		// История удаляется вместе с элементом, файлы ревизии - вместе с ревизией
+		`CREATE TRIGGER IF NOT EXISTS items_delete_revisions AFTER DELETE ON items BEGIN
+			DELETE FROM item_revisions WHERE item_id = OLD.id;
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS item_revisions_delete_files AFTER DELETE ON item_revisions BEGIN
+			DELETE FROM item_revision_files WHERE revision_id = OLD.id;
+		END`,
+
+		`CREATE TRIGGER IF NOT EXISTS item_revision_files_ref_insert AFTER INSERT ON item_revision_files BEGIN
+			`+fileRefIncrement("NEW.hash", "NULL", "NULL")+`
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS item_revision_files_ref_delete AFTER DELETE ON item_revision_files BEGIN
+			`+fileRefDecrement("OLD.hash")+`
+		END`,
+	)
+}
+
+// migrateItemListIndexes добавляет индексы для постраничной выборки элементов сетки:
+// по каждому ключу сортировки внутри папки и по всей библиотеке, а также по размерам файлов элемента.
+// Названия NULL заменяются пустой строкой - NULL выпадал бы из сравнения с курсором страницы.
+func migrateItemListIndexes(tx *sql.Tx) error {
+	return execAll(tx,
+		`UPDATE items SET title = '' WHERE title IS NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_items_parent_title ON items(parent_id, title COLLATE NOCASE)`,
+		`CREATE INDEX IF NOT EXISTS idx_items_parent_created ON items(parent_id, created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_items_parent_updated ON items(parent_id, updated_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_items_title ON items(title COLLATE NOCASE)`,
+		`CREATE INDEX IF NOT EXISTS idx_items_created ON items(created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_item_files_item_size ON item_files(item_id, size)`,
+	)
+}
+
+// migratePageTexts создаёт таблицу текста сохранённых копий веб-страниц. Текст копии хранится
+// в хранилище файлов блобом, а в базе - только для полнотекстового поиска, под хэшем этого блоба.
+func migratePageTexts(tx *sql.Tx) error {
+	return execAll(tx,
+		`CREATE TABLE IF NOT EXISTS page_texts (
+			hash TEXT PRIMARY KEY,
+			text TEXT NOT NULL DEFAULT ''
+		)`,
+	)
 }
 
 // seedBootstrapPeers добавляет предопределённые bootstrap-узлы
@@ -1,6 +1,6 @@
 package models
 
-// Favorite представляет избранный элемент (может быть тегом или папкой)
+// Favorite представляет избранный элемент (тег, папку или сохранённый поиск)
 type Favorite struct {
 	ID         int    `json:"id"`
 	EntityType string `json:"entity_type"`
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// File запись реестра блобов: файл на диске, адресуемый хэшем содержимого.
+// RefCount - число ссылок на блоб (локальные item_files и файловые сообщения чата);
+// блоб без ссылок удаляет сборщик мусора по истечении периода ожидания.
+type File struct {
+	ID             int        `json:"id"`
+	Hash           string     `json:"hash"`
+	Size           int64      `json:"size"`
+	MimeType       string     `json:"mime_type,omitempty"`
+	RefCount       int        `json:"ref_count"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UnreferencedAt *time.Time `json:"unreferenced_at,omitempty"` // Когда блоб потерял последнюю ссылку
+}
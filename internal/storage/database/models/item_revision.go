@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// ItemRevision сохранённое предыдущее состояние элемента. Создаётся при каждом сохранении
+// элемента и позволяет сравнить версии и вернуть элемент к любой из них вместе с файлами.
+type ItemRevision struct {
+	ID          int       `json:"id"`
+	ItemID      int       `json:"item_id"`
+	Type        ItemType  `json:"type"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	ContentMeta string    `json:"content_meta"` // JSON блоков контента
+	Tags        []string  `json:"tags"`         // Имена тегов
+	FileHashes  []string  `json:"file_hashes"`  // Хэши файлов, на которые ссылалась ревизия
+	CreatedAt   time.Time `json:"created_at"`
+}
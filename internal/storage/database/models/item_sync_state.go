@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// ItemSyncState состояние синхронизации библиотеки контакта
+type ItemSyncState struct {
+	PeerID       string     `json:"peer_id"`
+	Subscribed   bool       `json:"subscribed"`     // Обновлять библиотеку автоматически
+	Cursor       int64      `json:"cursor"`         // Курсор владельца (UnixNano), 0 - полная синхронизация
+	LastSyncedAt *time.Time `json:"last_synced_at"` // Время последней успешной синхронизации
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// LinkPreview превью ссылки, загруженное со страницы (OpenGraph, Twitter Card или обычный HTML).
+// Хранится в блоке ссылки content_meta; значок сайта и изображение лежат в хранилище файлов
+// и адресуются хэшем, как файлы блоков.
+type LinkPreview struct {
+	URL         string    `json:"url,omitempty"` // Итоговый адрес страницы после перенаправлений
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	SiteName    string    `json:"site_name,omitempty"`
+	FaviconHash string    `json:"favicon_hash,omitempty"`
+	ImageHash   string    `json:"image_hash,omitempty"`
+	FetchedAt   time.Time `json:"fetched_at"`
+	Error       string    `json:"error,omitempty"` // Почему страницу не удалось загрузить
+}
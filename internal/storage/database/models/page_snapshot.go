@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// PageSnapshot сохранённая копия веб-страницы из блока ссылки. Хранится в блоке ссылки content_meta;
+// копия (один HTML-файл со встроенными стилями и изображениями) и её текст лежат в хранилище файлов
+// и привязаны к элементу, как файлы блоков.
+type PageSnapshot struct {
+	URL        string    `json:"url,omitempty"` // Итоговый адрес страницы после перенаправлений
+	Title      string    `json:"title,omitempty"`
+	FileHash   string    `json:"file_hash"` // HTML-копия страницы
+	TextHash   string    `json:"text_hash"` // Текст страницы для поиска и просмотра
+	Size       int64     `json:"size"`      // Размер HTML-копии в байтах
+	ArchivedAt time.Time `json:"archived_at"`
+}
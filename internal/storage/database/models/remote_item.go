@@ -9,6 +9,7 @@ type RemoteItem struct {
 	SourcePeerID string    `json:"source_peer_id"` // PeerID владельца
 	OriginalID   int       `json:"original_id"`    // ID элемента у владельца
 	OriginalHash string    `json:"original_hash"`  // Content hash у владельца
+	ItemType     ItemType  `json:"item_type"`      // Тип элемента у владельца
 	Title        string    `json:"title"`
 	Description  string    `json:"description,omitempty"`
 	ContentMeta  string    `json:"content_meta,omitempty"`
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// FavoriteEntitySavedSearch тип сущности избранного для сохранённого поиска
+const FavoriteEntitySavedSearch = "saved_search"
+
+// SavedSearch сохранённый поиск (умная папка): запрос строки поиска
+// вместе с настройками фильтрации и сортировки результатов
+type SavedSearch struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Query     string    `json:"query"`
+	ItemType  string    `json:"item_type"`  // Как FilterOptions.ItemType
+	Priority  string    `json:"priority"`   // Как FilterOptions.Priority
+	SortBy    string    `json:"sort_by"`    // Как FilterOptions.SortBy
+	SortOrder string    `json:"sort_order"` // Как FilterOptions.SortOrder
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
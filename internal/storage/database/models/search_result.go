@@ -0,0 +1,8 @@
+package models
+
+// SearchResult элемент, найденный полнотекстовым поиском
+type SearchResult struct {
+	Item    *Item   `json:"item"`
+	Snippet string  `json:"snippet"` // Фрагмент текста, совпадения обрамлены маркерами подсветки
+	Rank    float64 `json:"rank"`    // Релевантность bm25: чем меньше, тем выше в выдаче
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// SharingVisibility определяет, кому доступны папка или тег при P2P синхронизации
+type SharingVisibility string
+
+const (
+	// SharingPrivate - доступно только владельцу (по умолчанию)
+	SharingPrivate SharingVisibility = "private"
+	// SharingContacts - доступно выбранным контактам
+	SharingContacts SharingVisibility = "contacts"
+	// SharingPublic - доступно всем незаблокированным пирам
+	SharingPublic SharingVisibility = "public"
+)
+
+// SharingEntityFolder и SharingEntityTag - типы сущностей, для которых задаётся доступ
+const (
+	SharingEntityFolder = "folder"
+	SharingEntityTag    = "tag"
+)
+
+// SharingRule правило доступа к папке или тегу.
+// Правило папки действует на всё её содержимое (включая вложенные папки),
+// правило тега - на все элементы с этим тегом. Элемент без правил приватен,
+// а явное правило private перекрывает любые разрешающие правила.
+type SharingRule struct {
+	ID         int               `json:"id"`
+	EntityType string            `json:"entity_type"` // folder или tag
+	EntityID   int               `json:"entity_id"`
+	Visibility SharingVisibility `json:"visibility"`
+	ContactIDs []int             `json:"contact_ids,omitempty"` // Для visibility = contacts
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
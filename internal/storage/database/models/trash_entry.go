@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// TrashEntry запись корзины: удалённый элемент вместе со всем его поддеревом.
+// Элементы поддерева остаются в items с trash_id и скрыты из всех выборок,
+// пока запись не восстановлена или не удалена окончательно.
+type TrashEntry struct {
+	ID               int       `json:"id"`
+	ItemID           int       `json:"item_id"`
+	Item             *Item     `json:"item,omitempty"`
+	OriginalParentID *int      `json:"original_parent_id,omitempty"` // Папка, из которой удалён элемент (nil - корень)
+	ItemCount        int       `json:"item_count"`                   // Число элементов в поддереве, включая сам элемент
+	DeletedAt        time.Time `json:"deleted_at"`
+}
@@ -12,7 +12,7 @@ import (
 
 // GetBootstrapPeer получает bootstrap-узел по ID
 func GetBootstrapPeer(id int) (*models.BootstrapPeer, error) {
-	row := database.DB.QueryRow(`
+	row := database.GetDB().QueryRow(`
 		SELECT id, multiaddr, peer_id, is_active, last_connected, added_at
 		FROM bootstrap_peers
 		WHERE id = ?
@@ -49,7 +49,7 @@ func GetBootstrapPeer(id int) (*models.BootstrapPeer, error) {
 
 // GetAllBootstrapPeers получает все bootstrap-узлы
 func GetAllBootstrapPeers() ([]*models.BootstrapPeer, error) {
-	rows, err := database.DB.Query(`
+	rows, err := database.GetDB().Query(`
 		SELECT id, multiaddr, peer_id, is_active, last_connected, added_at
 		FROM bootstrap_peers
 		ORDER BY added_at
@@ -92,7 +92,7 @@ func GetAllBootstrapPeers() ([]*models.BootstrapPeer, error) {
 
 // GetActiveBootstrapPeers получает активные bootstrap-узлы
 func GetActiveBootstrapPeers() ([]*models.BootstrapPeer, error) {
-	rows, err := database.DB.Query(`
+	rows, err := database.GetDB().Query(`
 		SELECT id, multiaddr, peer_id, is_active, last_connected, added_at
 		FROM bootstrap_peers
 		WHERE is_active = 1
@@ -143,7 +143,7 @@ func CreateBootstrapPeer(peer *models.BootstrapPeer) error {
 		peerID = nil
 	}
 
-	result, err := database.DB.Exec(`
+	result, err := database.GetDB().Exec(`
 		INSERT INTO bootstrap_peers (multiaddr, peer_id, is_active, last_connected, added_at)
 		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
 	`, peer.Multiaddr, peerID, peer.IsActive, peer.LastConnected)
@@ -168,7 +168,7 @@ func UpdateBootstrapPeer(peer *models.BootstrapPeer) error {
 		peerID = nil
 	}
 
-	_, err := database.DB.Exec(`
+	_, err := database.GetDB().Exec(`
 		UPDATE bootstrap_peers
 		SET multiaddr = ?, peer_id = ?, is_active = ?, last_connected = ?
 		WHERE id = ?
@@ -179,7 +179,7 @@ func UpdateBootstrapPeer(peer *models.BootstrapPeer) error {
 // UpdateBootstrapPeerLastConnected обновляет время последнего подключения
 func UpdateBootstrapPeerLastConnected(multiaddr string) error {
 	now := time.Now().Format("2006-01-02 15:04:05")
-	_, err := database.DB.Exec(`
+	_, err := database.GetDB().Exec(`
 		UPDATE bootstrap_peers
 		SET last_connected = ?
 		WHERE multiaddr = ?
@@ -189,20 +189,20 @@ func UpdateBootstrapPeerLastConnected(multiaddr string) error {
 
 // DeleteBootstrapPeer удаляет bootstrap-узел по ID
 func DeleteBootstrapPeer(id int) error {
-	_, err := database.DB.Exec(`DELETE FROM bootstrap_peers WHERE id = ?`, id)
+	_, err := database.GetDB().Exec(`DELETE FROM bootstrap_peers WHERE id = ?`, id)
 	return err
 }
 
 // DeleteBootstrapPeerByMultiaddr удаляет bootstrap-узел по Multiaddr
 func DeleteBootstrapPeerByMultiaddr(multiaddr string) error {
-	_, err := database.DB.Exec(`DELETE FROM bootstrap_peers WHERE multiaddr = ?`, multiaddr)
+	_, err := database.GetDB().Exec(`DELETE FROM bootstrap_peers WHERE multiaddr = ?`, multiaddr)
 	return err
 }
 
 // BootstrapPeerExists проверяет, существует ли bootstrap-узел
 func BootstrapPeerExists(multiaddr string) (bool, error) {
 	var count int
-	err := database.DB.QueryRow(`SELECT COUNT(*) FROM bootstrap_peers WHERE multiaddr = ?`, multiaddr).Scan(&count)
+	err := database.GetDB().QueryRow(`SELECT COUNT(*) FROM bootstrap_peers WHERE multiaddr = ?`, multiaddr).Scan(&count)
 	if err != nil {
 		return false, err
 	}
@@ -211,7 +211,7 @@ func BootstrapPeerExists(multiaddr string) (bool, error) {
 
 // SetBootstrapPeerActive устанавливает активность bootstrap-узла
 func SetBootstrapPeerActive(id int, active bool) error {
-	_, err := database.DB.Exec(`
+	_, err := database.GetDB().Exec(`
 		UPDATE bootstrap_peers
 		SET is_active = ?
 		WHERE id = ?
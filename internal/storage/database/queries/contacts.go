@@ -6,13 +6,13 @@ import (
 	"errors"
 	"time"
 
-	"projectT/internal/storage/database"
 	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/vault"
 )
 
 // GetContact получает контакт по ID с данными профиля из profiles
-func GetContact(id int) (*models.Contact, error) {
-	row := database.DB.QueryRow(`
+func (r *ContactsRepo) GetContact(id int) (*models.Contact, error) {
+	row := r.conn().QueryRow(`
 		SELECT 
 			c.id, c.peer_id, c.multiaddr, c.notes, c.is_blocked, c.last_seen, c.added_at, c.updated_at,
 			p.username, p.title, p.avatar_path
@@ -49,6 +49,7 @@ func GetContact(id int) (*models.Contact, error) {
 		t, _ := time.Parse("2006-01-02 15:04:05", lastSeen.String)
 		contact.LastSeen = &t
 	}
+	contact.Notes = vault.DecryptField(contact.Notes)
 	contact.AddedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
 	contact.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAt)
 
@@ -56,8 +57,8 @@ func GetContact(id int) (*models.Contact, error) {
 }
 
 // GetContactByPeerID получает контакт по PeerID с данными профиля
-func GetContactByPeerID(peerID string) (*models.Contact, error) {
-	row := database.DB.QueryRow(`
+func (r *ContactsRepo) GetContactByPeerID(peerID string) (*models.Contact, error) {
+	row := r.conn().QueryRow(`
 		SELECT 
 			c.id, c.peer_id, c.multiaddr, c.notes, c.is_blocked, c.last_seen, c.added_at, c.updated_at,
 			p.username, p.title, p.avatar_path
@@ -94,6 +95,7 @@ func GetContactByPeerID(peerID string) (*models.Contact, error) {
 		t, _ := time.Parse("2006-01-02 15:04:05", lastSeen.String)
 		contact.LastSeen = &t
 	}
+	contact.Notes = vault.DecryptField(contact.Notes)
 	contact.AddedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
 	contact.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAt)
 
@@ -101,8 +103,8 @@ func GetContactByPeerID(peerID string) (*models.Contact, error) {
 }
 
 // GetAllContacts получает все контакты с данными профилей
-func GetAllContacts() ([]*models.Contact, error) {
-	rows, err := database.DB.Query(`
+func (r *ContactsRepo) GetAllContacts() ([]*models.Contact, error) {
+	rows, err := r.conn().Query(`
 		SELECT 
 			c.id, c.peer_id, c.multiaddr, c.notes, c.is_blocked, c.last_seen, c.added_at, c.updated_at,
 			p.username, p.title, p.avatar_path
@@ -142,6 +144,7 @@ func GetAllContacts() ([]*models.Contact, error) {
 			t, _ := time.Parse("2006-01-02 15:04:05", lastSeen.String)
 			contact.LastSeen = &t
 		}
+		contact.Notes = vault.DecryptField(contact.Notes)
 		contact.AddedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
 		contact.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAt)
 
@@ -152,11 +155,15 @@ func GetAllContacts() ([]*models.Contact, error) {
 }
 
 // CreateContact создаёт новый контакт
-func CreateContact(contact *models.Contact) error {
-	result, err := database.DB.Exec(`
+func (r *ContactsRepo) CreateContact(contact *models.Contact) error {
+	notes, err := vault.EncryptField(contact.Notes)
+	if err != nil {
+		return err
+	}
+	result, err := r.conn().Exec(`
 		INSERT INTO contacts (peer_id, multiaddr, notes, is_blocked, last_seen, added_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
-	`, contact.PeerID, contact.Multiaddr, contact.Notes, contact.IsBlocked, contact.LastSeen)
+	`, contact.PeerID, contact.Multiaddr, notes, contact.IsBlocked, contact.LastSeen)
 	if err != nil {
 		return err
 	}
@@ -170,17 +177,21 @@ func CreateContact(contact *models.Contact) error {
 }
 
 // UpdateContact обновляет контакт
-func UpdateContact(contact *models.Contact) error {
-	_, err := database.DB.Exec(`
+func (r *ContactsRepo) UpdateContact(contact *models.Contact) error {
+	notes, err := sealedColumn(contact.Notes)
+	if err != nil {
+		return err
+	}
+	_, err = r.conn().Exec(`
 		UPDATE contacts
-		SET multiaddr = ?, notes = ?, is_blocked = ?, last_seen = ?, updated_at = CURRENT_TIMESTAMP
+		SET multiaddr = ?, notes = COALESCE(?, notes), is_blocked = ?, last_seen = ?, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
-	`, contact.Multiaddr, contact.Notes, contact.IsBlocked, contact.LastSeen, contact.ID)
+	`, contact.Multiaddr, notes, contact.IsBlocked, contact.LastSeen, contact.ID)
 	return err
 }
 
 // UpdateContactLastSeen обновляет время последней активности контакта
-func UpdateContactLastSeen(id int, lastSeen *time.Time) error {
+func (r *ContactsRepo) UpdateContactLastSeen(id int, lastSeen *time.Time) error {
 	var lastSeenStr interface{}
 	if lastSeen != nil {
 		lastSeenStr = lastSeen.Format("2006-01-02 15:04:05")
@@ -188,7 +199,7 @@ func UpdateContactLastSeen(id int, lastSeen *time.Time) error {
 		lastSeenStr = nil
 	}
 
-	_, err := database.DB.Exec(`
+	_, err := r.conn().Exec(`
 		UPDATE contacts
 		SET last_seen = ?, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
@@ -197,18 +208,22 @@ func UpdateContactLastSeen(id int, lastSeen *time.Time) error {
 }
 
 // UpdateContactNotes обновляет заметки контакта
-func UpdateContactNotes(id int, notes string) error {
-	_, err := database.DB.Exec(`
+func (r *ContactsRepo) UpdateContactNotes(id int, notes string) error {
+	value, err := sealedColumn(notes)
+	if err != nil {
+		return err
+	}
+	_, err = r.conn().Exec(`
 		UPDATE contacts
-		SET notes = ?, updated_at = CURRENT_TIMESTAMP
+		SET notes = COALESCE(?, notes), updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
-	`, notes, id)
+	`, value, id)
 	return err
 }
 
 // UpdateContactMultiaddr обновляет адрес для подключения
-func UpdateContactMultiaddr(id int, multiaddr string) error {
-	_, err := database.DB.Exec(`
+func (r *ContactsRepo) UpdateContactMultiaddr(id int, multiaddr string) error {
+	_, err := r.conn().Exec(`
 		UPDATE contacts
 		SET multiaddr = ?, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
@@ -217,8 +232,8 @@ func UpdateContactMultiaddr(id int, multiaddr string) error {
 }
 
 // UpdateContactByPeerID обновляет multiaddr контакта по PeerID
-func UpdateContactByPeerID(peerID, multiaddr string) error {
-	_, err := database.DB.Exec(`
+func (r *ContactsRepo) UpdateContactByPeerID(peerID, multiaddr string) error {
+	_, err := r.conn().Exec(`
 		UPDATE contacts
 		SET multiaddr = ?, updated_at = CURRENT_TIMESTAMP
 		WHERE peer_id = ?
@@ -227,21 +242,21 @@ func UpdateContactByPeerID(peerID, multiaddr string) error {
 }
 
 // DeleteContact удаляет контакт по ID
-func DeleteContact(id int) error {
-	_, err := database.DB.Exec(`DELETE FROM contacts WHERE id = ?`, id)
+func (r *ContactsRepo) DeleteContact(id int) error {
+	_, err := r.conn().Exec(`DELETE FROM contacts WHERE id = ?`, id)
 	return err
 }
 
 // DeleteContactByPeerID удаляет контакт по PeerID
-func DeleteContactByPeerID(peerID string) error {
-	_, err := database.DB.Exec(`DELETE FROM contacts WHERE peer_id = ?`, peerID)
+func (r *ContactsRepo) DeleteContactByPeerID(peerID string) error {
+	_, err := r.conn().Exec(`DELETE FROM contacts WHERE peer_id = ?`, peerID)
 	return err
 }
 
 // IsContactBlocked проверяет, заблокирован ли контакт
-func IsContactBlocked(peerID string) (bool, error) {
+func (r *ContactsRepo) IsContactBlocked(peerID string) (bool, error) {
 	var isBlocked bool
-	err := database.DB.QueryRow(`SELECT is_blocked FROM contacts WHERE peer_id = ?`, peerID).Scan(&isBlocked)
+	err := r.conn().QueryRow(`SELECT is_blocked FROM contacts WHERE peer_id = ?`, peerID).Scan(&isBlocked)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return false, errors.New("контакт не найден")
@@ -252,8 +267,8 @@ func IsContactBlocked(peerID string) (bool, error) {
 }
 
 // SearchContacts ищет контакты по имени профиля
-func SearchContacts(query string) ([]*models.Contact, error) {
-	rows, err := database.DB.Query(`
+func (r *ContactsRepo) SearchContacts(query string) ([]*models.Contact, error) {
+	rows, err := r.conn().Query(`
 		SELECT 
 			c.id, c.peer_id, c.multiaddr, c.notes, c.is_blocked, c.last_seen, c.added_at, c.updated_at,
 			p.username, p.title, p.avatar_path
@@ -294,6 +309,7 @@ func SearchContacts(query string) ([]*models.Contact, error) {
 			t, _ := time.Parse("2006-01-02 15:04:05", lastSeen.String)
 			contact.LastSeen = &t
 		}
+		contact.Notes = vault.DecryptField(contact.Notes)
 		contact.AddedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
 		contact.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAt)
 
@@ -304,8 +320,8 @@ func SearchContacts(query string) ([]*models.Contact, error) {
 }
 
 // BlockContact блокирует контакт
-func BlockContact(id int) error {
-	_, err := database.DB.Exec(`
+func (r *ContactsRepo) BlockContact(id int) error {
+	_, err := r.conn().Exec(`
 		UPDATE contacts
 		SET is_blocked = 1, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
@@ -314,8 +330,8 @@ func BlockContact(id int) error {
 }
 
 // UnblockContact разблокирует контакт
-func UnblockContact(id int) error {
-	_, err := database.DB.Exec(`
+func (r *ContactsRepo) UnblockContact(id int) error {
+	_, err := r.conn().Exec(`
 		UPDATE contacts
 		SET is_blocked = 0, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
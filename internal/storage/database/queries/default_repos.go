@@ -0,0 +1,478 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"projectT/internal/storage/database/models"
+)
+
+// Функции пакета работают через репозитории по умолчанию над database.GetDB().
+// Код, которому нужна другая база или транзакция, создаёт репозитории сам (NewItemsRepo и т.п.).
+
+var (
+	defaultItems    = NewItemsRepo(nil)
+	defaultTags     = NewTagsRepo(nil)
+	defaultFiles    = NewFilesRepo(nil)
+	defaultContacts = NewContactsRepo(nil)
+	defaultMessages = NewMessagesRepo(nil)
+	defaultProfiles = NewProfilesRepo(nil)
+	defaultSharing  = NewSharingRepo(nil)
+)
+
+// BeginTransaction начинает транзакцию на database.GetDB()
+func BeginTransaction(ctx context.Context) (*sql.Tx, error) {
+	return defaultItems.BeginTransaction(ctx)
+}
+
+// CreateItem создает новый элемент
+func CreateItem(item *models.Item) error {
+	return defaultItems.CreateItem(item)
+}
+
+// GetItemByID возвращает элемент по ID. Элемент в корзине не возвращается: sql.ErrNoRows
+func GetItemByID(id int) (*models.Item, error) {
+	return defaultItems.GetItemByID(id)
+}
+
+// GetItemByHash возвращает элемент по хешу содержимого
+func GetItemByHash(contentHash string) (*models.Item, error) {
+	return defaultItems.GetItemByHash(contentHash)
+}
+
+// GetItemsByParent возвращает элементы по родительскому ID
+func GetItemsByParent(parentID int) ([]*models.Item, error) {
+	return defaultItems.GetItemsByParent(parentID)
+}
+
+// GetAllItems возвращает все элементы из базы данных
+func GetAllItems() ([]*models.Item, error) {
+	return defaultItems.GetAllItems()
+}
+
+// PinItem закрепляет элемент
+func PinItem(itemID int) error {
+	return defaultItems.PinItem(itemID)
+}
+
+// UnpinItem открепляет элемент
+func UnpinItem(itemID int) error {
+	return defaultItems.UnpinItem(itemID)
+}
+
+// IsItemPinned проверяет, закреплен ли элемент
+func IsItemPinned(itemID int) (bool, error) {
+	return defaultItems.IsItemPinned(itemID)
+}
+
+// UpdateItem обновляет элемент
+func UpdateItem(item *models.Item) error {
+	return defaultItems.UpdateItem(item)
+}
+
+// DeleteItem удаляет элемент по ID
+func DeleteItem(id int) error {
+	return defaultItems.DeleteItem(id)
+}
+
+// GetItemSubtree возвращает элемент rootID и все вложенные в него элементы, кроме находящихся в корзине.
+// Родители идут раньше вложенных элементов.
+func GetItemSubtree(rootID int) ([]*models.Item, error) {
+	return defaultItems.GetItemSubtree(rootID)
+}
+
+// UpdateItemTimestamps задаёт время создания и изменения элемента (например, при импорте)
+func UpdateItemTimestamps(id int, createdAt, updatedAt time.Time) error {
+	return defaultItems.UpdateItemTimestamps(id, createdAt, updatedAt)
+}
+
+// SearchItems выполняет поиск элементов по названию, описанию, тексту блоков и тегам.
+// Элементы возвращаются в порядке релевантности.
+func SearchItems(query string) ([]*models.Item, error) {
+	return defaultItems.SearchItems(query)
+}
+
+// SearchItemsWithSnippets разбирает структурированный запрос (см. ParseSearchQuery)
+// и возвращает подходящие элементы с подсвеченными фрагментами текста
+func SearchItemsWithSnippets(query string) ([]*models.SearchResult, error) {
+	return defaultItems.SearchItemsWithSnippets(query)
+}
+
+// SearchItemsByQuery выполняет разобранный запрос.
+// Если в запросе есть слова и доступен FTS5, элементы упорядочены по релевантности,
+// иначе - по дате изменения. Без FTS5 слова ищутся как подстроки через LIKE.
+// Пустой запрос, как и раньше, совпадает со всеми элементами.
+func SearchItemsByQuery(query *SearchQuery) ([]*models.SearchResult, error) {
+	return defaultItems.SearchItemsByQuery(query)
+}
+
+// CreateTag создает новый тег в транзакции
+func CreateTag(ctx context.Context, tag *models.Tag) error {
+	return defaultTags.CreateTag(ctx, tag)
+}
+
+// GetTagByID возвращает тег по ID
+func GetTagByID(ctx context.Context, id int) (*models.Tag, error) {
+	return defaultTags.GetTagByID(ctx, id)
+}
+
+// GetTagByName возвращает тег по имени
+func GetTagByName(ctx context.Context, name string) (*models.Tag, error) {
+	return defaultTags.GetTagByName(ctx, name)
+}
+
+// GetOrCreateTag получает существующий тег или создает новый
+func GetOrCreateTag(ctx context.Context, name string) (*models.Tag, error) {
+	return defaultTags.GetOrCreateTag(ctx, name)
+}
+
+// GetOrCreateTags получает или создает несколько тегов
+func GetOrCreateTags(ctx context.Context, tagNames []string) ([]int, error) {
+	return defaultTags.GetOrCreateTags(ctx, tagNames)
+}
+
+// GetAllTags возвращает все теги с подсчетом элементов
+func GetAllTags(ctx context.Context) ([]*models.Tag, error) {
+	return defaultTags.GetAllTags(ctx)
+}
+
+// SearchTagsByName ищет теги по имени
+func SearchTagsByName(ctx context.Context, name string) ([]*models.Tag, error) {
+	return defaultTags.SearchTagsByName(ctx, name)
+}
+
+// UpdateTag обновляет тег
+func UpdateTag(ctx context.Context, tag *models.Tag) error {
+	return defaultTags.UpdateTag(ctx, tag)
+}
+
+// DeleteTag удаляет тег
+func DeleteTag(ctx context.Context, id int) error {
+	return defaultTags.DeleteTag(ctx, id)
+}
+
+// AddTagToItem добавляет связь тега с элементом
+func AddTagToItem(ctx context.Context, itemID, tagID int) error {
+	return defaultTags.AddTagToItem(ctx, itemID, tagID)
+}
+
+// RemoveTagFromItem удаляет связь тега с элементом
+func RemoveTagFromItem(ctx context.Context, itemID, tagID int) error {
+	return defaultTags.RemoveTagFromItem(ctx, itemID, tagID)
+}
+
+// ReplaceItemTags заменяет все теги элемента на новые
+func ReplaceItemTags(ctx context.Context, itemID int, tagIDs []int) error {
+	return defaultTags.ReplaceItemTags(ctx, itemID, tagIDs)
+}
+
+// GetTagsForItem возвращает все теги элемента
+func GetTagsForItem(ctx context.Context, itemID int) ([]*models.Tag, error) {
+	return defaultTags.GetTagsForItem(ctx, itemID)
+}
+
+// GetItemsForTag возвращает все элементы тега
+func GetItemsForTag(ctx context.Context, tagID int) ([]*models.Item, error) {
+	return defaultTags.GetItemsForTag(ctx, tagID)
+}
+
+// GetTagsUsageCount возвращает количество использований каждого тега
+func GetTagsUsageCount(ctx context.Context) (map[int]int, error) {
+	return defaultTags.GetTagsUsageCount(ctx)
+}
+
+// BulkUpdateTags обновляет несколько тегов в одной транзакции
+func BulkUpdateTags(ctx context.Context, tags []*models.Tag) error {
+	return defaultTags.BulkUpdateTags(ctx, tags)
+}
+
+// CreateItemFile создаёт запись о файле элемента
+func CreateItemFile(file *models.ItemFile) error {
+	return defaultFiles.CreateItemFile(file)
+}
+
+// GetItemFile возвращает файл элемента по ID элемента
+func GetItemFile(itemID int) (*models.ItemFile, error) {
+	return defaultFiles.GetItemFile(itemID)
+}
+
+// GetFileByHash возвращает файл по хешу
+func GetFileByHash(hash string) (*models.ItemFile, error) {
+	return defaultFiles.GetFileByHash(hash)
+}
+
+// GetFilesByItemID возвращает все файлы элемента (может быть несколько)
+func GetFilesByItemID(itemID int) ([]*models.ItemFile, error) {
+	return defaultFiles.GetFilesByItemID(itemID)
+}
+
+// GetRemoteFilesByPeer возвращает все файлы от указанного пира
+func GetRemoteFilesByPeer(sourcePeerID string) ([]*models.ItemFile, error) {
+	return defaultFiles.GetRemoteFilesByPeer(sourcePeerID)
+}
+
+// UpdateItemFile обновляет информацию о файле
+func UpdateItemFile(file *models.ItemFile) error {
+	return defaultFiles.UpdateItemFile(file)
+}
+
+// DeleteItemFile удаляет файл элемента
+func DeleteItemFile(itemID int, hash string) error {
+	return defaultFiles.DeleteItemFile(itemID, hash)
+}
+
+// DeleteFilesByItemID удаляет все файлы элемента
+func DeleteFilesByItemID(itemID int) error {
+	return defaultFiles.DeleteFilesByItemID(itemID)
+}
+
+// DeleteRemoteFilesByPeer удаляет все файлы от указанного пира
+func DeleteRemoteFilesByPeer(sourcePeerID string) error {
+	return defaultFiles.DeleteRemoteFilesByPeer(sourcePeerID)
+}
+
+// ItemFileExists проверяет, существует ли файл с указанным хешем
+func ItemFileExists(hash string) (bool, error) {
+	return defaultFiles.ItemFileExists(hash)
+}
+
+// GetFileRecord возвращает запись реестра блобов по хэшу
+func GetFileRecord(hash string) (*models.File, error) {
+	return defaultFiles.GetFileRecord(hash)
+}
+
+// GetReferencedFileHashes возвращает хэши блобов, на которые есть ссылки (фаза mark сборщика мусора).
+// Помимо счётчика в files учитываются файловые блоки content_meta всех элементов, включая корзину:
+// блок мог не попасть в item_files, но файл элементу по-прежнему нужен.
+func GetReferencedFileHashes() (map[string]bool, error) {
+	return defaultFiles.GetReferencedFileHashes()
+}
+
+// GetRecentlyUnreferencedHashes возвращает хэши блобов, потерявших последнюю ссылку после since.
+// Такие блобы ещё в периоде ожидания и сборщиком мусора не удаляются.
+func GetRecentlyUnreferencedHashes(since time.Time) (map[string]bool, error) {
+	return defaultFiles.GetRecentlyUnreferencedHashes(since)
+}
+
+// DeleteUnreferencedFileRecords удаляет из реестра записи блобов, убранных с диска.
+// Записи, на которые успела появиться ссылка, сохраняются.
+func DeleteUnreferencedFileRecords(hashes []string) error {
+	return defaultFiles.DeleteUnreferencedFileRecords(hashes)
+}
+
+// GetContact получает контакт по ID с данными профиля из profiles
+func GetContact(id int) (*models.Contact, error) {
+	return defaultContacts.GetContact(id)
+}
+
+// GetContactByPeerID получает контакт по PeerID с данными профиля
+func GetContactByPeerID(peerID string) (*models.Contact, error) {
+	return defaultContacts.GetContactByPeerID(peerID)
+}
+
+// GetAllContacts получает все контакты с данными профилей
+func GetAllContacts() ([]*models.Contact, error) {
+	return defaultContacts.GetAllContacts()
+}
+
+// CreateContact создаёт новый контакт
+func CreateContact(contact *models.Contact) error {
+	return defaultContacts.CreateContact(contact)
+}
+
+// UpdateContact обновляет контакт
+func UpdateContact(contact *models.Contact) error {
+	return defaultContacts.UpdateContact(contact)
+}
+
+// UpdateContactLastSeen обновляет время последней активности контакта
+func UpdateContactLastSeen(id int, lastSeen *time.Time) error {
+	return defaultContacts.UpdateContactLastSeen(id, lastSeen)
+}
+
+// UpdateContactNotes обновляет заметки контакта
+func UpdateContactNotes(id int, notes string) error {
+	return defaultContacts.UpdateContactNotes(id, notes)
+}
+
+// UpdateContactMultiaddr обновляет адрес для подключения
+func UpdateContactMultiaddr(id int, multiaddr string) error {
+	return defaultContacts.UpdateContactMultiaddr(id, multiaddr)
+}
+
+// UpdateContactByPeerID обновляет multiaddr контакта по PeerID
+func UpdateContactByPeerID(peerID, multiaddr string) error {
+	return defaultContacts.UpdateContactByPeerID(peerID, multiaddr)
+}
+
+// DeleteContact удаляет контакт по ID
+func DeleteContact(id int) error {
+	return defaultContacts.DeleteContact(id)
+}
+
+// DeleteContactByPeerID удаляет контакт по PeerID
+func DeleteContactByPeerID(peerID string) error {
+	return defaultContacts.DeleteContactByPeerID(peerID)
+}
+
+// IsContactBlocked проверяет, заблокирован ли контакт
+func IsContactBlocked(peerID string) (bool, error) {
+	return defaultContacts.IsContactBlocked(peerID)
+}
+
+// SearchContacts ищет контакты по имени профиля
+func SearchContacts(query string) ([]*models.Contact, error) {
+	return defaultContacts.SearchContacts(query)
+}
+
+// BlockContact блокирует контакт
+func BlockContact(id int) error {
+	return defaultContacts.BlockContact(id)
+}
+
+// UnblockContact разблокирует контакт
+func UnblockContact(id int) error {
+	return defaultContacts.UnblockContact(id)
+}
+
+// GetChatMessage получает сообщение по ID
+func GetChatMessage(id int) (*models.ChatMessage, error) {
+	return defaultMessages.GetChatMessage(id)
+}
+
+// GetMessagesForContact получает все сообщения для контакта
+func GetMessagesForContact(contactID int, limit, offset int) ([]*models.ChatMessage, error) {
+	return defaultMessages.GetMessagesForContact(contactID, limit, offset)
+}
+
+// GetUnreadMessagesCount получает количество непрочитанных сообщений для контакта
+func GetUnreadMessagesCount(contactID int) (int, error) {
+	return defaultMessages.GetUnreadMessagesCount(contactID)
+}
+
+// CreateChatMessage создаёт новое сообщение
+func CreateChatMessage(message *models.ChatMessage) error {
+	return defaultMessages.CreateChatMessage(message)
+}
+
+// MarkMessageAsRead помечает сообщение как прочитанное
+func MarkMessageAsRead(id int) error {
+	return defaultMessages.MarkMessageAsRead(id)
+}
+
+// MarkAllMessagesAsRead помечает все сообщения для контакта как прочитанные
+func MarkAllMessagesAsRead(contactID int) error {
+	return defaultMessages.MarkAllMessagesAsRead(contactID)
+}
+
+// UpdateChatMessage обновляет сообщение
+func UpdateChatMessage(message *models.ChatMessage) error {
+	return defaultMessages.UpdateChatMessage(message)
+}
+
+// UpdateChatMessageMetadata обновляет метаданные сообщения
+func UpdateChatMessageMetadata(id int, metadata string) error {
+	return defaultMessages.UpdateChatMessageMetadata(id, metadata)
+}
+
+// GetFileMessagesForContact получает сообщения с файлами и изображениями для контакта
+func GetFileMessagesForContact(contactID int) ([]*models.ChatMessage, error) {
+	return defaultMessages.GetFileMessagesForContact(contactID)
+}
+
+// DeleteChatMessage удаляет сообщение по ID
+func DeleteChatMessage(id int) error {
+	return defaultMessages.DeleteChatMessage(id)
+}
+
+// DeleteMessagesForContact удаляет все сообщения для контакта
+func DeleteMessagesForContact(contactID int) error {
+	return defaultMessages.DeleteMessagesForContact(contactID)
+}
+
+// GetLastMessageForContact получает последнее сообщение для контакта
+func GetLastMessageForContact(contactID int) (*models.ChatMessage, error) {
+	return defaultMessages.GetLastMessageForContact(contactID)
+}
+
+// GetLocalProfile возвращает локальный профиль пользователя
+func GetLocalProfile() (*models.Profile, error) {
+	return defaultProfiles.GetLocalProfile()
+}
+
+// GetRemoteProfile возвращает чужой профиль по PeerID
+func GetRemoteProfile(peerID string) (*models.Profile, error) {
+	return defaultProfiles.GetRemoteProfile(peerID)
+}
+
+// EnsureProfileForContact создаёт профиль для контакта если он ещё не существует
+// Используется при добавлении нового контакта в contacts
+func EnsureProfileForContact(peerID, username, avatarPath string) error {
+	return defaultProfiles.EnsureProfileForContact(peerID, username, avatarPath)
+}
+
+// GetAllRemoteProfiles возвращает все чужие профили
+func GetAllRemoteProfiles() ([]*models.Profile, error) {
+	return defaultProfiles.GetAllRemoteProfiles()
+}
+
+// CreateRemoteProfile создаёт чужой профиль
+func CreateRemoteProfile(profile *models.Profile) error {
+	return defaultProfiles.CreateRemoteProfile(profile)
+}
+
+// UpdateRemoteProfile обновляет чужой профиль
+func UpdateRemoteProfile(profile *models.Profile) error {
+	return defaultProfiles.UpdateRemoteProfile(profile)
+}
+
+// UpdateLocalProfile обновляет локальный профиль
+func UpdateLocalProfile(profile *models.Profile) error {
+	return defaultProfiles.UpdateLocalProfile(profile)
+}
+
+// UpdateLocalProfileField обновляет конкретное поле локального профиля
+func UpdateLocalProfileField(field string, value interface{}) error {
+	return defaultProfiles.UpdateLocalProfileField(field, value)
+}
+
+// DeleteRemoteProfile удаляет чужой профиль по PeerID
+func DeleteRemoteProfile(peerID string) error {
+	return defaultProfiles.DeleteRemoteProfile(peerID)
+}
+
+// ProfileExists проверяет, существует ли профиль с указанным PeerID
+func ProfileExists(peerID string) (bool, error) {
+	return defaultProfiles.ProfileExists(peerID)
+}
+
+// GetProfileByPeerID возвращает профиль (локальный или чужой) по PeerID
+func GetProfileByPeerID(peerID string) (*models.Profile, error) {
+	return defaultProfiles.GetProfileByPeerID(peerID)
+}
+
+// SetSharingRule создаёт или обновляет правило доступа к папке или тегу
+func SetSharingRule(rule *models.SharingRule) error {
+	return defaultSharing.SetSharingRule(rule)
+}
+
+// GetSharingRule возвращает правило доступа к папке или тегу
+func GetSharingRule(entityType string, entityID int) (*models.SharingRule, error) {
+	return defaultSharing.GetSharingRule(entityType, entityID)
+}
+
+// DeleteSharingRule удаляет правило доступа к папке или тегу
+func DeleteSharingRule(entityType string, entityID int) error {
+	return defaultSharing.DeleteSharingRule(entityType, entityID)
+}
+
+// GetItemsSharedWithPeer возвращает элементы, к которым у пира есть доступ
+func GetItemsSharedWithPeer(peerID string) ([]*models.Item, error) {
+	return defaultSharing.GetItemsSharedWithPeer(peerID)
+}
+
+// IsItemSharedWithPeer проверяет, есть ли у пира доступ к элементу
+func IsItemSharedWithPeer(peerID string, itemID int) (bool, error) {
+	return defaultSharing.IsItemSharedWithPeer(peerID, itemID)
+}
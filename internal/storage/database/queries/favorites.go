@@ -59,7 +59,16 @@ func GetFavoriteTags() ([]*models.Tag, error) {
 	return favoritesService.GetFavoriteTags()
 }
 
-// GetAllFavorites возвращает все избранные элементы (и теги, и папки)
+// GetFavoriteSavedSearches возвращает все избранные сохранённые поиски
+func GetFavoriteSavedSearches() ([]*models.SavedSearch, error) {
+	// Создаем реализацию сервиса избранного
+	favoritesService := NewFavoritesServiceImpl()
+
+	// Вызываем метод получения избранных сохранённых поисков
+	return favoritesService.GetFavoriteSavedSearches()
+}
+
+// GetAllFavorites возвращает все избранные элементы (теги, папки и сохранённые поиски)
 func GetAllFavorites() ([]*models.Favorite, error) {
 	// Создаем реализацию сервиса избранного
 	favoritesService := NewFavoritesServiceImpl()
@@ -2,22 +2,26 @@ package queries
 
 import (
 	"database/sql"
-	"projectT/internal/storage/database"
 	"projectT/internal/storage/database/models"
 )
 
 // FavoritesServiceImpl реализует интерфейс FavoritesServiceInterface
-type FavoritesServiceImpl struct{}
+type FavoritesServiceImpl struct{ repo }
 
-// NewFavoritesServiceImpl создает новый экземпляр реализации сервиса избранного
+// NewFavoritesServiceImpl создает новый экземпляр реализации сервиса избранного над f.conn()
 func NewFavoritesServiceImpl() *FavoritesServiceImpl {
 	return &FavoritesServiceImpl{}
 }
 
+// NewFavoritesServiceImplWithDB создает реализацию сервиса избранного над db (*sql.DB или *sql.Tx)
+func NewFavoritesServiceImplWithDB(db DBTX) *FavoritesServiceImpl {
+	return &FavoritesServiceImpl{repo{db: db}}
+}
+
 // AddToFavorites добавляет элемент в избранное
 func (f *FavoritesServiceImpl) AddToFavorites(entityType string, entityID int) error {
 	query := `INSERT INTO favorites (entity_type, entity_id) VALUES (?, ?)`
-	result, err := database.DB.Exec(query, entityType, entityID)
+	result, err := f.conn().Exec(query, entityType, entityID)
 	if err != nil {
 		return err
 	}
@@ -28,7 +32,7 @@ func (f *FavoritesServiceImpl) AddToFavorites(entityType string, entityID int) e
 // RemoveFromFavorites удаляет элемент из избранного
 func (f *FavoritesServiceImpl) RemoveFromFavorites(entityType string, entityID int) error {
 	query := `DELETE FROM favorites WHERE entity_type = ? AND entity_id = ?`
-	result, err := database.DB.Exec(query, entityType, entityID)
+	result, err := f.conn().Exec(query, entityType, entityID)
 	if err != nil {
 		return err
 	}
@@ -40,7 +44,7 @@ func (f *FavoritesServiceImpl) RemoveFromFavorites(entityType string, entityID i
 func (f *FavoritesServiceImpl) IsFavorite(entityType string, entityID int) (bool, error) {
 	query := `SELECT 1 FROM favorites WHERE entity_type = ? AND entity_id = ?`
 	var exists int
-	err := database.DB.QueryRow(query, entityType, entityID).Scan(&exists)
+	err := f.conn().QueryRow(query, entityType, entityID).Scan(&exists)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return false, nil
@@ -56,9 +60,9 @@ func (f *FavoritesServiceImpl) GetFavoriteFolders() ([]*models.Item, error) {
 		SELECT i.id, i.type, i.title, i.description, i.content_meta, i.parent_id, i.created_at, i.updated_at
 		FROM items i
 		INNER JOIN favorites f ON i.id = f.entity_id
-		WHERE f.entity_type = 'folder'
+		WHERE f.entity_type = 'folder' AND i.trash_id IS NULL
 	`
-	rows, err := database.DB.Query(query)
+	rows, err := f.conn().Query(query)
 	if err != nil {
 		return nil, err
 	}
@@ -89,7 +93,7 @@ func (f *FavoritesServiceImpl) GetFavoriteTags() ([]*models.Tag, error) {
 		INNER JOIN favorites f ON t.id = f.entity_id
 		WHERE f.entity_type = 'tag'
 	`
-	rows, err := database.DB.Query(query)
+	rows, err := f.conn().Query(query)
 	if err != nil {
 		return nil, err
 	}
@@ -110,10 +114,21 @@ func (f *FavoritesServiceImpl) GetFavoriteTags() ([]*models.Tag, error) {
 	return tags, nil
 }
 
-// GetAllFavorites возвращает все избранные элементы (и теги, и папки)
+// GetFavoriteSavedSearches возвращает все избранные сохранённые поиски
+func (f *FavoritesServiceImpl) GetFavoriteSavedSearches() ([]*models.SavedSearch, error) {
+	return querySavedSearches(`
+		SELECT s.id, s.name, s.query, s.item_type, s.priority, s.sort_by, s.sort_order, s.created_at, s.updated_at
+		FROM saved_searches s
+		INNER JOIN favorites f ON s.id = f.entity_id
+		WHERE f.entity_type = 'saved_search'
+		ORDER BY f.id
+	`)
+}
+
+// GetAllFavorites возвращает все избранные элементы (теги, папки и сохранённые поиски)
 func (f *FavoritesServiceImpl) GetAllFavorites() ([]*models.Favorite, error) {
 	query := `SELECT id, entity_type, entity_id FROM favorites ORDER BY id`
-	rows, err := database.DB.Query(query)
+	rows, err := f.conn().Query(query)
 	if err != nil {
 		return nil, err
 	}
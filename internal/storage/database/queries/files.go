@@ -0,0 +1,115 @@
+package queries
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"projectT/internal/storage/database/models"
+)
+
+// ErrFileRecordNotFound блоб отсутствует в реестре files
+var ErrFileRecordNotFound = errors.New("запись о файле не найдена")
+
+// GetFileRecord возвращает запись реестра блобов по хэшу
+func (r *FilesRepo) GetFileRecord(hash string) (*models.File, error) {
+	var file models.File
+	var mimeType sql.NullString
+	var unreferencedAt sql.NullTime
+
+	err := r.conn().QueryRow(`
+		SELECT id, hash, size, mime_type, ref_count, created_at, unreferenced_at
+		FROM files
+		WHERE hash = ?
+	`, hash).Scan(&file.ID, &file.Hash, &file.Size, &mimeType, &file.RefCount, &file.CreatedAt, &unreferencedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrFileRecordNotFound
+		}
+		return nil, err
+	}
+
+	file.MimeType = mimeType.String
+	if unreferencedAt.Valid {
+		file.UnreferencedAt = &unreferencedAt.Time
+	}
+	return &file, nil
+}
+
+// GetReferencedFileHashes возвращает хэши блобов, на которые есть ссылки (фаза mark сборщика мусора).
+// Помимо счётчика в files учитываются файловые блоки content_meta всех элементов, включая корзину:
+// блок мог не попасть в item_files, но файл элементу по-прежнему нужен. Значки сайтов и изображения
+// превью ссылок есть только в блоках, поэтому учитываются так же, как и сохранённые копии страниц.
+func (r *FilesRepo) GetReferencedFileHashes() (map[string]bool, error) {
+	rows, err := r.conn().Query(`
+		SELECT hash FROM files WHERE ref_count > 0
+		UNION
+		SELECT CASE WHEN block.type = 'object' THEN json_extract(block.value, '$.' || field.name) END
+		FROM items,
+			json_each(CASE WHEN json_valid(items.content_meta) AND json_type(items.content_meta) = 'array'
+				THEN items.content_meta ELSE '[]' END) AS block,
+			(SELECT 'file_hash' AS name UNION ALL SELECT 'preview.favicon_hash' UNION ALL SELECT 'preview.image_hash'
+				UNION ALL SELECT 'snapshot.file_hash' UNION ALL SELECT 'snapshot.text_hash') AS field
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hashes := make(map[string]bool)
+	for rows.Next() {
+		var hash sql.NullString
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		if hash.Valid && hash.String != "" {
+			hashes[hash.String] = true
+		}
+	}
+	return hashes, rows.Err()
+}
+
+// GetRecentlyUnreferencedHashes возвращает хэши блобов, потерявших последнюю ссылку после since.
+// Такие блобы ещё в периоде ожидания и сборщиком мусора не удаляются.
+func (r *FilesRepo) GetRecentlyUnreferencedHashes(since time.Time) (map[string]bool, error) {
+	rows, err := r.conn().Query(`
+		SELECT hash FROM files
+		WHERE ref_count = 0 AND unreferenced_at > ?
+	`, since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hashes := make(map[string]bool)
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes[hash] = true
+	}
+	return hashes, rows.Err()
+}
+
+// DeleteUnreferencedFileRecords удаляет из реестра записи блобов, убранных с диска.
+// Записи, на которые успела появиться ссылка, сохраняются. Текст удалённых копий страниц
+// убирается из поиска вместе с блобом.
+func (r *FilesRepo) DeleteUnreferencedFileRecords(hashes []string) error {
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(hashes)), ",")
+	args := make([]interface{}, len(hashes))
+	for i, hash := range hashes {
+		args[i] = hash
+	}
+
+	if _, err := r.conn().Exec(`DELETE FROM page_texts WHERE hash IN (`+placeholders+`)`, args...); err != nil {
+		return err
+	}
+	_, err := r.conn().Exec(`DELETE FROM files WHERE ref_count = 0 AND hash IN (`+placeholders+`)`, args...)
+	return err
+}
@@ -0,0 +1,177 @@
+package queries
+
+import (
+	"testing"
+	"time"
+
+	"projectT/internal/storage/database/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fileRefCount возвращает счётчик ссылок блоба
+func fileRefCount(t *testing.T, hash string) int {
+	record, err := GetFileRecord(hash)
+	require.NoError(t, err)
+	return record.RefCount
+}
+
+// TestFileRefcountItemFiles проверяет, что общий блоб считается по числу ссылающихся элементов
+func TestFileRefcountItemFiles(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	first := createTrashTestItem(t, models.ItemTypeElement, "Первый", nil)
+	second := createTrashTestItem(t, models.ItemTypeElement, "Второй", nil)
+
+	_, err := GetFileRecord("shared")
+	assert.ErrorIs(t, err, ErrFileRecordNotFound)
+
+	require.NoError(t, CreateItemFile(&models.ItemFile{ItemID: first.ID, Hash: "shared", FilePath: "sh/shared.png", Size: 100}))
+	require.NoError(t, CreateItemFile(&models.ItemFile{ItemID: second.ID, Hash: "shared", FilePath: "sh/shared.png", Size: 100}))
+	assert.Equal(t, 2, fileRefCount(t, "shared"))
+
+	// Повторное сохранение той же записи не добавляет ссылку
+	require.NoError(t, CreateItemFile(&models.ItemFile{ItemID: first.ID, Hash: "shared", FilePath: "sh/shared.png", Size: 100}))
+	assert.Equal(t, 2, fileRefCount(t, "shared"))
+
+	// Чужие файлы хранятся отдельно и в счётчике не участвуют
+	require.NoError(t, CreateItemFile(&models.ItemFile{ItemID: 999, Hash: "shared", FilePath: "remote/shared.png", IsRemote: true, SourcePeerID: "peer"}))
+	assert.Equal(t, 2, fileRefCount(t, "shared"))
+
+	require.NoError(t, DeleteItemFile(first.ID, "shared"))
+	assert.Equal(t, 1, fileRefCount(t, "shared"))
+
+	// Удаление элемента отвязывает его файлы
+	require.NoError(t, DeleteItem(second.ID))
+	record, err := GetFileRecord("shared")
+	require.NoError(t, err)
+	assert.Zero(t, record.RefCount)
+	require.NotNil(t, record.UnreferencedAt)
+
+	referenced, err := GetReferencedFileHashes()
+	require.NoError(t, err)
+	assert.False(t, referenced["shared"])
+
+	recent, err := GetRecentlyUnreferencedHashes(time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.True(t, recent["shared"])
+
+	recent, err = GetRecentlyUnreferencedHashes(time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.False(t, recent["shared"])
+
+	// Новая ссылка снимает отметку об отвязке
+	third := createTrashTestItem(t, models.ItemTypeElement, "Третий", nil)
+	require.NoError(t, CreateItemFile(&models.ItemFile{ItemID: third.ID, Hash: "shared", FilePath: "sh/shared.png", Size: 100}))
+	record, err = GetFileRecord("shared")
+	require.NoError(t, err)
+	assert.Equal(t, 1, record.RefCount)
+	assert.Nil(t, record.UnreferencedAt)
+}
+
+// TestFileRefcountChatAttachments проверяет, что вложения чата удерживают блоб
+func TestFileRefcountChatAttachments(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	contact := &models.Contact{PeerID: "peer-files"}
+	require.NoError(t, CreateContact(contact))
+
+	message := &models.ChatMessage{
+		ContactID:   contact.ID,
+		FromPeerID:  "me",
+		Content:     "photo.png",
+		ContentType: "image",
+		Metadata:    `{"file_hash":"attached","file_name":"photo.png","size":42,"status":"sent"}`,
+	}
+	require.NoError(t, CreateChatMessage(message))
+	require.NoError(t, CreateChatMessage(&models.ChatMessage{ContactID: contact.ID, FromPeerID: "me", Content: "привет", ContentType: "text"}))
+	assert.Equal(t, 1, fileRefCount(t, "attached"))
+
+	record, err := GetFileRecord("attached")
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), record.Size)
+
+	// Смена статуса передачи не меняет счётчик
+	require.NoError(t, UpdateChatMessageMetadata(message.ID, `{"file_hash":"attached","file_name":"photo.png","size":42,"status":"downloaded"}`))
+	assert.Equal(t, 1, fileRefCount(t, "attached"))
+
+	require.NoError(t, DeleteChatMessage(message.ID))
+	assert.Zero(t, fileRefCount(t, "attached"))
+}
+
+// TestGetReferencedFileHashes проверяет фазу mark: счётчик, файловые блоки content_meta, файлы превью и копий страниц
+func TestGetReferencedFileHashes(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	item := createTrashTestItem(t, models.ItemTypeElement, "С файлами", nil)
+	require.NoError(t, CreateItemFile(&models.ItemFile{ItemID: item.ID, Hash: "linked", FilePath: "li/linked"}))
+
+	// Блок без записи в item_files тоже удерживает файл
+	meta := &models.Item{
+		Type:        models.ItemTypeElement,
+		Title:       "Только блоки",
+		ContentMeta: `[{"type":"image","file_hash":"from_meta"},{"type":"link","content":"https://example.com","preview":{"favicon_hash":"favicon","image_hash":"preview"},"snapshot":{"file_hash":"page","text_hash":"page_text"}}]`,
+	}
+	require.NoError(t, CreateItem(meta))
+	broken := &models.Item{Type: models.ItemTypeElement, Title: "Битый", ContentMeta: "не json"}
+	require.NoError(t, CreateItem(broken))
+
+	// Элемент в корзине по-прежнему ссылается на свои файлы
+	_, err := MoveItemToTrash(meta.ID)
+	require.NoError(t, err)
+
+	referenced, err := GetReferencedFileHashes()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"linked": true, "from_meta": true, "favicon": true, "preview": true, "page": true, "page_text": true}, referenced)
+}
+
+// TestDeleteUnreferencedFileRecords проверяет, что из реестра удаляются только записи без ссылок
+func TestDeleteUnreferencedFileRecords(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	item := createTrashTestItem(t, models.ItemTypeElement, "Элемент", nil)
+	require.NoError(t, CreateItemFile(&models.ItemFile{ItemID: item.ID, Hash: "kept", FilePath: "ke/kept"}))
+	require.NoError(t, CreateItemFile(&models.ItemFile{ItemID: item.ID, Hash: "gone", FilePath: "go/gone"}))
+	require.NoError(t, DeleteItemFile(item.ID, "gone"))
+
+	require.NoError(t, DeleteUnreferencedFileRecords(nil))
+	require.NoError(t, DeleteUnreferencedFileRecords([]string{"kept", "gone"}))
+
+	assert.Equal(t, 1, fileRefCount(t, "kept"))
+	_, err := GetFileRecord("gone")
+	assert.ErrorIs(t, err, ErrFileRecordNotFound)
+}
+
+// TestPageTexts проверяет текст копий страниц: индексацию недостающих и удаление вместе с блобом
+func TestPageTexts(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+	repo := NewFilesRepo(nil)
+
+	item := &models.Item{
+		Type:        models.ItemTypeElement,
+		Title:       "Копии",
+		ContentMeta: `[{"type":"link","content":"https://a.example","snapshot":{"file_hash":"a","text_hash":"a_text"}},{"type":"link","content":"https://b.example","snapshot":{"file_hash":"b","text_hash":"b_text"}},{"type":"link","content":"https://c.example"}]`,
+	}
+	require.NoError(t, CreateItem(item))
+
+	require.NoError(t, repo.SavePageText("a_text", "первая"))
+	require.NoError(t, repo.SavePageText("a_text", "не перезаписывается"))
+	unindexed, err := repo.GetUnindexedPageTexts()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b_text"}, unindexed)
+
+	var text string
+	require.NoError(t, repo.conn().QueryRow(`SELECT text FROM page_texts WHERE hash = 'a_text'`).Scan(&text))
+	assert.Equal(t, "первая", text)
+
+	require.NoError(t, repo.DeleteUnreferencedFileRecords([]string{"a_text"}))
+	unindexed, err = repo.GetUnindexedPageTexts()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a_text", "b_text"}, unindexed)
+}
@@ -0,0 +1,137 @@
+package queries
+
+import (
+	"database/sql"
+
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/database/models"
+)
+
+// fileActualRefCount подсчитывает фактические ссылки на блоб files.hash:
+// локальные item_files, файлы ревизий и файловые сообщения чата (те же правила, что у триггеров files)
+const fileActualRefCount = `
+	(SELECT COUNT(*) FROM item_files WHERE item_files.is_remote = 0 AND item_files.hash = files.hash) +
+	(SELECT COUNT(*) FROM item_revision_files WHERE item_revision_files.hash = files.hash) +
+	(SELECT COUNT(*) FROM chat_messages
+		WHERE chat_messages.content_type IN ('file', 'image') AND json_valid(chat_messages.metadata)
+			AND json_extract(chat_messages.metadata, '$.file_hash') = files.hash)`
+
+// FileRefCount расхождение счётчика ссылок блоба с фактическим числом ссылок
+type FileRefCount struct {
+	Hash   string
+	Stored int
+	Actual int
+}
+
+// GetAllItemsIncludingTrashed возвращает все элементы, включая находящиеся в корзине.
+// Нужен проверке целостности: дерево папок проверяется целиком.
+func GetAllItemsIncludingTrashed() ([]*models.Item, error) {
+	rows, err := database.GetDB().Query(`
+		SELECT id, type, COALESCE(title, ''), COALESCE(description, ''), COALESCE(content_meta, ''),
+			parent_id, COALESCE(content_hash, ''), created_at, updated_at
+		FROM items
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*models.Item
+	for rows.Next() {
+		var item models.Item
+		var parentID sql.NullInt64
+
+		if err := rows.Scan(
+			&item.ID, &item.Type, &item.Title, &item.Description, &item.ContentMeta,
+			&parentID, &item.ContentHash, &item.CreatedAt, &item.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		if parentID.Valid {
+			id := int(parentID.Int64)
+			item.ParentID = &id
+		}
+		items = append(items, &item)
+	}
+
+	return items, rows.Err()
+}
+
+// GetLocalItemFiles возвращает все записи о локальных файлах элементов
+func GetLocalItemFiles() ([]*models.ItemFile, error) {
+	rows, err := database.GetDB().Query(`
+		SELECT item_id, hash, file_path, COALESCE(size, 0), COALESCE(mime_type, '')
+		FROM item_files
+		WHERE is_remote = 0
+		ORDER BY item_id, hash
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []*models.ItemFile
+	for rows.Next() {
+		var file models.ItemFile
+		if err := rows.Scan(&file.ItemID, &file.Hash, &file.FilePath, &file.Size, &file.MimeType); err != nil {
+			return nil, err
+		}
+		files = append(files, &file)
+	}
+
+	return files, rows.Err()
+}
+
+// UpdateItemContentHash сохраняет пересчитанный хэш содержимого элемента
+func UpdateItemContentHash(itemID int, contentHash string) error {
+	_, err := database.GetDB().Exec(`UPDATE items SET content_hash = ? WHERE id = ?`, contentHash, itemID)
+	return err
+}
+
+// UpdateItemParent переносит элемент в другую папку (nil - в корень), не меняя остальных полей
+func UpdateItemParent(itemID int, parentID *int) error {
+	_, err := database.GetDB().Exec(`UPDATE items SET parent_id = ? WHERE id = ?`, parentID, itemID)
+	return err
+}
+
+// GetFileRefCountMismatches возвращает блобы, у которых счётчик ссылок расходится с фактическим
+func GetFileRefCountMismatches() ([]FileRefCount, error) {
+	rows, err := database.GetDB().Query(`
+		SELECT hash, stored, actual FROM (
+			SELECT hash, ref_count AS stored, ` + fileActualRefCount + ` AS actual
+			FROM files
+		)
+		WHERE stored != actual
+		ORDER BY hash
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mismatches []FileRefCount
+	for rows.Next() {
+		var mismatch FileRefCount
+		if err := rows.Scan(&mismatch.Hash, &mismatch.Stored, &mismatch.Actual); err != nil {
+			return nil, err
+		}
+		mismatches = append(mismatches, mismatch)
+	}
+
+	return mismatches, rows.Err()
+}
+
+// RecountFileReferences пересчитывает счётчики ссылок всех блобов
+func RecountFileReferences() error {
+	_, err := database.GetDB().Exec(`
+		UPDATE files SET
+			ref_count = ` + fileActualRefCount + `,
+			unreferenced_at = CASE
+				WHEN ` + fileActualRefCount + ` > 0 THEN NULL
+				ELSE COALESCE(unreferenced_at, CURRENT_TIMESTAMP)
+			END
+	`)
+	return err
+}
@@ -5,12 +5,11 @@ import (
 	"database/sql"
 	"errors"
 
-	"projectT/internal/storage/database"
 	"projectT/internal/storage/database/models"
 )
 
 // CreateItemFile создаёт запись о файле элемента
-func CreateItemFile(file *models.ItemFile) error {
+func (r *FilesRepo) CreateItemFile(file *models.ItemFile) error {
 	query := `
 		INSERT INTO item_files (item_id, hash, file_path, size, mime_type, is_remote, source_peer_id)
 		VALUES (?, ?, ?, ?, ?, ?, ?)
@@ -21,7 +20,7 @@ func CreateItemFile(file *models.ItemFile) error {
 			is_remote = excluded.is_remote,
 			source_peer_id = excluded.source_peer_id
 	`
-	_, err := database.DB.Exec(query,
+	_, err := r.conn().Exec(query,
 		file.ItemID, file.Hash, file.FilePath, file.Size, file.MimeType,
 		file.IsRemote, file.SourcePeerID,
 	)
@@ -29,7 +28,7 @@ func CreateItemFile(file *models.ItemFile) error {
 }
 
 // GetItemFile возвращает файл элемента по ID элемента
-func GetItemFile(itemID int) (*models.ItemFile, error) {
+func (r *FilesRepo) GetItemFile(itemID int) (*models.ItemFile, error) {
 	query := `
 		SELECT item_id, hash, file_path, size, mime_type, is_remote, source_peer_id
 		FROM item_files
@@ -39,7 +38,7 @@ func GetItemFile(itemID int) (*models.ItemFile, error) {
 	var file models.ItemFile
 	var sourcePeerID sql.NullString
 
-	err := database.DB.QueryRow(query, itemID).Scan(
+	err := r.conn().QueryRow(query, itemID).Scan(
 		&file.ItemID, &file.Hash, &file.FilePath, &file.Size,
 		&file.MimeType, &file.IsRemote, &sourcePeerID,
 	)
@@ -58,7 +57,7 @@ func GetItemFile(itemID int) (*models.ItemFile, error) {
 }
 
 // GetFileByHash возвращает файл по хешу
-func GetFileByHash(hash string) (*models.ItemFile, error) {
+func (r *FilesRepo) GetFileByHash(hash string) (*models.ItemFile, error) {
 	query := `
 		SELECT item_id, hash, file_path, size, mime_type, is_remote, source_peer_id
 		FROM item_files
@@ -68,7 +67,7 @@ func GetFileByHash(hash string) (*models.ItemFile, error) {
 	var file models.ItemFile
 	var sourcePeerID sql.NullString
 
-	err := database.DB.QueryRow(query, hash).Scan(
+	err := r.conn().QueryRow(query, hash).Scan(
 		&file.ItemID, &file.Hash, &file.FilePath, &file.Size,
 		&file.MimeType, &file.IsRemote, &sourcePeerID,
 	)
@@ -87,13 +86,13 @@ func GetFileByHash(hash string) (*models.ItemFile, error) {
 }
 
 // GetFilesByItemID возвращает все файлы элемента (может быть несколько)
-func GetFilesByItemID(itemID int) ([]*models.ItemFile, error) {
+func (r *FilesRepo) GetFilesByItemID(itemID int) ([]*models.ItemFile, error) {
 	query := `
 		SELECT item_id, hash, file_path, size, mime_type, is_remote, source_peer_id
 		FROM item_files
 		WHERE item_id = ?
 	`
-	rows, err := database.DB.Query(query, itemID)
+	rows, err := r.conn().Query(query, itemID)
 	if err != nil {
 		return nil, err
 	}
@@ -123,13 +122,13 @@ func GetFilesByItemID(itemID int) ([]*models.ItemFile, error) {
 }
 
 // GetRemoteFilesByPeer возвращает все файлы от указанного пира
-func GetRemoteFilesByPeer(sourcePeerID string) ([]*models.ItemFile, error) {
+func (r *FilesRepo) GetRemoteFilesByPeer(sourcePeerID string) ([]*models.ItemFile, error) {
 	query := `
 		SELECT item_id, hash, file_path, size, mime_type, is_remote, source_peer_id
 		FROM item_files
 		WHERE source_peer_id = ? AND is_remote = 1
 	`
-	rows, err := database.DB.Query(query, sourcePeerID)
+	rows, err := r.conn().Query(query, sourcePeerID)
 	if err != nil {
 		return nil, err
 	}
@@ -159,13 +158,13 @@ func GetRemoteFilesByPeer(sourcePeerID string) ([]*models.ItemFile, error) {
 }
 
 // UpdateItemFile обновляет информацию о файле
-func UpdateItemFile(file *models.ItemFile) error {
+func (r *FilesRepo) UpdateItemFile(file *models.ItemFile) error {
 	query := `
 		UPDATE item_files 
 		SET file_path = ?, size = ?, mime_type = ?, is_remote = ?, source_peer_id = ?
 		WHERE item_id = ? AND hash = ?
 	`
-	_, err := database.DB.Exec(query,
+	_, err := r.conn().Exec(query,
 		file.FilePath, file.Size, file.MimeType, file.IsRemote,
 		file.SourcePeerID, file.ItemID, file.Hash,
 	)
@@ -173,26 +172,26 @@ func UpdateItemFile(file *models.ItemFile) error {
 }
 
 // DeleteItemFile удаляет файл элемента
-func DeleteItemFile(itemID int, hash string) error {
-	_, err := database.DB.Exec(`DELETE FROM item_files WHERE item_id = ? AND hash = ?`, itemID, hash)
+func (r *FilesRepo) DeleteItemFile(itemID int, hash string) error {
+	_, err := r.conn().Exec(`DELETE FROM item_files WHERE item_id = ? AND hash = ?`, itemID, hash)
 	return err
 }
 
 // DeleteFilesByItemID удаляет все файлы элемента
-func DeleteFilesByItemID(itemID int) error {
-	_, err := database.DB.Exec(`DELETE FROM item_files WHERE item_id = ?`, itemID)
+func (r *FilesRepo) DeleteFilesByItemID(itemID int) error {
+	_, err := r.conn().Exec(`DELETE FROM item_files WHERE item_id = ?`, itemID)
 	return err
 }
 
 // DeleteRemoteFilesByPeer удаляет все файлы от указанного пира
-func DeleteRemoteFilesByPeer(sourcePeerID string) error {
-	_, err := database.DB.Exec(`DELETE FROM item_files WHERE source_peer_id = ?`, sourcePeerID)
+func (r *FilesRepo) DeleteRemoteFilesByPeer(sourcePeerID string) error {
+	_, err := r.conn().Exec(`DELETE FROM item_files WHERE source_peer_id = ?`, sourcePeerID)
 	return err
 }
 
 // ItemFileExists проверяет, существует ли файл с указанным хешем
-func ItemFileExists(hash string) (bool, error) {
+func (r *FilesRepo) ItemFileExists(hash string) (bool, error) {
 	var exists bool
-	err := database.DB.QueryRow(`SELECT COUNT(*) > 0 FROM item_files WHERE hash = ?`, hash).Scan(&exists)
+	err := r.conn().QueryRow(`SELECT COUNT(*) > 0 FROM item_files WHERE hash = ?`, hash).Scan(&exists)
 	return exists, err
 }
@@ -0,0 +1,220 @@
+package queries
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"projectT/internal/storage/database/models"
+)
+
+// ItemListOptions фильтр и порядок выборки элементов для сетки
+type ItemListOptions struct {
+	ParentID  int    // Папка, элементы которой выбираются; 0 - корень
+	AllItems  bool   // Выбрать элементы всех папок, ParentID не учитывается
+	ItemType  string // Тип элемента: "all", "folders", "images", "files", "links", "text"
+	Priority  string // Какие элементы идут первыми: "none", "folders_first", "images_first", "files_first", "links_first", "text_first"
+	SortBy    string // Ключ сортировки: "name", "created_date", "modified_date", "content_size"
+	SortOrder string // Порядок: "asc", "desc"
+}
+
+// ItemCursor позиция последнего элемента страницы, после которой начинается следующая
+type ItemCursor struct {
+	rank int
+	key  interface{}
+	id   int
+}
+
+// ErrInvalidItemCursor строка курсора повреждена или получена не от ItemCursor.String
+var ErrInvalidItemCursor = errors.New("неверный курсор страницы")
+
+// itemCursorJSON представление курсора для передачи клиентам API
+type itemCursorJSON struct {
+	Rank int         `json:"r"`
+	Key  interface{} `json:"k"`
+	ID   int         `json:"i"`
+}
+
+// String возвращает курсор в виде непрозрачной строки для передачи вне процесса
+func (c *ItemCursor) String() string {
+	data, _ := json.Marshal(itemCursorJSON{Rank: c.rank, Key: c.key, ID: c.id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ParseItemCursor восстанавливает курсор из строки ItemCursor.String
+func ParseItemCursor(s string) (*ItemCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidItemCursor
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	var raw itemCursorJSON
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, ErrInvalidItemCursor
+	}
+
+	cursor := &ItemCursor{rank: raw.Rank, id: raw.ID}
+	switch key := raw.Key.(type) {
+	case string:
+		cursor.key = key
+	case json.Number:
+		size, err := key.Int64()
+		if err != nil {
+			return nil, ErrInvalidItemCursor
+		}
+		cursor.key = size
+	default:
+		return nil, ErrInvalidItemCursor
+	}
+	return cursor, nil
+}
+
+// ItemPage страница элементов; Next равен nil, если страница последняя
+type ItemPage struct {
+	Items []*models.Item
+	Next  *ItemCursor
+}
+
+// itemSortKey выражение для ORDER BY и значение ключа для курсора.
+// Даты читаются как текст, чтобы курсор сравнивался с тем, что хранится в базе, без преобразований.
+type itemSortKey struct {
+	order string
+	value string
+}
+
+// itemContentSizeExpr суммарный размер файлов элемента
+const itemContentSizeExpr = "(SELECT COALESCE(SUM(f.size), 0) FROM item_files f WHERE f.item_id = items.id)"
+
+// itemSortKeys ключи сортировки по SortBy. Названия сравниваются без учета регистра только для латиницы (COLLATE NOCASE).
+var itemSortKeys = map[string]itemSortKey{
+	"name":          {order: "items.title COLLATE NOCASE", value: "items.title"},
+	"created_date":  {order: "items.created_at", value: "CAST(items.created_at AS TEXT)"},
+	"modified_date": {order: "items.updated_at", value: "CAST(items.updated_at AS TEXT)"},
+	"content_size":  {order: itemContentSizeExpr, value: itemContentSizeExpr},
+}
+
+// itemTypeConditions условия на тип элемента. Вид элемента определяется по наличию слова в content_meta,
+// текст - элемент без content_meta, но с описанием.
+var itemTypeConditions = map[string]string{
+	"folders": `items.type = 'folder'`,
+	"images":  `items.type = 'element' AND instr(lower(items.content_meta), 'image') > 0`,
+	"files":   `items.type = 'element' AND instr(lower(items.content_meta), 'file') > 0`,
+	"links":   `items.type = 'element' AND instr(lower(items.content_meta), 'link') > 0`,
+	"text":    `items.type = 'element' AND COALESCE(items.content_meta, '') = '' AND COALESCE(items.description, '') <> ''`,
+}
+
+// itemTypeCondition возвращает условие фильтра по типу; для "all" условия нет,
+// а неизвестный тип оставляет только элементы
+func itemTypeCondition(itemType string) string {
+	if itemType == "" || itemType == "all" {
+		return ""
+	}
+	if cond, ok := itemTypeConditions[itemType]; ok {
+		return cond
+	}
+	return `items.type = 'element'`
+}
+
+// itemRankExpr возвращает выражение ранга: 0 для элементов, которые по приоритету идут первыми, 1 для остальных
+func itemRankExpr(priority string) string {
+	cond, ok := itemTypeConditions[strings.TrimSuffix(priority, "_first")]
+	if !ok || !strings.HasSuffix(priority, "_first") {
+		return "0"
+	}
+	return "CASE WHEN " + cond + " THEN 0 ELSE 1 END"
+}
+
+// ListItems возвращает страницу элементов (не из корзины) после курсора after, отфильтрованных и упорядоченных по opts.
+// Элементы идут по рангу приоритета, затем по ключу сортировки и ID, поэтому страницы не пересекаются
+// и не пропускают элементы с одинаковым ключом. after равен nil для первой страницы, limit <= 0 - без ограничения.
+func (r *ItemsRepo) ListItems(opts ItemListOptions, after *ItemCursor, limit int) (*ItemPage, error) {
+	sortKey, ok := itemSortKeys[opts.SortBy]
+	if !ok {
+		sortKey = itemSortKeys["name"]
+	}
+	direction, cmp := "ASC", ">"
+	if opts.SortOrder == "desc" {
+		direction, cmp = "DESC", "<"
+	}
+	rank := itemRankExpr(opts.Priority)
+
+	conditions := []string{"items.trash_id IS NULL"}
+	var args []interface{}
+	if !opts.AllItems {
+		if opts.ParentID == 0 {
+			conditions = append(conditions, "(items.parent_id = 0 OR items.parent_id IS NULL)")
+		} else {
+			conditions = append(conditions, "items.parent_id = ?")
+			args = append(args, opts.ParentID)
+		}
+	}
+	if cond := itemTypeCondition(opts.ItemType); cond != "" {
+		conditions = append(conditions, "("+cond+")")
+	}
+	if after != nil {
+		conditions = append(conditions, "("+rank+" > ? OR ("+rank+" = ? AND ("+
+			sortKey.order+" "+cmp+" ? OR ("+sortKey.order+" = ? AND items.id "+cmp+" ?))))")
+		args = append(args, after.rank, after.rank, after.key, after.key, after.id)
+	}
+
+	// Лишний элемент показывает, есть ли следующая страница
+	fetch := -1
+	if limit > 0 {
+		fetch = limit + 1
+	}
+	args = append(args, fetch)
+
+	// Постоянный ранг в ORDER BY был бы принят за номер столбца
+	order := sortKey.order + ` ` + direction + `, items.id ` + direction
+	if rank != "0" {
+		order = rank + `, ` + order
+	}
+
+	query := `
+		SELECT items.id, items.type, items.title, items.description, items.content_meta, items.parent_id,
+			items.content_hash, items.created_at, items.updated_at, ` + rank + `, ` + sortKey.value + `
+		FROM items
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY ` + order + `
+		LIMIT ?`
+	rows, err := r.conn().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := &ItemPage{}
+	var last ItemCursor
+	for rows.Next() {
+		if limit > 0 && len(page.Items) == limit {
+			page.Next = &last
+			break
+		}
+
+		var item models.Item
+		var parentID sql.NullInt64
+		err := rows.Scan(
+			&item.ID, &item.Type, &item.Title, &item.Description, &item.ContentMeta, &parentID, &item.ContentHash, &item.CreatedAt, &item.UpdatedAt,
+			&last.rank, &last.key,
+		)
+		if err != nil {
+			return nil, err
+		}
+		last.id = item.ID
+
+		if parentID.Valid {
+			parentIDValue := int(parentID.Int64)
+			item.ParentID = &parentIDValue
+		}
+
+		page.Items = append(page.Items, &item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
@@ -0,0 +1,114 @@
+package queries
+
+import (
+	"testing"
+	"time"
+
+	"projectT/internal/storage/database/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createListItem создает элемент с заданными полями и временем изменения
+func createListItem(t *testing.T, item *models.Item, updatedAt time.Time) *models.Item {
+	require.NoError(t, CreateItem(item))
+	require.NoError(t, UpdateItemTimestamps(item.ID, updatedAt, updatedAt))
+	return item
+}
+
+// listTitles собирает все страницы выборки и возвращает названия элементов по порядку.
+// Курсор каждый раз передаётся через строку, как его передают клиенты API.
+func listTitles(t *testing.T, opts ItemListOptions, limit int) []string {
+	var titles []string
+	var after *ItemCursor
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 100, "страницы не должны повторяться")
+		page, err := defaultItems.ListItems(opts, after, limit)
+		require.NoError(t, err)
+		if limit > 0 {
+			assert.LessOrEqual(t, len(page.Items), limit)
+		}
+		for _, item := range page.Items {
+			titles = append(titles, item.Title)
+		}
+		if page.Next == nil {
+			return titles
+		}
+		after, err = ParseItemCursor(page.Next.String())
+		require.NoError(t, err)
+	}
+}
+
+// TestListItemsSortAndPages проверяет, что постраничная выборка совпадает с выборкой без ограничения
+// при любом ключе и порядке сортировки, в том числе при одинаковых ключах
+func TestListItemsSortAndPages(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	folder := createListItem(t, &models.Item{Type: models.ItemTypeFolder, Title: "папка"}, base)
+	createListItem(t, &models.Item{Type: models.ItemTypeElement, Title: "Beta"}, base.Add(2*time.Hour))
+	createListItem(t, &models.Item{Type: models.ItemTypeElement, Title: "alpha"}, base.Add(time.Hour))
+	createListItem(t, &models.Item{Type: models.ItemTypeElement, Title: "gamma"}, base.Add(time.Hour))
+	createListItem(t, &models.Item{Type: models.ItemTypeElement, Title: "Delta"}, base.Add(3*time.Hour))
+	createListItem(t, &models.Item{Type: models.ItemTypeElement, Title: "внутри", ParentID: &folder.ID}, base)
+
+	byName := ItemListOptions{SortBy: "name", SortOrder: "asc"}
+	assert.Equal(t, []string{"alpha", "Beta", "Delta", "gamma", "папка"}, listTitles(t, byName, 0))
+	assert.Equal(t, []string{"alpha", "Beta", "Delta", "gamma", "папка"}, listTitles(t, byName, 2))
+
+	byName.SortOrder = "desc"
+	assert.Equal(t, []string{"папка", "gamma", "Delta", "Beta", "alpha"}, listTitles(t, byName, 2))
+
+	for _, order := range []string{"asc", "desc"} {
+		byModified := ItemListOptions{SortBy: "modified_date", SortOrder: order}
+		assert.Equal(t, listTitles(t, byModified, 0), listTitles(t, byModified, 1), order)
+	}
+	assert.Equal(t, []string{"Delta", "Beta"}, listTitles(t, ItemListOptions{SortBy: "modified_date", SortOrder: "desc"}, 0)[:2])
+
+	inFolder := ItemListOptions{ParentID: folder.ID, SortBy: "name"}
+	assert.Equal(t, []string{"внутри"}, listTitles(t, inFolder, 2))
+	all := ItemListOptions{AllItems: true, ParentID: folder.ID, SortBy: "name"}
+	assert.Len(t, listTitles(t, all, 2), 6)
+}
+
+// TestListItemsFilterAndPriority проверяет фильтр по типу, приоритет, сортировку по размеру файлов и исключение корзины
+func TestListItemsFilterAndPriority(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	createListItem(t, &models.Item{Type: models.ItemTypeFolder, Title: "zz папка"}, now)
+	image := createListItem(t, &models.Item{Type: models.ItemTypeElement, Title: "image", ContentMeta: `[{"type":"image"}]`}, now)
+	file := createListItem(t, &models.Item{Type: models.ItemTypeElement, Title: "file", ContentMeta: `[{"type":"file"}]`}, now)
+	createListItem(t, &models.Item{Type: models.ItemTypeElement, Title: "text", Description: "заметка"}, now)
+	deleted := createListItem(t, &models.Item{Type: models.ItemTypeElement, Title: "deleted", Description: "удалена"}, now)
+	_, err := MoveItemToTrash(deleted.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, CreateItemFile(&models.ItemFile{ItemID: image.ID, Hash: "h1", FilePath: "a", Size: 100}))
+	require.NoError(t, CreateItemFile(&models.ItemFile{ItemID: file.ID, Hash: "h2", FilePath: "b", Size: 70}))
+	require.NoError(t, CreateItemFile(&models.ItemFile{ItemID: file.ID, Hash: "h3", FilePath: "c", Size: 50}))
+
+	assert.Equal(t, []string{"zz папка"}, listTitles(t, ItemListOptions{ItemType: "folders"}, 0))
+	assert.Equal(t, []string{"image"}, listTitles(t, ItemListOptions{ItemType: "images"}, 0))
+	assert.Equal(t, []string{"text"}, listTitles(t, ItemListOptions{ItemType: "text"}, 0))
+
+	foldersFirst := ItemListOptions{Priority: "folders_first", SortBy: "name"}
+	assert.Equal(t, []string{"zz папка", "file", "image", "text"}, listTitles(t, foldersFirst, 1))
+	// Приоритетные элементы остаются первыми и при обратном порядке
+	foldersFirst.SortOrder = "desc"
+	assert.Equal(t, []string{"zz папка", "text", "image", "file"}, listTitles(t, foldersFirst, 1))
+
+	bySize := ItemListOptions{ItemType: "all", SortBy: "content_size", SortOrder: "desc"}
+	assert.Equal(t, []string{"file", "image"}, listTitles(t, bySize, 1)[:2])
+}
+
+// TestParseItemCursorInvalid проверяет, что повреждённый курсор отклоняется
+func TestParseItemCursorInvalid(t *testing.T) {
+	for _, s := range []string{"", "не base64", "eyJyIjowfQ"} {
+		_, err := ParseItemCursor(s)
+		assert.ErrorIs(t, err, ErrInvalidItemCursor, s)
+	}
+}
@@ -0,0 +1,83 @@
+package queries
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/database/models"
+)
+
+// GetItemSyncState возвращает состояние синхронизации библиотеки пира.
+// Если пир ещё не синхронизировался, возвращается пустое состояние.
+func GetItemSyncState(peerID string) (*models.ItemSyncState, error) {
+	state := &models.ItemSyncState{PeerID: peerID}
+
+	var lastSynced sql.NullTime
+	err := database.GetDB().QueryRow(`
+		SELECT subscribed, cursor, last_synced_at
+		FROM item_sync_state
+		WHERE peer_id = ?
+	`, peerID).Scan(&state.Subscribed, &state.Cursor, &lastSynced)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return state, nil
+		}
+		return nil, err
+	}
+
+	if lastSynced.Valid {
+		state.LastSyncedAt = &lastSynced.Time
+	}
+
+	return state, nil
+}
+
+// SetItemSubscription включает или выключает подписку на библиотеку пира
+func SetItemSubscription(peerID string, subscribed bool) error {
+	_, err := database.GetDB().Exec(`
+		INSERT INTO item_sync_state (peer_id, subscribed)
+		VALUES (?, ?)
+		ON CONFLICT(peer_id) DO UPDATE SET subscribed = excluded.subscribed
+	`, peerID, subscribed)
+	return err
+}
+
+// UpdateItemSyncCursor сохраняет курсор после успешной синхронизации
+func UpdateItemSyncCursor(peerID string, cursor int64) error {
+	_, err := database.GetDB().Exec(`
+		INSERT INTO item_sync_state (peer_id, cursor, last_synced_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(peer_id) DO UPDATE SET
+			cursor = excluded.cursor,
+			last_synced_at = excluded.last_synced_at
+	`, peerID, cursor, time.Now())
+	return err
+}
+
+// ResetItemSyncCursor сбрасывает курсор, чтобы следующая синхронизация была полной
+func ResetItemSyncCursor(peerID string) error {
+	_, err := database.GetDB().Exec(`UPDATE item_sync_state SET cursor = 0 WHERE peer_id = ?`, peerID)
+	return err
+}
+
+// GetSubscribedPeers возвращает PeerID контактов с подпиской на библиотеку
+func GetSubscribedPeers() ([]string, error) {
+	rows, err := database.GetDB().Query(`SELECT peer_id FROM item_sync_state WHERE subscribed = 1 ORDER BY peer_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var peers []string
+	for rows.Next() {
+		var peerID string
+		if err := rows.Scan(&peerID); err != nil {
+			return nil, err
+		}
+		peers = append(peers, peerID)
+	}
+
+	return peers, rows.Err()
+}
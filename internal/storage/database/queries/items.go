@@ -1,20 +1,20 @@
 package queries
 
 import (
+	"context"
 	"database/sql"
 	"errors"
-	"projectT/internal/storage/database"
 	"projectT/internal/storage/database/models"
 	"time"
 )
 
 // CreateItem создает новый элемент
-func CreateItem(item *models.Item) error {
+func (r *ItemsRepo) CreateItem(item *models.Item) error {
 	query := `
 		INSERT INTO items (type, title, description, content_meta, parent_id, content_hash, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`
-	result, err := database.DB.Exec(query, item.Type, item.Title, item.Description, item.ContentMeta, item.ParentID, item.ContentHash, time.Now(), time.Now())
+	result, err := r.conn().Exec(query, item.Type, item.Title, item.Description, item.ContentMeta, item.ParentID, item.ContentHash, time.Now(), time.Now())
 	if err != nil {
 		return err
 	}
@@ -30,16 +30,16 @@ func CreateItem(item *models.Item) error {
 	return nil
 }
 
-// GetItemByID возвращает элемент по ID
-func GetItemByID(id int) (*models.Item, error) {
+// GetItemByID возвращает элемент по ID. Элемент в корзине не возвращается: sql.ErrNoRows
+func (r *ItemsRepo) GetItemByID(id int) (*models.Item, error) {
 	query := `
 		SELECT id, type, title, description, content_meta, parent_id, content_hash, created_at, updated_at
 		FROM items
-	WHERE id = ?
+		WHERE id = ? AND trash_id IS NULL
 	`
 	var item models.Item
 	var parentID sql.NullInt64
-	err := database.DB.QueryRow(query, id).Scan(
+	err := r.conn().QueryRow(query, id).Scan(
 		&item.ID, &item.Type, &item.Title, &item.Description, &item.ContentMeta, &parentID, &item.ContentHash, &item.CreatedAt, &item.UpdatedAt,
 	)
 	if err != nil {
@@ -55,15 +55,15 @@ func GetItemByID(id int) (*models.Item, error) {
 }
 
 // GetItemByHash возвращает элемент по хешу содержимого
-func GetItemByHash(contentHash string) (*models.Item, error) {
+func (r *ItemsRepo) GetItemByHash(contentHash string) (*models.Item, error) {
 	query := `
 		SELECT id, type, title, description, content_meta, parent_id, content_hash, created_at, updated_at
 		FROM items
-	WHERE content_hash = ?
+	WHERE content_hash = ? AND trash_id IS NULL
 	`
 	var item models.Item
 	var parentID sql.NullInt64
-	err := database.DB.QueryRow(query, contentHash).Scan(
+	err := r.conn().QueryRow(query, contentHash).Scan(
 		&item.ID, &item.Type, &item.Title, &item.Description, &item.ContentMeta, &parentID, &item.ContentHash, &item.CreatedAt, &item.UpdatedAt,
 	)
 	if err != nil {
@@ -82,7 +82,7 @@ func GetItemByHash(contentHash string) (*models.Item, error) {
 }
 
 // GetItemsByParent возвращает элементы по родительскому ID
-func GetItemsByParent(parentID int) ([]*models.Item, error) {
+func (r *ItemsRepo) GetItemsByParent(parentID int) ([]*models.Item, error) {
 	var query string
 	var rows *sql.Rows
 	var err error
@@ -92,19 +92,19 @@ func GetItemsByParent(parentID int) ([]*models.Item, error) {
 		query = `
 			SELECT id, type, title, description, content_meta, parent_id, content_hash, created_at, updated_at
 			FROM items
-			WHERE parent_id = 0 OR parent_id IS NULL
+			WHERE (parent_id = 0 OR parent_id IS NULL) AND trash_id IS NULL
 			ORDER BY updated_at DESC
 		`
-		rows, err = database.DB.Query(query)
+		rows, err = r.conn().Query(query)
 	} else {
 		// Для конкретной папки
 		query = `
 			SELECT id, type, title, description, content_meta, parent_id, content_hash, created_at, updated_at
 			FROM items
-			WHERE parent_id = ?
+			WHERE parent_id = ? AND trash_id IS NULL
 			ORDER BY updated_at DESC
 		`
-		rows, err = database.DB.Query(query, parentID)
+		rows, err = r.conn().Query(query, parentID)
 	}
 
 	if err != nil {
@@ -135,9 +135,9 @@ func GetItemsByParent(parentID int) ([]*models.Item, error) {
 }
 
 // GetAllItems возвращает все элементы из базы данных
-func GetAllItems() ([]*models.Item, error) {
-	db := database.DB
-	query := `SELECT id, type, title, description, content_meta, parent_id, content_hash, created_at, updated_at FROM items ORDER BY created_at DESC`
+func (r *ItemsRepo) GetAllItems() ([]*models.Item, error) {
+	db := r.conn()
+	query := `SELECT id, type, title, description, content_meta, parent_id, content_hash, created_at, updated_at FROM items WHERE trash_id IS NULL ORDER BY created_at DESC`
 	rows, err := db.Query(query)
 	if err != nil {
 		return nil, err
@@ -173,61 +173,139 @@ func GetAllItems() ([]*models.Item, error) {
 }
 
 // PinItem закрепляет элемент
-func PinItem(itemID int) error {
+func (r *ItemsRepo) PinItem(itemID int) error {
 	query := `INSERT INTO pinned_items (item_id) VALUES (?)`
-	_, err := database.DB.Exec(query, itemID)
+	_, err := r.conn().Exec(query, itemID)
 	return err
 }
 
 // UnpinItem открепляет элемент
-func UnpinItem(itemID int) error {
+func (r *ItemsRepo) UnpinItem(itemID int) error {
 	query := `DELETE FROM pinned_items WHERE item_id = ?`
-	_, err := database.DB.Exec(query, itemID)
+	_, err := r.conn().Exec(query, itemID)
 	return err
 }
 
 // IsItemPinned проверяет, закреплен ли элемент
-func IsItemPinned(itemID int) (bool, error) {
+func (r *ItemsRepo) IsItemPinned(itemID int) (bool, error) {
 	var count int
 	query := `SELECT COUNT(*) FROM pinned_items WHERE item_id = ?`
-	err := database.DB.QueryRow(query, itemID).Scan(&count)
+	err := r.conn().QueryRow(query, itemID).Scan(&count)
 	return count > 0, err
 }
 
+// BeginTransaction начинает транзакцию на соединении репозитория, например чтобы сохранить ревизию
+// и изменить элемент атомарно. Репозиторий, уже работающий внутри транзакции, новую начать не может.
+func (r *ItemsRepo) BeginTransaction(ctx context.Context) (*sql.Tx, error) {
+	db, ok := r.conn().(interface {
+		BeginTx(context.Context, *sql.TxOptions) (*sql.Tx, error)
+	})
+	if !ok {
+		return nil, errors.New("репозиторий уже работает внутри транзакции")
+	}
+	return db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+		ReadOnly:  false,
+	})
+}
+
 // UpdateItem обновляет элемент
-func UpdateItem(item *models.Item) error {
+func (r *ItemsRepo) UpdateItem(item *models.Item) error {
 	query := `
 	UPDATE items
 	SET type = ?, title = ?, description = ?, content_meta = ?, parent_id = ?, content_hash = ?, updated_at = ?
 	WHERE id = ?
 	`
-	_, err := database.DB.Exec(query, item.Type, item.Title, item.Description, item.ContentMeta, item.ParentID, item.ContentHash, time.Now(), item.ID)
+	_, err := r.conn().Exec(query, item.Type, item.Title, item.Description, item.ContentMeta, item.ParentID, item.ContentHash, time.Now(), item.ID)
 	return err
 }
 
 // DeleteItem удаляет элемент по ID
-func DeleteItem(id int) error {
+func (r *ItemsRepo) DeleteItem(id int) error {
 	query := `DELETE FROM items WHERE id = ?`
-	_, err := database.DB.Exec(query, id)
+	if _, err := r.conn().Exec(query, id); err != nil {
+		return err
+	}
+
+	// Правило доступа папки не должно достаться новому элементу с тем же ID
+	return deleteSharingRule(r.conn(), models.SharingEntityFolder, id)
+}
+
+// GetItemSubtree возвращает элемент rootID и все вложенные в него элементы, кроме находящихся в корзине.
+// Родители идут раньше вложенных элементов.
+func (r *ItemsRepo) GetItemSubtree(rootID int) ([]*models.Item, error) {
+	rows, err := r.conn().Query(`
+		WITH RECURSIVE subtree(id, depth) AS (
+			SELECT id, 0 FROM items WHERE id = ? AND trash_id IS NULL
+			UNION
+			SELECT c.id, s.depth + 1 FROM items c
+			JOIN subtree s ON c.parent_id = s.id
+			WHERE c.trash_id IS NULL
+		)
+		SELECT i.id, i.type, i.title, i.description, i.content_meta, i.parent_id, COALESCE(i.content_hash, ''), i.created_at, i.updated_at
+		FROM items i
+		JOIN subtree s ON s.id = i.id
+		ORDER BY s.depth, i.id
+	`, rootID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*models.Item
+	for rows.Next() {
+		var item models.Item
+		var parentID sql.NullInt64
+		err := rows.Scan(
+			&item.ID, &item.Type, &item.Title, &item.Description, &item.ContentMeta, &parentID, &item.ContentHash, &item.CreatedAt, &item.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if parentID.Valid {
+			parentIDValue := int(parentID.Int64)
+			item.ParentID = &parentIDValue
+		}
+
+		items = append(items, &item)
+	}
+
+	return items, rows.Err()
+}
+
+// UpdateItemTimestamps задаёт время создания и изменения элемента (например, при импорте)
+func (r *ItemsRepo) UpdateItemTimestamps(id int, createdAt, updatedAt time.Time) error {
+	_, err := r.conn().Exec(`UPDATE items SET created_at = ?, updated_at = ? WHERE id = ?`, createdAt, updatedAt, id)
 	return err
 }
 
-// SearchItems выполняет поиск элементов по названию или тегам
-func SearchItems(query string) ([]*models.Item, error) {
-	// Подготавливаем параметры для поиска
-	searchPattern := "%" + query + "%"
-
-	// SQL-запрос для поиска по названию и через связь с тегами
-	sqlQuery := `
-	SELECT DISTINCT i.id, i.type, i.title, i.description, i.content_meta, i.parent_id, i.content_hash, i.created_at, i.updated_at
-	FROM items i
-	LEFT JOIN item_tags it ON i.id = it.item_id
-	LEFT JOIN tags t ON it.tag_id = t.id
-	WHERE i.title LIKE ? OR i.description LIKE ? OR t.name LIKE ?
-	ORDER BY i.updated_at DESC
-	`
+// UpdateItemContentMeta заменяет content_meta и content_hash элемента, если content_meta не изменился
+// с момента чтения (oldMeta). Время изменения не обновляется: так сохраняются данные, вычисленные
+// по содержимому (например, превью ссылок), а не правки пользователя. Возвращает false, если
+// элемент успели изменить или удалить.
+func (r *ItemsRepo) UpdateItemContentMeta(id int, oldMeta, newMeta, contentHash string) (bool, error) {
+	result, err := r.conn().Exec(`
+		UPDATE items SET content_meta = ?, content_hash = ?
+		WHERE id = ? AND COALESCE(content_meta, '') = ?
+	`, newMeta, contentHash, id, oldMeta)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
 
-	rows, err := database.DB.Query(sqlQuery, searchPattern, searchPattern, searchPattern)
+// GetItemsWithLinks возвращает элементы вне корзины, в content_meta которых есть блоки ссылок
+func (r *ItemsRepo) GetItemsWithLinks() ([]*models.Item, error) {
+	rows, err := r.conn().Query(`
+		SELECT id, type, title, description, content_meta, parent_id, content_hash, created_at, updated_at
+		FROM items
+		WHERE trash_id IS NULL AND json_valid(content_meta) AND json_type(content_meta) = 'array'
+			AND EXISTS (SELECT 1 FROM json_each(items.content_meta) AS block
+				WHERE block.type = 'object' AND json_extract(block.value, '$.type') = 'link')
+		ORDER BY id
+	`)
 	if err != nil {
 		return nil, err
 	}
@@ -237,7 +315,6 @@ func SearchItems(query string) ([]*models.Item, error) {
 	for rows.Next() {
 		var item models.Item
 		var parentID sql.NullInt64
-
 		err := rows.Scan(
 			&item.ID, &item.Type, &item.Title, &item.Description, &item.ContentMeta, &parentID, &item.ContentHash, &item.CreatedAt, &item.UpdatedAt,
 		)
@@ -253,5 +330,5 @@ func SearchItems(query string) ([]*models.Item, error) {
 		items = append(items, &item)
 	}
 
-	return items, nil
+	return items, rows.Err()
 }
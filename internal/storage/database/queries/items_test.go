@@ -23,8 +23,8 @@ func setupTestDB(t *testing.T) func() {
 	require.NoError(t, err)
 
 	// Сохраняем оригинальную БД
-	originalDB := database.DB
-	database.DB = db
+	originalDB := database.GetDB()
+	database.SetDB(db)
 
 	// Запускаем миграции
 	database.RunMigrations()
@@ -32,7 +32,7 @@ func setupTestDB(t *testing.T) func() {
 	// Возвращаем функцию очистки
 	return func() {
 		database.CloseDB()
-		database.DB = originalDB
+		database.SetDB(originalDB)
 	}
 }
 
@@ -222,6 +222,58 @@ func TestUpdateItem(t *testing.T) {
 	assert.True(t, dbItem.UpdatedAt.After(item.CreatedAt))
 }
 
+// TestUpdateItemContentMeta проверяет, что content_meta заменяется, только если не изменился с момента чтения
+func TestUpdateItemContentMeta(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	item := &models.Item{Type: models.ItemTypeElement, Title: "Ссылка", ContentMeta: `[{"type":"link","content":"https://example.com"}]`}
+	require.NoError(t, CreateItem(item))
+	created, err := GetItemByID(item.ID)
+	require.NoError(t, err)
+
+	repo := NewItemsRepo(nil)
+	updated, err := repo.UpdateItemContentMeta(item.ID, item.ContentMeta, `[{"type":"link","content":"https://example.com","preview":{}}]`, "new-hash")
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	dbItem, err := GetItemByID(item.ID)
+	require.NoError(t, err)
+	assert.Contains(t, dbItem.ContentMeta, "preview")
+	assert.Equal(t, "new-hash", dbItem.ContentHash)
+	assert.Equal(t, created.UpdatedAt, dbItem.UpdatedAt)
+
+	// Содержимое уже изменилось: устаревшая версия не перезаписывает его
+	updated, err = repo.UpdateItemContentMeta(item.ID, item.ContentMeta, "[]", "stale-hash")
+	require.NoError(t, err)
+	assert.False(t, updated)
+}
+
+// TestGetItemsWithLinks проверяет выбор элементов с блоками ссылок
+func TestGetItemsWithLinks(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	withLink := &models.Item{Type: models.ItemTypeElement, Title: "Ссылка", ContentMeta: `[{"type":"image","file_hash":"a"},{"type":"link","content":"https://example.com"}]`}
+	require.NoError(t, CreateItem(withLink))
+	for _, item := range []*models.Item{
+		{Type: models.ItemTypeElement, Title: "Изображение", ContentMeta: `[{"type":"image","file_hash":"a"}]`},
+		{Type: models.ItemTypeElement, Title: "Текст", Description: "https://example.com"},
+		{Type: models.ItemTypeElement, Title: "Битый", ContentMeta: "не json"},
+	} {
+		require.NoError(t, CreateItem(item))
+	}
+	trashed := &models.Item{Type: models.ItemTypeElement, Title: "В корзине", ContentMeta: `[{"type":"link","content":"https://example.org"}]`}
+	require.NoError(t, CreateItem(trashed))
+	_, err := MoveItemToTrash(trashed.ID)
+	require.NoError(t, err)
+
+	items, err := NewItemsRepo(nil).GetItemsWithLinks()
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, withLink.ID, items[0].ID)
+}
+
 // TestUpdateItem_NotFound проверяет обновление несуществующего элемента
 func TestUpdateItem_NotFound(t *testing.T) {
 	cleanup := setupTestDB(t)
@@ -7,13 +7,13 @@ import (
 	"fmt"
 	"time"
 
-	"projectT/internal/storage/database"
 	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/vault"
 )
 
 // GetChatMessage получает сообщение по ID
-func GetChatMessage(id int) (*models.ChatMessage, error) {
-	row := database.DB.QueryRow(`
+func (r *MessagesRepo) GetChatMessage(id int) (*models.ChatMessage, error) {
+	row := r.conn().QueryRow(`
 		SELECT id, contact_id, from_peer_id, content, content_type, metadata, is_read, sent_at, COALESCE(updated_at, sent_at)
 		FROM chat_messages
 		WHERE id = ?
@@ -44,6 +44,7 @@ func GetChatMessage(id int) (*models.ChatMessage, error) {
 	if metadata.Valid {
 		message.Metadata = metadata.String
 	}
+	message.Content = vault.DecryptField(message.Content)
 	message.SentAt, _ = parseTime(sentAt)
 	message.UpdatedAt, _ = parseTime(updatedAt)
 
@@ -51,8 +52,8 @@ func GetChatMessage(id int) (*models.ChatMessage, error) {
 }
 
 // GetMessagesForContact получает все сообщения для контакта
-func GetMessagesForContact(contactID int, limit, offset int) ([]*models.ChatMessage, error) {
-	rows, err := database.DB.Query(`
+func (r *MessagesRepo) GetMessagesForContact(contactID int, limit, offset int) ([]*models.ChatMessage, error) {
+	rows, err := r.conn().Query(`
 		SELECT id, contact_id, from_peer_id, content, content_type, metadata, is_read, sent_at, COALESCE(updated_at, sent_at)
 		FROM chat_messages
 		WHERE contact_id = ?
@@ -88,6 +89,7 @@ func GetMessagesForContact(contactID int, limit, offset int) ([]*models.ChatMess
 		if metadata.Valid {
 			message.Metadata = metadata.String
 		}
+		message.Content = vault.DecryptField(message.Content)
 
 		// Пробуем распарсить в формате RFC3339, затем в SQL формате
 		message.SentAt, _ = parseTime(sentAt)
@@ -124,9 +126,9 @@ func parseTime(timeStr string) (time.Time, error) {
 }
 
 // GetUnreadMessagesCount получает количество непрочитанных сообщений для контакта
-func GetUnreadMessagesCount(contactID int) (int, error) {
+func (r *MessagesRepo) GetUnreadMessagesCount(contactID int) (int, error) {
 	var count int
-	err := database.DB.QueryRow(`
+	err := r.conn().QueryRow(`
 		SELECT COUNT(*) FROM chat_messages
 		WHERE contact_id = ? AND is_read = 0
 	`, contactID).Scan(&count)
@@ -134,11 +136,15 @@ func GetUnreadMessagesCount(contactID int) (int, error) {
 }
 
 // CreateChatMessage создаёт новое сообщение
-func CreateChatMessage(message *models.ChatMessage) error {
-	result, err := database.DB.Exec(`
+func (r *MessagesRepo) CreateChatMessage(message *models.ChatMessage) error {
+	content, err := vault.EncryptField(message.Content)
+	if err != nil {
+		return err
+	}
+	result, err := r.conn().Exec(`
 		INSERT INTO chat_messages (contact_id, from_peer_id, content, content_type, metadata, is_read, sent_at)
 		VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
-	`, message.ContactID, message.FromPeerID, message.Content, message.ContentType, message.Metadata, message.IsRead)
+	`, message.ContactID, message.FromPeerID, content, message.ContentType, message.Metadata, message.IsRead)
 	if err != nil {
 		return err
 	}
@@ -152,8 +158,8 @@ func CreateChatMessage(message *models.ChatMessage) error {
 }
 
 // MarkMessageAsRead помечает сообщение как прочитанное
-func MarkMessageAsRead(id int) error {
-	_, err := database.DB.Exec(`
+func (r *MessagesRepo) MarkMessageAsRead(id int) error {
+	_, err := r.conn().Exec(`
 		UPDATE chat_messages
 		SET is_read = 1
 		WHERE id = ?
@@ -162,8 +168,8 @@ func MarkMessageAsRead(id int) error {
 }
 
 // MarkAllMessagesAsRead помечает все сообщения для контакта как прочитанные
-func MarkAllMessagesAsRead(contactID int) error {
-	_, err := database.DB.Exec(`
+func (r *MessagesRepo) MarkAllMessagesAsRead(contactID int) error {
+	_, err := r.conn().Exec(`
 		UPDATE chat_messages
 		SET is_read = 1
 		WHERE contact_id = ? AND is_read = 0
@@ -172,30 +178,90 @@ func MarkAllMessagesAsRead(contactID int) error {
 }
 
 // UpdateChatMessage обновляет сообщение
-func UpdateChatMessage(message *models.ChatMessage) error {
-	_, err := database.DB.Exec(`
+func (r *MessagesRepo) UpdateChatMessage(message *models.ChatMessage) error {
+	content, err := sealedColumn(message.Content)
+	if err != nil {
+		return err
+	}
+	_, err = r.conn().Exec(`
 		UPDATE chat_messages
-		SET content = ?, content_type = ?, metadata = ?, is_read = ?, updated_at = CURRENT_TIMESTAMP
+		SET content = COALESCE(?, content), content_type = ?, metadata = ?, is_read = ?, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
-	`, message.Content, message.ContentType, message.Metadata, message.IsRead, message.ID)
+	`, content, message.ContentType, message.Metadata, message.IsRead, message.ID)
 	return err
 }
 
+// UpdateChatMessageMetadata обновляет метаданные сообщения
+func (r *MessagesRepo) UpdateChatMessageMetadata(id int, metadata string) error {
+	_, err := r.conn().Exec(`
+		UPDATE chat_messages
+		SET metadata = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, metadata, id)
+	return err
+}
+
+// GetFileMessagesForContact получает сообщения с файлами и изображениями для контакта
+func (r *MessagesRepo) GetFileMessagesForContact(contactID int) ([]*models.ChatMessage, error) {
+	rows, err := r.conn().Query(`
+		SELECT id, contact_id, from_peer_id, content, content_type, metadata, is_read, sent_at, COALESCE(updated_at, sent_at)
+		FROM chat_messages
+		WHERE contact_id = ? AND content_type IN ('file', 'image')
+		ORDER BY sent_at ASC
+	`, contactID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*models.ChatMessage
+	for rows.Next() {
+		message := &models.ChatMessage{}
+		var metadata sql.NullString
+		var sentAt, updatedAt string
+
+		if err := rows.Scan(
+			&message.ID,
+			&message.ContactID,
+			&message.FromPeerID,
+			&message.Content,
+			&message.ContentType,
+			&metadata,
+			&message.IsRead,
+			&sentAt,
+			&updatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		if metadata.Valid {
+			message.Metadata = metadata.String
+		}
+		message.Content = vault.DecryptField(message.Content)
+		message.SentAt, _ = parseTime(sentAt)
+		message.UpdatedAt, _ = parseTime(updatedAt)
+
+		messages = append(messages, message)
+	}
+
+	return messages, rows.Err()
+}
+
 // DeleteChatMessage удаляет сообщение по ID
-func DeleteChatMessage(id int) error {
-	_, err := database.DB.Exec(`DELETE FROM chat_messages WHERE id = ?`, id)
+func (r *MessagesRepo) DeleteChatMessage(id int) error {
+	_, err := r.conn().Exec(`DELETE FROM chat_messages WHERE id = ?`, id)
 	return err
 }
 
 // DeleteMessagesForContact удаляет все сообщения для контакта
-func DeleteMessagesForContact(contactID int) error {
-	_, err := database.DB.Exec(`DELETE FROM chat_messages WHERE contact_id = ?`, contactID)
+func (r *MessagesRepo) DeleteMessagesForContact(contactID int) error {
+	_, err := r.conn().Exec(`DELETE FROM chat_messages WHERE contact_id = ?`, contactID)
 	return err
 }
 
 // GetLastMessageForContact получает последнее сообщение для контакта
-func GetLastMessageForContact(contactID int) (*models.ChatMessage, error) {
-	row := database.DB.QueryRow(`
+func (r *MessagesRepo) GetLastMessageForContact(contactID int) (*models.ChatMessage, error) {
+	row := r.conn().QueryRow(`
 		SELECT id, contact_id, from_peer_id, content, content_type, metadata, is_read, sent_at, COALESCE(updated_at, sent_at)
 		FROM chat_messages
 		WHERE contact_id = ?
@@ -228,6 +294,7 @@ func GetLastMessageForContact(contactID int) (*models.ChatMessage, error) {
 	if metadata.Valid {
 		message.Metadata = metadata.String
 	}
+	message.Content = vault.DecryptField(message.Content)
 	message.SentAt, _ = time.Parse("2006-01-02 15:04:05", sentAt)
 	message.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAt)
 
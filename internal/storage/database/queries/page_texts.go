@@ -0,0 +1,40 @@
+package queries
+
+import (
+	"database/sql"
+)
+
+// SavePageText сохраняет для поиска текст копии страницы под хэшем блоба с этим текстом.
+// Блоб адресуется содержимым, поэтому существующий текст не перезаписывается.
+func (r *FilesRepo) SavePageText(hash, text string) error {
+	_, err := r.conn().Exec(`INSERT OR IGNORE INTO page_texts (hash, text) VALUES (?, ?)`, hash, text)
+	return err
+}
+
+// GetUnindexedPageTexts возвращает хэши блобов с текстом копий страниц, на которые ссылаются блоки
+// элементов, но текста которых нет в поиске (например, элемент пришёл при синхронизации или из архива)
+func (r *FilesRepo) GetUnindexedPageTexts() ([]string, error) {
+	rows, err := r.conn().Query(`
+		SELECT DISTINCT json_extract(block.value, '$.snapshot.text_hash')
+		FROM items,
+			json_each(CASE WHEN json_valid(items.content_meta) AND json_type(items.content_meta) = 'array'
+				THEN items.content_meta ELSE '[]' END) AS block
+		WHERE block.type = 'object'
+			AND COALESCE(json_extract(block.value, '$.snapshot.text_hash'), '') != ''
+			AND json_extract(block.value, '$.snapshot.text_hash') NOT IN (SELECT hash FROM page_texts)
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var hash sql.NullString
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash.String)
+	}
+	return hashes, rows.Err()
+}
@@ -57,7 +57,7 @@ func CreateP2PProfile(profile *models.P2PProfile) error {
 		INSERT INTO profiles (owner_type, peer_id, username, title, created_at, updated_at)
 		VALUES ('local', ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 	`
-	result, err := database.DB.Exec(query, localProfile.PeerID, localProfile.Username, localProfile.Title)
+	result, err := database.GetDB().Exec(query, localProfile.PeerID, localProfile.Username, localProfile.Title)
 	if err != nil {
 		return err
 	}
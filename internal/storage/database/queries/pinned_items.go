@@ -11,9 +11,10 @@ func GetPinnedItems() ([]*models.Item, error) {
 		SELECT i.id, i.type, i.title, i.description, i.content_meta, i.parent_id, i.created_at, i.updated_at
 		FROM items i
 		INNER JOIN pinned_items pi ON i.id = pi.item_id
+		WHERE i.trash_id IS NULL
 	`
 
-	rows, err := database.DB.Query(query)
+	rows, err := database.GetDB().Query(query)
 	if err != nil {
 		return nil, err
 	}
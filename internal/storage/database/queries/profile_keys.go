@@ -19,7 +19,7 @@ func GetProfileKeys(profileID int) (*models.ProfileKey, error) {
 	`
 	var keys models.ProfileKey
 
-	err := database.DB.QueryRow(query, profileID).Scan(
+	err := database.GetDB().QueryRow(query, profileID).Scan(
 		&keys.ProfileID,
 		&keys.PrivateKey,
 		&keys.PublicKey,
@@ -42,7 +42,7 @@ func CreateProfileKeys(keys *models.ProfileKey) error {
 		INSERT INTO profile_keys (profile_id, private_key, public_key, signature, is_key_encrypted)
 		VALUES (?, ?, ?, ?, ?)
 	`
-	_, err := database.DB.Exec(query,
+	_, err := database.GetDB().Exec(query,
 		keys.ProfileID, keys.PrivateKey, keys.PublicKey, keys.Signature, keys.IsKeyEncrypted,
 	)
 	return err
@@ -55,7 +55,7 @@ func UpdateProfileKeys(keys *models.ProfileKey) error {
 		SET private_key = ?, public_key = ?, signature = ?, is_key_encrypted = ?
 		WHERE profile_id = ?
 	`
-	_, err := database.DB.Exec(query,
+	_, err := database.GetDB().Exec(query,
 		keys.PrivateKey, keys.PublicKey, keys.Signature, keys.IsKeyEncrypted, keys.ProfileID,
 	)
 	return err
@@ -75,27 +75,27 @@ func UpdateProfileKeyField(profileID int, field string, value interface{}) error
 	}
 
 	query := `UPDATE profile_keys SET ` + field + ` = ? WHERE profile_id = ?`
-	_, err := database.DB.Exec(query, value, profileID)
+	_, err := database.GetDB().Exec(query, value, profileID)
 	return err
 }
 
 // DeleteProfileKeys удаляет ключи профиля
 func DeleteProfileKeys(profileID int) error {
-	_, err := database.DB.Exec(`DELETE FROM profile_keys WHERE profile_id = ?`, profileID)
+	_, err := database.GetDB().Exec(`DELETE FROM profile_keys WHERE profile_id = ?`, profileID)
 	return err
 }
 
 // ProfileKeysExists проверяет, существуют ли ключи у профиля
 func ProfileKeysExists(profileID int) (bool, error) {
 	var exists bool
-	err := database.DB.QueryRow(`SELECT COUNT(*) > 0 FROM profile_keys WHERE profile_id = ?`, profileID).Scan(&exists)
+	err := database.GetDB().QueryRow(`SELECT COUNT(*) > 0 FROM profile_keys WHERE profile_id = ?`, profileID).Scan(&exists)
 	return exists, err
 }
 
 // IsProfileKeyEncrypted проверяет, зашифрован ли приватный ключ профиля
 func IsProfileKeyEncrypted(profileID int) (bool, error) {
 	var isEncrypted bool
-	err := database.DB.QueryRow(`SELECT is_key_encrypted FROM profile_keys WHERE profile_id = ?`, profileID).Scan(&isEncrypted)
+	err := database.GetDB().QueryRow(`SELECT is_key_encrypted FROM profile_keys WHERE profile_id = ?`, profileID).Scan(&isEncrypted)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return false, errors.New("ключи профиля не найдены")
@@ -107,7 +107,7 @@ func IsProfileKeyEncrypted(profileID int) (bool, error) {
 
 // UpdateProfileEncryptionStatus обновляет статус шифрования приватного ключа
 func UpdateProfileEncryptionStatus(profileID int, isEncrypted bool) error {
-	_, err := database.DB.Exec(`
+	_, err := database.GetDB().Exec(`
 		UPDATE profile_keys
 		SET is_key_encrypted = ?
 		WHERE profile_id = ?
@@ -6,12 +6,11 @@ import (
 	"errors"
 	"time"
 
-	"projectT/internal/storage/database"
 	"projectT/internal/storage/database/models"
 )
 
 // GetLocalProfile возвращает локальный профиль пользователя
-func GetLocalProfile() (*models.Profile, error) {
+func (r *ProfilesRepo) GetLocalProfile() (*models.Profile, error) {
 	query := `
 		SELECT id, owner_type, peer_id, username, title,
 		       COALESCE(avatar_path, ''),
@@ -27,7 +26,7 @@ func GetLocalProfile() (*models.Profile, error) {
 	var cachedAt sql.NullString
 	var createdAt, updatedAt string
 
-	err := database.DB.QueryRow(query).Scan(
+	err := r.conn().QueryRow(query).Scan(
 		&profile.ID, &profile.OwnerType, &profile.PeerID, &profile.Username,
 		&profile.Title, &profile.AvatarPath, &profile.BackgroundPath,
 		&profile.ContentChar, &profile.DemoElements, &cachedAt,
@@ -47,7 +46,7 @@ func GetLocalProfile() (*models.Profile, error) {
 }
 
 // GetRemoteProfile возвращает чужой профиль по PeerID
-func GetRemoteProfile(peerID string) (*models.Profile, error) {
+func (r *ProfilesRepo) GetRemoteProfile(peerID string) (*models.Profile, error) {
 	query := `
 		SELECT id, owner_type, peer_id, username, title,
 		       COALESCE(avatar_path, ''),
@@ -63,7 +62,7 @@ func GetRemoteProfile(peerID string) (*models.Profile, error) {
 	var cachedAt sql.NullString
 	var createdAt, updatedAt string
 
-	err := database.DB.QueryRow(query).Scan(
+	err := r.conn().QueryRow(query, peerID).Scan(
 		&profile.ID, &profile.OwnerType, &profile.PeerID, &profile.Username,
 		&profile.Title, &profile.AvatarPath, &profile.BackgroundPath,
 		&profile.ContentChar, &profile.DemoElements, &cachedAt,
@@ -88,10 +87,10 @@ func GetRemoteProfile(peerID string) (*models.Profile, error) {
 
 // EnsureProfileForContact создаёт профиль для контакта если он ещё не существует
 // Используется при добавлении нового контакта в contacts
-func EnsureProfileForContact(peerID, username, avatarPath string) error {
+func (r *ProfilesRepo) EnsureProfileForContact(peerID, username, avatarPath string) error {
 	// Проверяем, существует ли уже профиль
 	exists := false
-	err := database.DB.QueryRow(`
+	err := r.conn().QueryRow(`
 		SELECT EXISTS(SELECT 1 FROM profiles WHERE peer_id = ?)
 	`, peerID).Scan(&exists)
 	if err != nil {
@@ -104,18 +103,18 @@ func EnsureProfileForContact(peerID, username, avatarPath string) error {
 	}
 
 	// Создаём новый remote профиль
-	_, err = database.DB.Exec(`
+	_, err = r.conn().Exec(`
 		INSERT INTO profiles (owner_type, peer_id, username, title, avatar_path,
 		                      background_path, content_char, demo_elements,
 		                      created_at, updated_at)
 		VALUES ('remote', ?, ?, ?, ?, '', '', '', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
-	`, peerID, username, avatarPath)
+	`, peerID, username, "", avatarPath)
 
 	return err
 }
 
 // GetAllRemoteProfiles возвращает все чужие профили
-func GetAllRemoteProfiles() ([]*models.Profile, error) {
+func (r *ProfilesRepo) GetAllRemoteProfiles() ([]*models.Profile, error) {
 	query := `
 		SELECT id, owner_type, peer_id, username, title, avatar_path, background_path,
 		       content_char, demo_elements, cached_at, created_at, updated_at
@@ -123,7 +122,7 @@ func GetAllRemoteProfiles() ([]*models.Profile, error) {
 		WHERE owner_type = 'remote'
 		ORDER BY username
 	`
-	rows, err := database.DB.Query(query)
+	rows, err := r.conn().Query(query)
 	if err != nil {
 		return nil, err
 	}
@@ -159,13 +158,13 @@ func GetAllRemoteProfiles() ([]*models.Profile, error) {
 }
 
 // CreateRemoteProfile создаёт чужой профиль
-func CreateRemoteProfile(profile *models.Profile) error {
+func (r *ProfilesRepo) CreateRemoteProfile(profile *models.Profile) error {
 	query := `
 		INSERT INTO profiles (owner_type, peer_id, username, title, avatar_path, background_path,
 		                      content_char, demo_elements, cached_at, created_at, updated_at)
 		VALUES ('remote', ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 	`
-	result, err := database.DB.Exec(query,
+	result, err := r.conn().Exec(query,
 		profile.PeerID, profile.Username, profile.Title, profile.AvatarPath,
 		profile.BackgroundPath, profile.ContentChar, profile.DemoElements,
 	)
@@ -182,7 +181,7 @@ func CreateRemoteProfile(profile *models.Profile) error {
 }
 
 // UpdateRemoteProfile обновляет чужой профиль
-func UpdateRemoteProfile(profile *models.Profile) error {
+func (r *ProfilesRepo) UpdateRemoteProfile(profile *models.Profile) error {
 	query := `
 		UPDATE profiles
 		SET username = ?, title = ?, avatar_path = ?, background_path = ?,
@@ -190,7 +189,7 @@ func UpdateRemoteProfile(profile *models.Profile) error {
 		    updated_at = CURRENT_TIMESTAMP
 		WHERE peer_id = ? AND owner_type = 'remote'
 	`
-	_, err := database.DB.Exec(query,
+	_, err := r.conn().Exec(query,
 		profile.Username, profile.Title, profile.AvatarPath, profile.BackgroundPath,
 		profile.ContentChar, profile.DemoElements, profile.PeerID,
 	)
@@ -198,14 +197,14 @@ func UpdateRemoteProfile(profile *models.Profile) error {
 }
 
 // UpdateLocalProfile обновляет локальный профиль
-func UpdateLocalProfile(profile *models.Profile) error {
+func (r *ProfilesRepo) UpdateLocalProfile(profile *models.Profile) error {
 	query := `
 		UPDATE profiles
 		SET username = ?, title = ?, avatar_path = ?, background_path = ?,
 		    content_char = ?, demo_elements = ?, updated_at = CURRENT_TIMESTAMP
 		WHERE owner_type = 'local'
 	`
-	_, err := database.DB.Exec(query,
+	_, err := r.conn().Exec(query,
 		profile.Username, profile.Title, profile.AvatarPath, profile.BackgroundPath,
 		profile.ContentChar, profile.DemoElements,
 	)
@@ -213,7 +212,7 @@ func UpdateLocalProfile(profile *models.Profile) error {
 }
 
 // UpdateLocalProfileField обновляет конкретное поле локального профиля
-func UpdateLocalProfileField(field string, value interface{}) error {
+func (r *ProfilesRepo) UpdateLocalProfileField(field string, value interface{}) error {
 	var query string
 	switch field {
 	case "username":
@@ -231,25 +230,25 @@ func UpdateLocalProfileField(field string, value interface{}) error {
 	default:
 		return errors.New("неподдерживаемое поле: " + field)
 	}
-	_, err := database.DB.Exec(query, value)
+	_, err := r.conn().Exec(query, value)
 	return err
 }
 
 // DeleteRemoteProfile удаляет чужой профиль по PeerID
-func DeleteRemoteProfile(peerID string) error {
-	_, err := database.DB.Exec(`DELETE FROM profiles WHERE peer_id = ? AND owner_type = 'remote'`, peerID)
+func (r *ProfilesRepo) DeleteRemoteProfile(peerID string) error {
+	_, err := r.conn().Exec(`DELETE FROM profiles WHERE peer_id = ? AND owner_type = 'remote'`, peerID)
 	return err
 }
 
 // ProfileExists проверяет, существует ли профиль с указанным PeerID
-func ProfileExists(peerID string) (bool, error) {
+func (r *ProfilesRepo) ProfileExists(peerID string) (bool, error) {
 	var exists bool
-	err := database.DB.QueryRow(`SELECT COUNT(*) > 0 FROM profiles WHERE peer_id = ?`, peerID).Scan(&exists)
+	err := r.conn().QueryRow(`SELECT COUNT(*) > 0 FROM profiles WHERE peer_id = ?`, peerID).Scan(&exists)
 	return exists, err
 }
 
 // GetProfileByPeerID возвращает профиль (локальный или чужой) по PeerID
-func GetProfileByPeerID(peerID string) (*models.Profile, error) {
+func (r *ProfilesRepo) GetProfileByPeerID(peerID string) (*models.Profile, error) {
 	query := `
 		SELECT id, owner_type, peer_id, username, title,
 		       COALESCE(avatar_path, ''),
@@ -265,7 +264,7 @@ func GetProfileByPeerID(peerID string) (*models.Profile, error) {
 	var cachedAt sql.NullString
 	var createdAt, updatedAt string
 
-	err := database.DB.QueryRow(query).Scan(
+	err := r.conn().QueryRow(query, peerID).Scan(
 		&profile.ID, &profile.OwnerType, &profile.PeerID, &profile.Username,
 		&profile.Title, &profile.AvatarPath, &profile.BackgroundPath,
 		&profile.ContentChar, &profile.DemoElements, &cachedAt,
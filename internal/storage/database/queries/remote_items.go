@@ -13,10 +13,11 @@ import (
 // CreateRemoteItem создаёт кэшированный элемент от другого пира
 func CreateRemoteItem(item *models.RemoteItem) error {
 	query := `
-		INSERT INTO remote_items (source_peer_id, original_id, original_hash, title, description, 
+		INSERT INTO remote_items (source_peer_id, original_id, original_hash, item_type, title, description, 
 		                          content_meta, signature, version, cached_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, 1, CURRENT_TIMESTAMP)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, 1, CURRENT_TIMESTAMP)
 		ON CONFLICT(source_peer_id, original_hash) DO UPDATE SET
+			item_type = excluded.item_type,
 			title = excluded.title,
 			description = excluded.description,
 			content_meta = excluded.content_meta,
@@ -24,8 +25,8 @@ func CreateRemoteItem(item *models.RemoteItem) error {
 			version = version + 1,
 			cached_at = CURRENT_TIMESTAMP
 	`
-	result, err := database.DB.Exec(query,
-		item.SourcePeerID, item.OriginalID, item.OriginalHash,
+	result, err := database.GetDB().Exec(query,
+		item.SourcePeerID, item.OriginalID, item.OriginalHash, remoteItemType(item),
 		item.Title, item.Description, item.ContentMeta, item.Signature,
 	)
 	if err != nil {
@@ -43,7 +44,7 @@ func CreateRemoteItem(item *models.RemoteItem) error {
 // GetRemoteItemByHash возвращает элемент по хешу и PeerID владельца
 func GetRemoteItemByHash(sourcePeerID, originalHash string) (*models.RemoteItem, error) {
 	query := `
-		SELECT id, source_peer_id, original_id, original_hash, title, description, 
+		SELECT id, source_peer_id, original_id, original_hash, COALESCE(item_type, 'element'), title, description, 
 		       content_meta, signature, version, cached_at
 		FROM remote_items
 		WHERE source_peer_id = ? AND original_hash = ?
@@ -52,8 +53,8 @@ func GetRemoteItemByHash(sourcePeerID, originalHash string) (*models.RemoteItem,
 	var item models.RemoteItem
 	var cachedAt string
 
-	err := database.DB.QueryRow(query, sourcePeerID, originalHash).Scan(
-		&item.ID, &item.SourcePeerID, &item.OriginalID, &item.OriginalHash,
+	err := database.GetDB().QueryRow(query, sourcePeerID, originalHash).Scan(
+		&item.ID, &item.SourcePeerID, &item.OriginalID, &item.OriginalHash, &item.ItemType,
 		&item.Title, &item.Description, &item.ContentMeta, &item.Signature,
 		&item.Version, &cachedAt,
 	)
@@ -71,13 +72,13 @@ func GetRemoteItemByHash(sourcePeerID, originalHash string) (*models.RemoteItem,
 // GetRemoteItemsByPeer возвращает все кэшированные элементы от пира
 func GetRemoteItemsByPeer(sourcePeerID string) ([]*models.RemoteItem, error) {
 	query := `
-		SELECT id, source_peer_id, original_id, original_hash, title, description, 
+		SELECT id, source_peer_id, original_id, original_hash, COALESCE(item_type, 'element'), title, description, 
 		       content_meta, signature, version, cached_at
 		FROM remote_items
 		WHERE source_peer_id = ?
 		ORDER BY title
 	`
-	rows, err := database.DB.Query(query, sourcePeerID)
+	rows, err := database.GetDB().Query(query, sourcePeerID)
 	if err != nil {
 		return nil, err
 	}
@@ -89,7 +90,7 @@ func GetRemoteItemsByPeer(sourcePeerID string) ([]*models.RemoteItem, error) {
 		var cachedAt string
 
 		err := rows.Scan(
-			&item.ID, &item.SourcePeerID, &item.OriginalID, &item.OriginalHash,
+			&item.ID, &item.SourcePeerID, &item.OriginalID, &item.OriginalHash, &item.ItemType,
 			&item.Title, &item.Description, &item.ContentMeta, &item.Signature,
 			&item.Version, &cachedAt,
 		)
@@ -107,7 +108,7 @@ func GetRemoteItemsByPeer(sourcePeerID string) ([]*models.RemoteItem, error) {
 // GetRemoteItemByID возвращает элемент по локальному ID
 func GetRemoteItemByID(id int) (*models.RemoteItem, error) {
 	query := `
-		SELECT id, source_peer_id, original_id, original_hash, title, description, 
+		SELECT id, source_peer_id, original_id, original_hash, COALESCE(item_type, 'element'), title, description, 
 		       content_meta, signature, version, cached_at
 		FROM remote_items
 		WHERE id = ?
@@ -116,8 +117,8 @@ func GetRemoteItemByID(id int) (*models.RemoteItem, error) {
 	var item models.RemoteItem
 	var cachedAt string
 
-	err := database.DB.QueryRow(query, id).Scan(
-		&item.ID, &item.SourcePeerID, &item.OriginalID, &item.OriginalHash,
+	err := database.GetDB().QueryRow(query, id).Scan(
+		&item.ID, &item.SourcePeerID, &item.OriginalID, &item.OriginalHash, &item.ItemType,
 		&item.Title, &item.Description, &item.ContentMeta, &item.Signature,
 		&item.Version, &cachedAt,
 	)
@@ -132,16 +133,66 @@ func GetRemoteItemByID(id int) (*models.RemoteItem, error) {
 	return &item, nil
 }
 
-// UpdateRemoteItem обновляет кэшированный элемент
+// GetRemoteItemByOriginalID возвращает элемент по ID у владельца и PeerID владельца
+func GetRemoteItemByOriginalID(sourcePeerID string, originalID int) (*models.RemoteItem, error) {
+	query := `
+		SELECT id, source_peer_id, original_id, original_hash, COALESCE(item_type, 'element'), title, description, 
+		       content_meta, signature, version, cached_at
+		FROM remote_items
+		WHERE source_peer_id = ? AND original_id = ?
+		ORDER BY version DESC
+		LIMIT 1
+	`
+	var item models.RemoteItem
+	var cachedAt string
+
+	err := database.GetDB().QueryRow(query, sourcePeerID, originalID).Scan(
+		&item.ID, &item.SourcePeerID, &item.OriginalID, &item.OriginalHash, &item.ItemType,
+		&item.Title, &item.Description, &item.ContentMeta, &item.Signature,
+		&item.Version, &cachedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("элемент не найден")
+		}
+		return nil, err
+	}
+
+	item.CachedAt, _ = time.Parse("2006-01-02 15:04:05", cachedAt)
+	return &item, nil
+}
+
+// GetRemoteOriginalIDs возвращает ID у владельца всех кэшированных элементов пира
+func GetRemoteOriginalIDs(sourcePeerID string) ([]int, error) {
+	rows, err := database.GetDB().Query(`SELECT DISTINCT original_id FROM remote_items WHERE source_peer_id = ? ORDER BY original_id`, sourcePeerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// UpdateRemoteItem обновляет кэшированный элемент.
+// Хэш тоже обновляется: при редактировании у владельца меняется content_hash.
 func UpdateRemoteItem(item *models.RemoteItem) error {
 	query := `
 		UPDATE remote_items 
-		SET title = ?, description = ?, content_meta = ?, signature = ?, 
+		SET original_id = ?, original_hash = ?, item_type = ?, title = ?, description = ?, content_meta = ?, signature = ?, 
 		    version = ?, cached_at = CURRENT_TIMESTAMP
 		WHERE id = ?
 	`
-	_, err := database.DB.Exec(query,
-		item.Title, item.Description, item.ContentMeta,
+	_, err := database.GetDB().Exec(query,
+		item.OriginalID, item.OriginalHash, remoteItemType(item), item.Title, item.Description, item.ContentMeta,
 		item.Signature, item.Version, item.ID,
 	)
 	return err
@@ -149,26 +200,40 @@ func UpdateRemoteItem(item *models.RemoteItem) error {
 
 // DeleteRemoteItem удаляет кэшированный элемент
 func DeleteRemoteItem(id int) error {
-	_, err := database.DB.Exec(`DELETE FROM remote_items WHERE id = ?`, id)
+	_, err := database.GetDB().Exec(`DELETE FROM remote_items WHERE id = ?`, id)
+	return err
+}
+
+// DeleteRemoteItemByOriginalID удаляет кэшированный элемент пира по ID у владельца
+func DeleteRemoteItemByOriginalID(sourcePeerID string, originalID int) error {
+	_, err := database.GetDB().Exec(`DELETE FROM remote_items WHERE source_peer_id = ? AND original_id = ?`, sourcePeerID, originalID)
 	return err
 }
 
 // DeleteRemoteItemsByPeer удаляет все кэшированные элементы от пира
 func DeleteRemoteItemsByPeer(sourcePeerID string) error {
-	_, err := database.DB.Exec(`DELETE FROM remote_items WHERE source_peer_id = ?`, sourcePeerID)
+	_, err := database.GetDB().Exec(`DELETE FROM remote_items WHERE source_peer_id = ?`, sourcePeerID)
 	return err
 }
 
 // RemoteItemExists проверяет, существует ли элемент с указанным хешем
 func RemoteItemExists(sourcePeerID, originalHash string) (bool, error) {
 	var exists bool
-	err := database.DB.QueryRow(`SELECT COUNT(*) > 0 FROM remote_items WHERE source_peer_id = ? AND original_hash = ?`, sourcePeerID, originalHash).Scan(&exists)
+	err := database.GetDB().QueryRow(`SELECT COUNT(*) > 0 FROM remote_items WHERE source_peer_id = ? AND original_hash = ?`, sourcePeerID, originalHash).Scan(&exists)
 	return exists, err
 }
 
 // GetAllRemoteItemsCount возвращает общее количество кэшированных элементов
 func GetAllRemoteItemsCount() (int, error) {
 	var count int
-	err := database.DB.QueryRow(`SELECT COUNT(*) FROM remote_items`).Scan(&count)
+	err := database.GetDB().QueryRow(`SELECT COUNT(*) FROM remote_items`).Scan(&count)
 	return count, err
 }
+
+// remoteItemType возвращает тип элемента, по умолчанию - element
+func remoteItemType(item *models.RemoteItem) models.ItemType {
+	if item.ItemType == "" {
+		return models.ItemTypeElement
+	}
+	return item.ItemType
+}
@@ -0,0 +1,135 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+
+	"projectT/internal/storage/database"
+)
+
+// DBTX соединение, через которое репозитории выполняют запросы: *sql.DB или *sql.Tx.
+// Репозиторий, созданный над *sql.Tx, выполняет все запросы внутри этой транзакции,
+// а собственные транзакции (например при замене тегов элемента) становятся её частью.
+type DBTX interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// repo общая часть SQLite-репозиториев
+type repo struct {
+	db DBTX
+}
+
+// conn возвращает соединение репозитория. Репозиторий без соединения работает с database.GetDB(),
+// который читается при каждом запросе: так репозитории по умолчанию видят базу, открытую позже них.
+func (r repo) conn() DBTX {
+	if r.db != nil {
+		return r.db
+	}
+	return database.GetDB()
+}
+
+// begin начинает транзакцию на соединении репозитория.
+// Если репозиторий уже работает внутри транзакции, новая не начинается: Commit и Rollback
+// оставляют решение внешней транзакции.
+func (r repo) begin(ctx context.Context) (*scopedTx, error) {
+	switch db := r.conn().(type) {
+	case *sql.Tx:
+		return &scopedTx{DBTX: db}, nil
+	case interface {
+		BeginTx(context.Context, *sql.TxOptions) (*sql.Tx, error)
+	}:
+		tx, err := db.BeginTx(ctx, &sql.TxOptions{
+			Isolation: sql.LevelSerializable,
+			ReadOnly:  false,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &scopedTx{DBTX: tx, tx: tx}, nil
+	default:
+		return &scopedTx{DBTX: db}, nil
+	}
+}
+
+// scopedTx транзакция репозитория; tx равна nil, если транзакцией владеет вызывающий
+type scopedTx struct {
+	DBTX
+	tx *sql.Tx
+}
+
+// Commit фиксирует собственную транзакцию
+func (t *scopedTx) Commit() error {
+	if t.tx == nil {
+		return nil
+	}
+	return t.tx.Commit()
+}
+
+// Rollback откатывает собственную транзакцию
+func (t *scopedTx) Rollback() error {
+	if t.tx == nil {
+		return nil
+	}
+	return t.tx.Rollback()
+}
+
+// ItemsRepo элементы и поиск по ним
+type ItemsRepo struct{ repo }
+
+// NewItemsRepo создает репозиторий элементов над db; nil означает database.GetDB()
+func NewItemsRepo(db DBTX) *ItemsRepo {
+	return &ItemsRepo{repo{db: db}}
+}
+
+// TagsRepo теги и их связи с элементами
+type TagsRepo struct{ repo }
+
+// NewTagsRepo создает репозиторий тегов над db; nil означает database.GetDB()
+func NewTagsRepo(db DBTX) *TagsRepo {
+	return &TagsRepo{repo{db: db}}
+}
+
+// FilesRepo файлы элементов и записи о файлах хранилища
+type FilesRepo struct{ repo }
+
+// NewFilesRepo создает репозиторий файлов над db; nil означает database.GetDB()
+func NewFilesRepo(db DBTX) *FilesRepo {
+	return &FilesRepo{repo{db: db}}
+}
+
+// ContactsRepo контакты
+type ContactsRepo struct{ repo }
+
+// NewContactsRepo создает репозиторий контактов над db; nil означает database.GetDB()
+func NewContactsRepo(db DBTX) *ContactsRepo {
+	return &ContactsRepo{repo{db: db}}
+}
+
+// MessagesRepo сообщения чата
+type MessagesRepo struct{ repo }
+
+// NewMessagesRepo создает репозиторий сообщений над db; nil означает database.GetDB()
+func NewMessagesRepo(db DBTX) *MessagesRepo {
+	return &MessagesRepo{repo{db: db}}
+}
+
+// ProfilesRepo локальный и удалённые профили
+type ProfilesRepo struct{ repo }
+
+// NewProfilesRepo создает репозиторий профилей над db; nil означает database.GetDB()
+func NewProfilesRepo(db DBTX) *ProfilesRepo {
+	return &ProfilesRepo{repo{db: db}}
+}
+
+// SharingRepo правила доступа пиров к папкам и тегам
+type SharingRepo struct{ repo }
+
+// NewSharingRepo создает репозиторий правил доступа над db; nil означает database.GetDB()
+func NewSharingRepo(db DBTX) *SharingRepo {
+	return &SharingRepo{repo{db: db}}
+}
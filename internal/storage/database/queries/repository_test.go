@@ -0,0 +1,114 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/database/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openRepoTestDB открывает отдельную от database.GetDB() базу со схемой
+func openRepoTestDB(t *testing.T) *sql.DB {
+	db, err := database.Open(filepath.Join(t.TempDir(), "repo.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, database.Migrate(db))
+	return db
+}
+
+// TestRepoUsesOwnDB проверяет, что репозиторий работает со своей базой, а не с database.GetDB()
+func TestRepoUsesOwnDB(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+	db := openRepoTestDB(t)
+	items := NewItemsRepo(db)
+
+	item := &models.Item{Type: models.ItemTypeElement, Title: "Отдельная база"}
+	require.NoError(t, items.CreateItem(item))
+
+	got, err := items.GetItemByID(item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Отдельная база", got.Title)
+
+	global, err := GetAllItems()
+	require.NoError(t, err)
+	assert.Empty(t, global)
+}
+
+// TestRepoInsideTx проверяет, что репозитории над *sql.Tx не фиксируют собственные транзакции
+func TestRepoInsideTx(t *testing.T) {
+	db := openRepoTestDB(t)
+	ctx := context.Background()
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+
+	item := &models.Item{Type: models.ItemTypeElement, Title: "В транзакции"}
+	require.NoError(t, NewItemsRepo(tx).CreateItem(item))
+	tags := NewTagsRepo(tx)
+	tagIDs, err := tags.GetOrCreateTags(ctx, []string{"первый", "второй"})
+	require.NoError(t, err)
+	require.NoError(t, tags.ReplaceItemTags(ctx, item.ID, tagIDs))
+
+	itemTags, err := tags.GetTagsForItem(ctx, item.ID)
+	require.NoError(t, err)
+	assert.Len(t, itemTags, 2)
+
+	require.NoError(t, tx.Rollback())
+
+	allTags, err := NewTagsRepo(db).GetAllTags(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, allTags)
+	allItems, err := NewItemsRepo(db).GetAllItems()
+	require.NoError(t, err)
+	assert.Empty(t, allItems)
+}
+
+// TestRepoSharingAndSearchUseOwnDB проверяет, что правила доступа и имена тегов в поиске
+// берутся из базы репозитория, а удаление элемента убирает его правило в той же транзакции
+func TestRepoSharingAndSearchUseOwnDB(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+	db := openRepoTestDB(t)
+	ctx := context.Background()
+
+	items := NewItemsRepo(db)
+	folder := &models.Item{Type: models.ItemTypeFolder, Title: "Общая"}
+	require.NoError(t, items.CreateItem(folder))
+	tags := NewTagsRepo(db)
+	tagIDs, err := tags.GetOrCreateTags(ctx, []string{"отдельный"})
+	require.NoError(t, err)
+	require.NoError(t, tags.ReplaceItemTags(ctx, folder.ID, tagIDs))
+
+	sharing := NewSharingRepo(db)
+	require.NoError(t, sharing.SetSharingRule(&models.SharingRule{
+		EntityType: models.SharingEntityFolder, EntityID: folder.ID, Visibility: models.SharingPublic,
+	}))
+	global, err := GetSharingRule(models.SharingEntityFolder, folder.ID)
+	require.NoError(t, err)
+	assert.Zero(t, global.ID, "правило не попадает в database.GetDB()")
+
+	query, err := ParseSearchQuery("tag:отдельный")
+	require.NoError(t, err)
+	results, err := items.SearchItemsByQuery(query)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, folder.ID, results[0].Item.ID)
+
+	tx, err := items.BeginTransaction(ctx)
+	require.NoError(t, err)
+	require.NoError(t, NewItemsRepo(tx).DeleteItem(folder.ID))
+	_, err = NewItemsRepo(tx).BeginTransaction(ctx)
+	assert.Error(t, err, "внутри транзакции новая не начинается")
+	require.NoError(t, tx.Commit())
+
+	rule, err := sharing.GetSharingRule(models.SharingEntityFolder, folder.ID)
+	require.NoError(t, err)
+	assert.Zero(t, rule.ID)
+}
@@ -0,0 +1,241 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/database/models"
+)
+
+// ErrItemRevisionNotFound возвращается, если ревизии не существует
+var ErrItemRevisionNotFound = errors.New("ревизия не найдена")
+
+// CreateItemRevisionTx сохраняет текущее состояние элемента как ревизию в рамках транзакции tx.
+// Вызывается перед изменением элемента. Если состояние совпадает с последней ревизией, новая не создаётся.
+// maxRevisions ограничивает число ревизий элемента: самые старые удаляются (0 - без ограничения).
+// Возвращает созданную ревизию или nil, если создавать было нечего.
+func CreateItemRevisionTx(ctx context.Context, tx *sql.Tx, itemID, maxRevisions int) (*models.ItemRevision, error) {
+	revision := &models.ItemRevision{ItemID: itemID}
+	var description, contentMeta sql.NullString
+	err := tx.QueryRowContext(ctx, `
+		SELECT type, COALESCE(title, ''), description, content_meta
+		FROM items
+		WHERE id = ?
+	`, itemID).Scan(&revision.Type, &revision.Title, &description, &contentMeta)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("элемент не найден")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения элемента: %w", err)
+	}
+	revision.Description = description.String
+	revision.ContentMeta = contentMeta.String
+	if revision.ContentMeta == "" {
+		revision.ContentMeta = "[]"
+	}
+
+	revision.Tags, err = queryStrings(ctx, tx, `
+		SELECT t.name FROM tags t
+		INNER JOIN item_tags it ON t.id = it.tag_id
+		WHERE it.item_id = ?
+		ORDER BY t.name
+	`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения тегов элемента: %w", err)
+	}
+	if revision.Tags == nil {
+		revision.Tags = []string{}
+	}
+	tagsJSON, err := json.Marshal(revision.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	// Повторное сохранение без изменений не засоряет историю
+	var latestID int
+	err = tx.QueryRowContext(ctx, `
+		SELECT id FROM item_revisions
+		WHERE item_id = ? AND id = (SELECT MAX(id) FROM item_revisions WHERE item_id = ?)
+			AND type = ? AND title = ? AND description = ? AND content_meta = ? AND tags = ?
+	`, itemID, itemID, revision.Type, revision.Title, revision.Description, revision.ContentMeta, string(tagsJSON)).Scan(&latestID)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("ошибка получения последней ревизии: %w", err)
+	}
+
+	// Файлы ревизии: локальные файлы элемента и файловые блоки content_meta
+	revision.FileHashes, err = queryStrings(ctx, tx, `
+		SELECT hash FROM item_files WHERE item_id = ? AND is_remote = 0
+		UNION
+		SELECT json_extract(block.value, '$.file_hash')
+		FROM json_each(CASE WHEN json_valid(?) AND json_type(?) = 'array' THEN ? ELSE '[]' END) AS block
+		WHERE block.type = 'object' AND COALESCE(json_extract(block.value, '$.file_hash'), '') != ''
+	`, itemID, revision.ContentMeta, revision.ContentMeta, revision.ContentMeta)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения файлов элемента: %w", err)
+	}
+
+	revision.CreatedAt = time.Now()
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO item_revisions (item_id, type, title, description, content_meta, tags, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, itemID, revision.Type, revision.Title, revision.Description, revision.ContentMeta, string(tagsJSON), revision.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания ревизии: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	revision.ID = int(id)
+
+	for _, hash := range revision.FileHashes {
+		if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO item_revision_files (revision_id, hash) VALUES (?, ?)`, revision.ID, hash); err != nil {
+			return nil, fmt.Errorf("ошибка сохранения файлов ревизии: %w", err)
+		}
+	}
+
+	if maxRevisions > 0 {
+		// Файлы удалённых ревизий отвязывают триггеры
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM item_revisions
+			WHERE item_id = ? AND id NOT IN (
+				SELECT id FROM item_revisions WHERE item_id = ? ORDER BY id DESC LIMIT ?
+			)
+		`, itemID, itemID, maxRevisions)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка удаления старых ревизий: %w", err)
+		}
+	}
+
+	return revision, nil
+}
+
+// GetItemRevisions возвращает ревизии элемента, начиная с самой новой
+func GetItemRevisions(itemID int) ([]*models.ItemRevision, error) {
+	rows, err := database.GetDB().Query(`
+		SELECT id, item_id, type, title, description, content_meta, tags, created_at
+		FROM item_revisions
+		WHERE item_id = ?
+		ORDER BY id DESC
+	`, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	var revisions []*models.ItemRevision
+	for rows.Next() {
+		revision, err := scanItemRevision(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, revision := range revisions {
+		if err := loadRevisionFiles(revision); err != nil {
+			return nil, err
+		}
+	}
+	return revisions, nil
+}
+
+// GetItemRevision возвращает ревизию по ID
+func GetItemRevision(id int) (*models.ItemRevision, error) {
+	row := database.GetDB().QueryRow(`
+		SELECT id, item_id, type, title, description, content_meta, tags, created_at
+		FROM item_revisions
+		WHERE id = ?
+	`, id)
+
+	revision, err := scanItemRevision(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrItemRevisionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := loadRevisionFiles(revision); err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
+
+// PruneItemRevisions оставляет у каждого элемента не больше keep самых новых ревизий (0 - ничего не удаляет).
+// Возвращает число удалённых ревизий.
+func PruneItemRevisions(keep int) (int, error) {
+	if keep <= 0 {
+		return 0, nil
+	}
+
+	result, err := database.GetDB().Exec(`
+		DELETE FROM item_revisions
+		WHERE (SELECT COUNT(*) FROM item_revisions newer
+			WHERE newer.item_id = item_revisions.item_id AND newer.id > item_revisions.id) >= ?
+	`, keep)
+	if err != nil {
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	return int(count), err
+}
+
+// scanItemRevision читает строку item_revisions без файлов
+func scanItemRevision(row interface{ Scan(...interface{}) error }) (*models.ItemRevision, error) {
+	var revision models.ItemRevision
+	var tags string
+	if err := row.Scan(&revision.ID, &revision.ItemID, &revision.Type, &revision.Title, &revision.Description,
+		&revision.ContentMeta, &tags, &revision.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(tags), &revision.Tags); err != nil {
+		return nil, fmt.Errorf("ошибка разбора тегов ревизии %d: %w", revision.ID, err)
+	}
+	return &revision, nil
+}
+
+// loadRevisionFiles заполняет хэши файлов ревизии
+func loadRevisionFiles(revision *models.ItemRevision) error {
+	hashes, err := queryStrings(context.Background(), database.GetDB(),
+		`SELECT hash FROM item_revision_files WHERE revision_id = ?`, revision.ID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения файлов ревизии: %w", err)
+	}
+	sort.Strings(hashes)
+	revision.FileHashes = hashes
+	return nil
+}
+
+// queryStrings выполняет запрос, возвращающий один строковый столбец
+func queryStrings(ctx context.Context, db interface {
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+}, query string, args ...interface{}) ([]string, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
@@ -0,0 +1,139 @@
+package queries
+
+import (
+	"context"
+	"testing"
+
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/database/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// snapshotRevision сохраняет текущее состояние элемента как ревизию
+func snapshotRevision(t *testing.T, itemID, maxRevisions int) *models.ItemRevision {
+	t.Helper()
+
+	ctx := context.Background()
+	tx, err := BeginTransaction(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	revision, err := CreateItemRevisionTx(ctx, tx, itemID, maxRevisions)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+	return revision
+}
+
+// TestItemRevisionSnapshot проверяет содержимое ревизии и пропуск неизменённого состояния
+func TestItemRevisionSnapshot(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	item := createTrashTestItem(t, models.ItemTypeElement, "Заметка", nil)
+	item.Description = "Первая версия"
+	item.ContentMeta = `[{"type":"file","file_hash":"meta-only"},{"type":"text","content":"текст"}]`
+	require.NoError(t, UpdateItem(item))
+	require.NoError(t, CreateItemFile(&models.ItemFile{ItemID: item.ID, Hash: "linked", FilePath: "li/linked", Size: 10}))
+	tagIDs, err := GetOrCreateTags(ctx, []string{"работа", "важное"})
+	require.NoError(t, err)
+	require.NoError(t, ReplaceItemTags(ctx, item.ID, tagIDs))
+
+	revision := snapshotRevision(t, item.ID, 0)
+	require.NotNil(t, revision)
+
+	revisions, err := GetItemRevisions(item.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	stored := revisions[0]
+	assert.Equal(t, revision.ID, stored.ID)
+	assert.Equal(t, "Заметка", stored.Title)
+	assert.Equal(t, "Первая версия", stored.Description)
+	assert.Equal(t, item.ContentMeta, stored.ContentMeta)
+	assert.Equal(t, []string{"важное", "работа"}, stored.Tags)
+	assert.Equal(t, []string{"linked", "meta-only"}, stored.FileHashes)
+
+	// Без изменений новая ревизия не создаётся
+	assert.Nil(t, snapshotRevision(t, item.ID, 0))
+
+	require.NoError(t, ReplaceItemTags(ctx, item.ID, nil))
+	assert.NotNil(t, snapshotRevision(t, item.ID, 0), "изменение тегов - новое состояние")
+
+	loaded, err := GetItemRevision(revision.ID)
+	require.NoError(t, err)
+	assert.Equal(t, stored, loaded)
+
+	_, err = GetItemRevision(999)
+	assert.ErrorIs(t, err, ErrItemRevisionNotFound)
+}
+
+// TestItemRevisionFilesRefcount проверяет, что файлы ревизий удерживают блобы
+func TestItemRevisionFilesRefcount(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	item := createTrashTestItem(t, models.ItemTypeElement, "С файлом", nil)
+	require.NoError(t, CreateItemFile(&models.ItemFile{ItemID: item.ID, Hash: "kept", FilePath: "ke/kept", Size: 10}))
+	snapshotRevision(t, item.ID, 0)
+	assert.Equal(t, 2, fileRefCount(t, "kept"))
+
+	// Файл убран из элемента, но остаётся в истории
+	require.NoError(t, DeleteItemFile(item.ID, "kept"))
+	assert.Equal(t, 1, fileRefCount(t, "kept"))
+	referenced, err := GetReferencedFileHashes()
+	require.NoError(t, err)
+	assert.True(t, referenced["kept"])
+
+	mismatches, err := GetFileRefCountMismatches()
+	require.NoError(t, err)
+	assert.Empty(t, mismatches)
+
+	// Удаление элемента удаляет историю и отвязывает её файлы
+	require.NoError(t, DeleteItem(item.ID))
+	assert.Zero(t, fileRefCount(t, "kept"))
+	revisions, err := GetItemRevisions(item.ID)
+	require.NoError(t, err)
+	assert.Empty(t, revisions)
+
+	var count int
+	require.NoError(t, database.GetDB().QueryRow(`SELECT COUNT(*) FROM item_revision_files`).Scan(&count))
+	assert.Zero(t, count)
+}
+
+// TestItemRevisionLimit проверяет ограничение числа ревизий элемента
+func TestItemRevisionLimit(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	item := createTrashTestItem(t, models.ItemTypeElement, "Версия 0", nil)
+	other := createTrashTestItem(t, models.ItemTypeElement, "Другой", nil)
+	snapshotRevision(t, other.ID, 0)
+
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, CreateItemFile(&models.ItemFile{ItemID: item.ID, Hash: "blob", FilePath: "bl/blob", Size: 1}))
+		snapshotRevision(t, item.ID, 3)
+		item.Title = "Версия " + string(rune('0'+i))
+		require.NoError(t, UpdateItem(item))
+	}
+
+	revisions, err := GetItemRevisions(item.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 3)
+	assert.Equal(t, "Версия 4", revisions[0].Title, "новые ревизии первыми")
+	assert.Equal(t, "Версия 2", revisions[2].Title)
+	assert.Equal(t, 4, fileRefCount(t, "blob"), "файлы удалённых ревизий отвязаны")
+
+	pruned, err := PruneItemRevisions(1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, pruned)
+	revisions, err = GetItemRevisions(item.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, "Версия 4", revisions[0].Title)
+
+	revisions, err = GetItemRevisions(other.ID)
+	require.NoError(t, err)
+	assert.Len(t, revisions, 1, "ревизии других элементов не затронуты")
+}
@@ -0,0 +1,152 @@
+package queries
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/database/models"
+)
+
+// ErrSavedSearchNotFound возвращается, если сохранённый поиск не существует
+var ErrSavedSearchNotFound = errors.New("сохранённый поиск не найден")
+
+const savedSearchColumns = `id, name, query, item_type, priority, sort_by, sort_order, created_at, updated_at`
+
+// validateSavedSearch проверяет имя и синтаксис запроса сохранённого поиска
+func validateSavedSearch(search *models.SavedSearch) error {
+	search.Name = strings.TrimSpace(search.Name)
+	if search.Name == "" {
+		return errors.New("название сохранённого поиска не может быть пустым")
+	}
+	if _, err := ParseSearchQuery(search.Query); err != nil {
+		return err
+	}
+	return nil
+}
+
+// CreateSavedSearch сохраняет поиск и заполняет его ID
+func CreateSavedSearch(search *models.SavedSearch) error {
+	if err := validateSavedSearch(search); err != nil {
+		return err
+	}
+
+	result, err := database.GetDB().Exec(`
+		INSERT INTO saved_searches (name, query, item_type, priority, sort_by, sort_order)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, search.Name, search.Query, search.ItemType, search.Priority, search.SortBy, search.SortOrder)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения поиска: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	search.ID = int(id)
+	return nil
+}
+
+// UpdateSavedSearch изменяет название, запрос и настройки сохранённого поиска
+func UpdateSavedSearch(search *models.SavedSearch) error {
+	if err := validateSavedSearch(search); err != nil {
+		return err
+	}
+
+	result, err := database.GetDB().Exec(`
+		UPDATE saved_searches
+		SET name = ?, query = ?, item_type = ?, priority = ?, sort_by = ?, sort_order = ?,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, search.Name, search.Query, search.ItemType, search.Priority, search.SortBy, search.SortOrder, search.ID)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления сохранённого поиска: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrSavedSearchNotFound
+	}
+	return nil
+}
+
+// GetSavedSearchByID возвращает сохранённый поиск по ID
+func GetSavedSearchByID(id int) (*models.SavedSearch, error) {
+	row := database.GetDB().QueryRow(`SELECT `+savedSearchColumns+` FROM saved_searches WHERE id = ?`, id)
+
+	search, err := scanSavedSearch(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSavedSearchNotFound
+	}
+	return search, err
+}
+
+// GetAllSavedSearches возвращает сохранённые поиски, упорядоченные по названию
+func GetAllSavedSearches() ([]*models.SavedSearch, error) {
+	return querySavedSearches(`SELECT ` + savedSearchColumns + ` FROM saved_searches ORDER BY name COLLATE NOCASE, id`)
+}
+
+// DeleteSavedSearch удаляет сохранённый поиск вместе с отметкой избранного
+func DeleteSavedSearch(id int) error {
+	tx, err := database.GetDB().Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback() // Игнорируем ошибку отката, т.к. коммит уже мог состояться
+	}()
+
+	if _, err := tx.Exec(`DELETE FROM favorites WHERE entity_type = ? AND entity_id = ?`,
+		models.FavoriteEntitySavedSearch, id); err != nil {
+		return fmt.Errorf("ошибка удаления из избранного: %w", err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM saved_searches WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления сохранённого поиска: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrSavedSearchNotFound
+	}
+
+	return tx.Commit()
+}
+
+// querySavedSearches выполняет запрос, возвращающий колонки savedSearchColumns
+func querySavedSearches(query string, args ...any) ([]*models.SavedSearch, error) {
+	rows, err := database.GetDB().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var searches []*models.SavedSearch
+	for rows.Next() {
+		search, err := scanSavedSearch(rows)
+		if err != nil {
+			return nil, err
+		}
+		searches = append(searches, search)
+	}
+	return searches, rows.Err()
+}
+
+// scanSavedSearch читает строку с колонками savedSearchColumns
+func scanSavedSearch(scanner interface{ Scan(dest ...any) error }) (*models.SavedSearch, error) {
+	var search models.SavedSearch
+	err := scanner.Scan(&search.ID, &search.Name, &search.Query, &search.ItemType, &search.Priority,
+		&search.SortBy, &search.SortOrder, &search.CreatedAt, &search.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &search, nil
+}
@@ -0,0 +1,91 @@
+package queries
+
+import (
+	"testing"
+
+	"projectT/internal/storage/database/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSavedSearchCRUD проверяет создание, чтение, изменение и удаление сохранённого поиска
+func TestSavedSearchCRUD(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	search := &models.SavedSearch{
+		Name:      "  Свежие картинки ",
+		Query:     "type:image created:>2025-01-01",
+		ItemType:  "all",
+		Priority:  "none",
+		SortBy:    "modified_date",
+		SortOrder: "desc",
+	}
+	require.NoError(t, CreateSavedSearch(search))
+	require.NotZero(t, search.ID)
+
+	loaded, err := GetSavedSearchByID(search.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Свежие картинки", loaded.Name)
+	assert.Equal(t, "type:image created:>2025-01-01", loaded.Query)
+	assert.Equal(t, "modified_date", loaded.SortBy)
+	assert.Equal(t, "desc", loaded.SortOrder)
+
+	loaded.Name = "Архив"
+	loaded.Query = "tag:архив"
+	require.NoError(t, UpdateSavedSearch(loaded))
+
+	all, err := GetAllSavedSearches()
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, "Архив", all[0].Name)
+	assert.Equal(t, "tag:архив", all[0].Query)
+
+	require.NoError(t, DeleteSavedSearch(search.ID))
+	_, err = GetSavedSearchByID(search.ID)
+	assert.ErrorIs(t, err, ErrSavedSearchNotFound)
+	assert.ErrorIs(t, DeleteSavedSearch(search.ID), ErrSavedSearchNotFound)
+}
+
+// TestSavedSearchValidation проверяет отказ сохранять поиск без имени или с неверным запросом
+func TestSavedSearchValidation(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	assert.Error(t, CreateSavedSearch(&models.SavedSearch{Name: " ", Query: "tag:work"}))
+	assert.Error(t, CreateSavedSearch(&models.SavedSearch{Name: "Плохой", Query: "created:>вчера"}))
+
+	all, err := GetAllSavedSearches()
+	require.NoError(t, err)
+	assert.Empty(t, all)
+}
+
+// TestSavedSearchFavorites проверяет добавление сохранённого поиска в избранное
+func TestSavedSearchFavorites(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	search := &models.SavedSearch{Name: "Работа", Query: "tag:work"}
+	require.NoError(t, CreateSavedSearch(search))
+
+	require.NoError(t, AddToFavorites(models.FavoriteEntitySavedSearch, search.ID))
+
+	isFavorite, err := IsFavorite(models.FavoriteEntitySavedSearch, search.ID)
+	require.NoError(t, err)
+	assert.True(t, isFavorite)
+
+	favorites, err := GetFavoriteSavedSearches()
+	require.NoError(t, err)
+	require.Len(t, favorites, 1)
+	assert.Equal(t, "Работа", favorites[0].Name)
+
+	// Неизвестные типы сущностей по-прежнему отклоняются
+	assert.Error(t, AddToFavorites("contact", 1))
+
+	// Удаление поиска убирает его из избранного
+	require.NoError(t, DeleteSavedSearch(search.ID))
+	all, err := GetAllFavorites()
+	require.NoError(t, err)
+	assert.Empty(t, all)
+}
@@ -0,0 +1,220 @@
+package queries
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"unicode"
+
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/database/models"
+)
+
+// Маркеры подсветки совпадений в SearchResult.Snippet
+const (
+	SearchHighlightStart = "\x02"
+	SearchHighlightEnd   = "\x03"
+)
+
+// searchResultLimit максимальное количество результатов поиска
+const searchResultLimit = 200
+
+// searchSnippetTokens длина фрагмента в токенах
+const searchSnippetTokens = 12
+
+// searchTerms разбивает запрос на слова (буквы и цифры любого алфавита)
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// ftsMatchQuery строит выражение MATCH: все слова обязательны и ищутся
+// по префиксу, чтобы поиск работал во время набора
+func ftsMatchQuery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = `"` + term + `"*`
+	}
+	return strings.Join(parts, " ")
+}
+
+// SearchItems выполняет поиск элементов по названию, описанию, тексту блоков и тегам.
+// Элементы возвращаются в порядке релевантности.
+func (r *ItemsRepo) SearchItems(query string) ([]*models.Item, error) {
+	results, err := r.SearchItemsWithSnippets(query)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*models.Item, len(results))
+	for i, result := range results {
+		items[i] = result.Item
+	}
+	return items, nil
+}
+
+// SearchItemsWithSnippets разбирает структурированный запрос (см. ParseSearchQuery)
+// и возвращает подходящие элементы с подсвеченными фрагментами текста
+func (r *ItemsRepo) SearchItemsWithSnippets(query string) ([]*models.SearchResult, error) {
+	parsed, err := ParseSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.SearchItemsByQuery(parsed)
+}
+
+// SearchItemsByQuery выполняет разобранный запрос.
+// Если в запросе есть слова и доступен FTS5, элементы упорядочены по релевантности,
+// иначе - по дате изменения. Без FTS5 слова ищутся как подстроки через LIKE.
+// Пустой запрос, как и раньше, совпадает со всеми элементами.
+func (r *ItemsRepo) SearchItemsByQuery(query *SearchQuery) ([]*models.SearchResult, error) {
+	names, err := query.resolveSearchNames(r.conn())
+	if err != nil {
+		return nil, err
+	}
+
+	useFTS := database.SearchIndexAvailable(r.conn())
+	where, args := query.compileWhere(names, useFTS)
+
+	textTerms := query.TextTerms()
+	var words []string
+	matches := make([]string, 0, len(textTerms))
+	for _, term := range textTerms {
+		words = append(words, searchTerms(term.Value)...)
+		matches = append(matches, "("+term.ftsMatch()+")")
+	}
+
+	ranked := useFTS && len(textTerms) > 0
+
+	var sqlQuery string
+	var queryArgs []any
+	if ranked {
+		// Веса bm25 по колонкам: title, description, content, tags, archive
+		sqlQuery = `
+			SELECT i.id, i.type, i.title, i.description, i.content_meta, i.parent_id, COALESCE(i.content_hash, ''), i.created_at, i.updated_at,
+			       COALESCE(f.snippet, ''), COALESCE(f.rank, 0)
+			FROM items i
+			LEFT JOIN (
+				SELECT rowid,
+				       snippet(items_fts, -1, ?, ?, '…', ?) AS snippet,
+				       bm25(items_fts, 10.0, 4.0, 2.0, 6.0, 1.0) AS rank
+				FROM items_fts
+				WHERE items_fts MATCH ?
+			) f ON f.rowid = i.id
+			WHERE i.trash_id IS NULL AND (` + where + `)
+			ORDER BY f.rank IS NULL, f.rank, i.updated_at DESC
+			LIMIT ?
+		`
+		queryArgs = append(queryArgs, SearchHighlightStart, SearchHighlightEnd, searchSnippetTokens, strings.Join(matches, " OR "))
+		queryArgs = append(queryArgs, args...)
+		queryArgs = append(queryArgs, searchResultLimit)
+	} else {
+		sqlQuery = `
+			SELECT i.id, i.type, i.title, i.description, i.content_meta, i.parent_id, COALESCE(i.content_hash, ''), i.created_at, i.updated_at,
+			       '', 0
+			FROM items i
+			WHERE i.trash_id IS NULL AND (` + where + `)
+			ORDER BY i.updated_at DESC
+		`
+		queryArgs = args
+	}
+
+	rows, err := r.conn().Query(sqlQuery, queryArgs...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*models.SearchResult
+	for rows.Next() {
+		var item models.Item
+		var parentID sql.NullInt64
+		result := &models.SearchResult{Item: &item}
+
+		err := rows.Scan(
+			&item.ID, &item.Type, &item.Title, &item.Description, &item.ContentMeta, &parentID, &item.ContentHash, &item.CreatedAt, &item.UpdatedAt,
+			&result.Snippet, &result.Rank,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if parentID.Valid {
+			parentIDValue := int(parentID.Int64)
+			item.ParentID = &parentIDValue
+		}
+
+		if !ranked && len(words) > 0 {
+			result.Snippet = highlightSnippet(&item, words)
+		}
+
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
+
+// highlightSnippet строит фрагмент вокруг первого совпадения в описании или
+// тексте блоков элемента (аналог snippet() FTS5 для поиска через LIKE)
+func highlightSnippet(item *models.Item, terms []string) string {
+	texts := []string{item.Description}
+
+	var blocks []struct {
+		Type    string `json:"type"`
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal([]byte(item.ContentMeta), &blocks); err == nil {
+		for _, block := range blocks {
+			if block.Type == "text" {
+				texts = append(texts, block.Content)
+			}
+		}
+	}
+	texts = append(texts, item.Title)
+
+	for _, text := range texts {
+		words := strings.Fields(text)
+		for i, word := range words {
+			if !matchesAnyTerm(word, terms) {
+				continue
+			}
+
+			start := max(0, i-searchSnippetTokens/2)
+			end := min(len(words), start+searchSnippetTokens)
+
+			fragment := make([]string, 0, end-start)
+			for _, w := range words[start:end] {
+				if matchesAnyTerm(w, terms) {
+					w = SearchHighlightStart + w + SearchHighlightEnd
+				}
+				fragment = append(fragment, w)
+			}
+
+			snippet := strings.Join(fragment, " ")
+			if start > 0 {
+				snippet = "…" + snippet
+			}
+			if end < len(words) {
+				snippet += "…"
+			}
+			return snippet
+		}
+	}
+
+	return ""
+}
+
+// matchesAnyTerm проверяет, начинается ли слово с одного из искомых слов без учёта регистра
+func matchesAnyTerm(word string, terms []string) bool {
+	word = strings.ToLower(strings.TrimFunc(word, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}))
+	for _, term := range terms {
+		if strings.HasPrefix(word, strings.ToLower(term)) {
+			return true
+		}
+	}
+	return false
+}
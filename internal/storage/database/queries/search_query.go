@@ -0,0 +1,414 @@
+package queries
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Поля структурированного поискового запроса
+const (
+	SearchFieldText    = ""        // Слово или фраза в кавычках
+	SearchFieldTag     = "tag"     // tag:name - элемент отмечен тегом
+	SearchFieldType    = "type"    // type:image|file|link|text|folder
+	SearchFieldIn      = "in"      // in:"Папка" - элемент внутри папки (на любой глубине)
+	SearchFieldCreated = "created" // created:>2025-01-01
+	SearchFieldUpdated = "updated" // updated:<=2025-06-30
+	SearchFieldSize    = "size"    // size:>10MB - суммарный размер файлов элемента
+)
+
+// searchTypeValues допустимые значения type:
+var searchTypeValues = map[string]bool{
+	"image": true, "file": true, "link": true, "text": true, "folder": true,
+}
+
+// SearchTerm одно условие поискового запроса
+type SearchTerm struct {
+	Field   string // Одно из SearchField*
+	Op      string // Оператор сравнения для created/updated/size: =, >, >=, <, <=
+	Value   string // Значение (для size - размер в байтах)
+	Phrase  bool   // Значение было в кавычках
+	Negated bool   // Условие с префиксом "-"
+}
+
+// SearchClause условия, объединённые OR
+type SearchClause struct {
+	Terms []SearchTerm
+}
+
+// SearchQuery разобранный поисковый запрос: все клаузы должны выполняться (AND).
+// OR связывает соседние условия сильнее, чем пробел:
+// `tag:work OR tag:home type:image` = (tag:work OR tag:home) AND type:image.
+type SearchQuery struct {
+	Clauses []SearchClause
+}
+
+// ParseSearchQuery разбирает строку поиска.
+// Поддерживаются слова, "фразы в кавычках", OR, отрицание "-" и поля
+// tag:, type:, in:, created:, updated:, size:. Неизвестные поля ищутся как текст.
+func ParseSearchQuery(input string) (*SearchQuery, error) {
+	tokens := tokenizeSearchQuery(input)
+
+	query := &SearchQuery{}
+	joinNext := false
+	for _, tok := range tokens {
+		if tok.or {
+			joinNext = len(query.Clauses) > 0
+			continue
+		}
+
+		terms, err := parseSearchToken(tok)
+		if err != nil {
+			return nil, err
+		}
+		if len(terms) == 0 {
+			continue
+		}
+
+		if joinNext {
+			last := &query.Clauses[len(query.Clauses)-1]
+			last.Terms = append(last.Terms, terms...)
+		} else {
+			query.Clauses = append(query.Clauses, SearchClause{Terms: terms})
+		}
+		joinNext = false
+	}
+
+	return query, nil
+}
+
+// searchToken лексема запроса до разбора значения поля
+type searchToken struct {
+	negated bool
+	field   string
+	value   string
+	quoted  bool
+	or      bool
+}
+
+// tokenizeSearchQuery разбивает запрос на лексемы с учётом кавычек.
+// Незакрытая кавычка продолжается до конца строки.
+func tokenizeSearchQuery(input string) []searchToken {
+	var tokens []searchToken
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		var tok searchToken
+		if runes[i] == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			tok.negated = true
+			i++
+		}
+
+		// Префикс поля: буквы до двоеточия
+		if runes[i] != '"' {
+			j := i
+			for j < len(runes) && unicode.IsLetter(runes[j]) {
+				j++
+			}
+			if j < len(runes) && j > i && runes[j] == ':' && isSearchField(strings.ToLower(string(runes[i:j]))) {
+				tok.field = strings.ToLower(string(runes[i:j]))
+				i = j + 1
+			}
+		}
+
+		if i < len(runes) && runes[i] == '"' {
+			j := i + 1
+			for j < len(runes) && runes[j] != '"' {
+				j++
+			}
+			tok.value = string(runes[i+1 : j])
+			tok.quoted = true
+			i = j + 1
+		} else {
+			j := i
+			for j < len(runes) && !unicode.IsSpace(runes[j]) {
+				j++
+			}
+			tok.value = string(runes[i:j])
+			i = j
+		}
+
+		if !tok.negated && !tok.quoted && tok.field == "" && tok.value == "OR" {
+			tok.or = true
+		}
+		tokens = append(tokens, tok)
+	}
+
+	return tokens
+}
+
+// isSearchField проверяет, является ли префикс известным полем запроса
+func isSearchField(name string) bool {
+	switch name {
+	case SearchFieldTag, SearchFieldType, SearchFieldIn, SearchFieldCreated, SearchFieldUpdated, SearchFieldSize:
+		return true
+	}
+	return false
+}
+
+// parseSearchToken превращает лексему в условия; type:a|b даёт несколько условий, объединяемых OR
+func parseSearchToken(tok searchToken) ([]SearchTerm, error) {
+	term := SearchTerm{Field: tok.field, Value: strings.TrimSpace(tok.value), Phrase: tok.quoted, Negated: tok.negated}
+	if term.Value == "" {
+		return nil, nil
+	}
+
+	switch tok.field {
+	case SearchFieldType:
+		var terms []SearchTerm
+		for _, value := range strings.Split(strings.ToLower(term.Value), "|") {
+			if !searchTypeValues[value] {
+				return nil, fmt.Errorf("неизвестный тип %q, допустимы image, file, link, text, folder", value)
+			}
+			t := term
+			t.Value = value
+			terms = append(terms, t)
+		}
+		if tok.negated && len(terms) > 1 {
+			// -type:a|b означает "ни a, ни b", а не "не a или не b"
+			return nil, fmt.Errorf("отрицание нескольких типов не поддерживается: %s", term.Value)
+		}
+		return terms, nil
+
+	case SearchFieldCreated, SearchFieldUpdated:
+		op, value := splitSearchOperator(term.Value)
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return nil, fmt.Errorf("некорректная дата %q, ожидается ГГГГ-ММ-ДД", value)
+		}
+		term.Op, term.Value = op, value
+		return []SearchTerm{term}, nil
+
+	case SearchFieldSize:
+		op, value := splitSearchOperator(term.Value)
+		size, err := parseSearchSize(value)
+		if err != nil {
+			return nil, err
+		}
+		term.Op, term.Value = op, strconv.FormatInt(size, 10)
+		return []SearchTerm{term}, nil
+
+	case SearchFieldText:
+		// Знаки препинания без букв и цифр искать нечего
+		if len(searchTerms(term.Value)) == 0 {
+			return nil, nil
+		}
+	}
+
+	return []SearchTerm{term}, nil
+}
+
+// splitSearchOperator отделяет оператор сравнения от значения (по умолчанию "=")
+func splitSearchOperator(value string) (string, string) {
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(value, op) {
+			return op, strings.TrimPrefix(value, op)
+		}
+	}
+	return "=", value
+}
+
+// parseSearchSize разбирает размер вида 10MB, 512KB, 1.5GB или 100 (байт)
+func parseSearchSize(value string) (int64, error) {
+	units := []struct {
+		suffix     string
+		multiplier float64
+	}{
+		{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1},
+	}
+
+	upper := strings.ToUpper(value)
+	multiplier := 1.0
+	for _, unit := range units {
+		if strings.HasSuffix(upper, unit.suffix) {
+			multiplier = unit.multiplier
+			upper = strings.TrimSuffix(upper, unit.suffix)
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(upper, 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("некорректный размер %q, ожидается например 10MB", value)
+	}
+	return int64(number * multiplier), nil
+}
+
+// TextTerms возвращает слова и фразы запроса без отрицания (для ранжирования и подсветки)
+func (q *SearchQuery) TextTerms() []SearchTerm {
+	var terms []SearchTerm
+	for _, clause := range q.Clauses {
+		for _, term := range clause.Terms {
+			if term.Field == SearchFieldText && !term.Negated {
+				terms = append(terms, term)
+			}
+		}
+	}
+	return terms
+}
+
+// searchNames ID тегов и папок, имена которых упомянуты в запросе.
+// Имена сравниваются в Go без учёта регистра: COLLATE NOCASE в SQLite понимает только ASCII.
+type searchNames struct {
+	tags    map[string][]int
+	folders map[string][]int
+}
+
+// resolveSearchNames находит в db ID тегов из tag: и папок из in:
+func (q *SearchQuery) resolveSearchNames(db DBTX) (*searchNames, error) {
+	names := &searchNames{tags: map[string][]int{}, folders: map[string][]int{}}
+
+	var needTags, needFolders bool
+	for _, clause := range q.Clauses {
+		for _, term := range clause.Terms {
+			needTags = needTags || term.Field == SearchFieldTag
+			needFolders = needFolders || term.Field == SearchFieldIn
+		}
+	}
+
+	if needTags {
+		if err := loadSearchNames(db, `SELECT id, name FROM tags`, names.tags); err != nil {
+			return nil, fmt.Errorf("ошибка загрузки тегов: %w", err)
+		}
+	}
+	if needFolders {
+		if err := loadSearchNames(db, `SELECT id, COALESCE(title, '') FROM items WHERE type = 'folder' AND trash_id IS NULL`, names.folders); err != nil {
+			return nil, fmt.Errorf("ошибка загрузки папок: %w", err)
+		}
+	}
+
+	return names, nil
+}
+
+// loadSearchNames заполняет индекс "имя в нижнем регистре -> ID" результатом запроса к db
+func loadSearchNames(db DBTX, query string, index map[string][]int) error {
+	rows, err := db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return err
+		}
+		key := strings.ToLower(name)
+		index[key] = append(index[key], id)
+	}
+	return rows.Err()
+}
+
+// idList форматирует ID для IN (...); пустой список не совпадает ни с чем
+func idList(ids []int) string {
+	if len(ids) == 0 {
+		return "NULL"
+	}
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// compileWhere строит условие WHERE над items (алиас i), item_tags и item_files.
+// Если useFTS, слова ищутся через items_fts, иначе через LIKE.
+func (q *SearchQuery) compileWhere(names *searchNames, useFTS bool) (string, []any) {
+	if len(q.Clauses) == 0 {
+		return "1 = 1", nil
+	}
+
+	var args []any
+	clauses := make([]string, 0, len(q.Clauses))
+	for _, clause := range q.Clauses {
+		parts := make([]string, 0, len(clause.Terms))
+		for _, term := range clause.Terms {
+			sql, termArgs := term.compile(names, useFTS)
+			if term.Negated {
+				sql = "NOT " + sql
+			}
+			parts = append(parts, sql)
+			args = append(args, termArgs...)
+		}
+		clauses = append(clauses, "("+strings.Join(parts, " OR ")+")")
+	}
+
+	return strings.Join(clauses, " AND "), args
+}
+
+// compile строит SQL-условие для одного условия запроса (без учёта отрицания)
+func (t SearchTerm) compile(names *searchNames, useFTS bool) (string, []any) {
+	switch t.Field {
+	case SearchFieldTag:
+		return `EXISTS (
+			SELECT 1 FROM item_tags it
+			WHERE it.item_id = i.id AND it.tag_id IN (` + idList(names.tags[strings.ToLower(t.Value)]) + `)
+		)`, nil
+
+	case SearchFieldType:
+		// Те же правила, что у фильтра FilterOptions.ItemType (sorting.ItemSorter.matchItemType)
+		switch t.Value {
+		case "folder":
+			return `(i.type = 'folder')`, nil
+		case "text":
+			return `(i.type = 'element' AND COALESCE(i.content_meta, '') = '' AND COALESCE(i.description, '') != '')`, nil
+		default:
+			return `(i.type = 'element' AND i.content_meta LIKE ?)`, []any{"%" + t.Value + "%"}
+		}
+
+	case SearchFieldIn:
+		return `i.id IN (
+			WITH RECURSIVE inside(id) AS (
+				SELECT id FROM items WHERE parent_id IN (` + idList(names.folders[strings.ToLower(t.Value)]) + `)
+				UNION
+				SELECT c.id FROM items c JOIN inside ON c.parent_id = inside.id
+			)
+			SELECT id FROM inside
+		)`, nil
+
+	case SearchFieldCreated, SearchFieldUpdated:
+		column := "i.created_at"
+		if t.Field == SearchFieldUpdated {
+			column = "i.updated_at"
+		}
+		// Дата хранится строкой, первые 10 символов - ГГГГ-ММ-ДД
+		return fmt.Sprintf(`(substr(%s, 1, 10) %s ?)`, column, t.Op), []any{t.Value}
+
+	case SearchFieldSize:
+		size, _ := strconv.ParseInt(t.Value, 10, 64)
+		return fmt.Sprintf(`((SELECT COALESCE(SUM(f.size), 0) FROM item_files f WHERE f.item_id = i.id) %s ?)`, t.Op), []any{size}
+	}
+
+	if useFTS {
+		return `i.id IN (SELECT rowid FROM items_fts WHERE items_fts MATCH ?)`, []any{t.ftsMatch()}
+	}
+
+	pattern := "%" + t.Value + "%"
+	return `(i.title LIKE ? OR i.description LIKE ? OR i.content_meta LIKE ? OR EXISTS (
+		SELECT 1 FROM item_tags it
+		JOIN tags t ON t.id = it.tag_id
+		WHERE it.item_id = i.id AND t.name LIKE ?
+	) OR EXISTS (
+		SELECT 1
+		FROM json_each(CASE WHEN json_valid(i.content_meta) AND json_type(i.content_meta) = 'array'
+			THEN i.content_meta ELSE '[]' END) b
+		JOIN page_texts p ON p.hash = json_extract(b.value, '$.snapshot.text_hash')
+		WHERE p.text LIKE ?
+	))`, []any{pattern, pattern, pattern, pattern, pattern}
+}
+
+// ftsMatch строит выражение MATCH: фраза ищется целиком, слово - по префиксу
+// (составное слово вроде "wi-fi" превращается в несколько обязательных слов)
+func (t SearchTerm) ftsMatch() string {
+	if t.Phrase {
+		return `"` + strings.ReplaceAll(t.Value, `"`, `""`) + `"`
+	}
+	return ftsMatchQuery(searchTerms(t.Value))
+}
@@ -0,0 +1,124 @@
+package queries
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/database/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseSearchQuery проверяет разбор полей, фраз, отрицания и OR
+func TestParseSearchQuery(t *testing.T) {
+	q, err := ParseSearchQuery(`"отчёт за год" tag:work OR tag:"личное" -tag:архив type:image|link in:"Мои папки" created:>=2025-01-01 size:>10MB`)
+	require.NoError(t, err)
+	require.Len(t, q.Clauses, 7)
+
+	assert.Equal(t, SearchTerm{Field: SearchFieldText, Value: "отчёт за год", Phrase: true}, q.Clauses[0].Terms[0])
+
+	require.Len(t, q.Clauses[1].Terms, 2, "OR объединяет соседние условия")
+	assert.Equal(t, "work", q.Clauses[1].Terms[0].Value)
+	assert.Equal(t, "личное", q.Clauses[1].Terms[1].Value)
+
+	assert.Equal(t, SearchTerm{Field: SearchFieldTag, Value: "архив", Negated: true}, q.Clauses[2].Terms[0])
+
+	require.Len(t, q.Clauses[3].Terms, 2)
+	assert.Equal(t, "image", q.Clauses[3].Terms[0].Value)
+	assert.Equal(t, "link", q.Clauses[3].Terms[1].Value)
+
+	assert.Equal(t, SearchTerm{Field: SearchFieldIn, Value: "Мои папки", Phrase: true}, q.Clauses[4].Terms[0])
+	assert.Equal(t, SearchTerm{Field: SearchFieldCreated, Op: ">=", Value: "2025-01-01"}, q.Clauses[5].Terms[0])
+	assert.Equal(t, SearchTerm{Field: SearchFieldSize, Op: ">", Value: "10485760"}, q.Clauses[6].Terms[0])
+
+	q, err = ParseSearchQuery(`http://example.com`)
+	require.NoError(t, err)
+	assert.Equal(t, SearchFieldText, q.Clauses[0].Terms[0].Field, "неизвестный префикс ищется как текст")
+
+	for _, bad := range []string{"type:video", "created:вчера", "size:много", "-type:image|file"} {
+		_, err := ParseSearchQuery(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+// createSearchFixture создаёт элементы для проверки структурированного поиска
+func createSearchFixture(t *testing.T) map[string]*models.Item {
+	ctx := context.Background()
+
+	items := map[string]*models.Item{
+		"folder":  {Type: models.ItemTypeFolder, Title: "Проекты"},
+		"image":   {Type: models.ItemTypeElement, Title: "Скриншот макета", ContentMeta: `[{"type":"image","file_hash":"h1"}]`},
+		"file":    {Type: models.ItemTypeElement, Title: "Договор", ContentMeta: `[{"type":"file","file_hash":"h2"}]`},
+		"link":    {Type: models.ItemTypeElement, Title: "Статья о макетах", ContentMeta: `[{"type":"link","content":"https://example.com"}]`},
+		"text":    {Type: models.ItemTypeElement, Title: "Идеи", Description: "годовой отчёт по проекту"},
+		"archive": {Type: models.ItemTypeElement, Title: "Старый отчёт", Description: "архивный"},
+	}
+
+	require.NoError(t, CreateItem(items["folder"]))
+	for _, key := range []string{"image", "file", "link"} {
+		items[key].ParentID = &items["folder"].ID
+	}
+	for _, key := range []string{"image", "file", "link", "text", "archive"} {
+		require.NoError(t, CreateItem(items[key]))
+	}
+
+	require.NoError(t, CreateItemFile(&models.ItemFile{ItemID: items["file"].ID, Hash: "h2", FilePath: "f", Size: 20 << 20}))
+	require.NoError(t, CreateItemFile(&models.ItemFile{ItemID: items["image"].ID, Hash: "h1", FilePath: "i", Size: 1 << 20}))
+
+	workIDs, err := GetOrCreateTags(ctx, []string{"work"})
+	require.NoError(t, err)
+	archiveIDs, err := GetOrCreateTags(ctx, []string{"архив"})
+	require.NoError(t, err)
+	require.NoError(t, ReplaceItemTags(ctx, items["image"].ID, workIDs))
+	require.NoError(t, ReplaceItemTags(ctx, items["text"].ID, workIDs))
+	require.NoError(t, ReplaceItemTags(ctx, items["archive"].ID, append(workIDs, archiveIDs...)))
+
+	_, err = database.GetDB().Exec(`UPDATE items SET created_at = ? WHERE id = ?`, time.Date(2024, 6, 1, 12, 0, 0, 0, time.Local), items["archive"].ID)
+	require.NoError(t, err)
+
+	return items
+}
+
+// searchIDs выполняет запрос и возвращает ID найденных элементов
+func searchIDs(t *testing.T, query string) []int {
+	results, err := SearchItemsWithSnippets(query)
+	require.NoError(t, err, query)
+
+	ids := make([]int, len(results))
+	for i, result := range results {
+		ids[i] = result.Item.ID
+	}
+	return ids
+}
+
+// TestSearchItems_StructuredQuery проверяет компиляцию запроса в SQL
+func TestSearchItems_StructuredQuery(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+	items := createSearchFixture(t)
+	id := func(keys ...string) []int {
+		ids := make([]int, len(keys))
+		for i, key := range keys {
+			ids[i] = items[key].ID
+		}
+		return ids
+	}
+
+	assert.ElementsMatch(t, id("image", "text", "archive"), searchIDs(t, "tag:work"))
+	assert.ElementsMatch(t, id("image", "text"), searchIDs(t, "tag:WORK -tag:Архив"))
+	assert.ElementsMatch(t, id("image", "link"), searchIDs(t, "type:image|link"))
+	assert.ElementsMatch(t, id("image", "link"), searchIDs(t, "type:image OR type:link"))
+	assert.ElementsMatch(t, id("folder"), searchIDs(t, "type:folder"))
+	assert.ElementsMatch(t, id("text", "archive"), searchIDs(t, "type:text"))
+	assert.ElementsMatch(t, id("image", "file", "link"), searchIDs(t, `in:"проекты"`))
+	assert.ElementsMatch(t, id("archive"), searchIDs(t, "created:<2025-01-01"))
+	assert.ElementsMatch(t, id("file"), searchIDs(t, "size:>10MB"))
+	assert.ElementsMatch(t, id("image", "file"), searchIDs(t, "size:>=1MB"))
+	assert.ElementsMatch(t, id("text"), searchIDs(t, `"годовой отчёт"`))
+	assert.ElementsMatch(t, id("file", "text"), searchIDs(t, "Договор OR Идеи"))
+	assert.ElementsMatch(t, id("image"), searchIDs(t, "tag:work type:image"))
+	assert.Empty(t, searchIDs(t, `"отчёт годовой"`), "фраза ищется целиком")
+}
@@ -0,0 +1,119 @@
+package queries
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/database/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requireSearchIndex пропускает тест, если SQLite собран без FTS5 (нужен тег sqlite_fts5)
+func requireSearchIndex(t *testing.T) {
+	if !database.SearchIndexAvailable(database.GetDB()) {
+		t.Skip("SQLite собран без FTS5, запустите тесты с -tags sqlite_fts5")
+	}
+}
+
+// TestSearchItems_TextBlockContent проверяет поиск по тексту блоков content_meta
+func TestSearchItems_TextBlockContent(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	item := &models.Item{
+		Type:        models.ItemTypeElement,
+		Title:       "Заметка",
+		ContentMeta: `[{"type":"text","content":"Рецепт борща со свёклой"},{"type":"link","content":"https://example.com"}]`,
+	}
+	require.NoError(t, CreateItem(item))
+	require.NoError(t, CreateItem(&models.Item{Type: models.ItemTypeElement, Title: "Другое"}))
+
+	results, err := SearchItemsWithSnippets("борща")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, item.ID, results[0].Item.ID)
+	assert.Contains(t, results[0].Snippet, SearchHighlightStart+"борща"+SearchHighlightEnd)
+}
+
+// TestSearchItems_RankingAndCase проверяет ранжирование и поиск без учёта регистра и по префиксу
+func TestSearchItems_RankingAndCase(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+	requireSearchIndex(t)
+
+	inDescription := &models.Item{Type: models.ItemTypeElement, Title: "Список", Description: "купить документы и хлеб"}
+	inTitle := &models.Item{Type: models.ItemTypeElement, Title: "Документы на визу"}
+	require.NoError(t, CreateItem(inDescription))
+	require.NoError(t, CreateItem(inTitle))
+
+	results, err := SearchItemsWithSnippets("ДОКУМ")
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, inTitle.ID, results[0].Item.ID, "совпадение в названии должно быть выше")
+	assert.Equal(t, inDescription.ID, results[1].Item.ID)
+	assert.LessOrEqual(t, results[0].Rank, results[1].Rank)
+}
+
+// TestSearchItems_IndexSync проверяет, что индекс следует за изменением элементов и тегов
+func TestSearchItems_IndexSync(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+	requireSearchIndex(t)
+	ctx := context.Background()
+
+	item := &models.Item{Type: models.ItemTypeElement, Title: "Отпуск"}
+	require.NoError(t, CreateItem(item))
+
+	tagIDs, err := GetOrCreateTags(ctx, []string{"путешествия"})
+	require.NoError(t, err)
+	require.NoError(t, ReplaceItemTags(ctx, item.ID, tagIDs))
+
+	results, err := SearchItems("путешествия")
+	require.NoError(t, err)
+	require.Len(t, results, 1, "элемент должен находиться по тегу")
+
+	tag, err := GetTagByID(ctx, tagIDs[0])
+	require.NoError(t, err)
+	tag.Name = "поездки"
+	require.NoError(t, UpdateTag(ctx, tag))
+
+	results, err = SearchItems("поездки")
+	require.NoError(t, err)
+	assert.Len(t, results, 1, "переименование тега должно попасть в индекс")
+	results, err = SearchItems("путешествия")
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	item.Title = "Командировка"
+	require.NoError(t, UpdateItem(item))
+	results, err = SearchItems("отпуск")
+	require.NoError(t, err)
+	assert.Empty(t, results, "старое название не должно находиться")
+
+	require.NoError(t, DeleteItem(item.ID))
+	results, err = SearchItems("командировка")
+	require.NoError(t, err)
+	assert.Empty(t, results, "удалённый элемент не должен находиться")
+}
+
+// TestSearchItems_RebuildExistingItems проверяет построение индекса для элементов, созданных до него
+func TestSearchItems_RebuildExistingItems(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+	requireSearchIndex(t)
+
+	_, err := database.GetDB().Exec(`DROP TRIGGER items_fts_ai`)
+	require.NoError(t, err)
+	require.NoError(t, CreateItem(&models.Item{Type: models.ItemTypeElement, Title: "Старый элемент"}))
+
+	require.NoError(t, database.Migrate(database.GetDB()))
+
+	results, err := SearchItemsWithSnippets("старый")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.True(t, strings.Contains(results[0].Snippet, SearchHighlightStart))
+}
@@ -0,0 +1,231 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"projectT/internal/storage/database/models"
+)
+
+// sharedItemsQuery выбирает элементы, доступные пиру.
+// ancestry связывает каждый элемент с ним самим и всеми родительскими папками,
+// applicable - правила, действующие на элемент через папки и теги.
+// Элемент доступен, если пир не заблокирован, ни одно правило не делает его
+// приватным и хотя бы одно правило открывает его публично или этому пиру.
+const sharedItemsQuery = `
+	WITH RECURSIVE ancestry(item_id, folder_id) AS (
+		SELECT id, id FROM items
+		UNION
+		SELECT a.item_id, i.parent_id
+		FROM ancestry a
+		JOIN items i ON i.id = a.folder_id
+		WHERE i.parent_id IS NOT NULL AND i.parent_id != 0
+	),
+	applicable(item_id, rule_id, visibility) AS (
+		SELECT a.item_id, r.id, r.visibility
+		FROM ancestry a
+		JOIN sharing_rules r ON r.entity_type = 'folder' AND r.entity_id = a.folder_id
+		UNION ALL
+		SELECT it.item_id, r.id, r.visibility
+		FROM item_tags it
+		JOIN sharing_rules r ON r.entity_type = 'tag' AND r.entity_id = it.tag_id
+	)
+	SELECT i.id, i.type, i.title, i.description, i.content_meta, i.parent_id,
+	       COALESCE(i.content_hash, ''), i.created_at, i.updated_at
+	FROM items i
+	WHERE i.trash_id IS NULL
+	  AND NOT EXISTS (SELECT 1 FROM contacts WHERE peer_id = ? AND is_blocked = 1)
+	  AND NOT EXISTS (
+		SELECT 1 FROM applicable ap
+		WHERE ap.item_id = i.id AND ap.visibility = 'private'
+	  )
+	  AND EXISTS (
+		SELECT 1 FROM applicable ap
+		WHERE ap.item_id = i.id
+		  AND (ap.visibility = 'public'
+		       OR (ap.visibility = 'contacts' AND EXISTS (
+		           SELECT 1 FROM sharing_rule_contacts src
+		           JOIN contacts c ON c.id = src.contact_id
+		           WHERE src.rule_id = ap.rule_id AND c.peer_id = ?
+		       )))
+	  )
+`
+
+// validateSharingRule проверяет тип сущности и уровень доступа
+func validateSharingRule(entityType string, visibility models.SharingVisibility) error {
+	if entityType != models.SharingEntityFolder && entityType != models.SharingEntityTag {
+		return fmt.Errorf("неизвестный тип сущности для правила доступа: %s", entityType)
+	}
+
+	switch visibility {
+	case models.SharingPrivate, models.SharingContacts, models.SharingPublic:
+		return nil
+	default:
+		return fmt.Errorf("неизвестный уровень доступа: %s", visibility)
+	}
+}
+
+// SetSharingRule создаёт или обновляет правило доступа к папке или тегу
+// и заменяет список контактов правила
+func (r *SharingRepo) SetSharingRule(rule *models.SharingRule) error {
+	if err := validateSharingRule(rule.EntityType, rule.Visibility); err != nil {
+		return err
+	}
+
+	tx, err := r.begin(context.Background())
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback() // Игнорируем ошибку отката, т.к. коммит уже мог состояться
+	}()
+
+	_, err = tx.Exec(`
+		INSERT INTO sharing_rules (entity_type, entity_id, visibility, created_at, updated_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		ON CONFLICT(entity_type, entity_id) DO UPDATE SET
+			visibility = excluded.visibility,
+			updated_at = CURRENT_TIMESTAMP
+	`, rule.EntityType, rule.EntityID, rule.Visibility)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения правила доступа: %w", err)
+	}
+
+	var ruleID int
+	err = tx.QueryRow(`SELECT id FROM sharing_rules WHERE entity_type = ? AND entity_id = ?`,
+		rule.EntityType, rule.EntityID).Scan(&ruleID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения правила доступа: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM sharing_rule_contacts WHERE rule_id = ?`, ruleID); err != nil {
+		return fmt.Errorf("ошибка очистки контактов правила: %w", err)
+	}
+
+	// Список контактов имеет смысл только для доступа выбранным контактам
+	if rule.Visibility == models.SharingContacts {
+		for _, contactID := range rule.ContactIDs {
+			if _, err := tx.Exec(`INSERT OR IGNORE INTO sharing_rule_contacts (rule_id, contact_id) VALUES (?, ?)`,
+				ruleID, contactID); err != nil {
+				return fmt.Errorf("ошибка добавления контакта в правило: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка коммита транзакции: %w", err)
+	}
+
+	rule.ID = ruleID
+	return nil
+}
+
+// GetSharingRule возвращает правило доступа к папке или тегу.
+// Если правило не задано, возвращается приватное правило с ID 0.
+func (r *SharingRepo) GetSharingRule(entityType string, entityID int) (*models.SharingRule, error) {
+	rule := &models.SharingRule{
+		EntityType: entityType,
+		EntityID:   entityID,
+		Visibility: models.SharingPrivate,
+	}
+
+	var createdAt, updatedAt sql.NullTime
+	err := r.conn().QueryRow(`
+		SELECT id, visibility, created_at, updated_at
+		FROM sharing_rules
+		WHERE entity_type = ? AND entity_id = ?
+	`, entityType, entityID).Scan(&rule.ID, &rule.Visibility, &createdAt, &updatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return rule, nil
+		}
+		return nil, err
+	}
+
+	if createdAt.Valid {
+		rule.CreatedAt = createdAt.Time
+	}
+	if updatedAt.Valid {
+		rule.UpdatedAt = updatedAt.Time
+	}
+
+	rows, err := r.conn().Query(`SELECT contact_id FROM sharing_rule_contacts WHERE rule_id = ? ORDER BY contact_id`, rule.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var contactID int
+		if err := rows.Scan(&contactID); err != nil {
+			return nil, err
+		}
+		rule.ContactIDs = append(rule.ContactIDs, contactID)
+	}
+
+	return rule, rows.Err()
+}
+
+// DeleteSharingRule удаляет правило доступа к папке или тегу (сущность снова становится приватной)
+func (r *SharingRepo) DeleteSharingRule(entityType string, entityID int) error {
+	return deleteSharingRule(r.conn(), entityType, entityID)
+}
+
+// deleteSharingRule удаляет правило доступа через db: его вызывает и удаление элемента,
+// чтобы правило удалялось в той же транзакции
+func deleteSharingRule(db DBTX, entityType string, entityID int) error {
+	_, err := db.Exec(`
+		DELETE FROM sharing_rule_contacts
+		WHERE rule_id IN (SELECT id FROM sharing_rules WHERE entity_type = ? AND entity_id = ?)
+	`, entityType, entityID)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`DELETE FROM sharing_rules WHERE entity_type = ? AND entity_id = ?`, entityType, entityID)
+	return err
+}
+
+// GetItemsSharedWithPeer возвращает элементы, к которым у пира есть доступ.
+// Заблокированному контакту всегда возвращается пустой список.
+func (r *SharingRepo) GetItemsSharedWithPeer(peerID string) ([]*models.Item, error) {
+	rows, err := r.conn().Query(sharedItemsQuery+` ORDER BY i.created_at DESC`, peerID, peerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*models.Item
+	for rows.Next() {
+		var item models.Item
+		var parentID sql.NullInt64
+		err := rows.Scan(
+			&item.ID, &item.Type, &item.Title, &item.Description, &item.ContentMeta, &parentID, &item.ContentHash, &item.CreatedAt, &item.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if parentID.Valid {
+			parentIDValue := int(parentID.Int64)
+			item.ParentID = &parentIDValue
+		}
+
+		items = append(items, &item)
+	}
+
+	return items, rows.Err()
+}
+
+// IsItemSharedWithPeer проверяет, есть ли у пира доступ к элементу
+func (r *SharingRepo) IsItemSharedWithPeer(peerID string, itemID int) (bool, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM (` + sharedItemsQuery + `) WHERE id = ?`
+	if err := r.conn().QueryRow(query, peerID, peerID, itemID).Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
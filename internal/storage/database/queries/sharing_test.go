@@ -0,0 +1,164 @@
+package queries
+
+import (
+	"context"
+	"testing"
+
+	"projectT/internal/storage/database/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createSharingTestItem создаёт элемент в указанной папке
+func createSharingTestItem(t *testing.T, itemType models.ItemType, title string, parentID *int) *models.Item {
+	item := &models.Item{Type: itemType, Title: title, ParentID: parentID}
+	require.NoError(t, CreateItem(item))
+	return item
+}
+
+// sharedTitles возвращает названия элементов, доступных пиру
+func sharedTitles(t *testing.T, peerID string) []string {
+	items, err := GetItemsSharedWithPeer(peerID)
+	require.NoError(t, err)
+
+	titles := make([]string, 0, len(items))
+	for _, item := range items {
+		titles = append(titles, item.Title)
+	}
+	return titles
+}
+
+// TestSharingDefaultsToPrivate проверяет, что без правил ничего не раскрывается
+func TestSharingDefaultsToPrivate(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	createSharingTestItem(t, models.ItemTypeElement, "Личная заметка", nil)
+
+	assert.Empty(t, sharedTitles(t, "peer-a"))
+
+	rule, err := GetSharingRule(models.SharingEntityFolder, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 0, rule.ID)
+	assert.Equal(t, models.SharingPrivate, rule.Visibility)
+}
+
+// TestSharingFolderInheritance проверяет наследование доступа вложенными элементами
+func TestSharingFolderInheritance(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	root := createSharingTestItem(t, models.ItemTypeFolder, "Публичная", nil)
+	child := createSharingTestItem(t, models.ItemTypeFolder, "Вложенная", &root.ID)
+	createSharingTestItem(t, models.ItemTypeElement, "Глубокий элемент", &child.ID)
+	secret := createSharingTestItem(t, models.ItemTypeFolder, "Секретная", &root.ID)
+	createSharingTestItem(t, models.ItemTypeElement, "Секрет", &secret.ID)
+	createSharingTestItem(t, models.ItemTypeElement, "Вне папки", nil)
+
+	require.NoError(t, SetSharingRule(&models.SharingRule{
+		EntityType: models.SharingEntityFolder, EntityID: root.ID, Visibility: models.SharingPublic,
+	}))
+	require.NoError(t, SetSharingRule(&models.SharingRule{
+		EntityType: models.SharingEntityFolder, EntityID: secret.ID, Visibility: models.SharingPrivate,
+	}))
+
+	assert.ElementsMatch(t, []string{"Публичная", "Вложенная", "Глубокий элемент"}, sharedTitles(t, "peer-a"))
+
+	shared, err := IsItemSharedWithPeer("peer-a", secret.ID)
+	require.NoError(t, err)
+	assert.False(t, shared, "явный запрет должен перекрывать доступ родителя")
+
+	// Удаление правила возвращает папку к наследованию
+	require.NoError(t, DeleteSharingRule(models.SharingEntityFolder, secret.ID))
+	assert.Contains(t, sharedTitles(t, "peer-a"), "Секрет")
+}
+
+// TestSharingSpecificContacts проверяет доступ только для выбранных контактов
+func TestSharingSpecificContacts(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	friend := &models.Contact{PeerID: "peer-friend"}
+	require.NoError(t, CreateContact(friend))
+	stranger := &models.Contact{PeerID: "peer-stranger"}
+	require.NoError(t, CreateContact(stranger))
+
+	folder := createSharingTestItem(t, models.ItemTypeFolder, "Для друга", nil)
+	rule := &models.SharingRule{
+		EntityType: models.SharingEntityFolder,
+		EntityID:   folder.ID,
+		Visibility: models.SharingContacts,
+		ContactIDs: []int{friend.ID},
+	}
+	require.NoError(t, SetSharingRule(rule))
+
+	assert.Equal(t, []string{"Для друга"}, sharedTitles(t, "peer-friend"))
+	assert.Empty(t, sharedTitles(t, "peer-stranger"))
+	assert.Empty(t, sharedTitles(t, "peer-unknown"))
+
+	saved, err := GetSharingRule(models.SharingEntityFolder, folder.ID)
+	require.NoError(t, err)
+	assert.Equal(t, rule.ID, saved.ID)
+	assert.Equal(t, []int{friend.ID}, saved.ContactIDs)
+}
+
+// TestSharingByTag проверяет доступ через тег и очистку правила при удалении тега
+func TestSharingByTag(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	item := createSharingTestItem(t, models.ItemTypeElement, "С тегом", nil)
+	createSharingTestItem(t, models.ItemTypeElement, "Без тега", nil)
+
+	tag := &models.Tag{Name: "общее"}
+	require.NoError(t, CreateTag(ctx, tag))
+	require.NoError(t, AddTagToItem(ctx, item.ID, tag.ID))
+	require.NoError(t, SetSharingRule(&models.SharingRule{
+		EntityType: models.SharingEntityTag, EntityID: tag.ID, Visibility: models.SharingPublic,
+	}))
+
+	assert.Equal(t, []string{"С тегом"}, sharedTitles(t, "peer-a"))
+
+	require.NoError(t, DeleteTag(ctx, tag.ID))
+	rule, err := GetSharingRule(models.SharingEntityTag, tag.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, rule.ID)
+}
+
+// TestSharingBlockedContact проверяет, что заблокированный контакт не получает ничего
+func TestSharingBlockedContact(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	blocked := &models.Contact{PeerID: "peer-blocked", IsBlocked: true}
+	require.NoError(t, CreateContact(blocked))
+
+	folder := createSharingTestItem(t, models.ItemTypeFolder, "Публичная", nil)
+	require.NoError(t, SetSharingRule(&models.SharingRule{
+		EntityType: models.SharingEntityFolder,
+		EntityID:   folder.ID,
+		Visibility: models.SharingContacts,
+		ContactIDs: []int{blocked.ID},
+	}))
+	assert.Empty(t, sharedTitles(t, "peer-blocked"))
+
+	require.NoError(t, SetSharingRule(&models.SharingRule{
+		EntityType: models.SharingEntityFolder, EntityID: folder.ID, Visibility: models.SharingPublic,
+	}))
+	assert.Empty(t, sharedTitles(t, "peer-blocked"))
+
+	shared, err := IsItemSharedWithPeer("peer-blocked", folder.ID)
+	require.NoError(t, err)
+	assert.False(t, shared)
+}
+
+// TestSetSharingRuleValidation проверяет отклонение неверных правил
+func TestSetSharingRuleValidation(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	assert.Error(t, SetSharingRule(&models.SharingRule{EntityType: "item", EntityID: 1, Visibility: models.SharingPublic}))
+	assert.Error(t, SetSharingRule(&models.SharingRule{EntityType: models.SharingEntityTag, EntityID: 1, Visibility: "friends"}))
+}
@@ -5,51 +5,38 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"projectT/internal/storage/database"
 	"projectT/internal/storage/database/models"
 	"strings"
 	"sync"
 	"time"
 )
 
-// Кэш структуры таблицы тегов
+// tagTableInfo результат проверки структуры таблицы тегов
+type tagTableInfo struct {
+	hasDesc   bool
+	checkedAt time.Time
+}
+
+// Кэш структуры таблицы тегов по базам: после переключения библиотеки database.GetDB() - другая база
 var (
-	tagTableChecked   bool
-	tagTableHasDesc   bool
-	tagTableMutex     sync.RWMutex
-	tagTableCheckTime time.Time
+	tagTableCache = map[*sql.DB]tagTableInfo{}
+	tagTableMutex sync.RWMutex
 )
 
-// checkTagTableStructure проверяет структуру таблицы тегов с кэшированием
-// Если tx передана, использует её, иначе использует database.DB
-func checkTagTableStructure(ctx context.Context, tx *sql.Tx) (bool, error) {
-	tagTableMutex.RLock()
-	// Кэшируем на 5 минут
-	if tagTableChecked && time.Since(tagTableCheckTime) < 5*time.Minute {
-		hasDesc := tagTableHasDesc
+// checkTagTableStructure проверяет структуру таблицы тегов в db. Для *sql.DB результат кэшируется
+// на 5 минут; внутри транзакции таблица проверяется через неё саму.
+func checkTagTableStructure(ctx context.Context, db DBTX) (bool, error) {
+	conn, cacheable := db.(*sql.DB)
+	if cacheable {
+		tagTableMutex.RLock()
+		info, ok := tagTableCache[conn]
 		tagTableMutex.RUnlock()
-		return hasDesc, nil
-	}
-	tagTableMutex.RUnlock()
-
-	tagTableMutex.Lock()
-	defer tagTableMutex.Unlock()
-
-	// Двойная проверка
-	if tagTableChecked && time.Since(tagTableCheckTime) < 5*time.Minute {
-		return tagTableHasDesc, nil
-	}
-
-	query := `PRAGMA table_info(tags)`
-	var rows *sql.Rows
-	var err error
-
-	if tx != nil {
-		rows, err = tx.QueryContext(ctx, query)
-	} else {
-		rows, err = database.DB.QueryContext(ctx, query)
+		if ok && time.Since(info.checkedAt) < 5*time.Minute {
+			return info.hasDesc, nil
+		}
 	}
 
+	rows, err := db.QueryContext(ctx, `PRAGMA table_info(tags)`)
 	if err != nil {
 		return false, fmt.Errorf("ошибка проверки структуры таблицы тегов: %w", err)
 	}
@@ -72,30 +59,23 @@ func checkTagTableStructure(ctx context.Context, tx *sql.Tx) (bool, error) {
 		}
 	}
 
-	// Обновляем кэш
-	tagTableChecked = true
-	tagTableHasDesc = hasDescription
-	tagTableCheckTime = time.Now()
+	if cacheable {
+		tagTableMutex.Lock()
+		tagTableCache[conn] = tagTableInfo{hasDesc: hasDescription, checkedAt: time.Now()}
+		tagTableMutex.Unlock()
+	}
 
 	return hasDescription, nil
 }
 
-// BeginTransaction начинает транзакцию
-func BeginTransaction(ctx context.Context) (*sql.Tx, error) {
-	return database.DB.BeginTx(ctx, &sql.TxOptions{
-		Isolation: sql.LevelSerializable,
-		ReadOnly:  false,
-	})
-}
-
 // CreateTag создает новый тег в транзакции
-func CreateTag(ctx context.Context, tag *models.Tag) error {
-	hasDesc, err := checkTagTableStructure(ctx, nil)
+func (r *TagsRepo) CreateTag(ctx context.Context, tag *models.Tag) error {
+	hasDesc, err := checkTagTableStructure(ctx, r.conn())
 	if err != nil {
 		return err
 	}
 
-	tx, err := BeginTransaction(ctx)
+	tx, err := r.begin(ctx)
 	if err != nil {
 		return fmt.Errorf("ошибка начала транзакции: %w", err)
 	}
@@ -133,20 +113,20 @@ func CreateTag(ctx context.Context, tag *models.Tag) error {
 }
 
 // GetTagByID возвращает тег по ID
-func GetTagByID(ctx context.Context, id int) (*models.Tag, error) {
-	hasDesc, err := checkTagTableStructure(ctx, nil)
+func (r *TagsRepo) GetTagByID(ctx context.Context, id int) (*models.Tag, error) {
+	hasDesc, err := checkTagTableStructure(ctx, r.conn())
 	if err != nil {
 		return nil, err
 	}
 
 	var tag models.Tag
 	if hasDesc {
-		err = database.DB.QueryRowContext(ctx,
+		err = r.conn().QueryRowContext(ctx,
 			`SELECT id, name, description, color FROM tags WHERE id = ?`,
 			id,
 		).Scan(&tag.ID, &tag.Name, &tag.Description, &tag.Color)
 	} else {
-		err = database.DB.QueryRowContext(ctx,
+		err = r.conn().QueryRowContext(ctx,
 			`SELECT id, name, color FROM tags WHERE id = ?`,
 			id,
 		).Scan(&tag.ID, &tag.Name, &tag.Color)
@@ -164,20 +144,20 @@ func GetTagByID(ctx context.Context, id int) (*models.Tag, error) {
 }
 
 // GetTagByName возвращает тег по имени
-func GetTagByName(ctx context.Context, name string) (*models.Tag, error) {
-	hasDesc, err := checkTagTableStructure(ctx, nil)
+func (r *TagsRepo) GetTagByName(ctx context.Context, name string) (*models.Tag, error) {
+	hasDesc, err := checkTagTableStructure(ctx, r.conn())
 	if err != nil {
 		return nil, err
 	}
 
 	var tag models.Tag
 	if hasDesc {
-		err = database.DB.QueryRowContext(ctx,
+		err = r.conn().QueryRowContext(ctx,
 			`SELECT id, name, description, color FROM tags WHERE name = ?`,
 			name,
 		).Scan(&tag.ID, &tag.Name, &tag.Description, &tag.Color)
 	} else {
-		err = database.DB.QueryRowContext(ctx,
+		err = r.conn().QueryRowContext(ctx,
 			`SELECT id, name, color FROM tags WHERE name = ?`,
 			name,
 		).Scan(&tag.ID, &tag.Name, &tag.Color)
@@ -195,9 +175,9 @@ func GetTagByName(ctx context.Context, name string) (*models.Tag, error) {
 }
 
 // GetOrCreateTag получает существующий тег или создает новый
-func GetOrCreateTag(ctx context.Context, name string) (*models.Tag, error) {
+func (r *TagsRepo) GetOrCreateTag(ctx context.Context, name string) (*models.Tag, error) {
 	// Сначала пытаемся получить существующий тег
-	tag, err := GetTagByName(ctx, name)
+	tag, err := r.GetTagByName(ctx, name)
 	if err == nil {
 		return tag, nil
 	}
@@ -208,9 +188,9 @@ func GetOrCreateTag(ctx context.Context, name string) (*models.Tag, error) {
 		Color: "#808080", // Серый цвет по умолчанию
 	}
 
-	if err := CreateTag(ctx, newTag); err != nil {
+	if err := r.CreateTag(ctx, newTag); err != nil {
 		// Проверяем, не создался ли тег параллельно
-		tag, err2 := GetTagByName(ctx, name)
+		tag, err2 := r.GetTagByName(ctx, name)
 		if err2 == nil {
 			return tag, nil
 		}
@@ -221,7 +201,7 @@ func GetOrCreateTag(ctx context.Context, name string) (*models.Tag, error) {
 }
 
 // GetOrCreateTags получает или создает несколько тегов
-func GetOrCreateTags(ctx context.Context, tagNames []string) ([]int, error) {
+func (r *TagsRepo) GetOrCreateTags(ctx context.Context, tagNames []string) ([]int, error) {
 	if len(tagNames) == 0 {
 		return []int{}, nil
 	}
@@ -245,7 +225,7 @@ func GetOrCreateTags(ctx context.Context, tagNames []string) ([]int, error) {
 	}
 
 	// Начинаем транзакцию
-	tx, err := BeginTransaction(ctx)
+	tx, err := r.begin(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
 	}
@@ -326,8 +306,8 @@ func GetOrCreateTags(ctx context.Context, tagNames []string) ([]int, error) {
 }
 
 // GetAllTags возвращает все теги с подсчетом элементов
-func GetAllTags(ctx context.Context) ([]*models.Tag, error) {
-	hasDesc, err := checkTagTableStructure(ctx, nil)
+func (r *TagsRepo) GetAllTags(ctx context.Context) ([]*models.Tag, error) {
+	hasDesc, err := checkTagTableStructure(ctx, r.conn())
 	if err != nil {
 		return nil, err
 	}
@@ -339,6 +319,7 @@ func GetAllTags(ctx context.Context) ([]*models.Tag, error) {
 			       COUNT(DISTINCT it.item_id) as item_count
 			FROM tags t
 			LEFT JOIN item_tags it ON t.id = it.tag_id
+			     AND it.item_id IN (SELECT id FROM items WHERE trash_id IS NULL)
 			GROUP BY t.id, t.name, t.color, t.description
 			ORDER BY t.name
 		`
@@ -348,12 +329,13 @@ func GetAllTags(ctx context.Context) ([]*models.Tag, error) {
 			       COUNT(DISTINCT it.item_id) as item_count
 			FROM tags t
 			LEFT JOIN item_tags it ON t.id = it.tag_id
+			     AND it.item_id IN (SELECT id FROM items WHERE trash_id IS NULL)
 			GROUP BY t.id, t.name, t.color
 			ORDER BY t.name
 		`
 	}
 
-	rows, err := database.DB.QueryContext(ctx, query)
+	rows, err := r.conn().QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка запроса тегов: %w", err)
 	}
@@ -386,8 +368,8 @@ func GetAllTags(ctx context.Context) ([]*models.Tag, error) {
 }
 
 // SearchTagsByName ищет теги по имени
-func SearchTagsByName(ctx context.Context, name string) ([]*models.Tag, error) {
-	hasDesc, err := checkTagTableStructure(ctx, nil)
+func (r *TagsRepo) SearchTagsByName(ctx context.Context, name string) ([]*models.Tag, error) {
+	hasDesc, err := checkTagTableStructure(ctx, r.conn())
 	if err != nil {
 		return nil, err
 	}
@@ -401,6 +383,7 @@ func SearchTagsByName(ctx context.Context, name string) ([]*models.Tag, error) {
 			       COUNT(DISTINCT it.item_id) as item_count
 			FROM tags t
 			LEFT JOIN item_tags it ON t.id = it.tag_id
+			     AND it.item_id IN (SELECT id FROM items WHERE trash_id IS NULL)
 			WHERE LOWER(t.name) LIKE ?
 			GROUP BY t.id, t.name, t.color, t.description
 			ORDER BY t.name
@@ -412,6 +395,7 @@ func SearchTagsByName(ctx context.Context, name string) ([]*models.Tag, error) {
 			       COUNT(DISTINCT it.item_id) as item_count
 			FROM tags t
 			LEFT JOIN item_tags it ON t.id = it.tag_id
+			     AND it.item_id IN (SELECT id FROM items WHERE trash_id IS NULL)
 			WHERE LOWER(t.name) LIKE ?
 			GROUP BY t.id, t.name, t.color
 			ORDER BY t.name
@@ -419,7 +403,7 @@ func SearchTagsByName(ctx context.Context, name string) ([]*models.Tag, error) {
 		`
 	}
 
-	rows, err := database.DB.QueryContext(ctx, query, searchTerm)
+	rows, err := r.conn().QueryContext(ctx, query, searchTerm)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка поиска тегов: %w", err)
 	}
@@ -452,13 +436,13 @@ func SearchTagsByName(ctx context.Context, name string) ([]*models.Tag, error) {
 }
 
 // UpdateTag обновляет тег
-func UpdateTag(ctx context.Context, tag *models.Tag) error {
-	hasDesc, err := checkTagTableStructure(ctx, nil)
+func (r *TagsRepo) UpdateTag(ctx context.Context, tag *models.Tag) error {
+	hasDesc, err := checkTagTableStructure(ctx, r.conn())
 	if err != nil {
 		return err
 	}
 
-	tx, err := BeginTransaction(ctx)
+	tx, err := r.begin(ctx)
 	if err != nil {
 		return fmt.Errorf("ошибка начала транзакции: %w", err)
 	}
@@ -489,8 +473,8 @@ func UpdateTag(ctx context.Context, tag *models.Tag) error {
 }
 
 // DeleteTag удаляет тег
-func DeleteTag(ctx context.Context, id int) error {
-	tx, err := BeginTransaction(ctx)
+func (r *TagsRepo) DeleteTag(ctx context.Context, id int) error {
+	tx, err := r.begin(ctx)
 	if err != nil {
 		return fmt.Errorf("ошибка начала транзакции: %w", err)
 	}
@@ -499,11 +483,27 @@ func DeleteTag(ctx context.Context, id int) error {
 	}()
 
 	// Удаляем связи с элементами
+	if err := touchItems(ctx, tx, `SELECT item_id FROM item_tags WHERE tag_id = ?`, id); err != nil {
+		return err
+	}
 	_, err = tx.ExecContext(ctx, `DELETE FROM item_tags WHERE tag_id = ?`, id)
 	if err != nil {
 		return fmt.Errorf("ошибка удаления связей тега: %w", err)
 	}
 
+	// Удаляем правило доступа тега, чтобы оно не досталось новому тегу с тем же ID
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM sharing_rule_contacts
+		WHERE rule_id IN (SELECT id FROM sharing_rules WHERE entity_type = 'tag' AND entity_id = ?)
+	`, id)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления контактов правила доступа тега: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM sharing_rules WHERE entity_type = 'tag' AND entity_id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления правила доступа тега: %w", err)
+	}
+
 	// Удаляем сам тег
 	_, err = tx.ExecContext(ctx, `DELETE FROM tags WHERE id = ?`, id)
 	if err != nil {
@@ -517,33 +517,49 @@ func DeleteTag(ctx context.Context, id int) error {
 	return nil
 }
 
+// touchItems отмечает элементы, выбранные подзапросом ids, изменёнными. Теги определяют доступ
+// к элементу, поэтому их изменение должно попасть в дельта-синхронизацию по updated_at.
+func touchItems(ctx context.Context, db DBTX, ids string, args ...interface{}) error {
+	args = append([]interface{}{time.Now()}, args...)
+	if _, err := db.ExecContext(ctx, `UPDATE items SET updated_at = ? WHERE id IN (`+ids+`)`, args...); err != nil {
+		return fmt.Errorf("ошибка обновления времени изменения элементов: %w", err)
+	}
+	return nil
+}
+
 // AddTagToItem добавляет связь тега с элементом
-func AddTagToItem(ctx context.Context, itemID, tagID int) error {
-	_, err := database.DB.ExecContext(ctx,
+func (r *TagsRepo) AddTagToItem(ctx context.Context, itemID, tagID int) error {
+	result, err := r.conn().ExecContext(ctx,
 		`INSERT OR IGNORE INTO item_tags (item_id, tag_id) VALUES (?, ?)`,
 		itemID, tagID,
 	)
 	if err != nil {
 		return fmt.Errorf("ошибка добавления связи тега: %w", err)
 	}
-	return nil
+	if added, err := result.RowsAffected(); err != nil || added == 0 {
+		return nil
+	}
+	return touchItems(ctx, r.conn(), `?`, itemID)
 }
 
 // RemoveTagFromItem удаляет связь тега с элементом
-func RemoveTagFromItem(ctx context.Context, itemID, tagID int) error {
-	_, err := database.DB.ExecContext(ctx,
+func (r *TagsRepo) RemoveTagFromItem(ctx context.Context, itemID, tagID int) error {
+	result, err := r.conn().ExecContext(ctx,
 		`DELETE FROM item_tags WHERE item_id = ? AND tag_id = ?`,
 		itemID, tagID,
 	)
 	if err != nil {
 		return fmt.Errorf("ошибка удаления связи тега: %w", err)
 	}
-	return nil
+	if removed, err := result.RowsAffected(); err != nil || removed == 0 {
+		return nil
+	}
+	return touchItems(ctx, r.conn(), `?`, itemID)
 }
 
 // ReplaceItemTags заменяет все теги элемента на новые
-func ReplaceItemTags(ctx context.Context, itemID int, tagIDs []int) error {
-	tx, err := BeginTransaction(ctx)
+func (r *TagsRepo) ReplaceItemTags(ctx context.Context, itemID int, tagIDs []int) error {
+	tx, err := r.begin(ctx)
 	if err != nil {
 		return fmt.Errorf("ошибка начала транзакции: %w", err)
 	}
@@ -579,6 +595,10 @@ func ReplaceItemTags(ctx context.Context, itemID int, tagIDs []int) error {
 		}
 	}
 
+	if err := touchItems(ctx, tx, `?`, itemID); err != nil {
+		return err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("ошибка коммита транзакции: %w", err)
 	}
@@ -587,8 +607,8 @@ func ReplaceItemTags(ctx context.Context, itemID int, tagIDs []int) error {
 }
 
 // GetTagsForItem возвращает все теги элемента
-func GetTagsForItem(ctx context.Context, itemID int) ([]*models.Tag, error) {
-	hasDesc, err := checkTagTableStructure(ctx, nil)
+func (r *TagsRepo) GetTagsForItem(ctx context.Context, itemID int) ([]*models.Tag, error) {
+	hasDesc, err := checkTagTableStructure(ctx, r.conn())
 	if err != nil {
 		return nil, err
 	}
@@ -612,7 +632,7 @@ func GetTagsForItem(ctx context.Context, itemID int) ([]*models.Tag, error) {
 		`
 	}
 
-	rows, err := database.DB.QueryContext(ctx, query, itemID)
+	rows, err := r.conn().QueryContext(ctx, query, itemID)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка запроса тегов элемента: %w", err)
 	}
@@ -642,17 +662,17 @@ func GetTagsForItem(ctx context.Context, itemID int) ([]*models.Tag, error) {
 }
 
 // GetItemsForTag возвращает все элементы тега
-func GetItemsForTag(ctx context.Context, tagID int) ([]*models.Item, error) {
+func (r *TagsRepo) GetItemsForTag(ctx context.Context, tagID int) ([]*models.Item, error) {
 	query := `
 		SELECT i.id, i.type, i.title, i.description, i.content_meta,
 		       i.parent_id, i.created_at, i.updated_at
 		FROM items i
 		INNER JOIN item_tags it ON i.id = it.item_id
-		WHERE it.tag_id = ?
+		WHERE it.tag_id = ? AND i.trash_id IS NULL
 		ORDER BY i.updated_at DESC
 	`
 
-	rows, err := database.DB.QueryContext(ctx, query, tagID)
+	rows, err := r.conn().QueryContext(ctx, query, tagID)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка запроса элементов тега: %w", err)
 	}
@@ -680,14 +700,15 @@ func GetItemsForTag(ctx context.Context, tagID int) ([]*models.Item, error) {
 }
 
 // GetTagsUsageCount возвращает количество использований каждого тега
-func GetTagsUsageCount(ctx context.Context) (map[int]int, error) {
+func (r *TagsRepo) GetTagsUsageCount(ctx context.Context) (map[int]int, error) {
 	query := `
 		SELECT tag_id, COUNT(*) as usage_count
 		FROM item_tags
+		WHERE item_id IN (SELECT id FROM items WHERE trash_id IS NULL)
 		GROUP BY tag_id
 	`
 
-	rows, err := database.DB.QueryContext(ctx, query)
+	rows, err := r.conn().QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка запроса статистики тегов: %w", err)
 	}
@@ -710,12 +731,12 @@ func GetTagsUsageCount(ctx context.Context) (map[int]int, error) {
 }
 
 // BulkUpdateTags обновляет несколько тегов в одной транзакции
-func BulkUpdateTags(ctx context.Context, tags []*models.Tag) error {
+func (r *TagsRepo) BulkUpdateTags(ctx context.Context, tags []*models.Tag) error {
 	if len(tags) == 0 {
 		return nil
 	}
 
-	tx, err := BeginTransaction(ctx)
+	tx, err := r.begin(ctx)
 	if err != nil {
 		return fmt.Errorf("ошибка начала транзакции: %w", err)
 	}
@@ -0,0 +1,247 @@
+package queries
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/database/models"
+)
+
+// ErrTrashEntryNotFound возвращается, если записи корзины не существует
+var ErrTrashEntryNotFound = errors.New("запись корзины не найдена")
+
+// trashedItemsSubquery выбирает ID элементов, удалённых вместе с записью корзины
+const trashedItemsSubquery = `SELECT id FROM items WHERE trash_id = ?`
+
+// MoveItemToTrash перемещает элемент в корзину вместе со всеми вложенными элементами.
+// Строки, теги и файлы не удаляются: поддерево лишь помечается trash_id и скрывается из выборок.
+func MoveItemToTrash(itemID int) (*models.TrashEntry, error) {
+	tx, err := database.GetDB().Begin()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback() // Игнорируем ошибку отката, т.к. коммит уже мог состояться
+	}()
+
+	var parentID, trashID sql.NullInt64
+	err = tx.QueryRow(`SELECT parent_id, trash_id FROM items WHERE id = ?`, itemID).Scan(&parentID, &trashID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("элемент не найден")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения элемента: %w", err)
+	}
+	if trashID.Valid {
+		return nil, errors.New("элемент уже в корзине")
+	}
+
+	entry := &models.TrashEntry{ItemID: itemID, DeletedAt: time.Now()}
+	if parentID.Valid && parentID.Int64 != 0 {
+		parent := int(parentID.Int64)
+		entry.OriginalParentID = &parent
+	}
+
+	result, err := tx.Exec(`INSERT INTO trash (item_id, original_parent_id, deleted_at) VALUES (?, ?, ?)`,
+		itemID, entry.OriginalParentID, entry.DeletedAt)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания записи корзины: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	entry.ID = int(id)
+
+	// Уже удалённые ранее вложенные элементы остаются в своих записях корзины
+	result, err = tx.Exec(`
+		UPDATE items SET trash_id = ?
+		WHERE id IN (
+			WITH RECURSIVE subtree(id) AS (
+				SELECT ?
+				UNION
+				SELECT c.id FROM items c
+				JOIN subtree s ON c.parent_id = s.id
+				WHERE c.trash_id IS NULL
+			)
+			SELECT id FROM subtree
+		)
+	`, entry.ID, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка перемещения элементов в корзину: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	entry.ItemCount = int(affected)
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка коммита транзакции: %w", err)
+	}
+
+	return entry, nil
+}
+
+// GetTrashEntries возвращает содержимое корзины, начиная с последних удалённых
+func GetTrashEntries() ([]*models.TrashEntry, error) {
+	rows, err := database.GetDB().Query(`
+		SELECT t.id, t.item_id, t.original_parent_id, t.deleted_at,
+		       (SELECT COUNT(*) FROM items WHERE trash_id = t.id),
+		       i.id, i.type, i.title, i.description, i.content_meta, i.parent_id, COALESCE(i.content_hash, ''), i.created_at, i.updated_at
+		FROM trash t
+		JOIN items i ON i.id = t.item_id
+		ORDER BY t.deleted_at DESC, t.id DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.TrashEntry
+	for rows.Next() {
+		var entry models.TrashEntry
+		var item models.Item
+		var originalParentID, parentID sql.NullInt64
+		err := rows.Scan(
+			&entry.ID, &entry.ItemID, &originalParentID, &entry.DeletedAt, &entry.ItemCount,
+			&item.ID, &item.Type, &item.Title, &item.Description, &item.ContentMeta, &parentID, &item.ContentHash, &item.CreatedAt, &item.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if originalParentID.Valid {
+			value := int(originalParentID.Int64)
+			entry.OriginalParentID = &value
+		}
+		if parentID.Valid {
+			value := int(parentID.Int64)
+			item.ParentID = &value
+		}
+		entry.Item = &item
+
+		entries = append(entries, &entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// GetExpiredTrashEntryIDs возвращает записи корзины, удалённые раньше cutoff
+func GetExpiredTrashEntryIDs(cutoff time.Time) ([]int, error) {
+	rows, err := database.GetDB().Query(`SELECT id FROM trash WHERE deleted_at < ? ORDER BY id`, cutoff.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// RestoreFromTrash возвращает элемент из корзины вместе с поддеревом, тегами и файлами.
+// Если исходной папки больше нет (или она сама в корзине), элемент восстанавливается в корень.
+func RestoreFromTrash(trashID int) (*models.Item, error) {
+	tx, err := database.GetDB().Begin()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback() // Игнорируем ошибку отката, т.к. коммит уже мог состояться
+	}()
+
+	var itemID int
+	var originalParentID sql.NullInt64
+	err = tx.QueryRow(`SELECT item_id, original_parent_id FROM trash WHERE id = ?`, trashID).Scan(&itemID, &originalParentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTrashEntryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения записи корзины: %w", err)
+	}
+
+	var parentID *int
+	if originalParentID.Valid {
+		var alive int
+		err := tx.QueryRow(`SELECT COUNT(*) FROM items WHERE id = ? AND type = 'folder' AND trash_id IS NULL`,
+			originalParentID.Int64).Scan(&alive)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка проверки исходной папки: %w", err)
+		}
+		if alive > 0 {
+			value := int(originalParentID.Int64)
+			parentID = &value
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE items SET trash_id = NULL WHERE trash_id = ?`, trashID); err != nil {
+		return nil, fmt.Errorf("ошибка восстановления элементов: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE items SET parent_id = ? WHERE id = ?`, parentID, itemID); err != nil {
+		return nil, fmt.Errorf("ошибка восстановления расположения элемента: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM trash WHERE id = ?`, trashID); err != nil {
+		return nil, fmt.Errorf("ошибка удаления записи корзины: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка коммита транзакции: %w", err)
+	}
+
+	return GetItemByID(itemID)
+}
+
+// PurgeTrashEntry окончательно удаляет поддерево записи корзины со связями.
+// Файлы только отвязываются: блоб может использоваться другим элементом или сообщением чата,
+// а неиспользуемые блобы удаляет с диска сборщик мусора.
+func PurgeTrashEntry(trashID int) error {
+	tx, err := database.GetDB().Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback() // Игнорируем ошибку отката, т.к. коммит уже мог состояться
+	}()
+
+	var exists int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM trash WHERE id = ?`, trashID).Scan(&exists); err != nil {
+		return fmt.Errorf("ошибка получения записи корзины: %w", err)
+	}
+	if exists == 0 {
+		return ErrTrashEntryNotFound
+	}
+
+	// items удаляются последними: остальные выражения выбирают поддерево по trash_id
+	statements := []string{
+		`DELETE FROM item_files WHERE is_remote = 0 AND item_id IN (` + trashedItemsSubquery + `)`,
+		`DELETE FROM item_tags WHERE item_id IN (` + trashedItemsSubquery + `)`,
+		`DELETE FROM pinned_items WHERE item_id IN (` + trashedItemsSubquery + `)`,
+		`DELETE FROM favorites WHERE entity_type = 'folder' AND entity_id IN (` + trashedItemsSubquery + `)`,
+		`DELETE FROM sharing_rule_contacts WHERE rule_id IN (
+			SELECT id FROM sharing_rules WHERE entity_type = 'folder' AND entity_id IN (` + trashedItemsSubquery + `)
+		)`,
+		`DELETE FROM sharing_rules WHERE entity_type = 'folder' AND entity_id IN (` + trashedItemsSubquery + `)`,
+		`DELETE FROM items WHERE trash_id = ?`,
+		`DELETE FROM trash WHERE id = ?`,
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt, trashID); err != nil {
+			return fmt.Errorf("ошибка окончательного удаления: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка коммита транзакции: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"projectT/internal/storage/database/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTrashTestItem создаёт элемент в указанной папке
+func createTrashTestItem(t *testing.T, itemType models.ItemType, title string, parentID *int) *models.Item {
+	item := &models.Item{Type: itemType, Title: title, ParentID: parentID, ContentMeta: "[]"}
+	require.NoError(t, CreateItem(item))
+	return item
+}
+
+// visibleIDs возвращает ID элементов, видимых в GetAllItems
+func visibleIDs(t *testing.T) []int {
+	items, err := GetAllItems()
+	require.NoError(t, err)
+
+	ids := make([]int, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
+
+// TestTrashMoveAndRestoreSubtree проверяет, что папка уходит в корзину и возвращается целиком
+func TestTrashMoveAndRestoreSubtree(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	folder := createTrashTestItem(t, models.ItemTypeFolder, "Рецепты", nil)
+	note := createTrashTestItem(t, models.ItemTypeElement, "Борщ", &folder.ID)
+	sub := createTrashTestItem(t, models.ItemTypeFolder, "Десерты", &folder.ID)
+	cake := createTrashTestItem(t, models.ItemTypeElement, "Торт", &sub.ID)
+	other := createTrashTestItem(t, models.ItemTypeElement, "Другое", nil)
+
+	tag, err := GetOrCreateTag(ctx, "еда")
+	require.NoError(t, err)
+	require.NoError(t, AddTagToItem(ctx, note.ID, tag.ID))
+	require.NoError(t, CreateItemFile(&models.ItemFile{ItemID: cake.ID, Hash: "abc123", FilePath: "ab/abc123.png", Size: 10}))
+
+	entry, err := MoveItemToTrash(folder.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, entry.ItemCount)
+	assert.Nil(t, entry.OriginalParentID)
+
+	assert.Equal(t, []int{other.ID}, visibleIDs(t))
+	children, err := GetItemsByParent(folder.ID)
+	require.NoError(t, err)
+	assert.Empty(t, children)
+
+	tagItems, err := GetItemsForTag(ctx, tag.ID)
+	require.NoError(t, err)
+	assert.Empty(t, tagItems)
+
+	results, err := SearchItems("Борщ")
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	_, err = MoveItemToTrash(note.ID)
+	assert.Error(t, err, "элемент уже в корзине")
+
+	_, err = GetItemByID(note.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows, "элемент в корзине не возвращается по ID")
+
+	entries, err := GetTrashEntries()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "Рецепты", entries[0].Item.Title)
+	assert.Equal(t, 4, entries[0].ItemCount)
+
+	restored, err := RestoreFromTrash(entry.ID)
+	require.NoError(t, err)
+	assert.Equal(t, folder.ID, restored.ID)
+
+	assert.ElementsMatch(t, []int{folder.ID, note.ID, sub.ID, cake.ID, other.ID}, visibleIDs(t))
+
+	restoredCake, err := GetItemByID(cake.ID)
+	require.NoError(t, err)
+	require.NotNil(t, restoredCake.ParentID)
+	assert.Equal(t, sub.ID, *restoredCake.ParentID)
+
+	tags, err := GetTagsForItem(ctx, note.ID)
+	require.NoError(t, err)
+	require.Len(t, tags, 1)
+	assert.Equal(t, "еда", tags[0].Name)
+
+	files, err := GetFilesByItemID(cake.ID)
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	entries, err = GetTrashEntries()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+// TestTrashRestoreWithoutParent проверяет восстановление в корень, если исходная папка тоже в корзине
+func TestTrashRestoreWithoutParent(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	folder := createTrashTestItem(t, models.ItemTypeFolder, "Папка", nil)
+	note := createTrashTestItem(t, models.ItemTypeElement, "Заметка", &folder.ID)
+
+	noteEntry, err := MoveItemToTrash(note.ID)
+	require.NoError(t, err)
+	require.NotNil(t, noteEntry.OriginalParentID)
+	assert.Equal(t, folder.ID, *noteEntry.OriginalParentID)
+
+	folderEntry, err := MoveItemToTrash(folder.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, folderEntry.ItemCount, "ранее удалённая заметка остаётся в своей записи")
+
+	restored, err := RestoreFromTrash(noteEntry.ID)
+	require.NoError(t, err)
+	assert.Nil(t, restored.ParentID)
+	assert.Equal(t, []int{note.ID}, visibleIDs(t))
+
+	// Папка восстанавливается отдельно и пустой
+	_, err = RestoreFromTrash(folderEntry.ID)
+	require.NoError(t, err)
+	children, err := GetItemsByParent(folder.ID)
+	require.NoError(t, err)
+	assert.Empty(t, children)
+
+	_, err = RestoreFromTrash(folderEntry.ID)
+	assert.ErrorIs(t, err, ErrTrashEntryNotFound)
+}
+
+// TestTrashPurge проверяет окончательное удаление поддерева со связями
+func TestTrashPurge(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	folder := createTrashTestItem(t, models.ItemTypeFolder, "Старое", nil)
+	note := createTrashTestItem(t, models.ItemTypeElement, "Черновик", &folder.ID)
+
+	tag, err := GetOrCreateTag(ctx, "черновики")
+	require.NoError(t, err)
+	require.NoError(t, AddTagToItem(ctx, note.ID, tag.ID))
+	require.NoError(t, CreateItemFile(&models.ItemFile{ItemID: note.ID, Hash: "def456", FilePath: "de/def456.txt", Size: 5}))
+	require.NoError(t, AddToFavorites("folder", folder.ID))
+
+	entry, err := MoveItemToTrash(folder.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, PurgeTrashEntry(entry.ID))
+
+	// Файл только отвязан: блоб удалит сборщик мусора
+	record, err := GetFileRecord("def456")
+	require.NoError(t, err)
+	assert.Zero(t, record.RefCount)
+	assert.NotNil(t, record.UnreferencedAt)
+
+	_, err = GetItemByID(note.ID)
+	assert.Error(t, err)
+	remaining, err := GetFilesByItemID(note.ID)
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+
+	usage, err := GetTagsUsageCount(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, usage[tag.ID])
+
+	favorites, err := GetAllFavorites()
+	require.NoError(t, err)
+	assert.Empty(t, favorites)
+
+	assert.ErrorIs(t, PurgeTrashEntry(entry.ID), ErrTrashEntryNotFound)
+}
+
+// TestTrashExpiredEntries проверяет выбор записей, пролежавших в корзине дольше срока
+func TestTrashExpiredEntries(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	note := createTrashTestItem(t, models.ItemTypeElement, "Заметка", nil)
+	entry, err := MoveItemToTrash(note.ID)
+	require.NoError(t, err)
+
+	expired, err := GetExpiredTrashEntryIDs(time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+
+	expired, err = GetExpiredTrashEntryIDs(time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []int{entry.ID}, expired)
+}
@@ -0,0 +1,113 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/vault"
+)
+
+// sealedColumn возвращает значение чувствительной колонки для записи: зашифрованное, если хранилище
+// разблокировано. Для заглушки заблокированного хранилища возвращает nil, и запрос с COALESCE(?, колонка)
+// оставляет прежнее зашифрованное значение вместо того, чтобы затереть его заглушкой.
+func sealedColumn(value string) (interface{}, error) {
+	if value == vault.LockedPlaceholder && !vault.Unlocked() {
+		return nil, nil
+	}
+	return vault.EncryptField(value)
+}
+
+// sensitiveColumns колонки, которые шифруются в зашифрованном хранилище.
+// Поля элементов не шифруются: по ним работают полнотекстовый поиск, триггеры ссылок на файлы и фильтры.
+var sensitiveColumns = []struct{ table, column string }{
+	{"chat_messages", "content"},
+	{"contacts", "notes"},
+}
+
+// RecodeSensitiveColumns пропускает значения чувствительных колонок через recode и записывает
+// изменившиеся в одной транзакции. Возвращает число изменённых значений.
+func RecodeSensitiveColumns(ctx context.Context, recode func(string) (string, error)) (int, error) {
+	tx, err := database.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback() // Игнорируем ошибку отката, т.к. коммит уже мог состояться
+	}()
+
+	changed := 0
+	for _, c := range sensitiveColumns {
+		values, err := readColumn(ctx, tx, c.table, c.column)
+		if err != nil {
+			return 0, err
+		}
+		for id, value := range values {
+			recoded, err := recode(value)
+			if err != nil {
+				return 0, fmt.Errorf("%s.%s (id=%d): %w", c.table, c.column, id, err)
+			}
+			if recoded == value {
+				continue
+			}
+			// updated_at не трогаем: смена формата хранения не редактирование
+			if _, err := tx.ExecContext(ctx, `UPDATE `+c.table+` SET `+c.column+` = ? WHERE id = ?`, recoded, id); err != nil {
+				return 0, fmt.Errorf("ошибка обновления %s.%s: %w", c.table, c.column, err)
+			}
+			changed++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	return changed, nil
+}
+
+// readColumn читает непустые значения колонки по id
+func readColumn(ctx context.Context, tx *sql.Tx, table, column string) (map[int]string, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT id, `+column+` FROM `+table+` WHERE COALESCE(`+column+`, '') != ''`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения %s.%s: %w", table, column, err)
+	}
+	defer rows.Close()
+
+	values := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var value string
+		if err := rows.Scan(&id, &value); err != nil {
+			return nil, err
+		}
+		values[id] = value
+	}
+	return values, rows.Err()
+}
+
+// ClearPageTexts удаляет открытый текст копий страниц и убирает его из полнотекстового индекса.
+// Текст уже лежит в хранилище файлов и в зашифрованном хранилище в базу не попадает.
+func ClearPageTexts(ctx context.Context) error {
+	tx, err := database.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback() // Игнорируем ошибку отката, т.к. коммит уже мог состояться
+	}()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM page_texts`); err != nil {
+		return fmt.Errorf("ошибка удаления текста копий страниц: %w", err)
+	}
+	// Триггер индекса срабатывает только на добавление текста
+	if database.SearchIndexAvailable(tx) {
+		if _, err := tx.ExecContext(ctx, `UPDATE items_fts SET archive = '' WHERE archive != ''`); err != nil {
+			return fmt.Errorf("ошибка удаления текста копий страниц из поиска: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	return nil
+}
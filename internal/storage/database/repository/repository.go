@@ -0,0 +1,201 @@
+// Package repository описывает доступ сервисов к базе данных через интерфейсы.
+//
+// Сервисы получают репозитории в конструкторах, а не обращаются к глобальной базе database.GetDB():
+// так их можно проверить на отдельной базе или подставной реализации, а несколько
+// операций - выполнить в одной транзакции (см. WithTx). SQLite-реализация - типы
+// пакета queries, созданные над *sql.DB или *sql.Tx.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/queries"
+)
+
+// Items элементы и поиск по ним
+type Items interface {
+	CreateItem(item *models.Item) error
+	GetItemByID(id int) (*models.Item, error)
+	GetItemByHash(contentHash string) (*models.Item, error)
+	GetItemsByParent(parentID int) ([]*models.Item, error)
+	GetAllItems() ([]*models.Item, error)
+	ListItems(opts queries.ItemListOptions, after *queries.ItemCursor, limit int) (*queries.ItemPage, error)
+	PinItem(itemID int) error
+	UnpinItem(itemID int) error
+	IsItemPinned(itemID int) (bool, error)
+	UpdateItem(item *models.Item) error
+	DeleteItem(id int) error
+	GetItemSubtree(rootID int) ([]*models.Item, error)
+	UpdateItemTimestamps(id int, createdAt, updatedAt time.Time) error
+	UpdateItemContentMeta(id int, oldMeta, newMeta, contentHash string) (bool, error)
+	GetItemsWithLinks() ([]*models.Item, error)
+	SearchItems(query string) ([]*models.Item, error)
+	SearchItemsWithSnippets(query string) ([]*models.SearchResult, error)
+	SearchItemsByQuery(query *queries.SearchQuery) ([]*models.SearchResult, error)
+}
+
+// Tags теги и их связи с элементами
+type Tags interface {
+	CreateTag(ctx context.Context, tag *models.Tag) error
+	GetTagByID(ctx context.Context, id int) (*models.Tag, error)
+	GetTagByName(ctx context.Context, name string) (*models.Tag, error)
+	GetOrCreateTag(ctx context.Context, name string) (*models.Tag, error)
+	GetOrCreateTags(ctx context.Context, tagNames []string) ([]int, error)
+	GetAllTags(ctx context.Context) ([]*models.Tag, error)
+	SearchTagsByName(ctx context.Context, name string) ([]*models.Tag, error)
+	UpdateTag(ctx context.Context, tag *models.Tag) error
+	DeleteTag(ctx context.Context, id int) error
+	AddTagToItem(ctx context.Context, itemID, tagID int) error
+	RemoveTagFromItem(ctx context.Context, itemID, tagID int) error
+	ReplaceItemTags(ctx context.Context, itemID int, tagIDs []int) error
+	GetTagsForItem(ctx context.Context, itemID int) ([]*models.Tag, error)
+	GetItemsForTag(ctx context.Context, tagID int) ([]*models.Item, error)
+	GetTagsUsageCount(ctx context.Context) (map[int]int, error)
+	BulkUpdateTags(ctx context.Context, tags []*models.Tag) error
+}
+
+// Files файлы элементов и записи о файлах хранилища
+type Files interface {
+	CreateItemFile(file *models.ItemFile) error
+	GetItemFile(itemID int) (*models.ItemFile, error)
+	GetFileByHash(hash string) (*models.ItemFile, error)
+	GetFilesByItemID(itemID int) ([]*models.ItemFile, error)
+	GetRemoteFilesByPeer(sourcePeerID string) ([]*models.ItemFile, error)
+	UpdateItemFile(file *models.ItemFile) error
+	DeleteItemFile(itemID int, hash string) error
+	DeleteFilesByItemID(itemID int) error
+	DeleteRemoteFilesByPeer(sourcePeerID string) error
+	ItemFileExists(hash string) (bool, error)
+	GetFileRecord(hash string) (*models.File, error)
+	GetReferencedFileHashes() (map[string]bool, error)
+	GetRecentlyUnreferencedHashes(since time.Time) (map[string]bool, error)
+	DeleteUnreferencedFileRecords(hashes []string) error
+	SavePageText(hash, text string) error
+	GetUnindexedPageTexts() ([]string, error)
+}
+
+// Contacts контакты
+type Contacts interface {
+	GetContact(id int) (*models.Contact, error)
+	GetContactByPeerID(peerID string) (*models.Contact, error)
+	GetAllContacts() ([]*models.Contact, error)
+	CreateContact(contact *models.Contact) error
+	UpdateContact(contact *models.Contact) error
+	UpdateContactLastSeen(id int, lastSeen *time.Time) error
+	UpdateContactNotes(id int, notes string) error
+	UpdateContactMultiaddr(id int, multiaddr string) error
+	UpdateContactByPeerID(peerID, multiaddr string) error
+	DeleteContact(id int) error
+	DeleteContactByPeerID(peerID string) error
+	IsContactBlocked(peerID string) (bool, error)
+	SearchContacts(query string) ([]*models.Contact, error)
+	BlockContact(id int) error
+	UnblockContact(id int) error
+}
+
+// Messages сообщения чата
+type Messages interface {
+	GetChatMessage(id int) (*models.ChatMessage, error)
+	GetMessagesForContact(contactID int, limit, offset int) ([]*models.ChatMessage, error)
+	GetUnreadMessagesCount(contactID int) (int, error)
+	CreateChatMessage(message *models.ChatMessage) error
+	MarkMessageAsRead(id int) error
+	MarkAllMessagesAsRead(contactID int) error
+	UpdateChatMessage(message *models.ChatMessage) error
+	UpdateChatMessageMetadata(id int, metadata string) error
+	GetFileMessagesForContact(contactID int) ([]*models.ChatMessage, error)
+	DeleteChatMessage(id int) error
+	DeleteMessagesForContact(contactID int) error
+	GetLastMessageForContact(contactID int) (*models.ChatMessage, error)
+}
+
+// Profiles локальный и удалённые профили
+type Profiles interface {
+	GetLocalProfile() (*models.Profile, error)
+	GetRemoteProfile(peerID string) (*models.Profile, error)
+	EnsureProfileForContact(peerID, username, avatarPath string) error
+	GetAllRemoteProfiles() ([]*models.Profile, error)
+	CreateRemoteProfile(profile *models.Profile) error
+	UpdateRemoteProfile(profile *models.Profile) error
+	UpdateLocalProfile(profile *models.Profile) error
+	UpdateLocalProfileField(field string, value interface{}) error
+	DeleteRemoteProfile(peerID string) error
+	ProfileExists(peerID string) (bool, error)
+	GetProfileByPeerID(peerID string) (*models.Profile, error)
+}
+
+// Favorites избранное
+type Favorites interface {
+	AddToFavorites(entityType string, entityID int) error
+	RemoveFromFavorites(entityType string, entityID int) error
+	IsFavorite(entityType string, entityID int) (bool, error)
+	GetFavoriteFolders() ([]*models.Item, error)
+	GetFavoriteTags() ([]*models.Tag, error)
+	GetFavoriteSavedSearches() ([]*models.SavedSearch, error)
+	GetAllFavorites() ([]*models.Favorite, error)
+}
+
+// Sharing правила доступа пиров к папкам и тегам
+type Sharing interface {
+	SetSharingRule(rule *models.SharingRule) error
+	GetSharingRule(entityType string, entityID int) (*models.SharingRule, error)
+	DeleteSharingRule(entityType string, entityID int) error
+	GetItemsSharedWithPeer(peerID string) ([]*models.Item, error)
+	IsItemSharedWithPeer(peerID string, itemID int) (bool, error)
+}
+
+// Repositories набор репозиториев над одним соединением
+type Repositories struct {
+	Items     Items
+	Tags      Tags
+	Files     Files
+	Contacts  Contacts
+	Messages  Messages
+	Profiles  Profiles
+	Favorites Favorites
+	Sharing   Sharing
+}
+
+// NewSQLite создает репозитории над db (*sql.DB или *sql.Tx)
+func NewSQLite(db queries.DBTX) *Repositories {
+	return &Repositories{
+		Items:     queries.NewItemsRepo(db),
+		Tags:      queries.NewTagsRepo(db),
+		Files:     queries.NewFilesRepo(db),
+		Contacts:  queries.NewContactsRepo(db),
+		Messages:  queries.NewMessagesRepo(db),
+		Profiles:  queries.NewProfilesRepo(db),
+		Favorites: queries.NewFavoritesServiceImplWithDB(db),
+		Sharing:   queries.NewSharingRepo(db),
+	}
+}
+
+// Default возвращает репозитории над текущей базой database.GetDB().
+// Соединение читается при каждом запросе, поэтому их можно создать до открытия базы.
+func Default() *Repositories {
+	return NewSQLite(nil)
+}
+
+// WithTx выполняет fn с репозиториями внутри одной транзакции db.
+// Транзакция фиксируется, если fn вернула nil, и откатывается в остальных случаях.
+func WithTx(ctx context.Context, db *sql.DB, fn func(repos *Repositories) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback() // Игнорируем ошибку отката, т.к. коммит уже мог состояться
+	}()
+
+	if err := fn(NewSQLite(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка коммита транзакции: %w", err)
+	}
+	return nil
+}
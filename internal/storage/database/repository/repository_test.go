@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/database/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWithTx проверяет фиксацию транзакции при успехе и откат при ошибке
+func TestWithTx(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "repo.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.Migrate(db))
+	ctx := context.Background()
+
+	err = WithTx(ctx, db, func(repos *Repositories) error {
+		return repos.Items.CreateItem(&models.Item{Type: models.ItemTypeFolder, Title: "Сохранена"})
+	})
+	require.NoError(t, err)
+
+	errAbort := errors.New("отмена")
+	err = WithTx(ctx, db, func(repos *Repositories) error {
+		item := &models.Item{Type: models.ItemTypeElement, Title: "Откачена"}
+		if err := repos.Items.CreateItem(item); err != nil {
+			return err
+		}
+		if err := repos.Favorites.AddToFavorites("folder", item.ID); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+
+	items, err := NewSQLite(db).Items.GetAllItems()
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "Сохранена", items[0].Title)
+
+	favorites, err := NewSQLite(db).Favorites.GetAllFavorites()
+	require.NoError(t, err)
+	assert.Empty(t, favorites)
+}
@@ -0,0 +1,179 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+// Полнотекстовый индекс items_fts - производные данные: он не входит в
+// версионированные миграции и пересоздаётся при запуске, если отсутствует
+// или устарела его схема. FTS5 доступен только при сборке с тегом sqlite_fts5;
+// без него триггеры индекса удаляются, а поиск работает через LIKE.
+
+// searchIndexTriggers триггеры, поддерживающие items_fts в актуальном состоянии
+var searchIndexTriggers = []string{
+	"items_fts_ai", "items_fts_au", "items_fts_ad",
+	"item_tags_fts_ai", "item_tags_fts_ad", "tags_fts_au",
+	"page_texts_fts_ai",
+}
+
+// searchBlocksExpr выражение для json_each по блокам content_meta; повреждённый JSON считается пустым списком
+func searchBlocksExpr(contentMeta string) string {
+	return fmt.Sprintf(`json_each(CASE WHEN json_valid(%[1]s) THEN
+			CASE WHEN json_type(%[1]s) = 'array' THEN %[1]s ELSE '[]' END
+			ELSE '[]' END)`, contentMeta)
+}
+
+// searchTextExpr выражение с текстом текстовых блоков content_meta элемента
+func searchTextExpr(contentMeta string) string {
+	return fmt.Sprintf(`(
+		SELECT COALESCE(group_concat(json_extract(b.value, '$.content'), ' '), '')
+		FROM %s b
+		WHERE json_extract(b.value, '$.type') = 'text'
+	)`, searchBlocksExpr(contentMeta))
+}
+
+// searchArchiveExpr выражение с текстом сохранённых копий страниц из блоков ссылок content_meta элемента
+func searchArchiveExpr(contentMeta string) string {
+	return fmt.Sprintf(`(
+		SELECT COALESCE(group_concat(p.text, ' '), '')
+		FROM %s b
+		JOIN page_texts p ON p.hash = json_extract(b.value, '$.snapshot.text_hash')
+		WHERE json_extract(b.value, '$.type') = 'link'
+	)`, searchBlocksExpr(contentMeta))
+}
+
+// searchTagsExpr выражение с именами тегов элемента через пробел
+func searchTagsExpr(itemID string) string {
+	return fmt.Sprintf(`(
+		SELECT COALESCE(group_concat(t.name, ' '), '')
+		FROM item_tags it
+		JOIN tags t ON t.id = it.tag_id
+		WHERE it.item_id = %s
+	)`, itemID)
+}
+
+// searchIndexInsert вставляет в индекс строку элемента, поля которого доступны через ref (например NEW)
+func searchIndexInsert(ref string) string {
+	return fmt.Sprintf(`INSERT INTO items_fts (rowid, title, description, content, tags, archive)
+		VALUES (%[1]s.id, COALESCE(%[1]s.title, ''), COALESCE(%[1]s.description, ''), %[2]s, %[3]s, %[4]s)`,
+		ref, searchTextExpr(ref+".content_meta"), searchTagsExpr(ref+".id"), searchArchiveExpr(ref+".content_meta"))
+}
+
+// RowQuerier то, из чего можно прочитать строку: *sql.DB или *sql.Tx
+type RowQuerier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// SearchIndexAvailable проверяет, собран ли SQLite с FTS5 и поддерживается ли индекс триггерами
+func SearchIndexAvailable(db RowQuerier) bool {
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name = 'items_fts_ai'
+	`).Scan(&count)
+	return err == nil && count > 0 && fts5Enabled(db)
+}
+
+// fts5Enabled проверяет, собран ли SQLite с модулем FTS5
+func fts5Enabled(db RowQuerier) bool {
+	var enabled bool
+	err := db.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&enabled)
+	return err == nil && enabled
+}
+
+// ensureSearchIndex создаёт полнотекстовый индекс и его триггеры.
+// Если триггеров не было (новая база или запуск без FTS5) или в индексе нет новых колонок,
+// индекс пересоздаётся и заполняется целиком.
+func ensureSearchIndex(db *sql.DB) error {
+	if !fts5Enabled(db) {
+		// Триггеры на items_fts без модуля FTS5 ломают любую запись в items
+		for _, name := range searchIndexTriggers {
+			if _, err := db.Exec(`DROP TRIGGER IF EXISTS ` + name); err != nil {
+				return fmt.Errorf("ошибка удаления триггера %s: %w", name, err)
+			}
+		}
+		log.Println("SQLite собран без FTS5: полнотекстовый поиск отключён")
+		return nil
+	}
+
+	missing := false
+	for _, name := range searchIndexTriggers {
+		var count int
+		err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name = ?`, name).Scan(&count)
+		if err != nil {
+			return fmt.Errorf("ошибка проверки триггеров поиска: %w", err)
+		}
+		missing = missing || count == 0
+	}
+	// Колонка archive появилась позже остальных: индекс без неё пересоздаётся
+	var archiveColumn int
+	err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('items_fts') WHERE name = 'archive'`).Scan(&archiveColumn)
+	if err != nil {
+		return fmt.Errorf("ошибка проверки полнотекстового индекса: %w", err)
+	}
+	if !missing && archiveColumn > 0 {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback() // Игнорируем ошибку отката, т.к. коммит уже мог состояться
+	}()
+
+	var statements []string
+	for _, name := range searchIndexTriggers {
+		statements = append(statements, `DROP TRIGGER IF EXISTS `+name)
+	}
+	statements = append(statements,
+		// Пока триггеров не было, индекс мог устареть, а его схема - поменяться: пересоздаём его полностью
+		`DROP TABLE IF EXISTS items_fts`,
+		`CREATE VIRTUAL TABLE items_fts USING fts5(
+			title, description, content, tags, archive,
+			tokenize = 'unicode61 remove_diacritics 2'
+		)`,
+		`CREATE TRIGGER items_fts_ai AFTER INSERT ON items BEGIN
+			`+searchIndexInsert("NEW")+`;
+		END`,
+		`CREATE TRIGGER items_fts_au AFTER UPDATE OF title, description, content_meta ON items BEGIN
+			DELETE FROM items_fts WHERE rowid = OLD.id;
+			`+searchIndexInsert("NEW")+`;
+		END`,
+		`CREATE TRIGGER items_fts_ad AFTER DELETE ON items BEGIN
+			DELETE FROM items_fts WHERE rowid = OLD.id;
+		END`,
+		`CREATE TRIGGER item_tags_fts_ai AFTER INSERT ON item_tags BEGIN
+			UPDATE items_fts SET tags = `+searchTagsExpr("NEW.item_id")+` WHERE rowid = NEW.item_id;
+		END`,
+		`CREATE TRIGGER item_tags_fts_ad AFTER DELETE ON item_tags BEGIN
+			UPDATE items_fts SET tags = `+searchTagsExpr("OLD.item_id")+` WHERE rowid = OLD.item_id;
+		END`,
+		`CREATE TRIGGER tags_fts_au AFTER UPDATE OF name ON tags BEGIN
+			UPDATE items_fts SET tags = `+searchTagsExpr("items_fts.rowid")+`
+			WHERE rowid IN (SELECT item_id FROM item_tags WHERE tag_id = NEW.id);
+		END`,
+		// Текст копии страницы может появиться позже блока ссылки, например после синхронизации
+		`CREATE TRIGGER page_texts_fts_ai AFTER INSERT ON page_texts BEGIN
+			UPDATE items_fts SET archive = `+searchArchiveExpr("(SELECT content_meta FROM items WHERE id = items_fts.rowid)")+`
+			WHERE rowid IN (SELECT id FROM items WHERE instr(content_meta, NEW.hash) > 0);
+		END`,
+		`INSERT INTO items_fts (rowid, title, description, content, tags, archive)
+		SELECT i.id, COALESCE(i.title, ''), COALESCE(i.description, ''), `+
+			searchTextExpr("i.content_meta")+`, `+searchTagsExpr("i.id")+`, `+searchArchiveExpr("i.content_meta")+`
+		FROM items i`,
+	)
+
+	if err := execAll(tx, statements...); err != nil {
+		return fmt.Errorf("ошибка создания полнотекстового индекса: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка коммита транзакции: %w", err)
+	}
+
+	log.Println("Полнотекстовый индекс элементов перестроен")
+	return nil
+}
@@ -1,6 +1,10 @@
 package filesystem
 
 import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
 )
 
@@ -65,3 +69,38 @@ func TestFilesystem(t *testing.T) {
 	t.Logf("File hash: %s", fileData.Hash)
 	t.Log("All tests passed!")
 }
+
+// TestRemoveStalePlainCaches проверяет, что удаляются только копии завершившихся процессов
+func TestRemoveStalePlainCaches(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+
+	// PID процесса, который уже завершился
+	exited := exec.Command(os.Args[0], "-test.run=^$")
+	if err := exited.Run(); err != nil {
+		t.Fatalf("Failed to run process: %v", err)
+	}
+
+	dirs := map[int]bool{os.Getpid(): true, os.Getppid(): true, exited.Process.Pid: false}
+	for pid := range dirs {
+		dir := filepath.Join(os.TempDir(), fmt.Sprintf("%s%d", plainCachePrefix, pid))
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatalf("Failed to create cache dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "plain"), []byte("secret"), 0600); err != nil {
+			t.Fatalf("Failed to write cached file: %v", err)
+		}
+	}
+
+	if err := RemoveStalePlainCaches(); err != nil {
+		t.Fatalf("Failed to remove stale caches: %v", err)
+	}
+	for pid, kept := range dirs {
+		_, err := os.Stat(filepath.Join(os.TempDir(), fmt.Sprintf("%s%d", plainCachePrefix, pid)))
+		if kept && err != nil {
+			t.Fatalf("Expected cache of running process %d to be kept: %v", pid, err)
+		}
+		if !kept && !os.IsNotExist(err) {
+			t.Fatalf("Expected cache of exited process %d to be removed, got %v", pid, err)
+		}
+	}
+}
@@ -3,10 +3,13 @@ package filesystem
 import (
 	"fmt"
 	"io"
+	"io/fs"
 	"mime"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
 // FileData содержит информацию о файле
@@ -37,10 +40,11 @@ func SaveFileWithOriginalName(fileBytes []byte, originalName string) (*FileData,
 
 	// Проверяем, существует ли уже файл с таким хэшем (и расширением)
 	if _, err := os.Stat(filePath); err == nil {
-		// Файл уже существует, возвращаем информацию о нем
-		info, err := os.Stat(filePath)
-		if err != nil {
-			return nil, err
+		// Обновляем время изменения: повторно сохранённый блоб не должен попасть
+		// под сборщик мусора, пока на него не появилась ссылка
+		now := time.Now()
+		if err := os.Chtimes(filePath, now, now); err != nil {
+			return nil, fmt.Errorf("ошибка обновления времени файла: %w", err)
 		}
 
 		// Определяем MIME-тип на основе расширения или содержимого
@@ -49,9 +53,10 @@ func SaveFileWithOriginalName(fileBytes []byte, originalName string) (*FileData,
 			mimeType = detectMimeType(fileBytes)
 		}
 
+		// Файл уже существует, возвращаем информацию о нем
 		return &FileData{
 			Hash:     hash,
-			Size:     info.Size(),
+			Size:     int64(len(fileBytes)),
 			MimeType: mimeType,
 			Path:     filePath,
 		}, nil
@@ -62,8 +67,8 @@ func SaveFileWithOriginalName(fileBytes []byte, originalName string) (*FileData,
 		return nil, fmt.Errorf("ошибка создания директории: %w", err)
 	}
 
-	// Сохраняем файл
-	if err := os.WriteFile(filePath, fileBytes, 0644); err != nil {
+	// Сохраняем файл (в зашифрованном хранилище - зашифрованным)
+	if err := WriteBlobFile(filePath, fileBytes); err != nil {
 		return nil, fmt.Errorf("ошибка сохранения файла: %w", err)
 	}
 
@@ -77,15 +82,9 @@ func SaveFileWithOriginalName(fileBytes []byte, originalName string) (*FileData,
 		}
 	}
 
-	// Получаем информацию о файле
-	info, err := os.Stat(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка получения информации о файле: %w", err)
-	}
-
 	return &FileData{
 		Hash:     hash,
-		Size:     info.Size(),
+		Size:     int64(len(fileBytes)),
 		MimeType: mimeType,
 		Path:     filePath,
 	}, nil
@@ -100,8 +99,8 @@ func ReadFile(hash string) ([]byte, error) {
 		return nil, fmt.Errorf("файл с хэшем %s не найден", hash)
 	}
 
-	// Читаем файл
-	fileBytes, err := os.ReadFile(filePath)
+	// Читаем файл, расшифровывая его при необходимости
+	fileBytes, err := ReadBlobFile(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка чтения файла: %w", err)
 	}
@@ -122,46 +121,122 @@ func DeleteFile(hash string) error {
 	if err := os.Remove(filePath); err != nil {
 		return fmt.Errorf("ошибка удаления файла: %w", err)
 	}
+	RemoveThumbnails(hash)
 
 	return nil
 }
 
+// BlobInfo блоб на диске в хэш-ориентированном хранилище
+type BlobInfo struct {
+	Hash    string
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// ListBlobs возвращает все блобы хранилища файлов.
+// Один хэш может встречаться несколько раз, если файл сохранялся с разными расширениями.
+func ListBlobs() ([]BlobInfo, error) {
+	root := filepath.Join(storageRoot, filesDir)
+
+	var blobs []BlobInfo
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		name := entry.Name()
+		hash := strings.TrimSuffix(name, filepath.Ext(name))
+		if !IsValidHash(hash) {
+			// Посторонние файлы в хранилище не трогаем
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		blobs = append(blobs, BlobInfo{
+			Hash:    hash,
+			Path:    path,
+			Size:    plainSize(path, info.Size()),
+			ModTime: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка обхода хранилища файлов: %w", err)
+	}
+
+	return blobs, nil
+}
+
+// RemoveBlob удаляет блоб с диска
+func RemoveBlob(blob BlobInfo) error {
+	if err := os.Remove(blob.Path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("ошибка удаления файла: %w", err)
+	}
+	RemoveThumbnails(blob.Hash)
+	return nil
+}
+
+// QuarantineBlob переносит повреждённый блоб в карантин, чтобы он не отдавался как исправный.
+// Возвращает новый путь к файлу.
+func QuarantineBlob(blob BlobInfo) (string, error) {
+	target := filepath.Join(GetQuarantineDir(), filepath.Base(blob.Path))
+	if err := EnsureParentDir(target); err != nil {
+		return "", fmt.Errorf("ошибка создания директории карантина: %w", err)
+	}
+	if err := os.Rename(blob.Path, target); err != nil {
+		return "", fmt.Errorf("ошибка переноса файла в карантин: %w", err)
+	}
+	// Миниатюры могли быть построены по повреждённому содержимому
+	RemoveThumbnails(blob.Hash)
+	return target, nil
+}
+
 // GetFileInfo возвращает информацию о файле по его хэшу
 func GetFileInfo(hash string) (*FileData, error) {
 	filePath := GetFilePathByHash(hash)
 
-	// Получаем информацию о файле
-	info, err := os.Stat(filePath)
+	// Открываем файл: размер и первые байты для MIME-типа берутся из открытого содержимого
+	file, size, err := OpenBlobFile(filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, fmt.Errorf("файл с хэшем %s не найден", hash)
 		}
-		return nil, fmt.Errorf("ошибка получения информации о файле: %w", err)
-	}
-
-	// Читаем первые байты файла для определения MIME-типа
-	file, err := os.Open(filePath)
-	if err != nil {
 		return nil, fmt.Errorf("ошибка открытия файла: %w", err)
 	}
 	defer file.Close()
 
 	buffer := make([]byte, 512)
-	_, err = file.Read(buffer)
-	if err != nil && err != io.EOF {
+	n, err := io.ReadFull(file, buffer)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
 		return nil, fmt.Errorf("ошибка чтения файла: %w", err)
 	}
 
-	mimeType := detectMimeType(buffer)
+	// Только прочитанные байты: нули в хвосте буфера делают любой короткий файл "бинарным"
+	mimeType := detectMimeType(buffer[:n])
 
 	return &FileData{
 		Hash:     hash,
-		Size:     info.Size(),
+		Size:     size,
 		MimeType: mimeType,
 		Path:     filePath,
 	}, nil
 }
 
+// DetectMimeType определяет MIME-тип по содержимому файла так же, как при сохранении
+func DetectMimeType(fileBytes []byte) string {
+	return detectMimeType(fileBytes)
+}
+
 // detectMimeType определяет MIME-тип на основе содержимого файла
 func detectMimeType(fileBytes []byte) string {
 	mimeType := http.DetectContentType(fileBytes)
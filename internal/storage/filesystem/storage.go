@@ -3,6 +3,7 @@ package filesystem
 import (
 	"os"
 	"path/filepath"
+	"strconv"
 )
 
 var (
@@ -81,3 +82,46 @@ func GetStorageRoot() string {
 func GetFilesDir() string {
 	return filesDir
 }
+
+// GetTransfersDir возвращает директорию для незавершённых P2P-передач файлов
+func GetTransfersDir() string {
+	return filepath.Join(storageRoot, "transfers")
+}
+
+// GetQuarantineDir возвращает директорию для повреждённых файлов, найденных проверкой целостности
+func GetQuarantineDir() string {
+	return filepath.Join(storageRoot, "quarantine")
+}
+
+// GetRestoreDir возвращает директорию, куда файлы из резервной копии расшифровываются перед восстановлением
+func GetRestoreDir() string {
+	return filepath.Join(storageRoot, "restore")
+}
+
+// GetThumbnailsDir возвращает директорию кэша миниатюр
+func GetThumbnailsDir() string {
+	return filepath.Join(storageRoot, "thumbnails")
+}
+
+// GetThumbnailPath возвращает путь к миниатюре файла с хэшем hash и размером size:
+// thumbnails/{размер}/{первые_2_символа_хэша}/{хэш}
+func GetThumbnailPath(hash string, size int) string {
+	prefix := hash
+	if len(hash) >= 2 {
+		prefix = hash[:2]
+	}
+	return filepath.Join(GetThumbnailsDir(), strconv.Itoa(size), prefix, hash)
+}
+
+// RemoveThumbnails удаляет все миниатюры файла
+func RemoveThumbnails(hash string) {
+	sizes, err := os.ReadDir(GetThumbnailsDir())
+	if err != nil {
+		return
+	}
+	for _, entry := range sizes {
+		if size, err := strconv.Atoi(entry.Name()); err == nil && entry.IsDir() {
+			os.Remove(GetThumbnailPath(hash, size))
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package filesystem
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"projectT/internal/storage/vault"
+)
+
+// Файлы хранилища шифруются прозрачно: пока мастер-ключ разблокирован (vault.Unlocked),
+// SaveFileWithOriginalName пишет их зашифрованными, а ReadFile и OpenBlobFile расшифровывают
+// по заголовку, так что зашифрованные и открытые файлы могут лежать вперемешку.
+// Хэш и имя файла всегда считаются по открытому содержимому.
+
+// ErrBlobCorrupted содержимое файла не совпадает с хэшем или не расшифровывается
+var ErrBlobCorrupted = errors.New("файл повреждён")
+
+// plainCacheMu защищает временные расшифрованные копии файлов
+var plainCacheMu sync.Mutex
+
+// VaultEnabled сообщает, включено ли шифрование для текущего хранилища
+func VaultEnabled() bool {
+	return vault.Enabled(storageRoot)
+}
+
+// WriteBlobFile записывает содержимое файла на диск, шифруя его при разблокированном хранилище.
+// Запись идёт через временный файл, чтобы прерванная запись не оставила блоб с верным именем и неверным содержимым.
+func WriteBlobFile(filePath string, data []byte) error {
+	tmp := filePath + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	if vault.Unlocked() {
+		err = vault.Seal(out, bytes.NewReader(data), int64(len(data)))
+	} else {
+		_, err = out.Write(data)
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, filePath)
+}
+
+// OpenBlobFile открывает файл хранилища для чтения открытого содержимого и возвращает его размер.
+// Зашифрованный файл при заблокированном хранилище возвращает vault.ErrLocked.
+func OpenBlobFile(filePath string) (io.ReadCloser, int64, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	header := make([]byte, vault.HeaderSize)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		file.Close()
+		return nil, 0, err
+	}
+	if !vault.IsSealed(header[:n]) {
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return nil, 0, err
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			file.Close()
+			return nil, 0, err
+		}
+		return file, info.Size(), nil
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	plain, err := vault.Open(file)
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	return &blobReader{Reader: plain, file: file}, vault.SealedSize(header), nil
+}
+
+// blobReader открытое содержимое зашифрованного файла
+type blobReader struct {
+	io.Reader
+	file *os.File
+}
+
+func (r *blobReader) Close() error {
+	return r.file.Close()
+}
+
+// ReadBlobFile читает открытое содержимое файла хранилища
+func ReadBlobFile(filePath string) ([]byte, error) {
+	r, size, err := OpenBlobFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	data := make([]byte, 0, size)
+	buf := bytes.NewBuffer(data)
+	if _, err := io.Copy(buf, r); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// IsBlobSealed проверяет, зашифрован ли файл на диске
+func IsBlobSealed(filePath string) (bool, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	header := make([]byte, vault.HeaderSize)
+	n, _ := io.ReadFull(file, header)
+	return vault.IsSealed(header[:n]), nil
+}
+
+// plainSize возвращает размер открытого содержимого файла: для зашифрованного - из заголовка
+func plainSize(filePath string, diskSize int64) int64 {
+	if diskSize < vault.HeaderSize {
+		return diskSize
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return diskSize
+	}
+	defer file.Close()
+
+	header := make([]byte, vault.HeaderSize)
+	if _, err := io.ReadFull(file, header); err != nil || !vault.IsSealed(header) {
+		return diskSize
+	}
+	return vault.SealedSize(header)
+}
+
+// SealBlob шифрует открытый файл хранилища на месте. Уже зашифрованный файл не трогает.
+// Перед заменой проверяется, что содержимое совпадает с хэшем в имени: повреждённый файл
+// остаётся как есть, чтобы его нашла проверка целостности.
+func SealBlob(blob BlobInfo) (bool, error) {
+	if !vault.Unlocked() {
+		return false, vault.ErrLocked
+	}
+	sealed, err := IsBlobSealed(blob.Path)
+	if err != nil || sealed {
+		return false, err
+	}
+
+	data, err := os.ReadFile(blob.Path)
+	if err != nil {
+		return false, err
+	}
+	if CalculateHash(data) != blob.Hash {
+		return false, fmt.Errorf("%w: %s не совпадает с хэшем", ErrBlobCorrupted, blob.Path)
+	}
+	if err := rewriteBlob(blob, data); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// UnsealBlob расшифровывает файл хранилища на месте. Открытый файл не трогает.
+func UnsealBlob(blob BlobInfo) (bool, error) {
+	sealed, err := IsBlobSealed(blob.Path)
+	if err != nil || !sealed {
+		return false, err
+	}
+
+	data, err := ReadBlobFile(blob.Path)
+	if errors.Is(err, vault.ErrLocked) {
+		return false, err
+	}
+	if err != nil {
+		return false, fmt.Errorf("%w: %s: %v", ErrBlobCorrupted, blob.Path, err)
+	}
+	if CalculateHash(data) != blob.Hash {
+		return false, fmt.Errorf("%w: %s не совпадает с хэшем", ErrBlobCorrupted, blob.Path)
+	}
+
+	tmp := blob.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return false, err
+	}
+	if err := os.Rename(tmp, blob.Path); err != nil {
+		os.Remove(tmp)
+		return false, err
+	}
+	return true, nil
+}
+
+// rewriteBlob перезаписывает файл зашифрованным, сохраняя время изменения для сборщика мусора
+func rewriteBlob(blob BlobInfo, data []byte) error {
+	if err := WriteBlobFile(blob.Path, data); err != nil {
+		return err
+	}
+	if blob.ModTime.IsZero() {
+		return nil
+	}
+	return os.Chtimes(blob.Path, blob.ModTime, blob.ModTime)
+}
+
+// PlainFilePath возвращает путь к открытому содержимому файла для кода, которому нужен путь
+// (просмотр изображений, проигрыватели, открытие во внешней программе).
+// Для зашифрованного файла содержимое расшифровывается во временную директорию вне хранилища;
+// она очищается ClearPlainCache, а после аварийного завершения - RemoveStalePlainCaches при разблокировке.
+// Для отсутствующего файла, как и GetFilePathByHash, возвращает путь в хранилище.
+func PlainFilePath(hash string) (string, error) {
+	filePath := GetFilePathByHash(hash)
+	sealed, err := IsBlobSealed(filePath)
+	if os.IsNotExist(err) || (err == nil && !sealed) {
+		return filePath, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	plainCacheMu.Lock()
+	defer plainCacheMu.Unlock()
+
+	target := filepath.Join(plainCacheDir(), filepath.Base(filePath))
+	if _, err := os.Stat(target); err == nil {
+		return target, nil
+	}
+
+	data, err := ReadBlobFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(plainCacheDir(), 0700); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(target, data, 0600); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// ClearPlainCache удаляет временные расшифрованные копии файлов
+func ClearPlainCache() error {
+	plainCacheMu.Lock()
+	defer plainCacheMu.Unlock()
+	return os.RemoveAll(plainCacheDir())
+}
+
+// RemoveStalePlainCaches удаляет временные расшифрованные копии, оставшиеся от завершившихся процессов.
+// ClearPlainCache вызывается только при штатном завершении, поэтому после падения или kill
+// открытые копии зашифрованных файлов иначе остались бы на диске навсегда.
+// Директории работающих процессов (например, демона рядом с приложением) не трогаются.
+func RemoveStalePlainCaches() error {
+	dirs, err := filepath.Glob(filepath.Join(os.TempDir(), plainCachePrefix+"*"))
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, dir := range dirs {
+		pid, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(dir), plainCachePrefix))
+		if err != nil || pid == os.Getpid() || processAlive(pid) {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// plainCachePrefix начало имени директории временных расшифрованных копий; дальше идёт PID процесса
+const plainCachePrefix = "projectT-vault-"
+
+// plainCacheDir директория временных расшифрованных копий
+func plainCacheDir() string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("%s%d", plainCachePrefix, os.Getpid()))
+}
+
+// processAlive сообщает, работает ли процесс pid. При сомнении процесс считается работающим,
+// чтобы не удалить копии, которые он ещё использует.
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// В Windows FindProcess открывает процесс и возвращает ошибку, если его нет
+	if runtime.GOOS == "windows" {
+		process.Release()
+		return true
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
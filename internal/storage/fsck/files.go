@@ -0,0 +1,279 @@
+package fsck
+
+import (
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/queries"
+	"projectT/internal/storage/filesystem"
+)
+
+// blobState исправный блоб на диске и MIME-тип его содержимого
+type blobState struct {
+	info     filesystem.BlobInfo
+	mimeType string
+}
+
+// checkBlobs перехэширует все блобы хранилища и возвращает исправные, сгруппированные по хэшу.
+// Повреждённые блобы при исправлении переносятся в карантин.
+func (c *checker) checkBlobs() (map[string][]*blobState, error) {
+	list, err := filesystem.ListBlobs()
+	if err != nil {
+		return nil, err
+	}
+	c.report.BlobsChecked = len(list)
+
+	blobs := make(map[string][]*blobState)
+	for _, blob := range list {
+		data, err := filesystem.ReadBlobFile(blob.Path)
+		if err != nil {
+			c.skip(Issue{
+				Kind:   IssueCorruptBlob,
+				Hash:   blob.Hash,
+				Path:   blob.Path,
+				Detail: "ошибка чтения файла: " + err.Error(),
+			}, "файл недоступен для чтения, пропущен")
+			continue
+		}
+
+		if actual := filesystem.CalculateHash(data); actual != blob.Hash {
+			blob := blob
+			c.add(Issue{
+				Kind:   IssueCorruptBlob,
+				Hash:   blob.Hash,
+				Path:   blob.Path,
+				Detail: "содержимое не совпадает с хэшем (фактический хэш " + actual + ")",
+			}, func() (string, error) {
+				target, err := filesystem.QuarantineBlob(blob)
+				if err != nil {
+					return "", err
+				}
+				return "перенесён в карантин: " + target, nil
+			})
+			continue
+		}
+
+		blobs[blob.Hash] = append(blobs[blob.Hash], &blobState{
+			info:     blob,
+			mimeType: filesystem.DetectMimeType(data),
+		})
+	}
+
+	return blobs, nil
+}
+
+// checkItemFiles сверяет записи item_files с элементами и блобами на диске
+func (c *checker) checkItemFiles(items map[int]*models.Item, blobs map[string][]*blobState) error {
+	files, err := queries.GetLocalItemFiles()
+	if err != nil {
+		return fmt.Errorf("ошибка получения записей о файлах: %w", err)
+	}
+	c.report.ItemFilesChecked = len(files)
+
+	for _, file := range files {
+		file := file
+		unlink := func() (string, error) {
+			if err := queries.DeleteItemFile(file.ItemID, file.Hash); err != nil {
+				return "", err
+			}
+			return "запись о файле удалена", nil
+		}
+
+		if items[file.ItemID] == nil {
+			c.add(Issue{
+				Kind:   IssueOrphanItemFile,
+				ItemID: file.ItemID,
+				Hash:   file.Hash,
+				Detail: "запись о файле ссылается на несуществующий элемент",
+			}, unlink)
+			continue
+		}
+
+		candidates := blobs[file.Hash]
+		if len(candidates) == 0 {
+			c.add(Issue{
+				Kind:   IssueMissingFile,
+				ItemID: file.ItemID,
+				Hash:   file.Hash,
+				Path:   file.FilePath,
+				Detail: "файл отсутствует на диске или повреждён",
+			}, unlink)
+			continue
+		}
+
+		blob := pickBlob(file, candidates)
+		c.checkItemFileMeta(file, blob)
+	}
+
+	return nil
+}
+
+// pickBlob выбирает блоб, на который указывает запись: по пути, а если путь устарел - первый с тем же хэшем
+func pickBlob(file *models.ItemFile, candidates []*blobState) *blobState {
+	for _, blob := range candidates {
+		if samePath(blob.info.Path, file.FilePath) {
+			return blob
+		}
+	}
+	return candidates[0]
+}
+
+// samePath сравнивает пути с учётом относительных путей и разных разделителей
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return absA == absB
+}
+
+// checkItemFileMeta сверяет путь, размер и MIME-тип записи с блобом
+func (c *checker) checkItemFileMeta(file *models.ItemFile, blob *blobState) {
+	update := func(apply func(), action string) func() (string, error) {
+		return func() (string, error) {
+			apply()
+			if err := queries.UpdateItemFile(file); err != nil {
+				return "", err
+			}
+			return action, nil
+		}
+	}
+
+	if _, err := os.Stat(file.FilePath); err != nil {
+		c.add(Issue{
+			Kind:   IssueWrongPath,
+			ItemID: file.ItemID,
+			Hash:   file.Hash,
+			Path:   file.FilePath,
+			Detail: "по записанному пути файла нет, блоб найден в " + blob.info.Path,
+		}, update(func() { file.FilePath = blob.info.Path }, "путь исправлен"))
+	}
+
+	if file.Size != blob.info.Size {
+		c.add(Issue{
+			Kind:   IssueSizeMismatch,
+			ItemID: file.ItemID,
+			Hash:   file.Hash,
+			Path:   blob.info.Path,
+			Detail: fmt.Sprintf("размер в записи %d, на диске %d", file.Size, blob.info.Size),
+		}, update(func() { file.Size = blob.info.Size }, "размер исправлен"))
+	}
+
+	if !mimeMatches(file.MimeType, blob) {
+		c.add(Issue{
+			Kind:   IssueMimeMismatch,
+			ItemID: file.ItemID,
+			Hash:   file.Hash,
+			Path:   blob.info.Path,
+			Detail: fmt.Sprintf("MIME-тип в записи %q, по содержимому %q", file.MimeType, blob.mimeType),
+		}, update(func() { file.MimeType = blob.mimeType }, "MIME-тип исправлен"))
+	}
+}
+
+// mimeMatches проверяет MIME-тип записи: он должен совпадать с типом по содержимому
+// или по расширению файла (например, docx по содержимому определяется как zip)
+func mimeMatches(recorded string, blob *blobState) bool {
+	if recorded == "" {
+		return false
+	}
+
+	recorded = baseMimeType(recorded)
+	if recorded == baseMimeType(blob.mimeType) {
+		return true
+	}
+	byExt := mime.TypeByExtension(filepath.Ext(blob.info.Path))
+	return byExt != "" && recorded == baseMimeType(byExt)
+}
+
+// baseMimeType отбрасывает параметры MIME-типа (например, charset)
+func baseMimeType(mimeType string) string {
+	if i := strings.Index(mimeType, ";"); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(mimeType))
+}
+
+// checkRefCounts сверяет счётчики ссылок files с фактическими ссылками
+func (c *checker) checkRefCounts() error {
+	mismatches, err := queries.GetFileRefCountMismatches()
+	if err != nil {
+		return fmt.Errorf("ошибка проверки счётчиков ссылок: %w", err)
+	}
+
+	// Пересчёт выполняется одним запросом для всех блобов
+	recounted := false
+	var recountErr error
+	recount := func() (string, error) {
+		if !recounted {
+			recounted = true
+			recountErr = queries.RecountFileReferences()
+		}
+		if recountErr != nil {
+			return "", recountErr
+		}
+		return "счётчик пересчитан", nil
+	}
+
+	for _, mismatch := range mismatches {
+		c.add(Issue{
+			Kind:   IssueRefCount,
+			Hash:   mismatch.Hash,
+			Detail: fmt.Sprintf("счётчик ссылок %d, фактически ссылок %d", mismatch.Stored, mismatch.Actual),
+		}, recount)
+	}
+
+	return nil
+}
+
+// checkOrphanBlobs находит блобы без ссылок. Обычно их удаляет сборщик мусора после периода ожидания;
+// при исправлении удаляются блобы старше orphanRepairAge.
+func (c *checker) checkOrphanBlobs(blobs map[string][]*blobState) error {
+	referenced, err := queries.GetReferencedFileHashes()
+	if err != nil {
+		return fmt.Errorf("ошибка получения используемых файлов: %w", err)
+	}
+
+	hashes := make([]string, 0, len(blobs))
+	for hash := range blobs {
+		if !referenced[hash] {
+			hashes = append(hashes, hash)
+		}
+	}
+	sort.Strings(hashes)
+
+	cutoff := time.Now().Add(-orphanRepairAge)
+	for _, hash := range hashes {
+		for _, blob := range blobs[hash] {
+			blob := blob
+			issue := Issue{
+				Kind:   IssueOrphanBlob,
+				Hash:   hash,
+				Path:   blob.info.Path,
+				Detail: fmt.Sprintf("на файл нет ссылок (%d байт)", blob.info.Size),
+			}
+
+			if blob.info.ModTime.After(cutoff) {
+				c.skip(issue, "файл сохранён недавно, пропущен")
+				continue
+			}
+			c.add(issue, func() (string, error) {
+				if err := filesystem.RemoveBlob(blob.info); err != nil {
+					return "", err
+				}
+				if err := queries.DeleteUnreferencedFileRecords([]string{hash}); err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("удалён, освобождено %d байт", blob.info.Size), nil
+			})
+		}
+	}
+
+	return nil
+}
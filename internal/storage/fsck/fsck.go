@@ -0,0 +1,171 @@
+// Package fsck проверяет целостность хранилища: блобы на диске, записи item_files,
+// счётчики ссылок files, хэши содержимого и дерево папок. Умеет исправлять найденные проблемы.
+package fsck
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// IssueKind тип найденной проблемы
+type IssueKind string
+
+const (
+	IssueCorruptBlob     IssueKind = "corrupt_blob"      // Содержимое блоба не совпадает с хэшем в имени файла
+	IssueOrphanBlob      IssueKind = "orphan_blob"       // Блоб на диске, на который нет ссылок
+	IssueMissingFile     IssueKind = "missing_file"      // item_files ссылается на отсутствующий блоб
+	IssueOrphanItemFile  IssueKind = "orphan_item_file"  // item_files ссылается на несуществующий элемент
+	IssueWrongPath       IssueKind = "wrong_path"        // В item_files записан путь, по которому блоба нет
+	IssueSizeMismatch    IssueKind = "size_mismatch"     // Размер в item_files не совпадает с размером блоба
+	IssueMimeMismatch    IssueKind = "mime_mismatch"     // MIME-тип в item_files не соответствует содержимому
+	IssueRefCount        IssueKind = "ref_count"         // Счётчик ссылок files расходится с фактическим
+	IssueContentHash     IssueKind = "content_hash"      // items.content_hash не совпадает с GenerateContentHash
+	IssueMissingParent   IssueKind = "missing_parent"    // parent_id указывает на несуществующий элемент
+	IssueParentNotFolder IssueKind = "parent_not_folder" // parent_id указывает на элемент, который не является папкой
+	IssueParentCycle     IssueKind = "parent_cycle"      // Цепочка parent_id замкнута в цикл
+)
+
+// orphanRepairAge блобы без ссылок моложе этого срока не удаляются при исправлении:
+// файл мог быть только что сохранён и ещё не привязан к элементу
+const orphanRepairAge = time.Hour
+
+// Issue найденная проблема
+type Issue struct {
+	Kind     IssueKind
+	ItemID   int    // Элемент, к которому относится проблема (0 - не относится)
+	Hash     string // Хэш блоба, если проблема связана с файлом
+	Path     string // Путь к файлу на диске
+	Detail   string // Описание проблемы
+	Repaired bool   // Исправлена ли проблема
+	Action   string // Что сделано при исправлении или почему исправление пропущено
+}
+
+// String возвращает описание проблемы одной строкой
+func (i Issue) String() string {
+	var b strings.Builder
+	b.WriteString("[" + string(i.Kind) + "]")
+	if i.ItemID != 0 {
+		fmt.Fprintf(&b, " элемент %d", i.ItemID)
+	}
+	if i.Hash != "" {
+		b.WriteString(" файл " + i.Hash)
+	}
+	b.WriteString(": " + i.Detail)
+	if i.Action != "" {
+		b.WriteString(" → " + i.Action)
+	}
+	return b.String()
+}
+
+// Options параметры проверки
+type Options struct {
+	Repair bool // Исправлять найденные проблемы; без него выполняется только отчёт (dry-run)
+}
+
+// Report результат проверки
+type Report struct {
+	Repair           bool
+	ItemsChecked     int
+	ItemFilesChecked int
+	BlobsChecked     int
+	Issues           []Issue
+	Duration         time.Duration
+}
+
+// Unresolved возвращает число проблем, оставшихся неисправленными
+func (r *Report) Unresolved() int {
+	count := 0
+	for _, issue := range r.Issues {
+		if !issue.Repaired {
+			count++
+		}
+	}
+	return count
+}
+
+// Summary возвращает итоговую строку отчёта
+func (r *Report) Summary() string {
+	mode := "проверка без исправления"
+	if r.Repair {
+		mode = "проверка с исправлением"
+	}
+	return fmt.Sprintf("%s: элементов %d, записей о файлах %d, файлов на диске %d; проблем %d, не исправлено %d (%s)",
+		mode, r.ItemsChecked, r.ItemFilesChecked, r.BlobsChecked, len(r.Issues), r.Unresolved(), r.Duration.Round(time.Millisecond))
+}
+
+// String возвращает полный отчёт: проблемы по строке и итог
+func (r *Report) String() string {
+	var b strings.Builder
+	for _, issue := range r.Issues {
+		b.WriteString(issue.String())
+		b.WriteString("\n")
+	}
+	b.WriteString(r.Summary())
+	return b.String()
+}
+
+// checker состояние одного прохода проверки
+type checker struct {
+	repair bool
+	report *Report
+}
+
+// add добавляет проблему; fix вызывается только в режиме исправления и возвращает описание действия
+func (c *checker) add(issue Issue, fix func() (string, error)) {
+	if c.repair && fix != nil {
+		action, err := fix()
+		if err != nil {
+			issue.Action = "ошибка исправления: " + err.Error()
+		} else {
+			issue.Action = action
+			issue.Repaired = true
+		}
+	}
+	c.report.Issues = append(c.report.Issues, issue)
+}
+
+// skip добавляет проблему, которую в этом проходе исправлять не следует
+func (c *checker) skip(issue Issue, reason string) {
+	if c.repair {
+		issue.Action = reason
+	}
+	c.report.Issues = append(c.report.Issues, issue)
+}
+
+// Run проверяет целостность хранилища и при opts.Repair исправляет найденное.
+// Порядок важен: повреждённые блобы уходят в карантин до проверки item_files,
+// чтобы ссылки на них были обработаны как ссылки на отсутствующие файлы.
+func Run(opts Options) (*Report, error) {
+	started := time.Now()
+	c := &checker{
+		repair: opts.Repair,
+		report: &Report{Repair: opts.Repair},
+	}
+
+	items, err := c.checkItems()
+	if err != nil {
+		return nil, err
+	}
+
+	blobs, err := c.checkBlobs()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.checkItemFiles(items, blobs); err != nil {
+		return nil, err
+	}
+
+	if err := c.checkRefCounts(); err != nil {
+		return nil, err
+	}
+
+	// Блобы без ссылок ищем последними: исправления выше могли отвязать файлы
+	if err := c.checkOrphanBlobs(blobs); err != nil {
+		return nil, err
+	}
+
+	c.report.Duration = time.Since(started)
+	return c.report, nil
+}
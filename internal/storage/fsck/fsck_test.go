@@ -0,0 +1,216 @@
+package fsck
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/queries"
+	"projectT/internal/storage/filesystem"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testStorageConfig конфигурация временного хранилища файлов
+type testStorageConfig struct {
+	path string
+}
+
+func (c testStorageConfig) GetPath() string     { return c.path }
+func (c testStorageConfig) GetFilesDir() string { return "files" }
+
+// setupFsckTest подключает БД в памяти и временное хранилище файлов
+func setupFsckTest(t *testing.T) {
+	t.Helper()
+
+	db, err := database.Open(":memory:")
+	require.NoError(t, err)
+	originalDB := database.GetDB()
+	database.SetDB(db)
+	database.RunMigrations()
+
+	root := filesystem.GetStorageRoot()
+	filesystem.InitStorage(testStorageConfig{path: t.TempDir()})
+
+	t.Cleanup(func() {
+		database.CloseDB()
+		database.SetDB(originalDB)
+		filesystem.InitStorage(testStorageConfig{path: root})
+	})
+}
+
+// createItem создаёт элемент с корректным хэшем содержимого
+func createItem(t *testing.T, itemType models.ItemType, title string, parentID *int) *models.Item {
+	t.Helper()
+
+	item := &models.Item{Type: itemType, Title: title, ParentID: parentID, ContentMeta: "[]"}
+	if itemType != models.ItemTypeFolder {
+		item.ContentHash = filesystem.GenerateContentHash(item.Title, item.Description, item.ContentMeta)
+	}
+	require.NoError(t, queries.CreateItem(item))
+	return item
+}
+
+// saveBlob сохраняет блоб и привязывает его к элементу с корректными метаданными
+func saveBlob(t *testing.T, item *models.Item, content, name string) *filesystem.FileData {
+	t.Helper()
+
+	fileData, err := filesystem.SaveFileWithOriginalName([]byte(content), name)
+	require.NoError(t, err)
+	info, err := filesystem.GetFileInfo(fileData.Hash)
+	require.NoError(t, err)
+
+	if item != nil {
+		require.NoError(t, queries.CreateItemFile(&models.ItemFile{
+			ItemID: item.ID, Hash: fileData.Hash, FilePath: fileData.Path, Size: fileData.Size, MimeType: info.MimeType,
+		}))
+	}
+	return fileData
+}
+
+// issueKinds возвращает число проблем каждого типа
+func issueKinds(report *Report) map[IssueKind]int {
+	kinds := make(map[IssueKind]int)
+	for _, issue := range report.Issues {
+		kinds[issue.Kind]++
+	}
+	return kinds
+}
+
+// TestRunCleanStorage проверяет, что в исправном хранилище проблем нет
+func TestRunCleanStorage(t *testing.T) {
+	setupFsckTest(t)
+
+	folder := createItem(t, models.ItemTypeFolder, "Папка", nil)
+	note := createItem(t, models.ItemTypeElement, "Заметка", &folder.ID)
+	saveBlob(t, note, "содержимое заметки", "note.txt")
+
+	report, err := Run(Options{})
+	require.NoError(t, err)
+	assert.Empty(t, report.Issues, report.String())
+	assert.Equal(t, 2, report.ItemsChecked)
+	assert.Equal(t, 1, report.ItemFilesChecked)
+	assert.Equal(t, 1, report.BlobsChecked)
+}
+
+// TestRunFindsAndRepairsIssues проверяет отчёт без исправления и исправление всех типов проблем
+func TestRunFindsAndRepairsIssues(t *testing.T) {
+	setupFsckTest(t)
+
+	folder := createItem(t, models.ItemTypeFolder, "Папка", nil)
+	note := createItem(t, models.ItemTypeElement, "Заметка", &folder.ID)
+
+	// Повреждённый блоб: содержимое изменено после сохранения
+	corrupt := saveBlob(t, note, "исходное содержимое", "corrupt.txt")
+	require.NoError(t, os.WriteFile(corrupt.Path, []byte("испорчено"), 0644))
+
+	// Запись о файле, которого нет на диске
+	require.NoError(t, queries.CreateItemFile(&models.ItemFile{
+		ItemID: note.ID, Hash: filesystem.CalculateHash([]byte("нет на диске")), FilePath: "missing.txt", Size: 1,
+	}))
+
+	// Неверные размер, MIME-тип и путь
+	sized := saveBlob(t, note, "размер записан неверно", "sized.txt")
+	require.NoError(t, queries.UpdateItemFile(&models.ItemFile{
+		ItemID: note.ID, Hash: sized.Hash, FilePath: "old/location.txt", Size: 1, MimeType: "image/png",
+	}))
+
+	// Запись о файле несуществующего элемента
+	orphanRow := saveBlob(t, nil, "файл удалённого элемента", "gone.txt")
+	require.NoError(t, queries.CreateItemFile(&models.ItemFile{ItemID: 999, Hash: orphanRow.Hash, FilePath: orphanRow.Path, Size: orphanRow.Size}))
+
+	// Блоб без ссылок, давно сохранённый
+	orphan := saveBlob(t, nil, "никому не нужен", "orphan.txt")
+	old := time.Now().Add(-2 * orphanRepairAge)
+	require.NoError(t, os.Chtimes(orphan.Path, old, old))
+	require.NoError(t, os.Chtimes(orphanRow.Path, old, old))
+
+	// Неверный хэш содержимого и испорченный счётчик ссылок
+	require.NoError(t, queries.UpdateItemContentHash(note.ID, "устаревший"))
+	_, err := database.GetDB().Exec(`UPDATE files SET ref_count = 7 WHERE hash = ?`, sized.Hash)
+	require.NoError(t, err)
+
+	// Дерево: несуществующий родитель, родитель-элемент и цикл из двух папок
+	lost := createItem(t, models.ItemTypeElement, "Потерянный", nil)
+	require.NoError(t, queries.UpdateItemParent(lost.ID, intPtr(999)))
+	child := createItem(t, models.ItemTypeElement, "Вложен в элемент", &note.ID)
+	loopA := createItem(t, models.ItemTypeFolder, "Цикл А", nil)
+	loopB := createItem(t, models.ItemTypeFolder, "Цикл Б", &loopA.ID)
+	require.NoError(t, queries.UpdateItemParent(loopA.ID, &loopB.ID))
+
+	expected := map[IssueKind]int{
+		IssueCorruptBlob:     1,
+		IssueMissingFile:     2, // Включая ссылку на повреждённый блоб
+		IssueWrongPath:       1,
+		IssueSizeMismatch:    1,
+		IssueMimeMismatch:    1,
+		IssueOrphanItemFile:  1,
+		IssueOrphanBlob:      1,
+		IssueRefCount:        1,
+		IssueContentHash:     1,
+		IssueMissingParent:   1,
+		IssueParentNotFolder: 1,
+		IssueParentCycle:     1,
+	}
+
+	// Dry-run только сообщает
+	report, err := Run(Options{})
+	require.NoError(t, err)
+	assert.Equal(t, expected, issueKinds(report), report.String())
+	assert.Equal(t, len(report.Issues), report.Unresolved())
+	assert.FileExists(t, corrupt.Path)
+	assert.FileExists(t, orphan.Path)
+
+	// Исправление
+	report, err = Run(Options{Repair: true})
+	require.NoError(t, err)
+	assert.Zero(t, report.Unresolved(), report.String())
+
+	assert.NoFileExists(t, corrupt.Path)
+	assert.FileExists(t, filepath.Join(filesystem.GetQuarantineDir(), filepath.Base(corrupt.Path)), "повреждённый файл перенесён в карантин")
+	assert.NoFileExists(t, orphan.Path)
+
+	files, err := queries.GetFilesByItemID(note.ID)
+	require.NoError(t, err)
+	require.Len(t, files, 1, "записи об отсутствующих файлах удалены")
+	assert.Equal(t, sized.Hash, files[0].Hash)
+	assert.Equal(t, sized.Path, files[0].FilePath)
+	assert.Equal(t, sized.Size, files[0].Size)
+	assert.Equal(t, "text/plain; charset=utf-8", files[0].MimeType)
+
+	for _, id := range []int{lost.ID, child.ID, loopA.ID} {
+		item, err := queries.GetItemByID(id)
+		require.NoError(t, err)
+		assert.Nil(t, item.ParentID, "элемент %d перенесён в корень", id)
+	}
+	item, err := queries.GetItemByID(loopB.ID)
+	require.NoError(t, err)
+	assert.Equal(t, loopA.ID, *item.ParentID, "цикл разрывается в одном месте")
+
+	// Повторная проверка чистая (блоб удалённого элемента отвязан и удалён)
+	report, err = Run(Options{})
+	require.NoError(t, err)
+	assert.Empty(t, report.Issues, report.String())
+}
+
+// TestRunSkipsRecentOrphans проверяет, что недавно сохранённые блобы без ссылок не удаляются
+func TestRunSkipsRecentOrphans(t *testing.T) {
+	setupFsckTest(t)
+
+	orphan := saveBlob(t, nil, "только что сохранён", "fresh.txt")
+
+	report, err := Run(Options{Repair: true})
+	require.NoError(t, err)
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, IssueOrphanBlob, report.Issues[0].Kind)
+	assert.False(t, report.Issues[0].Repaired)
+	assert.FileExists(t, orphan.Path)
+}
+
+func intPtr(v int) *int {
+	return &v
+}
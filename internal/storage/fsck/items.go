@@ -0,0 +1,165 @@
+package fsck
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/queries"
+	"projectT/internal/storage/filesystem"
+)
+
+// checkItems проверяет хэши содержимого и дерево папок. Возвращает элементы по ID.
+func (c *checker) checkItems() (map[int]*models.Item, error) {
+	list, err := queries.GetAllItemsIncludingTrashed()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения элементов: %w", err)
+	}
+
+	items := make(map[int]*models.Item, len(list))
+	for _, item := range list {
+		items[item.ID] = item
+	}
+	c.report.ItemsChecked = len(list)
+
+	for _, item := range list {
+		c.checkContentHash(item)
+	}
+	c.checkParents(list, items)
+	c.checkParentCycles(list, items)
+
+	return items, nil
+}
+
+// checkContentHash сверяет items.content_hash с хэшем, вычисленным по содержимому.
+// У папок хэш необязателен и проверяется, только если заполнен.
+func (c *checker) checkContentHash(item *models.Item) {
+	if item.Type == models.ItemTypeFolder && item.ContentHash == "" {
+		return
+	}
+
+	expected := filesystem.GenerateContentHash(item.Title, item.Description, item.ContentMeta)
+	if item.ContentHash == expected {
+		return
+	}
+
+	detail := "хэш содержимого не совпадает с вычисленным"
+	if item.ContentHash == "" {
+		detail = "хэш содержимого не заполнен"
+	}
+	c.add(Issue{Kind: IssueContentHash, ItemID: item.ID, Detail: detail}, func() (string, error) {
+		if err := queries.UpdateItemContentHash(item.ID, expected); err != nil {
+			return "", err
+		}
+		item.ContentHash = expected
+		return "хэш пересчитан", nil
+	})
+}
+
+// checkParents находит ссылки parent_id на несуществующие элементы и на элементы, не являющиеся папками.
+// Такие элементы при исправлении переносятся в корень.
+func (c *checker) checkParents(list []*models.Item, items map[int]*models.Item) {
+	for _, item := range list {
+		if item.ParentID == nil {
+			continue
+		}
+
+		parent, ok := items[*item.ParentID]
+		switch {
+		case !ok:
+			c.add(Issue{
+				Kind:   IssueMissingParent,
+				ItemID: item.ID,
+				Detail: fmt.Sprintf("родительский элемент %d не существует", *item.ParentID),
+			}, c.moveToRoot(item))
+		case parent.Type != models.ItemTypeFolder:
+			c.add(Issue{
+				Kind:   IssueParentNotFolder,
+				ItemID: item.ID,
+				Detail: fmt.Sprintf("родительский элемент %d не является папкой", parent.ID),
+			}, c.moveToRoot(item))
+		}
+	}
+}
+
+// checkParentCycles находит замкнутые цепочки parent_id. Такие элементы недостижимы из корня;
+// при исправлении цикл разрывается переносом элемента с наименьшим ID в корень.
+func (c *checker) checkParentCycles(list []*models.Item, items map[int]*models.Item) {
+	const (
+		unvisited = iota
+		inPath
+		done
+	)
+	state := make(map[int]int, len(list))
+
+	for _, start := range list {
+		if state[start.ID] != unvisited {
+			continue
+		}
+
+		// Идём вверх по родителям, запоминая путь, пока не дойдём до корня,
+		// уже проверенного элемента или элемента текущего пути (цикл)
+		var path []*models.Item
+		var loop *models.Item
+		for current := start; current != nil; {
+			state[current.ID] = inPath
+			path = append(path, current)
+			if current.ParentID == nil {
+				break
+			}
+
+			parent := items[*current.ParentID]
+			if parent != nil && state[parent.ID] == inPath {
+				loop = parent
+				break
+			}
+			if parent != nil && state[parent.ID] == done {
+				break
+			}
+			current = parent
+		}
+
+		if loop != nil {
+			// Цикл - часть пути начиная с loop
+			for i, item := range path {
+				if item.ID == loop.ID {
+					c.reportCycle(path[i:])
+					break
+				}
+			}
+		}
+
+		for _, item := range path {
+			state[item.ID] = done
+		}
+	}
+}
+
+// reportCycle добавляет проблему для одного цикла
+func (c *checker) reportCycle(path []*models.Item) {
+	cycle := append([]*models.Item(nil), path...)
+	sort.Slice(cycle, func(i, j int) bool { return cycle[i].ID < cycle[j].ID })
+
+	ids := make([]string, len(cycle))
+	for i, item := range cycle {
+		ids[i] = fmt.Sprint(item.ID)
+	}
+
+	c.add(Issue{
+		Kind:   IssueParentCycle,
+		ItemID: cycle[0].ID,
+		Detail: "элементы образуют цикл по parent_id: " + strings.Join(ids, ", "),
+	}, c.moveToRoot(cycle[0]))
+}
+
+// moveToRoot возвращает исправление, переносящее элемент в корень
+func (c *checker) moveToRoot(item *models.Item) func() (string, error) {
+	return func() (string, error) {
+		if err := queries.UpdateItemParent(item.ID, nil); err != nil {
+			return "", err
+		}
+		item.ParentID = nil
+		return "перенесён в корень", nil
+	}
+}
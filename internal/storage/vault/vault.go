@@ -0,0 +1,325 @@
+// Package vault хранит мастер-ключ зашифрованного хранилища и шифрует им файлы и чувствительные
+// колонки базы данных.
+//
+// Мастер-ключ - случайные 32 байта, зашифрованные паролем так же, как приватные ключи профиля
+// (crypto.EncryptPrivateKey), в файле vault.key в корне хранилища. Пока файл есть, режим включён;
+// пока ключ не разблокирован паролем, зашифрованные данные недоступны.
+//
+// Каждый файл шифруется собственным случайным ключом, который хранится в заголовке файла,
+// зашифрованный мастер-ключом:
+//
+//	marker (4) + размер открытого текста (8) + nonce (12) + ключ файла в AES-GCM (48) + crypto.EncryptStream
+//
+// Значение колонки - префикс "vault:v1:" и base64 от nonce (12) и AES-GCM открытого текста.
+package vault
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"projectT/internal/services/crypto"
+)
+
+const (
+	// KeyFile имя файла мастер-ключа в корне хранилища
+	KeyFile = "vault.key"
+
+	wrappedKeySize = 12 + crypto.KeySize + 16
+	// HeaderSize размер заголовка зашифрованного файла перед потоком
+	HeaderSize = 4 + 8 + wrappedKeySize
+
+	fieldPrefix = "vault:v1:"
+)
+
+// FileMarker маркер зашифрованного файла ("TPVF" - ProjectT Vault File)
+var FileMarker = []byte{0x54, 0x50, 0x56, 0x46}
+
+var (
+	// ErrLocked хранилище зашифровано, а ключ не разблокирован
+	ErrLocked = errors.New("хранилище зашифровано и заблокировано: введите пароль")
+	// ErrWrongPassword пароль не подходит к мастер-ключу
+	ErrWrongPassword = errors.New("неверный пароль хранилища")
+	// ErrAlreadyEnabled шифрование уже включено
+	ErrAlreadyEnabled = errors.New("шифрование хранилища уже включено")
+	// ErrNotEnabled шифрование не включено
+	ErrNotEnabled = errors.New("шифрование хранилища не включено")
+)
+
+// LockedPlaceholder подставляется вместо зашифрованного значения колонки, пока хранилище заблокировано
+const LockedPlaceholder = "🔒 зашифровано"
+
+var (
+	mu        sync.RWMutex
+	masterKey []byte
+)
+
+// Enabled сообщает, включено ли шифрование для хранилища с корнем root
+func Enabled(root string) bool {
+	_, err := os.Stat(filepath.Join(root, KeyFile))
+	return err == nil
+}
+
+// Unlocked сообщает, разблокирован ли мастер-ключ. Только при разблокированном ключе новые файлы
+// и значения колонок записываются зашифрованными.
+func Unlocked() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return masterKey != nil
+}
+
+// Setup создаёт мастер-ключ, защищённый паролем, и разблокирует его
+func Setup(root, password string) error {
+	if password == "" {
+		return errors.New("пароль не может быть пустым")
+	}
+	if Enabled(root) {
+		return ErrAlreadyEnabled
+	}
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return err
+	}
+	encrypted, err := crypto.EncryptPrivateKey(key, password)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(root, KeyFile)
+	if err := os.WriteFile(path+".tmp", encrypted, 0600); err != nil {
+		return fmt.Errorf("ошибка сохранения ключа хранилища: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("ошибка сохранения ключа хранилища: %w", err)
+	}
+
+	setKey(key)
+	return nil
+}
+
+// Unlock расшифровывает мастер-ключ паролем
+func Unlock(root, password string) error {
+	data, err := os.ReadFile(filepath.Join(root, KeyFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotEnabled
+		}
+		return fmt.Errorf("ошибка чтения ключа хранилища: %w", err)
+	}
+	// marker + соль + nonce + тег GCM: короче DecryptPrivateKey не разбирает
+	if len(data) < len(crypto.EncryptedKeyMarker)+16+12+16 {
+		return errors.New("файл ключа хранилища повреждён")
+	}
+
+	key, err := crypto.DecryptPrivateKey(data, password)
+	if err != nil || len(key) != crypto.KeySize {
+		return ErrWrongPassword
+	}
+	setKey(key)
+	return nil
+}
+
+// ChangePassword перешифровывает мастер-ключ новым паролем. Файлы и колонки не перешифровываются.
+func ChangePassword(root, oldPassword, newPassword string) error {
+	if newPassword == "" {
+		return errors.New("пароль не может быть пустым")
+	}
+	path := filepath.Join(root, KeyFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения ключа хранилища: %w", err)
+	}
+	encrypted, err := crypto.ChangePassword(data, oldPassword, newPassword)
+	if err != nil {
+		return ErrWrongPassword
+	}
+	if err := os.WriteFile(path+".tmp", encrypted, 0600); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// Remove удаляет мастер-ключ и блокирует хранилище. Вызывается после расшифровки всех данных.
+func Remove(root string) error {
+	Lock()
+	if err := os.Remove(filepath.Join(root, KeyFile)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("ошибка удаления ключа хранилища: %w", err)
+	}
+	return nil
+}
+
+// Lock забывает мастер-ключ
+func Lock() {
+	setKey(nil)
+}
+
+func setKey(key []byte) {
+	mu.Lock()
+	defer mu.Unlock()
+	masterKey = key
+}
+
+func currentKey() []byte {
+	mu.RLock()
+	defer mu.RUnlock()
+	return masterKey
+}
+
+// IsSealed проверяет по началу файла, зашифрован ли он
+func IsSealed(header []byte) bool {
+	return len(header) >= HeaderSize && bytes.Equal(header[:len(FileMarker)], FileMarker)
+}
+
+// SealedSize возвращает размер открытого текста по заголовку зашифрованного файла
+func SealedSize(header []byte) int64 {
+	return int64(binary.BigEndian.Uint64(header[len(FileMarker):]))
+}
+
+// Seal шифрует src размером size в dst новым ключом файла
+func Seal(dst io.Writer, src io.Reader, size int64) error {
+	master := currentKey()
+	if master == nil {
+		return ErrLocked
+	}
+
+	fileKey, err := crypto.GenerateKey()
+	if err != nil {
+		return err
+	}
+	header := make([]byte, 0, HeaderSize)
+	header = append(header, FileMarker...)
+	header = binary.BigEndian.AppendUint64(header, uint64(size))
+	// Маркер и размер аутентифицируются вместе с ключом файла
+	wrapped, err := seal(filesKey(master), fileKey, header)
+	if err != nil {
+		return err
+	}
+	header = append(header, wrapped...)
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+	return crypto.EncryptStream(dst, src, fileKey)
+}
+
+// Open возвращает Reader открытого текста зашифрованного файла; src читается с начала заголовка
+func Open(src io.Reader) (io.Reader, error) {
+	header := make([]byte, HeaderSize)
+	if _, err := io.ReadFull(src, header); err != nil || !IsSealed(header) {
+		return nil, errors.New("файл не зашифрован или повреждён")
+	}
+
+	master := currentKey()
+	if master == nil {
+		return nil, ErrLocked
+	}
+	fileKey, err := open(filesKey(master), header[len(FileMarker)+8:], header[:len(FileMarker)+8])
+	if err != nil {
+		return nil, crypto.ErrStreamCorrupted
+	}
+	return crypto.NewStreamReader(src, fileKey)
+}
+
+// EncryptField шифрует значение колонки. Пустые значения, уже зашифрованные значения и значения
+// при выключенном или заблокированном режиме возвращаются как есть.
+func EncryptField(value string) (string, error) {
+	master := currentKey()
+	if master == nil || value == "" || IsEncryptedField(value) {
+		return value, nil
+	}
+	sealed, err := seal(fieldKey(master), []byte(value), nil)
+	if err != nil {
+		return "", err
+	}
+	return fieldPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptField расшифровывает значение колонки для показа. Незашифрованные значения возвращаются как есть,
+// зашифрованные при заблокированном хранилище или повреждённые - как LockedPlaceholder.
+func DecryptField(value string) string {
+	plain, err := OpenField(value)
+	if err != nil {
+		return LockedPlaceholder
+	}
+	return plain
+}
+
+// OpenField расшифровывает значение колонки и возвращает ошибку, если это невозможно
+func OpenField(value string) (string, error) {
+	if !IsEncryptedField(value) {
+		return value, nil
+	}
+	master := currentKey()
+	if master == nil {
+		return "", ErrLocked
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, fieldPrefix))
+	if err != nil {
+		return "", crypto.ErrStreamCorrupted
+	}
+	plain, err := open(fieldKey(master), sealed, nil)
+	if err != nil {
+		return "", crypto.ErrStreamCorrupted
+	}
+	return string(plain), nil
+}
+
+// IsEncryptedField проверяет, зашифровано ли значение колонки
+func IsEncryptedField(value string) bool {
+	return strings.HasPrefix(value, fieldPrefix)
+}
+
+// filesKey ключ, которым шифруются ключи файлов
+func filesKey(master []byte) []byte {
+	return crypto.DeriveSubkey(master, "projectT vault files")
+}
+
+// fieldKey ключ колонок базы данных
+func fieldKey(master []byte) []byte {
+	return crypto.DeriveSubkey(master, "projectT vault fields")
+}
+
+// seal шифрует короткие данные AES-GCM: nonce + ciphertext
+func seal(key, plain, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("ошибка генерации nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, plain, aad), nil
+}
+
+// open расшифровывает результат seal
+func open(key, sealed, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, crypto.ErrStreamCorrupted
+	}
+	return gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания шифра: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,79 @@
+package vault
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupVault включает шифрование во временной директории и возвращает её путь
+func setupVault(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	require.NoError(t, Setup(root, "secret"))
+	t.Cleanup(Lock)
+	return root
+}
+
+// TestSealOpen проверяет шифрование файла, размер в заголовке и обнаружение порчи
+func TestSealOpen(t *testing.T) {
+	setupVault(t)
+
+	plain := bytes.Repeat([]byte("содержимое файла "), 10000)
+	var sealed bytes.Buffer
+	require.NoError(t, Seal(&sealed, bytes.NewReader(plain), int64(len(plain))))
+	assert.True(t, IsSealed(sealed.Bytes()))
+	assert.Equal(t, int64(len(plain)), SealedSize(sealed.Bytes()))
+	assert.False(t, bytes.Contains(sealed.Bytes(), []byte("содержимое")))
+
+	r, err := Open(bytes.NewReader(sealed.Bytes()))
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, plain, data)
+
+	// Размер в заголовке аутентифицирован вместе с ключом файла
+	tampered := append([]byte{}, sealed.Bytes()...)
+	tampered[len(FileMarker)+7] ^= 1
+	_, err = Open(bytes.NewReader(tampered))
+	assert.Error(t, err)
+
+	Lock()
+	_, err = Open(bytes.NewReader(sealed.Bytes()))
+	assert.ErrorIs(t, err, ErrLocked)
+}
+
+// TestFields проверяет шифрование колонок и поведение при заблокированном хранилище
+func TestFields(t *testing.T) {
+	root := setupVault(t)
+
+	encrypted, err := EncryptField("заметка")
+	require.NoError(t, err)
+	assert.True(t, IsEncryptedField(encrypted))
+	again, err := EncryptField(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, encrypted, again, "повторное шифрование ничего не меняет")
+	assert.Equal(t, "заметка", DecryptField(encrypted))
+	assert.Equal(t, "открытое", DecryptField("открытое"))
+
+	Lock()
+	assert.Equal(t, LockedPlaceholder, DecryptField(encrypted))
+	_, err = OpenField(encrypted)
+	assert.ErrorIs(t, err, ErrLocked)
+	plain, err := EncryptField("без ключа")
+	require.NoError(t, err)
+	assert.Equal(t, "без ключа", plain)
+
+	assert.ErrorIs(t, Unlock(root, "wrong"), ErrWrongPassword)
+	require.NoError(t, ChangePassword(root, "secret", "new secret"))
+	require.NoError(t, Unlock(root, "new secret"))
+	assert.Equal(t, "заметка", DecryptField(encrypted), "смена пароля не меняет мастер-ключ")
+
+	assert.ErrorIs(t, Setup(root, "other"), ErrAlreadyEnabled)
+	require.NoError(t, Remove(root))
+	assert.False(t, Enabled(root))
+	assert.False(t, Unlocked())
+}
@@ -0,0 +1,40 @@
+// Package testutil содержит общие фикстуры тестов: пустую библиотеку
+// с базой в памяти и хранилищем файлов во временной директории
+package testutil
+
+import (
+	"testing"
+
+	"projectT/internal/storage/database"
+	"projectT/internal/storage/filesystem"
+)
+
+// StorageConfig конфигурация хранилища файлов в директории Path
+type StorageConfig struct {
+	Path string
+}
+
+func (c StorageConfig) GetPath() string     { return c.Path }
+func (c StorageConfig) GetFilesDir() string { return "files" }
+
+// SetupLibrary подключает пустую библиотеку: БД в памяти с применёнными миграциями
+// и хранилище файлов во временной директории. По завершении теста возвращает прежние.
+func SetupLibrary(t testing.TB) {
+	t.Helper()
+
+	db, err := database.Open(":memory:")
+	if err != nil {
+		t.Fatalf("Ошибка открытия БД: %v", err)
+	}
+	originalDB := database.SetDB(db)
+	database.RunMigrations()
+
+	root := filesystem.GetStorageRoot()
+	filesystem.InitStorage(StorageConfig{Path: t.TempDir()})
+
+	t.Cleanup(func() {
+		db.Close()
+		database.SetDB(originalDB)
+		filesystem.InitStorage(StorageConfig{Path: root})
+	})
+}
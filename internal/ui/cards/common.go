@@ -1,6 +1,10 @@
 package cards
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	"projectT/internal/storage/database/models"
+)
 
 // Block определяет структуру блока контента
 type Block struct {
@@ -10,6 +14,10 @@ type Block struct {
 	OriginalName string `json:"original_name,omitempty"`
 	Extension    string `json:"extension,omitempty"`
 	Description  string `json:"description,omitempty"`
+	// Preview превью ссылки (заголовок, описание, значок и изображение страницы)
+	Preview *models.LinkPreview `json:"preview,omitempty"`
+	// Snapshot сохранённая копия страницы ссылки
+	Snapshot *models.PageSnapshot `json:"snapshot,omitempty"`
 }
 
 // ParseBlocks парсит JSON-строку в массив блоков
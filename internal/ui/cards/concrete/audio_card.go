@@ -218,7 +218,7 @@ func (ac *AudioCard) getDisplayName(block *cards.Block) string {
 
 // loadAudioInfo загружает информацию об аудиофайле (длительность)
 func (ac *AudioCard) loadAudioInfo(block *cards.Block) {
-	filePath := filesystem.GetFilePathByHash(block.FileHash)
+	filePath, _ := filesystem.PlainFilePath(block.FileHash)
 	if filePath == "" {
 		ac.durationLabel.SetText("--:--")
 		return
@@ -280,7 +280,7 @@ func (ac *AudioCard) togglePlayPause() {
 	}
 
 	block := ac.audioFiles[ac.currentFileIdx]
-	filePath := filesystem.GetFilePathByHash(block.FileHash)
+	filePath, _ := filesystem.PlainFilePath(block.FileHash)
 	if filePath == "" {
 		return
 	}
@@ -484,7 +484,7 @@ func (ac *AudioCard) openCurrentFileWithDefaultApp() {
 	}
 
 	block := ac.audioFiles[ac.currentFileIdx]
-	filePath := filesystem.GetFilePathByHash(block.FileHash)
+	filePath, _ := filesystem.PlainFilePath(block.FileHash)
 	if filePath == "" {
 		return
 	}
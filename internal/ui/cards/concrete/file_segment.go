@@ -240,7 +240,7 @@ func (fc *FileCard) openSpecificFileWithDefaultApp(index int) {
 		targetBlock.FileHash, targetBlock.Type)
 
 	// Получаем путь к файлу по хешу
-	filePath := filesystem.GetFilePathByHash(targetBlock.FileHash)
+	filePath, _ := filesystem.PlainFilePath(targetBlock.FileHash)
 	if filePath == "" {
 		fmt.Printf("[ERROR] Не удалось получить путь к файлу\n")
 		return
@@ -10,6 +10,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"projectT/internal/services/thumbnail"
 	"projectT/internal/storage/database/models"
 	"projectT/internal/storage/filesystem"
 	"projectT/internal/ui/cards"
@@ -98,8 +99,8 @@ func NewImageCardWithCallback(item *models.Item, clickCallback func()) interface
 		imageCard.fixedWidth = 250  // фиксируем ширину
 		imageCard.fixedHeight = 250 // по умолчанию используем 250, если не можем вычислить
 
-		// Получаем размеры первого изображения
-		if fileContent, _, err := filesystem.ReadFileByHash(imageCard.getCurrentImageHash()); err == nil && fileContent != nil {
+		// Получаем размеры первого изображения (миниатюра сохраняет пропорции оригинала)
+		if fileContent, err := loadCardImage(imageCard.getCurrentImageHash()); err == nil && fileContent != nil {
 			if height, err := calculateImageHeight(fileContent, 250); err == nil {
 				imageCard.fixedHeight = height
 			}
@@ -192,8 +193,8 @@ func (ic *ImageCard) extractAllImagesFromContentMeta(contentMeta string) []*canv
 	// Ищем все изображения в блоках
 	for _, block := range blocks {
 		if block.Type == "image" && block.FileHash != "" {
-			// Загружаем содержимое файла изображения из файловой системы по хешу
-			fileContent, _, err := filesystem.ReadFileByHash(block.FileHash)
+			// Загружаем миниатюру изображения по хешу
+			fileContent, err := loadCardImage(block.FileHash)
 			if err != nil || fileContent == nil {
 				continue // Пропускаем, если не удалось прочитать файл
 			}
@@ -210,6 +211,16 @@ func (ic *ImageCard) extractAllImagesFromContentMeta(contentMeta string) []*canv
 	return images
 }
 
+// loadCardImage возвращает изображение для карточки: миниатюру, а если её не построить
+// (например, SVG) - оригинал
+func loadCardImage(hash string) ([]byte, error) {
+	if data, err := cards.LoadThumbnail(hash, thumbnail.Large); err == nil {
+		return data, nil
+	}
+	data, _, err := filesystem.ReadFileByHash(hash)
+	return data, err
+}
+
 // getImageCounterText возвращает текст для счетчика изображений
 func (ic *ImageCard) getImageCounterText() string {
 	if ic.totalImages > 0 {
@@ -318,7 +329,7 @@ func (c *ImageCard) openImageWithDefaultWindowsApp() {
 	}
 
 	// Получаем путь к файлу изображения по хешу
-	imagePath := filesystem.GetFilePathByHash(targetBlock.FileHash)
+	imagePath, _ := filesystem.PlainFilePath(targetBlock.FileHash)
 	if imagePath == "" {
 		// Попробуем найти файл вручную
 		imagePath = c.findFileManually(targetBlock.FileHash)
@@ -1,20 +1,37 @@
 package concrete
 
 import (
-	"encoding/json"
+	"bytes"
+	"image"
+	"strings"
+
+	"projectT/internal/services/thumbnail"
 	"projectT/internal/storage/database/models"
 	"projectT/internal/ui/cards"
 	"projectT/internal/ui/cards/hover_preview"
 	"projectT/internal/ui/cards/interfaces"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 )
 
+const (
+	// linkPreviewWidth ширина изображения превью, как у карточки изображения
+	linkPreviewWidth = 250
+	// linkPreviewMaxHeight наибольшая высота изображения превью: ссылка не должна занимать всю карточку
+	linkPreviewMaxHeight = 140
+	// linkFaviconSize размер значка сайта
+	linkFaviconSize = 16
+	// linkDescriptionLength сколько символов описания страницы показывается в карточке
+	linkDescriptionLength = 160
+)
+
 // LinkCard карточка для ссылок
 type LinkCard struct {
 	*cards.BaseCard
-	richText             *widget.RichText
 	isContentInitialized bool // Флаг: контент уже инициализирован
 }
 
@@ -29,47 +46,142 @@ func NewLinkCardWithCallback(item *models.Item, clickCallback func()) interfaces
 		BaseCard: cards.NewBaseCard(item),
 	}
 
-	// Извлекаем все ссылки
-	allLinks := linkCard.extractAllLinks(item.ContentMeta)
+	// Ссылки с загруженным превью показываются карточкой страницы, остальные - гиперссылкой
+	var links []fyne.CanvasObject
+	for _, block := range linkCard.extractAllLinks(item.ContentMeta) {
+		links = append(links, newLinkView(block))
+	}
 
-	// Создаем сегменты для RichText
-	segments := []widget.RichTextSegment{}
+	// Контейнер без фона, рамки и отступов, так как будет использоваться внутри другой карточки
+	linkCard.Container = container.NewVBox(links...)
 
-	// Добавляем все ссылки как гиперссылки, разделенные разрывами строк
-	for i, link := range allLinks {
-		text := link
-		if len(text) > 40 {
-			text = text[:37] + "..."
-		}
-		linkSegment := &widget.HyperlinkSegment{
-			Text: text,
+	// Устанавливаем флаг, что контент инициализирован
+	linkCard.isContentInitialized = true
+
+	return linkCard
+}
+
+// newLinkView создает представление одной ссылки: изображение, значок, заголовок, описание и сайт страницы
+func newLinkView(block cards.Block) fyne.CanvasObject {
+	target := hover_preview.ParseURL(block.Content)
+	openLink := func() {
+		// Открываем URL
+		_ = fyne.CurrentApp().OpenURL(target)
+	}
+
+	preview := block.Preview
+	if preview == nil || (preview.Title == "" && preview.ImageHash == "") {
+		link := widget.NewRichText(&widget.HyperlinkSegment{
+			Text:     shortenLink(block.Content),
+			OnTapped: openLink,
+		})
+		if snapshot := newSnapshotLink(block); snapshot != nil {
+			return container.NewVBox(link, snapshot)
 		}
-		segments = append(segments, linkSegment)
+		return link
+	}
 
-		linkSegment.OnTapped = func(index int) func() {
-			return func() {
-				// Открываем URL
-				_ = fyne.CurrentApp().OpenURL(hover_preview.ParseURL(allLinks[index]))
-			}
-		}(i)
+	title := preview.Title
+	if title == "" {
+		title = shortenLink(block.Content)
+	}
+	titleLink := widget.NewHyperlink(title, target)
+	titleLink.TextStyle = fyne.TextStyle{Bold: true}
+	titleLink.Wrapping = fyne.TextWrapWord
+	titleLink.OnTapped = openLink
+
+	var header fyne.CanvasObject = titleLink
+	if favicon := loadLinkImage(preview.FaviconHash, thumbnail.Small); favicon != nil {
+		favicon.SetMinSize(fyne.NewSize(linkFaviconSize, linkFaviconSize))
+		header = container.NewBorder(nil, nil, container.NewCenter(favicon), nil, titleLink)
+	}
 
-		// Добавляем разрыв строки после каждой ссылки, кроме последней
-		if i < len(allLinks)-1 {
-			segments = append(segments, &widget.TextSegment{
-				Text: "\n",
-			})
+	sections := []fyne.CanvasObject{}
+	if picture := loadLinkImage(preview.ImageHash, thumbnail.Medium); picture != nil {
+		size := picture.Image.Bounds().Size()
+		height := float32(linkPreviewMaxHeight)
+		if size.X > 0 {
+			height = min(height, float32(linkPreviewWidth)*float32(size.Y)/float32(size.X))
 		}
+		picture.SetMinSize(fyne.NewSize(linkPreviewWidth, height))
+		sections = append(sections, picture)
 	}
-	// Создаем RichText с сегментами
-	linkCard.richText = widget.NewRichText(segments...)
+	sections = append(sections, header)
 
-	// Контейнер без фона, рамки и отступов, так как будет использоваться внутри другой карточки
-	linkCard.Container = linkCard.richText
+	if preview.Description != "" {
+		description := widget.NewLabel(shortenText(preview.Description, linkDescriptionLength))
+		description.Wrapping = fyne.TextWrapWord
+		sections = append(sections, description)
+	}
 
-	// Устанавливаем флаг, что контент инициализирован
-	linkCard.isContentInitialized = true
+	site := preview.SiteName
+	if site == "" {
+		site = target.Host
+	}
+	if site != "" {
+		sections = append(sections, widget.NewRichText(&widget.TextSegment{
+			Text: site,
+			Style: widget.RichTextStyle{
+				ColorName: theme.ColorNamePlaceHolder,
+				SizeName:  theme.SizeNameCaptionText,
+			},
+		}))
+	}
+	if snapshot := newSnapshotLink(block); snapshot != nil {
+		sections = append(sections, snapshot)
+	}
 
-	return linkCard
+	return container.NewVBox(sections...)
+}
+
+// newSnapshotLink создает ссылку на сохранённую копию страницы; nil, если копии нет
+func newSnapshotLink(block cards.Block) fyne.CanvasObject {
+	page := block.Snapshot
+	if page == nil {
+		return nil
+	}
+	return widget.NewRichText(&widget.HyperlinkSegment{
+		Text: "🗄 Сохранённая копия · " + page.ArchivedAt.Local().Format("02.01.2006"),
+		OnTapped: func() {
+			hover_preview.ShowSnapshotViewer(page, block.Content)
+		},
+	})
+}
+
+// loadLinkImage загружает миниатюру изображения превью; nil, если файла нет или он не декодируется
+func loadLinkImage(hash string, size thumbnail.Size) *canvas.Image {
+	if hash == "" {
+		return nil
+	}
+	data, err := cards.LoadThumbnail(hash, size)
+	if err != nil {
+		return nil
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	picture := canvas.NewImageFromImage(decoded)
+	picture.FillMode = canvas.ImageFillContain
+	picture.ScaleMode = canvas.ImageScaleSmooth
+	return picture
+}
+
+// shortenLink сокращает адрес ссылки для показа в карточке
+func shortenLink(link string) string {
+	if len(link) > 40 {
+		return link[:37] + "..."
+	}
+	return link
+}
+
+// shortenText обрезает текст до limit символов
+func shortenText(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:limit-1])) + "…"
 }
 
 // Методы, необходимые для реализации интерфейса CardRenderer
@@ -100,22 +212,18 @@ func (lc *LinkCard) UpdateContent() {
 	lc.Container = newCard.GetContainer()
 }
 
-func (lc *LinkCard) extractAllLinks(contentMeta string) []string {
-	var links []string
+// extractAllLinks возвращает блоки ссылок вместе с их превью
+func (lc *LinkCard) extractAllLinks(contentMeta string) []cards.Block {
+	var links []cards.Block
 	if contentMeta == "" {
 		return links
 	}
 
-	type Block struct {
-		Type    string `json:"type"`
-		Content string `json:"content,omitempty"`
-	}
-
-	var blocks []Block
-	if err := json.Unmarshal([]byte(contentMeta), &blocks); err == nil {
+	blocks, err := cards.ParseBlocks(contentMeta)
+	if err == nil {
 		for _, block := range blocks {
 			if block.Type == "link" && block.Content != "" {
-				links = append(links, block.Content)
+				links = append(links, block)
 			}
 		}
 	}
@@ -230,7 +230,7 @@ func (vc *VideoCard) getDisplayName(block *cards.Block) string {
 
 // loadVideoInfo загружает информацию о видеофайле (длительность)
 func (vc *VideoCard) loadVideoInfo(block *cards.Block) {
-	filePath := filesystem.GetFilePathByHash(block.FileHash)
+	filePath, _ := filesystem.PlainFilePath(block.FileHash)
 	if filePath == "" {
 		vc.durationLabel.SetText("--:--")
 		return
@@ -263,7 +263,7 @@ func (vc *VideoCard) togglePlayPause() {
 	}
 
 	block := vc.videoFiles[vc.currentFileIdx]
-	filePath := filesystem.GetFilePathByHash(block.FileHash)
+	filePath, _ := filesystem.PlainFilePath(block.FileHash)
 	if filePath == "" {
 		return
 	}
@@ -388,7 +388,7 @@ func (vc *VideoCard) openCurrentFileWithDefaultApp() {
 	}
 
 	block := vc.videoFiles[vc.currentFileIdx]
-	filePath := filesystem.GetFilePathByHash(block.FileHash)
+	filePath, _ := filesystem.PlainFilePath(block.FileHash)
 	if filePath == "" {
 		return
 	}
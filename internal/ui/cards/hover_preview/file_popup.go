@@ -63,7 +63,7 @@ func NewFilePopup(fileItems []FileItem, trigger fyne.CanvasObject) *FilePopup {
 			currentFileItem := fileItem // Capture loop variable
 			if currentFileItem.Hash != "" && filesystem.IsValidHash(currentFileItem.Hash) {
 				// Get file path by hash
-				filePath := filesystem.GetFilePathByHash(currentFileItem.Hash)
+				filePath, _ := filesystem.PlainFilePath(currentFileItem.Hash)
 				if filePath != "" {
 					openFileWithDefaultApp(filePath)
 				} else {
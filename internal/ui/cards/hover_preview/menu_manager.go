@@ -4,12 +4,13 @@ import (
 	"context"
 	"fmt"
 	"image/color"
+	"projectT/internal/services"
 	"projectT/internal/services/favorites"
 	"projectT/internal/services/pinned"
 	"projectT/internal/storage/database/models"
 	"projectT/internal/storage/database/queries"
-	"projectT/internal/storage/filesystem"
 	"projectT/internal/ui/edit_item"
+	"projectT/internal/ui/sharing"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -30,6 +31,9 @@ var favoritesService = favorites.NewService()
 // pinnedService - глобальный экземпляр сервиса закрепленных элементов
 var pinnedService = pinned.NewService()
 
+// trashService - глобальный экземпляр сервиса корзины
+var trashService = services.NewTrashService()
+
 // globalSearchEntry глобальная ссылка на поисковую строку
 var globalSearchEntry *widget.Entry
 
@@ -94,7 +98,7 @@ func (mm *MenuManager) ShowSimpleMenu(item *models.Item, cont fyne.CanvasObject,
 					widget.NewButton("🗑 Удалить", func() {
 						appWindow := fyne.CurrentApp().Driver().AllWindows()[0]
 						dialog.ShowConfirm("Подтверждение удаления",
-							fmt.Sprintf("Вы уверены, что хотите удалить элемент \"%s\"?", item.Title),
+							fmt.Sprintf("Переместить элемент \"%s\" в корзину?", item.Title),
 							func(confirmed bool) {
 								if confirmed {
 									if err := mm.deleteItem(item); err != nil {
@@ -162,6 +166,28 @@ func (mm *MenuManager) ShowSimpleMenu(item *models.Item, cont fyne.CanvasObject,
 
 					// Вставляем кнопку избранного первой в список кнопок
 					buttons = append([]fyne.CanvasObject{favButton}, buttons...)
+
+					// Кнопка настройки доступа к папке для P2P
+					shareButton := widget.NewButton("🔒 Доступ", func() {
+						appWindow := fyne.CurrentApp().Driver().AllWindows()[0]
+						sharing.ShowSharingDialog(appWindow, models.SharingEntityFolder, item.ID, item.Title)
+					})
+					buttons = append(buttons, shareButton)
+				}
+
+				// Копии страниц сохраняются только по запросу: для элементов со ссылками
+				if links, archived := itemLinks(item); links > 0 {
+					label := "🗄 Сохранить копию"
+					if archived > 0 {
+						label = "🗄 Обновить копию"
+					}
+					buttons = append(buttons, widget.NewButton(label, func() {
+						popup.Hide()
+						if onClose != nil {
+							onClose()
+						}
+						archiveItemPages(item)
+					}))
 				}
 
 				// Добавляем кнопку перемещения для всех типов элементов
@@ -262,44 +288,12 @@ func (mm *MenuManager) ShowSimpleMenu(item *models.Item, cont fyne.CanvasObject,
 	}
 }
 
-// deleteItem удаляет элемент и все вложенные элементы, если это папка
+// deleteItem перемещает элемент в корзину (папку - вместе со всем содержимым).
+// Окончательное удаление строк и файлов выполняется из корзины или по истечении срока хранения.
 func (mm *MenuManager) deleteItem(item *models.Item) error {
-	// Если элемент - папка, удаляем все вложенные элементы рекурсивно
-	if item.Type == models.ItemTypeFolder {
-		// Получаем все элементы в папке
-		items, err := queries.GetItemsByParent(item.ID)
-		if err != nil {
-			return fmt.Errorf("ошибка получения вложенных элементов: %v", err)
-		}
-
-		// Рекурсивно удаляем все вложенные элементы
-		for _, childItem := range items {
-			if err := mm.deleteItem(childItem); err != nil {
-				return fmt.Errorf("ошибка удаления вложенного элемента: %v", err)
-			}
-		}
-	}
-
-	// ✅ Получаем файлы элемента перед удалением
-	files, err := queries.GetFilesByItemID(item.ID)
-	if err == nil && len(files) > 0 {
-		// ✅ Удаляем файлы с диска
-		for _, file := range files {
-			if err := filesystem.DeleteFile(file.Hash); err != nil {
-				fmt.Printf("WARN: ошибка удаления файла %s: %v\n", file.Hash, err)
-			}
-		}
-		// ✅ Удаляем записи о файлах из БД
-		if err := queries.DeleteFilesByItemID(item.ID); err != nil {
-			fmt.Printf("WARN: ошибка удаления записей о файлах: %v\n", err)
-		}
+	if _, err := trashService.MoveToTrash(item.ID); err != nil {
+		return fmt.Errorf("ошибка перемещения в корзину: %v", err)
 	}
-
-	// ✅ Удаляем сам элемент
-	if err := queries.DeleteItem(item.ID); err != nil {
-		return fmt.Errorf("ошибка удаления элемента: %v", err)
-	}
-
 	return nil
 }
 
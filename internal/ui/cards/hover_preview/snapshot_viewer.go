@@ -0,0 +1,134 @@
+package hover_preview
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"projectT/internal/services"
+	"projectT/internal/services/snapshot"
+	"projectT/internal/storage/database/models"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// snapshotViewerTextLimit сколько символов текста копии показывается в просмотре; полная копия открывается в браузере
+const snapshotViewerTextLimit = 100000
+
+// itemLinks возвращает число блоков ссылок элемента и сколько из них уже имеют сохранённую копию
+func itemLinks(item *models.Item) (links, archived int) {
+	var blocks []struct {
+		Type     string               `json:"type"`
+		Content  string               `json:"content"`
+		Snapshot *models.PageSnapshot `json:"snapshot"`
+	}
+	if err := json.Unmarshal([]byte(item.ContentMeta), &blocks); err != nil {
+		return 0, 0
+	}
+	for _, block := range blocks {
+		if block.Type == "link" && strings.TrimSpace(block.Content) != "" {
+			links++
+			if block.Snapshot != nil {
+				archived++
+			}
+		}
+	}
+	return links, archived
+}
+
+// archiveItemPages сохраняет копии страниц всех ссылок элемента, показывая ход сохранения
+func archiveItemPages(item *models.Item) {
+	windows := fyne.CurrentApp().Driver().AllWindows()
+	if len(windows) == 0 {
+		return
+	}
+	window := windows[0]
+
+	progress := dialog.NewCustomWithoutButtons("Сохранение копии страницы",
+		container.NewVBox(widget.NewLabel("Загружаем страницу со стилями и изображениями..."), widget.NewProgressBarInfinite()),
+		window)
+	progress.Show()
+
+	go func() {
+		saved, err := snapshot.Get().ArchiveItem(context.Background(), item.ID)
+		progress.Hide()
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("Не удалось сохранить копию страницы: %w", err), window)
+			return
+		}
+		dialog.ShowInformation("Копия сохранена",
+			fmt.Sprintf("Сохранено копий страниц: %d. Копия открывается из карточки элемента и без сети.", saved), window)
+	}()
+}
+
+// ShowSnapshotViewer показывает сохранённую копию страницы ссылки link: её текст и кнопки
+// открытия полной копии в браузере и исходной страницы
+func ShowSnapshotViewer(page *models.PageSnapshot, link string) {
+	windows := fyne.CurrentApp().Driver().AllWindows()
+	if len(windows) == 0 {
+		return
+	}
+	window := windows[0]
+
+	text, err := snapshot.ReadText(page)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("Не удалось открыть копию страницы: %w", err), window)
+		return
+	}
+	if runes := []rune(text); len(runes) > snapshotViewerTextLimit {
+		text = string(runes[:snapshotViewerTextLimit]) + "\n\n… Текст сокращён, полная копия открывается в браузере."
+	}
+	if strings.TrimSpace(text) == "" {
+		text = "На странице нет текста. Копия открывается в браузере."
+	}
+
+	title := page.Title
+	if title == "" {
+		title = link
+	}
+	header := container.NewVBox(
+		widget.NewLabelWithStyle(title, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabel(fmt.Sprintf("Сохранено %s, %s", page.ArchivedAt.Local().Format("02.01.2006 15:04"), services.FormatBytes(page.Size))),
+	)
+
+	body := widget.NewLabel(text)
+	body.Wrapping = fyne.TextWrapWord
+	scroll := container.NewVScroll(body)
+	scroll.SetMinSize(fyne.NewSize(640, 400))
+
+	openCopy := widget.NewButton("🗄 Открыть копию в браузере", func() {
+		path, err := snapshot.HTMLPath(page)
+		if err == nil {
+			path, err = filepath.Abs(path)
+		}
+		if err == nil {
+			err = fyne.CurrentApp().OpenURL(fileURL(path))
+		}
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("Не удалось открыть копию страницы: %w", err), window)
+		}
+	})
+	openOriginal := widget.NewButton("🔗 Открыть страницу", func() {
+		_ = fyne.CurrentApp().OpenURL(ParseURL(link))
+	})
+
+	content := container.NewBorder(header, container.NewHBox(openCopy, openOriginal), nil, nil, scroll)
+	viewer := dialog.NewCustom("Сохранённая копия страницы", "Закрыть", content, window)
+	viewer.Resize(fyne.NewSize(720, 560))
+	viewer.Show()
+}
+
+// fileURL возвращает file://-адрес локального файла
+func fileURL(path string) *url.URL {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return &url.URL{Scheme: "file", Path: path}
+}
@@ -0,0 +1,69 @@
+package cards
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+
+	"projectT/internal/services/thumbnail"
+)
+
+// thumbnailMemoryLimit сколько миниатюр держится в памяти между перерисовками сетки
+const thumbnailMemoryLimit = 256
+
+// thumbnailMemory недавно показанные миниатюры: повторная отрисовка карточки не читает диск
+var thumbnailMemory = struct {
+	sync.Mutex
+	order   *list.List               // Ключи от недавно использованных к давним
+	entries map[string]*list.Element // Ключ -> элемент order со значением thumbnailEntry
+}{
+	order:   list.New(),
+	entries: make(map[string]*list.Element),
+}
+
+// thumbnailEntry миниатюра в памяти
+type thumbnailEntry struct {
+	key  string
+	data []byte
+}
+
+// LoadThumbnail возвращает миниатюру изображения с хэшем hash.
+// Если файл не изображение или миниатюру построить не удалось, возвращает ошибку - тогда карточка
+// показывает оригинал или заглушку.
+func LoadThumbnail(hash string, size thumbnail.Size) ([]byte, error) {
+	key := fmt.Sprintf("%s/%d", hash, size)
+
+	thumbnailMemory.Lock()
+	if element, ok := thumbnailMemory.entries[key]; ok {
+		thumbnailMemory.order.MoveToFront(element)
+		data := element.Value.(*thumbnailEntry).data
+		thumbnailMemory.Unlock()
+		return data, nil
+	}
+	thumbnailMemory.Unlock()
+
+	data, err := thumbnail.Get().Load(hash, size)
+	if err != nil {
+		return nil, err
+	}
+
+	thumbnailMemory.Lock()
+	defer thumbnailMemory.Unlock()
+	if _, ok := thumbnailMemory.entries[key]; !ok {
+		thumbnailMemory.entries[key] = thumbnailMemory.order.PushFront(&thumbnailEntry{key: key, data: data})
+		for thumbnailMemory.order.Len() > thumbnailMemoryLimit {
+			oldest := thumbnailMemory.order.Back()
+			thumbnailMemory.order.Remove(oldest)
+			delete(thumbnailMemory.entries, oldest.Value.(*thumbnailEntry).key)
+		}
+	}
+	return data, nil
+}
+
+// ForgetThumbnails очищает миниатюры в памяти
+func ForgetThumbnails() {
+	thumbnailMemory.Lock()
+	defer thumbnailMemory.Unlock()
+	thumbnailMemory.order.Init()
+	thumbnailMemory.entries = make(map[string]*list.Element)
+}
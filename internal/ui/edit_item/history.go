@@ -0,0 +1,220 @@
+package edit_item
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"projectT/internal/services"
+	"projectT/internal/storage/database/models"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	compareWithCurrent = "С текущей версией"
+	compareWithNext    = "Со следующей версией"
+)
+
+// historyPanel окно истории изменений элемента: список ревизий, отличия и откат
+type historyPanel struct {
+	window     fyne.Window
+	service    *services.RevisionService
+	revisions  []*models.ItemRevision
+	current    *models.ItemRevision
+	selected   int
+	compare    *widget.RadioGroup
+	diffBox    *fyne.Container
+	restore    *widget.Button
+	onRestored func()
+}
+
+// ShowRevisionHistory показывает историю изменений элемента.
+// onRestored вызывается после отката к выбранной ревизии.
+func ShowRevisionHistory(parentWindow fyne.Window, itemID int, onRestored func()) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	service := services.NewRevisionService()
+	revisions, err := service.GetRevisions(itemID)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("Ошибка загрузки истории: %v", err), parentWindow)
+		return
+	}
+	if len(revisions) == 0 {
+		dialog.ShowInformation("История изменений", "У элемента ещё нет сохранённых версий", parentWindow)
+		return
+	}
+	current, err := service.CurrentState(ctx, itemID)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("Ошибка загрузки элемента: %v", err), parentWindow)
+		return
+	}
+
+	p := &historyPanel{
+		window:    parentWindow,
+		service:   service,
+		revisions: revisions,
+		current:   current,
+		selected:  -1,
+		diffBox:   container.NewVBox(),
+	}
+
+	list := widget.NewList(
+		func() int { return len(p.revisions) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			revision := p.revisions[id]
+			obj.(*widget.Label).SetText(revision.CreatedAt.Format("02.01.2006 15:04:05") + "  " + revisionTitle(revision))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		p.selected = id
+		p.restore.Enable()
+		p.showDiff()
+	}
+
+	p.compare = widget.NewRadioGroup([]string{compareWithCurrent, compareWithNext}, func(string) {
+		p.showDiff()
+	})
+	p.compare.Horizontal = true
+	p.compare.SetSelected(compareWithCurrent)
+
+	p.restore = widget.NewButton("Восстановить эту версию", p.confirmRestore)
+	p.restore.Importance = widget.WarningImportance
+	p.restore.Disable()
+
+	p.diffBox.Add(widget.NewLabel("Выберите версию слева"))
+
+	right := container.NewBorder(p.compare, container.NewCenter(p.restore), nil, nil,
+		container.NewVScroll(p.diffBox))
+	split := container.NewHSplit(list, right)
+	split.Offset = 0.35
+
+	d := dialog.NewCustom("История изменений", "Закрыть", split, parentWindow)
+	d.Resize(fyne.NewSize(800, 500))
+	p.onRestored = func() {
+		d.Hide()
+		if onRestored != nil {
+			onRestored()
+		}
+	}
+	d.Show()
+}
+
+// showDiff показывает отличия выбранной ревизии от текущей или следующей версии
+func (p *historyPanel) showDiff() {
+	if p.selected < 0 || p.diffBox == nil {
+		return
+	}
+
+	old := p.revisions[p.selected]
+	newer := p.current
+	newerName := "текущей версии"
+	if p.compare.Selected == compareWithNext && p.selected > 0 {
+		newer = p.revisions[p.selected-1]
+		newerName = "версии от " + newer.CreatedAt.Format("02.01.2006 15:04:05")
+	}
+
+	diff, err := p.service.Diff(old, newer)
+	if err != nil {
+		dialog.ShowError(err, p.window)
+		return
+	}
+
+	p.diffBox.RemoveAll()
+	header := widget.NewLabel(fmt.Sprintf("Версия от %s в сравнении с %s",
+		old.CreatedAt.Format("02.01.2006 15:04:05"), newerName))
+	header.TextStyle = fyne.TextStyle{Bold: true}
+	p.diffBox.Add(header)
+
+	if !diff.HasChanges() {
+		p.diffBox.Add(widget.NewLabel("Отличий нет"))
+		return
+	}
+
+	for _, field := range diff.Fields {
+		p.diffBox.Add(diffLabel(fmt.Sprintf("%s: «%s» → «%s»", field.Field, field.Old, field.New), widget.MediumImportance))
+	}
+	if len(diff.Fields) > 0 && len(diff.Blocks) > 0 {
+		p.diffBox.Add(widget.NewSeparator())
+	}
+
+	for _, change := range diff.Blocks {
+		switch change.Kind {
+		case services.BlockUnchanged:
+			p.diffBox.Add(diffLabel("  "+describeBlock(change.Old), widget.LowImportance))
+		case services.BlockRemoved:
+			p.diffBox.Add(diffLabel("− "+describeBlock(change.Old), widget.DangerImportance))
+		case services.BlockAdded:
+			p.diffBox.Add(diffLabel("+ "+describeBlock(change.New), widget.SuccessImportance))
+		case services.BlockChanged:
+			p.diffBox.Add(diffLabel("− "+describeBlock(change.Old), widget.DangerImportance))
+			p.diffBox.Add(diffLabel("+ "+describeBlock(change.New), widget.SuccessImportance))
+		}
+	}
+}
+
+// confirmRestore откатывает элемент к выбранной ревизии после подтверждения
+func (p *historyPanel) confirmRestore() {
+	if p.selected < 0 {
+		return
+	}
+	revision := p.revisions[p.selected]
+
+	dialog.ShowConfirm("Восстановление версии",
+		fmt.Sprintf("Вернуть элемент к версии от %s? Текущая версия сохранится в истории.",
+			revision.CreatedAt.Format("02.01.2006 15:04:05")),
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+			defer cancel()
+			if _, err := p.service.Restore(ctx, revision.ID); err != nil {
+				dialog.ShowError(fmt.Errorf("Ошибка восстановления версии: %v", err), p.window)
+				return
+			}
+			if p.onRestored != nil {
+				p.onRestored()
+			}
+		}, p.window)
+}
+
+// revisionTitle возвращает заголовок ревизии для списка
+func revisionTitle(revision *models.ItemRevision) string {
+	if revision.Title == "" {
+		return "(без названия)"
+	}
+	return revision.Title
+}
+
+// describeBlock возвращает краткое описание блока контента
+func describeBlock(block *services.Block) string {
+	switch block.Type {
+	case "image":
+		return "🖼️ " + block.OriginalName
+	case "file":
+		return "📎 " + block.OriginalName
+	case "link":
+		return "🔗 " + block.Content
+	}
+
+	text := strings.Join(strings.Fields(block.Content), " ")
+	if runes := []rune(text); len(runes) > 120 {
+		text = string(runes[:120]) + "…"
+	}
+	return text
+}
+
+// diffLabel создает строку отличий
+func diffLabel(text string, importance widget.Importance) *widget.Label {
+	label := widget.NewLabel(text)
+	label.Importance = importance
+	label.Wrapping = fyne.TextWrapWord
+	return label
+}
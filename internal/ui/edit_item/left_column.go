@@ -5,6 +5,7 @@ import (
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 )
 
@@ -59,13 +60,28 @@ func CreateLeftColumn(modalWindow fyne.Window, viewModel *CreateItemViewModel,
 
 	createButton.Importance = widget.HighImportance
 
+	buttons := []fyne.CanvasObject{createButton}
+
+	// История изменений доступна только для существующего элемента
+	if viewModel.EditMode && viewModel.ID != 0 {
+		historyButton := widget.NewButtonWithIcon("История", theme.HistoryIcon(), func() {
+			ShowRevisionHistory(modalWindow, viewModel.ID, func() {
+				// Форма показывает уже устаревшее состояние элемента
+				if formWidgets.CloseDialog != nil {
+					formWidgets.CloseDialog()
+				}
+			})
+		})
+		buttons = append(buttons, historyButton)
+	}
+
 	// Компоновка левой колонки
 	leftContent := container.NewVBox(
 		formWidgets.ImageUploadArea,
 		widget.NewSeparator(),
 		formWidgets.FileUploadArea,
 		widget.NewSeparator(),
-		container.NewPadded(container.NewCenter(createButton)),
+		container.NewPadded(container.NewCenter(container.NewVBox(buttons...))),
 	)
 
 	return leftContent
@@ -12,17 +12,18 @@ import (
 	"projectT/internal/services"
 	"projectT/internal/storage/database/models"
 	"projectT/internal/storage/database/queries"
-	"projectT/internal/storage/filesystem"
 )
 
 // Block represents a content block of an item (дублируем определение для совместимости)
 type Block struct {
-	Type         string `json:"type"`
-	Content      string `json:"content,omitempty"`
-	FileHash     string `json:"file_hash,omitempty"`
-	OriginalName string `json:"original_name,omitempty"`
-	Extension    string `json:"extension,omitempty"`
-	Description  string `json:"description,omitempty"`
+	Type         string               `json:"type"`
+	Content      string               `json:"content,omitempty"`
+	FileHash     string               `json:"file_hash,omitempty"`
+	OriginalName string               `json:"original_name,omitempty"`
+	Extension    string               `json:"extension,omitempty"`
+	Description  string               `json:"description,omitempty"`
+	Preview      *models.LinkPreview  `json:"preview,omitempty"`
+	Snapshot     *models.PageSnapshot `json:"snapshot,omitempty"`
 }
 
 // Контекст с таймаутом для операций с БД
@@ -365,6 +366,8 @@ func convertServiceBlocksToLocal(serviceBlocks []services.Block) []Block {
 			OriginalName: svcBlock.OriginalName,
 			Extension:    svcBlock.Extension,
 			Description:  svcBlock.Description,
+			Preview:      svcBlock.Preview,
+			Snapshot:     svcBlock.Snapshot,
 		}
 	}
 	return localBlocks
@@ -381,33 +384,21 @@ func convertLocalBlocksToService(localBlocks []Block) []services.Block {
 			OriginalName: localBlock.OriginalName,
 			Extension:    localBlock.Extension,
 			Description:  localBlock.Description,
+			Preview:      localBlock.Preview,
+			Snapshot:     localBlock.Snapshot,
 		}
 	}
 	return serviceBlocks
 }
 
-// cleanupOldFiles удаляет неиспользуемые файлы
+// cleanupOldFiles отвязывает от элемента файлы, которых больше нет в его блоках.
+// Сами блобы с диска удаляет сборщик мусора, когда на них не останется ссылок.
 func cleanupOldFiles(oldBlocks, newBlocks []Block, itemID int) {
-	// Создаем мапу новых хэшей
-	newHashes := make(map[string]bool)
-	for _, block := range newBlocks {
-		if block.FileHash != "" {
-			newHashes[block.FileHash] = true
-		}
-	}
-
-	// Удаляем старые файлы, которых нет в новых
-	for _, block := range oldBlocks {
-		if block.FileHash != "" && !newHashes[block.FileHash] {
-			// ✅ Сначала удаляем запись из item_files
-			if err := queries.DeleteItemFile(itemID, block.FileHash); err != nil {
-				fmt.Printf("WARN: ошибка удаления записи о файле %s: %v\n", block.FileHash, err)
-			}
-
-			// ✅ Затем удаляем файл с диска
-			if err := filesystem.DeleteFile(block.FileHash); err != nil {
-				fmt.Printf("WARN: ошибка удаления файла %s: %v\n", block.FileHash, err)
-			}
+	contentService := services.NewContentBlocksService()
+	unused := contentService.CleanupOldFiles(convertLocalBlocksToService(oldBlocks), convertLocalBlocksToService(newBlocks))
+	for _, hash := range unused {
+		if err := queries.DeleteItemFile(itemID, hash); err != nil {
+			fmt.Printf("WARN: ошибка удаления записи о файле %s: %v\n", hash, err)
 		}
 	}
 }
@@ -36,6 +36,11 @@ func setCurrentFolder(id *int, name string) {
 	selectedFolder = &SelectedFolder{ID: id, Name: name}
 }
 
+// ResetCurrentFolder сбрасывает выбранную папку на корень, например после открытия другой библиотеки
+func ResetCurrentFolder() {
+	setCurrentFolder(nil, "Сохраненное")
+}
+
 // Интерфейс для взаимодействия с менеджером хлебных крошек
 type BreadcrumbManagerInterface interface {
 	GetCurrentFolderID() *int
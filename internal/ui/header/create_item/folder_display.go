@@ -24,8 +24,8 @@ func ResetButtonImportance(container *fyne.Container) {
 // GetAllItems возвращает все элементы из базы данных
 func GetAllItems() ([]*models.Item, error) {
 	// Выполняем SQL-запрос для получения всех элементов
-	query := `SELECT id, type, title, description, content_meta, parent_id, created_at, updated_at FROM items ORDER BY updated_at DESC`
-	rows, err := database.DB.Query(query)
+	query := `SELECT id, type, title, description, content_meta, parent_id, created_at, updated_at FROM items WHERE trash_id IS NULL ORDER BY updated_at DESC`
+	rows, err := database.GetDB().Query(query)
 	if err != nil {
 		return nil, err
 	}
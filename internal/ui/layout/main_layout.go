@@ -142,3 +142,17 @@ func (h *workspaceNavigationHandler) SetSearchQuery(query string) error {
 	}
 	return fmt.Errorf("search entry is not initialized")
 }
+
+func (h *workspaceNavigationHandler) OpenSavedSearch(searchID int) error {
+	if h.workspace != nil {
+		return h.workspace.OpenSavedSearch(searchID)
+	}
+	return nil
+}
+
+func (h *workspaceNavigationHandler) CurrentSearchQuery() string {
+	if h.searchEntry != nil {
+		return h.searchEntry.Text
+	}
+	return ""
+}
@@ -0,0 +1,159 @@
+// Package sharing содержит диалог настройки доступа к папкам и тегам для P2P.
+package sharing
+
+import (
+	"fmt"
+
+	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/queries"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Варианты доступа в порядке отображения
+const (
+	optionInherit  = "Не задано"
+	optionPrivate  = "Только я"
+	optionContacts = "Выбранные контакты"
+	optionPublic   = "Все контакты"
+)
+
+// ShowSharingDialog показывает диалог настройки доступа к папке или тегу
+func ShowSharingDialog(window fyne.Window, entityType string, entityID int, name string) {
+	if window == nil {
+		return
+	}
+
+	rule, err := queries.GetSharingRule(entityType, entityID)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("ошибка загрузки правила доступа: %w", err), window)
+		return
+	}
+
+	contacts, err := queries.GetAllContacts()
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("ошибка загрузки контактов: %w", err), window)
+		return
+	}
+
+	selected := make(map[int]bool, len(rule.ContactIDs))
+	for _, id := range rule.ContactIDs {
+		selected[id] = true
+	}
+
+	// Список контактов: заблокированным доступ не выдаётся никогда
+	contactChecks := make([]*widget.Check, 0, len(contacts))
+	contactsBox := container.NewVBox()
+	for _, contact := range contacts {
+		contactID := contact.ID
+		label := contact.Username
+		if label == "" {
+			label = contact.PeerID
+		}
+
+		check := widget.NewCheck(label, func(checked bool) {
+			selected[contactID] = checked
+		})
+		check.SetChecked(selected[contactID])
+		if contact.IsBlocked {
+			check.SetText(label + " (заблокирован)")
+			check.SetChecked(false)
+			check.Disable()
+		} else {
+			contactChecks = append(contactChecks, check)
+		}
+		contactsBox.Add(check)
+	}
+	if len(contacts) == 0 {
+		contactsBox.Add(widget.NewLabel("Контактов пока нет"))
+	}
+
+	setContactsEnabled := func(enabled bool) {
+		for _, check := range contactChecks {
+			if enabled {
+				check.Enable()
+			} else {
+				check.Disable()
+			}
+		}
+	}
+
+	// «Не задано» - правила нет: папка наследует доступ родителя, тег ни на что не влияет
+	options := []string{optionInherit, optionPrivate, optionContacts, optionPublic}
+	visibility := widget.NewRadioGroup(options, func(selectedOption string) {
+		setContactsEnabled(selectedOption == optionContacts)
+	})
+
+	switch {
+	case rule.ID == 0:
+		visibility.SetSelected(optionInherit)
+	case rule.Visibility == models.SharingContacts:
+		visibility.SetSelected(optionContacts)
+	case rule.Visibility == models.SharingPublic:
+		visibility.SetSelected(optionPublic)
+	default:
+		visibility.SetSelected(optionPrivate)
+	}
+
+	hint := widget.NewLabel("Запрет «Только я» перекрывает доступ, открытый через другие папки и теги.")
+	hint.Wrapping = fyne.TextWrapWord
+	hint.TextStyle = fyne.TextStyle{Italic: true}
+
+	contactsScroll := container.NewVScroll(contactsBox)
+	contactsScroll.SetMinSize(fyne.NewSize(360, 160))
+
+	content := container.NewVBox(
+		visibility,
+		widget.NewSeparator(),
+		widget.NewLabel("Контакты:"),
+		contactsScroll,
+		hint,
+	)
+
+	title := fmt.Sprintf("Доступ: %s", name)
+	dialog.ShowCustomConfirm(title, "Сохранить", "Отмена", content, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		if err := saveSharingRule(entityType, entityID, visibility.Selected, selected); err != nil {
+			dialog.ShowError(err, window)
+		}
+	}, window)
+}
+
+// saveSharingRule сохраняет выбранный в диалоге уровень доступа
+func saveSharingRule(entityType string, entityID int, option string, selected map[int]bool) error {
+	if option == optionInherit {
+		if err := queries.DeleteSharingRule(entityType, entityID); err != nil {
+			return fmt.Errorf("ошибка удаления правила доступа: %w", err)
+		}
+		return nil
+	}
+
+	rule := &models.SharingRule{
+		EntityType: entityType,
+		EntityID:   entityID,
+		Visibility: models.SharingPrivate,
+	}
+
+	switch option {
+	case optionContacts:
+		rule.Visibility = models.SharingContacts
+		for contactID, checked := range selected {
+			if checked {
+				rule.ContactIDs = append(rule.ContactIDs, contactID)
+			}
+		}
+	case optionPublic:
+		rule.Visibility = models.SharingPublic
+	}
+
+	if err := queries.SetSharingRule(rule); err != nil {
+		return fmt.Errorf("ошибка сохранения правила доступа: %w", err)
+	}
+	return nil
+}
@@ -13,14 +13,16 @@ type NavigationHandler interface {
 	NavigateToFolder(folderID int) error
 	SearchByTag(tagName string) error
 	SetSearchQuery(query string) error
+	OpenSavedSearch(searchID int) error
+	CurrentSearchQuery() string
 }
 
 // CreateNavigation создает навигационные кнопки
 func CreateNavigation(handler NavigationHandler) *fyne.Container {
-	var profileButton, savedButton, tagsButton, chatsButton *widget.Button
+	var profileButton, savedButton, tagsButton, chatsButton, trashButton, settingsButton *widget.Button
 
 	updateButtonState := func(clickedButton *widget.Button, contentType string) {
-		buttons := []*widget.Button{profileButton, savedButton, tagsButton, chatsButton}
+		buttons := []*widget.Button{profileButton, savedButton, tagsButton, chatsButton, trashButton, settingsButton}
 		for _, btn := range buttons {
 			btn.Importance = widget.LowImportance
 			btn.Refresh()
@@ -50,6 +52,14 @@ func CreateNavigation(handler NavigationHandler) *fyne.Container {
 		updateButtonState(chatsButton, "chats")
 	})
 
+	trashButton = createCustomNavButton("Корзина", theme.DeleteIcon(), func() {
+		updateButtonState(trashButton, "trash")
+	})
+
+	settingsButton = createCustomNavButton("Настройки", theme.SettingsIcon(), func() {
+		updateButtonState(settingsButton, "settings")
+	})
+
 	// Устанавливаем начальное состояние
 	updateButtonState(savedButton, "saved")
 
@@ -60,6 +70,8 @@ func CreateNavigation(handler NavigationHandler) *fyne.Container {
 		savedButton,
 		tagsButton,
 		chatsButton,
+		trashButton,
+		settingsButton,
 		separator,
 	)
 }
@@ -0,0 +1,155 @@
+package sidebar
+
+import (
+	"fmt"
+	"strings"
+
+	"projectT/internal/services"
+	"projectT/internal/services/favorites"
+	"projectT/internal/storage/database/models"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// savedSearchesService - глобальный экземпляр сервиса сохранённых поисков
+var savedSearchesService = services.NewSavedSearchesService()
+
+// favoritesService - глобальный экземпляр сервиса избранного
+var favoritesService = favorites.NewService()
+
+// createSavedSearchesSection создает секцию "Умные папки" со списком сохранённых поисков
+func createSavedSearchesSection(handler NavigationHandler) *fyne.Container {
+	sectionContainer := container.NewVBox()
+
+	var updateContent func()
+	updateContent = func() {
+		titleLabel := widget.NewLabel("Умные папки")
+		titleLabel.TextStyle = fyne.TextStyle{Bold: true}
+
+		// Сохраняет текущий запрос строки поиска с текущими настройками фильтрации
+		addButton := widget.NewButtonWithIcon("", theme.ContentAddIcon(), func() {
+			query := ""
+			if handler != nil {
+				query = handler.CurrentSearchQuery()
+			}
+			showSaveSearchDialog(query)
+		})
+		addButton.Importance = widget.LowImportance
+
+		rows := []fyne.CanvasObject{container.NewBorder(nil, nil, nil, addButton, titleLabel)}
+
+		searches, err := savedSearchesService.GetAllSavedSearches()
+		if err != nil {
+			fmt.Printf("Ошибка загрузки сохранённых поисков: %v\n", err)
+		}
+		for _, search := range searches {
+			rows = append(rows, createSavedSearchRow(search, handler))
+		}
+
+		if err == nil && len(searches) == 0 {
+			infoLabel := widget.NewLabel("Сохраните поиск кнопкой +")
+			infoLabel.TextStyle = fyne.TextStyle{Italic: true}
+			rows = append(rows, infoLabel)
+		}
+
+		sectionContainer.Objects = rows
+		sectionContainer.Refresh()
+	}
+
+	updateContent()
+
+	// Список меняется вместе с избранным (события сохранённых поисков идут через тот же менеджер)
+	eventChan := favorites.GetEventManager().Subscribe()
+	go func() {
+		for range eventChan {
+			updateContent()
+		}
+	}()
+
+	return sectionContainer
+}
+
+// createSavedSearchRow создает строку сохранённого поиска: открытие, избранное и удаление
+func createSavedSearchRow(search *models.SavedSearch, handler NavigationHandler) fyne.CanvasObject {
+	openButton := widget.NewButton("🔍 "+search.Name, func() {
+		if handler != nil {
+			_ = handler.OpenSavedSearch(search.ID)
+		}
+	})
+	openButton.Alignment = widget.ButtonAlignLeading
+	openButton.Importance = widget.LowImportance
+
+	isFavorite, err := favoritesService.IsFavorite(models.FavoriteEntitySavedSearch, search.ID)
+	if err != nil {
+		isFavorite = false
+	}
+
+	favText := "⭐️"
+	if isFavorite {
+		favText = "✨"
+	}
+	favButton := widget.NewButton(favText, func() {
+		// Список перерисуется по событию избранного
+		if isFavorite {
+			_ = favoritesService.RemoveFromFavorites(models.FavoriteEntitySavedSearch, search.ID)
+		} else {
+			_ = favoritesService.AddToFavorites(models.FavoriteEntitySavedSearch, search.ID)
+		}
+	})
+	favButton.Importance = widget.LowImportance
+
+	deleteButton := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+		appWindow := fyne.CurrentApp().Driver().AllWindows()[0]
+		dialog.ShowConfirm("Удаление умной папки",
+			fmt.Sprintf("Удалить сохранённый поиск \"%s\"? Элементы не будут удалены.", search.Name),
+			func(confirmed bool) {
+				if !confirmed {
+					return
+				}
+				if err := savedSearchesService.DeleteSavedSearch(search.ID); err != nil {
+					dialog.ShowError(fmt.Errorf("не удалось удалить сохранённый поиск: %w", err), appWindow)
+				}
+			}, appWindow)
+	})
+	deleteButton.Importance = widget.LowImportance
+
+	return container.NewBorder(nil, nil, nil, container.NewHBox(favButton, deleteButton), openButton)
+}
+
+// showSaveSearchDialog запрашивает название и запрос нового сохранённого поиска.
+// Сохраняются также текущие настройки фильтрации и сортировки.
+func showSaveSearchDialog(query string) {
+	appWindow := fyne.CurrentApp().Driver().AllWindows()[0]
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("Название")
+
+	queryEntry := widget.NewEntry()
+	queryEntry.SetPlaceHolder("tag:работа type:image created:>2025-01-01")
+	queryEntry.SetText(query)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Название", nameEntry),
+		widget.NewFormItem("Запрос", queryEntry),
+	}
+
+	dialog.ShowForm("Новая умная папка", "Сохранить", "Отмена", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		name := strings.TrimSpace(nameEntry.Text)
+		if name == "" {
+			name = strings.TrimSpace(queryEntry.Text)
+		}
+
+		options := services.GlobalSortSettingsService.GetFilterOptions()
+		if _, err := savedSearchesService.CreateSavedSearch(name, queryEntry.Text, options); err != nil {
+			dialog.ShowError(fmt.Errorf("не удалось сохранить поиск: %w", err), appWindow)
+		}
+	}, appWindow)
+}
@@ -18,10 +18,15 @@ func CreateSidebar(width float32, handler NavigationHandler) *fyne.Container {
 	// Создаем область "Часто используемые" с кликабельным текстом
 	frequentContainer := createFrequentlyUsedSection(handler)
 
+	// Сохранённые поиски (умные папки)
+	savedSearchesContainer := createSavedSearchesSection(handler)
+
 	// Общий контейнер для левой панели
 	sidebarContainer := container.NewVBox(
 		navigation,
 		frequentContainer,
+		widget.NewSeparator(),
+		savedSearchesContainer,
 	)
 	sidebarContainer.Resize(fyne.NewSize(width, 0))
 
@@ -84,6 +89,27 @@ func createFrequentlyUsedSection(handler NavigationHandler) *fyne.Container {
 			}
 		}
 
+		// Получаем избранные сохранённые поиски
+		favoriteSearches, err := queries.GetFavoriteSavedSearches()
+		if err != nil {
+			fmt.Printf("Ошибка загрузки избранных поисков: %v\n", err)
+		} else {
+			for _, search := range favoriteSearches {
+				btn := widget.NewButton("🔍 "+search.Name, func(searchID int) func() {
+					return func() {
+						// Открываем результаты сохранённого поиска
+						if handler != nil {
+							_ = handler.OpenSavedSearch(searchID)
+						}
+					}
+				}(search.ID))
+
+				btn.Alignment = widget.ButtonAlignLeading
+				btn.Importance = widget.LowImportance
+				buttons = append(buttons, btn)
+			}
+		}
+
 		// Если нет избранных элементов, добавляем информационное сообщение
 		if len(buttons) == 0 {
 			infoLabel := widget.NewLabel("Нет избранных элементов")
@@ -104,66 +130,7 @@ func createFrequentlyUsedSection(handler NavigationHandler) *fyne.Container {
 	go func() {
 		for range eventChan {
 			// Обновляем содержимое напрямую (в Fyne обновления через Refresh могут быть безопасными)
-			// Обновляем содержимое контейнера
-			frequentLabel := widget.NewLabel("Избранные")
-			frequentLabel.TextStyle = fyne.TextStyle{Bold: true}
-
-			buttons := make([]fyne.CanvasObject, 0)
-
-			// Получаем избранные папки
-			favoriteFolders, err := queries.GetFavoriteFolders()
-			if err != nil {
-				fmt.Printf("Ошибка загрузки избранных папок: %v\n", err)
-			} else {
-				for _, folder := range favoriteFolders {
-					buttonText := "📁 " + folder.Title
-					btn := widget.NewButton(buttonText, func(folderID int) func() {
-						return func() {
-							// Переходим к выбранной папке
-							if handler != nil {
-								_ = handler.NavigateToFolder(folderID)
-							}
-						}
-					}(folder.ID))
-
-					btn.Alignment = widget.ButtonAlignLeading
-					btn.Importance = widget.LowImportance
-					buttons = append(buttons, btn)
-				}
-			}
-
-			// Получаем избранные теги
-			favoriteTags, err := queries.GetFavoriteTags()
-			if err != nil {
-				fmt.Printf("Ошибка загрузки избранных тегов: %v\n", err)
-			} else {
-				for _, tag := range favoriteTags {
-					buttonText := "# " + tag.Name
-					btn := widget.NewButton(buttonText, func(tagName string) func() {
-						return func() {
-							// Устанавливаем тег в поисковую строку
-							if handler != nil {
-								_ = handler.SetSearchQuery(tagName)
-							}
-						}
-					}(tag.Name))
-
-					btn.Alignment = widget.ButtonAlignLeading
-					btn.Importance = widget.LowImportance
-					buttons = append(buttons, btn)
-				}
-			}
-
-			// Если нет избранных элементов, добавляем информационное сообщение
-			if len(buttons) == 0 {
-				infoLabel := widget.NewLabel("Нет избранных элементов")
-				infoLabel.TextStyle = fyne.TextStyle{Italic: true}
-				buttons = append(buttons, infoLabel)
-			}
-
-			// Обновляем содержимое контейнера
-			frequentContainer.Objects = append([]fyne.CanvasObject{frequentLabel}, buttons...)
-			frequentContainer.Refresh()
+			updateContent()
 		}
 	}()
 
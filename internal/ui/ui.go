@@ -2,7 +2,10 @@ package ui
 
 import (
 	"projectT/internal/services/p2p/network"
+	"projectT/internal/ui/cards"
+	"projectT/internal/ui/header/create_item"
 	"projectT/internal/ui/layout"
+	"projectT/internal/ui/workspace"
 
 	"fyne.io/fyne/v2"
 )
@@ -26,3 +29,11 @@ func NewUI(window fyne.Window, p2pNetwork *network.P2PNetwork) *UI {
 
 	return ui
 }
+
+// ResetState сбрасывает состояние интерфейса, относящееся к открытой библиотеке.
+// Вызывается перед построением интерфейса другой библиотеки.
+func ResetState() {
+	workspace.StopActive()
+	create_item.ResetCurrentFolder()
+	cards.ForgetThumbnails()
+}
@@ -27,6 +27,7 @@ type UI struct {
 	profileName              *widget.Label
 	profileStatus            *widget.Label
 	characteristicsContainer *fyne.Container
+	libraryButton            *widget.Button
 	myAddressLabel           *widget.Label
 	connectionStatusLabel    *widget.Label
 	peersCountLabel          *widget.Label
@@ -0,0 +1,112 @@
+package chats
+
+import (
+	"fmt"
+
+	"projectT/internal/services/p2p"
+	"projectT/internal/storage/database/models"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showContactLibrary показывает элементы, которыми поделился контакт
+func (ui *UI) showContactLibrary(contact *models.Contact) {
+	if ui.window == nil || contact == nil {
+		return
+	}
+	if ui.p2pUI == nil {
+		ui.showErrorDialog("Ошибка", "P2P не запущен")
+		return
+	}
+
+	itemsList := container.NewVBox(widget.NewLabel("Загрузка..."))
+	scroll := container.NewVScroll(itemsList)
+	scroll.SetMinSize(fyne.NewSize(480, 360))
+
+	// Подписка: библиотека обновляется в фоне, когда контакт онлайн
+	subscribeCheck := widget.NewCheck("Обновлять автоматически", nil)
+	subscribeCheck.SetChecked(ui.p2pUI.IsLibrarySubscribed(contact.PeerID))
+	subscribeCheck.OnChanged = func(checked bool) {
+		if err := ui.p2pUI.SetLibrarySubscription(contact.PeerID, checked); err != nil {
+			ui.showErrorDialog("Ошибка", fmt.Sprintf("Не удалось изменить подписку: %v", err))
+		}
+	}
+
+	content := container.NewBorder(subscribeCheck, nil, nil, nil, scroll)
+
+	title := fmt.Sprintf("Библиотека: %s", contact.Username)
+	d := dialog.NewCustom(title, "Закрыть", content, ui.window)
+	d.Show()
+
+	go func() {
+		statuses, err := ui.p2pUI.BrowseContactLibrary(contact.PeerID)
+		if err != nil {
+			itemsList.Objects = []fyne.CanvasObject{
+				widget.NewLabel(fmt.Sprintf("Не удалось загрузить элементы: %v", err)),
+			}
+			itemsList.Refresh()
+			return
+		}
+
+		if len(statuses) == 0 {
+			emptyLabel := widget.NewLabel("Контакт пока ничем не поделился")
+			emptyLabel.TextStyle = fyne.TextStyle{Italic: true}
+			itemsList.Objects = []fyne.CanvasObject{emptyLabel}
+			itemsList.Refresh()
+			return
+		}
+
+		objects := make([]fyne.CanvasObject, 0, len(statuses)*2)
+		for _, status := range statuses {
+			objects = append(objects, ui.createRemoteItemRow(status), widget.NewSeparator())
+		}
+		itemsList.Objects = objects
+		itemsList.Refresh()
+	}()
+}
+
+// createRemoteItemRow создаёт строку элемента с отметкой о проверке подписи
+func (ui *UI) createRemoteItemRow(status *p2p.RemoteItemStatus) fyne.CanvasObject {
+	item := status.Item
+
+	prefix := "📄 "
+	if item.ItemType == models.ItemTypeFolder {
+		prefix = "📁 "
+	}
+
+	titleLabel := widget.NewLabel(prefix + item.Title)
+	titleLabel.TextStyle = fyne.TextStyle{Bold: true}
+
+	var signatureIcon *widget.Icon
+	var signatureLabel *widget.Label
+	if status.Verified {
+		signatureIcon = widget.NewIcon(theme.ConfirmIcon())
+		signatureLabel = widget.NewLabel("Подпись подтверждена")
+	} else {
+		signatureIcon = widget.NewIcon(theme.WarningIcon())
+		text := "Подпись не прошла проверку"
+		if status.VerifyError != "" {
+			text = fmt.Sprintf("Подпись не проверена: %s", status.VerifyError)
+		}
+		signatureLabel = widget.NewLabel(text)
+	}
+	signatureLabel.TextStyle = fyne.TextStyle{Italic: true}
+
+	rows := []fyne.CanvasObject{
+		container.NewBorder(nil, nil, signatureIcon, nil, titleLabel),
+	}
+
+	if item.Description != "" {
+		description := widget.NewLabel(item.Description)
+		description.Wrapping = fyne.TextWrapWord
+		rows = append(rows, description)
+	}
+
+	rows = append(rows, signatureLabel)
+
+	return container.NewVBox(rows...)
+}
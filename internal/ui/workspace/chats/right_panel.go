@@ -47,6 +47,12 @@ func (ui *UI) createProfileArea() *fyne.Container {
 	ui.profileStatus.TextStyle = fyne.TextStyle{Italic: true}
 	ui.profileStatus.Alignment = fyne.TextAlignCenter
 
+	// Кнопка просмотра библиотеки контакта (скрыта для собственного профиля)
+	ui.libraryButton = widget.NewButton("Библиотека", func() {
+		ui.showContactLibrary(ui.currentContact)
+	})
+	ui.libraryButton.Hide()
+
 	// Отступы сверху и снизу
 	spacerTop := canvas.NewRectangle(color.Transparent)
 	spacerTop.SetMinSize(fyne.NewSize(0, 20))
@@ -60,6 +66,7 @@ func (ui *UI) createProfileArea() *fyne.Container {
 		spacerBottom,
 		ui.profileName,
 		ui.profileStatus,
+		container.NewCenter(ui.libraryButton),
 		layout.NewSpacer(),
 	)
 
@@ -114,6 +121,11 @@ func (ui *UI) showUserProfile() {
 	// Обновляем правую панель с профилем пользователя
 	ui.updateProfile(tempContact)
 
+	// Свою библиотеку через P2P не просматриваем
+	if ui.libraryButton != nil {
+		ui.libraryButton.Hide()
+	}
+
 	// Загружаем характеристики из профиля
 	if localProfile.ContentChar != "" && ui.characteristicsContainer != nil {
 		ui.loadCharacteristics(localProfile.ContentChar)
@@ -129,6 +141,11 @@ func (ui *UI) updateProfile(contact *models.Contact) {
 		return
 	}
 
+	// Библиотека доступна для контактов
+	if ui.libraryButton != nil {
+		ui.libraryButton.Show()
+	}
+
 	// Обновляем имя
 	if ui.profileName != nil {
 		ui.profileName.SetText(contact.Username)
@@ -19,9 +19,9 @@ func TestNew(t *testing.T) {
 	defer database.CloseDB()
 
 	// Сохраняем оригинальную БД
-	originalDB := database.DB
-	database.DB = db
-	defer func() { database.DB = originalDB }()
+	originalDB := database.GetDB()
+	database.SetDB(db)
+	defer func() { database.SetDB(originalDB) }()
 
 	// Запускаем миграции
 	database.RunMigrations()
@@ -8,6 +8,7 @@ import (
 
 	"projectT/internal/services"
 	db_models "projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/queries"
 	ui_models "projectT/internal/ui/workspace/saved/models"
 	"projectT/internal/ui/workspace/saved/utils"
 
@@ -42,6 +43,10 @@ type GridManager struct {
 	sortOptions       *services.FilterOptions                   // Настройки сортировки и фильтрации
 	lastScrollPos     fyne.Position                             // Последняя позиция скролла для оптимизации
 	scrollThreshold   float32                                   // Порог изменения скролла для обновления (в пикселях)
+	snippets          map[int]string                            // Фрагменты совпадений по ID элемента при загрузке результатов поиска
+	pageParentID      int                                       // Папка постранично загружаемого списка
+	pageOptions       *services.FilterOptions                   // Настройки постранично загружаемого списка
+	nextPage          *queries.ItemCursor                       // Курсор следующей страницы; nil, если загружено всё
 }
 
 // NewGridManager создает новый менеджер сетки
@@ -247,6 +252,8 @@ func (gm *GridManager) updateLayout() {
 
 // Обработчик изменения размера
 func (gm *GridManager) onSizeChanged(pos fyne.Position) {
+	gm.loadMoreIfNeeded(pos)
+
 	// Проверяем, изменилась ли позиция скролла достаточно, чтобы обновить макет
 	scrollDeltaX := pos.X - gm.lastScrollPos.X
 	scrollDeltaY := pos.Y - gm.lastScrollPos.Y
@@ -316,6 +323,7 @@ func (gm *GridManager) loadItems(items []*db_models.Item, addCreateElement bool)
 	gm.cards = make([]*ui_models.CardInfo, 0, capacity)
 
 	// Добавляем переданные элементы параллельно с использованием worker pool
+	gm.cardCache.PrefetchThumbnails(items)
 	gm.createCardsConcurrently(items)
 
 	// Добавляем элемент "Создать элемент" если требуется (последовательно, т.к. это один элемент)
@@ -374,6 +382,11 @@ func (gm *GridManager) createCardsConcurrently(items []*db_models.Item) {
 	// Это делается последовательно, но быстро - только MinSize и Refresh
 	for _, cardInfo := range results {
 		if cardInfo != nil {
+			// Для результатов поиска добавляем фрагмент с совпадениями под карточкой
+			if snippet := gm.snippets[cardInfo.Item.ID]; snippet != "" && cardInfo.Widget != nil {
+				cardInfo.Widget = withSearchSnippet(cardInfo.Widget, snippet)
+			}
+
 			// Применяем размеры из кэша
 			widthCells, heightCells := gm.getCardSize(cardInfo.Item)
 			cardInfo.WidthCells = widthCells
@@ -443,30 +456,103 @@ func (gm *GridManager) GetSortOptions() *services.FilterOptions {
 	return gm.sortOptions
 }
 
-// LoadItemsByParentWithSort загружает элементы по родительскому ID с учетом настроек сортировки
+// LoadItemsByParentWithSort загружает первую страницу элементов папки с учетом настроек сортировки.
+// Остальные страницы подгружаются при прокрутке к концу сетки.
 func (gm *GridManager) LoadItemsByParentWithSort(parentID int) error {
-	items, err := gm.itemLoader.LoadAndSortItemsByParent(parentID, gm.sortOptions)
-	if err != nil {
+	if err := gm.loadFirstPage(parentID, "current_folder"); err != nil {
 		return err
 	}
 
 	gm.currentParentID = parentID
-	gm.LoadItems(items)
+	return nil
+}
+
+// LoadAllItemsWithSort загружает первую страницу элементов всех папок с учетом настроек сортировки
+func (gm *GridManager) LoadAllItemsWithSort() error {
+	return gm.loadFirstPage(0, "all_items")
+}
 
+// loadFirstPage загружает в сетку первую страницу списка и запоминает его для подгрузки следующих
+func (gm *GridManager) loadFirstPage(parentID int, tabMode string) error {
+	options := *gm.sortOptions
+	options.TabMode = tabMode
+
+	page, err := gm.itemLoader.LoadItemsPage(parentID, &options, nil)
+	if err != nil {
+		return err
+	}
+
+	gm.LoadItems(page.Items)
+	gm.pageParentID = parentID
+	gm.pageOptions = &options
+	gm.nextPage = page.Next
+
+	// Если первая страница не заполняет видимую область, прокрутки не будет - подгружаем сразу
+	gm.loadMoreIfNeeded(gm.scroll.Offset)
 	return nil
 }
 
-// LoadItemsBySearchWithSort загружает элементы по поисковому запросу с учетом настроек сортировки
+// loadMoreIfNeeded подгружает следующие страницы, пока конец сетки ближе порога от нижнего края видимой области
+func (gm *GridManager) loadMoreIfNeeded(pos fyne.Position) {
+	for gm.nextPage != nil && pos.Y+gm.scroll.Size().Height+utils.LoadMoreThreshold >= gm.container.Size().Height {
+		if err := gm.loadNextPage(); err != nil {
+			return
+		}
+	}
+}
+
+// loadNextPage добавляет в сетку следующую страницу списка
+func (gm *GridManager) loadNextPage() error {
+	page, err := gm.itemLoader.LoadItemsPage(gm.pageParentID, gm.pageOptions, gm.nextPage)
+	if err != nil {
+		return err
+	}
+
+	gm.nextPage = page.Next
+	gm.cardCache.PrefetchThumbnails(page.Items)
+	gm.createCardsConcurrently(page.Items)
+	gm.updateLayout()
+	go canvas.Refresh(gm.container)
+	return nil
+}
+
+// LoadItemsBySearchWithSort загружает результаты поиска по релевантности с учетом фильтров
+// и показывает под карточками фрагменты с подсвеченными совпадениями
 func (gm *GridManager) LoadItemsBySearchWithSort(query string) error {
-	items, err := gm.itemLoader.LoadAndSortItemsBySearch(query, gm.sortOptions)
+	results, err := gm.itemLoader.LoadAndSortItemsBySearch(query, gm.sortOptions)
 	if err != nil {
 		return err
 	}
 
-	gm.LoadItems(items)
+	gm.loadSearchResults(results)
+	return nil
+}
+
+// LoadItemsBySavedSearch загружает результаты сохранённого поиска с его собственными
+// настройками фильтрации и сортировки, не меняя настройки сетки
+func (gm *GridManager) LoadItemsBySavedSearch(query string, options *services.FilterOptions) error {
+	results, err := gm.itemLoader.LoadAndSortItemsBySavedSearch(query, options)
+	if err != nil {
+		return err
+	}
+
+	gm.loadSearchResults(results)
 	return nil
 }
 
+// loadSearchResults показывает результаты поиска вместе с фрагментами совпадений
+func (gm *GridManager) loadSearchResults(results []*db_models.SearchResult) {
+	items := make([]*db_models.Item, len(results))
+	gm.snippets = make(map[int]string, len(results))
+	for i, result := range results {
+		items[i] = result.Item
+		gm.snippets[result.Item.ID] = result.Snippet
+	}
+
+	gm.LoadItems(items)
+	gm.snippets = nil
+}
+
 // Clear очищает все элементы
 func (gm *GridManager) Clear() {
 	gm.clear()
@@ -475,6 +561,7 @@ func (gm *GridManager) Clear() {
 // Внутренний метод очистки
 func (gm *GridManager) clear() {
 	gm.cards = gm.cards[:0]
+	gm.nextPage = nil
 	gm.container.Objects = gm.container.Objects[:0]
 	// Очищаем кэш размеров при полной очистке сетки
 	gm.widgetSizeCache = make(map[int]fyne.Size)
@@ -3,9 +3,13 @@ package loading
 import (
 	"projectT/internal/services"
 	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/queries"
 	"projectT/internal/ui/workspace/saved/sorting"
 )
 
+// ItemPageSize количество элементов, загружаемых в сетку за один раз
+const ItemPageSize = 100
+
 // ItemLoader загружает элементы из базы данных
 type ItemLoader struct {
 	currentParentID int
@@ -30,16 +34,14 @@ func (il *ItemLoader) LoadItemsByParent(parentID int) ([]*models.Item, error) {
 	return items, err
 }
 
-// LoadAndSortItemsByParent загружает и сортирует элементы по родителю с учетом настроек фильтрации
-func (il *ItemLoader) LoadAndSortItemsByParent(parentID int, options *services.FilterOptions) ([]*models.Item, error) {
-	items, err := il.itemsService.GetItemsByParent(parentID)
-	if err == nil {
+// LoadItemsPage загружает страницу элементов папки (или всех папок в режиме "all_items") после курсора after.
+// Фильтрация и сортировка по настройкам выполняются в базе данных.
+func (il *ItemLoader) LoadItemsPage(parentID int, options *services.FilterOptions, after *queries.ItemCursor) (*queries.ItemPage, error) {
+	page, err := il.itemsService.ListItems(parentID, options, after, ItemPageSize)
+	if err == nil && options.TabMode != "all_items" {
 		il.currentParentID = parentID
-		// Сортируем элементы по настройкам
-		sortedItems := il.sortingManager.GetSortedItems(items, options)
-		return sortedItems, err
 	}
-	return items, err
+	return page, err
 }
 
 // LoadItemsBySearch загружает элементы по поиску
@@ -47,16 +49,48 @@ func (il *ItemLoader) LoadItemsBySearch(query string) ([]*models.Item, error) {
 	return il.itemsService.SearchItems(query)
 }
 
-// LoadAndSortItemsBySearch загружает и сортирует элементы по поиску с учетом настроек фильтрации
-func (il *ItemLoader) LoadAndSortItemsBySearch(query string, options *services.FilterOptions) ([]*models.Item, error) {
-	items, err := il.itemsService.SearchItems(query)
+// LoadAndSortItemsBySearch загружает результаты полнотекстового поиска с учетом настроек фильтрации.
+// Результаты остаются упорядоченными по релевантности: применяются только фильтр по типу и приоритет.
+func (il *ItemLoader) LoadAndSortItemsBySearch(query string, options *services.FilterOptions) ([]*models.SearchResult, error) {
+	results, err := il.itemsService.SearchItemsWithSnippets(query)
 	if err != nil {
-		return items, err
+		return nil, err
 	}
 
-	// Сортируем элементы по настройкам
-	sortedItems := il.sortingManager.GetSortedItems(items, options)
-	return sortedItems, nil
+	items, byID := indexSearchResults(results)
+	return il.searchResultsInOrder(il.sortingManager.GetFilteredItems(items, options), byID), nil
+}
+
+// LoadAndSortItemsBySavedSearch загружает результаты сохранённого поиска (умной папки).
+// В отличие от строки поиска, они сортируются по настройкам сохранённого поиска, а не по релевантности.
+func (il *ItemLoader) LoadAndSortItemsBySavedSearch(query string, options *services.FilterOptions) ([]*models.SearchResult, error) {
+	results, err := il.itemsService.SearchItemsWithSnippets(query)
+	if err != nil {
+		return nil, err
+	}
+
+	items, byID := indexSearchResults(results)
+	return il.searchResultsInOrder(il.sortingManager.GetSortedItems(items, options), byID), nil
+}
+
+// indexSearchResults возвращает элементы результатов поиска и результаты по ID элемента
+func indexSearchResults(results []*models.SearchResult) ([]*models.Item, map[int]*models.SearchResult) {
+	items := make([]*models.Item, len(results))
+	byID := make(map[int]*models.SearchResult, len(results))
+	for i, result := range results {
+		items[i] = result.Item
+		byID[result.Item.ID] = result
+	}
+	return items, byID
+}
+
+// searchResultsInOrder возвращает результаты поиска в порядке отобранных элементов
+func (il *ItemLoader) searchResultsInOrder(items []*models.Item, byID map[int]*models.SearchResult) []*models.SearchResult {
+	ordered := make([]*models.SearchResult, len(items))
+	for i, item := range items {
+		ordered[i] = byID[item.ID]
+	}
+	return ordered
 }
 
 // GetCurrentParentID возвращает текущий parent ID
@@ -1,7 +1,9 @@
 package rendering
 
 import (
+	"projectT/internal/services/thumbnail"
 	db_models "projectT/internal/storage/database/models"
+	"projectT/internal/ui/cards"
 	ui_models "projectT/internal/ui/workspace/saved/models"
 )
 
@@ -61,3 +63,20 @@ func (cc *CardCache) Remove(itemID int) {
 	delete(cc.cache, itemID)
 	delete(cc.rendererCache, itemID)
 }
+
+// PrefetchThumbnails ставит в очередь построение миниатюр всех изображений элементов,
+// чтобы пул воркеров начал работу до того, как карточки запросят их по одной
+func (cc *CardCache) PrefetchThumbnails(items []*db_models.Item) {
+	thumbnails := thumbnail.Get()
+	for _, item := range items {
+		blocks, err := cards.ParseBlocks(item.ContentMeta)
+		if err != nil {
+			continue
+		}
+		for _, block := range blocks {
+			if block.Type == "image" && block.FileHash != "" {
+				thumbnails.Schedule(block.FileHash)
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package saved
+
+import (
+	"strings"
+
+	"projectT/internal/storage/database/queries"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// withSearchSnippet добавляет под карточкой фрагмент текста с подсвеченными совпадениями
+func withSearchSnippet(card fyne.CanvasObject, snippet string) fyne.CanvasObject {
+	return container.NewVBox(card, newSnippetText(snippet))
+}
+
+// newSnippetText превращает фрагмент с маркерами подсветки в RichText, где совпадения выделены жирным
+func newSnippetText(snippet string) *widget.RichText {
+	var segments []widget.RichTextSegment
+
+	for snippet != "" {
+		start := strings.Index(snippet, queries.SearchHighlightStart)
+		if start < 0 {
+			segments = append(segments, snippetSegment(snippet, false))
+			break
+		}
+		if start > 0 {
+			segments = append(segments, snippetSegment(snippet[:start], false))
+		}
+
+		rest := snippet[start+len(queries.SearchHighlightStart):]
+		end := strings.Index(rest, queries.SearchHighlightEnd)
+		if end < 0 {
+			segments = append(segments, snippetSegment(rest, true))
+			break
+		}
+
+		segments = append(segments, snippetSegment(rest[:end], true))
+		snippet = rest[end+len(queries.SearchHighlightEnd):]
+	}
+
+	text := widget.NewRichText(segments...)
+	text.Wrapping = fyne.TextWrapWord
+	return text
+}
+
+// snippetSegment создаёт сегмент фрагмента; совпадения выделяются жирным
+func snippetSegment(text string, highlighted bool) *widget.TextSegment {
+	style := widget.RichTextStyleInline
+	style.TextStyle = fyne.TextStyle{Italic: !highlighted, Bold: highlighted}
+	return &widget.TextSegment{Text: text, Style: style}
+}
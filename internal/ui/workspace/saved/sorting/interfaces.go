@@ -8,6 +8,7 @@ import (
 // ItemSortingInterface интерфейс для сортировки элементов
 type ItemSortingInterface interface {
 	SortItems(items []*models.Item, options *services.FilterOptions) []*models.Item
+	FilterItems(items []*models.Item, options *services.FilterOptions) []*models.Item
 }
 
 // SortingManager предоставляет методы для управления сортировкой
@@ -26,3 +27,8 @@ func NewSortingManager() *SortingManager {
 func (sm *SortingManager) GetSortedItems(items []*models.Item, options *services.FilterOptions) []*models.Item {
 	return sm.sorter.SortItems(items, options)
 }
+
+// GetFilteredItems возвращает элементы, отфильтрованные по типу и приоритету, без изменения их порядка
+func (sm *SortingManager) GetFilteredItems(items []*models.Item, options *services.FilterOptions) []*models.Item {
+	return sm.sorter.FilterItems(items, options)
+}
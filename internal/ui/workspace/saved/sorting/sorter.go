@@ -61,6 +61,18 @@ func (is *ItemSorter) SortItems(items []*models.Item, options *services.FilterOp
 	return sortedItems
 }
 
+// FilterItems применяет фильтр по типу и приоритет, сохраняя исходный порядок элементов
+// (например, порядок релевантности результатов поиска)
+func (is *ItemSorter) FilterItems(items []*models.Item, options *services.FilterOptions) []*models.Item {
+	filteredItems := is.filterByType(items, options.ItemType)
+
+	if options.Priority != "none" {
+		filteredItems = is.applyPriority(filteredItems, options.Priority)
+	}
+
+	return filteredItems
+}
+
 // filterByType фильтрует элементы по типу
 func (is *ItemSorter) filterByType(items []*models.Item, itemType string) []*models.Item {
 	if itemType == "all" {
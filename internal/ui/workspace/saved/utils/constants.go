@@ -23,6 +23,9 @@ const (
 	// ScrollThreshold - порог изменения скролла для обновления макета (в пикселях)
 	ScrollThreshold = 50.0
 
+	// LoadMoreThreshold - расстояние до конца сетки, с которого подгружается следующая страница (в пикселях)
+	LoadMoreThreshold float32 = 600
+
 	// DebounceDelay - задержка дебаунсинга для обновления макета (в миллисекундах)
 	DebounceDelay = 250
 
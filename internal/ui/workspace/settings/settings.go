@@ -0,0 +1,879 @@
+package settings
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"projectT/internal/services"
+	"projectT/internal/services/archive"
+	"projectT/internal/services/backup"
+	"projectT/internal/services/library"
+	"projectT/internal/services/snapshot"
+	"projectT/internal/storage/database/models"
+	"projectT/internal/storage/database/queries"
+	"projectT/internal/storage/fsck"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	scopeLibrary = "Вся библиотека"
+	scopeFolder  = "Папка"
+	scopeTag     = "Тег"
+)
+
+// UI вкладка настроек: библиотеки, обслуживание хранилища, экспорт и импорт, резервные копии, шифрование
+type UI struct {
+	content      fyne.CanvasObject
+	window       fyne.Window
+	checkButton  *widget.Button
+	repairButton *widget.Button
+	status       *widget.Label
+	report       *widget.Label
+	onChanged    func() // Вызывается после исправления или импорта, чтобы обновить сетку элементов
+
+	scopeSelect   *widget.Select
+	targetSelect  *widget.Select
+	targets       map[string]int // Название папки или тега в targetSelect -> ID
+	exportButton  *widget.Button
+	importButton  *widget.Button
+	archiveStatus *widget.Label
+
+	snapshotSelect *widget.Select
+	snapshots      map[string]string // Подпись копии в snapshotSelect -> ID
+	backupButton   *widget.Button
+	restoreButton  *widget.Button
+	backupStatus   *widget.Label
+
+	vault          *services.VaultService
+	vaultState     *widget.Label
+	enableButton   *widget.Button
+	disableButton  *widget.Button
+	passwordButton *widget.Button
+	vaultStatus    *widget.Label
+
+	librarySelect *widget.Select
+	libraries     map[string]string // Название библиотеки в librarySelect -> ID
+	openButton    *widget.Button
+	createButton  *widget.Button
+	renameButton  *widget.Button
+	removeButton  *widget.Button
+	libraryStatus *widget.Label
+}
+
+// New создает вкладку настроек
+func New() *UI {
+	ui := &UI{}
+	ui.content = ui.createView()
+	return ui
+}
+
+// SetWindow устанавливает окно для диалогов
+func (s *UI) SetWindow(window fyne.Window) {
+	s.window = window
+}
+
+// SetOnChanged устанавливает обработчик изменения данных после исправления
+func (s *UI) SetOnChanged(callback func()) {
+	s.onChanged = callback
+}
+
+// GetContent возвращает содержимое вкладки
+func (s *UI) GetContent() fyne.CanvasObject {
+	return s.content
+}
+
+func (s *UI) createView() fyne.CanvasObject {
+	titleLabel := widget.NewLabel("Настройки")
+	titleLabel.TextStyle = fyne.TextStyle{Bold: true}
+
+	return container.NewBorder(titleLabel, nil, nil, nil,
+		container.NewVScroll(container.NewVBox(
+			s.createLibrarySection(),
+			widget.NewSeparator(),
+			s.createStorageSection(),
+			widget.NewSeparator(),
+			s.createArchiveSection(),
+			widget.NewSeparator(),
+			s.createBackupSection(),
+			widget.NewSeparator(),
+			s.createVaultSection(),
+		)),
+	)
+}
+
+// createLibrarySection создает раздел переключения библиотек
+func (s *UI) createLibrarySection() fyne.CanvasObject {
+	sectionTitle := widget.NewLabel("Библиотеки")
+	sectionTitle.TextStyle = fyne.TextStyle{Bold: true}
+
+	description := widget.NewLabel("У каждой библиотеки своя база данных, файлы и резервные копии, например рабочая и личная коллекции. " +
+		"При переключении интерфейс и P2P перезапускаются с данными выбранной библиотеки.")
+	description.Wrapping = fyne.TextWrapWord
+
+	s.librarySelect = widget.NewSelect(nil, func(string) { s.setLibraryBusy(false, "") })
+	s.openButton = widget.NewButtonWithIcon("Открыть", theme.FolderOpenIcon(), s.openLibrary)
+	s.createButton = widget.NewButtonWithIcon("Создать", theme.ContentAddIcon(), s.createLibrary)
+	s.renameButton = widget.NewButtonWithIcon("Переименовать", theme.DocumentCreateIcon(), s.renameLibrary)
+	s.removeButton = widget.NewButtonWithIcon("Удалить", theme.DeleteIcon(), s.removeLibrary)
+	s.removeButton.Importance = widget.DangerImportance
+
+	s.libraryStatus = widget.NewLabel("")
+	s.libraryStatus.Wrapping = fyne.TextWrapWord
+
+	s.loadLibraries("")
+
+	return container.NewVBox(
+		sectionTitle,
+		description,
+		s.librarySelect,
+		container.NewHBox(s.openButton, s.createButton, s.renameButton, s.removeButton),
+		s.libraryStatus,
+	)
+}
+
+// loadLibraries заполняет список библиотек и выбирает selectID, по умолчанию - открытую
+func (s *UI) loadLibraries(selectID string) {
+	registry := library.Current()
+	if registry == nil {
+		s.librarySelect.Options = nil
+		s.librarySelect.Refresh()
+		s.setLibraryBusy(true, "Реестр библиотек недоступен: открыта основная библиотека")
+		return
+	}
+
+	active := registry.Active()
+	if selectID == "" {
+		selectID = active.ID
+	}
+	s.libraries = make(map[string]string)
+	options := make([]string, 0)
+	selected := ""
+	for _, lib := range registry.List() {
+		label := lib.Name
+		if lib.ID == active.ID {
+			label += " (открыта)"
+		}
+		s.libraries[label] = lib.ID
+		options = append(options, label)
+		if lib.ID == selectID {
+			selected = label
+		}
+	}
+	s.librarySelect.Options = options
+	s.librarySelect.SetSelected(selected)
+	s.setLibraryBusy(false, "")
+}
+
+// selectedLibrary возвращает выбранную в списке библиотеку
+func (s *UI) selectedLibrary() (library.Library, bool) {
+	registry := library.Current()
+	id, ok := s.libraries[s.librarySelect.Selected]
+	if registry == nil || !ok {
+		return library.Library{}, false
+	}
+	lib, err := registry.Get(id)
+	return lib, err == nil
+}
+
+// openLibrary переключает приложение на выбранную библиотеку
+func (s *UI) openLibrary() {
+	lib, ok := s.selectedLibrary()
+	if !ok {
+		return
+	}
+	s.setLibraryBusy(true, fmt.Sprintf("Открытие библиотеки «%s»...", lib.Name))
+	go func() {
+		// При успехе вкладка строится заново вместе со всем интерфейсом
+		if err := library.Switch(lib.ID); err != nil {
+			s.setLibraryBusy(false, "")
+			s.showError(err)
+		}
+	}()
+}
+
+// createLibrary создает пустую библиотеку с введённым названием
+func (s *UI) createLibrary() {
+	window := s.dialogWindow()
+	registry := library.Current()
+	if window == nil || registry == nil {
+		return
+	}
+	name := widget.NewEntry()
+	dialog.ShowForm("Новая библиотека", "Создать", "Отмена",
+		[]*widget.FormItem{widget.NewFormItem("Название", name)},
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			lib, err := registry.Create(name.Text)
+			if err != nil {
+				s.showError(err)
+				return
+			}
+			s.loadLibraries(lib.ID)
+			s.libraryStatus.SetText(fmt.Sprintf("Библиотека «%s» создана. Откройте её, чтобы добавить элементы.", lib.Name))
+		}, window)
+}
+
+// renameLibrary переименовывает выбранную библиотеку
+func (s *UI) renameLibrary() {
+	window := s.dialogWindow()
+	registry := library.Current()
+	lib, ok := s.selectedLibrary()
+	if window == nil || !ok {
+		return
+	}
+	name := widget.NewEntry()
+	name.SetText(lib.Name)
+	dialog.ShowForm("Переименовать библиотеку", "Сохранить", "Отмена",
+		[]*widget.FormItem{widget.NewFormItem("Название", name)},
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if err := registry.Rename(lib.ID, name.Text); err != nil {
+				s.showError(err)
+				return
+			}
+			s.loadLibraries(lib.ID)
+		}, window)
+}
+
+// removeLibrary после подтверждения удаляет выбранную библиотеку со всеми данными
+func (s *UI) removeLibrary() {
+	window := s.dialogWindow()
+	registry := library.Current()
+	lib, ok := s.selectedLibrary()
+	if window == nil || !ok {
+		return
+	}
+	dialog.ShowConfirm("Удаление библиотеки",
+		fmt.Sprintf("Удалить библиотеку «%s» вместе со всеми элементами, файлами и резервными копиями? "+
+			"Это действие нельзя отменить.", lib.Name),
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if err := registry.Remove(lib.ID); err != nil {
+				s.showError(err)
+			}
+			s.loadLibraries("")
+		}, window)
+}
+
+// setLibraryBusy блокирует кнопки библиотек на время переключения; основную и открытую библиотеки удалить нельзя
+func (s *UI) setLibraryBusy(busy bool, status string) {
+	lib, selected := s.selectedLibrary()
+	active := selected && library.Current() != nil && library.Current().Active().ID == lib.ID
+
+	for button, enabled := range map[*widget.Button]bool{
+		s.openButton:   !busy && selected && !active,
+		s.createButton: !busy && library.Current() != nil,
+		s.renameButton: !busy && selected,
+		s.removeButton: !busy && selected && !active && !lib.IsDefault(),
+	} {
+		if enabled {
+			button.Enable()
+		} else {
+			button.Disable()
+		}
+	}
+	s.libraryStatus.SetText(status)
+}
+
+// createStorageSection создает раздел проверки целостности хранилища
+func (s *UI) createStorageSection() fyne.CanvasObject {
+	sectionTitle := widget.NewLabel("Целостность хранилища")
+	sectionTitle.TextStyle = fyne.TextStyle{Bold: true}
+
+	description := widget.NewLabel("Проверка перехэширует файлы на диске и сверяет их с базой данных: " +
+		"отсутствующие и повреждённые файлы, файлы без ссылок, размеры и MIME-типы, хэши содержимого элементов и дерево папок.")
+	description.Wrapping = fyne.TextWrapWord
+
+	s.checkButton = widget.NewButtonWithIcon("Проверить", theme.SearchIcon(), func() {
+		s.run(false)
+	})
+
+	s.repairButton = widget.NewButtonWithIcon("Исправить", theme.ViewRefreshIcon(), func() {
+		window := s.dialogWindow()
+		if window == nil {
+			return
+		}
+		dialog.ShowConfirm("Исправление хранилища",
+			"Исправить найденные проблемы? Записи об отсутствующих файлах будут удалены, "+
+				"повреждённые файлы перенесены в карантин, а элементы с неверным родителем - в корень.",
+			func(confirmed bool) {
+				if confirmed {
+					s.run(true)
+				}
+			}, window)
+	})
+	s.repairButton.Importance = widget.WarningImportance
+
+	s.status = widget.NewLabel("")
+	s.status.TextStyle = fyne.TextStyle{Italic: true}
+
+	s.report = widget.NewLabel("")
+	s.report.Wrapping = fyne.TextWrapWord
+
+	return container.NewVBox(
+		sectionTitle,
+		description,
+		container.NewHBox(s.checkButton, s.repairButton),
+		s.status,
+		s.report,
+	)
+}
+
+// run выполняет проверку в фоне и показывает отчёт
+func (s *UI) run(repair bool) {
+	s.checkButton.Disable()
+	s.repairButton.Disable()
+	s.status.SetText("Проверка выполняется...")
+	s.report.SetText("")
+
+	go func() {
+		report, err := fsck.Run(fsck.Options{Repair: repair})
+
+		s.checkButton.Enable()
+		s.repairButton.Enable()
+		if err != nil {
+			s.status.SetText("")
+			s.showError(fmt.Errorf("ошибка проверки целостности: %w", err))
+			return
+		}
+
+		s.status.SetText(report.Summary())
+		if len(report.Issues) == 0 {
+			s.report.SetText("Проблем не найдено")
+		} else {
+			s.report.SetText(report.String())
+		}
+
+		if repair && len(report.Issues) > 0 && s.onChanged != nil {
+			s.onChanged()
+		}
+	}()
+}
+
+// createArchiveSection создает раздел экспорта и импорта библиотеки
+func (s *UI) createArchiveSection() fyne.CanvasObject {
+	sectionTitle := widget.NewLabel("Экспорт и импорт")
+	sectionTitle.TextStyle = fyne.TextStyle{Bold: true}
+
+	description := widget.NewLabel("Архив содержит элементы, теги, дерево папок и файлы и переносится на другое устройство без P2P. " +
+		"При импорте уже имеющиеся элементы и файлы не дублируются, теги объединяются по имени.")
+	description.Wrapping = fyne.TextWrapWord
+
+	s.targetSelect = widget.NewSelect(nil, nil)
+	s.targetSelect.Disable()
+	s.scopeSelect = widget.NewSelect([]string{scopeLibrary, scopeFolder, scopeTag}, func(string) {
+		s.loadTargets()
+	})
+	s.scopeSelect.SetSelected(scopeLibrary)
+
+	s.exportButton = widget.NewButtonWithIcon("Экспортировать...", theme.UploadIcon(), s.showExportDialog)
+	s.importButton = widget.NewButtonWithIcon("Импортировать...", theme.DownloadIcon(), s.showImportDialog)
+
+	s.archiveStatus = widget.NewLabel("")
+	s.archiveStatus.Wrapping = fyne.TextWrapWord
+
+	return container.NewVBox(
+		sectionTitle,
+		description,
+		container.NewHBox(s.scopeSelect, s.targetSelect),
+		container.NewHBox(s.exportButton, s.importButton),
+		s.archiveStatus,
+	)
+}
+
+// loadTargets заполняет список папок или тегов для выбранной части библиотеки
+func (s *UI) loadTargets() {
+	s.targets = make(map[string]int)
+	var names []string
+
+	switch s.scopeSelect.Selected {
+	case scopeFolder:
+		items, err := queries.GetAllItems()
+		if err != nil {
+			s.showError(fmt.Errorf("ошибка загрузки папок: %w", err))
+			return
+		}
+		for _, item := range items {
+			if item.Type == models.ItemTypeFolder {
+				name := s.uniqueTargetName(item.Title, item.ID)
+				s.targets[name] = item.ID
+				names = append(names, name)
+			}
+		}
+	case scopeTag:
+		tags, err := services.NewTagsService().GetAllTags(context.Background())
+		if err != nil {
+			s.showError(fmt.Errorf("ошибка загрузки тегов: %w", err))
+			return
+		}
+		for _, tag := range tags {
+			s.targets[tag.Name] = tag.ID
+			names = append(names, tag.Name)
+		}
+	}
+
+	sort.Strings(names)
+	s.targetSelect.Options = names
+	s.targetSelect.ClearSelected()
+	if len(names) > 0 {
+		s.targetSelect.SetSelected(names[0])
+	}
+	if s.scopeSelect.Selected == scopeLibrary {
+		s.targetSelect.Disable()
+	} else {
+		s.targetSelect.Enable()
+	}
+}
+
+// uniqueTargetName различает папки с одинаковыми названиями
+func (s *UI) uniqueTargetName(title string, id int) string {
+	if title == "" {
+		title = "(без названия)"
+	}
+	if _, exists := s.targets[title]; exists {
+		return fmt.Sprintf("%s (%d)", title, id)
+	}
+	return title
+}
+
+// selectedScope возвращает выбранную часть библиотеки для экспорта
+func (s *UI) selectedScope() (archive.ScopeKind, int, bool) {
+	switch s.scopeSelect.Selected {
+	case scopeFolder, scopeTag:
+		id, ok := s.targets[s.targetSelect.Selected]
+		if !ok {
+			return "", 0, false
+		}
+		if s.scopeSelect.Selected == scopeFolder {
+			return archive.ScopeFolder, id, true
+		}
+		return archive.ScopeTag, id, true
+	}
+	return archive.ScopeLibrary, 0, true
+}
+
+// showExportDialog выбирает файл архива и экспортирует в него
+func (s *UI) showExportDialog() {
+	window := s.dialogWindow()
+	if window == nil {
+		return
+	}
+	scope, id, ok := s.selectedScope()
+	if !ok {
+		dialog.ShowInformation("Экспорт", "Выберите папку или тег для экспорта", window)
+		return
+	}
+
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			s.showError(err)
+			return
+		}
+		if writer == nil {
+			return
+		}
+
+		s.setArchiveBusy(true, "Экспорт выполняется...")
+		go func() {
+			report, err := archive.NewService().Export(context.Background(), writer, scope, id)
+			if closeErr := writer.Close(); err == nil && closeErr != nil {
+				err = closeErr
+			}
+
+			s.setArchiveBusy(false, "")
+			if err != nil {
+				s.showError(fmt.Errorf("ошибка экспорта: %w", err))
+				return
+			}
+			s.archiveStatus.SetText("Экспорт завершён: " + report.String())
+		}()
+	}, window)
+	save.SetFileName("library.zip")
+	save.SetFilter(storage.NewExtensionFileFilter([]string{".zip"}))
+	save.Show()
+}
+
+// showImportDialog выбирает файл архива и импортирует его в корень библиотеки
+func (s *UI) showImportDialog() {
+	window := s.dialogWindow()
+	if window == nil {
+		return
+	}
+
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			s.showError(err)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		filePath := reader.URI().Path()
+		reader.Close()
+
+		s.setArchiveBusy(true, "Импорт выполняется...")
+		go func() {
+			report, err := archive.NewService().ImportFile(context.Background(), filePath, archive.ImportOptions{})
+
+			s.setArchiveBusy(false, "")
+			if err != nil {
+				s.showError(fmt.Errorf("ошибка импорта: %w", err))
+				return
+			}
+			text := "Импорт завершён: " + report.String()
+			for _, warning := range report.Warnings {
+				text += "\n" + warning
+			}
+			s.archiveStatus.SetText(text)
+			s.loadTargets()
+
+			// Текст сохранённых копий страниц из архива добавляется в поиск
+			if _, err := snapshot.Get().IndexTexts(); err != nil {
+				s.showError(fmt.Errorf("ошибка индексации копий страниц: %w", err))
+			}
+
+			if report.ItemsCreated > 0 && s.onChanged != nil {
+				s.onChanged()
+			}
+		}()
+	}, window)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{".zip"}))
+	open.Show()
+}
+
+// setArchiveBusy блокирует кнопки экспорта и импорта на время операции
+func (s *UI) setArchiveBusy(busy bool, status string) {
+	if busy {
+		s.exportButton.Disable()
+		s.importButton.Disable()
+	} else {
+		s.exportButton.Enable()
+		s.importButton.Enable()
+	}
+	s.archiveStatus.SetText(status)
+}
+
+// createBackupSection создает раздел резервных копий
+func (s *UI) createBackupSection() fyne.CanvasObject {
+	sectionTitle := widget.NewLabel("Резервные копии")
+	sectionTitle.TextStyle = fyne.TextStyle{Bold: true}
+
+	description := widget.NewLabel("Зашифрованные копии базы данных и файлов. Каждая копия дописывает только новые файлы; " +
+		"перед восстановлением копия полностью проверяется, а прежняя база сохраняется рядом.")
+	description.Wrapping = fyne.TextWrapWord
+
+	s.snapshotSelect = widget.NewSelect(nil, nil)
+	s.snapshotSelect.PlaceHolder = "Выберите копию"
+
+	s.backupButton = widget.NewButtonWithIcon("Создать копию", theme.DocumentSaveIcon(), func() {
+		s.withBackupPassword(s.createBackup)
+	})
+	s.restoreButton = widget.NewButtonWithIcon("Восстановить", theme.HistoryIcon(), s.confirmRestore)
+	s.restoreButton.Importance = widget.WarningImportance
+
+	s.backupStatus = widget.NewLabel("")
+	s.backupStatus.Wrapping = fyne.TextWrapWord
+
+	s.loadSnapshots()
+
+	return container.NewVBox(
+		sectionTitle,
+		description,
+		s.snapshotSelect,
+		container.NewHBox(s.backupButton, s.restoreButton),
+		s.backupStatus,
+	)
+}
+
+// loadSnapshots заполняет список резервных копий
+func (s *UI) loadSnapshots() {
+	list, err := backup.NewService().List()
+	if err != nil {
+		s.backupStatus.SetText(err.Error())
+		return
+	}
+
+	s.snapshots = make(map[string]string, len(list))
+	options := make([]string, 0, len(list))
+	for _, snapshot := range list {
+		label := fmt.Sprintf("%s (файлов: %d)", snapshot.CreatedAt.Local().Format("02.01.2006 15:04:05"), snapshot.Blobs)
+		s.snapshots[label] = snapshot.ID
+		options = append(options, label)
+	}
+	s.snapshotSelect.Options = options
+	s.snapshotSelect.ClearSelected()
+	if len(options) == 0 {
+		s.restoreButton.Disable()
+	} else {
+		s.restoreButton.Enable()
+	}
+}
+
+// withBackupPassword вызывает action с сервисом копий; если пароль не задан в настройках, запрашивает его
+func (s *UI) withBackupPassword(action func(service *backup.Service)) {
+	service := backup.NewService()
+	if backup.HasPassword() {
+		action(service)
+		return
+	}
+
+	window := s.dialogWindow()
+	if window == nil {
+		return
+	}
+	password := widget.NewPasswordEntry()
+	dialog.ShowForm("Пароль резервных копий", "OK", "Отмена",
+		[]*widget.FormItem{widget.NewFormItem("Пароль", password)},
+		func(confirmed bool) {
+			if !confirmed || password.Text == "" {
+				return
+			}
+			service.SetPassword(password.Text)
+			action(service)
+		}, window)
+}
+
+// createBackup создаёт копию в фоне
+func (s *UI) createBackup(service *backup.Service) {
+	s.setBackupBusy(true, "Создание копии...")
+	go func() {
+		report, err := service.Create(context.Background())
+		s.setBackupBusy(false, "")
+		if err != nil {
+			s.showError(fmt.Errorf("ошибка резервного копирования: %w", err))
+			return
+		}
+		s.backupStatus.SetText("Копия создана: " + report.String())
+		s.loadSnapshots()
+	}()
+}
+
+// confirmRestore восстанавливает выбранную копию после подтверждения
+func (s *UI) confirmRestore() {
+	window := s.dialogWindow()
+	if window == nil {
+		return
+	}
+	id, ok := s.snapshots[s.snapshotSelect.Selected]
+	if !ok {
+		dialog.ShowInformation("Восстановление", "Выберите копию для восстановления", window)
+		return
+	}
+
+	dialog.ShowConfirm("Восстановление из копии",
+		"Заменить библиотеку состоянием на "+s.snapshotSelect.Selected+"? "+
+			"Изменения после этой копии пропадут, но прежняя база данных будет сохранена рядом с текущей.",
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			s.withBackupPassword(func(service *backup.Service) {
+				s.setBackupBusy(true, "Проверка и восстановление копии...")
+				go func() {
+					report, err := service.Restore(context.Background(), id)
+					s.setBackupBusy(false, "")
+					if err != nil {
+						s.showError(fmt.Errorf("ошибка восстановления: %w", err))
+						return
+					}
+					s.backupStatus.SetText(report.String())
+					s.loadTargets()
+					if s.onChanged != nil {
+						s.onChanged()
+					}
+				}()
+			})
+		}, window)
+}
+
+// setBackupBusy блокирует кнопки резервных копий на время операции
+func (s *UI) setBackupBusy(busy bool, status string) {
+	if busy {
+		s.backupButton.Disable()
+		s.restoreButton.Disable()
+	} else {
+		s.backupButton.Enable()
+		s.restoreButton.Enable()
+	}
+	s.backupStatus.SetText(status)
+}
+
+// createVaultSection создает раздел шифрования хранилища
+func (s *UI) createVaultSection() fyne.CanvasObject {
+	sectionTitle := widget.NewLabel("Шифрование хранилища")
+	sectionTitle.TextStyle = fyne.TextStyle{Bold: true}
+
+	description := widget.NewLabel("Файлы и сообщения чата с заметками контактов хранятся на диске зашифрованными; " +
+		"при запуске приложение спрашивает пароль. Названия и описания элементов не шифруются: по ним работает поиск. " +
+		"Забытый пароль восстановить нельзя.")
+	description.Wrapping = fyne.TextWrapWord
+
+	s.vault = services.NewVaultService()
+	s.vaultState = widget.NewLabel("")
+	s.vaultState.TextStyle = fyne.TextStyle{Bold: true}
+
+	s.enableButton = widget.NewButtonWithIcon("Включить", theme.VisibilityOffIcon(), s.enableVault)
+	s.disableButton = widget.NewButtonWithIcon("Выключить", theme.VisibilityIcon(), s.disableVault)
+	s.disableButton.Importance = widget.WarningImportance
+	s.passwordButton = widget.NewButtonWithIcon("Сменить пароль", theme.AccountIcon(), s.changeVaultPassword)
+
+	s.vaultStatus = widget.NewLabel("")
+	s.vaultStatus.Wrapping = fyne.TextWrapWord
+
+	s.setVaultBusy(false, "")
+
+	return container.NewVBox(
+		sectionTitle,
+		description,
+		s.vaultState,
+		container.NewHBox(s.enableButton, s.disableButton, s.passwordButton),
+		s.vaultStatus,
+	)
+}
+
+// enableVault запрашивает новый пароль и шифрует библиотеку в фоне
+func (s *UI) enableVault() {
+	window := s.dialogWindow()
+	if window == nil {
+		return
+	}
+	password := widget.NewPasswordEntry()
+	confirm := widget.NewPasswordEntry()
+	dialog.ShowForm("Включить шифрование", "Зашифровать", "Отмена",
+		[]*widget.FormItem{
+			widget.NewFormItem("Пароль", password),
+			widget.NewFormItem("Повторите пароль", confirm),
+		},
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if password.Text == "" || password.Text != confirm.Text {
+				s.showError(fmt.Errorf("пароли не совпадают или пусты"))
+				return
+			}
+			s.setVaultBusy(true, "Шифрование файлов и базы данных...")
+			go func() {
+				report, err := s.vault.Enable(context.Background(), password.Text)
+				s.setVaultBusy(false, "")
+				if err != nil {
+					s.showError(fmt.Errorf("ошибка включения шифрования: %w", err))
+					return
+				}
+				s.vaultStatus.SetText("Зашифровано: " + report.String())
+			}()
+		}, window)
+}
+
+// disableVault после подтверждения паролем расшифровывает библиотеку в фоне
+func (s *UI) disableVault() {
+	window := s.dialogWindow()
+	if window == nil {
+		return
+	}
+	password := widget.NewPasswordEntry()
+	dialog.ShowForm("Выключить шифрование", "Расшифровать", "Отмена",
+		[]*widget.FormItem{widget.NewFormItem("Пароль", password)},
+		func(confirmed bool) {
+			if !confirmed || password.Text == "" {
+				return
+			}
+			s.setVaultBusy(true, "Расшифровка файлов и базы данных...")
+			go func() {
+				report, err := s.vault.Disable(context.Background(), password.Text)
+				s.setVaultBusy(false, "")
+				if err != nil {
+					s.showError(fmt.Errorf("ошибка выключения шифрования: %w", err))
+					return
+				}
+				s.vaultStatus.SetText("Расшифровано: " + report.String())
+				// В зашифрованном хранилище текст копий страниц в поиск не попадал
+				if _, err := snapshot.Get().IndexTexts(); err != nil {
+					s.showError(fmt.Errorf("ошибка добавления текста копий страниц в поиск: %w", err))
+				}
+			}()
+		}, window)
+}
+
+// changeVaultPassword меняет пароль хранилища
+func (s *UI) changeVaultPassword() {
+	window := s.dialogWindow()
+	if window == nil {
+		return
+	}
+	oldPassword := widget.NewPasswordEntry()
+	newPassword := widget.NewPasswordEntry()
+	dialog.ShowForm("Сменить пароль хранилища", "Сменить", "Отмена",
+		[]*widget.FormItem{
+			widget.NewFormItem("Текущий пароль", oldPassword),
+			widget.NewFormItem("Новый пароль", newPassword),
+		},
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if err := s.vault.ChangePassword(oldPassword.Text, newPassword.Text); err != nil {
+				s.showError(err)
+				return
+			}
+			s.vaultStatus.SetText("Пароль изменён")
+		}, window)
+}
+
+// setVaultBusy блокирует кнопки шифрования на время операции и показывает текущий режим
+func (s *UI) setVaultBusy(busy bool, status string) {
+	enabled := s.vault.Enabled()
+	switch {
+	case !enabled:
+		s.vaultState.SetText("Шифрование выключено")
+	case s.vault.Unlocked():
+		s.vaultState.SetText("Шифрование включено")
+	default:
+		s.vaultState.SetText("Шифрование включено, хранилище заблокировано")
+	}
+
+	// Прерванное включение можно продолжить той же кнопкой
+	for button, active := range map[*widget.Button]bool{
+		s.enableButton:   !busy,
+		s.disableButton:  !busy && enabled,
+		s.passwordButton: !busy && enabled,
+	} {
+		if active {
+			button.Enable()
+		} else {
+			button.Disable()
+		}
+	}
+	s.vaultStatus.SetText(status)
+}
+
+// showError показывает ошибку в диалоге
+func (s *UI) showError(err error) {
+	if window := s.dialogWindow(); window != nil {
+		dialog.ShowError(err, window)
+	}
+}
+
+// dialogWindow возвращает окно для диалогов
+func (s *UI) dialogWindow() fyne.Window {
+	if s.window != nil {
+		return s.window
+	}
+	if windows := fyne.CurrentApp().Driver().AllWindows(); len(windows) > 0 {
+		return windows[0]
+	}
+	return nil
+}
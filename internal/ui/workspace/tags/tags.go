@@ -7,6 +7,7 @@ import (
 	"projectT/internal/services"
 	"projectT/internal/services/favorites"
 	"projectT/internal/storage/database/models"
+	"projectT/internal/ui/sharing"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
@@ -153,7 +154,14 @@ func (t *UI) createTable() *widget.Table {
 				}
 				favBtn.Importance = widget.LowImportance
 
+				shareBtn := widget.NewButton("🔒", func() {
+					w := fyne.CurrentApp().Driver().AllWindows()[0]
+					sharing.ShowSharingDialog(w, models.SharingEntityTag, tag.ID, tag.Name)
+				})
+				shareBtn.Importance = widget.LowImportance
+
 				cellContainer.Add(favBtn)
+				cellContainer.Add(shareBtn)
 				cellContainer.Add(editBtn)
 				cellContainer.Add(deleteBtn)
 			}
@@ -166,7 +174,7 @@ func (t *UI) createTable() *widget.Table {
 	table.SetColumnWidth(2, 200) // Имя
 	table.SetColumnWidth(3, 100) // Количество
 	table.SetColumnWidth(4, 250) // Описание
-	table.SetColumnWidth(5, 100) // Действия
+	table.SetColumnWidth(5, 130) // Действия
 
 	return table
 }
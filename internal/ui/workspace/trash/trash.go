@@ -0,0 +1,180 @@
+package trash
+
+import (
+	"fmt"
+	"projectT/internal/services"
+	"projectT/internal/storage/database/models"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// trashService - глобальный экземпляр сервиса корзины
+var trashService = services.NewTrashService()
+
+// UI вкладка корзины: восстановление и окончательное удаление элементов
+type UI struct {
+	content   fyne.CanvasObject
+	list      *fyne.Container
+	window    fyne.Window
+	onChanged func() // Вызывается после восстановления или удаления, чтобы обновить сетку элементов
+}
+
+// New создает вкладку корзины
+func New() *UI {
+	ui := &UI{}
+	ui.content = ui.createView()
+	return ui
+}
+
+// SetWindow устанавливает окно для диалогов
+func (t *UI) SetWindow(window fyne.Window) {
+	t.window = window
+}
+
+// SetOnChanged устанавливает обработчик изменения содержимого корзины
+func (t *UI) SetOnChanged(callback func()) {
+	t.onChanged = callback
+}
+
+// GetContent возвращает содержимое вкладки
+func (t *UI) GetContent() fyne.CanvasObject {
+	return t.content
+}
+
+func (t *UI) createView() fyne.CanvasObject {
+	titleLabel := widget.NewLabel("Корзина")
+	titleLabel.TextStyle = fyne.TextStyle{Bold: true}
+
+	emptyButton := widget.NewButtonWithIcon("Очистить корзину", theme.DeleteIcon(), func() {
+		t.confirm("Очистка корзины", "Удалить всё содержимое корзины навсегда? Это действие нельзя отменить.", func() error {
+			return trashService.EmptyTrash()
+		})
+	})
+	emptyButton.Importance = widget.DangerImportance
+
+	t.list = container.NewVBox()
+	t.Refresh()
+
+	return container.NewBorder(
+		container.NewBorder(nil, nil, titleLabel, emptyButton),
+		nil, nil, nil,
+		container.NewVScroll(t.list),
+	)
+}
+
+// Refresh перезагружает содержимое корзины
+func (t *UI) Refresh() {
+	entries, err := trashService.GetTrashEntries()
+	if err != nil {
+		t.list.Objects = []fyne.CanvasObject{widget.NewLabel("Ошибка загрузки корзины: " + err.Error())}
+		t.list.Refresh()
+		return
+	}
+
+	if len(entries) == 0 {
+		emptyLabel := widget.NewLabel("Корзина пуста")
+		emptyLabel.TextStyle = fyne.TextStyle{Italic: true}
+		t.list.Objects = []fyne.CanvasObject{emptyLabel}
+		t.list.Refresh()
+		return
+	}
+
+	objects := make([]fyne.CanvasObject, 0, len(entries)*2)
+	for _, entry := range entries {
+		objects = append(objects, t.createEntryRow(entry), widget.NewSeparator())
+	}
+	t.list.Objects = objects
+	t.list.Refresh()
+}
+
+// createEntryRow создает строку записи корзины с кнопками восстановления и удаления
+func (t *UI) createEntryRow(entry *models.TrashEntry) fyne.CanvasObject {
+	prefix := "📄 "
+	if entry.Item.Type == models.ItemTypeFolder {
+		prefix = "📁 "
+	}
+
+	title := entry.Item.Title
+	if title == "" {
+		title = "--заголовок отсутствует--"
+	}
+	titleLabel := widget.NewLabel(prefix + title)
+	titleLabel.TextStyle = fyne.TextStyle{Bold: true}
+
+	details := fmt.Sprintf("Удалено %s", entry.DeletedAt.Local().Format("02.01.2006 15:04"))
+	if entry.ItemCount > 1 {
+		details += fmt.Sprintf(" · вложенных элементов: %d", entry.ItemCount-1)
+	}
+	detailsLabel := widget.NewLabel(details)
+	detailsLabel.TextStyle = fyne.TextStyle{Italic: true}
+
+	restoreButton := widget.NewButtonWithIcon("Восстановить", theme.ContentUndoIcon(), func() {
+		if _, err := trashService.Restore(entry.ID); err != nil {
+			t.showError(fmt.Errorf("не удалось восстановить элемент: %w", err))
+			return
+		}
+		t.changed()
+	})
+
+	purgeButton := widget.NewButtonWithIcon("Удалить навсегда", theme.DeleteIcon(), func() {
+		t.confirm("Окончательное удаление",
+			fmt.Sprintf("Удалить \"%s\" навсегда? Это действие нельзя отменить.", title),
+			func() error {
+				return trashService.Purge(entry.ID)
+			})
+	})
+	purgeButton.Importance = widget.LowImportance
+
+	return container.NewBorder(nil, nil, nil,
+		container.NewHBox(restoreButton, purgeButton),
+		container.NewVBox(titleLabel, detailsLabel),
+	)
+}
+
+// confirm выполняет действие после подтверждения и обновляет корзину
+func (t *UI) confirm(title, message string, action func() error) {
+	window := t.dialogWindow()
+	if window == nil {
+		return
+	}
+
+	dialog.ShowConfirm(title, message, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		if err := action(); err != nil {
+			t.showError(err)
+		}
+		t.changed()
+	}, window)
+}
+
+// changed обновляет корзину и сообщает об изменении
+func (t *UI) changed() {
+	t.Refresh()
+	if t.onChanged != nil {
+		t.onChanged()
+	}
+}
+
+// showError показывает ошибку в диалоге
+func (t *UI) showError(err error) {
+	if window := t.dialogWindow(); window != nil {
+		dialog.ShowError(err, window)
+	}
+}
+
+// dialogWindow возвращает окно для диалогов
+func (t *UI) dialogWindow() fyne.Window {
+	if t.window != nil {
+		return t.window
+	}
+	if windows := fyne.CurrentApp().Driver().AllWindows(); len(windows) > 0 {
+		return windows[0]
+	}
+	return nil
+}
@@ -1,16 +1,22 @@
 package workspace
 
 import (
+	"errors"
 	"fmt"
 	"image/color"
+	"log"
 	"projectT/internal/services"
+	"projectT/internal/services/favorites"
 	"projectT/internal/storage/database/models"
 	"projectT/internal/storage/database/queries"
 	"projectT/internal/ui/workspace/chats"
 	"projectT/internal/ui/workspace/profile"
 	"projectT/internal/ui/workspace/saved"
-	"projectT/internal/ui/workspace/saved/sorting"
+	"projectT/internal/ui/workspace/settings"
 	"projectT/internal/ui/workspace/tags"
+	"projectT/internal/ui/workspace/trash"
+	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
@@ -19,17 +25,25 @@ import (
 	p2p_ui "projectT/internal/services/p2p/network"
 )
 
+// itemsRefreshDelay сколько ждать следующих изменений элементов в фоне, прежде чем обновить сетку
+const itemsRefreshDelay = 500 * time.Millisecond
+
 // itemsService - глобальный экземпляр сервиса элементов
 var itemsService = services.NewItemsService()
 
+// savedSearchesService - глобальный экземпляр сервиса сохранённых поисков
+var savedSearchesService = services.NewSavedSearchesService()
+
 // ContentType определяет тип отображаемого контента
 type ContentType string
 
 const (
-	ContentTypeSaved   ContentType = "saved"
-	ContentTypeProfile ContentType = "profile"
-	ContentTypeTags    ContentType = "tags"
-	ContentTypeChats   ContentType = "chats"
+	ContentTypeSaved    ContentType = "saved"
+	ContentTypeProfile  ContentType = "profile"
+	ContentTypeTags     ContentType = "tags"
+	ContentTypeChats    ContentType = "chats"
+	ContentTypeTrash    ContentType = "trash"
+	ContentTypeSettings ContentType = "settings"
 )
 
 // NavigationHandler интерфейс для обработки навигации
@@ -51,18 +65,28 @@ type Workspace struct {
 	profileUI         *profile.UI
 	tagsUI            *tags.UI
 	chatsUI           *chats.UI
+	trashUI           *trash.UI
+	settingsUI        *settings.UI
 	window            fyne.Window
 	p2pNetwork        *p2p_network.P2PNetwork // P2P сеть
 	// Флаги для отслеживания, были ли UI-компоненты инициализированы
 	tagsInitialized  bool
 	chatsInitialized bool
+	trashInitialized bool
 	// Фоновое изображение
 	background     *ScaledBackground // кастомный фон с масштабированием
 	backgroundRect *canvas.Rectangle // прямоугольник фона по умолчанию
 	// Режим отображения элементов
 	showMode string // "current_folder" или "all_items"
+	// ID открытого сохранённого поиска (0 - не открыт)
+	activeSavedSearchID int
+	// Подписка на изменения элементов; закрывается Stop
+	itemEvents <-chan string
 }
 
+// active рабочая область открытой библиотеки; её подписки снимает StopActive
+var active *Workspace
+
 // CreateWorkspace создает и возвращает рабочую область
 func CreateWorkspace(window fyne.Window, p2pNetwork *p2p_network.P2PNetwork) *Workspace {
 	ws := &Workspace{
@@ -101,6 +125,10 @@ func CreateWorkspace(window fyne.Window, p2pNetwork *p2p_network.P2PNetwork) *Wo
 	// Загружаем начальный контент (сохраненное)
 	ws.loadSavedContent()
 
+	// Элементы, добавленные в фоне, появляются в открытой папке без перехода
+	ws.watchItemChanges()
+
+	active = ws
 	return ws
 }
 
@@ -128,6 +156,9 @@ func (ws *Workspace) UpdateContent(contentType string, param ...interface{}) {
 		ws.chatsUI.Refresh()
 		// Обновляем кэш для этой вкладки
 		ws.contentCache[ct] = ws.createChatsContent()
+	} else if ct == ContentTypeTrash && ws.trashInitialized {
+		// Корзина могла измениться после удаления элементов - перечитываем её
+		ws.trashUI.Refresh()
 	} else {
 		// Проверяем кэш для других типов контента
 		if content, exists := ws.contentCache[ct]; exists && extraParam == nil {
@@ -161,6 +192,10 @@ func (ws *Workspace) UpdateContent(contentType string, param ...interface{}) {
 		newContent = ws.createTagsContent()
 	case ContentTypeChats:
 		newContent = ws.createChatsContent()
+	case ContentTypeTrash:
+		newContent = ws.createTrashContent()
+	case ContentTypeSettings:
+		newContent = ws.createSettingsContent()
 	default:
 		newContent = ws.createSavedContent()
 	}
@@ -173,11 +208,9 @@ func (ws *Workspace) UpdateContent(contentType string, param ...interface{}) {
 
 // loadSavedContent загружает сохраненные элементы
 func (ws *Workspace) loadSavedContent() {
-	items, err := itemsService.GetItemsByParent(0)
-	if err != nil {
-		items = []*models.Item{}
+	if err := ws.gridManager.LoadItemsByParentWithSort(0); err != nil {
+		ws.gridManager.LoadItems([]*models.Item{})
 	}
-	ws.gridManager.LoadItems(items)
 
 	// Устанавливаем корневой элемент как текущий
 	ws.gridManager.SetCurrentParentID(0)
@@ -185,6 +218,8 @@ func (ws *Workspace) loadSavedContent() {
 
 // NavigateToFolder переходит в указанную папку
 func (ws *Workspace) NavigateToFolder(folderID int) error {
+	ws.activeSavedSearchID = 0
+
 	err := ws.navigationManager.GoToFolderInPath(folderID)
 	if err != nil {
 		return err
@@ -202,8 +237,17 @@ func (ws *Workspace) NavigateToFolder(folderID int) error {
 	return nil
 }
 
-// RefreshCurrentFolder обновляет содержимое текущей папки
+// RefreshCurrentFolder обновляет содержимое текущей папки (или результаты открытого сохранённого поиска)
 func (ws *Workspace) RefreshCurrentFolder() error {
+	if ws.activeSavedSearchID != 0 {
+		err := ws.OpenSavedSearch(ws.activeSavedSearchID)
+		if !errors.Is(err, queries.ErrSavedSearchNotFound) {
+			return err
+		}
+		// Сохранённый поиск удалён - возвращаемся к текущей папке
+		ws.activeSavedSearchID = 0
+	}
+
 	currentParentID := ws.navigationManager.GetCurrentFolderID()
 	err := ws.gridManager.LoadItemsByParentWithSort(currentParentID)
 	if err != nil {
@@ -227,6 +271,30 @@ func (ws *Workspace) SetSearchQuery(query string) error {
 	return nil
 }
 
+// OpenSavedSearch показывает результаты сохранённого поиска (умной папки) в сетке "Сохраненного".
+// Запрос выполняется заново при каждом открытии и обновлении, поэтому результаты всегда актуальны.
+func (ws *Workspace) OpenSavedSearch(searchID int) error {
+	search, err := savedSearchesService.GetSavedSearchByID(searchID)
+	if err != nil {
+		return err
+	}
+
+	// Переключаемся на сетку, если открыта другая вкладка
+	gridContainer := ws.gridManager.GetContainer()
+	if len(ws.container.Objects) != 1 || ws.container.Objects[0] != gridContainer {
+		ws.UpdateContent(string(ContentTypeSaved))
+	}
+
+	err = ws.gridManager.LoadItemsBySavedSearch(search.Query, services.SavedSearchFilterOptions(search))
+	if err != nil {
+		return err
+	}
+
+	ws.activeSavedSearchID = search.ID
+	ws.currentType = ContentType(fmt.Sprintf("saved_search_%d", search.ID))
+	return nil
+}
+
 // SetupNavigation настраивает навигацию
 func (ws *Workspace) SetupNavigation(scroll *container.Scroll) {
 	// Настройка навигации - в данном случае просто устанавливаем обработчик скролла
@@ -249,6 +317,8 @@ func (ws *Workspace) OnSizeChanged(pos interface{}) {
 
 // SearchItems выполняет поиск элементов по запросу
 func (ws *Workspace) SearchItems(query string) error {
+	ws.activeSavedSearchID = 0
+
 	if query == "" {
 		// Если запрос пустой, возвращаемся к обычному отображению
 		currentParentID := ws.navigationManager.GetCurrentFolderID()
@@ -268,6 +338,8 @@ func (ws *Workspace) SearchItems(query string) error {
 
 // ClearSearch очищает результаты поиска и возвращает к нормальному отображению
 func (ws *Workspace) ClearSearch() error {
+	ws.activeSavedSearchID = 0
+
 	currentParentID := ws.navigationManager.GetCurrentFolderID()
 	err := ws.gridManager.LoadItemsByParentWithSort(currentParentID)
 	if err != nil {
@@ -319,6 +391,33 @@ func (ws *Workspace) createChatsContent() fyne.CanvasObject {
 	return ws.chatsUI.CreateView()
 }
 
+// createTrashContent создает контент для корзины
+func (ws *Workspace) createTrashContent() fyne.CanvasObject {
+	if !ws.trashInitialized {
+		ws.trashUI = trash.New()
+		ws.trashUI.SetWindow(ws.window)
+		// Восстановленные элементы должны появиться в сетке "Сохраненного"
+		ws.trashUI.SetOnChanged(func() {
+			_ = ws.RefreshCurrentFolder()
+		})
+		ws.trashInitialized = true
+	}
+	return ws.trashUI.GetContent()
+}
+
+// createSettingsContent создает контент для настроек
+func (ws *Workspace) createSettingsContent() fyne.CanvasObject {
+	if ws.settingsUI == nil {
+		ws.settingsUI = settings.New()
+		ws.settingsUI.SetWindow(ws.window)
+		// Исправление могло перенести элементы в корень или отвязать файлы
+		ws.settingsUI.SetOnChanged(func() {
+			_ = ws.RefreshCurrentFolder()
+		})
+	}
+	return ws.settingsUI.GetContent()
+}
+
 // initializeTagsUI инициализирует UI тегов при первом обращении
 func (ws *Workspace) initializeTagsUI() {
 	if !ws.tagsInitialized {
@@ -375,16 +474,10 @@ func (ws *Workspace) ApplyFilters(options services.FilterOptions) {
 	if options.TabMode == "all_items" {
 		// Режим "Все элементы" - отображаем все элементы без учета ParentID
 		ws.showMode = "all_items"
-		// Загружаем все элементы из базы данных
-		allItems, err := itemsService.GetAllItemsWithoutParentFilter() // используем метод, который возвращает все элементы
-		if err != nil {
-			// В случае ошибки загружаем пустой список
-			allItems = []*models.Item{}
+		if err := ws.gridManager.LoadAllItemsWithSort(); err != nil {
+			// В случае ошибки показываем пустую сетку
+			ws.gridManager.LoadItems([]*models.Item{})
 		}
-		// Применяем сортировку к полученным элементам
-		// Так как GridManager может не иметь метода GetSorter, применяем сортировку напрямую
-		sortedItems := ws.sortItems(allItems, &options)
-		ws.gridManager.LoadItems(sortedItems)
 	} else {
 		// Режим "Эта папка" - отображаем элементы текущей папки
 		ws.showMode = "current_folder"
@@ -396,9 +489,64 @@ func (ws *Workspace) ApplyFilters(options services.FilterOptions) {
 	}
 }
 
-// sortItems сортирует элементы по заданным настройкам
-func (ws *Workspace) sortItems(items []*models.Item, options *services.FilterOptions) []*models.Item {
-	// Используем сортировщик из пакета sorting
-	itemSorter := sorting.NewItemSorter()
-	return itemSorter.SortItems(items, options)
+// StopActive снимает подписки рабочей области открытой библиотеки.
+// Вызывается перед построением интерфейса другой библиотеки, чтобы старая сетка не обновлялась.
+func StopActive() {
+	if active != nil {
+		active.Stop()
+		active = nil
+	}
+}
+
+// Stop снимает подписку рабочей области на изменения элементов
+func (ws *Workspace) Stop() {
+	if ws.itemEvents != nil {
+		favorites.GetEventManager().Unsubscribe(ws.itemEvents)
+		ws.itemEvents = nil
+	}
+}
+
+// watchItemChanges обновляет сетку, когда элементы добавлены в фоне, например из отслеживаемых директорий.
+// Обновление прекращается после Stop.
+func (ws *Workspace) watchItemChanges() {
+	events := favorites.GetEventManager().Subscribe()
+	ws.itemEvents = events
+	go func() {
+		for event := range events {
+			if event != "items_changed" {
+				continue
+			}
+
+			// Файлы импортируются пачками: обновляем сетку один раз, когда события прекратились
+			timer := time.NewTimer(itemsRefreshDelay)
+		wait:
+			for {
+				select {
+				case next, ok := <-events:
+					if !ok {
+						timer.Stop()
+						return
+					}
+					if next == "items_changed" {
+						timer.Reset(itemsRefreshDelay)
+					}
+				case <-timer.C:
+					break wait
+				}
+			}
+
+			if ws.currentType != ContentTypeSaved && !strings.HasPrefix(string(ws.currentType), "folder_") {
+				continue
+			}
+			var err error
+			if ws.showMode == "all_items" {
+				err = ws.gridManager.LoadAllItemsWithSort()
+			} else {
+				err = ws.RefreshCurrentFolder()
+			}
+			if err != nil {
+				log.Printf("Ошибка обновления элементов: %v", err)
+			}
+		}
+	}()
 }
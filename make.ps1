@@ -9,21 +9,23 @@ param(
 $BINARY_NAME = "projectT"
 $CMD_PATH = ".\cmd\main.go"
 $VERSION = if ($env:VERSION) { $env:VERSION } else { "dev" }
+# sqlite_fts5 включает FTS5 в go-sqlite3 (полнотекстовый поиск)
+$TAGS = "sqlite_fts5"
 
 function Invoke-Build {
     Write-Host "Building $BINARY_NAME..." -ForegroundColor Cyan
-    go build -v -ldflags "-X main.Version=$VERSION" -o "$BINARY_NAME.exe" $CMD_PATH
+    go build -v -tags $TAGS -ldflags "-X main.Version=$VERSION" -o "$BINARY_NAME.exe" $CMD_PATH
     Write-Host "Build complete: $BINARY_NAME.exe" -ForegroundColor Green
 }
 
 function Invoke-Run {
     Write-Host "Running $BINARY_NAME..." -ForegroundColor Cyan
-    go run $CMD_PATH
+    go run -tags $TAGS $CMD_PATH
 }
 
 function Invoke-Test {
     Write-Host "Running tests..." -ForegroundColor Cyan
-    go test -v -race -cover ./...
+    go test -tags $TAGS -v -race -cover ./...
 }
 
 function Invoke-Clean {